@@ -10,6 +10,9 @@ const (
 	ABIMerkleAirdrop    = "MerkleAirdrop"
 	STAKEV2             = "StakeV2"
 	ABIERC20Test        = "ERC20Test"
+	ABIUniswapV2Router  = "UniswapV2Router"
+	ABIUniswapV3Pool    = "UniswapV3Pool"
+	ABIUniswapV3Factory = "UniswapV3Factory"
 )
 
 // 便捷函数 - 获取UniswapV2Pair ABI
@@ -33,6 +36,21 @@ func GetUniswapV2FactoryABI() abi.ABI {
 	return GetABIManager().MustGetABI(ABIUniswapV2Factory)
 }
 
+// 便捷函数 - 获取UniswapV2Router ABI
+func GetUniswapV2RouterABI() abi.ABI {
+	return GetABIManager().MustGetABI(ABIUniswapV2Router)
+}
+
+// 便捷函数 - 获取UniswapV3Pool ABI
+func GetUniswapV3PoolABI() abi.ABI {
+	return GetABIManager().MustGetABI(ABIUniswapV3Pool)
+}
+
+// 便捷函数 - 获取UniswapV3Factory ABI
+func GetUniswapV3FactoryABI() abi.ABI {
+	return GetABIManager().MustGetABI(ABIUniswapV3Factory)
+}
+
 // 便捷函数 - 获取MerkleAirdrop ABI
 func GetMerkleAirdropABI() abi.ABI {
 	return GetABIManager().MustGetABI(ABIMerkleAirdrop)
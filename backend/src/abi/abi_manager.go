@@ -92,6 +92,9 @@ func (am *ABIManager) PreloadCommonABIs() error {
 		"UniswapV2Factory": "config/uniswap_v2_factory.abi.json",
 		"MerkleAirdrop":    "config/merkle_airdrop.abi.json",
 		"StakeV2":          "config/StakeV2.abi.json",
+		"UniswapV2Router":  "config/uniswap_v2_router.abi.json",
+		"UniswapV3Pool":    "config/uniswap_v3_pool.abi.json",
+		"UniswapV3Factory": "config/uniswap_v3_factory.abi.json",
 	}
 
 	for name, path := range commonABIs {
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type AnalyticsApi struct {
+	svc *service.ProtocolStatsService
+}
+
+func NewAnalyticsApi() *AnalyticsApi {
+	return &AnalyticsApi{
+		svc: service.NewProtocolStatsService(),
+	}
+}
+
+// GetProtocolStats godoc
+// @Summary      协议每日汇总
+// @Description  按天返回 TVL、交易量、手续费、交易者、新增LP、质押（含各代币质押总量）、空投领取与 DAU/WAU/MAU
+// @Tags analytics
+// @Produce      json
+// @Param        chainId  query  int  false  "链ID，不传则按天合计所有链"
+// @Param        from     query  int  false  "开始时间（unix秒），默认30天前"
+// @Param        to       query  int  false  "结束时间（unix秒），默认当前"
+// @Success      200 {object} result.Response{data=model.ProtocolStatsReport}
+// @Router       /api/v1/analytics/protocol [get]
+func (a *AnalyticsApi) GetProtocolStats(c *gin.Context) {
+	var chainId int64
+	if c.Query("chainId") != "" {
+		id, ok := commonUtil.ParseChainId(c.Query("chainId"))
+		if !ok {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+		chainId = id
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if v, err := strconv.ParseInt(c.Query("from"), 10, 64); err == nil && v > 0 {
+		from = time.Unix(v, 0)
+	}
+	if v, err := strconv.ParseInt(c.Query("to"), 10, 64); err == nil && v > 0 {
+		to = time.Unix(v, 0)
+	}
+	if from.After(to) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	report, err := a.svc.Query(chainId, from, to)
+	if err != nil {
+		log.Logger.Error("查询协议汇总失败", zap.Error(err))
+		result.SysError(c, "查询协议汇总失败: "+err.Error())
+		return
+	}
+	result.OK(c, report)
+}
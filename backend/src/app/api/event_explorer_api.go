@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type EventExplorerApi struct {
+	svc *service.EventExplorerService
+}
+
+func NewEventExplorerApi() *EventExplorerApi {
+	return &EventExplorerApi{
+		svc: service.NewEventExplorerService(),
+	}
+}
+
+// explorerCsvHeader 导出 CSV 的列
+var explorerCsvHeader = []string{
+	"source", "eventType", "chainId", "blockNumber", "logIndex", "txHash", "contractAddress", "userAddress", "eventTime",
+	"tokenAddress", "amount", "poolId", "airdropId", "token0Address", "token1Address",
+	"amount0In", "amount1In", "amount0Out", "amount1Out",
+}
+
+// parseEventFilter 解析事件浏览的公共查询参数
+func parseEventFilter(c *gin.Context) (service.EventFilter, bool) {
+	var filter service.EventFilter
+	if c.Query("chainId") != "" {
+		id, ok := commonUtil.ParseChainId(c.Query("chainId"))
+		if !ok {
+			return filter, false
+		}
+		filter.ChainId = id
+	}
+	for _, addr := range []string{c.Query("contract"), c.Query("user")} {
+		if addr != "" && !commonUtil.ValidateHexAddress(addr) {
+			return filter, false
+		}
+	}
+	filter.Contract = c.Query("contract")
+	filter.User = c.Query("user")
+	filter.TxHash = c.Query("txHash")
+	if v := c.Query("eventType"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(strings.ToLower(t)); t != "" {
+				filter.Types = append(filter.Types, t)
+			}
+		}
+	}
+
+	var err error
+	if v := c.Query("fromBlock"); v != "" {
+		if filter.FromBlock, err = strconv.ParseInt(v, 10, 64); err != nil || filter.FromBlock < 0 {
+			return filter, false
+		}
+	}
+	if v := c.Query("toBlock"); v != "" {
+		if filter.ToBlock, err = strconv.ParseInt(v, 10, 64); err != nil || filter.ToBlock < 0 {
+			return filter, false
+		}
+	}
+	if v := c.Query("from"); v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || sec <= 0 {
+			return filter, false
+		}
+		filter.From = time.Unix(sec, 0)
+	}
+	if v := c.Query("to"); v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || sec <= 0 {
+			return filter, false
+		}
+		filter.To = time.Unix(sec, 0)
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		filter.Asc = true
+	case "desc":
+	default:
+		return filter, false
+	}
+	filter.Cursor = c.Query("cursor")
+	return filter, true
+}
+
+// ListEvents godoc
+// @Summary      事件浏览
+// @Description  统一查询质押、提取、兑换、添加/移除流动性、V3 Collect 与空投领取事件，按 (block_number, log_index) 游标分页
+// @Tags events
+// @Produce      json
+// @Param        chainId    query  int     false  "链ID"
+// @Param        contract   query  string  false  "合约地址（池子、质押或空投合约）"
+// @Param        user       query  string  false  "用户地址"
+// @Param        txHash     query  string  false  "交易哈希"
+// @Param        eventType  query  string  false  "事件类型，逗号分隔：stake,withdraw,swap,mint,burn,collect,claim"
+// @Param        fromBlock  query  int     false  "起始区块（含）"
+// @Param        toBlock    query  int     false  "结束区块（含）"
+// @Param        from       query  int     false  "开始时间（unix秒）"
+// @Param        to         query  int     false  "结束时间（unix秒）"
+// @Param        order      query  string  false  "排序：desc（默认）/asc"
+// @Param        cursor     query  string  false  "上一页返回的 nextCursor"
+// @Param        limit      query  int     false  "每页条数，默认50，最大500"
+// @Success      200 {object} result.Response{data=model.ExplorerEventPage}
+// @Router       /api/v1/events [get]
+func (a *EventExplorerApi) ListEvents(c *gin.Context) {
+	filter, ok := parseEventFilter(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	page, err := a.svc.Query(filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrUnknownEventType) {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+		log.Logger.Error("查询事件失败", zap.Error(err))
+		result.SysError(c, "查询事件失败: "+err.Error())
+		return
+	}
+	result.OK(c, page)
+}
+
+// ExportEvents godoc
+// @Summary      事件导出
+// @Description  以流式 CSV 或 NDJSON 导出全部匹配事件，过滤参数与事件浏览相同（忽略 limit）
+// @Tags events
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format  query  string  false  "导出格式：csv（默认）/ndjson"
+// @Success      200 {string} string "事件数据流"
+// @Router       /api/v1/events/export [get]
+func (a *EventExplorerApi) ExportEvents(c *gin.Context) {
+	filter, ok := parseEventFilter(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	// 先校验游标与事件类型，避免写出响应头后才发现参数错误
+	if err := service.ValidateEventFilter(filter); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	filename := "events-" + time.Now().UTC().Format("20060102150405")
+	var write func(model.ExplorerEvent) error
+	var flush func()
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", "attachment; filename="+filename+".csv")
+		w := csv.NewWriter(c.Writer)
+		if err := w.Write(explorerCsvHeader); err != nil {
+			return
+		}
+		write = func(e model.ExplorerEvent) error { return w.Write(explorerCsvRow(e)) }
+		flush = w.Flush
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", "attachment; filename="+filename+".ndjson")
+		enc := json.NewEncoder(c.Writer)
+		write = func(e model.ExplorerEvent) error { return enc.Encode(e) }
+		flush = func() {}
+	}
+	c.Status(200)
+
+	count := 0
+	err := a.svc.Stream(filter, func(e model.ExplorerEvent) error {
+		if err := write(e); err != nil {
+			return err
+		}
+		if count++; count%1000 == 0 {
+			flush()
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	flush()
+	c.Writer.Flush()
+	if err != nil {
+		// 响应头已写出，只能中断输出并记录日志
+		log.Logger.Error("导出事件失败", zap.Int("written", count), zap.Error(err))
+	}
+}
+
+func explorerCsvRow(e model.ExplorerEvent) []string {
+	poolId := ""
+	if e.PoolId != nil {
+		poolId = strconv.FormatInt(*e.PoolId, 10)
+	}
+	return []string{
+		e.Source, e.EventType, strconv.FormatInt(e.ChainId, 10), strconv.FormatInt(e.BlockNumber, 10),
+		strconv.Itoa(e.LogIndex), e.TxHash, e.ContractAddress, e.UserAddress, e.EventTime.UTC().Format(time.RFC3339),
+		e.TokenAddress, e.Amount, poolId, e.AirdropId, e.Token0Address, e.Token1Address,
+		e.Amount0In, e.Amount1In, e.Amount0Out, e.Amount1Out,
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type FeeApi struct {
+	svc *service.FeeService
+}
+
+func NewFeeApi() *FeeApi {
+	return &FeeApi{
+		svc: service.NewFeeService(),
+	}
+}
+
+// GetProtocolRevenue godoc
+// @Summary      协议收入报表
+// @Description  按池子与周期汇总交易量、LP手续费、协议计提手续费，以及铸造给 feeTo 的实收LP
+// @Tags analytics
+// @Produce      json
+// @Param        chainId      query  int     false  "链ID，不传则统计所有链"
+// @Param        poolAddress  query  string  false  "池子地址，不传则统计所有池子"
+// @Param        from         query  int     false  "开始时间（unix秒），默认30天前"
+// @Param        to           query  int     false  "结束时间（unix秒），默认当前"
+// @Param        interval     query  string  false  "统计周期：hour/day/week/month，默认 day"
+// @Success      200 {object} result.Response{data=model.ProtocolRevenueReport}
+// @Router       /api/v1/analytics/protocol-revenue [get]
+func (a *FeeApi) GetProtocolRevenue(c *gin.Context) {
+	var chainId int64
+	if c.Query("chainId") != "" {
+		id, ok := commonUtil.ParseChainId(c.Query("chainId"))
+		if !ok {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+		chainId = id
+	}
+	poolAddress := c.Query("poolAddress")
+	if poolAddress != "" && !commonUtil.ValidateHexAddress(poolAddress) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	interval := c.DefaultQuery("interval", "day")
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if v, err := strconv.ParseInt(c.Query("from"), 10, 64); err == nil && v > 0 {
+		from = time.Unix(v, 0)
+	}
+	if v, err := strconv.ParseInt(c.Query("to"), 10, 64); err == nil && v > 0 {
+		to = time.Unix(v, 0)
+	}
+	if !from.Before(to) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	report, err := a.svc.ProtocolRevenue(chainId, poolAddress, from, to, interval)
+	if err != nil {
+		log.Logger.Error("查询协议收入失败", zap.Error(err))
+		result.SysError(c, "查询协议收入失败: "+err.Error())
+		return
+	}
+	result.OK(c, report)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type LpPositionApi struct {
+	svc *service.LpPositionService
+}
+
+func NewLpPositionApi() *LpPositionApi {
+	return &LpPositionApi{
+		svc: service.NewLpPositionService(),
+	}
+}
+
+// GetPosition godoc
+// @Summary      获取LP持仓详情
+// @Description  按LP代币转账累计的持仓，返回池子份额、底层资产、价值、成本、手续费收益与无常损失；V3 池子需指定 tick 区间
+// @Tags liquidity
+// @Produce      json
+// @Param        chainId      query  int     true  "链ID"
+// @Param        poolAddress  query  string  true  "池子地址"
+// @Param        userAddress  query  string  true  "持有地址"
+// @Param        tickLower    query  int     false "V3 仓位区间下界"
+// @Param        tickUpper    query  int     false "V3 仓位区间上界"
+// @Success      200 {object} result.Response{data=model.LpPositionDetail}
+// @Router       /api/v1/liquidity/position [get]
+func (a *LpPositionApi) GetPosition(c *gin.Context) {
+	chainId, ok := commonUtil.ParseChainId(c.Query("chainId"))
+	poolAddress := c.Query("poolAddress")
+	userAddress := c.Query("userAddress")
+	if !ok || chainId <= 0 || !commonUtil.ValidateHexAddress(poolAddress) || !commonUtil.ValidateHexAddress(userAddress) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	tickLower, okLower := parseOptionalTick(c.Query("tickLower"))
+	tickUpper, okUpper := parseOptionalTick(c.Query("tickUpper"))
+	if !okLower || !okUpper {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	detail, err := a.svc.GetPosition(chainId, poolAddress, userAddress, tickLower, tickUpper)
+	if errors.Is(err, service.ErrTickRangeRequired) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		result.Error(c, result.DBNotExist)
+		return
+	}
+	if err != nil {
+		log.Logger.Error("查询LP持仓失败", zap.String("pool", poolAddress), zap.String("user", userAddress), zap.Error(err))
+		result.Error(c, result.DBQueryFailed)
+		return
+	}
+	result.OK(c, detail)
+}
+
+// parseOptionalTick 解析可选的 tick 参数，未传时返回 nil
+func parseOptionalTick(raw string) (*int, bool) {
+	if raw == "" {
+		return nil, true
+	}
+	tick, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, false
+	}
+	return &tick, true
+}
+
+// GetPositions godoc
+// @Summary      获取地址的全部LP持仓
+// @Tags liquidity
+// @Produce      json
+// @Param        userAddress  query  string  true   "持有地址"
+// @Param        chainId      query  int     false  "链ID，不传则查询所有链"
+// @Success      200 {object} result.Response{data=map[string]interface{}}
+// @Router       /api/v1/liquidity/positions [get]
+func (a *LpPositionApi) GetPositions(c *gin.Context) {
+	userAddress := c.Query("userAddress")
+	if !commonUtil.ValidateHexAddress(userAddress) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var chainId int64
+	if c.Query("chainId") != "" {
+		id, ok := commonUtil.ParseChainId(c.Query("chainId"))
+		if !ok {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+		chainId = id
+	}
+
+	list, err := a.svc.ListPositions(userAddress, chainId)
+	if err != nil {
+		log.Logger.Error("查询LP持仓列表失败", zap.String("user", userAddress), zap.Error(err))
+		result.Error(c, result.DBQueryFailed)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":  list,
+		"total": len(list),
+	})
+}
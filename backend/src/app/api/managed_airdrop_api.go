@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type ManagedAirdropApi struct {
+	svc *service.ManagedAirdropService
+}
+
+func NewManagedAirdropApi() *ManagedAirdropApi {
+	return &ManagedAirdropApi{
+		svc: service.NewManagedAirdropService(),
+	}
+}
+
+// managedAirdropError 参数错误与无权限附带原因返回，其余按系统错误返回
+func managedAirdropError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrManagedAirdropInvalidParam):
+		result.ErrorData(c, result.InvalidParameter, err.Error())
+	case errors.Is(err, service.ErrManagedAirdropForbidden):
+		result.ErrorData(c, result.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrManagedAirdropNotFound):
+		result.Error(c, result.DBNotExist)
+	default:
+		log.Logger.Error(action+"失败", zap.Error(err))
+		result.SysError(c, action+"失败: "+err.Error())
+	}
+}
+
+// CreateCampaign godoc
+// @Summary      创建空投活动草稿
+// @Description  需在 airdrop.admins 内。totalReward 为代币单位（18 位精度），startTime、endTime 为 Unix 秒，taskIds 为绑定的任务；contractAddress 留空时使用该链已索引空投的合约
+// @Tags airdrop-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.ManagedAirdropInput  true  "活动内容"
+// @Success      200 {object} result.Response{data=model.ManagedAirdrop}
+// @Router       /api/v1/admin/airdrops/campaigns [post]
+func (a *ManagedAirdropApi) CreateCampaign(c *gin.Context) {
+	var req service.ManagedAirdropInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	airdrop, err := a.svc.Draft(c.GetString("address"), req)
+	if err != nil {
+		managedAirdropError(c, "创建空投活动", err)
+		return
+	}
+	result.OK(c, airdrop)
+}
+
+// ListCampaigns godoc
+// @Summary      空投活动列表
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status    query  string  false  "状态：draft,creating,created,activating,active,ended"
+// @Param        page      query  int     false  "页码"
+// @Param        pageSize  query  int     false  "每页数量"
+// @Success      200 {object} result.Response{data=[]model.ManagedAirdrop}
+// @Router       /api/v1/admin/airdrops/campaigns [get]
+func (a *ManagedAirdropApi) ListCampaigns(c *gin.Context) {
+	pg := parsePagination(c.DefaultQuery("page", "1"), c.DefaultQuery("pageSize", "20"))
+	list, total, err := a.svc.List(c.GetString("address"), c.Query("status"), pg)
+	if err != nil {
+		managedAirdropError(c, "查询空投活动", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     pg.Page,
+		"pageSize": pg.PageSize,
+	})
+}
+
+// GetCampaign godoc
+// @Summary      空投活动详情
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "活动ID"
+// @Success      200 {object} result.Response{data=model.ManagedAirdrop}
+// @Router       /api/v1/admin/airdrops/campaigns/{id} [get]
+func (a *ManagedAirdropApi) GetCampaign(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	airdrop, err := a.svc.Get(c.GetString("address"), id)
+	if err != nil {
+		managedAirdropError(c, "查询空投活动", err)
+		return
+	}
+	result.OK(c, airdrop)
+}
+
+// UpdateCampaign godoc
+// @Summary      修改空投活动
+// @Description  只处理请求中出现的字段。草稿可修改全部字段；created、active 状态只能修改 description、iconUrl、tokenSymbol、tokenAddress、taskIds，并同步到空投列表与任务绑定；其余状态不可修改
+// @Tags airdrop-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path  int                          true  "活动ID"
+// @Param        body  body  service.ManagedAirdropInput  true  "修改内容"
+// @Success      200 {object} result.Response{data=model.ManagedAirdrop}
+// @Router       /api/v1/admin/airdrops/campaigns/{id} [put]
+func (a *ManagedAirdropApi) UpdateCampaign(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var req service.ManagedAirdropInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	airdrop, err := a.svc.Update(c.GetString("address"), id, req)
+	if err != nil {
+		managedAirdropError(c, "修改空投活动", err)
+		return
+	}
+	result.OK(c, airdrop)
+}
+
+// DeleteCampaign godoc
+// @Summary      删除空投活动草稿
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "活动ID"
+// @Success      200 {object} result.Response
+// @Router       /api/v1/admin/airdrops/campaigns/{id} [delete]
+func (a *ManagedAirdropApi) DeleteCampaign(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	if err := a.svc.Delete(c.GetString("address"), id); err != nil {
+		managedAirdropError(c, "删除空投活动", err)
+		return
+	}
+	result.OK(c, nil)
+}
+
+// CreateCampaignOnChain godoc
+// @Summary      链上创建空投活动
+// @Description  草稿状态可用：使用 airdrop.signer 经托管交易调用 createAirdrop（占位默克尔根，treeVersion 为 1），AirdropCreated 索引后回填空投ID并转为 created；交易失败时回到草稿
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "活动ID"
+// @Success      200 {object} result.Response{data=model.ManagedAirdrop}
+// @Router       /api/v1/admin/airdrops/campaigns/{id}/create [post]
+func (a *ManagedAirdropApi) CreateCampaignOnChain(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	airdrop, err := a.svc.Create(c.GetString("address"), id)
+	if err != nil {
+		managedAirdropError(c, "链上创建空投活动", err)
+		return
+	}
+	result.OK(c, airdrop)
+}
+
+// ActivateCampaign godoc
+// @Summary      激活空投活动
+// @Description  created 状态且已到开始时间可用：经托管交易调用 activateAirdrop，AirdropActivated 索引后转为 active；交易失败时回到 created
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "活动ID"
+// @Success      200 {object} result.Response{data=model.ManagedAirdrop}
+// @Router       /api/v1/admin/airdrops/campaigns/{id}/activate [post]
+func (a *ManagedAirdropApi) ActivateCampaign(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	airdrop, err := a.svc.Activate(c.GetString("address"), id)
+	if err != nil {
+		managedAirdropError(c, "激活空投活动", err)
+		return
+	}
+	result.OK(c, airdrop)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type ManagedTxApi struct {
+	svc *service.ManagedTxService
+}
+
+func NewManagedTxApi() *ManagedTxApi {
+	return &ManagedTxApi{
+		svc: service.NewManagedTxService(),
+	}
+}
+
+// managedTxError 按错误类型返回响应
+func managedTxError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrManagedTxNotFound):
+		result.Error(c, result.DBNotExist)
+	case errors.Is(err, service.ErrManagedTxForbidden):
+		result.ErrorData(c, result.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrManagedTxInvalidState), errors.Is(err, service.ErrTxFeeCapReached):
+		result.ErrorData(c, result.InvalidParameter, err.Error())
+	default:
+		log.Logger.Error(action+"失败", zap.Error(err))
+		result.SysError(c, action+"失败: "+err.Error())
+	}
+}
+
+// GetTx godoc
+// @Summary      托管交易状态
+// @Description  服务端托管发送的交易（更新默克尔根、份额价格、质押管理等），返回状态与每次广播记录。status: pending,submitted,confirmed,reverted,cancelled,failed
+// @Tags txs
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "托管交易ID"
+// @Success      200 {object} result.Response
+// @Router       /api/v1/txs/{id} [get]
+func (a *ManagedTxApi) GetTx(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	tx, err := a.svc.Get(id)
+	if err != nil {
+		managedTxError(c, "查询托管交易", err)
+		return
+	}
+	attempts, err := a.svc.ListAttempts(id)
+	if err != nil {
+		managedTxError(c, "查询托管交易", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"tx":       tx,
+		"attempts": attempts,
+	})
+}
+
+// SpeedUpTx godoc
+// @Summary      托管交易提速
+// @Description  以更高的 EIP-1559 费用重发同一 nonce 的交易，需在 tx_manager.admins 白名单内
+// @Tags txs
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "托管交易ID"
+// @Success      200 {object} result.Response{data=model.ManagedTx}
+// @Router       /api/v1/txs/{id}/speed-up [post]
+func (a *ManagedTxApi) SpeedUpTx(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	tx, err := a.svc.SpeedUp(c.GetString("address"), id)
+	if err != nil {
+		managedTxError(c, "托管交易提速", err)
+		return
+	}
+	result.OK(c, tx)
+}
+
+// CancelTx godoc
+// @Summary      取消托管交易
+// @Description  以更高费用向自身转账 0 占用同一 nonce，取消交易上链后状态为 cancelled；需在 tx_manager.admins 白名单内
+// @Tags txs
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "托管交易ID"
+// @Success      200 {object} result.Response{data=model.ManagedTx}
+// @Router       /api/v1/txs/{id}/cancel [post]
+func (a *ManagedTxApi) CancelTx(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	tx, err := a.svc.Cancel(c.GetString("address"), id)
+	if err != nil {
+		managedTxError(c, "取消托管交易", err)
+		return
+	}
+	result.OK(c, tx)
+}
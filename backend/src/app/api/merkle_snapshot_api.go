@@ -0,0 +1,379 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type MerkleSnapshotApi struct {
+	svc *service.MerkleSnapshotService
+}
+
+func NewMerkleSnapshotApi() *MerkleSnapshotApi {
+	return &MerkleSnapshotApi{
+		svc: service.NewMerkleSnapshotService(),
+	}
+}
+
+// merkleSnapshotError 参数错误（含 Safe 提案校验）与无权限附带原因返回，其余按系统错误返回
+func merkleSnapshotError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrMerkleSnapshotInvalidParam), errors.Is(err, service.ErrSafeInvalidParam):
+		result.ErrorData(c, result.InvalidParameter, err.Error())
+	case errors.Is(err, service.ErrMerkleSnapshotForbidden), errors.Is(err, service.ErrSafeForbidden):
+		result.ErrorData(c, result.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrMerkleSnapshotNotFound):
+		result.Error(c, result.DBNotExist)
+	default:
+		log.Logger.Error(action+"失败", zap.Error(err))
+		result.SysError(c, action+"失败: "+err.Error())
+	}
+}
+
+// snapshotPath 解析路径中的空投ID与快照版本，withVersion 为 false 时只解析空投ID
+func snapshotPath(c *gin.Context, withVersion bool) (int64, int, bool) {
+	airdropId, err := strconv.ParseInt(c.Param("airdropId"), 10, 64)
+	if err != nil || airdropId < 0 {
+		return 0, 0, false
+	}
+	if !withVersion {
+		return airdropId, 0, true
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		return 0, 0, false
+	}
+	return airdropId, version, true
+}
+
+// BuildSnapshot godoc
+// @Summary      生成默克尔快照
+// @Description  按已完成任务聚合用户奖励建树并保存为新版本（需在 airdrop.admins 内）。根与最新快照相同时返回最新快照，created 为 false。生成后不会上链，需调用发布接口
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path  int  true  "空投ID"
+// @Success      200 {object} result.Response{data=map[string]interface{}}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots [post]
+func (a *MerkleSnapshotApi) BuildSnapshot(c *gin.Context) {
+	airdropId, _, ok := snapshotPath(c, false)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	snapshot, created, err := a.svc.Rebuild(c.GetString("address"), airdropId)
+	if err != nil {
+		merkleSnapshotError(c, "生成默克尔快照", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"snapshot": snapshot,
+		"created":  created,
+	})
+}
+
+// ListSnapshots godoc
+// @Summary      默克尔快照列表
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path   int     true   "空投ID"
+// @Param        status     query  string  false  "状态：built,pending_approval,publishing,published,superseded,rejected,failed"
+// @Param        page       query  int     false  "页码"
+// @Param        pageSize   query  int     false  "每页数量"
+// @Success      200 {object} result.Response{data=[]model.MerkleSnapshot}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots [get]
+func (a *MerkleSnapshotApi) ListSnapshots(c *gin.Context) {
+	airdropId, _, ok := snapshotPath(c, false)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	pg := parsePagination(c.DefaultQuery("page", "1"), c.DefaultQuery("pageSize", "20"))
+	list, total, err := a.svc.List(c.GetString("address"), airdropId, c.Query("status"), pg)
+	if err != nil {
+		merkleSnapshotError(c, "查询默克尔快照", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     pg.Page,
+		"pageSize": pg.PageSize,
+	})
+}
+
+// GetSnapshot godoc
+// @Summary      默克尔快照详情
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path  int  true  "空投ID"
+// @Param        version    path  int  true  "快照版本"
+// @Success      200 {object} result.Response{data=model.MerkleSnapshot}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/{version} [get]
+func (a *MerkleSnapshotApi) GetSnapshot(c *gin.Context) {
+	airdropId, version, ok := snapshotPath(c, true)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	snapshot, err := a.svc.Get(c.GetString("address"), airdropId, version)
+	if err != nil {
+		merkleSnapshotError(c, "查询默克尔快照", err)
+		return
+	}
+	result.OK(c, snapshot)
+}
+
+// ListSnapshotLeaves godoc
+// @Summary      默克尔快照的分配与证明
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path   int     true   "空投ID"
+// @Param        version    path   int     true   "快照版本"
+// @Param        wallet     query  string  false  "按钱包地址过滤"
+// @Param        page       query  int     false  "页码"
+// @Param        pageSize   query  int     false  "每页数量"
+// @Success      200 {object} result.Response{data=[]model.MerkleSnapshotLeaf}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/{version}/leaves [get]
+func (a *MerkleSnapshotApi) ListSnapshotLeaves(c *gin.Context) {
+	airdropId, version, ok := snapshotPath(c, true)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	pg := parsePagination(c.DefaultQuery("page", "1"), c.DefaultQuery("pageSize", "20"))
+	list, total, err := a.svc.Leaves(c.GetString("address"), airdropId, version, c.Query("wallet"), pg)
+	if err != nil {
+		merkleSnapshotError(c, "查询默克尔快照叶子", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     pg.Page,
+		"pageSize": pg.PageSize,
+	})
+}
+
+// DownloadSnapshotTree godoc
+// @Summary      下载默克尔树
+// @Description  直接返回 OpenZeppelin StandardMerkleTree dump 格式的 JSON 文件（format 为 packed-v1，叶子为 abi.encodePacked 单次哈希）
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path  int  true  "空投ID"
+// @Param        version    path  int  true  "快照版本"
+// @Success      200 {object} map[string]interface{}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/{version}/tree [get]
+func (a *MerkleSnapshotApi) DownloadSnapshotTree(c *gin.Context) {
+	airdropId, version, ok := snapshotPath(c, true)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	tree, err := a.svc.Tree(c.GetString("address"), airdropId, version)
+	if err != nil {
+		merkleSnapshotError(c, "查询默克尔树", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="airdrop-%d-v%d.json"`, airdropId, version))
+	c.Data(http.StatusOK, "application/json", []byte(tree))
+}
+
+// DiffSnapshots godoc
+// @Summary      比较两个默克尔快照
+// @Description  返回新增、移除与金额变化的地址（金额为 wei）及合计变化
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path   int  true  "空投ID"
+// @Param        from       query  int  true  "起始版本"
+// @Param        to         query  int  true  "目标版本"
+// @Success      200 {object} result.Response{data=service.MerkleSnapshotDiff}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/diff [get]
+func (a *MerkleSnapshotApi) DiffSnapshots(c *gin.Context) {
+	airdropId, _, ok := snapshotPath(c, false)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	from, err1 := strconv.Atoi(c.Query("from"))
+	to, err2 := strconv.Atoi(c.Query("to"))
+	if err1 != nil || err2 != nil || from <= 0 || to <= 0 {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	diff, err := a.svc.Diff(c.GetString("address"), airdropId, from, to)
+	if err != nil {
+		merkleSnapshotError(c, "比较默克尔快照", err)
+		return
+	}
+	result.OK(c, diff)
+}
+
+// PublishSnapshot godoc
+// @Summary      发布默克尔快照
+// @Description  mode=tx 使用 airdrop.signer 经托管交易调用 updateMerkleRoot；mode=safe 生成 Safe 多签提案（调用方需为 Safe owner）。只能发布高于当前生效版本的快照，且同一空投同时只能有一个发布中的快照；上链确认后白名单证明切换为该快照
+// @Tags airdrop-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path  int                         true  "空投ID"
+// @Param        version    path  int                         true  "快照版本"
+// @Param        body       body  service.MerklePublishInput  false "发布方式"
+// @Success      200 {object} result.Response{data=model.MerkleSnapshot}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/{version}/publish [post]
+func (a *MerkleSnapshotApi) PublishSnapshot(c *gin.Context) {
+	airdropId, version, ok := snapshotPath(c, true)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var req service.MerklePublishInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+	}
+	snapshot, err := a.svc.Publish(c.GetString("address"), airdropId, version, req)
+	if err != nil {
+		merkleSnapshotError(c, "发布默克尔快照", err)
+		return
+	}
+	result.OK(c, snapshot)
+}
+
+// RollbackSnapshot godoc
+// @Summary      回滚到历史默克尔快照
+// @Description  复制曾经发布过的版本为新版本并重新发布其根（链上 newVersion 仍递增），发布方式同发布接口
+// @Tags airdrop-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path  int                         true  "空投ID"
+// @Param        version    path  int                         true  "回滚到的快照版本"
+// @Param        body       body  service.MerklePublishInput  false "发布方式"
+// @Success      200 {object} result.Response{data=model.MerkleSnapshot}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/{version}/rollback [post]
+func (a *MerkleSnapshotApi) RollbackSnapshot(c *gin.Context) {
+	airdropId, version, ok := snapshotPath(c, true)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var req service.MerklePublishInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+	}
+	snapshot, err := a.svc.Rollback(c.GetString("address"), airdropId, version, req)
+	if err != nil {
+		merkleSnapshotError(c, "回滚默克尔快照", err)
+		return
+	}
+	result.OK(c, snapshot)
+}
+
+// ListPendingSnapshots godoc
+// @Summary      待审批的默克尔快照
+// @Description  自动更新未通过策略检查（总额超过活动总奖励或奖励池余额、用户分配低于已领取金额、变化地址数超限、未配置签名账户）的快照，policyViolations 为未通过的检查项
+// @Tags airdrop-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page      query  int  false  "页码"
+// @Param        pageSize  query  int  false  "每页数量"
+// @Success      200 {object} result.Response{data=[]model.MerkleSnapshot}
+// @Router       /api/v1/admin/airdrops/pending-snapshots [get]
+func (a *MerkleSnapshotApi) ListPendingSnapshots(c *gin.Context) {
+	pg := parsePagination(c.DefaultQuery("page", "1"), c.DefaultQuery("pageSize", "20"))
+	list, total, err := a.svc.ListPending(c.GetString("address"), pg)
+	if err != nil {
+		merkleSnapshotError(c, "查询待审批默克尔快照", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     pg.Page,
+		"pageSize": pg.PageSize,
+	})
+}
+
+// ApproveSnapshot godoc
+// @Summary      审批通过并发布默克尔快照
+// @Description  仅待审批状态可用，发布方式同发布接口
+// @Tags airdrop-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path  int                        true  "空投ID"
+// @Param        version    path  int                        true  "快照版本"
+// @Param        body       body  service.MerkleReviewInput  false "发布方式"
+// @Success      200 {object} result.Response{data=model.MerkleSnapshot}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/{version}/approve [post]
+func (a *MerkleSnapshotApi) ApproveSnapshot(c *gin.Context) {
+	airdropId, version, ok := snapshotPath(c, true)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var req service.MerkleReviewInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+	}
+	snapshot, err := a.svc.Approve(c.GetString("address"), airdropId, version, req)
+	if err != nil {
+		merkleSnapshotError(c, "审批默克尔快照", err)
+		return
+	}
+	result.OK(c, snapshot)
+}
+
+// RejectSnapshot godoc
+// @Summary      拒绝待审批的默克尔快照
+// @Tags airdrop-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        airdropId  path  int                        true  "空投ID"
+// @Param        version    path  int                        true  "快照版本"
+// @Param        body       body  service.MerkleReviewInput  false "拒绝原因"
+// @Success      200 {object} result.Response{data=model.MerkleSnapshot}
+// @Router       /api/v1/admin/airdrops/{airdropId}/snapshots/{version}/reject [post]
+func (a *MerkleSnapshotApi) RejectSnapshot(c *gin.Context) {
+	airdropId, version, ok := snapshotPath(c, true)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var req service.MerkleReviewInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			result.Error(c, result.InvalidParameter)
+			return
+		}
+	}
+	snapshot, err := a.svc.Reject(c.GetString("address"), airdropId, version, req)
+	if err != nil {
+		merkleSnapshotError(c, "拒绝默克尔快照", err)
+		return
+	}
+	result.OK(c, snapshot)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type PortfolioApi struct {
+	svc *service.PortfolioService
+}
+
+func NewPortfolioApi() *PortfolioApi {
+	return &PortfolioApi{
+		svc: service.NewPortfolioService(),
+	}
+}
+
+// GetPortfolio godoc
+// @Summary      钱包资产总览
+// @Description  汇总所有已配置链上的质押（含锁定状态）、LP持仓及USD价值、空投待领取/已领取、积分，以及净值与24小时变化
+// @Tags portfolio
+// @Produce      json
+// @Param        address  path  string  true  "钱包地址"
+// @Success      200 {object} result.Response{data=model.Portfolio}
+// @Router       /api/v1/portfolio/{address} [get]
+func (a *PortfolioApi) GetPortfolio(c *gin.Context) {
+	address := c.Param("address")
+	if !commonUtil.ValidateHexAddress(address) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	portfolio, err := a.svc.GetPortfolio(address)
+	if err != nil {
+		log.Logger.Error("查询钱包资产失败", zap.String("address", address), zap.Error(err))
+		result.SysError(c, "查询钱包资产失败: "+err.Error())
+		return
+	}
+	result.OK(c, portfolio)
+}
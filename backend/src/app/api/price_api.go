@@ -0,0 +1,100 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/result"
+)
+
+type PriceApi struct {
+	svc *service.PriceService
+}
+
+func NewPriceApi() *PriceApi {
+	return &PriceApi{
+		svc: service.NewPriceService(),
+	}
+}
+
+// GetTokenPrices godoc
+// @Summary      获取代币USD价格
+// @Description  基于已索引池子储备量路由到稳定币计算的代币USD价格，路由不可达时使用手动价格源
+// @Tags price
+// @Produce      json
+// @Param        chainId  query  int  true  "链ID"
+// @Success      200 {object} result.Response{data=map[string]interface{}}
+// @Router       /api/v1/price/tokens [get]
+func (a *PriceApi) GetTokenPrices(c *gin.Context) {
+	chainId, ok := commonUtil.ParseChainId(c.Query("chainId"))
+	if !ok || chainId <= 0 {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	prices, err := a.svc.GetTokenPrices(chainId)
+	if err != nil {
+		result.Error(c, result.DBQueryFailed)
+		return
+	}
+
+	list := make([]*service.TokenPriceQuote, 0, len(prices))
+	for _, q := range prices {
+		list = append(list, q)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LiquidityUSD.GreaterThan(list[j].LiquidityUSD)
+	})
+
+	result.OK(c, gin.H{
+		"list":  list,
+		"total": len(list),
+	})
+}
+
+// GetPriceHistory godoc
+// @Summary      获取代币历史价格
+// @Tags price
+// @Produce      json
+// @Param        chainId       query  int     true   "链ID"
+// @Param        tokenAddress  query  string  true   "代币地址"
+// @Param        from          query  int     false  "开始时间（unix秒），默认7天前"
+// @Param        to            query  int     false  "结束时间（unix秒），默认当前"
+// @Success      200 {object} result.Response{data=map[string]interface{}}
+// @Router       /api/v1/price/history [get]
+func (a *PriceApi) GetPriceHistory(c *gin.Context) {
+	chainId, ok := commonUtil.ParseChainId(c.Query("chainId"))
+	tokenAddress := c.Query("tokenAddress")
+	if !ok || chainId <= 0 || !commonUtil.ValidateHexAddress(tokenAddress) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	to := time.Now()
+	from := to.Add(-7 * 24 * time.Hour)
+	if v, err := strconv.ParseInt(c.Query("from"), 10, 64); err == nil && v > 0 {
+		from = time.Unix(v, 0)
+	}
+	if v, err := strconv.ParseInt(c.Query("to"), 10, 64); err == nil && v > 0 {
+		to = time.Unix(v, 0)
+	}
+	if !from.Before(to) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	prices, err := a.svc.GetPriceHistory(chainId, tokenAddress, from, to)
+	if err != nil {
+		result.Error(c, result.DBQueryFailed)
+		return
+	}
+
+	result.OK(c, gin.H{
+		"list":  prices,
+		"total": len(prices),
+	})
+}
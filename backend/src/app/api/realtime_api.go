@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+const (
+	realtimeWriteTimeout = 10 * time.Second
+	realtimePingInterval = 30 * time.Second
+	realtimeSseHeartbeat = 15 * time.Second
+)
+
+// 跨域由 cors 中间件统一放行，这里不再校验 Origin
+var realtimeUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+type RealtimeApi struct {
+	hub *service.RealtimeHub
+}
+
+func NewRealtimeApi() *RealtimeApi {
+	return &RealtimeApi{
+		hub: service.GetRealtimeHub(),
+	}
+}
+
+// subscribe 解析订阅参数并注册订阅；cursor 可通过 query 或 SSE 的 Last-Event-ID 传入
+func (a *RealtimeApi) subscribe(c *gin.Context) (*service.RealtimeSubscription, []model.RealtimeMessage, bool, int64, bool) {
+	split := func(v string) []string {
+		if v == "" {
+			return nil
+		}
+		return strings.Split(v, ",")
+	}
+	pools, users, types := split(c.Query("pools")), split(c.Query("users")), split(c.Query("types"))
+	for _, addr := range append(append([]string{}, pools...), users...) {
+		if !commonUtil.ValidateHexAddress(strings.TrimSpace(addr)) {
+			result.Error(c, result.InvalidParameter)
+			return nil, nil, false, 0, false
+		}
+	}
+	validTypes := append(service.ExplorerEventTypes(), model.RealtimeTypePool)
+	for _, t := range types {
+		valid := false
+		for _, v := range validTypes {
+			valid = valid || strings.ToLower(strings.TrimSpace(t)) == v
+		}
+		if !valid {
+			result.Error(c, result.InvalidParameter)
+			return nil, nil, false, 0, false
+		}
+	}
+
+	cursorStr := c.Query("cursor")
+	if cursorStr == "" {
+		cursorStr = c.GetHeader("Last-Event-ID")
+	}
+	var cursor int64
+	if cursorStr != "" {
+		v, err := strconv.ParseInt(cursorStr, 10, 64)
+		if err != nil || v < 0 {
+			result.Error(c, result.InvalidParameter)
+			return nil, nil, false, 0, false
+		}
+		cursor = v
+	}
+
+	sub, replay, truncated, err := a.hub.Subscribe(service.NewRealtimeFilter(pools, users, types), cursor)
+	if err != nil {
+		log.Logger.Error("订阅实时推送失败", zap.Error(err))
+		result.SysError(c, "订阅实时推送失败: "+err.Error())
+		return nil, nil, false, 0, false
+	}
+	return sub, replay, truncated, cursor, true
+}
+
+// WebSocket godoc
+// @Summary      实时推送（WebSocket）
+// @Description  推送兑换、流动性、质押、空投领取事件与池子状态更新；每条消息为 model.RealtimeMessage JSON，重连时传入最后收到的 id 作为 cursor 重放
+// @Tags realtime
+// @Param        pools   query  string  false  "池子地址，逗号分隔"
+// @Param        users   query  string  false  "用户地址，逗号分隔"
+// @Param        types   query  string  false  "消息类型，逗号分隔：swap,mint,burn,collect,claim,stake,withdraw,pool"
+// @Param        cursor  query  int     false  "最后收到的消息 id"
+// @Router       /api/v1/realtime/ws [get]
+func (a *RealtimeApi) WebSocket(c *gin.Context) {
+	sub, replay, truncated, cursor, ok := a.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	conn, err := realtimeUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Logger.Warn("WebSocket 升级失败", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	send := func(msg model.RealtimeMessage) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+		return conn.WriteJSON(msg)
+	}
+
+	// 客户端只需接收，读循环用于处理关闭与 pong
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if truncated {
+		if err := send(model.RealtimeMessage{Type: model.RealtimeTypeReset}); err != nil {
+			return
+		}
+	}
+	delivered := cursor
+	replayed := make(map[int64]bool, len(replay))
+	for _, msg := range replay {
+		if err := send(msg); err != nil {
+			return
+		}
+		replayed[msg.Id] = true
+	}
+
+	ticker := time.NewTicker(realtimePingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "slow consumer"), time.Now().Add(realtimeWriteTimeout))
+				return
+			}
+			if msg.Id <= delivered || replayed[msg.Id] {
+				continue
+			}
+			if err := send(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(realtimeWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ServerSentEvents godoc
+// @Summary      实时推送（SSE）
+// @Description  与 WebSocket 推送内容相同；事件 id 为消息序号，浏览器重连时通过 Last-Event-ID 自动重放
+// @Tags realtime
+// @Produce      text/event-stream
+// @Param        pools   query  string  false  "池子地址，逗号分隔"
+// @Param        users   query  string  false  "用户地址，逗号分隔"
+// @Param        types   query  string  false  "消息类型，逗号分隔：swap,mint,burn,collect,claim,stake,withdraw,pool"
+// @Param        cursor  query  int     false  "最后收到的消息 id，默认取 Last-Event-ID"
+// @Router       /api/v1/realtime/sse [get]
+func (a *RealtimeApi) ServerSentEvents(c *gin.Context) {
+	sub, replay, truncated, cursor, ok := a.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(msg model.RealtimeMessage) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if msg.Id > 0 {
+			_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", msg.Id, msg.Type, data)
+		} else {
+			_, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", msg.Type, data)
+		}
+		c.Writer.Flush()
+		return err
+	}
+
+	if truncated {
+		if err := send(model.RealtimeMessage{Type: model.RealtimeTypeReset}); err != nil {
+			return
+		}
+	}
+	replayed := make(map[int64]bool, len(replay))
+	for _, msg := range replay {
+		if err := send(msg); err != nil {
+			return
+		}
+		replayed[msg.Id] = true
+	}
+
+	heartbeat := time.NewTicker(realtimeSseHeartbeat)
+	defer heartbeat.Stop()
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				// 消费过慢被断开，客户端按 Last-Event-ID 重连即可补齐
+				return
+			}
+			if msg.Id <= cursor || replayed[msg.Id] {
+				continue
+			}
+			if err := send(msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type SafeProposalApi struct {
+	svc *service.SafeProposalService
+}
+
+func NewSafeProposalApi() *SafeProposalApi {
+	return &SafeProposalApi{
+		svc: service.NewSafeProposalService(),
+	}
+}
+
+// safeProposalError 参数错误（含质押管理参数校验）与无权限附带原因返回，其余按系统错误返回
+func safeProposalError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrSafeInvalidParam), errors.Is(err, service.ErrStakingAdminInvalidParam):
+		result.ErrorData(c, result.InvalidParameter, err.Error())
+	case errors.Is(err, service.ErrSafeForbidden):
+		result.ErrorData(c, result.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrSafeNotFound):
+		result.Error(c, result.DBNotExist)
+	default:
+		log.Logger.Error(action+"失败", zap.Error(err))
+		result.SysError(c, action+"失败: "+err.Error())
+	}
+}
+
+// CreateProposal godoc
+// @Summary      生成 Safe 多签提案
+// @Description  合约 owner 为 Safe 时替代服务端直接发送：支持 create_airdrop、update_merkle_root、activate_airdrop 与 StakeV2 的 add_pool、set_pool_active、pause、unpause、grant_role、revoke_role。调用方需为该链 Safe 的 owner，每个调用以 Safe 身份 eth_call 模拟；多个调用经 MultiSendCallOnly 批量执行。返回 EIP-712 safeTxHash 与 Transaction Builder JSON，执行后跟踪到结果被索引
+// @Tags safe
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.SafeProposalInput  true  "提案内容"
+// @Success      200 {object} result.Response{data=map[string]interface{}}
+// @Router       /api/v1/safe/proposals [post]
+func (a *SafeProposalApi) CreateProposal(c *gin.Context) {
+	var req service.SafeProposalInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	proposal, err := a.svc.Propose(c.GetString("address"), req)
+	if err != nil {
+		safeProposalError(c, "生成 Safe 提案", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"proposal": proposal,
+		"builder":  json.RawMessage(proposal.BuilderJson),
+	})
+}
+
+// ListProposals godoc
+// @Summary      Safe 提案列表
+// @Tags safe
+// @Produce      json
+// @Security     BearerAuth
+// @Param        chainId   query  int     true   "链ID"
+// @Param        status    query  string  false  "状态：proposed,executed,indexed,failed,replaced"
+// @Param        page      query  int     false  "页码"
+// @Param        pageSize  query  int     false  "每页数量"
+// @Success      200 {object} result.Response{data=[]model.SafeProposal}
+// @Router       /api/v1/safe/proposals [get]
+func (a *SafeProposalApi) ListProposals(c *gin.Context) {
+	chainId, err := strconv.ParseInt(c.Query("chainId"), 10, 64)
+	if err != nil || chainId <= 0 {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	pg := parsePagination(c.DefaultQuery("page", "1"), c.DefaultQuery("pageSize", "20"))
+	list, total, err := a.svc.List(c.GetString("address"), chainId, c.Query("status"), pg)
+	if err != nil {
+		safeProposalError(c, "查询 Safe 提案", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     pg.Page,
+		"pageSize": pg.PageSize,
+	})
+}
+
+// GetProposal godoc
+// @Summary      Safe 提案详情
+// @Tags safe
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "提案ID"
+// @Success      200 {object} result.Response{data=model.SafeProposal}
+// @Router       /api/v1/safe/proposals/{id} [get]
+func (a *SafeProposalApi) GetProposal(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	proposal, err := a.svc.Get(c.GetString("address"), id)
+	if err != nil {
+		safeProposalError(c, "查询 Safe 提案", err)
+		return
+	}
+	result.OK(c, proposal)
+}
+
+// DownloadBuilder godoc
+// @Summary      下载 Transaction Builder 文件
+// @Description  直接返回可导入 Safe Transaction Builder 的 JSON 文件
+// @Tags safe
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "提案ID"
+// @Success      200 {object} map[string]interface{}
+// @Router       /api/v1/safe/proposals/{id}/builder [get]
+func (a *SafeProposalApi) DownloadBuilder(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	proposal, err := a.svc.Get(c.GetString("address"), id)
+	if err != nil {
+		safeProposalError(c, "查询 Safe 提案", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="safe-proposal-%d.json"`, proposal.Id))
+	c.Data(http.StatusOK, "application/json", []byte(proposal.BuilderJson))
+}
@@ -1,38 +1,46 @@
 package api
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"github.com/mumu/cryptoSwap/src/app/api/dto"
 	"github.com/mumu/cryptoSwap/src/app/service"
 	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
 	"github.com/mumu/cryptoSwap/src/core/result"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
 )
 
 type StakeApi struct {
-	svc *service.StakeService
+	svc     *service.StakeService
+	poolSvc *service.StakePoolService
 }
 
 func NewStakeApi() *StakeApi {
 	return &StakeApi{
-		svc: service.NewStakeService(),
+		svc:     service.NewStakeService(),
+		poolSvc: service.NewStakePoolService(),
 	}
 }
 
 // StakeRequest 质押请求参数
 type StakeRequest struct {
-	UserAddress string  `json:"userAddress" binding:"required"`
-	ChainId     int64   `json:"chainId" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Token       string  `json:"token" binding:"required"`
-	PoolId      string  `json:"poolId" binding:"required"`
+	UserAddress string          `json:"userAddress" binding:"required"`
+	ChainId     int64           `json:"chainId" binding:"required"`
+	Amount      decimal.Decimal `json:"amount"` // 按代币精度的数量，可传数字或字符串
+	Token       string          `json:"token"`  // 可选，传入时需与质押池代币一致
+	PoolId      string          `json:"poolId" binding:"required"`
 }
 
-// WithdrawRequest 提取请求参数
+// WithdrawRequest 提取请求参数，stakeId 与 amount 二选一
 type WithdrawRequest struct {
-	UserAddress string `json:"userAddress" binding:"required"`
-	ChainId     int64  `json:"chainId" binding:"required"`
-	StakeId     string `json:"stakeId" binding:"required"`
-	PoolId      string `json:"poolId" binding:"required"`
+	UserAddress string          `json:"userAddress" binding:"required"`
+	ChainId     int64           `json:"chainId" binding:"required"`
+	StakeId     string          `json:"stakeId"` // 按该笔质押记录的金额提取
+	Amount      decimal.Decimal `json:"amount"`  // 按代币精度的数量
+	PoolId      string          `json:"poolId" binding:"required"`
 }
 
 // GetStakeRecordsRequest 获取质押记录请求参数
@@ -43,6 +51,11 @@ type GetStakeRecordsRequest struct {
 	PageSize    int    `form:"pageSize,default=20"`
 }
 
+// GetStakePoolsRequest 质押池列表请求参数
+type GetStakePoolsRequest struct {
+	ChainId int64 `form:"chainId" binding:"required"`
+}
+
 // GetStakeOverviewRequest 获取质押概览请求参数
 type GetStakeOverviewRequest struct {
 	UserAddress string `json:"userAddress" binding:"required"`
@@ -50,13 +63,13 @@ type GetStakeOverviewRequest struct {
 }
 
 // Stake 质押接口
-// @Summary 质押代币
-// @Description 用户质押代币到流动性池
+// @Summary 构建质押交易
+// @Description 返回待用户钱包签名的 EIP-1559 交易：授权额度不足时先 approve，再 deposit。服务端不签名，质押记录以索引到的 Staked 事件为准
 // @Tags stake
 // @Accept json
 // @Produce json
 // @Param request body StakeRequest true "质押请求参数"
-// @Success 200 {object} result.Response{data=model.StakeRecord}
+// @Success 200 {object} result.Response{data=model.StakeTxPlan}
 // @Router /api/v1/stake [post]
 func (s *StakeApi) Stake(c *gin.Context) {
 	var req StakeRequest
@@ -66,33 +79,33 @@ func (s *StakeApi) Stake(c *gin.Context) {
 	}
 
 	// 验证地址格式
-	if !commonUtil.ValidateHexAddress(req.UserAddress) {
+	if !commonUtil.ValidateHexAddress(req.UserAddress) || (req.Token != "" && !commonUtil.ValidateHexAddress(req.Token)) {
 		result.Error(c, result.InvalidParameter)
 		return
 	}
 	// 将poolId字符串转换为int64
 	poolId, err := commonUtil.ParseInt64(req.PoolId)
-	if err != nil {
+	if err != nil || !req.Amount.IsPositive() {
 		result.Error(c, result.InvalidParameter)
 		return
 	}
-	stakeRecord, err := s.svc.ProcessStake(req.UserAddress, req.ChainId, req.Amount, req.Token, poolId)
+	plan, err := s.svc.BuildStakeTx(req.UserAddress, req.ChainId, req.Amount, req.Token, poolId)
 	if err != nil {
-		result.Error(c, result.StakeError)
+		stakeError(c, "构建质押交易失败", err)
 		return
 	}
 
-	result.OK(c, stakeRecord)
+	result.OK(c, plan)
 }
 
 // Withdraw godoc
-// @Summary 提取质押的代币
-// @Description 用户提取已质押的代币
+// @Summary 构建提取交易
+// @Description 返回待用户钱包签名的 EIP-1559 withdraw 交易。锁定期等条件由合约校验，交易预计回滚时返回错误；提取记录以索引到的 Withdrawn 事件为准
 // @Tags stake
 // @Accept json
 // @Produce json
 // @Param request body WithdrawRequest true "提取请求参数"
-// @Success 200 {object} result.Response{data=model.StakeRecord}
+// @Success 200 {object} result.Response{data=model.StakeTxPlan}
 // @Router /api/v1/stake/withdraw [post]
 func (s *StakeApi) Withdraw(c *gin.Context) {
 	var req WithdrawRequest
@@ -111,24 +124,34 @@ func (s *StakeApi) Withdraw(c *gin.Context) {
 		result.Error(c, result.InvalidParameter)
 		return
 	}
-	// 将poolId字符串转换为int64
+	// 将stakeId字符串转换为int64，未传时按 amount 提取
 	stakeId, err := commonUtil.ParseInt64(req.StakeId)
-	if err != nil {
+	if err != nil || (stakeId <= 0 && !req.Amount.IsPositive()) {
 		result.Error(c, result.InvalidParameter)
 		return
 	}
-	stakeRecord, err := s.svc.ProcessWithdraw(req.UserAddress, req.ChainId, stakeId, poolId)
+	plan, err := s.svc.BuildWithdrawTx(req.UserAddress, req.ChainId, req.Amount, stakeId, poolId)
 	if err != nil {
-		result.Error(c, result.DBQueryFailed)
+		stakeError(c, "构建提取交易失败", err)
 		return
 	}
 
-	result.OK(c, stakeRecord)
+	result.OK(c, plan)
+}
+
+// stakeError 参数类错误返回 InvalidParameter 并附带原因，其余按系统错误返回
+func stakeError(c *gin.Context, action string, err error) {
+	if errors.Is(err, service.ErrStakeInvalidParam) {
+		result.ErrorData(c, result.InvalidParameter, err.Error())
+		return
+	}
+	log.Logger.Error(action, zap.Error(err))
+	result.SysError(c, action+": "+err.Error())
 }
 
 // GetStakeRecords godoc
 // @Summary 获取用户的质押记录
-// @Description 分页获取用户的质押记录
+// @Description 分页获取用户的每笔质押及剩余本金，状态为 locked（锁定中）、unlockable（已解锁可提取）、withdrawn（已全部提取）；部分提取按解锁时间先后扣减
 // @Tags stake
 // @Accept json
 // @Produce json
@@ -152,6 +175,12 @@ func (s *StakeApi) GetStakeRecords(c *gin.Context) {
 	}
 
 	// 构建分页参数
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
 	pagination := dto.Pagination{
 		Page:     req.Page,
 		PageSize: req.PageSize,
@@ -176,7 +205,7 @@ func (s *StakeApi) GetStakeRecords(c *gin.Context) {
 
 // GetStakeOverview 获取质押概览
 // @Summary 获取用户的质押概览
-// @Description 获取用户的总质押量、收益等信息
+// @Description 获取用户的当前本金、锁定与可提取数量、已领取收益以及按质押池的持仓明细
 // @Tags stake
 // @Accept json
 // @Produce json
@@ -204,3 +233,26 @@ func (s *StakeApi) GetStakeOverview(c *gin.Context) {
 
 	result.OK(c, overview)
 }
+
+// GetStakePools godoc
+// @Summary 质押池列表
+// @Description 列出链上质押合约（chain 表 service_type = staking）的全部池子：代币信息、锁定时长、日份额价格、手续费率、TVL、启用状态与 APR。APR 按最近 7 天份额价格增长年化，数据缓存 60 秒
+// @Tags stake
+// @Produce json
+// @Param chainId query int64 true "链ID"
+// @Success 200 {object} result.Response{data=[]model.StakePoolInfo}
+// @Router /api/v1/stake/pools [get]
+func (s *StakeApi) GetStakePools(c *gin.Context) {
+	var req GetStakePoolsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	pools, err := s.poolSvc.ListPools(req.ChainId)
+	if err != nil {
+		stakeError(c, "获取质押池列表失败", err)
+		return
+	}
+	result.OK(c, pools)
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type StakingAdminApi struct {
+	svc *service.StakingAdminService
+}
+
+func NewStakingAdminApi() *StakingAdminApi {
+	return &StakingAdminApi{
+		svc: service.NewStakingAdminService(),
+	}
+}
+
+// stakingAdminError 参数错误与无权限附带原因返回，其余按系统错误返回
+func stakingAdminError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrStakingAdminInvalidParam):
+		result.ErrorData(c, result.InvalidParameter, err.Error())
+	case errors.Is(err, service.ErrStakingAdminForbidden):
+		result.ErrorData(c, result.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrStakingAdminNotFound):
+		result.Error(c, result.DBNotExist)
+	default:
+		log.Logger.Error(action+"失败", zap.Error(err))
+		result.SysError(c, action+"失败: "+err.Error())
+	}
+}
+
+// AddPool godoc
+// @Summary      新建质押池
+// @Description  调用 StakeV2 addPool。调用方需登录且在链上持有 ADMIN_ROLE；先以 eth_call 模拟，dryRun=true 时只模拟不发送。交易经托管发送，可按操作ID或 managedTxId 查询状态
+// @Tags staking-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.StakingAdminAddPoolInput  true  "池子参数"
+// @Success      200 {object} result.Response{data=model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/pools [post]
+func (a *StakingAdminApi) AddPool(c *gin.Context) {
+	var req service.StakingAdminAddPoolInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	action, err := a.svc.AddPool(c.GetString("address"), req)
+	if err != nil {
+		stakingAdminError(c, "新建质押池", err)
+		return
+	}
+	result.OK(c, action)
+}
+
+// SetPoolActive godoc
+// @Summary      启用/停用质押池
+// @Description  调用 StakeV2 setPoolActive，池子需已存在；dryRun=true 时只模拟
+// @Tags staking-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        poolId  path  int                                     true  "池子ID"
+// @Param        body    body  service.StakingAdminPoolActiveInput  true  "启用状态"
+// @Success      200 {object} result.Response{data=model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/pools/{poolId}/active [post]
+func (a *StakingAdminApi) SetPoolActive(c *gin.Context) {
+	poolId, err := strconv.ParseInt(c.Param("poolId"), 10, 64)
+	if err != nil || poolId < 0 {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var req service.StakingAdminPoolActiveInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	action, err := a.svc.SetPoolActive(c.GetString("address"), poolId, req)
+	if err != nil {
+		stakingAdminError(c, "设置质押池状态", err)
+		return
+	}
+	result.OK(c, action)
+}
+
+// Pause godoc
+// @Summary      暂停质押合约
+// @Tags staking-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.StakingAdminPauseInput  true  "链ID"
+// @Success      200 {object} result.Response{data=model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/pause [post]
+func (a *StakingAdminApi) Pause(c *gin.Context) {
+	var req service.StakingAdminPauseInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	action, err := a.svc.Pause(c.GetString("address"), req)
+	if err != nil {
+		stakingAdminError(c, "暂停质押合约", err)
+		return
+	}
+	result.OK(c, action)
+}
+
+// Unpause godoc
+// @Summary      恢复质押合约
+// @Tags staking-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.StakingAdminPauseInput  true  "链ID"
+// @Success      200 {object} result.Response{data=model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/unpause [post]
+func (a *StakingAdminApi) Unpause(c *gin.Context) {
+	var req service.StakingAdminPauseInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	action, err := a.svc.Unpause(c.GetString("address"), req)
+	if err != nil {
+		stakingAdminError(c, "恢复质押合约", err)
+		return
+	}
+	result.OK(c, action)
+}
+
+// GrantRole godoc
+// @Summary      授予质押合约角色
+// @Description  role 取 ADMIN_ROLE、UPGRADER_ROLE、DEFAULT_ADMIN_ROLE；调用方需持有该角色的管理角色
+// @Tags staking-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.StakingAdminRoleInput  true  "角色与账户"
+// @Success      200 {object} result.Response{data=model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/roles/grant [post]
+func (a *StakingAdminApi) GrantRole(c *gin.Context) {
+	var req service.StakingAdminRoleInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	action, err := a.svc.GrantRole(c.GetString("address"), req)
+	if err != nil {
+		stakingAdminError(c, "授予角色", err)
+		return
+	}
+	result.OK(c, action)
+}
+
+// RevokeRole godoc
+// @Summary      撤销质押合约角色
+// @Description  role 取 ADMIN_ROLE、UPGRADER_ROLE、DEFAULT_ADMIN_ROLE；调用方需持有该角色的管理角色
+// @Tags staking-admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.StakingAdminRoleInput  true  "角色与账户"
+// @Success      200 {object} result.Response{data=model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/roles/revoke [post]
+func (a *StakingAdminApi) RevokeRole(c *gin.Context) {
+	var req service.StakingAdminRoleInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	action, err := a.svc.RevokeRole(c.GetString("address"), req)
+	if err != nil {
+		stakingAdminError(c, "撤销角色", err)
+		return
+	}
+	result.OK(c, action)
+}
+
+// ListActions godoc
+// @Summary      质押管理操作审计列表
+// @Description  仅返回该链的操作，调用方需具备与管理操作相同的权限
+// @Tags staking-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        chainId   query  int     true   "链ID"
+// @Param        action    query  string  false  "操作类型：add_pool,set_pool_active,pause,unpause,grant_role,revoke_role"
+// @Param        status    query  string  false  "状态：simulated,simulation_failed,submitting,confirmed,reconciled,reverted,failed"
+// @Param        page      query  int     false  "页码"
+// @Param        pageSize  query  int     false  "每页数量"
+// @Success      200 {object} result.Response{data=[]model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/actions [get]
+func (a *StakingAdminApi) ListActions(c *gin.Context) {
+	chainId, err := strconv.ParseInt(c.Query("chainId"), 10, 64)
+	if err != nil || chainId <= 0 {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	pg := parsePagination(c.DefaultQuery("page", "1"), c.DefaultQuery("pageSize", "20"))
+	list, total, err := a.svc.ListActions(c.GetString("address"), chainId, c.Query("action"), c.Query("status"), pg)
+	if err != nil {
+		stakingAdminError(c, "查询质押管理操作", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     pg.Page,
+		"pageSize": pg.PageSize,
+	})
+}
+
+// GetAction godoc
+// @Summary      质押管理操作详情
+// @Tags staking-admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "操作ID"
+// @Success      200 {object} result.Response{data=model.StakingAdminAction}
+// @Router       /api/v1/admin/stake/actions/{id} [get]
+func (a *StakingAdminApi) GetAction(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	action, err := a.svc.GetAction(c.GetString("address"), id)
+	if err != nil {
+		stakingAdminError(c, "查询质押管理操作", err)
+		return
+	}
+	result.OK(c, action)
+}
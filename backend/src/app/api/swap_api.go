@@ -0,0 +1,51 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	commonUtil "github.com/mumu/cryptoSwap/src/common"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type SwapApi struct {
+	svc *service.QuoteService
+}
+
+func NewSwapApi() *SwapApi {
+	return &SwapApi{
+		svc: service.NewQuoteService(),
+	}
+}
+
+// Quote godoc
+// @Summary      兑换询价与路由
+// @Description  基于已索引池子储备量与恒定乘积公式计算最优路由（exactIn / exactOut），返回预期数量、价格影响、滑点保护数量与路由合约调用数据
+// @Tags swap
+// @Accept       json
+// @Produce      json
+// @Param        body  body  model.SwapQuoteRequest  true  "询价参数"
+// @Success      200 {object} result.Response{data=model.SwapQuoteResponse}
+// @Router       /api/v1/swap/quote [post]
+func (a *SwapApi) Quote(c *gin.Context) {
+	var req model.SwapQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ChainId <= 0 || req.Amount == "" ||
+		!commonUtil.ValidateHexAddress(req.TokenIn) || !commonUtil.ValidateHexAddress(req.TokenOut) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	if req.Recipient != "" && !commonUtil.ValidateHexAddress(req.Recipient) {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+
+	quote, err := a.svc.Quote(req)
+	if err != nil {
+		log.Logger.Warn("兑换询价失败", zap.String("token_in", req.TokenIn), zap.String("token_out", req.TokenOut), zap.Error(err))
+		result.SysError(c, "询价失败: "+err.Error())
+		return
+	}
+	result.OK(c, quote)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+// UdfApi TradingView UDF 数据源接口（返回 UDF 原生格式，不使用统一 result 包装）
+type UdfApi struct {
+	svc *service.CandleService
+}
+
+func NewUdfApi() *UdfApi {
+	return &UdfApi{
+		svc: service.NewCandleService(),
+	}
+}
+
+func udfSupportedResolutions() []string {
+	list := make([]string, 0, len(service.CandleResolutions))
+	for _, res := range service.CandleResolutions {
+		list = append(list, res.UDF)
+	}
+	return list
+}
+
+func udfError(c *gin.Context, msg string) {
+	c.JSON(http.StatusOK, gin.H{"s": "error", "errmsg": msg})
+}
+
+// Config godoc
+// @Summary      UDF 数据源配置
+// @Tags udf
+// @Produce      json
+// @Router       /api/v1/udf/config [get]
+func (a *UdfApi) Config(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"supported_resolutions":    udfSupportedResolutions(),
+		"supports_group_request":   false,
+		"supports_marks":           false,
+		"supports_search":          false,
+		"supports_timescale_marks": false,
+		"supports_time":            true,
+	})
+}
+
+// Time godoc
+// @Summary      UDF 服务器时间
+// @Tags udf
+// @Produce      plain
+// @Router       /api/v1/udf/time [get]
+func (a *UdfApi) Time(c *gin.Context) {
+	c.String(http.StatusOK, strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+// Symbols godoc
+// @Summary      UDF 交易对信息
+// @Tags udf
+// @Produce      json
+// @Param        symbol  query  string  true  "池子地址，或 链ID:池子地址"
+// @Router       /api/v1/udf/symbols [get]
+func (a *UdfApi) Symbols(c *gin.Context) {
+	symbol := c.Query("symbol")
+	pool, err := a.svc.ResolvePool(symbol)
+	if err != nil {
+		udfError(c, "unknown_symbol")
+		return
+	}
+
+	name := fmt.Sprintf("%s/%s", pool.Token0Symbol, pool.Token1Symbol)
+	c.JSON(http.StatusOK, gin.H{
+		"name":                  name,
+		"ticker":                fmt.Sprintf("%d:%s", pool.ChainId, pool.PoolAddress),
+		"description":           name,
+		"type":                  "crypto",
+		"session":               "24x7",
+		"timezone":              "Etc/UTC",
+		"exchange":              "AlanSwap",
+		"listed_exchange":       "AlanSwap",
+		"minmov":                1,
+		"pricescale":            100000000,
+		"has_intraday":          true,
+		"has_daily":             true,
+		"intraday_multipliers":  []string{"1", "5", "60", "240"},
+		"supported_resolutions": udfSupportedResolutions(),
+		"volume_precision":      2,
+		"data_status":           "streaming",
+	})
+}
+
+// History godoc
+// @Summary      UDF K线历史数据
+// @Description  成交价为 token1/token0，成交量为 USD 交易量（无法定价时为 token0 数量）
+// @Tags udf
+// @Produce      json
+// @Param        symbol      query  string  true   "池子地址，或 链ID:池子地址"
+// @Param        resolution  query  string  true   "周期：1, 5, 60, 240, 1D"
+// @Param        from        query  int     true   "开始时间（unix秒）"
+// @Param        to          query  int     true   "结束时间（unix秒）"
+// @Param        countback   query  int     false  "至少返回的K线数量"
+// @Router       /api/v1/udf/history [get]
+func (a *UdfApi) History(c *gin.Context) {
+	res, ok := a.svc.ParseResolution(c.Query("resolution"))
+	if !ok {
+		udfError(c, "unsupported resolution")
+		return
+	}
+	from, err1 := strconv.ParseInt(c.Query("from"), 10, 64)
+	to, err2 := strconv.ParseInt(c.Query("to"), 10, 64)
+	if err1 != nil || err2 != nil || from >= to {
+		udfError(c, "invalid time range")
+		return
+	}
+	countback, _ := strconv.Atoi(c.Query("countback"))
+
+	pool, err := a.svc.ResolvePool(c.Query("symbol"))
+	if err != nil {
+		udfError(c, "unknown_symbol")
+		return
+	}
+
+	candles, err := a.svc.GetCandles(*pool, res, time.Unix(from, 0), time.Unix(to, 0), countback)
+	if err != nil {
+		log.Logger.Error("查询K线失败", zap.String("pool", pool.PoolAddress), zap.Error(err))
+		udfError(c, "query failed")
+		return
+	}
+
+	if len(candles) == 0 {
+		resp := gin.H{"s": "no_data"}
+		if next, ok := a.svc.GetLastCandleTimeBefore(*pool, res, time.Unix(from, 0)); ok {
+			resp["nextTime"] = next.Unix()
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	t := make([]int64, 0, len(candles))
+	o := make([]float64, 0, len(candles))
+	h := make([]float64, 0, len(candles))
+	l := make([]float64, 0, len(candles))
+	cl := make([]float64, 0, len(candles))
+	v := make([]float64, 0, len(candles))
+	for _, candle := range candles {
+		t = append(t, candle.BucketStart.Unix())
+		o = append(o, candle.Open.InexactFloat64())
+		h = append(h, candle.High.InexactFloat64())
+		l = append(l, candle.Low.InexactFloat64())
+		cl = append(cl, candle.Close.InexactFloat64())
+		if candle.VolumeUSD.IsPositive() {
+			v = append(v, candle.VolumeUSD.InexactFloat64())
+		} else {
+			v = append(v, candle.Volume0.InexactFloat64())
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"s": "ok",
+		"t": t,
+		"o": o,
+		"h": h,
+		"l": l,
+		"c": cl,
+		"v": v,
+	})
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/result"
+	"go.uber.org/zap"
+)
+
+type WebhookApi struct {
+	svc *service.WebhookService
+}
+
+func NewWebhookApi() *WebhookApi {
+	return &WebhookApi{
+		svc: service.NewWebhookService(),
+	}
+}
+
+// webhookError 按错误类型返回响应
+func webhookError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookInvalidParam):
+		result.Error(c, result.InvalidParameter)
+	case errors.Is(err, service.ErrWebhookNotFound):
+		result.Error(c, result.DBNotExist)
+	default:
+		log.Logger.Error(action+"失败", zap.Error(err))
+		result.SysError(c, action+"失败: "+err.Error())
+	}
+}
+
+func pathId(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// CreateWebhook godoc
+// @Summary      创建 Webhook 订阅
+// @Description  按事件类型、池子、钱包订阅事件（stake,withdraw,swap,mint,burn,collect,claim,merkle_root,share_price_alert）。请求体带 X-AlanSwap-Signature: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))，timestamp 取 X-AlanSwap-Timestamp；secret 仅在创建时返回
+// @Tags webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body  service.WebhookSubscriptionInput  true  "订阅条件"
+// @Success      200 {object} result.Response
+// @Router       /api/v1/webhooks [post]
+func (a *WebhookApi) CreateWebhook(c *gin.Context) {
+	var req service.WebhookSubscriptionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	sub, secret, err := a.svc.CreateSubscription(c.GetString("address"), req)
+	if err != nil {
+		webhookError(c, "创建 webhook 订阅", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"subscription": sub,
+		"secret":       secret,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary      Webhook 订阅列表
+// @Tags webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} result.Response{data=[]model.WebhookSubscription}
+// @Router       /api/v1/webhooks [get]
+func (a *WebhookApi) ListWebhooks(c *gin.Context) {
+	subs, err := a.svc.ListSubscriptions(c.GetString("address"))
+	if err != nil {
+		webhookError(c, "查询 webhook 订阅", err)
+		return
+	}
+	result.OK(c, subs)
+}
+
+// GetWebhook godoc
+// @Summary      Webhook 订阅详情
+// @Tags webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "订阅ID"
+// @Success      200 {object} result.Response{data=model.WebhookSubscription}
+// @Router       /api/v1/webhooks/{id} [get]
+func (a *WebhookApi) GetWebhook(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	sub, err := a.svc.GetSubscription(c.GetString("address"), id)
+	if err != nil {
+		webhookError(c, "查询 webhook 订阅", err)
+		return
+	}
+	result.OK(c, sub)
+}
+
+// UpdateWebhook godoc
+// @Summary      更新 Webhook 订阅
+// @Description  整体替换订阅条件；isActive 不传时保持原状态
+// @Tags webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path  int                               true  "订阅ID"
+// @Param        body  body  service.WebhookSubscriptionInput  true  "订阅条件"
+// @Success      200 {object} result.Response{data=model.WebhookSubscription}
+// @Router       /api/v1/webhooks/{id} [put]
+func (a *WebhookApi) UpdateWebhook(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	var req service.WebhookSubscriptionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	sub, err := a.svc.UpdateSubscription(c.GetString("address"), id, req)
+	if err != nil {
+		webhookError(c, "更新 webhook 订阅", err)
+		return
+	}
+	result.OK(c, sub)
+}
+
+// DeleteWebhook godoc
+// @Summary      删除 Webhook 订阅
+// @Description  同时删除该订阅的投递任务与投递记录
+// @Tags webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "订阅ID"
+// @Success      200 {object} result.Response
+// @Router       /api/v1/webhooks/{id} [delete]
+func (a *WebhookApi) DeleteWebhook(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	if err := a.svc.DeleteSubscription(c.GetString("address"), id); err != nil {
+		webhookError(c, "删除 webhook 订阅", err)
+		return
+	}
+	result.OK(c, nil)
+}
+
+// RotateWebhookSecret godoc
+// @Summary      重新生成 Webhook 签名密钥
+// @Tags webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "订阅ID"
+// @Success      200 {object} result.Response
+// @Router       /api/v1/webhooks/{id}/rotate-secret [post]
+func (a *WebhookApi) RotateWebhookSecret(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	secret, err := a.svc.RotateSecret(c.GetString("address"), id)
+	if err != nil {
+		webhookError(c, "重新生成 webhook 密钥", err)
+		return
+	}
+	result.OK(c, gin.H{"secret": secret})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      Webhook 投递日志
+// @Description  按订阅分页查询投递任务；status=dead 即死信队列
+// @Tags webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path   int     true   "订阅ID"
+// @Param        status    query  string  false  "投递状态：pending/succeeded/dead"
+// @Param        page      query  int     false  "页码，默认1"
+// @Param        pageSize  query  int     false  "每页条数，默认20，最大100"
+// @Success      200 {object} result.Response
+// @Router       /api/v1/webhooks/{id}/deliveries [get]
+func (a *WebhookApi) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliverySucceeded, model.WebhookDeliveryDead:
+	default:
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	pg := parsePagination(c.DefaultQuery("page", "1"), c.DefaultQuery("pageSize", "20"))
+	list, total, err := a.svc.ListDeliveries(c.GetString("address"), id, status, pg.Offset, pg.PageSize)
+	if err != nil {
+		webhookError(c, "查询 webhook 投递日志", err)
+		return
+	}
+	result.OK(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     pg.Page,
+		"pageSize": pg.PageSize,
+	})
+}
+
+// ListWebhookAttempts godoc
+// @Summary      Webhook 投递请求记录
+// @Tags webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "投递任务ID"
+// @Success      200 {object} result.Response{data=[]model.WebhookDeliveryAttempt}
+// @Router       /api/v1/webhook-deliveries/{id}/attempts [get]
+func (a *WebhookApi) ListWebhookAttempts(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	list, err := a.svc.ListAttempts(c.GetString("address"), id)
+	if err != nil {
+		webhookError(c, "查询 webhook 投递记录", err)
+		return
+	}
+	result.OK(c, list)
+}
+
+// RedeliverWebhook godoc
+// @Summary      重新投递
+// @Description  将投递任务（通常来自死信队列）重置为待投递，重新计算重试次数
+// @Tags webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "投递任务ID"
+// @Success      200 {object} result.Response
+// @Router       /api/v1/webhook-deliveries/{id}/redeliver [post]
+func (a *WebhookApi) RedeliverWebhook(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		result.Error(c, result.InvalidParameter)
+		return
+	}
+	if err := a.svc.Redeliver(c.GetString("address"), id); err != nil {
+		webhookError(c, "重新投递 webhook", err)
+		return
+	}
+	result.OK(c, nil)
+}
@@ -0,0 +1,28 @@
+-- 事件总线 outbox：索引事件与 outbox 记录在同一事务内写入，中继任务按 id 顺序发布到 Redis Streams
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id             BIGSERIAL PRIMARY KEY,
+    event_id       VARCHAR(160) NOT NULL,
+    event_type     VARCHAR(20) NOT NULL,
+    chain_id       BIGINT NOT NULL,
+    schema_version INTEGER NOT NULL DEFAULT 1,
+    payload        JSONB NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at   TIMESTAMP,
+    stream_id      VARCHAR(64),
+    attempts       INTEGER NOT NULL DEFAULT 0,
+    last_error     TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_event_outbox_event_id ON event_outbox(event_id);
+-- 中继任务只扫描未发布的记录
+CREATE INDEX IF NOT EXISTS idx_event_outbox_unpublished ON event_outbox(id) WHERE published_at IS NULL;
+
+COMMENT ON TABLE event_outbox IS '事件总线 outbox，发布到 Redis Streams';
+COMMENT ON COLUMN event_outbox.event_id IS '事件唯一标识：事件类型:链ID:交易哈希:日志序号';
+COMMENT ON COLUMN event_outbox.event_type IS '统一事件类型：stake/withdraw/swap/mint/burn/collect/claim';
+COMMENT ON COLUMN event_outbox.schema_version IS '消息 schema 版本';
+COMMENT ON COLUMN event_outbox.payload IS '序列化后的事件消息';
+COMMENT ON COLUMN event_outbox.published_at IS '发布时间，为空表示待发布';
+COMMENT ON COLUMN event_outbox.stream_id IS 'Redis Stream 消息ID';
+COMMENT ON COLUMN event_outbox.attempts IS '发布失败次数';
+COMMENT ON COLUMN event_outbox.last_error IS '最近一次发布失败原因';
@@ -0,0 +1,13 @@
+-- 质押/提取记录补充事件所在合约与日志序号，供事件浏览按 (block_number, log_index) 游标分页
+ALTER TABLE user_operation_record ADD COLUMN IF NOT EXISTS contract_address VARCHAR(42);
+ALTER TABLE user_operation_record ADD COLUMN IF NOT EXISTS log_index INTEGER DEFAULT 0;
+
+COMMENT ON COLUMN user_operation_record.contract_address IS '事件所在质押合约地址';
+COMMENT ON COLUMN user_operation_record.log_index IS '日志在区块内的序号';
+
+-- 事件浏览的排序与过滤索引
+CREATE INDEX IF NOT EXISTS idx_liquidity_pool_events_cursor ON liquidity_pool_events(block_number, log_index);
+CREATE INDEX IF NOT EXISTS idx_user_operation_record_cursor ON user_operation_record(block_number, log_index);
+CREATE INDEX IF NOT EXISTS idx_user_operation_record_tx ON user_operation_record(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_reward_claimed_events_cursor ON reward_claimed_events(block_number, log_index);
+CREATE INDEX IF NOT EXISTS idx_reward_claimed_events_user ON reward_claimed_events(user_address);
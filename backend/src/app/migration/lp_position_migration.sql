@@ -0,0 +1,57 @@
+-- LP代币转账事件表
+CREATE TABLE IF NOT EXISTS lp_transfer_events (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    pool_address VARCHAR(42) NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    block_number BIGINT NOT NULL,
+    log_index INT NOT NULL,
+    block_time TIMESTAMP,
+    from_address VARCHAR(42) NOT NULL,
+    to_address VARCHAR(42) NOT NULL,
+    amount DECIMAL(78,0) DEFAULT '0',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, tx_hash, log_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lp_transfer_events_pool ON lp_transfer_events(chain_id, pool_address, block_number, log_index);
+CREATE INDEX IF NOT EXISTS idx_lp_transfer_events_from ON lp_transfer_events(from_address);
+CREATE INDEX IF NOT EXISTS idx_lp_transfer_events_to ON lp_transfer_events(to_address);
+
+COMMENT ON TABLE lp_transfer_events IS 'LP代币转账事件表';
+COMMENT ON COLUMN lp_transfer_events.from_address IS '转出地址（小写），零地址表示铸造';
+COMMENT ON COLUMN lp_transfer_events.to_address IS '转入地址（小写），零地址表示销毁';
+COMMENT ON COLUMN lp_transfer_events.amount IS 'LP代币数量';
+
+-- LP持仓表
+CREATE TABLE IF NOT EXISTS lp_positions (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    pool_address VARCHAR(42) NOT NULL,
+    owner_address VARCHAR(42) NOT NULL,
+    balance DECIMAL(78,0) DEFAULT '0',
+    cost_token0 DECIMAL(78,0) DEFAULT '0',
+    cost_token1 DECIMAL(78,0) DEFAULT '0',
+    cost_basis_usd DECIMAL(38,2) DEFAULT '0',
+    deposited0 DECIMAL(78,0) DEFAULT '0',
+    deposited1 DECIMAL(78,0) DEFAULT '0',
+    withdrawn0 DECIMAL(78,0) DEFAULT '0',
+    withdrawn1 DECIMAL(78,0) DEFAULT '0',
+    last_block_num BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, pool_address, owner_address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lp_positions_owner ON lp_positions(owner_address);
+
+COMMENT ON TABLE lp_positions IS 'LP持仓表（由LP代币转账事件累计）';
+COMMENT ON COLUMN lp_positions.owner_address IS '持有地址（小写）';
+COMMENT ON COLUMN lp_positions.balance IS 'LP代币余额';
+COMMENT ON COLUMN lp_positions.cost_token0 IS '剩余持仓对应的存入代币0数量';
+COMMENT ON COLUMN lp_positions.cost_token1 IS '剩余持仓对应的存入代币1数量';
+COMMENT ON COLUMN lp_positions.cost_basis_usd IS '剩余持仓的USD成本（按存入时价格）';
+COMMENT ON COLUMN lp_positions.deposited0 IS '累计存入代币0数量';
+COMMENT ON COLUMN lp_positions.deposited1 IS '累计存入代币1数量';
+COMMENT ON COLUMN lp_positions.withdrawn0 IS '累计取出代币0数量';
+COMMENT ON COLUMN lp_positions.withdrawn1 IS '累计取出代币1数量';
@@ -0,0 +1,43 @@
+-- 服务端管理的空投活动：草稿、链上创建、激活与结束
+CREATE TABLE IF NOT EXISTS managed_airdrops (
+    id               BIGSERIAL PRIMARY KEY,
+    chain_id         BIGINT NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    name             VARCHAR(128) NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    icon_url         TEXT NOT NULL DEFAULT '',
+    token_symbol     VARCHAR(32) NOT NULL,
+    token_address    VARCHAR(42) NOT NULL DEFAULT '',
+    total_reward     DECIMAL(78,0) NOT NULL,
+    start_time       TIMESTAMPTZ NOT NULL,
+    end_time         TIMESTAMPTZ NOT NULL,
+    task_ids         JSONB NOT NULL DEFAULT '[]',
+    status           VARCHAR(16) NOT NULL,
+    airdrop_id       BIGINT,
+    initial_root     VARCHAR(66),
+    create_tx_id     BIGINT,
+    activate_tx_id   BIGINT,
+    create_tx_hash   VARCHAR(66),
+    error            TEXT,
+    created_by       VARCHAR(42) NOT NULL,
+    updated_by       VARCHAR(42) NOT NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_managed_airdrops_status ON managed_airdrops(status);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_managed_airdrops_airdrop ON managed_airdrops(chain_id, airdrop_id) WHERE airdrop_id IS NOT NULL;
+
+COMMENT ON TABLE managed_airdrops IS '通过管理接口创建的空投活动，按索引的 AirdropCreated、AirdropActivated 事件推进状态';
+COMMENT ON COLUMN managed_airdrops.total_reward IS '活动总奖励（wei）';
+COMMENT ON COLUMN managed_airdrops.task_ids IS '绑定的任务ID，创建确认后写入 airdrop_task_bindings';
+COMMENT ON COLUMN managed_airdrops.status IS 'draft 草稿，creating 创建交易待确认，created 已创建未激活，activating 激活交易待确认，active 进行中，ended 已结束';
+COMMENT ON COLUMN managed_airdrops.airdrop_id IS '链上空投ID，由 AirdropCreated 事件回填';
+COMMENT ON COLUMN managed_airdrops.initial_root IS 'createAirdrop 使用的占位默克尔根（不对应任何叶子），首个快照发布后替换';
+COMMENT ON COLUMN managed_airdrops.create_tx_id IS 'createAirdrop 托管交易ID';
+COMMENT ON COLUMN managed_airdrops.activate_tx_id IS 'activateAirdrop 托管交易ID';
+
+-- 空投活动记录创建交易，用于关联管理接口发起的创建
+ALTER TABLE airdrop_campaigns ADD COLUMN IF NOT EXISTS created_tx_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_airdrop_campaigns_created_tx ON airdrop_campaigns(created_tx_hash);
+COMMENT ON COLUMN airdrop_campaigns.created_tx_hash IS 'AirdropCreated 事件所在交易哈希';
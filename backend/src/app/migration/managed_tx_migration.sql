@@ -0,0 +1,76 @@
+-- 服务端托管交易（发件箱）
+CREATE TABLE IF NOT EXISTS managed_txs (
+    id                       BIGSERIAL PRIMARY KEY,
+    chain_id                 BIGINT NOT NULL,
+    from_address             VARCHAR(42) NOT NULL,
+    to_address               VARCHAR(42) NOT NULL,
+    data                     TEXT NOT NULL DEFAULT '0x',
+    value                    DECIMAL(78,0) NOT NULL DEFAULT 0,
+    nonce                    BIGINT NOT NULL,
+    gas_limit                BIGINT NOT NULL DEFAULT 0,
+    max_fee_per_gas          DECIMAL(78,0) NOT NULL DEFAULT 0,
+    max_priority_fee_per_gas DECIMAL(78,0) NOT NULL DEFAULT 0,
+    purpose                  VARCHAR(64) NOT NULL DEFAULT '',
+    status                   VARCHAR(16) NOT NULL,
+    tx_hash                  VARCHAR(66),
+    attempts                 INT NOT NULL DEFAULT 0,
+    cancel_requested         BOOLEAN NOT NULL DEFAULT FALSE,
+    block_number             BIGINT NOT NULL DEFAULT 0,
+    gas_used                 BIGINT NOT NULL DEFAULT 0,
+    error                    TEXT,
+    last_broadcast_at        TIMESTAMP,
+    next_check_at            TIMESTAMP NOT NULL DEFAULT NOW(),
+    confirmed_at             TIMESTAMP,
+    created_at               TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (chain_id, from_address, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_managed_txs_due ON managed_txs(status, next_check_at);
+
+COMMENT ON TABLE managed_txs IS '服务端托管发送的交易';
+COMMENT ON COLUMN managed_txs.purpose IS '业务用途，如 airdrop.update_merkle_root、stake.share_price、stake.admin';
+COMMENT ON COLUMN managed_txs.status IS 'pending 未广播，submitted 等待回执，confirmed 成功，reverted 执行失败，cancelled 已取消，failed nonce 被占用或放弃';
+COMMENT ON COLUMN managed_txs.tx_hash IS '最近一次广播的哈希，上链后为实际上链的哈希';
+COMMENT ON COLUMN managed_txs.attempts IS '广播次数（含提速与取消）';
+COMMENT ON COLUMN managed_txs.next_check_at IS '下次检查回执或重发的时间，领取时推后一个租期';
+
+-- 托管交易的每次广播
+CREATE TABLE IF NOT EXISTS managed_tx_attempts (
+    id                       BIGSERIAL PRIMARY KEY,
+    tx_id                    BIGINT NOT NULL,
+    kind                     VARCHAR(16) NOT NULL,
+    tx_hash                  VARCHAR(66) NOT NULL,
+    gas_limit                BIGINT NOT NULL,
+    max_fee_per_gas          DECIMAL(78,0) NOT NULL,
+    max_priority_fee_per_gas DECIMAL(78,0) NOT NULL,
+    created_at               TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_managed_tx_attempts_tx ON managed_tx_attempts(tx_id);
+
+COMMENT ON TABLE managed_tx_attempts IS '托管交易的广播记录，同一 nonce 的任一哈希上链即为结果';
+COMMENT ON COLUMN managed_tx_attempts.kind IS 'initial 首次广播，speed_up 提速重发，cancel 取消';
+
+-- 发送账户 nonce 分配
+CREATE TABLE IF NOT EXISTS managed_tx_nonces (
+    chain_id   BIGINT NOT NULL,
+    address    VARCHAR(42) NOT NULL,
+    next_nonce BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chain_id, address)
+);
+
+COMMENT ON TABLE managed_tx_nonces IS '发送账户在每条链上的下一个可分配 nonce';
+
+-- 质押管理操作关联托管交易
+ALTER TABLE staking_admin_actions ADD COLUMN IF NOT EXISTS managed_tx_id BIGINT;
+COMMENT ON COLUMN staking_admin_actions.managed_tx_id IS '托管交易ID';
+
+-- 广播失败次数：未能广播的交易达到上限后以取消交易填补 nonce
+ALTER TABLE managed_txs ADD COLUMN IF NOT EXISTS broadcast_failures INT NOT NULL DEFAULT 0;
+COMMENT ON COLUMN managed_txs.broadcast_failures IS '广播被节点拒绝的次数，达到 tx_manager.max_broadcast_failures 后改发取消交易填补 nonce';
+
+-- 处理租约：监控任务与手动提速/取消互斥，避免同一 nonce 被同时替换
+ALTER TABLE managed_txs ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP;
+COMMENT ON COLUMN managed_txs.claimed_until IS '租约到期时间，监控任务领取或手动替换时写入，处理完成后清空';
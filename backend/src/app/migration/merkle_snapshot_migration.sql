@@ -0,0 +1,60 @@
+-- 空投默克尔树快照
+CREATE TABLE IF NOT EXISTS merkle_snapshots (
+    id               BIGSERIAL PRIMARY KEY,
+    airdrop_id       BIGINT NOT NULL,
+    chain_id         BIGINT NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    version          INT NOT NULL,
+    root             VARCHAR(66) NOT NULL,
+    leaf_count       INT NOT NULL,
+    total_amount     DECIMAL(78,0) NOT NULL DEFAULT 0,
+    tree             JSONB NOT NULL,
+    source           VARCHAR(16) NOT NULL,
+    rollback_of      INT,
+    created_by       VARCHAR(42) NOT NULL DEFAULT '',
+    status           VARCHAR(16) NOT NULL,
+    publish_mode     VARCHAR(8),
+    published_by     VARCHAR(42),
+    chain_version    BIGINT NOT NULL DEFAULT 0,
+    managed_tx_id    BIGINT,
+    safe_proposal_id BIGINT,
+    tx_hash          VARCHAR(66),
+    error            TEXT,
+    published_at     TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_merkle_snapshots_version UNIQUE (airdrop_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merkle_snapshots_status ON merkle_snapshots(status);
+
+COMMENT ON TABLE merkle_snapshots IS '空投默克尔树快照，叶子与根生成后不可修改';
+COMMENT ON COLUMN merkle_snapshots.version IS '同一空投内递增的快照版本';
+COMMENT ON COLUMN merkle_snapshots.tree IS 'OpenZeppelin StandardMerkleTree dump 格式的完整树';
+COMMENT ON COLUMN merkle_snapshots.source IS 'rebuild 按任务奖励重建，rollback 复制历史版本';
+COMMENT ON COLUMN merkle_snapshots.rollback_of IS '回滚时复制的快照版本';
+COMMENT ON COLUMN merkle_snapshots.status IS 'built 未发布，publishing 发布中，published 当前生效，superseded 已被替代，failed 发布失败';
+COMMENT ON COLUMN merkle_snapshots.publish_mode IS 'tx 托管交易发送，safe 生成 Safe 多签提案';
+COMMENT ON COLUMN merkle_snapshots.chain_version IS 'updateMerkleRoot 的 newVersion（初始版本 1 加快照版本；链上或已发布版本更高时在其之后递增）';
+
+-- 快照叶子
+CREATE TABLE IF NOT EXISTS merkle_snapshot_leaves (
+    snapshot_id    BIGINT NOT NULL,
+    wallet_address VARCHAR(42) NOT NULL,
+    amount         DECIMAL(78,0) NOT NULL,
+    leaf_hash      VARCHAR(66) NOT NULL,
+    proof          JSONB NOT NULL,
+    PRIMARY KEY (snapshot_id, wallet_address)
+);
+
+COMMENT ON TABLE merkle_snapshot_leaves IS '快照中每个地址的分配金额与证明，发布后写入 airdrop_whitelist';
+COMMENT ON COLUMN merkle_snapshot_leaves.amount IS '分配总额（wei）';
+
+-- 自动更新策略检查与审批
+ALTER TABLE merkle_snapshots ADD COLUMN IF NOT EXISTS policy_violations JSONB;
+ALTER TABLE merkle_snapshots ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(42);
+ALTER TABLE merkle_snapshots ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+COMMENT ON COLUMN merkle_snapshots.policy_violations IS '自动更新策略检查未通过的项：total_reward、reward_pool、claimed、max_changes';
+COMMENT ON COLUMN merkle_snapshots.reviewed_by IS '审批或拒绝待审批快照的管理员';
+COMMENT ON COLUMN merkle_snapshots.status IS 'built 未发布，pending_approval 待审批，publishing 发布中，published 当前生效，superseded 已被替代，rejected 审批拒绝，failed 发布失败';
@@ -0,0 +1,61 @@
+-- 流动性池事件补充区块内日志序号与区块时间（K线按区块时间聚合、按日志顺序确定开收盘）
+ALTER TABLE liquidity_pool_events ADD COLUMN IF NOT EXISTS log_index INTEGER DEFAULT 0;
+ALTER TABLE liquidity_pool_events ADD COLUMN IF NOT EXISTS block_time TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_liquidity_pool_events_pool_block_time ON liquidity_pool_events(chain_id, pool_address, block_time);
+
+COMMENT ON COLUMN liquidity_pool_events.log_index IS '日志在区块内的序号';
+COMMENT ON COLUMN liquidity_pool_events.block_time IS '区块时间';
+
+-- 池子K线表
+CREATE TABLE IF NOT EXISTS pool_candles (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    pool_address VARCHAR(42) NOT NULL,
+    resolution VARCHAR(8) NOT NULL,
+    bucket_start TIMESTAMP NOT NULL,
+    open DECIMAL(38,18) NOT NULL DEFAULT '0',
+    high DECIMAL(38,18) NOT NULL DEFAULT '0',
+    low DECIMAL(38,18) NOT NULL DEFAULT '0',
+    close DECIMAL(38,18) NOT NULL DEFAULT '0',
+    volume0 DECIMAL(38,18) NOT NULL DEFAULT '0',
+    volume1 DECIMAL(38,18) NOT NULL DEFAULT '0',
+    volume_usd DECIMAL(38,2) NOT NULL DEFAULT '0',
+    trade_count BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, pool_address, resolution, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pool_candles_query ON pool_candles(chain_id, pool_address, resolution, bucket_start DESC);
+
+COMMENT ON TABLE pool_candles IS '池子K线表（由Swap事件聚合）';
+COMMENT ON COLUMN pool_candles.resolution IS '周期：1m, 5m, 1h, 4h, 1d';
+COMMENT ON COLUMN pool_candles.bucket_start IS '周期开始时间（UTC对齐）';
+COMMENT ON COLUMN pool_candles.open IS '开盘价（token1/token0）';
+COMMENT ON COLUMN pool_candles.high IS '最高价';
+COMMENT ON COLUMN pool_candles.low IS '最低价';
+COMMENT ON COLUMN pool_candles.close IS '收盘价';
+COMMENT ON COLUMN pool_candles.volume0 IS 'token0交易量（已按精度换算）';
+COMMENT ON COLUMN pool_candles.volume1 IS 'token1交易量（已按精度换算）';
+COMMENT ON COLUMN pool_candles.volume_usd IS 'USD交易量';
+COMMENT ON COLUMN pool_candles.trade_count IS '成交笔数';
+
+-- K线游标：已计入K线的最后一笔Swap，重扫区块写入的重复事件不再累加
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS candle_block BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS candle_log_index INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS candle_rebuild_from TIMESTAMP;
+
+-- 已有K线的池子以已入库的最后一笔Swap作为游标
+UPDATE liquidity_pools p SET candle_block = e.block_number, candle_log_index = e.log_index
+FROM (
+    SELECT DISTINCT ON (chain_id, pool_address) chain_id, pool_address, block_number, log_index
+    FROM liquidity_pool_events
+    WHERE event_type = 'Swap'
+    ORDER BY chain_id, pool_address, block_number DESC, log_index DESC
+) e
+WHERE p.chain_id = e.chain_id AND p.pool_address = e.pool_address AND p.candle_block = 0;
+
+COMMENT ON COLUMN liquidity_pools.candle_block IS '已计入K线的最后一笔Swap的区块号';
+COMMENT ON COLUMN liquidity_pools.candle_log_index IS '已计入K线的最后一笔Swap的日志序号';
+COMMENT ON COLUMN liquidity_pools.candle_rebuild_from IS '待重建K线的起始时间（回补历史区块时由索引事务标记，提交后重建）';
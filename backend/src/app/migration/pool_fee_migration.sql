@@ -0,0 +1,19 @@
+-- 池子手续费配置：费率、协议分成与检测来源
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS protocol_fee_bps INT DEFAULT 0;
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS fee_source VARCHAR(16) DEFAULT 'default';
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS factory_address VARCHAR(42) DEFAULT '';
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS fee_to_address VARCHAR(42) DEFAULT '';
+
+COMMENT ON COLUMN liquidity_pools.protocol_fee_bps IS '手续费中归协议的部分（基点），V2 开启 feeTo 时为手续费的 1/6';
+COMMENT ON COLUMN liquidity_pools.fee_source IS '手续费来源：default/registry/factory';
+COMMENT ON COLUMN liquidity_pools.factory_address IS '池子工厂合约地址';
+COMMENT ON COLUMN liquidity_pools.fee_to_address IS '工厂 feeTo 地址，零地址表示协议费关闭';
+
+-- 小时快照拆分LP手续费与协议手续费；已有快照按协议费关闭处理
+ALTER TABLE pool_hour_snapshots ADD COLUMN IF NOT EXISTS lp_fees_usd DECIMAL(38,2) DEFAULT '0';
+ALTER TABLE pool_hour_snapshots ADD COLUMN IF NOT EXISTS protocol_fees_usd DECIMAL(38,2) DEFAULT '0';
+UPDATE pool_hour_snapshots SET lp_fees_usd = fees_usd WHERE lp_fees_usd = 0 AND protocol_fees_usd = 0;
+
+COMMENT ON COLUMN pool_hour_snapshots.lp_fees_usd IS '该小时归LP的USD手续费';
+COMMENT ON COLUMN pool_hour_snapshots.protocol_fees_usd IS '该小时归协议的USD手续费（按协议费率计提）';
+COMMENT ON COLUMN pool_hour_snapshots.apy IS '按近24小时LP手续费年化的APY（百分比）';
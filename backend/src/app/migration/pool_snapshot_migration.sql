@@ -0,0 +1,39 @@
+-- 池子小时快照表
+CREATE TABLE IF NOT EXISTS pool_hour_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    pool_address VARCHAR(42) NOT NULL,
+    hour_start TIMESTAMP NOT NULL,
+    reserve0 DECIMAL(78,0) DEFAULT '0',
+    reserve1 DECIMAL(78,0) DEFAULT '0',
+    total_supply DECIMAL(78,0) DEFAULT '0',
+    price DECIMAL(38,18) DEFAULT '0',
+    token0_usd DECIMAL(38,18) DEFAULT '0',
+    token1_usd DECIMAL(38,18) DEFAULT '0',
+    tvl_usd DECIMAL(38,2) DEFAULT '0',
+    volume_usd DECIMAL(38,2) DEFAULT '0',
+    fees_usd DECIMAL(38,2) DEFAULT '0',
+    apy DECIMAL(20,4) DEFAULT '0',
+    tx_count BIGINT DEFAULT 0,
+    block_number BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, pool_address, hour_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pool_hour_snapshots_hour ON pool_hour_snapshots(chain_id, hour_start);
+
+COMMENT ON TABLE pool_hour_snapshots IS '池子小时快照表';
+COMMENT ON COLUMN pool_hour_snapshots.hour_start IS '小时开始时间（UTC），储备量等为该小时结束时的状态';
+COMMENT ON COLUMN pool_hour_snapshots.reserve0 IS '代币0储备量';
+COMMENT ON COLUMN pool_hour_snapshots.reserve1 IS '代币1储备量';
+COMMENT ON COLUMN pool_hour_snapshots.total_supply IS 'LP代币总供应量';
+COMMENT ON COLUMN pool_hour_snapshots.price IS '池子价格（token1/token0）';
+COMMENT ON COLUMN pool_hour_snapshots.token0_usd IS '代币0的USD价格';
+COMMENT ON COLUMN pool_hour_snapshots.token1_usd IS '代币1的USD价格';
+COMMENT ON COLUMN pool_hour_snapshots.tvl_usd IS 'USD锁仓量';
+COMMENT ON COLUMN pool_hour_snapshots.volume_usd IS '该小时USD交易量';
+COMMENT ON COLUMN pool_hour_snapshots.fees_usd IS '该小时USD手续费';
+COMMENT ON COLUMN pool_hour_snapshots.apy IS '按近24小时手续费年化的APY（百分比）';
+COMMENT ON COLUMN pool_hour_snapshots.tx_count IS '该小时事件数';
+COMMENT ON COLUMN pool_hour_snapshots.block_number IS '该小时内最后一个事件所在区块';
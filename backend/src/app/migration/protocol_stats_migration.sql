@@ -0,0 +1,59 @@
+-- 协议每日汇总表
+CREATE TABLE IF NOT EXISTS protocol_daily_stats (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    tvl_usd DECIMAL(38,2) DEFAULT '0',
+    volume_usd DECIMAL(38,2) DEFAULT '0',
+    fees_usd DECIMAL(38,2) DEFAULT '0',
+    lp_fees_usd DECIMAL(38,2) DEFAULT '0',
+    protocol_fees_usd DECIMAL(38,2) DEFAULT '0',
+    swap_count BIGINT DEFAULT 0,
+    unique_traders BIGINT DEFAULT 0,
+    liquidity_providers BIGINT DEFAULT 0,
+    new_lps BIGINT DEFAULT 0,
+    stakers BIGINT DEFAULT 0,
+    new_stakers BIGINT DEFAULT 0,
+    stake_count BIGINT DEFAULT 0,
+    withdraw_count BIGINT DEFAULT 0,
+    airdrop_claims BIGINT DEFAULT 0,
+    airdrop_claimers BIGINT DEFAULT 0,
+    airdrop_claimed_amount DECIMAL(78,0) DEFAULT '0',
+    dau BIGINT DEFAULT 0,
+    wau BIGINT DEFAULT 0,
+    mau BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, day)
+);
+
+COMMENT ON TABLE protocol_daily_stats IS '协议每日汇总表（按链、UTC 自然日）';
+COMMENT ON COLUMN protocol_daily_stats.tvl_usd IS '当日结束时所有池子锁仓量（USD）';
+COMMENT ON COLUMN protocol_daily_stats.volume_usd IS '当日交易量（USD）';
+COMMENT ON COLUMN protocol_daily_stats.fees_usd IS '当日手续费（USD），含LP与协议部分';
+COMMENT ON COLUMN protocol_daily_stats.unique_traders IS '当日发起过兑换的地址数';
+COMMENT ON COLUMN protocol_daily_stats.liquidity_providers IS '当日增减过流动性的地址数';
+COMMENT ON COLUMN protocol_daily_stats.new_lps IS '当日首次提供流动性的地址数';
+COMMENT ON COLUMN protocol_daily_stats.stakers IS '当日结束时仍有质押余额的地址数';
+COMMENT ON COLUMN protocol_daily_stats.new_stakers IS '当日首次质押的地址数';
+COMMENT ON COLUMN protocol_daily_stats.airdrop_claimed_amount IS '当日空投领取总量（原始单位）';
+COMMENT ON COLUMN protocol_daily_stats.dau IS '当日活跃地址数（流动性、质押、空投任一事件）';
+COMMENT ON COLUMN protocol_daily_stats.wau IS '截至当日的近7天活跃地址数';
+COMMENT ON COLUMN protocol_daily_stats.mau IS '截至当日的近30天活跃地址数';
+
+-- 协议每日各代币质押量表
+CREATE TABLE IF NOT EXISTS protocol_daily_token_stakes (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    token_address VARCHAR(42) NOT NULL,
+    total_staked DECIMAL(78,0) DEFAULT '0',
+    stakers BIGINT DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, day, token_address)
+);
+
+COMMENT ON TABLE protocol_daily_token_stakes IS '协议每日各代币质押量表';
+COMMENT ON COLUMN protocol_daily_token_stakes.token_address IS '质押代币地址（小写）';
+COMMENT ON COLUMN protocol_daily_token_stakes.total_staked IS '当日结束时的质押总量';
+COMMENT ON COLUMN protocol_daily_token_stakes.stakers IS '当日结束时持有该代币质押的地址数';
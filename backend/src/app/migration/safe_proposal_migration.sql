@@ -0,0 +1,45 @@
+-- Safe 多签提案
+CREATE TABLE IF NOT EXISTS safe_proposals (
+    id            BIGSERIAL PRIMARY KEY,
+    chain_id      BIGINT NOT NULL,
+    safe_address  VARCHAR(42) NOT NULL,
+    title         VARCHAR(128) NOT NULL DEFAULT '',
+    description   TEXT NOT NULL DEFAULT '',
+    operator      VARCHAR(42) NOT NULL,
+    actions       JSONB NOT NULL DEFAULT '[]',
+    to_address    VARCHAR(42) NOT NULL,
+    value         DECIMAL(78,0) NOT NULL DEFAULT 0,
+    data          TEXT NOT NULL,
+    operation     SMALLINT NOT NULL DEFAULT 0,
+    safe_nonce    BIGINT NOT NULL,
+    safe_tx_hash  VARCHAR(66) NOT NULL,
+    builder_json  JSONB NOT NULL,
+    created_block BIGINT NOT NULL DEFAULT 0,
+    scanned_block BIGINT NOT NULL DEFAULT 0,
+    status        VARCHAR(16) NOT NULL,
+    exec_tx_hash  VARCHAR(66),
+    exec_block    BIGINT NOT NULL DEFAULT 0,
+    result        TEXT,
+    executed_at   TIMESTAMP,
+    indexed_at    TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_safe_proposals_hash UNIQUE (chain_id, safe_tx_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_safe_proposals_status ON safe_proposals(status);
+CREATE INDEX IF NOT EXISTS idx_safe_proposals_safe ON safe_proposals(chain_id, safe_address, safe_nonce);
+
+COMMENT ON TABLE safe_proposals IS 'Safe 多签提案：owner-only 合约调用打包为 SafeTx，由 Safe owner 签名执行';
+COMMENT ON COLUMN safe_proposals.actions IS '每个调用的操作类型、目标合约、调用数据与规范化参数';
+COMMENT ON COLUMN safe_proposals.to_address IS 'SafeTx.to，多个调用时为 MultiSendCallOnly';
+COMMENT ON COLUMN safe_proposals.operation IS '0 CALL，1 DELEGATECALL（MultiSendCallOnly 批量）';
+COMMENT ON COLUMN safe_proposals.safe_tx_hash IS 'EIP-712 SafeTx 哈希，owner 对其签名';
+COMMENT ON COLUMN safe_proposals.builder_json IS 'Safe Transaction Builder 导入文件';
+COMMENT ON COLUMN safe_proposals.scanned_block IS '已查找 ExecutionSuccess/ExecutionFailure 事件的区块高度';
+COMMENT ON COLUMN safe_proposals.status IS 'proposed 待签名执行，executed 已执行待索引，indexed 索引已确认，failed 执行失败，replaced nonce 被其他交易占用';
+COMMENT ON COLUMN safe_proposals.result IS '索引对账结果';
+
+-- 实际执行的 SafeTx 哈希：经 Transaction Builder 导入执行时，Safe 界面的打包方式与 nonce 可能与提案不同
+ALTER TABLE safe_proposals ADD COLUMN IF NOT EXISTS exec_safe_tx_hash VARCHAR(66);
+COMMENT ON COLUMN safe_proposals.exec_safe_tx_hash IS '实际执行的 SafeTx 哈希，按调用内容匹配时可能与 safe_tx_hash 不同';
@@ -0,0 +1,26 @@
+-- 质押池日份额价格维护记录
+CREATE TABLE IF NOT EXISTS share_price_updates (
+    id               BIGSERIAL PRIMARY KEY,
+    chain_id         BIGINT NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    pool_id          BIGINT NOT NULL,
+    day              BIGINT NOT NULL,
+    source           VARCHAR(16) NOT NULL,
+    price            DECIMAL(78,0) NOT NULL DEFAULT 0,
+    previous_price   DECIMAL(78,0) NOT NULL DEFAULT 0,
+    deviation_pct    DECIMAL(20,6) NOT NULL DEFAULT 0,
+    alerted          BOOLEAN NOT NULL DEFAULT FALSE,
+    status           VARCHAR(16) NOT NULL,
+    tx_hash          VARCHAR(66),
+    error            TEXT,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_price_updates_pool ON share_price_updates(chain_id, pool_id, day DESC);
+
+COMMENT ON TABLE share_price_updates IS '质押池日份额价格维护记录';
+COMMENT ON COLUMN share_price_updates.day IS 'UTC 自然日序号（unix 时间戳 / 86400），即合约 dailySharePrices 的日期键';
+COMMENT ON COLUMN share_price_updates.price IS '本次份额价格（1e18 精度）';
+COMMENT ON COLUMN share_price_updates.previous_price IS '上一次链上份额价格';
+COMMENT ON COLUMN share_price_updates.deviation_pct IS '相对上一次价格的偏离百分比';
+COMMENT ON COLUMN share_price_updates.status IS 'confirmed 已上链，skipped 价格未变化，held 偏离超限暂停提交，failed 失败';
@@ -0,0 +1,22 @@
+-- 质押池：索引质押合约 PoolCreated 事件
+CREATE TABLE IF NOT EXISTS stake_pools (
+    id               BIGSERIAL PRIMARY KEY,
+    chain_id         BIGINT NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    pool_id          BIGINT NOT NULL,
+    token_address    VARCHAR(42) NOT NULL,
+    lock_duration    BIGINT NOT NULL DEFAULT 0,
+    name             VARCHAR(128),
+    block_number     BIGINT NOT NULL,
+    tx_hash          VARCHAR(66) NOT NULL,
+    block_time       TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_stake_pools_pool UNIQUE (chain_id, contract_address, pool_id)
+);
+
+COMMENT ON TABLE stake_pools IS '质押池（PoolCreated 事件）';
+COMMENT ON COLUMN stake_pools.contract_address IS '质押合约地址（小写），来自 chain 表 service_type = staking';
+COMMENT ON COLUMN stake_pools.pool_id IS '合约内的池子ID';
+COMMENT ON COLUMN stake_pools.token_address IS '质押代币地址（小写），零地址表示原生币';
+COMMENT ON COLUMN stake_pools.lock_duration IS '锁定时长（秒）';
+COMMENT ON COLUMN stake_pools.block_time IS '池子创建所在区块时间';
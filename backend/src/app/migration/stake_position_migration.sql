@@ -0,0 +1,63 @@
+-- 质押操作记录：完整精度数量与收益
+ALTER TABLE user_operation_record ADD COLUMN IF NOT EXISTS amount_raw DECIMAL(78,0);
+ALTER TABLE user_operation_record ADD COLUMN IF NOT EXISTS reward DECIMAL(78,0) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN user_operation_record.amount_raw IS '原始单位完整数量，为空时取 amount';
+COMMENT ON COLUMN user_operation_record.reward IS '提取或领取收益时发放的收益（原始单位）';
+
+-- 质押持仓投影：按 (用户, 质押池) 累计
+CREATE TABLE IF NOT EXISTS stake_positions (
+    id                BIGSERIAL PRIMARY KEY,
+    chain_id          BIGINT NOT NULL,
+    contract_address  VARCHAR(42) NOT NULL,
+    user_address      VARCHAR(42) NOT NULL,
+    pool_id           BIGINT NOT NULL,
+    token_address     VARCHAR(42),
+    principal         DECIMAL(78,0) NOT NULL DEFAULT 0,
+    total_staked      DECIMAL(78,0) NOT NULL DEFAULT 0,
+    total_withdrawn   DECIMAL(78,0) NOT NULL DEFAULT 0,
+    claimed_rewards   DECIMAL(78,0) NOT NULL DEFAULT 0,
+    stake_count       INTEGER NOT NULL DEFAULT 0,
+    first_staked_at   TIMESTAMP,
+    last_activity_at  TIMESTAMP,
+    last_block_number BIGINT NOT NULL DEFAULT 0,
+    updated_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_stake_positions_user_pool UNIQUE (chain_id, contract_address, user_address, pool_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stake_positions_user ON stake_positions(user_address, chain_id);
+
+COMMENT ON TABLE stake_positions IS '质押持仓（由 Staked/Withdrawn/ClaimRewards 事件累计）';
+COMMENT ON COLUMN stake_positions.principal IS '当前本金（原始单位）';
+COMMENT ON COLUMN stake_positions.total_staked IS '累计质押（原始单位）';
+COMMENT ON COLUMN stake_positions.total_withdrawn IS '累计提取（原始单位）';
+COMMENT ON COLUMN stake_positions.claimed_rewards IS '累计领取收益（原始单位）';
+
+-- 质押批次：每笔 Staked 一条，提取按解锁时间先后扣减剩余本金
+CREATE TABLE IF NOT EXISTS stake_position_lots (
+    id               BIGSERIAL PRIMARY KEY,
+    position_id      BIGINT NOT NULL,
+    record_id        BIGINT NOT NULL,
+    chain_id         BIGINT NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    user_address     VARCHAR(42) NOT NULL,
+    pool_id          BIGINT NOT NULL,
+    token_address    VARCHAR(42),
+    amount           DECIMAL(78,0) NOT NULL,
+    remaining        DECIMAL(78,0) NOT NULL,
+    staked_at        TIMESTAMP,
+    unlock_time      TIMESTAMP,
+    withdrawn_at     TIMESTAMP,
+    tx_hash          VARCHAR(66),
+    block_number     BIGINT,
+    log_index        INTEGER,
+    CONSTRAINT uk_stake_position_lots_record UNIQUE (record_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stake_position_lots_position ON stake_position_lots(position_id, unlock_time);
+CREATE INDEX IF NOT EXISTS idx_stake_position_lots_user ON stake_position_lots(user_address, chain_id, staked_at DESC);
+
+COMMENT ON TABLE stake_position_lots IS '质押批次';
+COMMENT ON COLUMN stake_position_lots.record_id IS 'user_operation_record 中对应的 Staked 记录ID';
+COMMENT ON COLUMN stake_position_lots.remaining IS '剩余本金（原始单位），为 0 表示已全部提取';
+COMMENT ON COLUMN stake_position_lots.withdrawn_at IS '剩余本金归零的时间';
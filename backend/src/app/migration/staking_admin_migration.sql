@@ -0,0 +1,52 @@
+-- 质押合约管理操作审计
+CREATE TABLE IF NOT EXISTS staking_admin_actions (
+    id               BIGSERIAL PRIMARY KEY,
+    chain_id         BIGINT NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    action           VARCHAR(32) NOT NULL,
+    params           JSONB NOT NULL DEFAULT '{}',
+    operator         VARCHAR(42) NOT NULL,
+    sender           VARCHAR(42),
+    dry_run          BOOLEAN NOT NULL DEFAULT FALSE,
+    call_data        TEXT,
+    status           VARCHAR(20) NOT NULL,
+    tx_hash          VARCHAR(66),
+    error            TEXT,
+    result           TEXT,
+    reconciled_at    TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_staking_admin_actions_status ON staking_admin_actions(status);
+CREATE INDEX IF NOT EXISTS idx_staking_admin_actions_created ON staking_admin_actions(chain_id, created_at DESC);
+
+COMMENT ON TABLE staking_admin_actions IS '质押合约管理操作审计';
+COMMENT ON COLUMN staking_admin_actions.action IS 'add_pool, set_pool_active, pause, unpause, grant_role, revoke_role';
+COMMENT ON COLUMN staking_admin_actions.operator IS '发起操作的登录钱包（小写）';
+COMMENT ON COLUMN staking_admin_actions.sender IS '发送交易的服务端账户';
+COMMENT ON COLUMN staking_admin_actions.dry_run IS '仅通过 eth_call 模拟，不发送交易';
+COMMENT ON COLUMN staking_admin_actions.status IS 'simulated, simulation_failed, submitting, confirmed, reconciled, reverted, failed';
+COMMENT ON COLUMN staking_admin_actions.result IS '对账结果，如新建池子ID';
+
+-- 质押合约管理事件
+CREATE TABLE IF NOT EXISTS staking_admin_events (
+    id               BIGSERIAL PRIMARY KEY,
+    chain_id         BIGINT NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    event_type       VARCHAR(20) NOT NULL,
+    role             VARCHAR(66),
+    account          VARCHAR(42),
+    sender           VARCHAR(42),
+    block_number     BIGINT NOT NULL,
+    log_index        INTEGER NOT NULL,
+    tx_hash          VARCHAR(66) NOT NULL,
+    block_time       TIMESTAMP,
+    CONSTRAINT uk_staking_admin_events_log UNIQUE (chain_id, tx_hash, log_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_staking_admin_events_tx ON staking_admin_events(tx_hash);
+
+COMMENT ON TABLE staking_admin_events IS '质押合约管理事件（Paused、Unpaused、RoleGranted、RoleRevoked）';
+COMMENT ON COLUMN staking_admin_events.role IS '角色哈希';
+COMMENT ON COLUMN staking_admin_events.account IS '被授权/撤销的账户，或执行暂停的账户';
@@ -0,0 +1,8 @@
+-- 池子交易手续费（基点），询价按池子手续费计算
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS fee_bps INT DEFAULT 30;
+
+COMMENT ON COLUMN liquidity_pools.fee_bps IS '交易手续费（基点），V2 默认 30 即 0.3%';
+
+-- 路由合约地址配置在 chain 表中（service_type = 'router'），用于生成调用数据与链上校验报价
+-- INSERT INTO chain (chain_id, chain_name, address, service_type, last_block_num)
+-- VALUES (11155111, 'sepolia-router', '0x路由合约地址', 'router', 0);
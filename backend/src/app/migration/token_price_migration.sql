@@ -0,0 +1,26 @@
+-- 代币USD历史价格表
+CREATE TABLE IF NOT EXISTS token_prices (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    token_address VARCHAR(42) NOT NULL,
+    symbol VARCHAR(20),
+    price_usd DECIMAL(38,18) NOT NULL DEFAULT '0',
+    source VARCHAR(20) NOT NULL,
+    route TEXT,
+    liquidity_usd DECIMAL(38,2) DEFAULT '0',
+    snapshot_time TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, token_address, snapshot_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_prices_token_time ON token_prices(chain_id, token_address, snapshot_time DESC);
+
+COMMENT ON TABLE token_prices IS '代币USD历史价格表';
+COMMENT ON COLUMN token_prices.chain_id IS '链ID';
+COMMENT ON COLUMN token_prices.token_address IS '代币地址（小写）';
+COMMENT ON COLUMN token_prices.symbol IS '代币符号';
+COMMENT ON COLUMN token_prices.price_usd IS 'USD价格';
+COMMENT ON COLUMN token_prices.source IS '价格来源：stable 稳定币, route 池子路由, manual 手动价格源';
+COMMENT ON COLUMN token_prices.route IS '定价路由经过的池子地址，逗号分隔';
+COMMENT ON COLUMN token_prices.liquidity_usd IS '定价路由的瓶颈流动性（USD）';
+COMMENT ON COLUMN token_prices.snapshot_time IS '快照时间';
@@ -0,0 +1,69 @@
+-- 池子类型与 V3 池子状态
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS pool_type VARCHAR(8) DEFAULT 'v2';
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS sqrt_price_x96 DECIMAL(78,0) DEFAULT '0';
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS tick INT DEFAULT 0;
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS liquidity DECIMAL(78,0) DEFAULT '0';
+ALTER TABLE liquidity_pools ADD COLUMN IF NOT EXISTS tick_spacing INT DEFAULT 0;
+UPDATE liquidity_pools SET pool_type = 'v2' WHERE pool_type IS NULL;
+
+COMMENT ON COLUMN liquidity_pools.pool_type IS '池子类型：v2/v3';
+COMMENT ON COLUMN liquidity_pools.reserve0 IS '池子持有的代币0余额（V2 为储备量）';
+COMMENT ON COLUMN liquidity_pools.reserve1 IS '池子持有的代币1余额（V2 为储备量）';
+COMMENT ON COLUMN liquidity_pools.sqrt_price_x96 IS 'V3 当前价格 sqrtPriceX96（slot0）';
+COMMENT ON COLUMN liquidity_pools.tick IS 'V3 当前tick';
+COMMENT ON COLUMN liquidity_pools.liquidity IS 'V3 当前区间内的活跃流动性';
+COMMENT ON COLUMN liquidity_pools.tick_spacing IS 'V3 tick 间距';
+
+-- V3 事件字段：Swap 记录成交后的价格、tick 与活跃流动性；Mint/Burn/Collect 记录仓位区间
+ALTER TABLE liquidity_pool_events ADD COLUMN IF NOT EXISTS sqrt_price_x96 DECIMAL(78,0) DEFAULT '0';
+ALTER TABLE liquidity_pool_events ADD COLUMN IF NOT EXISTS tick INT DEFAULT 0;
+ALTER TABLE liquidity_pool_events ADD COLUMN IF NOT EXISTS tick_lower INT DEFAULT 0;
+ALTER TABLE liquidity_pool_events ADD COLUMN IF NOT EXISTS tick_upper INT DEFAULT 0;
+
+COMMENT ON COLUMN liquidity_pool_events.event_type IS '事件类型：Swap/AddLiquidity/RemoveLiquidity/Collect（V3）';
+COMMENT ON COLUMN liquidity_pool_events.liquidity IS 'V3 Swap 为成交后的活跃流动性，Mint/Burn 为仓位流动性变动';
+COMMENT ON COLUMN liquidity_pool_events.sqrt_price_x96 IS 'V3 Swap 成交后的 sqrtPriceX96';
+COMMENT ON COLUMN liquidity_pool_events.tick IS 'V3 Swap 成交后的tick';
+COMMENT ON COLUMN liquidity_pool_events.tick_lower IS 'V3 仓位区间下界';
+COMMENT ON COLUMN liquidity_pool_events.tick_upper IS 'V3 仓位区间上界';
+
+-- V3 区间仓位表
+CREATE TABLE IF NOT EXISTS v3_positions (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    pool_address VARCHAR(42) NOT NULL,
+    owner_address VARCHAR(42) NOT NULL,
+    tick_lower INT NOT NULL,
+    tick_upper INT NOT NULL,
+    liquidity DECIMAL(78,0) DEFAULT '0',
+    cost_token0 DECIMAL(78,0) DEFAULT '0',
+    cost_token1 DECIMAL(78,0) DEFAULT '0',
+    cost_basis_usd DECIMAL(38,2) DEFAULT '0',
+    deposited0 DECIMAL(78,0) DEFAULT '0',
+    deposited1 DECIMAL(78,0) DEFAULT '0',
+    withdrawn0 DECIMAL(78,0) DEFAULT '0',
+    withdrawn1 DECIMAL(78,0) DEFAULT '0',
+    collected0 DECIMAL(78,0) DEFAULT '0',
+    collected1 DECIMAL(78,0) DEFAULT '0',
+    last_block_num BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chain_id, pool_address, owner_address, tick_lower, tick_upper)
+);
+
+CREATE INDEX IF NOT EXISTS idx_v3_positions_owner ON v3_positions(owner_address);
+
+COMMENT ON TABLE v3_positions IS 'V3 区间仓位表（由 Mint/Burn/Collect 事件累计，按交易发起地址归属）';
+COMMENT ON COLUMN v3_positions.owner_address IS '交易发起地址（小写）';
+COMMENT ON COLUMN v3_positions.liquidity IS '仓位当前流动性';
+COMMENT ON COLUMN v3_positions.cost_token0 IS '剩余流动性对应的存入代币0数量';
+COMMENT ON COLUMN v3_positions.cost_token1 IS '剩余流动性对应的存入代币1数量';
+COMMENT ON COLUMN v3_positions.cost_basis_usd IS '剩余流动性的USD成本（按存入时价格）';
+COMMENT ON COLUMN v3_positions.withdrawn0 IS '累计移除的本金代币0数量（Burn）';
+COMMENT ON COLUMN v3_positions.withdrawn1 IS '累计移除的本金代币1数量（Burn）';
+COMMENT ON COLUMN v3_positions.collected0 IS '累计提取代币0数量（Collect，含本金与手续费）';
+COMMENT ON COLUMN v3_positions.collected1 IS '累计提取代币1数量（Collect，含本金与手续费）';
+
+-- V3 工厂配置在 chain 表中（service_type = 'v3_factory'），索引器会同时监听工厂创建的全部池子
+-- INSERT INTO chain (chain_id, chain_name, address, service_type, last_block_num)
+-- VALUES (11155111, 'sepolia-v3-factory', '0xV3工厂合约地址', 'v3_factory', 0);
@@ -0,0 +1,69 @@
+-- Webhook 订阅、投递任务与投递记录
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id            BIGSERIAL PRIMARY KEY,
+    owner_address VARCHAR(42) NOT NULL,
+    url           TEXT NOT NULL,
+    secret        VARCHAR(128) NOT NULL,
+    description   TEXT,
+    chain_id      BIGINT NOT NULL DEFAULT 0,
+    event_types   TEXT NOT NULL DEFAULT '',
+    pools         TEXT NOT NULL DEFAULT '',
+    wallets       TEXT NOT NULL DEFAULT '',
+    is_active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_owner ON webhook_subscriptions(owner_address);
+
+COMMENT ON TABLE webhook_subscriptions IS 'Webhook 订阅';
+COMMENT ON COLUMN webhook_subscriptions.owner_address IS '创建者钱包地址（小写）';
+COMMENT ON COLUMN webhook_subscriptions.secret IS 'HMAC-SHA256 签名密钥';
+COMMENT ON COLUMN webhook_subscriptions.chain_id IS '链ID，0 表示全部链';
+COMMENT ON COLUMN webhook_subscriptions.event_types IS '订阅的事件类型，逗号分隔，为空表示全部';
+COMMENT ON COLUMN webhook_subscriptions.pools IS '订阅的池子地址，逗号分隔，为空表示不过滤';
+COMMENT ON COLUMN webhook_subscriptions.wallets IS '订阅的钱包地址，逗号分隔，为空表示不过滤';
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    subscription_id  BIGINT NOT NULL,
+    event_id         VARCHAR(160) NOT NULL,
+    event_type       VARCHAR(20) NOT NULL,
+    payload          JSONB NOT NULL,
+    status           VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error       TEXT,
+    delivered_at     TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_webhook_deliveries_event ON webhook_deliveries(subscription_id, event_id);
+-- 投递任务只扫描待投递的记录
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+COMMENT ON TABLE webhook_deliveries IS 'Webhook 投递任务，status=dead 的记录即死信队列';
+COMMENT ON COLUMN webhook_deliveries.event_id IS '事件唯一标识：事件类型:链ID:交易哈希:日志序号';
+COMMENT ON COLUMN webhook_deliveries.payload IS '请求体';
+COMMENT ON COLUMN webhook_deliveries.status IS '投递状态：pending/succeeded/dead';
+COMMENT ON COLUMN webhook_deliveries.attempts IS '已投递次数';
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS '下次投递时间';
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id            BIGSERIAL PRIMARY KEY,
+    delivery_id   BIGINT NOT NULL,
+    attempt       INTEGER NOT NULL,
+    status_code   INTEGER NOT NULL DEFAULT 0,
+    error         TEXT,
+    response_body TEXT,
+    duration_ms   BIGINT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id);
+
+COMMENT ON TABLE webhook_delivery_attempts IS 'Webhook 每次投递请求的记录';
+COMMENT ON COLUMN webhook_delivery_attempts.status_code IS 'HTTP 状态码，0 表示请求未得到响应';
+COMMENT ON COLUMN webhook_delivery_attempts.response_body IS '截断后的响应内容';
@@ -5,7 +5,7 @@ type Chain struct {
 	ChainId      int64  `json:"chainId" gorm:"column:chain_id"`
 	ChainName    string `json:"chainName" gorm:"column:chain_name"`
 	Address      string `json:"address" gorm:"column:address"`          // 质押池合约地址
-	ServiceType  string `json:"serviceType" gorm:"column:service_type"` // 服务类型: staking/liquidity
+	ServiceType  string `json:"serviceType" gorm:"column:service_type"` // 服务类型: staking/liquidity/router/v3_factory
 	LastBlockNum uint64 `json:"lastBlockNum" gorm:"column:last_block_num"`
 }
 
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// 事件总线消息的 schema 名与版本；字段有不兼容变更时递增版本，消费方按 version 分支解析
+const (
+	BusEventSchema        = "alanswap.event"
+	BusEventSchemaVersion = 1
+)
+
+// EventOutbox 事件总线 outbox：与索引事件在同一事务内写入，由中继任务发布到 Redis Streams
+type EventOutbox struct {
+	Id            int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	EventId       string     `json:"eventId" gorm:"column:event_id;uniqueIndex"` // <eventType>:<chainId>:<txHash>:<logIndex>，重复索引同一日志时去重
+	EventType     string     `json:"eventType" gorm:"column:event_type"`
+	ChainId       int64      `json:"chainId" gorm:"column:chain_id"`
+	SchemaVersion int        `json:"schemaVersion" gorm:"column:schema_version"`
+	Payload       string     `json:"payload" gorm:"column:payload;type:jsonb"` // 序列化后的 BusEvent
+	CreatedAt     time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	PublishedAt   *time.Time `json:"publishedAt" gorm:"column:published_at"` // 为空表示尚未发布
+	StreamId      string     `json:"streamId" gorm:"column:stream_id"`       // 发布后的 Redis Stream 消息ID
+	Attempts      int        `json:"attempts" gorm:"column:attempts"`
+	LastError     string     `json:"lastError" gorm:"column:last_error"`
+}
+
+// TableName 指定表名
+func (EventOutbox) TableName() string {
+	return "event_outbox"
+}
+
+// BusEvent 发布到 Redis Streams 的事件消息
+type BusEvent struct {
+	Schema     string        `json:"schema"`  // 固定为 alanswap.event
+	Version    int           `json:"version"` // schema 版本
+	Id         string        `json:"id"`      // 与 outbox 的 event_id 一致，消费方可据此幂等
+	Type       string        `json:"type"`    // stake, withdraw, swap, mint, burn, collect, claim
+	ChainId    int64         `json:"chainId"`
+	OccurredAt time.Time     `json:"occurredAt"` // 事件所在区块时间
+	Data       ExplorerEvent `json:"data"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// 事件浏览的统一事件类型
+const (
+	ExplorerEventStake    = "stake"
+	ExplorerEventWithdraw = "withdraw"
+	ExplorerEventSwap     = "swap"
+	ExplorerEventMint     = "mint"
+	ExplorerEventBurn     = "burn"
+	ExplorerEventCollect  = "collect"
+	ExplorerEventClaim    = "claim"
+)
+
+// ExplorerEvent 跨事件表的统一事件记录；金额均为原始单位，表中没有的字段为空
+type ExplorerEvent struct {
+	Source          string    `json:"source"` // liquidity, stake, airdrop
+	EventType       string    `json:"eventType"`
+	ChainId         int64     `json:"chainId"`
+	BlockNumber     int64     `json:"blockNumber"`
+	LogIndex        int       `json:"logIndex"`
+	TxHash          string    `json:"txHash"`
+	ContractAddress string    `json:"contractAddress"` // 池子、质押或空投合约
+	UserAddress     string    `json:"userAddress"`
+	EventTime       time.Time `json:"eventTime"`
+	TokenAddress    string    `json:"tokenAddress,omitempty"` // 质押代币
+	Amount          string    `json:"amount,omitempty"`       // 质押、提取或领取数量
+	PoolId          *int64    `json:"poolId,omitempty"`       // 质押池ID
+	AirdropId       string    `json:"airdropId,omitempty"`
+	Token0Address   string    `json:"token0Address,omitempty"`
+	Token1Address   string    `json:"token1Address,omitempty"`
+	Amount0In       string    `json:"amount0In,omitempty"`
+	Amount1In       string    `json:"amount1In,omitempty"`
+	Amount0Out      string    `json:"amount0Out,omitempty"`
+	Amount1Out      string    `json:"amount1Out,omitempty"`
+
+	SourceRank int   `json:"-"`
+	RowId      int64 `json:"-"`
+}
+
+// ExplorerEventPage 游标分页结果，NextCursor 为空表示没有更多数据
+type ExplorerEventPage struct {
+	List       []ExplorerEvent `json:"list"`
+	NextCursor string          `json:"nextCursor"`
+	HasMore    bool            `json:"hasMore"`
+}
@@ -10,7 +10,7 @@ type LiquidityPoolEvent struct {
 	ChainId       int64     `json:"chainId" gorm:"column:chain_id;not null"`
 	TxHash        string    `json:"txHash" gorm:"column:tx_hash;not null;index"`
 	BlockNumber   int64     `json:"blockNumber" gorm:"column:block_number;not null"`
-	EventType     string    `json:"eventType" gorm:"column:event_type;not null"` // Swap, AddLiquidity, RemoveLiquidity
+	EventType     string    `json:"eventType" gorm:"column:event_type;not null"` // Swap, AddLiquidity, RemoveLiquidity, Collect（V3）
 	PoolAddress   string    `json:"poolAddress" gorm:"column:pool_address;not null;index"`
 	Token0Address string    `json:"token0Address" gorm:"column:token0_address"`
 	Token1Address string    `json:"token1Address" gorm:"column:token1_address"`
@@ -22,8 +22,15 @@ type LiquidityPoolEvent struct {
 	Amount1Out    string    `json:"amount1Out" gorm:"column:amount1_out;type:decimal(78,0)"`
 	Reserve0      string    `json:"reserve0" gorm:"column:reserve0;type:decimal(78,0)"` // 池子储备量
 	Reserve1      string    `json:"reserve1" gorm:"column:reserve1;type:decimal(78,0)"`
-	Price         string    `json:"price" gorm:"column:price;type:decimal(30,18)"`        // 价格
-	Liquidity     string    `json:"liquidity" gorm:"column:liquidity;type:decimal(78,0)"` // 流动性
+	Price         string    `json:"price" gorm:"column:price;type:decimal(30,18)"`                          // 价格
+	Liquidity     string    `json:"liquidity" gorm:"column:liquidity;type:decimal(78,0)"`                   // 流动性
+	LogIndex      int       `json:"logIndex" gorm:"column:log_index"`                                       // 日志在区块内的序号
+	BlockTime     time.Time `json:"blockTime" gorm:"column:block_time"`                                     // 区块时间
+	SqrtPriceX96  string    `json:"sqrtPriceX96,omitempty" gorm:"column:sqrt_price_x96;type:decimal(78,0)"` // V3 Swap 后的价格
+	Tick          int       `json:"tick,omitempty" gorm:"column:tick"`                                      // V3 Swap 后的当前tick
+	TickLower     int       `json:"tickLower,omitempty" gorm:"column:tick_lower"`                           // V3 仓位区间下界
+	TickUpper     int       `json:"tickUpper,omitempty" gorm:"column:tick_upper"`                           // V3 仓位区间上界
+	PoolType      string    `json:"-" gorm:"-"`                                                             // 解析时识别的池子类型，不入库
 	CreatedAt     time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt     time.Time `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
 }
@@ -35,25 +42,38 @@ func (LiquidityPoolEvent) TableName() string {
 
 // LiquidityPool 流动性池信息
 type LiquidityPool struct {
-	Id             int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	ChainId        int64     `json:"chainId" gorm:"column:chain_id;not null"`
-	PoolAddress    string    `json:"poolAddress" gorm:"column:pool_address;not null;uniqueIndex:idx_chain_pool"`
-	Token0Address  string    `json:"token0Address" gorm:"column:token0_address"`
-	Token1Address  string    `json:"token1Address" gorm:"column:token1_address"`
-	Token0Symbol   string    `json:"token0Symbol" gorm:"column:token0_symbol"`
-	Token1Symbol   string    `json:"token1Symbol" gorm:"column:token1_symbol"`
-	Token0Decimals int       `json:"token0Decimals" gorm:"column:token0_decimals"`
-	Token1Decimals int       `json:"token1Decimals" gorm:"column:token1_decimals"`
-	Reserve0       string    `json:"reserve0" gorm:"column:reserve0;type:decimal(78,0)"`
-	Reserve1       string    `json:"reserve1" gorm:"column:reserve1;type:decimal(78,0)"`
-	TotalSupply    string    `json:"totalSupply" gorm:"column:total_supply;type:decimal(78,0)"`
-	Price          string    `json:"price" gorm:"column:price;type:decimal(30,18)"`
-	Volume24h      string    `json:"volume24h" gorm:"column:volume_24h;type:decimal(78,0)"`
-	TxCount        int64     `json:"txCount" gorm:"column:tx_count"`
-	LastBlockNum   int64     `json:"lastBlockNum" gorm:"column:last_block_num"`
-	IsActive       bool      `json:"isActive" gorm:"column:is_active;default:true"`
-	CreatedAt      time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt      time.Time `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+	Id                int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId           int64      `json:"chainId" gorm:"column:chain_id;not null"`
+	PoolAddress       string     `json:"poolAddress" gorm:"column:pool_address;not null;uniqueIndex:idx_chain_pool"`
+	Token0Address     string     `json:"token0Address" gorm:"column:token0_address"`
+	Token1Address     string     `json:"token1Address" gorm:"column:token1_address"`
+	Token0Symbol      string     `json:"token0Symbol" gorm:"column:token0_symbol"`
+	Token1Symbol      string     `json:"token1Symbol" gorm:"column:token1_symbol"`
+	Token0Decimals    int        `json:"token0Decimals" gorm:"column:token0_decimals"`
+	Token1Decimals    int        `json:"token1Decimals" gorm:"column:token1_decimals"`
+	Reserve0          string     `json:"reserve0" gorm:"column:reserve0;type:decimal(78,0)"`
+	Reserve1          string     `json:"reserve1" gorm:"column:reserve1;type:decimal(78,0)"`
+	TotalSupply       string     `json:"totalSupply" gorm:"column:total_supply;type:decimal(78,0)"`
+	Price             string     `json:"price" gorm:"column:price;type:decimal(30,18)"`
+	Volume24h         string     `json:"volume24h" gorm:"column:volume_24h;type:decimal(78,0)"`
+	PoolType          string     `json:"poolType" gorm:"column:pool_type;default:v2"`                  // v2 / v3
+	SqrtPriceX96      string     `json:"sqrtPriceX96" gorm:"column:sqrt_price_x96;type:decimal(78,0)"` // V3 当前价格（slot0）
+	Tick              int        `json:"tick" gorm:"column:tick"`                                      // V3 当前tick
+	Liquidity         string     `json:"liquidity" gorm:"column:liquidity;type:decimal(78,0)"`         // V3 当前区间内的活跃流动性
+	TickSpacing       int        `json:"tickSpacing" gorm:"column:tick_spacing"`                       // V3 tick 间距
+	FeeBps            int        `json:"feeBps" gorm:"column:fee_bps;default:30"`                      // 交易手续费（基点），V2 默认 30 即 0.3%；V3 取池子 fee/100
+	ProtocolFeeBps    int        `json:"protocolFeeBps" gorm:"column:protocol_fee_bps;default:0"`      // 手续费中归协议的部分（基点）
+	FeeSource         string     `json:"feeSource" gorm:"column:fee_source"`                           // 手续费来源：default/registry/factory
+	FactoryAddress    string     `json:"factoryAddress" gorm:"column:factory_address"`
+	FeeToAddress      string     `json:"feeToAddress" gorm:"column:fee_to_address"` // 工厂 feeTo，零地址表示协议费关闭
+	TxCount           int64      `json:"txCount" gorm:"column:tx_count"`
+	LastBlockNum      int64      `json:"lastBlockNum" gorm:"column:last_block_num"`
+	CandleBlock       int64      `json:"-" gorm:"column:candle_block"`        // 已计入K线的最后一笔Swap的区块号
+	CandleLogIndex    int        `json:"-" gorm:"column:candle_log_index"`    // 已计入K线的最后一笔Swap的日志序号
+	CandleRebuildFrom *time.Time `json:"-" gorm:"column:candle_rebuild_from"` // 待重建K线的起始时间，索引事务提交后重建
+	IsActive          bool       `json:"isActive" gorm:"column:is_active;default:true"`
+	CreatedAt         time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt         time.Time  `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
 }
 
 // TableName 指定表名
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LpTransferEvent LP代币转账事件（from 为零地址表示铸造，to 为零地址表示销毁）
+type LpTransferEvent struct {
+	Id          int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId     int64     `json:"chainId" gorm:"column:chain_id;not null"`
+	PoolAddress string    `json:"poolAddress" gorm:"column:pool_address;not null"`
+	TxHash      string    `json:"txHash" gorm:"column:tx_hash;not null"`
+	BlockNumber int64     `json:"blockNumber" gorm:"column:block_number;not null"`
+	LogIndex    int       `json:"logIndex" gorm:"column:log_index;not null"`
+	BlockTime   time.Time `json:"blockTime" gorm:"column:block_time"`
+	FromAddress string    `json:"fromAddress" gorm:"column:from_address;not null"` // 小写地址
+	ToAddress   string    `json:"toAddress" gorm:"column:to_address;not null"`     // 小写地址
+	Amount      string    `json:"amount" gorm:"column:amount;type:decimal(78,0)"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (LpTransferEvent) TableName() string {
+	return "lp_transfer_events"
+}
+
+// LpPosition 地址在池子中的LP持仓与剩余成本
+type LpPosition struct {
+	Id           int64           `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId      int64           `json:"chainId" gorm:"column:chain_id;not null"`
+	PoolAddress  string          `json:"poolAddress" gorm:"column:pool_address;not null"`
+	OwnerAddress string          `json:"ownerAddress" gorm:"column:owner_address;not null"` // 小写地址
+	Balance      string          `json:"balance" gorm:"column:balance;type:decimal(78,0)"`
+	CostToken0   string          `json:"costToken0" gorm:"column:cost_token0;type:decimal(78,0)"` // 剩余持仓对应的存入代币0数量
+	CostToken1   string          `json:"costToken1" gorm:"column:cost_token1;type:decimal(78,0)"` // 剩余持仓对应的存入代币1数量
+	CostBasisUSD decimal.Decimal `json:"costBasisUsd" gorm:"column:cost_basis_usd;type:decimal(38,2)"`
+	Deposited0   string          `json:"deposited0" gorm:"column:deposited0;type:decimal(78,0)"` // 累计存入
+	Deposited1   string          `json:"deposited1" gorm:"column:deposited1;type:decimal(78,0)"`
+	Withdrawn0   string          `json:"withdrawn0" gorm:"column:withdrawn0;type:decimal(78,0)"` // 累计取出
+	Withdrawn1   string          `json:"withdrawn1" gorm:"column:withdrawn1;type:decimal(78,0)"`
+	LastBlockNum int64           `json:"lastBlockNum" gorm:"column:last_block_num"`
+	CreatedAt    time.Time       `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time       `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (LpPosition) TableName() string {
+	return "lp_positions"
+}
+
+// V3Position V3 区间流动性仓位（按 交易发起地址 + tick 区间 归集）
+type V3Position struct {
+	Id           int64           `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId      int64           `json:"chainId" gorm:"column:chain_id;not null"`
+	PoolAddress  string          `json:"poolAddress" gorm:"column:pool_address;not null"`
+	OwnerAddress string          `json:"ownerAddress" gorm:"column:owner_address;not null"` // 小写地址
+	TickLower    int             `json:"tickLower" gorm:"column:tick_lower;not null"`
+	TickUpper    int             `json:"tickUpper" gorm:"column:tick_upper;not null"`
+	Liquidity    string          `json:"liquidity" gorm:"column:liquidity;type:decimal(78,0)"`
+	CostToken0   string          `json:"costToken0" gorm:"column:cost_token0;type:decimal(78,0)"` // 剩余流动性对应的存入代币0数量
+	CostToken1   string          `json:"costToken1" gorm:"column:cost_token1;type:decimal(78,0)"`
+	CostBasisUSD decimal.Decimal `json:"costBasisUsd" gorm:"column:cost_basis_usd;type:decimal(38,2)"`
+	Deposited0   string          `json:"deposited0" gorm:"column:deposited0;type:decimal(78,0)"` // 累计存入
+	Deposited1   string          `json:"deposited1" gorm:"column:deposited1;type:decimal(78,0)"`
+	Withdrawn0   string          `json:"withdrawn0" gorm:"column:withdrawn0;type:decimal(78,0)"` // 累计移除的本金（Burn）
+	Withdrawn1   string          `json:"withdrawn1" gorm:"column:withdrawn1;type:decimal(78,0)"`
+	Collected0   string          `json:"collected0" gorm:"column:collected0;type:decimal(78,0)"` // 累计提取（Collect，含本金与手续费）
+	Collected1   string          `json:"collected1" gorm:"column:collected1;type:decimal(78,0)"`
+	LastBlockNum int64           `json:"lastBlockNum" gorm:"column:last_block_num"`
+	CreatedAt    time.Time       `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time       `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (V3Position) TableName() string {
+	return "v3_positions"
+}
+
+// LpPositionDetail LP持仓详情（接口返回）
+type LpPositionDetail struct {
+	ChainId         int64   `json:"chainId"`
+	PoolAddress     string  `json:"poolAddress"`
+	PoolType        string  `json:"poolType"`
+	OwnerAddress    string  `json:"ownerAddress"`
+	TickLower       int     `json:"tickLower,omitempty"` // 仅 V3
+	TickUpper       int     `json:"tickUpper,omitempty"` // 仅 V3
+	Liquidity       string  `json:"liquidity,omitempty"` // 仅 V3，仓位流动性
+	InRange         bool    `json:"inRange"`             // 当前价格是否在仓位区间内（V2 恒为 true）
+	Token0Symbol    string  `json:"token0Symbol"`
+	Token1Symbol    string  `json:"token1Symbol"`
+	LpBalance       string  `json:"lpBalance"`
+	ShareOfPool     float64 `json:"shareOfPool"` // 百分比
+	Underlying0     string  `json:"underlying0"` // 按精度换算后的代币数量
+	Underlying1     string  `json:"underlying1"`
+	ValueUSD        float64 `json:"valueUsd"`
+	CostBasisUSD    float64 `json:"costBasisUsd"`
+	HodlValueUSD    float64 `json:"hodlValueUsd"`    // 成本代币按当前价格的价值
+	FeesEarnedUSD   float64 `json:"feesEarnedUsd"`   // V3 为已提取的手续费
+	ImpermanentLoss float64 `json:"impermanentLoss"` // 百分比，负数表示损失
+	PnlUSD          float64 `json:"pnlUsd"`
+	Deposited0      string  `json:"deposited0"`
+	Deposited1      string  `json:"deposited1"`
+	Withdrawn0      string  `json:"withdrawn0"`
+	Withdrawn1      string  `json:"withdrawn1"`
+	LastUpdateBlock int64   `json:"lastUpdateBlock"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// 空投活动生命周期状态
+const (
+	ManagedAirdropDraft      = "draft"      // 草稿，可任意修改
+	ManagedAirdropCreating   = "creating"   // createAirdrop 已提交，等待确认与 AirdropCreated 索引
+	ManagedAirdropCreated    = "created"    // 链上已创建，未激活
+	ManagedAirdropActivating = "activating" // activateAirdrop 已提交，等待确认与 AirdropActivated 索引
+	ManagedAirdropActive     = "active"     // 进行中
+	ManagedAirdropEnded      = "ended"      // 已过结束时间
+)
+
+// ManagedAirdrop 通过管理接口创建的空投活动
+type ManagedAirdrop struct {
+	Id              int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId         int64     `json:"chainId" gorm:"column:chain_id"`
+	ContractAddress string    `json:"contractAddress" gorm:"column:contract_address"`
+	Name            string    `json:"name" gorm:"column:name"`
+	Description     string    `json:"description" gorm:"column:description"`
+	IconUrl         string    `json:"iconUrl" gorm:"column:icon_url"`
+	TokenSymbol     string    `json:"tokenSymbol" gorm:"column:token_symbol"`
+	TokenAddress    string    `json:"tokenAddress" gorm:"column:token_address"`
+	TotalReward     string    `json:"totalReward" gorm:"column:total_reward;type:decimal(78,0)"` // wei
+	StartTime       time.Time `json:"startTime" gorm:"column:start_time"`
+	EndTime         time.Time `json:"endTime" gorm:"column:end_time"`
+	TaskIds         string    `json:"taskIds" gorm:"column:task_ids;type:jsonb"` // 绑定的任务ID，JSON 数组
+	Status          string    `json:"status" gorm:"column:status"`
+	AirdropId       *int64    `json:"airdropId" gorm:"column:airdrop_id"` // 链上空投ID，AirdropCreated 索引后回填
+	InitialRoot     string    `json:"initialRoot" gorm:"column:initial_root"`
+	CreateTxId      *int64    `json:"createTxId" gorm:"column:create_tx_id"`
+	ActivateTxId    *int64    `json:"activateTxId" gorm:"column:activate_tx_id"`
+	CreateTxHash    string    `json:"createTxHash" gorm:"column:create_tx_hash"`
+	Error           string    `json:"error" gorm:"column:error"`
+	CreatedBy       string    `json:"createdBy" gorm:"column:created_by"`
+	UpdatedBy       string    `json:"updatedBy" gorm:"column:updated_by"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (ManagedAirdrop) TableName() string {
+	return "managed_airdrops"
+}
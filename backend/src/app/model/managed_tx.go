@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+// 托管交易状态
+const (
+	ManagedTxPending   = "pending"   // 已分配 nonce，尚未成功广播
+	ManagedTxSubmitted = "submitted" // 已广播，等待回执
+	ManagedTxConfirmed = "confirmed" // 已上链且执行成功
+	ManagedTxReverted  = "reverted"  // 已上链但执行失败
+	ManagedTxCancelled = "cancelled" // 取消交易占用了该 nonce（手动取消或多次广播失败后填补 nonce）
+	ManagedTxFailed    = "failed"    // nonce 被其他交易占用或放弃发送
+)
+
+// 托管交易的广播类型
+const (
+	ManagedTxAttemptInitial = "initial"  // 首次广播
+	ManagedTxAttemptSpeedUp = "speed_up" // 同 nonce 提高费用重发
+	ManagedTxAttemptCancel  = "cancel"   // 同 nonce 向自身转账 0 以取消
+)
+
+// ManagedTx 服务端托管发送的交易（持久化发件箱），调用方按 ID 查询状态
+type ManagedTx struct {
+	Id                   int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId              int64      `json:"chainId" gorm:"column:chain_id"`
+	FromAddress          string     `json:"fromAddress" gorm:"column:from_address"` // 小写
+	ToAddress            string     `json:"toAddress" gorm:"column:to_address"`     // 小写
+	Data                 string     `json:"data" gorm:"column:data"`                // 0x 开头的调用数据
+	Value                string     `json:"value" gorm:"column:value;type:decimal(78,0);default:0"`
+	Nonce                int64      `json:"nonce" gorm:"column:nonce"`
+	GasLimit             int64      `json:"gasLimit" gorm:"column:gas_limit"`
+	MaxFeePerGas         string     `json:"maxFeePerGas" gorm:"column:max_fee_per_gas;type:decimal(78,0);default:0"`
+	MaxPriorityFeePerGas string     `json:"maxPriorityFeePerGas" gorm:"column:max_priority_fee_per_gas;type:decimal(78,0);default:0"`
+	Purpose              string     `json:"purpose" gorm:"column:purpose"` // 业务用途，如 airdrop.update_merkle_root
+	Status               string     `json:"status" gorm:"column:status"`
+	TxHash               string     `json:"txHash" gorm:"column:tx_hash"` // 最近一次广播的哈希，上链后为实际上链的哈希
+	Attempts             int        `json:"attempts" gorm:"column:attempts"`
+	BroadcastFailures    int        `json:"broadcastFailures" gorm:"column:broadcast_failures"` // 广播被节点拒绝的次数，达到上限后以取消交易填补 nonce
+	CancelRequested      bool       `json:"cancelRequested" gorm:"column:cancel_requested"`
+	BlockNumber          int64      `json:"blockNumber" gorm:"column:block_number"`
+	GasUsed              int64      `json:"gasUsed" gorm:"column:gas_used"`
+	Error                string     `json:"error" gorm:"column:error"`
+	LastBroadcastAt      *time.Time `json:"lastBroadcastAt" gorm:"column:last_broadcast_at"`
+	NextCheckAt          time.Time  `json:"-" gorm:"column:next_check_at"`
+	ClaimedUntil         *time.Time `json:"-" gorm:"column:claimed_until"` // 监控任务或手动替换持有的租约，到期前其他处理方不能广播
+	ConfirmedAt          *time.Time `json:"confirmedAt" gorm:"column:confirmed_at"`
+	CreatedAt            time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt            time.Time  `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (ManagedTx) TableName() string {
+	return "managed_txs"
+}
+
+// Final 是否已是终态
+func (t *ManagedTx) Final() bool {
+	switch t.Status {
+	case ManagedTxConfirmed, ManagedTxReverted, ManagedTxCancelled, ManagedTxFailed:
+		return true
+	}
+	return false
+}
+
+// ManagedTxAttempt 托管交易的每一次广播（发送前写入，被节点拒绝的也保留），同一 nonce 的任一哈希上链即为结果
+type ManagedTxAttempt struct {
+	Id                   int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	TxId                 int64     `json:"txId" gorm:"column:tx_id"`
+	Kind                 string    `json:"kind" gorm:"column:kind"`
+	TxHash               string    `json:"txHash" gorm:"column:tx_hash"`
+	GasLimit             int64     `json:"gasLimit" gorm:"column:gas_limit"`
+	MaxFeePerGas         string    `json:"maxFeePerGas" gorm:"column:max_fee_per_gas;type:decimal(78,0)"`
+	MaxPriorityFeePerGas string    `json:"maxPriorityFeePerGas" gorm:"column:max_priority_fee_per_gas;type:decimal(78,0)"`
+	CreatedAt            time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (ManagedTxAttempt) TableName() string {
+	return "managed_tx_attempts"
+}
+
+// ManagedTxNonce 发送账户在每条链上的下一个可分配 nonce
+type ManagedTxNonce struct {
+	ChainId   int64     `gorm:"column:chain_id;primaryKey"`
+	Address   string    `gorm:"column:address;primaryKey"` // 小写
+	NextNonce int64     `gorm:"column:next_nonce"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (ManagedTxNonce) TableName() string {
+	return "managed_tx_nonces"
+}
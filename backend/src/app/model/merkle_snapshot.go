@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// 默克尔快照状态
+const (
+	MerkleSnapshotBuilt           = "built"            // 已生成，尚未发布
+	MerkleSnapshotPendingApproval = "pending_approval" // 自动更新未通过策略检查，等待管理员审批
+	MerkleSnapshotPublishing      = "publishing"       // 已提交托管交易或 Safe 提案，等待上链
+	MerkleSnapshotPublished       = "published"        // 当前链上生效的根，白名单与证明已切换
+	MerkleSnapshotSuperseded      = "superseded"       // 已被更新的版本替代（曾经发布，或待审批时出现了更新的快照）
+	MerkleSnapshotRejected        = "rejected"         // 审批拒绝
+	MerkleSnapshotFailed          = "failed"           // 发布交易失败或提案未执行
+)
+
+// 默克尔快照来源
+const (
+	MerkleSnapshotSourceRebuild  = "rebuild"  // 按已完成任务重新聚合奖励
+	MerkleSnapshotSourceRollback = "rollback" // 复制历史快照的叶子以回滚
+)
+
+// 发布方式
+const (
+	MerklePublishTx   = "tx"   // 服务端签名账户经托管交易发送
+	MerklePublishSafe = "safe" // 生成 Safe 多签提案
+)
+
+// MerkleSnapshot 空投默克尔树快照，叶子与根生成后不再修改，只有发布状态会变化
+type MerkleSnapshot struct {
+	Id               int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	AirdropId        int64      `json:"airdropId" gorm:"column:airdrop_id"`
+	ChainId          int64      `json:"chainId" gorm:"column:chain_id"`
+	ContractAddress  string     `json:"contractAddress" gorm:"column:contract_address"`
+	Version          int        `json:"version" gorm:"column:version"` // 同一空投内递增
+	Root             string     `json:"root" gorm:"column:root"`
+	LeafCount        int        `json:"leafCount" gorm:"column:leaf_count"`
+	TotalAmount      string     `json:"totalAmount" gorm:"column:total_amount;type:decimal(78,0)"` // 叶子金额合计（wei）
+	Tree             string     `json:"-" gorm:"column:tree;type:jsonb"`                           // OpenZeppelin StandardMerkleTree dump 格式
+	Source           string     `json:"source" gorm:"column:source"`
+	RollbackOf       *int       `json:"rollbackOf" gorm:"column:rollback_of"` // 回滚时复制的版本
+	CreatedBy        string     `json:"createdBy" gorm:"column:created_by"`   // 登录钱包，定时任务为 auto
+	Status           string     `json:"status" gorm:"column:status"`
+	PublishMode      string     `json:"publishMode" gorm:"column:publish_mode"`
+	PublishedBy      string     `json:"publishedBy" gorm:"column:published_by"`
+	ChainVersion     int64      `json:"chainVersion" gorm:"column:chain_version"` // updateMerkleRoot 的 newVersion
+	ManagedTxId      *int64     `json:"managedTxId" gorm:"column:managed_tx_id"`
+	SafeProposalId   *int64     `json:"safeProposalId" gorm:"column:safe_proposal_id"`
+	TxHash           string     `json:"txHash" gorm:"column:tx_hash"`
+	Error            string     `json:"error" gorm:"column:error"`
+	PolicyViolations string     `json:"policyViolations" gorm:"column:policy_violations;type:jsonb"` // 自动更新策略检查未通过的项，JSON 数组
+	ReviewedBy       string     `json:"reviewedBy" gorm:"column:reviewed_by"`                        // 审批或拒绝的管理员
+	ReviewedAt       *time.Time `json:"reviewedAt" gorm:"column:reviewed_at"`
+	PublishedAt      *time.Time `json:"publishedAt" gorm:"column:published_at"`
+	CreatedAt        time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (MerkleSnapshot) TableName() string {
+	return "merkle_snapshots"
+}
+
+// MerkleSnapshotLeaf 快照中每个地址的分配与证明
+type MerkleSnapshotLeaf struct {
+	SnapshotId    int64  `json:"-" gorm:"column:snapshot_id;primaryKey"`
+	WalletAddress string `json:"walletAddress" gorm:"column:wallet_address;primaryKey"` // 小写
+	Amount        string `json:"amount" gorm:"column:amount;type:decimal(78,0)"`        // wei
+	LeafHash      string `json:"leafHash" gorm:"column:leaf_hash"`
+	Proof         string `json:"proof" gorm:"column:proof;type:jsonb"`
+}
+
+// TableName 指定表名
+func (MerkleSnapshotLeaf) TableName() string {
+	return "merkle_snapshot_leaves"
+}
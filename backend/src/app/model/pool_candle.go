@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PoolCandle 池子K线（价格为 token1/token0，已按精度换算）
+type PoolCandle struct {
+	Id          int64           `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId     int64           `json:"chainId" gorm:"column:chain_id;not null"`
+	PoolAddress string          `json:"poolAddress" gorm:"column:pool_address;not null"`
+	Resolution  string          `json:"resolution" gorm:"column:resolution;not null"` // 1m, 5m, 1h, 4h, 1d
+	BucketStart time.Time       `json:"bucketStart" gorm:"column:bucket_start;not null"`
+	Open        decimal.Decimal `json:"open" gorm:"column:open;type:decimal(38,18)"`
+	High        decimal.Decimal `json:"high" gorm:"column:high;type:decimal(38,18)"`
+	Low         decimal.Decimal `json:"low" gorm:"column:low;type:decimal(38,18)"`
+	Close       decimal.Decimal `json:"close" gorm:"column:close;type:decimal(38,18)"`
+	Volume0     decimal.Decimal `json:"volume0" gorm:"column:volume0;type:decimal(38,18)"`
+	Volume1     decimal.Decimal `json:"volume1" gorm:"column:volume1;type:decimal(38,18)"`
+	VolumeUSD   decimal.Decimal `json:"volumeUsd" gorm:"column:volume_usd;type:decimal(38,2)"`
+	TradeCount  int64           `json:"tradeCount" gorm:"column:trade_count"`
+	CreatedAt   time.Time       `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time       `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (PoolCandle) TableName() string {
+	return "pool_candles"
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PoolHourSnapshot 池子小时快照：储备量与供应量为整点结束时的状态，交易量与手续费为该小时内的累计
+type PoolHourSnapshot struct {
+	Id              int64           `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId         int64           `json:"chainId" gorm:"column:chain_id;not null"`
+	PoolAddress     string          `json:"poolAddress" gorm:"column:pool_address;not null"`
+	HourStart       time.Time       `json:"hourStart" gorm:"column:hour_start;not null"`
+	Reserve0        string          `json:"reserve0" gorm:"column:reserve0;type:decimal(78,0)"`
+	Reserve1        string          `json:"reserve1" gorm:"column:reserve1;type:decimal(78,0)"`
+	TotalSupply     string          `json:"totalSupply" gorm:"column:total_supply;type:decimal(78,0)"`
+	Price           decimal.Decimal `json:"price" gorm:"column:price;type:decimal(38,18)"` // token1/token0
+	Token0USD       decimal.Decimal `json:"token0Usd" gorm:"column:token0_usd;type:decimal(38,18)"`
+	Token1USD       decimal.Decimal `json:"token1Usd" gorm:"column:token1_usd;type:decimal(38,18)"`
+	TvlUSD          decimal.Decimal `json:"tvlUsd" gorm:"column:tvl_usd;type:decimal(38,2)"`
+	VolumeUSD       decimal.Decimal `json:"volumeUsd" gorm:"column:volume_usd;type:decimal(38,2)"`
+	FeesUSD         decimal.Decimal `json:"feesUsd" gorm:"column:fees_usd;type:decimal(38,2)"`
+	LpFeesUSD       decimal.Decimal `json:"lpFeesUsd" gorm:"column:lp_fees_usd;type:decimal(38,2)"`             // 归LP的手续费
+	ProtocolFeesUSD decimal.Decimal `json:"protocolFeesUsd" gorm:"column:protocol_fees_usd;type:decimal(38,2)"` // 归协议的手续费（按费率计提）
+	Apy             decimal.Decimal `json:"apy" gorm:"column:apy;type:decimal(20,4)"`                           // 百分比，按近24小时LP手续费年化
+	TxCount         int64           `json:"txCount" gorm:"column:tx_count"`
+	BlockNumber     int64           `json:"blockNumber" gorm:"column:block_number"` // 该小时内最后一个事件所在区块
+	CreatedAt       time.Time       `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time       `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (PoolHourSnapshot) TableName() string {
+	return "pool_hour_snapshots"
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Portfolio 钱包在所有已配置链上的资产汇总；USD 金额统一为 decimal，代币数量同时给出原始单位与按精度换算的值
+type Portfolio struct {
+	Address            string             `json:"address"`
+	ChainIds           []int64            `json:"chainIds"`
+	NetWorthUSD        decimal.Decimal    `json:"netWorthUsd"`       // 质押 + LP 持仓的USD价值（空投代币无报价，不计入）
+	NetWorthUSD24hAgo  decimal.Decimal    `json:"netWorthUsd24hAgo"` // 24小时前同一口径的价值
+	Change24hUSD       decimal.Decimal    `json:"change24hUsd"`
+	Change24hPercent   decimal.Decimal    `json:"change24hPercent"`
+	StakedValueUSD     decimal.Decimal    `json:"stakedValueUsd"`
+	LiquidityValueUSD  decimal.Decimal    `json:"liquidityValueUsd"`
+	TotalPoints        decimal.Decimal    `json:"totalPoints"`
+	Stakes             []PortfolioStake   `json:"stakes"`
+	LiquidityPositions []LpPositionDetail `json:"liquidityPositions"`
+	Airdrops           []PortfolioAirdrop `json:"airdrops"`
+	Points             []PortfolioPoints  `json:"points"`
+	UpdatedAt          time.Time          `json:"updatedAt"`
+}
+
+// PortfolioStake 按链、质押池与代币合并的质押持仓
+type PortfolioStake struct {
+	ChainId        int64           `json:"chainId"`
+	PoolId         int64           `json:"poolId"`
+	TokenAddress   string          `json:"tokenAddress"`
+	TokenSymbol    string          `json:"tokenSymbol"`
+	Decimals       int             `json:"decimals"`
+	RawAmount      string          `json:"rawAmount"` // 原始单位
+	Amount         decimal.Decimal `json:"amount"`    // 按精度换算
+	PriceUSD       decimal.Decimal `json:"priceUsd"`
+	ValueUSD       decimal.Decimal `json:"valueUsd"`
+	Priced         bool            `json:"priced"` // 无法定价时 ValueUSD 为 0
+	LastStakedAt   time.Time       `json:"lastStakedAt"`
+	UnlockTime     time.Time       `json:"unlockTime"` // 最晚一笔质押的解锁时间
+	Locked         bool            `json:"locked"`
+	UnlockInSecond int64           `json:"unlockInSecond"` // 距解锁的秒数，已解锁为 0
+}
+
+// PortfolioAirdrop 用户在某个空投活动中的奖励
+type PortfolioAirdrop struct {
+	AirdropId     string     `json:"airdropId"`
+	ChainId       int64      `json:"chainId"`
+	Name          string     `json:"name"`
+	TokenSymbol   string     `json:"tokenSymbol"`
+	TotalReward   string     `json:"totalReward"` // 原始单位
+	ClaimedReward string     `json:"claimedReward"`
+	PendingReward string     `json:"pendingReward"`
+	TotalAmount   string     `json:"totalAmount"` // 按18位精度换算
+	ClaimedAmount string     `json:"claimedAmount"`
+	PendingAmount string     `json:"pendingAmount"`
+	Status        string     `json:"status"` // claimable, expired, closed
+	LastClaimTime *time.Time `json:"lastClaimTime"`
+}
+
+// PortfolioPoints 质押积分
+type PortfolioPoints struct {
+	ChainId      int64           `json:"chainId"`
+	TokenAddress string          `json:"tokenAddress"`
+	Points       decimal.Decimal `json:"points"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ProtocolRevenueRow 单个池子在一个统计周期内的手续费拆分与协议收入
+type ProtocolRevenueRow struct {
+	ChainId         int64           `json:"chainId"`
+	PoolAddress     string          `json:"poolAddress"`
+	PeriodStart     time.Time       `json:"periodStart"`
+	VolumeUSD       decimal.Decimal `json:"volumeUsd"`
+	FeesUSD         decimal.Decimal `json:"feesUsd"`
+	LpFeesUSD       decimal.Decimal `json:"lpFeesUsd"`
+	ProtocolFeesUSD decimal.Decimal `json:"protocolFeesUsd"` // 按协议费率计提
+	MintedLp        string          `json:"mintedLp"`        // 同期铸造给 feeTo 的LP数量（实收）
+	MintedLpUSD     decimal.Decimal `json:"mintedLpUsd"`     // 实收LP按铸造时单位LP价值折算
+}
+
+// ProtocolRevenueReport 协议收入报表
+type ProtocolRevenueReport struct {
+	Interval             string               `json:"interval"`
+	From                 time.Time            `json:"from"`
+	To                   time.Time            `json:"to"`
+	TotalVolumeUSD       decimal.Decimal      `json:"totalVolumeUsd"`
+	TotalFeesUSD         decimal.Decimal      `json:"totalFeesUsd"`
+	TotalLpFeesUSD       decimal.Decimal      `json:"totalLpFeesUsd"`
+	TotalProtocolFeesUSD decimal.Decimal      `json:"totalProtocolFeesUsd"`
+	TotalMintedLpUSD     decimal.Decimal      `json:"totalMintedLpUsd"`
+	List                 []ProtocolRevenueRow `json:"list"`
+}
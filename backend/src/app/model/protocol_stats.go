@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ProtocolDailyStats 协议每日汇总（按链，UTC 自然日）；当天的记录在日内会被反复刷新
+type ProtocolDailyStats struct {
+	Id                   int64           `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId              int64           `json:"chainId" gorm:"column:chain_id;not null"`
+	Day                  time.Time       `json:"day" gorm:"column:day;type:date;not null"`
+	TvlUSD               decimal.Decimal `json:"tvlUsd" gorm:"column:tvl_usd;type:decimal(38,2)"` // 当日结束时的池子锁仓量
+	VolumeUSD            decimal.Decimal `json:"volumeUsd" gorm:"column:volume_usd;type:decimal(38,2)"`
+	FeesUSD              decimal.Decimal `json:"feesUsd" gorm:"column:fees_usd;type:decimal(38,2)"`
+	LpFeesUSD            decimal.Decimal `json:"lpFeesUsd" gorm:"column:lp_fees_usd;type:decimal(38,2)"`
+	ProtocolFeesUSD      decimal.Decimal `json:"protocolFeesUsd" gorm:"column:protocol_fees_usd;type:decimal(38,2)"`
+	SwapCount            int64           `json:"swapCount" gorm:"column:swap_count"`
+	UniqueTraders        int64           `json:"uniqueTraders" gorm:"column:unique_traders"`
+	LiquidityProviders   int64           `json:"liquidityProviders" gorm:"column:liquidity_providers"` // 当日增减过流动性的地址数
+	NewLps               int64           `json:"newLps" gorm:"column:new_lps"`                         // 当日首次提供流动性的地址数
+	Stakers              int64           `json:"stakers" gorm:"column:stakers"`                        // 当日结束时仍有质押余额的地址数
+	NewStakers           int64           `json:"newStakers" gorm:"column:new_stakers"`                 // 当日首次质押的地址数
+	StakeCount           int64           `json:"stakeCount" gorm:"column:stake_count"`
+	WithdrawCount        int64           `json:"withdrawCount" gorm:"column:withdraw_count"`
+	AirdropClaims        int64           `json:"airdropClaims" gorm:"column:airdrop_claims"`
+	AirdropClaimers      int64           `json:"airdropClaimers" gorm:"column:airdrop_claimers"`
+	AirdropClaimedAmount string          `json:"airdropClaimedAmount" gorm:"column:airdrop_claimed_amount;type:decimal(78,0)"` // 原始单位
+	Dau                  int64           `json:"dau" gorm:"column:dau"`
+	Wau                  int64           `json:"wau" gorm:"column:wau"` // 截至当日的近7天活跃地址
+	Mau                  int64           `json:"mau" gorm:"column:mau"` // 截至当日的近30天活跃地址
+	CreatedAt            time.Time       `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt            time.Time       `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+
+	TokenStakes []ProtocolDailyTokenStake `json:"tokenStakes" gorm:"-"`
+}
+
+// TableName 指定表名
+func (ProtocolDailyStats) TableName() string {
+	return "protocol_daily_stats"
+}
+
+// ProtocolDailyTokenStake 每日结束时各质押代币的质押总量
+type ProtocolDailyTokenStake struct {
+	Id           int64     `json:"-" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId      int64     `json:"-" gorm:"column:chain_id;not null"`
+	Day          time.Time `json:"-" gorm:"column:day;type:date;not null"`
+	TokenAddress string    `json:"tokenAddress" gorm:"column:token_address;not null"` // 小写地址
+	TotalStaked  string    `json:"totalStaked" gorm:"column:total_staked;type:decimal(78,0)"`
+	Stakers      int64     `json:"stakers" gorm:"column:stakers"`
+	UpdatedAt    time.Time `json:"-" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (ProtocolDailyTokenStake) TableName() string {
+	return "protocol_daily_token_stakes"
+}
+
+// ProtocolStatsReport 协议汇总查询结果
+type ProtocolStatsReport struct {
+	ChainId         int64                `json:"chainId"` // 0 表示所有链合计
+	From            time.Time            `json:"from"`
+	To              time.Time            `json:"to"`
+	TotalVolumeUSD  decimal.Decimal      `json:"totalVolumeUsd"`
+	TotalFeesUSD    decimal.Decimal      `json:"totalFeesUsd"`
+	TotalSwapCount  int64                `json:"totalSwapCount"`
+	TotalNewLps     int64                `json:"totalNewLps"`
+	TotalNewStakers int64                `json:"totalNewStakers"`
+	TotalClaims     int64                `json:"totalClaims"`
+	List            []ProtocolDailyStats `json:"list"`
+}
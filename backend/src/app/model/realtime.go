@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// 推送消息类型，除以下两种外与事件浏览的统一事件类型一致
+const (
+	RealtimeTypePool  = "pool"  // 池子储备/价格更新
+	RealtimeTypeReset = "reset" // 重放的 cursor 过旧、部分消息已淘汰，客户端需通过 REST 接口重新加载
+)
+
+// RealtimeMessage 索引器提交一批事件后推送的消息
+type RealtimeMessage struct {
+	Id          int64               `json:"id"`   // 全局递增序号，断线重连时作为 cursor
+	Type        string              `json:"type"` // swap, mint, burn, collect, claim, stake, withdraw, pool
+	ChainId     int64               `json:"chainId"`
+	PoolAddress string              `json:"poolAddress,omitempty"` // 小写
+	UserAddress string              `json:"userAddress,omitempty"` // 小写
+	Event       *ExplorerEvent      `json:"event,omitempty"`
+	Pool        *RealtimePoolUpdate `json:"pool,omitempty"`
+	PublishedAt time.Time           `json:"publishedAt"`
+}
+
+// RealtimePoolUpdate 池子最新状态
+type RealtimePoolUpdate struct {
+	ChainId       int64  `json:"chainId"`
+	PoolAddress   string `json:"poolAddress"`
+	PoolType      string `json:"poolType"`
+	Token0Address string `json:"token0Address"`
+	Token1Address string `json:"token1Address"`
+	Token0Symbol  string `json:"token0Symbol"`
+	Token1Symbol  string `json:"token1Symbol"`
+	Reserve0      string `json:"reserve0"`
+	Reserve1      string `json:"reserve1"`
+	Price         string `json:"price"`
+	SqrtPriceX96  string `json:"sqrtPriceX96,omitempty"`
+	Tick          int    `json:"tick,omitempty"`
+	Liquidity     string `json:"liquidity,omitempty"`
+	LastBlockNum  int64  `json:"lastBlockNum"`
+}
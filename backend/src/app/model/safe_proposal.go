@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// Safe 提案中可编码的合约操作
+const (
+	SafeActionCreateAirdrop    = "create_airdrop"
+	SafeActionUpdateMerkleRoot = "update_merkle_root"
+	SafeActionActivateAirdrop  = "activate_airdrop"
+)
+
+// Safe 提案状态
+const (
+	SafeProposalProposed = "proposed" // 已生成批次，等待 Safe owner 签名执行
+	SafeProposalExecuted = "executed" // Safe 已执行（ExecutionSuccess），等待索引
+	SafeProposalIndexed  = "indexed"  // 操作结果已在索引数据中确认
+	SafeProposalFailed   = "failed"   // Safe 执行时内部调用失败（ExecutionFailure）
+	SafeProposalReplaced = "replaced" // 同一 Safe nonce 被其他交易占用
+)
+
+// SafeProposal Safe 多签提案：一组 owner-only 合约调用打包为一笔 SafeTx，
+// 多个调用通过 MultiSendCallOnly 以 DELEGATECALL 批量执行
+type SafeProposal struct {
+	Id             int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId        int64      `json:"chainId" gorm:"column:chain_id"`
+	SafeAddress    string     `json:"safeAddress" gorm:"column:safe_address"` // 小写
+	Title          string     `json:"title" gorm:"column:title"`
+	Description    string     `json:"description" gorm:"column:description"`
+	Operator       string     `json:"operator" gorm:"column:operator"`          // 发起提案的 Safe owner（小写）
+	Actions        string     `json:"actions" gorm:"column:actions;type:jsonb"` // 每个调用的操作类型、目标、调用数据与参数
+	ToAddress      string     `json:"toAddress" gorm:"column:to_address"`       // SafeTx.to，批量时为 MultiSendCallOnly
+	Value          string     `json:"value" gorm:"column:value;type:decimal(78,0);default:0"`
+	Data           string     `json:"data" gorm:"column:data"`           // SafeTx.data
+	Operation      int        `json:"operation" gorm:"column:operation"` // 0 CALL，1 DELEGATECALL
+	SafeNonce      int64      `json:"safeNonce" gorm:"column:safe_nonce"`
+	SafeTxHash     string     `json:"safeTxHash" gorm:"column:safe_tx_hash"`    // EIP-712 SafeTx 哈希，owner 对其签名
+	BuilderJson    string     `json:"-" gorm:"column:builder_json;type:jsonb"`  // Safe Transaction Builder 导入文件
+	CreatedBlock   int64      `json:"createdBlock" gorm:"column:created_block"` // 创建时的链上高度，执行事件从此处开始查找
+	ScannedBlock   int64      `json:"-" gorm:"column:scanned_block"`            // 已查找执行事件的区块高度
+	Status         string     `json:"status" gorm:"column:status"`
+	ExecTxHash     string     `json:"execTxHash" gorm:"column:exec_tx_hash"`          // 执行 SafeTx 的链上交易
+	ExecSafeTxHash string     `json:"execSafeTxHash" gorm:"column:exec_safe_tx_hash"` // 实际执行的 SafeTx 哈希，经 Transaction Builder 执行时可能与 SafeTxHash 不同
+	ExecBlock      int64      `json:"execBlock" gorm:"column:exec_block"`
+	Result         string     `json:"result" gorm:"column:result"` // 索引对账结果
+	ExecutedAt     *time.Time `json:"executedAt" gorm:"column:executed_at"`
+	IndexedAt      *time.Time `json:"indexedAt" gorm:"column:indexed_at"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (SafeProposal) TableName() string {
+	return "safe_proposals"
+}
+
+// SafeProposalAction 提案中的单个调用
+type SafeProposalAction struct {
+	Action string      `json:"action"` // create_airdrop、update_merkle_root、activate_airdrop，或质押管理操作 add_pool 等
+	To     string      `json:"to"`
+	Value  string      `json:"value"`
+	Data   string      `json:"data"`
+	Params interface{} `json:"params"` // 规范化后的请求参数，对账时使用
+}
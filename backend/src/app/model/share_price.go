@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 份额价格来源
+const (
+	SharePriceSourceOracle = "oracle" // 池子配置的 Chainlink 预言机
+	SharePriceSourceDex    = "dex"    // DEX 路由定价
+	SharePriceSourceStatic = "static" // 配置的固定价格
+)
+
+// 份额价格更新状态
+const (
+	SharePriceStatusConfirmed = "confirmed" // 交易已上链
+	SharePriceStatusSkipped   = "skipped"   // 与当天或最近一次发布的链上价格一致，无需更新
+	SharePriceStatusHeld      = "held"      // 偏离超限，按配置暂停提交
+	SharePriceStatusFailed    = "failed"    // 计算或交易失败
+)
+
+// WebhookEventSharePriceAlert 份额价格偏离告警事件类型
+const WebhookEventSharePriceAlert = "share_price_alert"
+
+// SharePriceUpdate 份额价格维护任务对单个池子单日的处理记录
+type SharePriceUpdate struct {
+	Id              int64           `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId         int64           `json:"chainId" gorm:"column:chain_id"`
+	ContractAddress string          `json:"contractAddress" gorm:"column:contract_address"`
+	PoolId          int64           `json:"poolId" gorm:"column:pool_id"`
+	Day             int64           `json:"day" gorm:"column:day"` // UTC 自然日序号，即合约 dailySharePrices 的日期键
+	Source          string          `json:"source" gorm:"column:source"`
+	Price           string          `json:"price" gorm:"column:price;type:decimal(78,0)"`                  // 本次价格（1e18 精度）
+	PreviousPrice   string          `json:"previousPrice" gorm:"column:previous_price;type:decimal(78,0)"` // 上一次链上价格，没有时为 0
+	DeviationPct    decimal.Decimal `json:"deviationPct" gorm:"column:deviation_pct;type:decimal(20,6)"`
+	Alerted         bool            `json:"alerted" gorm:"column:alerted"`
+	Status          string          `json:"status" gorm:"column:status"`
+	TxHash          string          `json:"txHash" gorm:"column:tx_hash"`
+	Error           string          `json:"error" gorm:"column:error"`
+	CreatedAt       time.Time       `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (SharePriceUpdate) TableName() string {
+	return "share_price_updates"
+}
+
+// SharePriceAlert 份额价格偏离告警
+type SharePriceAlert struct {
+	ChainId         int64           `json:"chainId"`
+	ContractAddress string          `json:"contractAddress"`
+	PoolId          int64           `json:"poolId"`
+	Date            string          `json:"date"` // yyyy-mm-dd（UTC）
+	Source          string          `json:"source"`
+	Price           decimal.Decimal `json:"price"`
+	PreviousPrice   decimal.Decimal `json:"previousPrice"`
+	DeviationPct    decimal.Decimal `json:"deviationPct"`
+	ThresholdPct    decimal.Decimal `json:"thresholdPct"`
+	Held            bool            `json:"held"` // true 表示未提交上链
+}
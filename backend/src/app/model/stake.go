@@ -2,23 +2,64 @@ package model
 
 import "time"
 
-// StakeRecord 质押记录
+// StakeRecord 质押记录（单笔质押批次），数量按代币精度换算
 type StakeRecord struct {
-	ID          int64     `json:"id" gorm:"primaryKey"`
-	UserAddress string    `json:"userAddress" gorm:"index"`
-	ChainId     int64     `json:"chainId" gorm:"index"`
-	Amount      float64   `json:"amount"`
-	Token       string    `json:"token"`
-	Status      string    `json:"status"` // active, withdrawn, expired
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID              int64     `json:"id" gorm:"primaryKey"` // 对应的 Staked 记录ID，可用于按笔提取
+	UserAddress     string    `json:"userAddress" gorm:"index"`
+	ChainId         int64     `json:"chainId" gorm:"index"`
+	PoolId          int64     `json:"poolId"`
+	Amount          float64   `json:"amount"`    // 质押数量
+	Remaining       float64   `json:"remaining"` // 剩余本金
+	Withdrawn       float64   `json:"withdrawn"` // 已提取
+	Token           string    `json:"token"`
+	Symbol          string    `json:"symbol"`
+	Status          string    `json:"status"` // locked, unlockable, withdrawn
+	UnlockTime      time.Time `json:"unlockTime"`
+	TxHash          string    `json:"txHash"`
+	ContractAddress string    `json:"contractAddress"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
 }
 
-// StakeOverview 质押概览
+// StakeOverview 质押概览；不同代币的数量直接相加，按池子的明细见 Positions
 type StakeOverview struct {
-	TotalStaked  float64 `json:"totalStaked"`
-	TotalRewards float64 `json:"totalRewards"`
-	ActiveStakes int     `json:"activeStakes"`
-	UserAddress  string  `json:"userAddress"`
-	ChainId      int64   `json:"chainId"`
+	TotalStaked      float64               `json:"totalStaked"` // 当前本金
+	LockedAmount     float64               `json:"lockedAmount"`
+	UnlockableAmount float64               `json:"unlockableAmount"`
+	ClaimedRewards   float64               `json:"claimedRewards"`
+	TotalRewards     float64               `json:"totalRewards"` // 积分折算收益
+	ActiveStakes     int                   `json:"activeStakes"` // 剩余本金大于0的质押笔数
+	UserAddress      string                `json:"userAddress"`
+	ChainId          int64                 `json:"chainId"`
+	Positions        []StakePositionDetail `json:"positions"`
+}
+
+// UnsignedTx 待用户钱包签名的 EIP-1559 交易；数值均为十进制字符串，Data 为 0x 开头的调用数据
+type UnsignedTx struct {
+	Step                 string `json:"step"` // approve, deposit, withdraw
+	Type                 string `json:"type"` // 固定为 0x2
+	ChainId              int64  `json:"chainId"`
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Data                 string `json:"data"`
+	Value                string `json:"value"`
+	Nonce                uint64 `json:"nonce"`
+	Gas                  uint64 `json:"gas"`
+	GasEstimated         bool   `json:"gasEstimated"` // false 表示依赖前一笔交易（如授权）无法预估，使用默认值
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+}
+
+// StakeTxPlan 质押/提取需要用户依次签名发送的交易，入库状态以索引到的 Staked/Withdrawn 事件为准
+type StakeTxPlan struct {
+	Action        string       `json:"action"` // stake, withdraw
+	ChainId       int64        `json:"chainId"`
+	UserAddress   string       `json:"userAddress"`
+	StakeContract string       `json:"stakeContract"`
+	PoolId        int64        `json:"poolId"`
+	TokenAddress  string       `json:"tokenAddress"` // 零地址表示原生币
+	Decimals      int          `json:"decimals"`
+	Amount        string       `json:"amount"` // 原始单位
+	Allowance     string       `json:"allowance,omitempty"`
+	Transactions  []UnsignedTx `json:"transactions"`
 }
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StakePool 质押合约 PoolCreated 事件索引的质押池
+type StakePool struct {
+	Id              int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId         int64     `json:"chainId" gorm:"column:chain_id;not null"`
+	ContractAddress string    `json:"contractAddress" gorm:"column:contract_address;not null"` // 质押合约地址（小写）
+	PoolId          int64     `json:"poolId" gorm:"column:pool_id;not null"`
+	TokenAddress    string    `json:"tokenAddress" gorm:"column:token_address;not null"` // 小写，零地址表示原生币
+	LockDuration    int64     `json:"lockDuration" gorm:"column:lock_duration"`          // 锁定时长（秒）
+	Name            string    `json:"name" gorm:"column:name"`
+	BlockNumber     int64     `json:"blockNumber" gorm:"column:block_number"`
+	TxHash          string    `json:"txHash" gorm:"column:tx_hash"`
+	BlockTime       time.Time `json:"blockTime" gorm:"column:block_time"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (StakePool) TableName() string {
+	return "stake_pools"
+}
+
+// StakePoolToken 质押池代币元数据
+type StakePoolToken struct {
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+	Native   bool   `json:"native"` // 原生币池子，通过 depositEth 质押
+}
+
+// StakePoolInfo 质押池目录条目：链上 getPools 数据合并已索引的 PoolCreated 事件
+type StakePoolInfo struct {
+	ChainId        int64            `json:"chainId"`
+	StakeContract  string           `json:"stakeContract"`
+	PoolId         int64            `json:"poolId"`
+	Name           string           `json:"name"`
+	Token          StakePoolToken   `json:"token"`
+	LockDuration   int64            `json:"lockDuration"` // 锁定时长（秒）
+	IsActive       bool             `json:"isActive"`
+	FeeRatio       string           `json:"feeRatio"` // 合约原始值
+	OracleDataFeed string           `json:"oracleDataFeed,omitempty"`
+	SharePrice     *decimal.Decimal `json:"sharePrice"`     // 最近一次日份额价格（已按 1e18 换算），未设置时为空
+	SharePriceDate string           `json:"sharePriceDate"` // 份额价格对应的 UTC 日期 yyyy-mm-dd
+	TotalStaked    decimal.Decimal  `json:"totalStaked"`    // 按代币精度换算的质押总量
+	TvlUSD         *decimal.Decimal `json:"tvlUsd"`         // 无法定价时为空
+	Apr            *decimal.Decimal `json:"apr"`            // 按份额价格增长年化，历史不足时为空
+	AprWindowDays  int              `json:"aprWindowDays"`  // 计算 APR 实际使用的天数
+	Live           bool             `json:"live"`           // false 表示链上读取失败，仅返回索引数据
+	CreatedBlock   int64            `json:"createdBlock,omitempty"`
+	CreatedTxHash  string           `json:"createdTxHash,omitempty"`
+	CreatedAt      *time.Time       `json:"createdAt,omitempty"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 质押状态
+const (
+	StakeStatusLocked     = "locked"     // 仍在锁定期
+	StakeStatusUnlockable = "unlockable" // 已过解锁时间，可提取
+	StakeStatusWithdrawn  = "withdrawn"  // 已全部提取
+)
+
+// StakePosition 用户在单个质押池的持仓投影，由已索引的 Staked/Withdrawn/ClaimRewards 事件累计得出
+type StakePosition struct {
+	Id              int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId         int64     `json:"chainId" gorm:"column:chain_id;not null"`
+	ContractAddress string    `json:"contractAddress" gorm:"column:contract_address;not null"` // 质押合约地址（小写）
+	UserAddress     string    `json:"userAddress" gorm:"column:user_address;not null"`         // 小写地址
+	PoolId          int64     `json:"poolId" gorm:"column:pool_id;not null"`
+	TokenAddress    string    `json:"tokenAddress" gorm:"column:token_address"`
+	Principal       string    `json:"principal" gorm:"column:principal;type:decimal(78,0)"`            // 当前本金（原始单位）
+	TotalStaked     string    `json:"totalStaked" gorm:"column:total_staked;type:decimal(78,0)"`       // 累计质押
+	TotalWithdrawn  string    `json:"totalWithdrawn" gorm:"column:total_withdrawn;type:decimal(78,0)"` // 累计提取
+	ClaimedRewards  string    `json:"claimedRewards" gorm:"column:claimed_rewards;type:decimal(78,0)"` // 累计领取收益（ClaimRewards 与提取时发放的收益）
+	StakeCount      int       `json:"stakeCount" gorm:"column:stake_count"`
+	FirstStakedAt   time.Time `json:"firstStakedAt" gorm:"column:first_staked_at"`
+	LastActivityAt  time.Time `json:"lastActivityAt" gorm:"column:last_activity_at"`
+	LastBlockNumber int64     `json:"lastBlockNumber" gorm:"column:last_block_number"`
+	UpdatedAt       time.Time `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (StakePosition) TableName() string {
+	return "stake_positions"
+}
+
+// StakePositionLot 单笔质押及其剩余本金；提取按解锁时间先后扣减
+type StakePositionLot struct {
+	Id              int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	PositionId      int64      `json:"positionId" gorm:"column:position_id;not null"`
+	RecordId        int64      `json:"recordId" gorm:"column:record_id;not null"` // user_operation_record 中的 Staked 记录
+	ChainId         int64      `json:"chainId" gorm:"column:chain_id;not null"`
+	ContractAddress string     `json:"contractAddress" gorm:"column:contract_address;not null"`
+	UserAddress     string     `json:"userAddress" gorm:"column:user_address;not null"`
+	PoolId          int64      `json:"poolId" gorm:"column:pool_id;not null"`
+	TokenAddress    string     `json:"tokenAddress" gorm:"column:token_address"`
+	Amount          string     `json:"amount" gorm:"column:amount;type:decimal(78,0)"`
+	Remaining       string     `json:"remaining" gorm:"column:remaining;type:decimal(78,0)"`
+	StakedAt        time.Time  `json:"stakedAt" gorm:"column:staked_at"`
+	UnlockTime      time.Time  `json:"unlockTime" gorm:"column:unlock_time"`
+	WithdrawnAt     *time.Time `json:"withdrawnAt" gorm:"column:withdrawn_at"` // 剩余本金归零的时间
+	TxHash          string     `json:"txHash" gorm:"column:tx_hash"`
+	BlockNumber     int64      `json:"blockNumber" gorm:"column:block_number"`
+	LogIndex        int        `json:"logIndex" gorm:"column:log_index"`
+}
+
+// TableName 指定表名
+func (StakePositionLot) TableName() string {
+	return "stake_position_lots"
+}
+
+// StakePositionDetail 质押持仓详情，数量按代币精度换算
+type StakePositionDetail struct {
+	ChainId          int64           `json:"chainId"`
+	ContractAddress  string          `json:"contractAddress"`
+	PoolId           int64           `json:"poolId"`
+	TokenAddress     string          `json:"tokenAddress"`
+	Symbol           string          `json:"symbol"`
+	Decimals         int             `json:"decimals"`
+	Status           string          `json:"status"` // locked, unlockable, withdrawn
+	Principal        decimal.Decimal `json:"principal"`
+	LockedAmount     decimal.Decimal `json:"lockedAmount"`
+	UnlockableAmount decimal.Decimal `json:"unlockableAmount"`
+	TotalStaked      decimal.Decimal `json:"totalStaked"`
+	TotalWithdrawn   decimal.Decimal `json:"totalWithdrawn"`
+	ClaimedRewards   decimal.Decimal `json:"claimedRewards"`
+	NextUnlockTime   *time.Time      `json:"nextUnlockTime"` // 最近一笔仍锁定质押的解锁时间
+	ActiveStakes     int             `json:"activeStakes"`
+	StakeCount       int             `json:"stakeCount"`
+	FirstStakedAt    time.Time       `json:"firstStakedAt"`
+	LastActivityAt   time.Time       `json:"lastActivityAt"`
+}
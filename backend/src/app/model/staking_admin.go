@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// 质押合约管理操作
+const (
+	StakingAdminAddPool       = "add_pool"
+	StakingAdminSetPoolActive = "set_pool_active"
+	StakingAdminPause         = "pause"
+	StakingAdminUnpause       = "unpause"
+	StakingAdminGrantRole     = "grant_role"
+	StakingAdminRevokeRole    = "revoke_role"
+)
+
+// 管理操作状态
+const (
+	StakingAdminStatusSimulated        = "simulated"         // 仅模拟（eth_call）成功
+	StakingAdminStatusSimulationFailed = "simulation_failed" // 模拟回滚，未提交
+	StakingAdminStatusSubmitting       = "submitting"        // 已通过模拟并提交托管交易，等待回执
+	StakingAdminStatusConfirmed        = "confirmed"         // 交易成功上链，等待索引对账
+	StakingAdminStatusReconciled       = "reconciled"        // 已在索引数据中确认状态变更
+	StakingAdminStatusReverted         = "reverted"          // 交易上链但执行失败
+	StakingAdminStatusFailed           = "failed"            // 发送失败、nonce 被占用或交易被取消
+)
+
+// StakingAdminAction 质押合约管理操作审计记录
+type StakingAdminAction struct {
+	Id              int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId         int64      `json:"chainId" gorm:"column:chain_id"`
+	ContractAddress string     `json:"contractAddress" gorm:"column:contract_address"`
+	Action          string     `json:"action" gorm:"column:action"`
+	Params          string     `json:"params" gorm:"column:params;type:jsonb"` // 请求参数
+	Operator        string     `json:"operator" gorm:"column:operator"`        // 发起操作的登录钱包（小写）
+	Sender          string     `json:"sender" gorm:"column:sender"`            // 发送交易的服务端账户
+	DryRun          bool       `json:"dryRun" gorm:"column:dry_run"`
+	CallData        string     `json:"callData" gorm:"column:call_data"`
+	Status          string     `json:"status" gorm:"column:status"`
+	ManagedTxId     *int64     `json:"managedTxId" gorm:"column:managed_tx_id"` // 托管交易ID，可查询广播与回执状态
+	TxHash          string     `json:"txHash" gorm:"column:tx_hash"`
+	Error           string     `json:"error" gorm:"column:error"`
+	Result          string     `json:"result" gorm:"column:result"` // 对账结果，如新建池子ID
+	ReconciledAt    *time.Time `json:"reconciledAt" gorm:"column:reconciled_at"`
+	CreatedAt       time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time  `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (StakingAdminAction) TableName() string {
+	return "staking_admin_actions"
+}
+
+// StakingAdminEvent 质押合约已索引的管理事件：Paused、Unpaused、RoleGranted、RoleRevoked
+type StakingAdminEvent struct {
+	Id              int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId         int64     `json:"chainId" gorm:"column:chain_id"`
+	ContractAddress string    `json:"contractAddress" gorm:"column:contract_address"` // 小写
+	EventType       string    `json:"eventType" gorm:"column:event_type"`
+	Role            string    `json:"role" gorm:"column:role"`       // 角色哈希，Paused/Unpaused 为空
+	Account         string    `json:"account" gorm:"column:account"` // 被授权/撤销的账户，或执行暂停的账户（小写）
+	Sender          string    `json:"sender" gorm:"column:sender"`   // 授权/撤销的执行账户（小写）
+	BlockNumber     int64     `json:"blockNumber" gorm:"column:block_number"`
+	LogIndex        int       `json:"logIndex" gorm:"column:log_index"`
+	TxHash          string    `json:"txHash" gorm:"column:tx_hash"`
+	BlockTime       time.Time `json:"blockTime" gorm:"column:block_time"`
+}
+
+// TableName 指定表名
+func (StakingAdminEvent) TableName() string {
+	return "staking_admin_events"
+}
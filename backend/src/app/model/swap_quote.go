@@ -0,0 +1,44 @@
+package model
+
+// SwapQuoteRequest 兑换询价请求
+type SwapQuoteRequest struct {
+	ChainId     int64  `json:"chainId" form:"chainId"`
+	TokenIn     string `json:"tokenIn" form:"tokenIn"`
+	TokenOut    string `json:"tokenOut" form:"tokenOut"`
+	Amount      string `json:"amount" form:"amount"`           // 链上整数数量：exactIn 为输入数量，exactOut 为期望输出数量
+	TradeType   string `json:"tradeType" form:"tradeType"`     // exactIn（默认）/ exactOut
+	SlippageBps int    `json:"slippageBps" form:"slippageBps"` // 滑点容忍（基点），默认 50
+	MaxHops     int    `json:"maxHops" form:"maxHops"`         // 最大跳数，默认 3
+	Recipient   string `json:"recipient" form:"recipient"`     // 接收地址，提供时生成路由合约调用数据
+	DeadlineSec int64  `json:"deadlineSec" form:"deadlineSec"` // 交易有效期（秒），默认 1200
+	Verify      bool   `json:"verify" form:"verify"`           // 是否调用路由合约 getAmountsOut/getAmountsIn 校验报价
+}
+
+// SwapQuoteHop 路由中的一跳
+type SwapQuoteHop struct {
+	PoolAddress string `json:"poolAddress"`
+	TokenIn     string `json:"tokenIn"`
+	TokenOut    string `json:"tokenOut"`
+	AmountIn    string `json:"amountIn"`
+	AmountOut   string `json:"amountOut"`
+	FeeBps      int    `json:"feeBps"`
+}
+
+// SwapQuoteResponse 兑换询价结果（数量均为链上整数）
+type SwapQuoteResponse struct {
+	TradeType       string         `json:"tradeType"`
+	AmountIn        string         `json:"amountIn"`
+	AmountOut       string         `json:"amountOut"`
+	Path            []string       `json:"path"`
+	Route           []SwapQuoteHop `json:"route"`
+	ExecutionPrice  string         `json:"executionPrice"` // 每单位 tokenIn 换得的 tokenOut（按精度换算）
+	PriceImpact     float64        `json:"priceImpact"`    // 百分比，不含手续费
+	SlippageBps     int            `json:"slippageBps"`
+	MinimumReceived string         `json:"minimumReceived,omitempty"` // exactIn：考虑滑点后的最少输出
+	MaximumSold     string         `json:"maximumSold,omitempty"`     // exactOut：考虑滑点后的最多输入
+	Router          string         `json:"router,omitempty"`
+	Calldata        string         `json:"calldata,omitempty"`
+	Deadline        int64          `json:"deadline,omitempty"`
+	Verified        *bool          `json:"verified,omitempty"`       // 与链上路由合约计算结果是否一致
+	OnchainAmounts  []string       `json:"onchainAmounts,omitempty"` // 链上 getAmountsOut/getAmountsIn 结果
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TokenPrice 代币USD历史价格
+type TokenPrice struct {
+	Id           int64           `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ChainId      int64           `json:"chainId" gorm:"column:chain_id;not null"`
+	TokenAddress string          `json:"tokenAddress" gorm:"column:token_address;not null"`
+	Symbol       string          `json:"symbol" gorm:"column:symbol"`
+	PriceUSD     decimal.Decimal `json:"priceUsd" gorm:"column:price_usd;type:decimal(38,18)"`
+	Source       string          `json:"source" gorm:"column:source"`                                 // stable, route, manual
+	Route        string          `json:"route" gorm:"column:route"`                                   // 定价经过的池子地址，逗号分隔
+	LiquidityUSD decimal.Decimal `json:"liquidityUsd" gorm:"column:liquidity_usd;type:decimal(38,2)"` // 路由瓶颈流动性
+	SnapshotTime time.Time       `json:"snapshotTime" gorm:"column:snapshot_time;not null"`
+	CreatedAt    time.Time       `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (TokenPrice) TableName() string {
+	return "token_prices"
+}
@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+// WebhookEventMerkleRoot 默克尔根更新事件类型，其余事件类型与事件浏览的统一事件类型一致
+const WebhookEventMerkleRoot = "merkle_root"
+
+// 投递状态
+const (
+	WebhookDeliveryPending   = "pending"   // 待投递或等待重试
+	WebhookDeliverySucceeded = "succeeded" // 接收方返回 2xx
+	WebhookDeliveryDead      = "dead"      // 重试耗尽或订阅已停用，进入死信队列
+)
+
+// WebhookSubscription 集成方注册的 Webhook 订阅；事件类型、池子、钱包三个条件之间为且，同一条件内多个值为或，为空不过滤
+type WebhookSubscription struct {
+	Id           int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	OwnerAddress string    `json:"ownerAddress" gorm:"column:owner_address"` // 创建者钱包地址（小写）
+	Url          string    `json:"url" gorm:"column:url"`
+	Secret       string    `json:"-" gorm:"column:secret"` // HMAC 签名密钥，仅在创建时返回
+	Description  string    `json:"description" gorm:"column:description"`
+	ChainId      int64     `json:"chainId" gorm:"column:chain_id"` // 0 表示全部链
+	EventTypes   string    `json:"-" gorm:"column:event_types"`    // 逗号分隔
+	Pools        string    `json:"-" gorm:"column:pools"`          // 逗号分隔，小写
+	Wallets      string    `json:"-" gorm:"column:wallets"`        // 逗号分隔，小写
+	IsActive     bool      `json:"isActive" gorm:"column:is_active"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+
+	EventTypeList []string `json:"eventTypes" gorm:"-"`
+	PoolList      []string `json:"pools" gorm:"-"`
+	WalletList    []string `json:"wallets" gorm:"-"`
+}
+
+// TableName 指定表名
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery 单个订阅对单个事件的投递任务
+type WebhookDelivery struct {
+	Id             int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	SubscriptionId int64      `json:"subscriptionId" gorm:"column:subscription_id"`
+	EventId        string     `json:"eventId" gorm:"column:event_id"`
+	EventType      string     `json:"eventType" gorm:"column:event_type"`
+	Payload        string     `json:"payload" gorm:"column:payload;type:jsonb"` // 序列化后的 WebhookEvent，即请求体
+	Status         string     `json:"status" gorm:"column:status"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt" gorm:"column:next_attempt_at"`
+	LastStatusCode int        `json:"lastStatusCode" gorm:"column:last_status_code"`
+	LastError      string     `json:"lastError" gorm:"column:last_error"`
+	DeliveredAt    *time.Time `json:"deliveredAt" gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryAttempt 每次投递请求的记录
+type WebhookDeliveryAttempt struct {
+	Id           int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	DeliveryId   int64     `json:"deliveryId" gorm:"column:delivery_id"`
+	Attempt      int       `json:"attempt" gorm:"column:attempt"`
+	StatusCode   int       `json:"statusCode" gorm:"column:status_code"` // 0 表示请求未得到响应
+	Error        string    `json:"error" gorm:"column:error"`
+	ResponseBody string    `json:"responseBody" gorm:"column:response_body"` // 截断后的响应内容
+	DurationMs   int64     `json:"durationMs" gorm:"column:duration_ms"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+// WebhookEvent Webhook 请求体
+type WebhookEvent struct {
+	Id         string      `json:"id"`   // 事件唯一标识，接收方可据此幂等
+	Type       string      `json:"type"` // swap, mint, burn, collect, stake, withdraw, claim, merkle_root, share_price_alert
+	ChainId    int64       `json:"chainId"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"` // ExplorerEvent、MerkleRootUpdatedEvent 或 SharePriceAlert
+
+	PoolAddress string `json:"-"` // 匹配订阅用，小写
+	UserAddress string `json:"-"` // 匹配订阅用，小写
+}
+
+// MerkleRootUpdatedEvent 空投合约 MerkleRootUpdated 事件
+type MerkleRootUpdatedEvent struct {
+	ChainId         int64     `json:"chainId"`
+	ContractAddress string    `json:"contractAddress"`
+	AirdropId       string    `json:"airdropId"`
+	MerkleRoot      string    `json:"merkleRoot"`
+	TreeVersion     uint32    `json:"treeVersion"`
+	BlockNumber     int64     `json:"blockNumber"`
+	LogIndex        int       `json:"logIndex"`
+	TxHash          string    `json:"txHash"`
+	EventTime       time.Time `json:"eventTime"`
+}
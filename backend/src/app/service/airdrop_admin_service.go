@@ -1,120 +1,119 @@
 package service
 
 import (
-    "context"
-    "crypto/ecdsa"
     "fmt"
     "math/big"
 
-    "github.com/ethereum/go-ethereum/accounts/abi/bind"
     "github.com/ethereum/go-ethereum/common"
-    "github.com/ethereum/go-ethereum/crypto"
     "github.com/mumu/cryptoSwap/src/abi"
-    "github.com/mumu/cryptoSwap/src/core/ctx"
+    "github.com/mumu/cryptoSwap/src/app/model"
+    "github.com/mumu/cryptoSwap/src/core/config"
     "github.com/mumu/cryptoSwap/src/core/log"
     "go.uber.org/zap"
 )
 
 // AirdropAdminService 负责管理员相关的链上操作
-// 注意：私钥与合约地址应从配置或安全存储加载，这里为示例硬编码
+// 签名账户来自 [airdrop] signer 配置的 [signers.<name>]，合约地址与链ID可通过 Configure 覆盖
 type AirdropAdminService struct {
-    adminPrivateKey      string
+    signerName           string
     merkleAirdropAddress string
     chainId              int64
 }
 
 func NewAirdropAdminService() *AirdropAdminService {
     return &AirdropAdminService{
-        adminPrivateKey:      "e22afa1331382e752eb5afce597290f5d14c307b613f649ae0b5c47cd84ad974", // 示例：请改为安全配置
-        merkleAirdropAddress: "0x0000000000000000000000000000000000000000",                         // 示例：替换为实际地址
-        chainId:              11155111,                                                                  // 示例：sepolia
+        signerName:           config.Conf.Airdrop.Signer,
+        merkleAirdropAddress: "0x0000000000000000000000000000000000000000", // 示例：替换为实际地址
+        chainId:              11155111,                                     // 示例：sepolia
     }
 }
 
-// Configure 允许在运行时设置链ID、合约地址与管理员私钥（留空则保持原值）
-func (s *AirdropAdminService) Configure(chainId int64, contractAddr string, adminKey string) {
+// Configure 允许在运行时设置链ID、合约地址与签名账户名称（留空则保持原值）
+func (s *AirdropAdminService) Configure(chainId int64, contractAddr string, signerName string) {
     if chainId > 0 {
         s.chainId = chainId
     }
     if contractAddr != "" {
         s.merkleAirdropAddress = contractAddr
     }
-    if adminKey != "" {
-        s.adminPrivateKey = adminKey
+    if signerName != "" {
+        s.signerName = signerName
     }
 }
 
-// UpdateMerkleRoot 发送 updateMerkleRoot(airdropId, newRoot, newVersion) 交易
-func (s *AirdropAdminService) UpdateMerkleRoot(airdropId *big.Int, newRoot common.Hash, newVersion uint32) (string, error) {
-    client := ctx.GetEvmClient(int(s.chainId))
-    if client == nil {
-        return "", fmt.Errorf("无法获取链ID=%d 的 EVM 客户端", s.chainId)
+// UpdateMerkleRoot 通过托管交易发送 updateMerkleRoot(airdropId, newRoot, newVersion)，返回可轮询的托管交易
+func (s *AirdropAdminService) UpdateMerkleRoot(airdropId *big.Int, newRoot common.Hash, newVersion uint32) (*model.ManagedTx, error) {
+    data, err := s.EncodeUpdateMerkleRootData(airdropId, newRoot, newVersion)
+    if err != nil {
+        return nil, err
     }
+    return s.submit("updateMerkleRoot", "airdrop.update_merkle_root", data)
+}
 
-    // 加载 ABI
-    am := abi.GetABIManager()
-    merkleABI, ok := am.GetABI("MerkleAirdrop")
-    if !ok {
-        return "", fmt.Errorf("MerkleAirdrop ABI 未加载，请确保已调用 abi.InitABIManager()")
+// CreateAirdrop 通过托管交易发送 createAirdrop(name, merkleRoot, totalReward, startTime, endTime, treeVersion)，
+// 空投ID由合约按 airdropCount 分配，需从 AirdropCreated 事件获取
+func (s *AirdropAdminService) CreateAirdrop(name string, merkleRoot common.Hash, totalReward *big.Int, startTime, endTime int64, treeVersion *big.Int) (*model.ManagedTx, error) {
+    data, err := encodeMerkleAirdropCall("createAirdrop", name, merkleRoot, totalReward, big.NewInt(startTime), big.NewInt(endTime), treeVersion)
+    if err != nil {
+        return nil, err
     }
+    return s.submit("createAirdrop", "airdrop.create_airdrop", data)
+}
 
-    // 私钥与发送者地址
-    pk, err := crypto.HexToECDSA(s.adminPrivateKey)
+// ActivateAirdrop 通过托管交易发送 activateAirdrop(airdropId)
+func (s *AirdropAdminService) ActivateAirdrop(airdropId *big.Int) (*model.ManagedTx, error) {
+    data, err := s.EncodeActivateAirdropData(airdropId)
     if err != nil {
-        return "", fmt.Errorf("管理员私钥无效: %v", err)
+        return nil, err
     }
-    pub := pk.Public().(*ecdsa.PublicKey)
-    sender := crypto.PubkeyToAddress(*pub)
+    return s.submit("activateAirdrop", "airdrop.activate_airdrop", data)
+}
 
-    // nonce & gas
-    nonce, err := client.PendingNonceAt(context.Background(), sender)
+// submit 使用配置的签名账户提交托管交易
+func (s *AirdropAdminService) submit(method, purpose string, data []byte) (*model.ManagedTx, error) {
+    txm, err := NewTxManagerByName(s.signerName)
     if err != nil {
-        return "", fmt.Errorf("获取 nonce 失败: %v", err)
+        return nil, fmt.Errorf("加载空投管理签名账户失败: %w", err)
     }
-    gasPrice, err := client.SuggestGasPrice(context.Background())
+    tx, err := txm.Submit(TxRequest{
+        ChainId: s.chainId,
+        To:      common.HexToAddress(s.merkleAirdropAddress),
+        Data:    data,
+        Purpose: purpose,
+    })
     if err != nil {
-        return "", fmt.Errorf("获取 gasPrice 失败: %v", err)
+        return nil, fmt.Errorf("%s 交易失败: %w", method, err)
     }
+    log.Logger.Info(method+" 已提交", zap.Int64("tx_id", tx.Id), zap.String("txHash", tx.TxHash))
+    return tx, nil
+}
 
-    // 构造 transactor
-    auth := bind.NewKeyedTransactor(pk)
-    auth.Nonce = new(big.Int).SetUint64(nonce)
-    auth.Value = big.NewInt(0)
-    auth.GasLimit = 300000
-    auth.GasPrice = gasPrice
-
-    // 绑定合约
-    contractAddr := common.HexToAddress(s.merkleAirdropAddress)
-    bound := bind.NewBoundContract(contractAddr, merkleABI, client, client, client)
+// EncodeUpdateMerkleRootData 仅编码调用数据（在需要构造裸交易或 Safe 提案时）
+func (s *AirdropAdminService) EncodeUpdateMerkleRootData(airdropId *big.Int, newRoot common.Hash, newVersion uint32) ([]byte, error) {
+    // 输入参数顺序需与函数定义一致
+    return encodeMerkleAirdropCall("updateMerkleRoot", airdropId, newRoot, newVersion)
+}
 
-    // 发送交易
-    tx, err := bound.Transact(auth, "updateMerkleRoot", airdropId, newRoot, newVersion)
-    if err != nil {
-        return "", fmt.Errorf("updateMerkleRoot 交易失败: %v", err)
-    }
-    log.Logger.Info("updateMerkleRoot 已发送", zap.String("txHash", tx.Hash().Hex()))
-    return tx.Hash().Hex(), nil
+// EncodeActivateAirdropData 编码 activateAirdrop(airdropId) 调用数据
+func (s *AirdropAdminService) EncodeActivateAirdropData(airdropId *big.Int) ([]byte, error) {
+    return encodeMerkleAirdropCall("activateAirdrop", airdropId)
 }
 
-// EncodeUpdateMerkleRootData 仅编码调用数据（在需要构造裸交易时）
-func (s *AirdropAdminService) EncodeUpdateMerkleRootData(airdropId *big.Int, newRoot common.Hash, newVersion uint32) ([]byte, error) {
+// encodeMerkleAirdropCall 按 MerkleAirdrop ABI 打包：4字节选择器 + 参数编码
+func encodeMerkleAirdropCall(name string, args ...interface{}) ([]byte, error) {
     am := abi.GetABIManager()
     merkleABI, ok := am.GetABI("MerkleAirdrop")
     if !ok {
         return nil, fmt.Errorf("MerkleAirdrop ABI 未加载")
     }
-    // 手动打包：方法签名获取 & 参数打包
-    method, exist := merkleABI.Methods["updateMerkleRoot"]
+    method, exist := merkleABI.Methods[name]
     if !exist {
-        return nil, fmt.Errorf("ABI 中未找到 updateMerkleRoot 方法")
+        return nil, fmt.Errorf("ABI 中未找到 %s 方法", name)
     }
-    // 输入参数顺序需与函数定义一致
-    packedArgs, err := method.Inputs.Pack(airdropId, newRoot, newVersion)
+    packedArgs, err := method.Inputs.Pack(args...)
     if err != nil {
         return nil, err
     }
-    // 4字节选择器 + 参数编码
-    selector := method.ID
-    data := append(selector, packedArgs...)
+    data := append(append([]byte{}, method.ID...), packedArgs...)
     return data, nil
-}
\ No newline at end of file
+}
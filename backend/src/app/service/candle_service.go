@@ -0,0 +1,445 @@
+package service
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CandleResolution K线周期
+type CandleResolution struct {
+	Name     string        // 入库名称：1m, 5m, 1h, 4h, 1d
+	UDF      string        // TradingView UDF 周期：1, 5, 60, 240, 1D
+	Duration time.Duration // 周期长度
+}
+
+// CandleResolutions 支持的K线周期（均可整除一天，按UTC对齐）
+var CandleResolutions = []CandleResolution{
+	{Name: "1m", UDF: "1", Duration: time.Minute},
+	{Name: "5m", UDF: "5", Duration: 5 * time.Minute},
+	{Name: "1h", UDF: "60", Duration: time.Hour},
+	{Name: "4h", UDF: "240", Duration: 4 * time.Hour},
+	{Name: "1d", UDF: "1D", Duration: 24 * time.Hour},
+}
+
+const candleRebuildBatchSize = 5000
+
+type CandleService struct {
+	priceSvc *PriceService
+}
+
+func NewCandleService() *CandleService {
+	return &CandleService{
+		priceSvc: NewPriceService(),
+	}
+}
+
+// ParseResolution 解析周期，兼容入库名称与 UDF 写法（D、1D）
+func (s *CandleService) ParseResolution(r string) (CandleResolution, bool) {
+	if r == "D" {
+		r = "1D"
+	}
+	for _, res := range CandleResolutions {
+		if strings.EqualFold(res.Name, r) || strings.EqualFold(res.UDF, r) {
+			return res, true
+		}
+	}
+	return CandleResolution{}, false
+}
+
+// ResolvePool 根据 UDF symbol 查找池子，symbol 格式为 池子地址 或 链ID:池子地址
+func (s *CandleService) ResolvePool(symbol string) (*model.LiquidityPool, error) {
+	var chainId int64
+	address := symbol
+	if idx := strings.Index(symbol, ":"); idx > 0 {
+		id, err := strconv.ParseInt(symbol[:idx], 10, 64)
+		if err != nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		chainId = id
+		address = symbol[idx+1:]
+	}
+
+	var pool model.LiquidityPool
+	query := ctx.Ctx.DB.Where("LOWER(pool_address) = ?", strings.ToLower(address))
+	if chainId > 0 {
+		query = query.Where("chain_id = ?", chainId)
+	}
+	if err := query.First(&pool).Error; err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// GetCandles 查询时间段内的K线；countback > 0 时至少返回该数量（向前扩展）
+func (s *CandleService) GetCandles(pool model.LiquidityPool, res CandleResolution, from, to time.Time, countback int) ([]model.PoolCandle, error) {
+	var candles []model.PoolCandle
+	if countback > 0 {
+		err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND resolution = ? AND bucket_start < ?",
+			pool.ChainId, pool.PoolAddress, res.Name, to.UTC()).
+			Order("bucket_start DESC").Limit(countback).Find(&candles).Error
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(candles, func(i, j int) bool { return candles[i].BucketStart.Before(candles[j].BucketStart) })
+		return candles, nil
+	}
+
+	err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND resolution = ? AND bucket_start >= ? AND bucket_start < ?",
+		pool.ChainId, pool.PoolAddress, res.Name, from.UTC().Truncate(res.Duration), to.UTC()).
+		Order("bucket_start ASC").Find(&candles).Error
+	return candles, err
+}
+
+// GetLastCandleTimeBefore 查询指定时间之前最近一根K线的时间（UDF no_data 的 nextTime）
+func (s *CandleService) GetLastCandleTimeBefore(pool model.LiquidityPool, res CandleResolution, before time.Time) (time.Time, bool) {
+	var candle model.PoolCandle
+	err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND resolution = ? AND bucket_start < ?",
+		pool.ChainId, pool.PoolAddress, res.Name, before.UTC()).
+		Order("bucket_start DESC").First(&candle).Error
+	if err != nil {
+		return time.Time{}, false
+	}
+	return candle.BucketStart, true
+}
+
+// SwapPrice 计算单笔Swap的成交价（token1/token0）与两侧成交数量（已按精度换算）
+func SwapPrice(pool model.LiquidityPool, e model.LiquidityPoolEvent) (price, amount0, amount1 decimal.Decimal, ok bool) {
+	a0 := new(big.Int).Sub(parseBigInt(e.Amount0In), parseBigInt(e.Amount0Out))
+	a1 := new(big.Int).Sub(parseBigInt(e.Amount1In), parseBigInt(e.Amount1Out))
+	amount0 = tokenAmount(a0.Abs(a0), pool.Token0Decimals)
+	amount1 = tokenAmount(a1.Abs(a1), pool.Token1Decimals)
+	if !amount0.IsPositive() || !amount1.IsPositive() {
+		return decimal.Zero, decimal.Zero, decimal.Zero, false
+	}
+	return amount1.DivRound(amount0, 18), amount0, amount1, true
+}
+
+type candleKey struct {
+	resolution string
+	bucket     int64
+}
+
+// AggregateSwaps 将按链上顺序排列的Swap事件聚合为各周期K线。
+// usdOf 返回单笔成交的USD交易量，可为 nil。
+func (s *CandleService) AggregateSwaps(pool model.LiquidityPool, events []model.LiquidityPoolEvent, usdOf func(e model.LiquidityPoolEvent) decimal.Decimal) []*model.PoolCandle {
+	candles := make(map[candleKey]*model.PoolCandle)
+	var order []candleKey
+	for _, e := range events {
+		if e.EventType != "Swap" {
+			continue
+		}
+		price, amount0, amount1, ok := SwapPrice(pool, e)
+		if !ok {
+			continue
+		}
+		volumeUSD := decimal.Zero
+		if usdOf != nil {
+			volumeUSD = usdOf(e)
+		}
+		at := eventTime(e)
+		for _, res := range CandleResolutions {
+			bucket := at.Truncate(res.Duration)
+			key := candleKey{resolution: res.Name, bucket: bucket.Unix()}
+			c, exists := candles[key]
+			if !exists {
+				c = &model.PoolCandle{
+					ChainId:     pool.ChainId,
+					PoolAddress: pool.PoolAddress,
+					Resolution:  res.Name,
+					BucketStart: bucket,
+					Open:        price,
+					High:        price,
+					Low:         price,
+				}
+				candles[key] = c
+				order = append(order, key)
+			}
+			if price.GreaterThan(c.High) {
+				c.High = price
+			}
+			if price.LessThan(c.Low) {
+				c.Low = price
+			}
+			c.Close = price
+			c.Volume0 = c.Volume0.Add(amount0)
+			c.Volume1 = c.Volume1.Add(amount1)
+			c.VolumeUSD = c.VolumeUSD.Add(volumeUSD)
+			c.TradeCount++
+		}
+	}
+
+	result := make([]*model.PoolCandle, 0, len(order))
+	for _, key := range order {
+		c := candles[key]
+		c.VolumeUSD = c.VolumeUSD.Round(2)
+		result = append(result, c)
+	}
+	return result
+}
+
+// MergeCandles 将新聚合的K线合并进已有K线：保留原开盘价，更新高低收与成交量
+func (s *CandleService) MergeCandles(tx *gorm.DB, candles []*model.PoolCandle) error {
+	for _, c := range candles {
+		err := tx.Exec(`
+			INSERT INTO pool_candles (chain_id, pool_address, resolution, bucket_start, open, high, low, close,
+				volume0, volume1, volume_usd, trade_count, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+			ON CONFLICT (chain_id, pool_address, resolution, bucket_start)
+			DO UPDATE SET
+				high = GREATEST(pool_candles.high, EXCLUDED.high),
+				low = LEAST(pool_candles.low, EXCLUDED.low),
+				close = EXCLUDED.close,
+				volume0 = pool_candles.volume0 + EXCLUDED.volume0,
+				volume1 = pool_candles.volume1 + EXCLUDED.volume1,
+				volume_usd = pool_candles.volume_usd + EXCLUDED.volume_usd,
+				trade_count = pool_candles.trade_count + EXCLUDED.trade_count,
+				updated_at = NOW()
+		`, c.ChainId, c.PoolAddress, c.Resolution, c.BucketStart, c.Open, c.High, c.Low, c.Close,
+			c.Volume0, c.Volume1, c.VolumeUSD, c.TradeCount).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplySwaps 增量更新池子K线，events 须已在同一事务中写入。
+// 池子记录保存已计入K线的最后一笔Swap（区块号, 日志序号），重扫区块写入的重复事件不再累加；
+// 早于游标的新事件或早于已有K线的事件（回补历史区块）只标记重建起点，由 RebuildPendingCandles 在索引事务之外重建
+func (s *CandleService) ApplySwaps(tx *gorm.DB, pool model.LiquidityPool, events []model.LiquidityPoolEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	// 锁定池子记录，与重建串行
+	var locked model.LiquidityPool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pool.Id).First(&locked).Error; err != nil {
+		return err
+	}
+	fresh, stale := splitSwapsByCursor(events, locked.CandleBlock, locked.CandleLogIndex)
+
+	var rebuildFrom *time.Time
+	if len(stale) > 0 {
+		from, err := s.firstUnappliedSwap(tx, pool, stale)
+		if err != nil {
+			return err
+		}
+		rebuildFrom = from
+	}
+	updates := map[string]interface{}{}
+	if len(fresh) > 0 {
+		last := fresh[len(fresh)-1]
+		updates["candle_block"] = last.BlockNumber
+		updates["candle_log_index"] = last.LogIndex
+
+		earliest := eventTime(fresh[0])
+		var latest *time.Time
+		if err := tx.Model(&model.PoolCandle{}).
+			Where("chain_id = ? AND pool_address = ? AND resolution = ?", pool.ChainId, pool.PoolAddress, CandleResolutions[0].Name).
+			Select("MAX(bucket_start)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		if rebuildFrom == nil && latest != nil && earliest.Truncate(CandleResolutions[0].Duration).Before(latest.UTC()) {
+			rebuildFrom = &earliest
+		}
+	}
+
+	if rebuildFrom != nil {
+		// 重建覆盖起点之后的全部事件，本批次无需再合并
+		updates["candle_rebuild_from"] = gorm.Expr("LEAST(COALESCE(candle_rebuild_from, ?), ?)", *rebuildFrom, *rebuildFrom)
+	} else if len(fresh) > 0 {
+		prices, err := s.priceSvc.GetTokenPrices(pool.ChainId)
+		if err != nil {
+			prices = map[string]*TokenPriceQuote{}
+		}
+		candles := s.AggregateSwaps(pool, fresh, func(e model.LiquidityPoolEvent) decimal.Decimal {
+			return s.priceSvc.SwapVolumeUSD(pool, e, prices)
+		})
+		if err := s.MergeCandles(tx, candles); err != nil {
+			return err
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return tx.Model(&model.LiquidityPool{}).Where("id = ?", pool.Id).Updates(updates).Error
+}
+
+// splitSwapsByCursor 按链上顺序排序后以游标切分：fresh 为晚于游标的事件（同一位置只保留一次），stale 为不晚于游标的事件
+func splitSwapsByCursor(events []model.LiquidityPoolEvent, block int64, logIndex int) (fresh, stale []model.LiquidityPoolEvent) {
+	sortEventsByChainOrder(events)
+	for _, e := range events {
+		if e.BlockNumber < block || e.BlockNumber == block && e.LogIndex <= logIndex {
+			stale = append(stale, e)
+			continue
+		}
+		fresh = append(fresh, e)
+		block, logIndex = e.BlockNumber, e.LogIndex
+	}
+	return fresh, stale
+}
+
+// firstUnappliedSwap 不晚于游标的事件中，本事务之前没有相同 (tx_hash, log_index) 记录的最早一笔的时间；
+// 均为重扫写入的重复事件时返回 nil
+func (s *CandleService) firstUnappliedSwap(tx *gorm.DB, pool model.LiquidityPool, stale []model.LiquidityPoolEvent) (*time.Time, error) {
+	type swapKey struct {
+		TxHash   string
+		LogIndex int
+	}
+	inBatch := make(map[swapKey]int64)
+	for _, e := range stale {
+		inBatch[swapKey{e.TxHash, e.LogIndex}]++
+	}
+	var rows []struct {
+		TxHash   string
+		LogIndex int
+		N        int64
+	}
+	if err := tx.Model(&model.LiquidityPoolEvent{}).
+		Select("tx_hash, log_index, COUNT(*) AS n").
+		Where("chain_id = ? AND pool_address = ? AND event_type = 'Swap' AND block_number BETWEEN ? AND ?",
+			pool.ChainId, pool.PoolAddress, stale[0].BlockNumber, stale[len(stale)-1].BlockNumber).
+		Group("tx_hash, log_index").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[swapKey]bool)
+	for _, r := range rows {
+		key := swapKey{r.TxHash, r.LogIndex}
+		applied[key] = r.N > inBatch[key]
+	}
+	for _, e := range stale {
+		if !applied[swapKey{e.TxHash, e.LogIndex}] {
+			at := eventTime(e)
+			return &at, nil
+		}
+	}
+	return nil, nil
+}
+
+// RebuildPendingCandles 重建链上已标记重建的池子K线，每个池子单独一个事务并锁定池子记录；
+// 失败时保留标记，下次调用重试
+func (s *CandleService) RebuildPendingCandles(chainId int64) error {
+	var pools []model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND candle_rebuild_from IS NOT NULL", chainId).Find(&pools).Error; err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		err := ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+			var locked model.LiquidityPool
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pool.Id).First(&locked).Error; err != nil {
+				return err
+			}
+			if locked.CandleRebuildFrom == nil {
+				return nil
+			}
+			if err := s.RebuildPoolCandles(tx, locked, *locked.CandleRebuildFrom); err != nil {
+				return err
+			}
+			return tx.Model(&model.LiquidityPool{}).Where("id = ?", locked.Id).Update("candle_rebuild_from", nil).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildPoolCandles 从原始Swap事件重建池子自 from 所在UTC日起的全部K线。
+// 回补可能重复写入事件，这里按 (tx_hash, log_index, 数量) 去重；USD交易量使用当时的历史价格。
+func (s *CandleService) RebuildPoolCandles(tx *gorm.DB, pool model.LiquidityPool, from time.Time) error {
+	start := from.UTC().Truncate(24 * time.Hour)
+	if err := tx.Where("chain_id = ? AND pool_address = ? AND bucket_start >= ?", pool.ChainId, pool.PoolAddress, start).
+		Delete(&model.PoolCandle{}).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	series0 := s.priceSvc.LoadPriceSeries(pool.ChainId, pool.Token0Address, start, now)
+	series1 := s.priceSvc.LoadPriceSeries(pool.ChainId, pool.Token1Address, start, now)
+	usdOf := func(e model.LiquidityPoolEvent) decimal.Decimal {
+		at := eventTime(e)
+		if p, ok := series0.At(at); ok {
+			vol0 := new(big.Int).Add(parseBigInt(e.Amount0In), parseBigInt(e.Amount0Out))
+			return tokenAmount(vol0, pool.Token0Decimals).Mul(p)
+		}
+		if p, ok := series1.At(at); ok {
+			vol1 := new(big.Int).Add(parseBigInt(e.Amount1In), parseBigInt(e.Amount1Out))
+			return tokenAmount(vol1, pool.Token1Decimals).Mul(p)
+		}
+		return decimal.Zero
+	}
+
+	// 分批读取，跨批次在内存中累积K线后一次写入
+	var all []*model.PoolCandle
+	index := make(map[candleKey]*model.PoolCandle)
+	for offset := 0; ; offset += candleRebuildBatchSize {
+		var events []model.LiquidityPoolEvent
+		err := tx.Raw(`
+			SELECT * FROM (
+				SELECT DISTINCT ON (tx_hash, log_index, amount0_in, amount1_in, amount0_out, amount1_out) *
+				FROM liquidity_pool_events
+				WHERE chain_id = ? AND pool_address = ? AND event_type = 'Swap'
+				  AND COALESCE(block_time, created_at) >= ?
+				ORDER BY tx_hash, log_index, amount0_in, amount1_in, amount0_out, amount1_out, id
+			) e
+			ORDER BY block_number, log_index, id
+			LIMIT ? OFFSET ?
+		`, pool.ChainId, pool.PoolAddress, start, candleRebuildBatchSize, offset).Scan(&events).Error
+		if err != nil {
+			return err
+		}
+		for _, c := range s.AggregateSwaps(pool, events, usdOf) {
+			key := candleKey{resolution: c.Resolution, bucket: c.BucketStart.Unix()}
+			existing, ok := index[key]
+			if !ok {
+				index[key] = c
+				all = append(all, c)
+				continue
+			}
+			if c.High.GreaterThan(existing.High) {
+				existing.High = c.High
+			}
+			if c.Low.LessThan(existing.Low) {
+				existing.Low = c.Low
+			}
+			existing.Close = c.Close
+			existing.Volume0 = existing.Volume0.Add(c.Volume0)
+			existing.Volume1 = existing.Volume1.Add(c.Volume1)
+			existing.VolumeUSD = existing.VolumeUSD.Add(c.VolumeUSD)
+			existing.TradeCount += c.TradeCount
+		}
+		if len(events) < candleRebuildBatchSize {
+			break
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(all, 200).Error
+}
+
+// eventTime 事件的区块时间（UTC），早期未记录区块时间的事件使用入库时间
+func eventTime(e model.LiquidityPoolEvent) time.Time {
+	if !e.BlockTime.IsZero() {
+		return e.BlockTime.UTC()
+	}
+	return e.CreatedAt.UTC()
+}
+
+// sortEventsByChainOrder 按 (区块号, 日志序号) 排序
+func sortEventsByChainOrder(events []model.LiquidityPoolEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].BlockNumber != events[j].BlockNumber {
+			return events[i].BlockNumber < events[j].BlockNumber
+		}
+		return events[i].LogIndex < events[j].LogIndex
+	})
+}
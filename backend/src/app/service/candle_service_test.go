@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+)
+
+func testSwap(block int64, logIndex int) model.LiquidityPoolEvent {
+	return model.LiquidityPoolEvent{BlockNumber: block, LogIndex: logIndex, EventType: "Swap"}
+}
+
+func swapPositions(events []model.LiquidityPoolEvent) [][2]int64 {
+	res := make([][2]int64, 0, len(events))
+	for _, e := range events {
+		res = append(res, [2]int64{e.BlockNumber, int64(e.LogIndex)})
+	}
+	return res
+}
+
+func TestSplitSwapsByCursor(t *testing.T) {
+	cases := []struct {
+		name         string
+		events       []model.LiquidityPoolEvent
+		block        int64
+		logIndex     int
+		fresh, stale [][2]int64
+	}{
+		{
+			name:   "尚无游标",
+			events: []model.LiquidityPoolEvent{testSwap(10, 2), testSwap(10, 0)},
+			fresh:  [][2]int64{{10, 0}, {10, 2}},
+		},
+		{
+			// 重扫 [10, 12]，游标停在 11:3
+			name:     "重扫区块",
+			events:   []model.LiquidityPoolEvent{testSwap(12, 1), testSwap(10, 5), testSwap(11, 3), testSwap(11, 4)},
+			block:    11,
+			logIndex: 3,
+			fresh:    [][2]int64{{11, 4}, {12, 1}},
+			stale:    [][2]int64{{10, 5}, {11, 3}},
+		},
+		{
+			name:     "批次内重复只计一次",
+			events:   []model.LiquidityPoolEvent{testSwap(20, 1), testSwap(20, 1), testSwap(21, 0)},
+			block:    19,
+			logIndex: 9,
+			fresh:    [][2]int64{{20, 1}, {21, 0}},
+			stale:    [][2]int64{{20, 1}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fresh, stale := splitSwapsByCursor(c.events, c.block, c.logIndex)
+			if got := swapPositions(fresh); !equalPositions(got, c.fresh) {
+				t.Fatalf("fresh = %v, want %v", got, c.fresh)
+			}
+			if got := swapPositions(stale); !equalPositions(got, c.stale) {
+				t.Fatalf("stale = %v, want %v", got, c.stale)
+			}
+		})
+	}
+}
+
+func equalPositions(a, b [][2]int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultEventBusPrefix    = "alanswap:events"
+	defaultEventBusMaxLen    = 100000
+	defaultEventBusBatchSize = 500
+	// eventBusDedupeTTL 发布去重标记的保留时间，需远大于 outbox 记录从发布到标记完成的最长间隔
+	eventBusDedupeTTL = 7 * 24 * time.Hour
+	// eventBusClaimIdle 消费者处理中断后，待确认消息空闲超过该时间会被组内其他消费者接管
+	eventBusClaimIdle = time.Minute
+	eventBusReadBlock = 5 * time.Second
+)
+
+// eventBusPublishScript 以去重标记保证同一 outbox 记录只写入 stream 一次：
+// 中继在 XADD 成功、回写 published_at 之前中断时，重试会直接返回首次写入的消息ID
+var eventBusPublishScript = redis.NewScript(`
+local existing = redis.call('GET', KEYS[2])
+if existing then
+	return existing
+end
+local id = redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[1], '*', 'id', ARGV[2], 'type', ARGV[3], 'version', ARGV[4], 'data', ARGV[5])
+redis.call('SET', KEYS[2], id, 'EX', ARGV[6])
+return id
+`)
+
+// eventBusStreams 事件总线使用的 Redis Streams 操作，默认实现为 redisEventBusStreams
+type eventBusStreams interface {
+	// Publish 写入一条 outbox 记录，dedupeKey 已存在时不重复写入并返回首次写入的消息ID
+	Publish(c context.Context, stream, dedupeKey string, maxLen int64, row model.EventOutbox) (string, error)
+	// CreateGroup 创建消费组（stream 不存在时一并创建），消费组已存在时不报错
+	CreateGroup(c context.Context, stream, group string) error
+	// ReadGroup 以消费组读取新消息，超时无消息时返回 redis.Nil
+	ReadGroup(c context.Context, group, consumer string, streams []string, count int64, block time.Duration) ([]redis.XStream, error)
+	Pending(c context.Context, stream, group string, count int64) ([]redis.XPendingExt, error)
+	Claim(c context.Context, stream, group, consumer string, minIdle time.Duration, ids []string) ([]redis.XMessage, error)
+	Ack(c context.Context, stream, group, id string) error
+}
+
+// redisEventBusStreams 基于 ctx.Ctx.Redis 的 eventBusStreams
+type redisEventBusStreams struct{}
+
+func (redisEventBusStreams) Publish(c context.Context, stream, dedupeKey string, maxLen int64, row model.EventOutbox) (string, error) {
+	return eventBusPublishScript.Run(c, ctx.Ctx.Redis, []string{stream, dedupeKey},
+		maxLen, row.EventId, row.EventType, row.SchemaVersion, row.Payload, int64(eventBusDedupeTTL/time.Second),
+	).Text()
+}
+
+func (redisEventBusStreams) CreateGroup(c context.Context, stream, group string) error {
+	// 新建的消费组从 stream 开头读取，不丢失建组前已发布的事件
+	err := ctx.Ctx.Redis.XGroupCreateMkStream(c, stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func (redisEventBusStreams) ReadGroup(c context.Context, group, consumer string, streams []string, count int64, block time.Duration) ([]redis.XStream, error) {
+	args := make([]string, 0, len(streams)*2)
+	args = append(args, streams...)
+	for range streams {
+		args = append(args, ">")
+	}
+	return ctx.Ctx.Redis.XReadGroup(c, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  args,
+		Count:    count,
+		Block:    block,
+	}).Result()
+}
+
+func (redisEventBusStreams) Pending(c context.Context, stream, group string, count int64) ([]redis.XPendingExt, error) {
+	return ctx.Ctx.Redis.XPendingExt(c, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+}
+
+func (redisEventBusStreams) Claim(c context.Context, stream, group, consumer string, minIdle time.Duration, ids []string) ([]redis.XMessage, error) {
+	return ctx.Ctx.Redis.XClaim(c, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+}
+
+func (redisEventBusStreams) Ack(c context.Context, stream, group, id string) error {
+	return ctx.Ctx.Redis.XAck(c, stream, group, id).Err()
+}
+
+type EventBusService struct {
+	bus eventBusStreams
+}
+
+func NewEventBusService() *EventBusService {
+	return &EventBusService{bus: redisEventBusStreams{}}
+}
+
+// EventBusEnabled 是否启用事件总线
+func EventBusEnabled() bool {
+	return config.Conf.EventBus.Enabled
+}
+
+// EventBusStream 事件类型对应的 stream 名
+func EventBusStream(eventType string) string {
+	prefix := config.Conf.EventBus.StreamPrefix
+	if prefix == "" {
+		prefix = defaultEventBusPrefix
+	}
+	return prefix + ":" + eventType
+}
+
+func eventBusDedupeKey(eventId string) string {
+	prefix := config.Conf.EventBus.StreamPrefix
+	if prefix == "" {
+		prefix = defaultEventBusPrefix
+	}
+	return prefix + ":published:" + eventId
+}
+
+// BusEventId 事件唯一标识：<eventType>:<chainId>:<txHash>:<logIndex>
+func BusEventId(e model.ExplorerEvent) string {
+	return fmt.Sprintf("%s:%d:%s:%d", e.EventType, e.ChainId, strings.ToLower(e.TxHash), e.LogIndex)
+}
+
+// EnqueueOutbox 在索引事务内写入 outbox，随事务一起提交或回滚；未启用事件总线时不写入
+func EnqueueOutbox(tx *gorm.DB, events []model.ExplorerEvent) error {
+	if !EventBusEnabled() || len(events) == 0 {
+		return nil
+	}
+	rows := make([]model.EventOutbox, 0, len(events))
+	for _, e := range events {
+		row, err := outboxRow(e)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+	// 重新索引同一区块时 event_id 冲突，保留首次写入的记录
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).CreateInBatches(&rows, 100).Error
+}
+
+// outboxRow 将事件封装为 outbox 记录，payload 为对外发布的 BusEvent
+func outboxRow(e model.ExplorerEvent) (model.EventOutbox, error) {
+	id := BusEventId(e)
+	payload, err := json.Marshal(model.BusEvent{
+		Schema:     model.BusEventSchema,
+		Version:    model.BusEventSchemaVersion,
+		Id:         id,
+		Type:       e.EventType,
+		ChainId:    e.ChainId,
+		OccurredAt: e.EventTime,
+		Data:       e,
+	})
+	if err != nil {
+		return model.EventOutbox{}, err
+	}
+	return model.EventOutbox{
+		EventId:       id,
+		EventType:     e.EventType,
+		ChainId:       e.ChainId,
+		SchemaVersion: model.BusEventSchemaVersion,
+		Payload:       string(payload),
+	}, nil
+}
+
+// PublishPending 按 id 顺序发布一批未发布的 outbox 记录，返回成功发布的条数。
+// 记录以 FOR UPDATE SKIP LOCKED 锁定，多个中继实例并行时不会重复领取；
+// 单条发布失败时记录原因并结束本批，保证同一 stream 内的顺序
+func (s *EventBusService) PublishPending(batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultEventBusBatchSize
+	}
+	maxLen := config.Conf.EventBus.MaxLen
+	if maxLen <= 0 {
+		maxLen = defaultEventBusMaxLen
+	}
+
+	published := 0
+	err := ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		var rows []model.EventOutbox
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL").
+			Order("id").Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+
+		bg := context.Background()
+		for _, row := range rows {
+			streamId, err := s.publish(bg, row, maxLen)
+			if err != nil {
+				log.Logger.Error("发布事件到 Redis Stream 失败", zap.String("event_id", row.EventId), zap.Error(err))
+				if uErr := tx.Model(&model.EventOutbox{}).Where("id = ?", row.Id).Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": err.Error(),
+				}).Error; uErr != nil {
+					return uErr
+				}
+				return nil
+			}
+			now := time.Now()
+			if err := tx.Model(&model.EventOutbox{}).Where("id = ?", row.Id).Updates(map[string]interface{}{
+				"published_at": now,
+				"stream_id":    streamId,
+			}).Error; err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+// publish 将一条 outbox 记录写入对应事件类型的 stream
+func (s *EventBusService) publish(c context.Context, row model.EventOutbox, maxLen int64) (string, error) {
+	return s.bus.Publish(c, EventBusStream(row.EventType), eventBusDedupeKey(row.EventId), maxLen, row)
+}
+
+// EventBusHandler 处理一条事件，返回错误时消息保持待确认状态，稍后重新投递
+type EventBusHandler func(event model.BusEvent) error
+
+// EventBusConsumer 基于消费组的 Redis Streams 消费者：同组内的消费者分摊消息，
+// 处理成功后 XACK，处理中断的消息空闲超时后由组内消费者重新领取，即至少一次投递
+type EventBusConsumer struct {
+	group    string
+	consumer string
+	streams  []string
+	handler  EventBusHandler
+	bus      eventBusStreams
+}
+
+// NewEventBusConsumer types 为空时订阅全部事件类型
+func NewEventBusConsumer(group, consumer string, types []string, handler EventBusHandler) *EventBusConsumer {
+	if len(types) == 0 {
+		types = ExplorerEventTypes()
+	}
+	streams := make([]string, 0, len(types))
+	for _, t := range types {
+		streams = append(streams, EventBusStream(t))
+	}
+	return &EventBusConsumer{group: group, consumer: consumer, streams: streams, handler: handler, bus: redisEventBusStreams{}}
+}
+
+// Run 阻塞消费直到 c 结束
+func (s *EventBusConsumer) Run(c context.Context) error {
+	for _, stream := range s.streams {
+		if err := s.bus.CreateGroup(c, stream, s.group); err != nil {
+			return err
+		}
+	}
+
+	lastClaim := time.Time{}
+	for c.Err() == nil {
+		if time.Since(lastClaim) >= eventBusClaimIdle {
+			s.claimStale(c)
+			lastClaim = time.Now()
+		}
+
+		res, err := s.bus.ReadGroup(c, s.group, s.consumer, s.streams, 100, eventBusReadBlock)
+		if err != nil {
+			if errors.Is(err, redis.Nil) || c.Err() != nil {
+				continue
+			}
+			log.Logger.Error("读取事件总线失败", zap.String("group", s.group), zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		for _, stream := range res {
+			s.handle(c, stream.Stream, stream.Messages)
+		}
+	}
+	return nil
+}
+
+// claimStale 接管组内空闲超时的待确认消息并重新处理
+func (s *EventBusConsumer) claimStale(c context.Context) {
+	for _, stream := range s.streams {
+		pending, err := s.bus.Pending(c, stream, s.group, 100)
+		if err != nil {
+			log.Logger.Warn("查询待确认事件失败", zap.String("stream", stream), zap.Error(err))
+			continue
+		}
+		ids := make([]string, 0, len(pending))
+		for _, p := range pending {
+			if p.Idle >= eventBusClaimIdle {
+				ids = append(ids, p.ID)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		msgs, err := s.bus.Claim(c, stream, s.group, s.consumer, eventBusClaimIdle, ids)
+		if err != nil {
+			log.Logger.Warn("接管待确认事件失败", zap.String("stream", stream), zap.Error(err))
+			continue
+		}
+		s.handle(c, stream, msgs)
+	}
+}
+
+func (s *EventBusConsumer) handle(c context.Context, stream string, msgs []redis.XMessage) {
+	for _, msg := range msgs {
+		data, _ := msg.Values["data"].(string)
+		var event model.BusEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			// 无法解析的消息重试也不会成功，直接确认避免反复投递
+			log.Logger.Error("解析事件总线消息失败", zap.String("stream", stream), zap.String("id", msg.ID), zap.Error(err))
+			s.bus.Ack(c, stream, s.group, msg.ID)
+			continue
+		}
+		if err := s.handler(event); err != nil {
+			log.Logger.Warn("处理事件总线消息失败，等待重新投递", zap.String("stream", stream), zap.String("event_id", event.Id), zap.Error(err))
+			continue
+		}
+		if err := s.bus.Ack(c, stream, s.group, msg.ID); err != nil {
+			log.Logger.Warn("确认事件总线消息失败", zap.String("stream", stream), zap.String("id", msg.ID), zap.Error(err))
+		}
+	}
+}
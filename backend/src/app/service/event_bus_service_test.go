@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mumu/cryptoSwap/src/app/model"
+)
+
+// memStreams 内存中的 eventBusStreams，按 Redis Streams 消费组语义维护待确认列表，空闲时间按 now 计算
+type memStreams struct {
+	mu      sync.Mutex
+	now     time.Time
+	seq     int64
+	streams map[string][]redis.XMessage
+	dedupe  map[string]string
+	groups  map[string]*memGroup
+}
+
+type memGroup struct {
+	delivered int // 已投递到的消息下标
+	pending   map[string]*memPending
+}
+
+type memPending struct {
+	consumer    string
+	deliveredAt time.Time
+	count       int64
+}
+
+func newMemStreams() *memStreams {
+	return &memStreams{
+		now:     time.Unix(1700000000, 0),
+		streams: map[string][]redis.XMessage{},
+		dedupe:  map[string]string{},
+		groups:  map[string]*memGroup{},
+	}
+}
+
+func (m *memStreams) advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
+
+func (m *memStreams) group(stream, group string) *memGroup {
+	key := stream + "|" + group
+	g := m.groups[key]
+	if g == nil {
+		g = &memGroup{pending: map[string]*memPending{}}
+		m.groups[key] = g
+	}
+	return g
+}
+
+func (m *memStreams) add(stream string, values map[string]interface{}) string {
+	m.seq++
+	id := fmt.Sprintf("%d-0", m.seq)
+	m.streams[stream] = append(m.streams[stream], redis.XMessage{ID: id, Values: values})
+	return id
+}
+
+func (m *memStreams) Publish(c context.Context, stream, dedupeKey string, maxLen int64, row model.EventOutbox) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id, ok := m.dedupe[dedupeKey]; ok {
+		return id, nil
+	}
+	id := m.add(stream, map[string]interface{}{
+		"id": row.EventId, "type": row.EventType, "version": fmt.Sprint(row.SchemaVersion), "data": row.Payload,
+	})
+	m.dedupe[dedupeKey] = id
+	return id, nil
+}
+
+func (m *memStreams) CreateGroup(c context.Context, stream, group string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.group(stream, group)
+	return nil
+}
+
+func (m *memStreams) ReadGroup(c context.Context, group, consumer string, streams []string, count int64, block time.Duration) ([]redis.XStream, error) {
+	m.mu.Lock()
+	var res []redis.XStream
+	for _, stream := range streams {
+		g := m.group(stream, group)
+		msgs := m.streams[stream]
+		var batch []redis.XMessage
+		for g.delivered < len(msgs) && int64(len(batch)) < count {
+			msg := msgs[g.delivered]
+			g.delivered++
+			g.pending[msg.ID] = &memPending{consumer: consumer, deliveredAt: m.now, count: 1}
+			batch = append(batch, msg)
+		}
+		if len(batch) > 0 {
+			res = append(res, redis.XStream{Stream: stream, Messages: batch})
+		}
+	}
+	m.mu.Unlock()
+	if len(res) == 0 {
+		// 模拟阻塞读取超时
+		select {
+		case <-c.Done():
+			return nil, c.Err()
+		case <-time.After(time.Millisecond):
+		}
+		return nil, redis.Nil
+	}
+	return res, nil
+}
+
+func (m *memStreams) Pending(c context.Context, stream, group string, count int64) ([]redis.XPendingExt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g := m.group(stream, group)
+	var res []redis.XPendingExt
+	for id, p := range g.pending {
+		res = append(res, redis.XPendingExt{ID: id, Consumer: p.consumer, Idle: m.now.Sub(p.deliveredAt), RetryCount: p.count})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	if int64(len(res)) > count {
+		res = res[:count]
+	}
+	return res, nil
+}
+
+func (m *memStreams) Claim(c context.Context, stream, group, consumer string, minIdle time.Duration, ids []string) ([]redis.XMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g := m.group(stream, group)
+	var res []redis.XMessage
+	for _, id := range ids {
+		p := g.pending[id]
+		if p == nil || m.now.Sub(p.deliveredAt) < minIdle {
+			continue
+		}
+		p.consumer, p.deliveredAt = consumer, m.now
+		p.count++
+		for _, msg := range m.streams[stream] {
+			if msg.ID == id {
+				res = append(res, msg)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (m *memStreams) Ack(c context.Context, stream, group, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.group(stream, group).pending, id)
+	return nil
+}
+
+func (m *memStreams) pendingCount(stream, group string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.group(stream, group).pending)
+}
+
+func testBusEvent(eventType string, logIndex int) model.ExplorerEvent {
+	return model.ExplorerEvent{
+		Source:      "liquidity",
+		EventType:   eventType,
+		ChainId:     11155111,
+		BlockNumber: 100,
+		LogIndex:    logIndex,
+		TxHash:      "0xABCDEF",
+		EventTime:   time.Unix(1700000000, 0).UTC(),
+	}
+}
+
+func publishTestEvents(t *testing.T, svc *EventBusService, events ...model.ExplorerEvent) []model.EventOutbox {
+	t.Helper()
+	rows := make([]model.EventOutbox, 0, len(events))
+	for _, e := range events {
+		row, err := outboxRow(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := svc.publish(context.Background(), row, defaultEventBusMaxLen); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func newTestConsumer(bus eventBusStreams, group, consumer string, types []string, handler EventBusHandler) *EventBusConsumer {
+	c := NewEventBusConsumer(group, consumer, types, handler)
+	c.bus = bus
+	return c
+}
+
+func TestEventBusPublishDedupe(t *testing.T) {
+	bus := newMemStreams()
+	svc := &EventBusService{bus: bus}
+
+	row, err := outboxRow(testBusEvent(model.ExplorerEventSwap, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.EventId != "swap:11155111:0xabcdef:3" {
+		t.Fatalf("event_id = %s", row.EventId)
+	}
+	first, err := svc.publish(context.Background(), row, defaultEventBusMaxLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 中继在回写 published_at 前中断，重试发布同一记录
+	second, err := svc.publish(context.Background(), row, defaultEventBusMaxLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("重复发布得到不同的消息ID: %s, %s", first, second)
+	}
+	stream := EventBusStream(model.ExplorerEventSwap)
+	if stream != defaultEventBusPrefix+":swap" {
+		t.Fatalf("stream = %s", stream)
+	}
+	if n := len(bus.streams[stream]); n != 1 {
+		t.Fatalf("stream 中有 %d 条消息，want 1", n)
+	}
+}
+
+func TestEventBusConsumeAndAck(t *testing.T) {
+	bus := newMemStreams()
+	svc := &EventBusService{bus: bus}
+	types := []string{model.ExplorerEventSwap, model.ExplorerEventMint}
+	rows := publishTestEvents(t, svc, testBusEvent(model.ExplorerEventSwap, 1), testBusEvent(model.ExplorerEventMint, 2))
+
+	received := make(chan model.BusEvent, len(rows))
+	consumer := newTestConsumer(bus, "g1", "c1", types, func(event model.BusEvent) error {
+		received <- event
+		return nil
+	})
+	c, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(c) }()
+
+	got := map[string]model.BusEvent{}
+	for len(got) < len(rows) {
+		select {
+		case e := <-received:
+			got[e.Id] = e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("只收到 %d 条事件", len(got))
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range rows {
+		e, ok := got[row.EventId]
+		if !ok {
+			t.Fatalf("未收到事件 %s", row.EventId)
+		}
+		if e.Schema != model.BusEventSchema || e.Version != model.BusEventSchemaVersion || e.Type != row.EventType || e.Data.LogIndex == 0 {
+			t.Fatalf("事件内容不符: %+v", e)
+		}
+	}
+	for _, tp := range types {
+		if n := bus.pendingCount(EventBusStream(tp), "g1"); n != 0 {
+			t.Fatalf("%s 仍有 %d 条待确认消息", tp, n)
+		}
+	}
+}
+
+func TestEventBusRedeliverPending(t *testing.T) {
+	bus := newMemStreams()
+	svc := &EventBusService{bus: bus}
+	types := []string{model.ExplorerEventSwap}
+	stream := EventBusStream(model.ExplorerEventSwap)
+	rows := publishTestEvents(t, svc, testBusEvent(model.ExplorerEventSwap, 1), testBusEvent(model.ExplorerEventSwap, 2))
+
+	// c1 处理第一条失败，消息保持待确认
+	var handled []string
+	failing := newTestConsumer(bus, "g1", "c1", types, func(event model.BusEvent) error {
+		handled = append(handled, event.Id)
+		if event.Id == rows[0].EventId {
+			return errors.New("下游不可用")
+		}
+		return nil
+	})
+	bg := context.Background()
+	res, err := bus.ReadGroup(bg, "g1", "c1", failing.streams, 100, eventBusReadBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range res {
+		failing.handle(bg, s.Stream, s.Messages)
+	}
+	if len(handled) != 2 {
+		t.Fatalf("处理了 %d 条，want 2", len(handled))
+	}
+	if n := bus.pendingCount(stream, "g1"); n != 1 {
+		t.Fatalf("待确认 %d 条，want 1", n)
+	}
+
+	var redelivered []string
+	other := newTestConsumer(bus, "g1", "c2", types, func(event model.BusEvent) error {
+		redelivered = append(redelivered, event.Id)
+		return nil
+	})
+	// 未达到空闲时间，不接管
+	other.claimStale(bg)
+	if len(redelivered) != 0 {
+		t.Fatalf("空闲未超时即被接管: %v", redelivered)
+	}
+
+	bus.advance(eventBusClaimIdle)
+	other.claimStale(bg)
+	if len(redelivered) != 1 || redelivered[0] != rows[0].EventId {
+		t.Fatalf("重新投递 = %v, want [%s]", redelivered, rows[0].EventId)
+	}
+	if n := bus.pendingCount(stream, "g1"); n != 0 {
+		t.Fatalf("重新处理后仍有 %d 条待确认", n)
+	}
+
+	// 其他消费组独立消费，不受 g1 确认影响
+	res, err = bus.ReadGroup(bg, "g2", "c1", other.streams, 100, eventBusReadBlock)
+	if err != nil || len(res) != 1 || len(res[0].Messages) != 2 {
+		t.Fatalf("g2 读取结果 = %v, %v", res, err)
+	}
+}
+
+func TestEventBusAckUnparsable(t *testing.T) {
+	bus := newMemStreams()
+	stream := EventBusStream(model.ExplorerEventSwap)
+	bus.mu.Lock()
+	bus.add(stream, map[string]interface{}{"id": "x", "data": "not json"})
+	bus.mu.Unlock()
+
+	called := false
+	consumer := newTestConsumer(bus, "g1", "c1", []string{model.ExplorerEventSwap}, func(event model.BusEvent) error {
+		called = true
+		return nil
+	})
+	bg := context.Background()
+	res, err := bus.ReadGroup(bg, "g1", "c1", consumer.streams, 100, eventBusReadBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer.handle(bg, stream, res[0].Messages)
+	if called {
+		t.Fatal("无法解析的消息不应交给 handler")
+	}
+	if n := bus.pendingCount(stream, "g1"); n != 0 {
+		t.Fatalf("无法解析的消息应直接确认，待确认 %d 条", n)
+	}
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+)
+
+const (
+	defaultExplorerPageSize = 50
+	maxExplorerPageSize     = 500
+	// explorerStreamBatch 导出时每批读取的条数
+	explorerStreamBatch = 1000
+)
+
+var (
+	ErrInvalidCursor    = errors.New("无效的游标")
+	ErrUnknownEventType = errors.New("不支持的事件类型")
+)
+
+// EventFilter 事件浏览的过滤条件；零值字段表示不过滤
+type EventFilter struct {
+	ChainId   int64
+	Contract  string
+	User      string
+	TxHash    string
+	Types     []string // 统一事件类型，见 model.ExplorerEvent*
+	FromBlock int64
+	ToBlock   int64
+	From      time.Time
+	To        time.Time
+	Cursor    string
+	Limit     int
+	Asc       bool // 默认按 (block_number, log_index) 倒序
+}
+
+// eventSource 一张被索引的事件表：selects 按 explorerColumns 的顺序给出列表达式，
+// types 为表内事件类型到统一事件类型的映射，typeColumn 为空表示整张表只有一种事件
+type eventSource struct {
+	rank       int
+	table      string
+	typeColumn string
+	types      map[string]string
+	fixedType  string
+	timeExpr   string
+	contract   string
+	user       string
+	selects    []string
+}
+
+var explorerColumns = []string{
+	"source", "event_type", "chain_id", "block_number", "log_index", "tx_hash", "contract_address", "user_address",
+	"event_time", "token_address", "amount", "pool_id", "airdrop_id", "token0_address", "token1_address",
+	"amount0_in", "amount1_in", "amount0_out", "amount1_out", "source_rank", "row_id",
+}
+
+// liquidityEventTypes 流动性池事件类型到统一事件类型的映射
+var liquidityEventTypes = map[string]string{
+	"Swap":            model.ExplorerEventSwap,
+	"AddLiquidity":    model.ExplorerEventMint,
+	"RemoveLiquidity": model.ExplorerEventBurn,
+	"Collect":         model.ExplorerEventCollect,
+}
+
+// stakeEventTypes 质押操作记录类型到统一事件类型的映射
+var stakeEventTypes = map[string]string{
+	"Staked":    model.ExplorerEventStake,
+	"Withdrawn": model.ExplorerEventWithdraw,
+	"withdraw":  model.ExplorerEventWithdraw, // 接口直接写入的提取记录
+}
+
+var eventSources = []eventSource{
+	{
+		rank:       0,
+		table:      "liquidity_pool_events",
+		typeColumn: "event_type",
+		types:      liquidityEventTypes,
+		timeExpr:   "COALESCE(block_time, created_at)",
+		contract:   "pool_address",
+		user:       "user_address",
+		selects: []string{
+			"'liquidity'",
+			"CASE event_type WHEN 'Swap' THEN 'swap' WHEN 'AddLiquidity' THEN 'mint' WHEN 'RemoveLiquidity' THEN 'burn' ELSE 'collect' END",
+			"chain_id", "block_number", "COALESCE(log_index, 0)", "tx_hash", "pool_address", "user_address",
+			"COALESCE(block_time, created_at)", "NULL::text", "NULL::text", "NULL::bigint", "NULL::text",
+			"token0_address", "token1_address", "amount0_in::text", "amount1_in::text", "amount0_out::text", "amount1_out::text",
+			"0", "id",
+		},
+	},
+	{
+		rank:       1,
+		table:      "user_operation_record",
+		typeColumn: "event_type",
+		types:      stakeEventTypes,
+		timeExpr:   "operation_time",
+		contract:   "contract_address",
+		user:       "address",
+		selects: []string{
+			"'stake'",
+			"CASE event_type WHEN 'Staked' THEN 'stake' ELSE 'withdraw' END",
+			"chain_id", "block_number", "COALESCE(log_index, 0)", "tx_hash", "COALESCE(contract_address, '')", "address",
+			"operation_time", "token_address", "amount::text", "pool_id", "NULL::text",
+			"NULL::text", "NULL::text", "NULL::text", "NULL::text", "NULL::text", "NULL::text",
+			"1", "id",
+		},
+	},
+	{
+		rank:      2,
+		table:     "reward_claimed_events",
+		fixedType: model.ExplorerEventClaim,
+		timeExpr:  "event_timestamp",
+		contract:  "contract_address",
+		user:      "user_address",
+		selects: []string{
+			"'airdrop'", "'claim'",
+			"chain_id::bigint", "block_number", "log_index", "tx_hash", "contract_address", "user_address",
+			"event_timestamp", "NULL::text", "claim_amount::text", "NULL::bigint", "airdrop_id::text",
+			"NULL::text", "NULL::text", "NULL::text", "NULL::text", "NULL::text", "NULL::text",
+			"2", "id",
+		},
+	},
+}
+
+// ExplorerEventTypes 支持的统一事件类型
+func ExplorerEventTypes() []string {
+	return []string{
+		model.ExplorerEventStake, model.ExplorerEventWithdraw, model.ExplorerEventSwap, model.ExplorerEventMint,
+		model.ExplorerEventBurn, model.ExplorerEventCollect, model.ExplorerEventClaim,
+	}
+}
+
+// explorerCursor 排序键：(block_number, log_index) 之后再以链、来源表与行ID保证唯一，翻页稳定
+type explorerCursor struct {
+	BlockNumber int64
+	LogIndex    int64
+	ChainId     int64
+	SourceRank  int64
+	RowId       int64
+}
+
+func encodeExplorerCursor(e model.ExplorerEvent) string {
+	raw := fmt.Sprintf("%d:%d:%d:%d:%d", e.BlockNumber, e.LogIndex, e.ChainId, e.SourceRank, e.RowId)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeExplorerCursor(cursor string) (*explorerCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 5 {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]int64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = v
+	}
+	return &explorerCursor{values[0], values[1], values[2], values[3], values[4]}, nil
+}
+
+type EventExplorerService struct{}
+
+func NewEventExplorerService() *EventExplorerService {
+	return &EventExplorerService{}
+}
+
+// Query 按游标查询一页事件
+func (s *EventExplorerService) Query(filter EventFilter) (*model.ExplorerEventPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultExplorerPageSize
+	}
+	if filter.Limit > maxExplorerPageSize {
+		filter.Limit = maxExplorerPageSize
+	}
+	return s.query(filter)
+}
+
+// Stream 按游标分批读取全部匹配事件并逐条回调，用于大结果集导出；回调返回错误时停止
+func (s *EventExplorerService) Stream(filter EventFilter, fn func(model.ExplorerEvent) error) error {
+	filter.Limit = explorerStreamBatch
+	for {
+		page, err := s.query(filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range page.List {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if !page.HasMore {
+			return nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// ValidateEventFilter 校验游标与事件类型
+func ValidateEventFilter(filter EventFilter) error {
+	if filter.Cursor != "" {
+		if _, err := decodeExplorerCursor(filter.Cursor); err != nil {
+			return err
+		}
+	}
+	for _, t := range filter.Types {
+		if !isExplorerEventType(t) {
+			return ErrUnknownEventType
+		}
+	}
+	return nil
+}
+
+func (s *EventExplorerService) query(filter EventFilter) (*model.ExplorerEventPage, error) {
+	if err := ValidateEventFilter(filter); err != nil {
+		return nil, err
+	}
+	var cursor *explorerCursor
+	if filter.Cursor != "" {
+		cursor, _ = decodeExplorerCursor(filter.Cursor)
+	}
+	wanted := make(map[string]bool)
+	for _, t := range filter.Types {
+		wanted[t] = true
+	}
+
+	direction, compare := "DESC", "<"
+	if filter.Asc {
+		direction, compare = "ASC", ">"
+	}
+	orderBy := fmt.Sprintf("block_number %[1]s, log_index %[1]s, chain_id %[1]s, source_rank %[1]s, row_id %[1]s", direction)
+
+	var parts []string
+	var args []interface{}
+	for _, src := range eventSources {
+		where, whereArgs, ok := src.where(filter, wanted, cursor, compare)
+		if !ok {
+			continue
+		}
+		columns := make([]string, len(src.selects))
+		for i, expr := range src.selects {
+			columns[i] = expr + " AS " + explorerColumns[i]
+		}
+		parts = append(parts, fmt.Sprintf("(SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %d)",
+			strings.Join(columns, ", "), src.table, where, orderBy, filter.Limit+1))
+		args = append(args, whereArgs...)
+	}
+
+	page := &model.ExplorerEventPage{List: []model.ExplorerEvent{}}
+	if len(parts) == 0 {
+		return page, nil
+	}
+	query := fmt.Sprintf("SELECT * FROM (%s) e ORDER BY %s LIMIT %d", strings.Join(parts, " UNION ALL "), orderBy, filter.Limit+1)
+	var rows []model.ExplorerEvent
+	if err := ctx.Ctx.DB.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		page.HasMore = true
+		page.NextCursor = encodeExplorerCursor(rows[len(rows)-1])
+	}
+	page.List = rows
+	return page, nil
+}
+
+// where 生成单张事件表的过滤条件；该表不包含所需事件类型时返回 false
+func (src eventSource) where(filter EventFilter, wanted map[string]bool, cursor *explorerCursor, compare string) (string, []interface{}, bool) {
+	conds := []string{"1 = 1"}
+	var args []interface{}
+
+	if len(wanted) > 0 {
+		if src.typeColumn == "" {
+			if !wanted[src.fixedType] {
+				return "", nil, false
+			}
+		} else {
+			var raw []string
+			for rawType, t := range src.types {
+				if wanted[t] {
+					raw = append(raw, rawType)
+				}
+			}
+			if len(raw) == 0 {
+				return "", nil, false
+			}
+			conds = append(conds, src.typeColumn+" IN ?")
+			args = append(args, raw)
+		}
+	} else if src.typeColumn != "" {
+		raw := make([]string, 0, len(src.types))
+		for rawType := range src.types {
+			raw = append(raw, rawType)
+		}
+		conds = append(conds, src.typeColumn+" IN ?")
+		args = append(args, raw)
+	}
+
+	if filter.ChainId > 0 {
+		conds = append(conds, "chain_id = ?")
+		args = append(args, filter.ChainId)
+	}
+	if filter.Contract != "" {
+		conds = append(conds, "LOWER("+src.contract+") = ?")
+		args = append(args, strings.ToLower(filter.Contract))
+	}
+	if filter.User != "" {
+		conds = append(conds, "LOWER("+src.user+") = ?")
+		args = append(args, strings.ToLower(filter.User))
+	}
+	if filter.TxHash != "" {
+		conds = append(conds, "LOWER(tx_hash) = ?")
+		args = append(args, strings.ToLower(filter.TxHash))
+	}
+	if filter.FromBlock > 0 {
+		conds = append(conds, "block_number >= ?")
+		args = append(args, filter.FromBlock)
+	}
+	if filter.ToBlock > 0 {
+		conds = append(conds, "block_number <= ?")
+		args = append(args, filter.ToBlock)
+	}
+	if !filter.From.IsZero() {
+		conds = append(conds, src.timeExpr+" >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, src.timeExpr+" <= ?")
+		args = append(args, filter.To)
+	}
+	if cursor != nil {
+		conds = append(conds, fmt.Sprintf("(block_number, COALESCE(log_index, 0), chain_id::bigint, %d, id) %s (?, ?, ?, ?, ?)", src.rank, compare))
+		args = append(args, cursor.BlockNumber, cursor.LogIndex, cursor.ChainId, cursor.SourceRank, cursor.RowId)
+	}
+	return strings.Join(conds, " AND "), args, true
+}
+
+func isExplorerEventType(t string) bool {
+	for _, v := range ExplorerEventTypes() {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ExplorerEventFromLiquidity 将流动性池事件转换为统一事件，未知事件类型返回 false
+func ExplorerEventFromLiquidity(e *model.LiquidityPoolEvent) (model.ExplorerEvent, bool) {
+	eventType, ok := liquidityEventTypes[e.EventType]
+	if !ok {
+		return model.ExplorerEvent{}, false
+	}
+	eventTime := e.BlockTime
+	if eventTime.IsZero() {
+		eventTime = e.CreatedAt
+	}
+	return model.ExplorerEvent{
+		Source:          "liquidity",
+		EventType:       eventType,
+		ChainId:         e.ChainId,
+		BlockNumber:     e.BlockNumber,
+		LogIndex:        e.LogIndex,
+		TxHash:          e.TxHash,
+		ContractAddress: e.PoolAddress,
+		UserAddress:     e.UserAddress,
+		EventTime:       eventTime,
+		Token0Address:   e.Token0Address,
+		Token1Address:   e.Token1Address,
+		Amount0In:       e.Amount0In,
+		Amount1In:       e.Amount1In,
+		Amount0Out:      e.Amount0Out,
+		Amount1Out:      e.Amount1Out,
+		RowId:           e.Id,
+	}, true
+}
+
+// ExplorerEventFromStake 将质押/提取记录转换为统一事件，未知事件类型返回 false
+func ExplorerEventFromStake(r *model.UserOperationRecord) (model.ExplorerEvent, bool) {
+	eventType, ok := stakeEventTypes[r.EventType]
+	if !ok {
+		return model.ExplorerEvent{}, false
+	}
+	poolId := r.PoolId
+	amount := strconv.FormatInt(r.Amount, 10)
+	if r.AmountRaw != "" {
+		amount = r.AmountRaw
+	}
+	return model.ExplorerEvent{
+		Source:          "stake",
+		EventType:       eventType,
+		ChainId:         r.ChainId,
+		BlockNumber:     r.BlockNumber,
+		LogIndex:        r.LogIndex,
+		TxHash:          r.TxHash,
+		ContractAddress: r.ContractAddress,
+		UserAddress:     r.Address,
+		EventTime:       r.OperationTime,
+		TokenAddress:    r.TokenAddress,
+		Amount:          amount,
+		PoolId:          &poolId,
+		SourceRank:      1,
+		RowId:           r.Id,
+	}, true
+}
+
+// ExplorerEventFromClaim 将空投领取事件转换为统一事件，地址与哈希按入库规则转为小写
+func ExplorerEventFromClaim(e *model.RewardClaimedEvent) model.ExplorerEvent {
+	return model.ExplorerEvent{
+		Source:          "airdrop",
+		EventType:       model.ExplorerEventClaim,
+		ChainId:         e.ChainId,
+		BlockNumber:     e.BlockNumber,
+		LogIndex:        e.LogIndex,
+		TxHash:          strings.ToLower(e.TxHash),
+		ContractAddress: strings.ToLower(e.ContractAddress),
+		UserAddress:     strings.ToLower(e.UserAddress),
+		EventTime:       e.EventTimestamp,
+		Amount:          e.ClaimAmount,
+		AirdropId:       e.AirdropId,
+		SourceRank:      2,
+		RowId:           e.Id,
+	}
+}
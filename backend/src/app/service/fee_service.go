@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/abi"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FeeSourceDefault  = "default"
+	FeeSourceRegistry = "registry"
+	FeeSourceFactory  = "factory"
+
+	defaultPoolFeeBps = 30
+	// v2ProtocolFeeDivisor Uniswap V2 开启 feeTo 后，协议获得手续费增长的 1/6（以铸造LP的方式结算）
+	v2ProtocolFeeDivisor = 6
+)
+
+type FeeService struct{}
+
+func NewFeeService() *FeeService {
+	return &FeeService{}
+}
+
+// PoolFeeRates 池子交易手续费与其中归协议的部分（基点）
+func PoolFeeRates(pool model.LiquidityPool) (feeBps int, protocolFeeBps int) {
+	feeBps = pool.FeeBps
+	if feeBps <= 0 || feeBps >= 10000 {
+		feeBps = defaultFeeBps()
+	}
+	protocolFeeBps = pool.ProtocolFeeBps
+	if protocolFeeBps < 0 || protocolFeeBps > feeBps {
+		protocolFeeBps = 0
+	}
+	return feeBps, protocolFeeBps
+}
+
+func defaultFeeBps() int {
+	if config.Conf != nil && config.Conf.Fee.DefaultFeeBps > 0 {
+		return config.Conf.Fee.DefaultFeeBps
+	}
+	return defaultPoolFeeBps
+}
+
+// lookupFeeRegistry 查询手续费登记表
+func lookupFeeRegistry(chainId int64, poolAddress string) (config.PoolFeeRegistry, bool) {
+	if config.Conf == nil {
+		return config.PoolFeeRegistry{}, false
+	}
+	for _, r := range config.Conf.Fee.Pools {
+		if r.ChainId == chainId && strings.EqualFold(r.PoolAddress, poolAddress) {
+			return r, true
+		}
+	}
+	return config.PoolFeeRegistry{}, false
+}
+
+// DetectPoolFees 确定池子的手续费配置并写回池子表：
+// 登记表中的费率优先；协议分成未登记时，读取池子的工厂合约 feeTo，开启时为手续费的 1/6，关闭时为 0。
+// V3 池子的费率与协议分成读取池子合约自身（fee 与 slot0.feeProtocol）。
+func (s *FeeService) DetectPoolFees(pool model.LiquidityPool) (*model.LiquidityPool, error) {
+	if PoolModelOf(pool).Type() == PoolTypeV3 {
+		return s.detectV3PoolFees(pool)
+	}
+	feeBps := defaultFeeBps()
+	source := FeeSourceDefault
+	registry, registered := lookupFeeRegistry(pool.ChainId, pool.PoolAddress)
+	if registered && registry.FeeBps > 0 {
+		feeBps = registry.FeeBps
+		source = FeeSourceRegistry
+	}
+
+	factory := pool.FactoryAddress
+	feeTo := pool.FeeToAddress
+	protocolFeeBps := 0
+	if registered && registry.ProtocolFeeBps != nil {
+		protocolFeeBps = *registry.ProtocolFeeBps
+	} else {
+		var err error
+		if factory == "" {
+			factory, err = callAddressGetter(pool.ChainId, pool.PoolAddress, abi.ABIUniswapV2Pair, "factory")
+			if err != nil {
+				return nil, fmt.Errorf("获取池子工厂地址失败: %v", err)
+			}
+		}
+		feeTo, err = callAddressGetter(pool.ChainId, factory, abi.ABIUniswapV2Factory, "feeTo")
+		if err != nil {
+			return nil, fmt.Errorf("获取工厂 feeTo 失败: %v", err)
+		}
+		if !strings.EqualFold(feeTo, zeroAddress) {
+			protocolFeeBps = feeBps / v2ProtocolFeeDivisor
+		}
+		if source == FeeSourceDefault {
+			source = FeeSourceFactory
+		}
+	}
+	if protocolFeeBps < 0 || protocolFeeBps > feeBps {
+		return nil, fmt.Errorf("协议分成配置无效: %d/%d", protocolFeeBps, feeBps)
+	}
+
+	return s.savePoolFees(pool, feeBps, protocolFeeBps, source, factory, feeTo)
+}
+
+// detectV3PoolFees V3 池子费率为 fee()（百万分之一）换算的基点；协议分成为手续费的 1/feeProtocol，
+// 协议费留存在池子内由工厂 owner 提取，不以铸造LP的方式结算，因此 feeTo 留空
+func (s *FeeService) detectV3PoolFees(pool model.LiquidityPool) (*model.LiquidityPool, error) {
+	registry, registered := lookupFeeRegistry(pool.ChainId, pool.PoolAddress)
+	feeBps := pool.FeeBps
+	source := FeeSourceFactory
+	if registered && registry.FeeBps > 0 {
+		feeBps = registry.FeeBps
+		source = FeeSourceRegistry
+	} else {
+		out, err := callView(pool.ChainId, pool.PoolAddress, abi.ABIUniswapV3Pool, "fee")
+		if err != nil {
+			return nil, fmt.Errorf("获取 V3 池子费率失败: %v", err)
+		}
+		fee, ok := out[0].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("V3 池子费率返回值格式错误")
+		}
+		feeBps = int(fee.Int64() / 100)
+	}
+
+	factory := pool.FactoryAddress
+	if factory == "" {
+		var err error
+		if factory, err = callAddressGetter(pool.ChainId, pool.PoolAddress, abi.ABIUniswapV3Pool, "factory"); err != nil {
+			return nil, fmt.Errorf("获取池子工厂地址失败: %v", err)
+		}
+	}
+
+	protocolFeeBps := 0
+	if registered && registry.ProtocolFeeBps != nil {
+		protocolFeeBps = *registry.ProtocolFeeBps
+	} else {
+		state, err := FetchV3PoolState(pool.ChainId, pool.PoolAddress, pool.Token0Address, pool.Token1Address)
+		if err != nil {
+			return nil, err
+		}
+		// 两侧分母可不同，按 token0 侧近似
+		if denominator := int(state.FeeProtocol % 16); denominator > 0 {
+			protocolFeeBps = feeBps / denominator
+		}
+	}
+	if feeBps <= 0 || protocolFeeBps < 0 || protocolFeeBps > feeBps {
+		return nil, fmt.Errorf("协议分成配置无效: %d/%d", protocolFeeBps, feeBps)
+	}
+	return s.savePoolFees(pool, feeBps, protocolFeeBps, source, factory, "")
+}
+
+// savePoolFees 将手续费配置写回池子表
+func (s *FeeService) savePoolFees(pool model.LiquidityPool, feeBps, protocolFeeBps int, source, factory, feeTo string) (*model.LiquidityPool, error) {
+	updates := map[string]interface{}{
+		"fee_bps":          feeBps,
+		"protocol_fee_bps": protocolFeeBps,
+		"fee_source":       source,
+		"factory_address":  factory,
+		"fee_to_address":   feeTo,
+	}
+	if err := ctx.Ctx.DB.Model(&model.LiquidityPool{}).Where("id = ?", pool.Id).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	pool.FeeBps = feeBps
+	pool.ProtocolFeeBps = protocolFeeBps
+	pool.FeeSource = source
+	pool.FactoryAddress = factory
+	pool.FeeToAddress = feeTo
+	return &pool, nil
+}
+
+// callAddressGetter 调用合约无参数、返回 address 的只读方法
+func callAddressGetter(chainId int64, contract, abiName, method string) (string, error) {
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return "", fmt.Errorf("不支持的 chainId: %d", chainId)
+	}
+	contractABI, ok := abi.GetABIManager().GetABI(abiName)
+	if !ok {
+		return "", fmt.Errorf("%s ABI 未找到", abiName)
+	}
+	data, err := contractABI.Pack(method)
+	if err != nil {
+		return "", err
+	}
+	to := common.HexToAddress(contract)
+	res, err := ctx.GetEvmClient(int(chainId)).CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return "", err
+	}
+	var addr common.Address
+	if err := contractABI.UnpackIntoInterface(&addr, method, res); err != nil {
+		return "", err
+	}
+	return addr.Hex(), nil
+}
+
+// protocolRevenueIntervals 协议收入报表支持的统计周期（PostgreSQL date_trunc 单位）
+var protocolRevenueIntervals = map[string]bool{"hour": true, "day": true, "week": true, "month": true}
+
+// ProtocolRevenue 按池子与周期汇总协议收入：
+// 计提部分来自小时快照（交易量 × 协议费率）；实收部分为同期铸造给工厂 feeTo 的LP，按所在小时快照的单位LP价值折算。
+func (s *FeeService) ProtocolRevenue(chainId int64, poolAddress string, from, to time.Time, interval string) (*model.ProtocolRevenueReport, error) {
+	if !protocolRevenueIntervals[interval] {
+		return nil, fmt.Errorf("不支持的统计周期: %s", interval)
+	}
+
+	var accrued []model.ProtocolRevenueRow
+	query := ctx.Ctx.DB.Model(&model.PoolHourSnapshot{}).
+		Select("chain_id, pool_address, date_trunc(?, hour_start) AS period_start, "+
+			"SUM(volume_usd) AS volume_usd, SUM(fees_usd) AS fees_usd, SUM(lp_fees_usd) AS lp_fees_usd, SUM(protocol_fees_usd) AS protocol_fees_usd", interval).
+		Where("hour_start >= ? AND hour_start < ?", from.UTC(), to.UTC())
+	if chainId > 0 {
+		query = query.Where("chain_id = ?", chainId)
+	}
+	if poolAddress != "" {
+		query = query.Where("LOWER(pool_address) = ?", strings.ToLower(poolAddress))
+	}
+	if err := query.Group("chain_id, pool_address, period_start").Scan(&accrued).Error; err != nil {
+		return nil, err
+	}
+
+	var realized []struct {
+		ChainId     int64
+		PoolAddress string
+		PeriodStart time.Time
+		MintedLp    decimal.Decimal
+		MintedUSD   decimal.Decimal
+	}
+	realizedSQL := `
+		SELECT t.chain_id, t.pool_address, date_trunc(?, t.block_time) AS period_start,
+			SUM(t.amount) AS minted_lp,
+			COALESCE(SUM(t.amount * s.tvl_usd / NULLIF(s.total_supply, 0)), 0) AS minted_usd
+		FROM lp_transfer_events t
+		JOIN liquidity_pools p ON p.chain_id = t.chain_id AND p.pool_address = t.pool_address
+		LEFT JOIN pool_hour_snapshots s ON s.chain_id = t.chain_id AND s.pool_address = t.pool_address
+			AND s.hour_start = date_trunc('hour', t.block_time)
+		WHERE t.from_address = ? AND p.fee_to_address <> '' AND t.to_address = LOWER(p.fee_to_address)
+			AND t.block_time >= ? AND t.block_time < ?
+			AND (? = 0 OR t.chain_id = ?) AND (? = '' OR LOWER(t.pool_address) = ?)
+		GROUP BY t.chain_id, t.pool_address, period_start`
+	pool := strings.ToLower(poolAddress)
+	if err := ctx.Ctx.DB.Raw(realizedSQL, interval, zeroAddress, from.UTC(), to.UTC(), chainId, chainId, pool, pool).
+		Scan(&realized).Error; err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string]*model.ProtocolRevenueRow)
+	keyOf := func(chain int64, addr string, period time.Time) string {
+		return fmt.Sprintf("%d:%s:%d", chain, strings.ToLower(addr), period.Unix())
+	}
+	for i := range accrued {
+		r := accrued[i]
+		r.MintedLp = "0"
+		rows[keyOf(r.ChainId, r.PoolAddress, r.PeriodStart)] = &r
+	}
+	for _, r := range realized {
+		key := keyOf(r.ChainId, r.PoolAddress, r.PeriodStart)
+		row, ok := rows[key]
+		if !ok {
+			row = &model.ProtocolRevenueRow{ChainId: r.ChainId, PoolAddress: r.PoolAddress, PeriodStart: r.PeriodStart}
+			rows[key] = row
+		}
+		row.MintedLp = r.MintedLp.String()
+		row.MintedLpUSD = r.MintedUSD.Round(2)
+	}
+
+	report := &model.ProtocolRevenueReport{Interval: interval, From: from.UTC(), To: to.UTC(), List: make([]model.ProtocolRevenueRow, 0, len(rows))}
+	for _, row := range rows {
+		report.List = append(report.List, *row)
+		report.TotalVolumeUSD = report.TotalVolumeUSD.Add(row.VolumeUSD)
+		report.TotalFeesUSD = report.TotalFeesUSD.Add(row.FeesUSD)
+		report.TotalLpFeesUSD = report.TotalLpFeesUSD.Add(row.LpFeesUSD)
+		report.TotalProtocolFeesUSD = report.TotalProtocolFeesUSD.Add(row.ProtocolFeesUSD)
+		report.TotalMintedLpUSD = report.TotalMintedLpUSD.Add(row.MintedLpUSD)
+	}
+	sort.Slice(report.List, func(i, j int) bool {
+		if !report.List[i].PeriodStart.Equal(report.List[j].PeriodStart) {
+			return report.List[i].PeriodStart.Before(report.List[j].PeriodStart)
+		}
+		if report.List[i].ChainId != report.List[j].ChainId {
+			return report.List[i].ChainId < report.List[j].ChainId
+		}
+		return report.List[i].PoolAddress < report.List[j].PoolAddress
+	})
+	return report, nil
+}
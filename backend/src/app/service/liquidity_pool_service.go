@@ -2,12 +2,12 @@ package service
 
 import (
 	"fmt"
-	"math"
 	"math/big"
 	"time"
 
 	"github.com/mumu/cryptoSwap/src/app/model"
 	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
 )
 
 type LiquidityPoolService struct{}
@@ -59,19 +59,9 @@ func (s *LiquidityPoolService) UpdatePool(pool *model.LiquidityPool) error {
 
 // --- 新增：查询与统计方法 ---
 
-// ListUserPoolAddresses 根据用户地址（可选链ID）查询其涉及的去重池子地址
+// ListUserPoolAddresses 根据用户地址（可选链ID）查询其当前持有LP的池子地址（来自LP代币转账累计的持仓）
 func (s *LiquidityPoolService) ListUserPoolAddresses(userAddress string, chainIdOpt int64) ([]string, error) {
-	var poolAddresses []string
-	subQuery := ctx.Ctx.DB.Model(&model.LiquidityPoolEvent{}).
-		Select("DISTINCT pool_address").
-		Where("user_address = ?", userAddress)
-	if chainIdOpt > 0 {
-		subQuery = subQuery.Where("chain_id = ?", chainIdOpt)
-	}
-	if err := subQuery.Pluck("pool_address", &poolAddresses).Error; err != nil {
-		return nil, err
-	}
-	return poolAddresses, nil
+	return NewLpPositionService().ListOwnerPoolAddresses(userAddress, chainIdOpt)
 }
 
 // ListActivePoolsByAddresses 根据地址列表（可选链ID）查询活跃池子（不分页）
@@ -129,27 +119,26 @@ func (s *LiquidityPoolService) ListActivePools(chainIdOpt int64, offset, limit i
 	return pools, total, nil
 }
 
-const DefaultFeeRate = 0.003
-
-// Compute24hStats 计算 24h 交易量、手续费与 APY（APY 为字符串，含百分号）
+// Compute24hStats 基于小时快照计算最近 24 个完整小时的交易量、LP手续费与 APY（APY 为字符串，含百分号）
 func (s *LiquidityPoolService) Compute24hStats(pool model.LiquidityPool) (volumeUSD float64, feesUSD float64, apy string) {
-	volumeUSD = compute24hVolumeUSD(pool)
-	feesUSD = volumeUSD * DefaultFeeRate
-	apy = computeAPY(pool, feesUSD)
+	snapshotSvc := NewPoolSnapshotService()
+	end := time.Now().UTC().Truncate(time.Hour)
+	stats, err := snapshotSvc.SumWindow(pool.ChainId, pool.PoolAddress, end.Add(-24*time.Hour), end)
+	if err != nil {
+		return 0, 0, "-"
+	}
+	volumeUSD = stats.VolumeUSD.InexactFloat64()
+	feesUSD = stats.LpFeesUSD.InexactFloat64()
+
+	apy = "-"
+	if latest, err := snapshotSvc.LatestSnapshot(pool.ChainId, pool.PoolAddress); err == nil && latest.TvlUSD.IsPositive() {
+		apy = fmt.Sprintf("%.1f%%", latest.Apy.InexactFloat64())
+	}
 	return
 }
 
 // --- 计算辅助方法（从 API 迁移） ---
 
-func isStable(symbol string) bool {
-	switch symbol {
-	case "USDC", "USDT", "DAI":
-		return true
-	default:
-		return false
-	}
-}
-
 func parseBigInt(s string) *big.Int {
 	if s == "" {
 		return big.NewInt(0)
@@ -169,94 +158,13 @@ func toFloatWithDecimals(v *big.Int, decimals int) float64 {
 	return f
 }
 
-// computeVolumeUSDForPeriod 计算任意时间段内的 USD 交易量（稳定币侧）
-func computeVolumeUSDForPeriod(pool model.LiquidityPool, start, end time.Time) float64 {
-	var events []model.LiquidityPoolEvent
-	if err := ctx.Ctx.DB.Model(&model.LiquidityPoolEvent{}).
-		Where("chain_id = ? AND pool_address = ? AND event_type = ? AND created_at >= ? AND created_at < ?",
-			pool.ChainId, pool.PoolAddress, "Swap", start, end).
-		Order("created_at DESC").
-		Find(&events).Error; err != nil {
-		return 0
-	}
-
-	var total float64
-	token0Stable := isStable(pool.Token0Symbol)
-	token1Stable := isStable(pool.Token1Symbol)
-
-	for _, e := range events {
-		a0in := parseBigInt(e.Amount0In)
-		a0out := parseBigInt(e.Amount0Out)
-		a1in := parseBigInt(e.Amount1In)
-		a1out := parseBigInt(e.Amount1Out)
-
-		if token0Stable {
-			vol0 := new(big.Int).Add(a0in, a0out)
-			total += toFloatWithDecimals(vol0, pool.Token0Decimals)
-		} else if token1Stable {
-			vol1 := new(big.Int).Add(a1in, a1out)
-			total += toFloatWithDecimals(vol1, pool.Token1Decimals)
-		}
-	}
-	return total
-}
-
-// ComputeFeesUSDForPeriod 计算任意时间段内的 USD 手续费
+// ComputeFeesUSDForPeriod 基于小时快照计算时间段内归LP的 USD 手续费（按整点小时统计）
 func (s *LiquidityPoolService) ComputeFeesUSDForPeriod(pool model.LiquidityPool, start, end time.Time) float64 {
-	vol := computeVolumeUSDForPeriod(pool, start, end)
-	return vol * DefaultFeeRate
-}
-
-// compute24hVolumeUSD 仅在稳定币池中返回 USD 交易量，否则为 0
-func compute24hVolumeUSD(pool model.LiquidityPool) float64 {
-	since := time.Now().Add(-24 * time.Hour)
-	var events []model.LiquidityPoolEvent
-	if err := ctx.Ctx.DB.Model(&model.LiquidityPoolEvent{}).
-		Where("chain_id = ? AND pool_address = ? AND event_type = ? AND created_at >= ?",
-			pool.ChainId, pool.PoolAddress, "Swap", since).
-		Order("created_at DESC").
-		Find(&events).Error; err != nil {
+	stats, err := NewPoolSnapshotService().SumWindow(pool.ChainId, pool.PoolAddress, start, end)
+	if err != nil {
 		return 0
 	}
-
-	var total float64
-	token0Stable := isStable(pool.Token0Symbol)
-	token1Stable := isStable(pool.Token1Symbol)
-
-	for _, e := range events {
-		a0in := parseBigInt(e.Amount0In)
-		a0out := parseBigInt(e.Amount0Out)
-		a1in := parseBigInt(e.Amount1In)
-		a1out := parseBigInt(e.Amount1Out)
-
-		if token0Stable {
-			vol0 := new(big.Int).Add(a0in, a0out)
-			total += toFloatWithDecimals(vol0, pool.Token0Decimals)
-		} else if token1Stable {
-			vol1 := new(big.Int).Add(a1in, a1out)
-			total += toFloatWithDecimals(vol1, pool.Token1Decimals)
-		}
-	}
-	return total
-}
-
-// computeAPY 基于稳定币侧的 TVL 估算 APY
-func computeAPY(pool model.LiquidityPool, feesUSD24h float64) string {
-	var tvlUSD float64
-	if isStable(pool.Token0Symbol) {
-		tvlUSD = 2 * toFloatWithDecimals(parseBigInt(pool.Reserve0), pool.Token0Decimals)
-	} else if isStable(pool.Token1Symbol) {
-		tvlUSD = 2 * toFloatWithDecimals(parseBigInt(pool.Reserve1), pool.Token1Decimals)
-	}
-
-	if tvlUSD <= 0 {
-		return "-"
-	}
-	apy := (feesUSD24h / tvlUSD) * 365 * 100
-	if math.IsNaN(apy) || math.IsInf(apy, 0) {
-		return "-"
-	}
-	return fmt.Sprintf("%.1f%%", apy)
+	return stats.LpFeesUSD.InexactFloat64()
 }
 
 // BatchCreateEvents 批量创建事件记录
@@ -301,6 +209,24 @@ func (s *LiquidityPoolService) GetPoolStats(chainId int64) (map[string]interface
 	}
 	stats["activeUsers"] = activeUsers
 
+	// USD 锁仓量与最近 24 个完整小时的交易量、手续费（来自小时快照）
+	snapshotSvc := NewPoolSnapshotService()
+	now := time.Now()
+	tvl, err := snapshotSvc.SumTvlAt(chainId, now)
+	if err != nil {
+		return nil, err
+	}
+	end := now.UTC().Truncate(time.Hour)
+	window, err := snapshotSvc.SumWindow(chainId, "", end.Add(-24*time.Hour), end)
+	if err != nil {
+		return nil, err
+	}
+	stats["tvlUSD"] = tvl.InexactFloat64()
+	stats["volume24hUSD"] = window.VolumeUSD.InexactFloat64()
+	stats["fees24hUSD"] = window.FeesUSD.InexactFloat64()
+	stats["lpFees24hUSD"] = window.LpFeesUSD.InexactFloat64()
+	stats["protocolFees24hUSD"] = window.ProtocolFeesUSD.InexactFloat64()
+
 	return stats, nil
 }
 
@@ -320,30 +246,27 @@ func (s *LiquidityPoolService) GetRecentEvents(chainId int64, limit int) ([]mode
 	return events, err
 }
 
-// GetPoolVolume 获取池子的交易量统计
+// GetPoolVolume 获取池子的 USD 交易量统计（来自小时快照）
 func (s *LiquidityPoolService) GetPoolVolume(chainId int64, poolAddress string, days int) (map[string]interface{}, error) {
 	var stats map[string]interface{} = make(map[string]interface{})
+	snapshotSvc := NewPoolSnapshotService()
+	end := time.Now().UTC().Truncate(time.Hour)
 
 	// 总交易量
-	var totalVolume int64
-	err := ctx.Ctx.DB.Model(&model.LiquidityPoolEvent{}).
-		Where("chain_id = ? AND pool_address = ? AND event_type = ?", chainId, poolAddress, "Swap").
-		Count(&totalVolume).Error
+	total, err := snapshotSvc.SumWindow(chainId, poolAddress, time.Time{}, end)
 	if err != nil {
 		return nil, err
 	}
-	stats["totalVolume"] = totalVolume
+	stats["totalVolume"] = total.VolumeUSD.InexactFloat64()
+	stats["totalTxCount"] = total.TxCount
 
 	// 指定天数内的交易量
-	var periodVolume int64
-	err = ctx.Ctx.DB.Model(&model.LiquidityPoolEvent{}).
-		Where("chain_id = ? AND pool_address = ? AND event_type = ? AND created_at >= DATE_SUB(NOW(), INTERVAL ? DAY)",
-			chainId, poolAddress, "Swap", days).
-		Count(&periodVolume).Error
+	period, err := snapshotSvc.SumWindow(chainId, poolAddress, end.AddDate(0, 0, -days), end)
 	if err != nil {
 		return nil, err
 	}
-	stats["periodVolume"] = periodVolume
+	stats["periodVolume"] = period.VolumeUSD.InexactFloat64()
+	stats["periodTxCount"] = period.TxCount
 
 	return stats, nil
 }
@@ -371,14 +294,14 @@ func (s *LiquidityPoolService) GetLiquidityStats(req *model.LiquidityStatsReques
 	if err != nil {
 		return nil, err
 	}
-	stats.TotalFees = fmt.Sprintf("$%.2f", totalFees)
+	stats.TotalFees = "$" + totalFees.StringFixed(2)
 
 	// 获取今日手续费变化量
 	feesTodayChange, err := s.calculateFeesTodayChange(req.ChainId)
 	if err != nil {
 		return nil, err
 	}
-	stats.TotalFeesTodayChange = fmt.Sprintf("+$%.2f", feesTodayChange)
+	stats.TotalFeesTodayChange = "+$" + feesTodayChange.StringFixed(2)
 
 	// 获取活跃池子数量
 	activePoolsCount, err := s.getActivePoolsCount(req.ChainId)
@@ -397,51 +320,9 @@ func (s *LiquidityPoolService) GetLiquidityStats(req *model.LiquidityStatsReques
 	return stats, nil
 }
 
-// calculateMyLiquidityValue 计算我的流动性总价值
+// calculateMyLiquidityValue 计算我的流动性总价值（按LP持仓份额折算池子锁仓量）
 func (s *LiquidityPoolService) calculateMyLiquidityValue(userAddress string, chainId int64) (float64, error) {
-	var totalValue float64
-
-	// 查询用户参与的流动性池事件
-	var userEvents []model.LiquidityPoolEvent
-	query := ctx.Ctx.DB.Where("user_address = ?", userAddress)
-	if chainId > 0 {
-		query = query.Where("chain_id = ?", chainId)
-	}
-
-	err := query.Find(&userEvents).Error
-	if err != nil {
-		return 0, err
-	}
-
-	// 计算每个池子的流动性价值
-	poolValues := make(map[string]float64)
-	for _, event := range userEvents {
-		// 获取池子当前价格
-		var pool model.LiquidityPool
-		err := ctx.Ctx.DB.Where("pool_address = ? AND chain_id = ?", event.PoolAddress, event.ChainId).
-			First(&pool).Error
-		if err != nil {
-			continue
-		}
-
-		// 计算用户在该池子的LP代币价值
-		price, _ := new(big.Float).SetString(pool.Price)
-		if price == nil {
-			continue
-		}
-
-		// 简化计算：假设用户LP代币价值与池子总价值成比例
-		// 实际应该根据用户LP代币数量计算
-		poolValue, _ := price.Float64()
-		poolValues[event.PoolAddress] = poolValue
-	}
-
-	// 累加所有池子的价值
-	for _, value := range poolValues {
-		totalValue += value
-	}
-
-	return totalValue, nil
+	return NewLpPositionService().ValueUSDAt(userAddress, chainId, time.Now())
 }
 
 // calculateMyLiquidityPeriodChange 计算我的流动性变化率
@@ -466,77 +347,23 @@ func (s *LiquidityPoolService) calculateMyLiquidityPeriodChange(userAddress stri
 	return (currentValue - previousValue) / previousValue, nil
 }
 
-// calculateTotalFees 计算累计手续费
-func (s *LiquidityPoolService) calculateTotalFees(chainId int64) (float64, error) {
-	var totalFees float64
-
-	// 查询所有Swap事件，计算手续费
-	// 假设手续费为交易量的0.3%
-	var swapEvents []model.LiquidityPoolEvent
-	query := ctx.Ctx.DB.Where("event_type = ?", "Swap")
-	if chainId > 0 {
-		query = query.Where("chain_id = ?", chainId)
-	}
-
-	err := query.Find(&swapEvents).Error
-	if err != nil {
-		return 0, err
-	}
-
-	for _, event := range swapEvents {
-		// 计算交易量（取较大的输入或输出）
-		amount0In, _ := new(big.Float).SetString(event.Amount0In)
-		amount1In, _ := new(big.Float).SetString(event.Amount1In)
-		amount0Out, _ := new(big.Float).SetString(event.Amount0Out)
-		amount1Out, _ := new(big.Float).SetString(event.Amount1Out)
-
-		var volume *big.Float
-		if amount0In != nil && amount0In.Sign() > 0 {
-			volume = amount0In
-		} else if amount1In != nil && amount1In.Sign() > 0 {
-			volume = amount1In
-		} else if amount0Out != nil && amount0Out.Sign() > 0 {
-			volume = amount0Out
-		} else if amount1Out != nil && amount1Out.Sign() > 0 {
-			volume = amount1Out
-		}
-
-		if volume != nil {
-			// 手续费率为0.3%
-			fee := new(big.Float).Mul(volume, big.NewFloat(0.003))
-			feeFloat, _ := fee.Float64()
-			totalFees += feeFloat
-		}
-	}
-
-	return totalFees, nil
+// calculateTotalFees 计算累计手续费（USD）
+func (s *LiquidityPoolService) calculateTotalFees(chainId int64) (decimal.Decimal, error) {
+	return s.sumFeesUSD(chainId, time.Time{}, time.Now())
 }
 
-// calculateFeesTodayChange 计算今日手续费变化量
-func (s *LiquidityPoolService) calculateFeesTodayChange(chainId int64) (float64, error) {
-	// 获取今日手续费
-	var todayFees float64
-	query := ctx.Ctx.DB.Where("event_type = ? AND created_at::date = CURRENT_DATE", "Swap")
-	if chainId > 0 {
-		query = query.Where("chain_id = ?", chainId)
-	}
+// calculateFeesTodayChange 计算今日手续费变化量（USD）
+func (s *LiquidityPoolService) calculateFeesTodayChange(chainId int64) (decimal.Decimal, error) {
+	return s.sumFeesUSD(chainId, s.getPeriodStartTime("today"), time.Now())
+}
 
-	var todayEvents []model.LiquidityPoolEvent
-	err := query.Find(&todayEvents).Error
+// sumFeesUSD 汇总时间段内所有池子的 USD 手续费（来自小时快照）
+func (s *LiquidityPoolService) sumFeesUSD(chainId int64, start, end time.Time) (decimal.Decimal, error) {
+	stats, err := NewPoolSnapshotService().SumWindow(chainId, "", start, end)
 	if err != nil {
-		return 0, err
-	}
-
-	for _, event := range todayEvents {
-		amount0In, _ := new(big.Float).SetString(event.Amount0In)
-		if amount0In != nil {
-			fee := new(big.Float).Mul(amount0In, big.NewFloat(0.003))
-			feeFloat, _ := fee.Float64()
-			todayFees += feeFloat
-		}
+		return decimal.Zero, err
 	}
-
-	return todayFees, nil
+	return stats.FeesUSD, nil
 }
 
 // getActivePoolsCount 获取活跃池子数量
@@ -578,30 +405,10 @@ func (s *LiquidityPoolService) getPeriodStartTime(period string) time.Time {
 	}
 }
 
-// getHistoricalLiquidityValue 获取历史流动性价值
+// getHistoricalLiquidityValue 获取周期开始时的流动性价值（来自小时快照）
 func (s *LiquidityPoolService) getHistoricalLiquidityValue(userAddress string, chainId int64, startTime time.Time) (float64, error) {
-	// 简化实现：查询指定时间点之后的用户事件
-	var historicalValue float64
-
-	var userEvents []model.LiquidityPoolEvent
-	query := ctx.Ctx.DB.Where("user_address = ? AND created_at >= ?", userAddress, startTime)
-	if chainId > 0 {
-		query = query.Where("chain_id = ?", chainId)
-	}
-
-	err := query.Find(&userEvents).Error
-	if err != nil {
-		return 0, err
-	}
-
-	// 简化计算：使用事件发生时的价格估算历史价值
-	for _, event := range userEvents {
-		price, _ := new(big.Float).SetString(event.Price)
-		if price != nil {
-			priceFloat, _ := price.Float64()
-			historicalValue += priceFloat
-		}
+	if startTime.IsZero() {
+		return 0, nil
 	}
-
-	return historicalValue, nil
+	return NewLpPositionService().ValueUSDAt(userAddress, chainId, startTime)
 }
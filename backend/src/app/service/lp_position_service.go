@@ -0,0 +1,464 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LpPositionService struct {
+	priceSvc    *PriceService
+	snapshotSvc *PoolSnapshotService
+}
+
+func NewLpPositionService() *LpPositionService {
+	return &LpPositionService{
+		priceSvc:    NewPriceService(),
+		snapshotSvc: NewPoolSnapshotService(),
+	}
+}
+
+// lpDeposit 一次增加流动性对应的代币数量
+type lpDeposit struct {
+	amount0 *big.Int
+	amount1 *big.Int
+}
+
+// ApplyActivity 在事务内按链上顺序将池子的LP转账与增减流动性事件累计到持仓。
+// 成本归属规则：
+//   - Mint 的代币数量归属于同一交易中、Mint 之前最近一次从零地址铸造的 LP 转账接收方（排除协议手续费铸造）；
+//   - Burn 的取出数量归属于同一交易中、Burn 之前最近一次转入池子合约的 LP 转出方，找不到时归属交易发起人；
+//   - LP 在地址间转移时，剩余成本按转移比例随之转移。
+func (s *LpPositionService) ApplyActivity(tx *gorm.DB, pool model.LiquidityPool, transfers []model.LpTransferEvent, events []model.LiquidityPoolEvent) error {
+	if len(transfers) == 0 && len(events) == 0 {
+		return nil
+	}
+	sortLpTransfers(transfers)
+	poolAddr := strings.ToLower(pool.PoolAddress)
+
+	txTransfers := make(map[string][]int)
+	for i, t := range transfers {
+		txTransfers[t.TxHash] = append(txTransfers[t.TxHash], i)
+	}
+
+	deposits := make(map[int]lpDeposit)
+	withdrawals := make(map[string][2]*big.Int)
+	for _, e := range events {
+		switch e.EventType {
+		case "AddLiquidity":
+			match := -1
+			for _, i := range txTransfers[e.TxHash] {
+				t := transfers[i]
+				if t.LogIndex < e.LogIndex && t.FromAddress == zeroAddress && t.ToAddress != zeroAddress {
+					match = i
+				}
+			}
+			if match >= 0 {
+				deposits[match] = lpDeposit{amount0: parseBigInt(e.Amount0In), amount1: parseBigInt(e.Amount1In)}
+			}
+		case "RemoveLiquidity":
+			owner := strings.ToLower(e.UserAddress)
+			for _, i := range txTransfers[e.TxHash] {
+				t := transfers[i]
+				if t.LogIndex < e.LogIndex && t.ToAddress == poolAddr && t.FromAddress != zeroAddress {
+					owner = t.FromAddress
+				}
+			}
+			w := withdrawals[owner]
+			if w[0] == nil {
+				w = [2]*big.Int{big.NewInt(0), big.NewInt(0)}
+			}
+			w[0].Add(w[0], parseBigInt(e.Amount0Out))
+			w[1].Add(w[1], parseBigInt(e.Amount1Out))
+			withdrawals[owner] = w
+		}
+	}
+
+	owners := make(map[string]bool)
+	for _, t := range transfers {
+		owners[t.FromAddress] = true
+		owners[t.ToAddress] = true
+	}
+	for owner := range withdrawals {
+		owners[owner] = true
+	}
+	positions, err := s.loadPositions(tx, pool, owners)
+	if err != nil {
+		return err
+	}
+	isHolder := func(addr string) bool {
+		return addr != zeroAddress && addr != poolAddr
+	}
+
+	for i, t := range transfers {
+		amount := parseBigInt(t.Amount)
+		moved0, moved1, movedUSD := big.NewInt(0), big.NewInt(0), decimal.Zero
+		if isHolder(t.FromAddress) {
+			from := positions[t.FromAddress]
+			balance := parseBigInt(from.Balance)
+			cost0, cost1 := parseBigInt(from.CostToken0), parseBigInt(from.CostToken1)
+			if balance.Sign() > 0 {
+				if amount.Cmp(balance) >= 0 {
+					moved0, moved1, movedUSD = cost0, cost1, from.CostBasisUSD
+				} else {
+					moved0 = new(big.Int).Div(new(big.Int).Mul(cost0, amount), balance)
+					moved1 = new(big.Int).Div(new(big.Int).Mul(cost1, amount), balance)
+					movedUSD = from.CostBasisUSD.Mul(decimal.NewFromBigInt(amount, 0)).Div(decimal.NewFromBigInt(balance, 0))
+				}
+			}
+			remaining := new(big.Int).Sub(balance, amount)
+			if remaining.Sign() < 0 {
+				remaining = big.NewInt(0)
+			}
+			from.Balance = remaining.String()
+			from.CostToken0 = new(big.Int).Sub(cost0, moved0).String()
+			from.CostToken1 = new(big.Int).Sub(cost1, moved1).String()
+			from.CostBasisUSD = from.CostBasisUSD.Sub(movedUSD)
+			from.LastBlockNum = t.BlockNumber
+		}
+		if !isHolder(t.ToAddress) {
+			// 转入零地址或池子合约（移除流动性）时成本随之结清
+			continue
+		}
+
+		to := positions[t.ToAddress]
+		to.Balance = new(big.Int).Add(parseBigInt(to.Balance), amount).String()
+		to.LastBlockNum = t.BlockNumber
+		if isHolder(t.FromAddress) {
+			to.CostToken0 = new(big.Int).Add(parseBigInt(to.CostToken0), moved0).String()
+			to.CostToken1 = new(big.Int).Add(parseBigInt(to.CostToken1), moved1).String()
+			to.CostBasisUSD = to.CostBasisUSD.Add(movedUSD)
+		}
+		if d, ok := deposits[i]; ok {
+			to.CostToken0 = new(big.Int).Add(parseBigInt(to.CostToken0), d.amount0).String()
+			to.CostToken1 = new(big.Int).Add(parseBigInt(to.CostToken1), d.amount1).String()
+			to.Deposited0 = new(big.Int).Add(parseBigInt(to.Deposited0), d.amount0).String()
+			to.Deposited1 = new(big.Int).Add(parseBigInt(to.Deposited1), d.amount1).String()
+			to.CostBasisUSD = to.CostBasisUSD.Add(historicalValueUSD(s.priceSvc, pool, d.amount0, d.amount1, t.BlockTime))
+		}
+	}
+
+	for owner, w := range withdrawals {
+		pos, ok := positions[owner]
+		if !ok || !isHolder(owner) {
+			continue
+		}
+		pos.Withdrawn0 = new(big.Int).Add(parseBigInt(pos.Withdrawn0), w[0]).String()
+		pos.Withdrawn1 = new(big.Int).Add(parseBigInt(pos.Withdrawn1), w[1]).String()
+	}
+
+	rows := make([]*model.LpPosition, 0, len(positions))
+	for owner, pos := range positions {
+		if isHolder(owner) {
+			rows = append(rows, pos)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain_id"}, {Name: "pool_address"}, {Name: "owner_address"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"balance", "cost_token0", "cost_token1", "cost_basis_usd",
+			"deposited0", "deposited1", "withdrawn0", "withdrawn1", "last_block_num", "updated_at",
+		}),
+	}).CreateInBatches(rows, 100).Error
+}
+
+// RebuildPositions 按全部已索引的LP转账与增减流动性事件重建池子的持仓（锁定池子行，避免与实时索引并发写入）
+func (s *LpPositionService) RebuildPositions(tx *gorm.DB, pool model.LiquidityPool) error {
+	var locked model.LiquidityPool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pool.Id).First(&locked).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("chain_id = ? AND pool_address = ?", pool.ChainId, pool.PoolAddress).Delete(&model.LpPosition{}).Error; err != nil {
+		return err
+	}
+
+	var transfers []model.LpTransferEvent
+	if err := tx.Where("chain_id = ? AND pool_address = ?", pool.ChainId, pool.PoolAddress).
+		Order("block_number ASC, log_index ASC").Find(&transfers).Error; err != nil {
+		return err
+	}
+	var events []model.LiquidityPoolEvent
+	if err := tx.Where("chain_id = ? AND pool_address = ? AND event_type IN ?", pool.ChainId, pool.PoolAddress, []string{"AddLiquidity", "RemoveLiquidity"}).
+		Find(&events).Error; err != nil {
+		return err
+	}
+	return s.ApplyActivity(tx, locked, transfers, events)
+}
+
+// ErrTickRangeRequired V3 池子的持仓按区间区分，查询单个持仓时必须指定 tick 区间
+var ErrTickRangeRequired = errors.New("V3 池子需要指定 tickLower 与 tickUpper")
+
+// GetPosition 查询地址在指定池子中的持仓详情；V3 池子需传入 tick 区间
+func (s *LpPositionService) GetPosition(chainId int64, poolAddress, owner string, tickLower, tickUpper *int) (*model.LpPositionDetail, error) {
+	var target model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND LOWER(pool_address) = ?", chainId, strings.ToLower(poolAddress)).First(&target).Error; err != nil {
+		return nil, err
+	}
+	if PoolModelOf(target).Type() == PoolTypeV3 {
+		if tickLower == nil || tickUpper == nil {
+			return nil, ErrTickRangeRequired
+		}
+		return NewV3PositionService().GetPosition(target, owner, *tickLower, *tickUpper)
+	}
+
+	var pos model.LpPosition
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND LOWER(pool_address) = ? AND owner_address = ?",
+		chainId, strings.ToLower(poolAddress), strings.ToLower(owner)).First(&pos).Error; err != nil {
+		return nil, err
+	}
+	var pool model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ?", pos.ChainId, pos.PoolAddress).First(&pool).Error; err != nil {
+		return nil, err
+	}
+	prices, err := s.priceSvc.GetTokenPrices(chainId)
+	if err != nil {
+		return nil, err
+	}
+	detail := s.buildDetail(pool, pos, prices)
+	return &detail, nil
+}
+
+// ListPositions 查询地址持有的全部LP持仓（V2 余额大于0的LP与 V3 流动性大于0的区间仓位）
+func (s *LpPositionService) ListPositions(owner string, chainIdOpt int64) ([]model.LpPositionDetail, error) {
+	var positions []model.LpPosition
+	query := ctx.Ctx.DB.Where("owner_address = ? AND balance > 0", strings.ToLower(owner))
+	if chainIdOpt > 0 {
+		query = query.Where("chain_id = ?", chainIdOpt)
+	}
+	if err := query.Order("chain_id ASC, pool_address ASC").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]model.LpPositionDetail, 0, len(positions))
+	priceCache := make(map[int64]map[string]*TokenPriceQuote)
+	for _, pos := range positions {
+		var pool model.LiquidityPool
+		if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ?", pos.ChainId, pos.PoolAddress).First(&pool).Error; err != nil {
+			continue
+		}
+		prices, ok := priceCache[pos.ChainId]
+		if !ok {
+			p, err := s.priceSvc.GetTokenPrices(pos.ChainId)
+			if err != nil {
+				return nil, err
+			}
+			prices = p
+			priceCache[pos.ChainId] = prices
+		}
+		list = append(list, s.buildDetail(pool, pos, prices))
+	}
+
+	v3List, err := NewV3PositionService().ListPositions(owner, chainIdOpt, priceCache)
+	if err != nil {
+		return nil, err
+	}
+	return append(list, v3List...), nil
+}
+
+// ListOwnerPoolAddresses 查询地址当前持有LP的池子地址
+func (s *LpPositionService) ListOwnerPoolAddresses(owner string, chainIdOpt int64) ([]string, error) {
+	var poolAddresses []string
+	query := ctx.Ctx.DB.Model(&model.LpPosition{}).
+		Where("owner_address = ? AND balance > 0", strings.ToLower(owner))
+	if chainIdOpt > 0 {
+		query = query.Where("chain_id = ?", chainIdOpt)
+	}
+	if err := query.Distinct("pool_address").Pluck("pool_address", &poolAddresses).Error; err != nil {
+		return nil, err
+	}
+	v3Pools, err := NewV3PositionService().ListOwnerPoolAddresses(owner, chainIdOpt)
+	if err != nil {
+		return nil, err
+	}
+	return append(poolAddresses, v3Pools...), nil
+}
+
+// ValueUSDAt 计算地址在指定时间点的LP持仓总价值：V2 为该时间点的LP余额占比 × 池子小时快照的USD锁仓量，
+// V3 区间仓位的估值见 V3PositionService.ValueUSDAt
+func (s *LpPositionService) ValueUSDAt(owner string, chainIdOpt int64, at time.Time) (float64, error) {
+	owner = strings.ToLower(owner)
+	var rows []struct {
+		ChainId     int64
+		PoolAddress string
+		Balance     decimal.Decimal
+	}
+	query := `
+		SELECT chain_id, pool_address,
+			SUM(CASE WHEN to_address = ? THEN amount ELSE 0 END) - SUM(CASE WHEN from_address = ? THEN amount ELSE 0 END) AS balance
+		FROM lp_transfer_events
+		WHERE (to_address = ? OR from_address = ?) AND block_time <= ? AND (? = 0 OR chain_id = ?)
+		GROUP BY chain_id, pool_address`
+	if err := ctx.Ctx.DB.Raw(query, owner, owner, owner, owner, at.UTC(), chainIdOpt, chainIdOpt).Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	total := decimal.Zero
+	for _, r := range rows {
+		if !r.Balance.IsPositive() {
+			continue
+		}
+		snap, err := s.snapshotSvc.SnapshotAt(r.ChainId, r.PoolAddress, at)
+		if err != nil {
+			continue
+		}
+		supply, err := decimal.NewFromString(snap.TotalSupply)
+		if err != nil || !supply.IsPositive() {
+			continue
+		}
+		total = total.Add(snap.TvlUSD.Mul(r.Balance).Div(supply))
+	}
+
+	v3Value, err := NewV3PositionService().ValueUSDAt(owner, chainIdOpt, at)
+	if err != nil {
+		return 0, err
+	}
+	return total.Add(v3Value).InexactFloat64(), nil
+}
+
+// buildDetail 根据池子当前储备与价格计算持仓的份额、底层资产、价值、手续费收益与无常损失。
+// 无常损失按存入成本的价格比与当前价格比计算：IL = 2√r/(1+r) − 1；
+// 手续费收益 = 当前价值 − 持币价值 × (1 + IL)，多次在不同价格存入时为近似值。
+func (s *LpPositionService) buildDetail(pool model.LiquidityPool, pos model.LpPosition, prices map[string]*TokenPriceQuote) model.LpPositionDetail {
+	detail := model.LpPositionDetail{
+		ChainId:         pos.ChainId,
+		PoolAddress:     pos.PoolAddress,
+		PoolType:        PoolTypeV2,
+		OwnerAddress:    pos.OwnerAddress,
+		InRange:         true,
+		Token0Symbol:    pool.Token0Symbol,
+		Token1Symbol:    pool.Token1Symbol,
+		LpBalance:       pos.Balance,
+		CostBasisUSD:    pos.CostBasisUSD.InexactFloat64(),
+		Deposited0:      tokenAmount(parseBigInt(pos.Deposited0), pool.Token0Decimals).String(),
+		Deposited1:      tokenAmount(parseBigInt(pos.Deposited1), pool.Token1Decimals).String(),
+		Withdrawn0:      tokenAmount(parseBigInt(pos.Withdrawn0), pool.Token0Decimals).String(),
+		Withdrawn1:      tokenAmount(parseBigInt(pos.Withdrawn1), pool.Token1Decimals).String(),
+		LastUpdateBlock: pos.LastBlockNum,
+		Underlying0:     "0",
+		Underlying1:     "0",
+	}
+
+	balance := parseBigInt(pos.Balance)
+	supply := parseBigInt(pool.TotalSupply)
+	if balance.Sign() <= 0 || supply.Sign() <= 0 {
+		return detail
+	}
+	reserve0, reserve1 := parseBigInt(pool.Reserve0), parseBigInt(pool.Reserve1)
+	under0 := new(big.Int).Div(new(big.Int).Mul(reserve0, balance), supply)
+	under1 := new(big.Int).Div(new(big.Int).Mul(reserve1, balance), supply)
+	detail.ShareOfPool = decimal.NewFromBigInt(balance, 0).Div(decimal.NewFromBigInt(supply, 0)).Mul(decimal.NewFromInt(100)).InexactFloat64()
+	detail.Underlying0 = tokenAmount(under0, pool.Token0Decimals).String()
+	detail.Underlying1 = tokenAmount(under1, pool.Token1Decimals).String()
+
+	value := poolTVLFromReserves(pool, under0, under1, prices)
+	detail.ValueUSD = value.InexactFloat64()
+	detail.PnlUSD = value.Sub(pos.CostBasisUSD).InexactFloat64()
+
+	cost0, cost1 := parseBigInt(pos.CostToken0), parseBigInt(pos.CostToken1)
+	if cost0.Sign() <= 0 || cost1.Sign() <= 0 || reserve0.Sign() <= 0 || reserve1.Sign() <= 0 {
+		// 没有存入记录（如索引前已持有）时无法计算无常损失与手续费收益
+		return detail
+	}
+	hodl := poolTVLFromReserves(pool, cost0, cost1, prices)
+	detail.HodlValueUSD = hodl.InexactFloat64()
+
+	entryRatio, _ := new(big.Rat).SetFrac(cost1, cost0).Float64()
+	currentRatio, _ := new(big.Rat).SetFrac(reserve1, reserve0).Float64()
+	if entryRatio <= 0 {
+		return detail
+	}
+	r := currentRatio / entryRatio
+	il := 2*math.Sqrt(r)/(1+r) - 1
+	if math.IsNaN(il) || math.IsInf(il, 0) {
+		return detail
+	}
+	detail.ImpermanentLoss = il * 100
+
+	fees := detail.ValueUSD - detail.HodlValueUSD*(1+il)
+	if fees < 0 {
+		fees = 0
+	}
+	detail.FeesEarnedUSD = fees
+	return detail
+}
+
+// historicalValueUSD 按指定时间的历史价格计算池子两种代币的USD价值，缺少历史价格时使用当前价格
+func historicalValueUSD(priceSvc *PriceService, pool model.LiquidityPool, amount0, amount1 *big.Int, at time.Time) decimal.Decimal {
+	priceOf := func(token string) (decimal.Decimal, bool) {
+		if !at.IsZero() {
+			if p, ok := priceSvc.GetPriceAt(pool.ChainId, token, at); ok {
+				return p, true
+			}
+		}
+		return priceSvc.GetTokenPriceUSD(pool.ChainId, token)
+	}
+	prices := make(map[string]*TokenPriceQuote)
+	if p, ok := priceOf(pool.Token0Address); ok {
+		prices[strings.ToLower(pool.Token0Address)] = &TokenPriceQuote{PriceUSD: p}
+	}
+	if p, ok := priceOf(pool.Token1Address); ok {
+		prices[strings.ToLower(pool.Token1Address)] = &TokenPriceQuote{PriceUSD: p}
+	}
+	return poolTVLFromReserves(pool, amount0, amount1, prices)
+}
+
+// loadPositions 加载相关地址的持仓，不存在的初始化为空持仓
+func (s *LpPositionService) loadPositions(tx *gorm.DB, pool model.LiquidityPool, owners map[string]bool) (map[string]*model.LpPosition, error) {
+	list := make([]string, 0, len(owners))
+	for owner := range owners {
+		list = append(list, owner)
+	}
+	var existing []model.LpPosition
+	if len(list) > 0 {
+		if err := tx.Where("chain_id = ? AND pool_address = ? AND owner_address IN ?", pool.ChainId, pool.PoolAddress, list).
+			Find(&existing).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	positions := make(map[string]*model.LpPosition, len(list))
+	for i := range existing {
+		positions[existing[i].OwnerAddress] = &existing[i]
+	}
+	for _, owner := range list {
+		if _, ok := positions[owner]; ok {
+			continue
+		}
+		positions[owner] = &model.LpPosition{
+			ChainId:      pool.ChainId,
+			PoolAddress:  pool.PoolAddress,
+			OwnerAddress: owner,
+			Balance:      "0",
+			CostToken0:   "0",
+			CostToken1:   "0",
+			CostBasisUSD: decimal.Zero,
+			Deposited0:   "0",
+			Deposited1:   "0",
+			Withdrawn0:   "0",
+			Withdrawn1:   "0",
+		}
+	}
+	return positions, nil
+}
+
+// sortLpTransfers 按 (区块号, 日志序号) 排序
+func sortLpTransfers(transfers []model.LpTransferEvent) {
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber < transfers[j].BlockNumber
+		}
+		return transfers[i].LogIndex < transfers[j].LogIndex
+	})
+}
@@ -0,0 +1,608 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mumu/cryptoSwap/src/app/api/dto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrManagedAirdropInvalidParam 参数错误或当前状态不允许该操作
+	ErrManagedAirdropInvalidParam = errors.New("空投活动参数无效")
+	// ErrManagedAirdropForbidden 调用方不在 airdrop.admins 内
+	ErrManagedAirdropForbidden = errors.New("无权管理空投活动")
+	// ErrManagedAirdropNotFound 活动不存在
+	ErrManagedAirdropNotFound = errors.New("空投活动不存在")
+)
+
+// managedAirdropInitialTreeVersion createAirdrop 的 treeVersion，之后快照发布的版本在此基础上加快照版本，始终更高
+var managedAirdropInitialTreeVersion = big.NewInt(1)
+
+// ManagedAirdropInput 创建或修改空投活动，修改时只处理非空字段。
+// 链上字段（chainId、contractAddress、name、totalReward、startTime、endTime）仅草稿可改，
+// 链下字段（description、iconUrl、tokenSymbol、tokenAddress、taskIds）在 draft、created、active 状态可改
+type ManagedAirdropInput struct {
+	ChainId         int64    `json:"chainId"`
+	ContractAddress string   `json:"contractAddress"` // 留空时使用该链已索引空投的合约
+	Name            *string  `json:"name"`
+	Description     *string  `json:"description"`
+	IconUrl         *string  `json:"iconUrl"`
+	TokenSymbol     *string  `json:"tokenSymbol"`
+	TokenAddress    *string  `json:"tokenAddress"`
+	TotalReward     *string  `json:"totalReward"` // 代币单位，按 18 位精度转换为 wei
+	StartTime       *int64   `json:"startTime"`   // Unix 秒
+	EndTime         *int64   `json:"endTime"`     // Unix 秒
+	TaskIds         *[]int64 `json:"taskIds"`
+}
+
+// ManagedAirdropService 空投活动生命周期：草稿 -> createAirdrop -> activateAirdrop -> 结束，
+// 链上状态以索引的 AirdropCreated、AirdropActivated 事件为准
+type ManagedAirdropService struct{}
+
+func NewManagedAirdropService() *ManagedAirdropService {
+	return &ManagedAirdropService{}
+}
+
+// CheckOperator 调用方需在 airdrop.admins 内
+func (s *ManagedAirdropService) CheckOperator(operator string) error {
+	if !common.IsHexAddress(operator) {
+		return fmt.Errorf("%w: 无效的登录地址", ErrManagedAirdropForbidden)
+	}
+	if !isAirdropAdmin(operator) {
+		return fmt.Errorf("%w: %s 不在 airdrop.admins 内", ErrManagedAirdropForbidden, operator)
+	}
+	return nil
+}
+
+// Draft 创建草稿
+func (s *ManagedAirdropService) Draft(operator string, in ManagedAirdropInput) (*model.ManagedAirdrop, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	if in.Name == nil || in.TokenSymbol == nil || in.TotalReward == nil || in.StartTime == nil || in.EndTime == nil {
+		return nil, fmt.Errorf("%w: name、tokenSymbol、totalReward、startTime、endTime 必填", ErrManagedAirdropInvalidParam)
+	}
+	airdrop := &model.ManagedAirdrop{
+		TaskIds:   "[]",
+		Status:    model.ManagedAirdropDraft,
+		CreatedBy: strings.ToLower(operator),
+		UpdatedBy: strings.ToLower(operator),
+	}
+	if err := s.apply(airdrop, in); err != nil {
+		return nil, err
+	}
+	if err := ctx.Ctx.DB.Create(airdrop).Error; err != nil {
+		return nil, err
+	}
+	log.Logger.Info("空投活动草稿已创建", zap.Int64("id", airdrop.Id), zap.String("name", airdrop.Name), zap.String("operator", operator))
+	return airdrop, nil
+}
+
+// Update 按状态修改活动；已创建的活动同步链下字段到 airdrop_campaigns 与任务绑定
+func (s *ManagedAirdropService) Update(operator string, id int64, in ManagedAirdropInput) (*model.ManagedAirdrop, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	airdrop, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	switch airdrop.Status {
+	case model.ManagedAirdropDraft:
+	case model.ManagedAirdropCreated, model.ManagedAirdropActive:
+		if in.ChainId != 0 || in.ContractAddress != "" || in.Name != nil || in.TotalReward != nil || in.StartTime != nil || in.EndTime != nil {
+			return nil, fmt.Errorf("%w: 链上已创建，chainId、contractAddress、name、totalReward、startTime、endTime 不可修改", ErrManagedAirdropInvalidParam)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s 状态不可修改", ErrManagedAirdropInvalidParam, airdrop.Status)
+	}
+	status := airdrop.Status
+	if err := s.apply(airdrop, in); err != nil {
+		return nil, err
+	}
+	airdrop.UpdatedBy = strings.ToLower(operator)
+	err = ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		// 按读取时的状态更新，避免与创建、激活或对账并发覆盖
+		res := tx.Model(&model.ManagedAirdrop{}).Where("id = ? AND status = ?", airdrop.Id, status).Select("*").Omit("id", "created_at").Updates(airdrop)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w: 活动状态已变化，请刷新后重试", ErrManagedAirdropInvalidParam)
+		}
+		if airdrop.AirdropId == nil {
+			return nil
+		}
+		return syncCampaignMetadata(tx, airdrop, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return airdrop, nil
+}
+
+// apply 校验并写入输入中非空的字段
+func (s *ManagedAirdropService) apply(airdrop *model.ManagedAirdrop, in ManagedAirdropInput) error {
+	chainChanged := in.ChainId != 0 && in.ChainId != airdrop.ChainId
+	if in.ChainId != 0 {
+		if ctx.Ctx.ChainMap[int(in.ChainId)] == nil {
+			return fmt.Errorf("%w: 不支持的 chainId %d", ErrManagedAirdropInvalidParam, in.ChainId)
+		}
+		airdrop.ChainId = in.ChainId
+	}
+	if airdrop.ChainId == 0 {
+		return fmt.Errorf("%w: chainId 必填", ErrManagedAirdropInvalidParam)
+	}
+	if in.ContractAddress != "" {
+		if !common.IsHexAddress(in.ContractAddress) {
+			return fmt.Errorf("%w: 无效的合约地址", ErrManagedAirdropInvalidParam)
+		}
+		airdrop.ContractAddress = strings.ToLower(in.ContractAddress)
+	}
+	if in.ContractAddress == "" && (airdrop.ContractAddress == "" || chainChanged) {
+		var contract string
+		if err := ctx.Ctx.DB.Raw(`SELECT COALESCE(merkle_airdrop_contract, '') FROM airdrop_campaigns
+            WHERE chain_id = ? AND merkle_airdrop_contract IS NOT NULL ORDER BY updated_at DESC LIMIT 1`, airdrop.ChainId).Scan(&contract).Error; err != nil {
+			return err
+		}
+		if contract == "" {
+			return fmt.Errorf("%w: 链 %d 没有已索引的空投合约，请指定 contractAddress", ErrManagedAirdropInvalidParam, airdrop.ChainId)
+		}
+		airdrop.ContractAddress = contract
+	}
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" || len(name) > 128 {
+			return fmt.Errorf("%w: name 不能为空且不超过 128 字节", ErrManagedAirdropInvalidParam)
+		}
+		airdrop.Name = name
+	}
+	if in.Description != nil {
+		airdrop.Description = *in.Description
+	}
+	if in.IconUrl != nil {
+		airdrop.IconUrl = *in.IconUrl
+	}
+	if in.TokenSymbol != nil {
+		symbol := strings.TrimSpace(*in.TokenSymbol)
+		if symbol == "" || len(symbol) > 32 {
+			return fmt.Errorf("%w: tokenSymbol 不能为空且不超过 32 字节", ErrManagedAirdropInvalidParam)
+		}
+		airdrop.TokenSymbol = symbol
+	}
+	if in.TokenAddress != nil {
+		if *in.TokenAddress != "" && !common.IsHexAddress(*in.TokenAddress) {
+			return fmt.Errorf("%w: 无效的 tokenAddress", ErrManagedAirdropInvalidParam)
+		}
+		airdrop.TokenAddress = strings.ToLower(*in.TokenAddress)
+	}
+	if in.TotalReward != nil {
+		d, err := decimal.NewFromString(strings.TrimSpace(*in.TotalReward))
+		wei := d.Shift(18)
+		if err != nil || !d.IsPositive() || !wei.IsInteger() {
+			return fmt.Errorf("%w: totalReward 需为正数且不超过 18 位小数", ErrManagedAirdropInvalidParam)
+		}
+		airdrop.TotalReward = wei.String()
+	}
+	if in.StartTime != nil {
+		airdrop.StartTime = time.Unix(*in.StartTime, 0)
+	}
+	if in.EndTime != nil {
+		airdrop.EndTime = time.Unix(*in.EndTime, 0)
+	}
+	if !airdrop.EndTime.After(airdrop.StartTime) {
+		return fmt.Errorf("%w: endTime 需晚于 startTime", ErrManagedAirdropInvalidParam)
+	}
+	if in.TaskIds != nil {
+		ids, err := normalizeTaskIds(*in.TaskIds)
+		if err != nil {
+			return err
+		}
+		data, _ := json.Marshal(ids)
+		airdrop.TaskIds = string(data)
+	}
+	return nil
+}
+
+// normalizeTaskIds 去重排序并确认任务存在
+func normalizeTaskIds(ids []int64) ([]int64, error) {
+	seen := make(map[int64]bool, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, fmt.Errorf("%w: 无效的任务ID %d", ErrManagedAirdropInvalidParam, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	if len(out) == 0 {
+		return out, nil
+	}
+	var count int64
+	if err := ctx.Ctx.DB.Raw("SELECT COUNT(*) FROM tasks WHERE task_id IN ?", out).Scan(&count).Error; err != nil {
+		return nil, err
+	}
+	if count != int64(len(out)) {
+		return nil, fmt.Errorf("%w: 部分任务不存在", ErrManagedAirdropInvalidParam)
+	}
+	return out, nil
+}
+
+// Delete 删除草稿
+func (s *ManagedAirdropService) Delete(operator string, id int64) error {
+	if err := s.CheckOperator(operator); err != nil {
+		return err
+	}
+	res := ctx.Ctx.DB.Where("id = ? AND status = ?", id, model.ManagedAirdropDraft).Delete(&model.ManagedAirdrop{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		if _, err := s.get(id); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: 只能删除草稿", ErrManagedAirdropInvalidParam)
+	}
+	return nil
+}
+
+// List 活动列表，可按状态过滤
+func (s *ManagedAirdropService) List(operator, status string, pagination dto.Pagination) ([]model.ManagedAirdrop, int64, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, 0, err
+	}
+	query := ctx.Ctx.DB.Model(&model.ManagedAirdrop{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := make([]model.ManagedAirdrop, 0)
+	if err := query.Order("id DESC").Offset(pagination.Offset).Limit(pagination.PageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Get 活动详情
+func (s *ManagedAirdropService) Get(operator string, id int64) (*model.ManagedAirdrop, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	return s.get(id)
+}
+
+func (s *ManagedAirdropService) get(id int64) (*model.ManagedAirdrop, error) {
+	var airdrop model.ManagedAirdrop
+	err := ctx.Ctx.DB.Where("id = ?", id).First(&airdrop).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrManagedAirdropNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &airdrop, nil
+}
+
+// Create 以托管交易调用 createAirdrop。合约要求非零根，草稿阶段没有分配，使用不对应任何叶子的占位根，
+// 首个默克尔快照发布后替换；空投ID在 AirdropCreated 索引后回填
+func (s *ManagedAirdropService) Create(operator string, id int64) (*model.ManagedAirdrop, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	airdrop, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	signerName := strings.TrimSpace(config.Conf.Airdrop.Signer)
+	if signerName == "" {
+		return nil, fmt.Errorf("%w: 未配置 airdrop.signer", ErrManagedAirdropInvalidParam)
+	}
+	if !airdrop.EndTime.After(time.Now()) {
+		return nil, fmt.Errorf("%w: 结束时间已过", ErrManagedAirdropInvalidParam)
+	}
+	totalReward, ok := new(big.Int).SetString(airdrop.TotalReward, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: 无效的 totalReward", ErrManagedAirdropInvalidParam)
+	}
+	if err := s.transition(airdrop, model.ManagedAirdropDraft, model.ManagedAirdropCreating, operator); err != nil {
+		return nil, err
+	}
+	root := crypto.Keccak256Hash([]byte(fmt.Sprintf("managed-airdrop:%d:%d", airdrop.ChainId, airdrop.Id)))
+	admin := NewAirdropAdminService()
+	admin.Configure(airdrop.ChainId, airdrop.ContractAddress, signerName)
+	tx, err := admin.CreateAirdrop(airdrop.Name, root, totalReward, airdrop.StartTime.Unix(), airdrop.EndTime.Unix(), managedAirdropInitialTreeVersion)
+	if err != nil {
+		return nil, s.sendFailed(airdrop, model.ManagedAirdropDraft, err)
+	}
+	if err := ctx.Ctx.DB.Model(&model.ManagedAirdrop{}).Where("id = ?", airdrop.Id).Updates(map[string]interface{}{
+		"initial_root":   root.Hex(),
+		"create_tx_id":   tx.Id,
+		"create_tx_hash": tx.TxHash,
+	}).Error; err != nil {
+		log.Logger.Error("记录 createAirdrop 托管交易失败", zap.Int64("id", airdrop.Id), zap.Int64("tx_id", tx.Id), zap.Error(err))
+	}
+	log.Logger.Info("createAirdrop 已提交", zap.Int64("id", airdrop.Id), zap.Int64("tx_id", tx.Id), zap.String("operator", operator))
+	return s.get(airdrop.Id)
+}
+
+// Activate 以托管交易调用 activateAirdrop，合约要求已到开始时间
+func (s *ManagedAirdropService) Activate(operator string, id int64) (*model.ManagedAirdrop, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	airdrop, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	signerName := strings.TrimSpace(config.Conf.Airdrop.Signer)
+	if signerName == "" {
+		return nil, fmt.Errorf("%w: 未配置 airdrop.signer", ErrManagedAirdropInvalidParam)
+	}
+	if airdrop.AirdropId == nil {
+		return nil, fmt.Errorf("%w: %s 状态不可激活", ErrManagedAirdropInvalidParam, airdrop.Status)
+	}
+	if time.Now().Before(airdrop.StartTime) {
+		return nil, fmt.Errorf("%w: 未到开始时间 %s", ErrManagedAirdropInvalidParam, airdrop.StartTime.Format(time.RFC3339))
+	}
+	if err := s.transition(airdrop, model.ManagedAirdropCreated, model.ManagedAirdropActivating, operator); err != nil {
+		return nil, err
+	}
+	admin := NewAirdropAdminService()
+	admin.Configure(airdrop.ChainId, airdrop.ContractAddress, signerName)
+	tx, err := admin.ActivateAirdrop(big.NewInt(*airdrop.AirdropId))
+	if err != nil {
+		return nil, s.sendFailed(airdrop, model.ManagedAirdropCreated, err)
+	}
+	if err := ctx.Ctx.DB.Model(&model.ManagedAirdrop{}).Where("id = ?", airdrop.Id).Update("activate_tx_id", tx.Id).Error; err != nil {
+		log.Logger.Error("记录 activateAirdrop 托管交易失败", zap.Int64("id", airdrop.Id), zap.Int64("tx_id", tx.Id), zap.Error(err))
+	}
+	log.Logger.Info("activateAirdrop 已提交", zap.Int64("id", airdrop.Id), zap.Int64("tx_id", tx.Id), zap.String("operator", operator))
+	return s.get(airdrop.Id)
+}
+
+// transition 条件更新状态，防止并发重复发送
+func (s *ManagedAirdropService) transition(airdrop *model.ManagedAirdrop, from, to, operator string) error {
+	res := ctx.Ctx.DB.Model(&model.ManagedAirdrop{}).Where("id = ? AND status = ?", airdrop.Id, from).Updates(map[string]interface{}{
+		"status":     to,
+		"error":      "",
+		"updated_by": strings.ToLower(operator),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s 状态不可执行该操作", ErrManagedAirdropInvalidParam, airdrop.Status)
+	}
+	return nil
+}
+
+// sendFailed 交易未能提交时回退状态并记录原因
+func (s *ManagedAirdropService) sendFailed(airdrop *model.ManagedAirdrop, back string, sendErr error) error {
+	if err := ctx.Ctx.DB.Model(&model.ManagedAirdrop{}).Where("id = ?", airdrop.Id).Updates(map[string]interface{}{
+		"status": back,
+		"error":  sendErr.Error(),
+	}).Error; err != nil {
+		log.Logger.Error("回退空投活动状态失败", zap.Int64("id", airdrop.Id), zap.Error(err))
+	}
+	if errors.Is(sendErr, ErrTxWouldRevert) {
+		return fmt.Errorf("%w: %v", ErrManagedAirdropInvalidParam, sendErr)
+	}
+	return sendErr
+}
+
+// Reconcile 推进状态：创建交易确认且 AirdropCreated 已索引后回填空投ID，激活交易确认且 AirdropActivated 已索引后为进行中，
+// 过结束时间后为已结束；交易失败时回退到发送前的状态。返回状态变化的数量
+func (s *ManagedAirdropService) Reconcile() (int, error) {
+	var list []model.ManagedAirdrop
+	if err := ctx.Ctx.DB.Where("status IN ?", []string{
+		model.ManagedAirdropCreating, model.ManagedAirdropCreated, model.ManagedAirdropActivating, model.ManagedAirdropActive,
+	}).Order("id ASC").Limit(200).Find(&list).Error; err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range list {
+		ok, err := s.reconcile(&list[i])
+		if err != nil {
+			log.Logger.Warn("空投活动状态对账失败", zap.Int64("id", list[i].Id), zap.Error(err))
+			continue
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (s *ManagedAirdropService) reconcile(airdrop *model.ManagedAirdrop) (bool, error) {
+	switch airdrop.Status {
+	case model.ManagedAirdropCreating:
+		if airdrop.CreateTxId == nil {
+			return s.abandonIfStale(airdrop, model.ManagedAirdropDraft)
+		}
+		tx, err := NewManagedTxService().Get(*airdrop.CreateTxId)
+		if err != nil {
+			return false, err
+		}
+		if failure := managedTxFailure(tx); failure != "" {
+			return s.revert(airdrop, model.ManagedAirdropDraft, "createAirdrop "+failure)
+		}
+		if tx.Status != model.ManagedTxConfirmed {
+			return false, nil
+		}
+		var airdropIds []int64
+		if err := ctx.Ctx.DB.Raw(`SELECT airdrop_id::bigint FROM airdrop_campaigns WHERE chain_id = ? AND created_tx_hash = ?`,
+			airdrop.ChainId, strings.ToLower(tx.TxHash)).Scan(&airdropIds).Error; err != nil {
+			return false, err
+		}
+		if len(airdropIds) == 0 {
+			return false, nil
+		}
+		airdrop.AirdropId = &airdropIds[0]
+		airdrop.CreateTxHash = strings.ToLower(tx.TxHash)
+		err = ctx.Ctx.DB.Transaction(func(db *gorm.DB) error {
+			res := db.Model(&model.ManagedAirdrop{}).Where("id = ? AND status = ?", airdrop.Id, model.ManagedAirdropCreating).Updates(map[string]interface{}{
+				"status":         model.ManagedAirdropCreated,
+				"airdrop_id":     airdropIds[0],
+				"create_tx_hash": airdrop.CreateTxHash,
+			})
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			return syncCampaignMetadata(db, airdrop, true)
+		})
+		if err != nil {
+			return false, err
+		}
+		log.Logger.Info("空投活动已在链上创建", zap.Int64("id", airdrop.Id), zap.Int64("airdrop_id", airdropIds[0]))
+		return true, nil
+	case model.ManagedAirdropActivating:
+		if airdrop.ActivateTxId == nil {
+			return s.abandonIfStale(airdrop, model.ManagedAirdropCreated)
+		}
+		tx, err := NewManagedTxService().Get(*airdrop.ActivateTxId)
+		if err != nil {
+			return false, err
+		}
+		if failure := managedTxFailure(tx); failure != "" {
+			return s.revert(airdrop, model.ManagedAirdropCreated, "activateAirdrop "+failure)
+		}
+		if tx.Status != model.ManagedTxConfirmed {
+			return false, nil
+		}
+		return s.activateIfIndexed(airdrop)
+	case model.ManagedAirdropCreated:
+		if !airdrop.EndTime.After(time.Now()) {
+			return s.end(airdrop)
+		}
+		// 也可能由其他方式（如 Hardhat 脚本）激活
+		return s.activateIfIndexed(airdrop)
+	case model.ManagedAirdropActive:
+		if !airdrop.EndTime.After(time.Now()) {
+			return s.end(airdrop)
+		}
+	}
+	return false, nil
+}
+
+// managedTxFailure 托管交易终态失败的描述，未失败时为空
+func managedTxFailure(tx *model.ManagedTx) string {
+	switch tx.Status {
+	case model.ManagedTxReverted, model.ManagedTxCancelled, model.ManagedTxFailed:
+		if tx.Error != "" {
+			return fmt.Sprintf("交易 %s: %s", tx.Status, tx.Error)
+		}
+		return "交易 " + tx.Status
+	}
+	return ""
+}
+
+// activateIfIndexed AirdropActivated 已索引时转为进行中
+func (s *ManagedAirdropService) activateIfIndexed(airdrop *model.ManagedAirdrop) (bool, error) {
+	if airdrop.AirdropId == nil {
+		return false, nil
+	}
+	var active []bool
+	if err := ctx.Ctx.DB.Raw(`SELECT is_active FROM airdrop_campaigns WHERE airdrop_id = ? AND chain_id = ?`,
+		*airdrop.AirdropId, airdrop.ChainId).Scan(&active).Error; err != nil {
+		return false, err
+	}
+	if len(active) == 0 || !active[0] {
+		return false, nil
+	}
+	res := ctx.Ctx.DB.Model(&model.ManagedAirdrop{}).Where("id = ? AND status = ?", airdrop.Id, airdrop.Status).
+		Updates(map[string]interface{}{"status": model.ManagedAirdropActive, "error": ""})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		log.Logger.Info("空投活动已激活", zap.Int64("id", airdrop.Id), zap.Int64("airdrop_id", *airdrop.AirdropId))
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (s *ManagedAirdropService) end(airdrop *model.ManagedAirdrop) (bool, error) {
+	res := ctx.Ctx.DB.Model(&model.ManagedAirdrop{}).Where("id = ? AND status = ?", airdrop.Id, airdrop.Status).
+		Update("status", model.ManagedAirdropEnded)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (s *ManagedAirdropService) revert(airdrop *model.ManagedAirdrop, back, reason string) (bool, error) {
+	res := ctx.Ctx.DB.Model(&model.ManagedAirdrop{}).Where("id = ? AND status = ?", airdrop.Id, airdrop.Status).
+		Updates(map[string]interface{}{"status": back, "error": reason})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		log.Logger.Warn("空投活动链上操作失败，已回退状态", zap.Int64("id", airdrop.Id), zap.String("status", back), zap.String("reason", reason))
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// abandonIfStale 状态已推进但未记录托管交易（提交前进程退出），超时后回退
+func (s *ManagedAirdropService) abandonIfStale(airdrop *model.ManagedAirdrop, back string) (bool, error) {
+	if time.Since(airdrop.UpdatedAt) < 10*time.Minute {
+		return false, nil
+	}
+	return s.revert(airdrop, back, "发送中断，未提交交易")
+}
+
+// syncCampaignMetadata 将链下字段写入 airdrop_campaigns，并使任务绑定与 task_ids 一致；
+// withSchedule 为 true 时同时写入开始、结束时间（事件中没有这两个字段）
+func syncCampaignMetadata(tx *gorm.DB, airdrop *model.ManagedAirdrop, withSchedule bool) error {
+	updates := map[string]interface{}{
+		"description":  airdrop.Description,
+		"icon_url":     airdrop.IconUrl,
+		"token_symbol": airdrop.TokenSymbol,
+		"updated_at":   time.Now(),
+	}
+	if withSchedule {
+		updates["start_time"] = airdrop.StartTime
+		updates["end_time"] = airdrop.EndTime
+	}
+	if err := tx.Table("airdrop_campaigns").Where("airdrop_id = ?", *airdrop.AirdropId).Updates(updates).Error; err != nil {
+		return err
+	}
+	var taskIds []int64
+	if err := json.Unmarshal([]byte(airdrop.TaskIds), &taskIds); err != nil {
+		return err
+	}
+	if len(taskIds) == 0 {
+		return tx.Exec(`DELETE FROM airdrop_task_bindings WHERE airdrop_id = ?`, *airdrop.AirdropId).Error
+	}
+	if err := tx.Exec(`DELETE FROM airdrop_task_bindings WHERE airdrop_id = ? AND task_id NOT IN ?`, *airdrop.AirdropId, taskIds).Error; err != nil {
+		return err
+	}
+	for _, taskId := range taskIds {
+		if err := tx.Exec(`
+            INSERT INTO airdrop_task_bindings (airdrop_id, task_id) VALUES (?, ?)
+            ON CONFLICT (airdrop_id, task_id) DO NOTHING
+        `, *airdrop.AirdropId, taskId).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
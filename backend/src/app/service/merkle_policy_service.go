@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/abi"
+	"github.com/mumu/cryptoSwap/src/app/api/dto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+// 自动更新策略检查项
+const (
+	MerklePolicyTotalReward = "total_reward" // 快照总额超过活动总奖励
+	MerklePolicyRewardPool  = "reward_pool"  // 未领取总额超过奖励池余额，或无法核对余额
+	MerklePolicyClaimed     = "claimed"      // 有用户的新分配低于已领取金额
+	MerklePolicyMaxChanges  = "max_changes"  // 变化的地址数超过上限
+	MerklePolicySigner      = "signer"       // 未配置签名账户，无法自动发布
+)
+
+const defaultMerkleMaxChanges = 50
+
+// MerklePolicyViolation 未通过的策略检查项
+type MerklePolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// MerkleReviewInput 审批参数，approve 时 mode 为发布方式
+type MerkleReviewInput struct {
+	Mode   string `json:"mode"`
+	Reason string `json:"reason"` // 拒绝原因
+}
+
+// AutoUpdate 定时任务：生成快照，通过策略检查时以托管交易发布，否则进入待审批。
+// 管理员手动生成的快照、发布失败与已审批处理过的快照不会被自动发布
+func (s *MerkleSnapshotService) AutoUpdate(airdropId int64) error {
+	snapshot, created, err := s.BuildSnapshot(airdropId, MerkleSnapshotCreatedByAuto)
+	if err != nil {
+		return err
+	}
+	if snapshot.Status != model.MerkleSnapshotBuilt || snapshot.CreatedBy != MerkleSnapshotCreatedByAuto {
+		return nil
+	}
+	// 等待发布中的快照完成，下一轮再检查（届时白名单已切换，变化数按新的生效分配计算）
+	var inFlight int64
+	if err := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("airdrop_id = ? AND status = ?", airdropId, model.MerkleSnapshotPublishing).
+		Count(&inFlight).Error; err != nil {
+		return err
+	}
+	if inFlight > 0 {
+		return nil
+	}
+	violations, err := s.CheckPolicy(snapshot)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(config.Conf.Airdrop.Signer) == "" {
+		violations = append(violations, MerklePolicyViolation{Rule: MerklePolicySigner, Message: "未配置 airdrop.signer，需审批后以 safe 方式发布"})
+	}
+	if len(violations) > 0 {
+		data, _ := json.Marshal(violations)
+		res := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("id = ? AND status = ?", snapshot.Id, model.MerkleSnapshotBuilt).
+			Updates(map[string]interface{}{
+				"status":            model.MerkleSnapshotPendingApproval,
+				"policy_violations": string(data),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			log.Logger.Warn("默克尔快照未通过自动发布策略，等待审批", zap.Int64("airdrop_id", airdropId),
+				zap.Int("version", snapshot.Version), zap.String("violations", string(data)))
+		}
+		return nil
+	}
+	if _, err := s.PublishAuto(snapshot); err != nil {
+		return err
+	}
+	log.Logger.Info("默克尔快照已自动发布", zap.Int64("airdrop_id", airdropId), zap.Int("version", snapshot.Version),
+		zap.String("root", snapshot.Root), zap.Bool("created", created))
+	return nil
+}
+
+// CheckPolicy 检查快照是否可自动发布：总额不超过活动总奖励与奖励池可用余额，
+// 无用户分配低于其已领取金额，相对当前生效分配变化的地址数不超过上限
+func (s *MerkleSnapshotService) CheckPolicy(snapshot *model.MerkleSnapshot) ([]MerklePolicyViolation, error) {
+	violations := make([]MerklePolicyViolation, 0)
+	total, ok := new(big.Int).SetString(snapshot.TotalAmount, 10)
+	if !ok {
+		return nil, fmt.Errorf("无效的快照总额 %s", snapshot.TotalAmount)
+	}
+
+	campaign, err := merkleCampaignOf(snapshot.AirdropId)
+	if err != nil {
+		return nil, err
+	}
+	totalReward, ok := new(big.Int).SetString(campaign.TotalReward, 10)
+	if !ok || total.Cmp(totalReward) > 0 {
+		violations = append(violations, MerklePolicyViolation{
+			Rule:    MerklePolicyTotalReward,
+			Message: fmt.Sprintf("快照总额 %s 超过活动总奖励 %s", total, campaign.TotalReward),
+		})
+	}
+
+	// 奖励池需覆盖本快照未领取部分与同一合约下其他空投的未领取部分
+	var claimed, othersOutstanding string
+	if err := ctx.Ctx.DB.Raw(`SELECT COALESCE(SUM(claim_amount), 0)::text FROM reward_claimed_events WHERE airdrop_id = ?`,
+		snapshot.AirdropId).Scan(&claimed).Error; err != nil {
+		return nil, err
+	}
+	if err := ctx.Ctx.DB.Raw(`
+        SELECT GREATEST(
+            (SELECT COALESCE(SUM(w.total_reward), 0) FROM airdrop_whitelist w
+                JOIN airdrop_campaigns c ON c.airdrop_id = w.airdrop_id
+                WHERE c.chain_id = ? AND c.merkle_airdrop_contract = ? AND w.airdrop_id <> ?)
+            - (SELECT COALESCE(SUM(claim_amount), 0) FROM reward_claimed_events
+                WHERE chain_id = ? AND contract_address = ? AND airdrop_id <> ?), 0)::text
+    `, snapshot.ChainId, snapshot.ContractAddress, snapshot.AirdropId,
+		snapshot.ChainId, snapshot.ContractAddress, snapshot.AirdropId).Scan(&othersOutstanding).Error; err != nil {
+		return nil, err
+	}
+	claimedWei, _ := new(big.Int).SetString(claimed, 10)
+	othersWei, _ := new(big.Int).SetString(othersOutstanding, 10)
+	outstanding := new(big.Int).Sub(total, claimedWei)
+	if outstanding.Sign() < 0 {
+		outstanding.SetInt64(0)
+	}
+	outstanding.Add(outstanding, othersWei)
+	balance, err := rewardPoolBalance(snapshot.ChainId)
+	switch {
+	case err != nil:
+		violations = append(violations, MerklePolicyViolation{Rule: MerklePolicyRewardPool, Message: "无法核对奖励池余额: " + err.Error()})
+	case outstanding.Cmp(balance) > 0:
+		violations = append(violations, MerklePolicyViolation{
+			Rule:    MerklePolicyRewardPool,
+			Message: fmt.Sprintf("未领取总额 %s 超过奖励池余额 %s", outstanding, balance),
+		})
+	}
+
+	// 已领取金额以索引的 RewardClaimed 事件为准
+	type underClaimed struct {
+		UserAddress string
+		Claimed     string
+		Amount      string
+	}
+	var under []underClaimed
+	if err := ctx.Ctx.DB.Raw(`
+        SELECT c.user_address, c.claimed::text AS claimed, COALESCE(l.amount, 0)::text AS amount
+        FROM (SELECT user_address, SUM(claim_amount) AS claimed FROM reward_claimed_events
+              WHERE airdrop_id = ? GROUP BY user_address) c
+        LEFT JOIN merkle_snapshot_leaves l ON l.snapshot_id = ? AND l.wallet_address = c.user_address
+        WHERE COALESCE(l.amount, 0) < c.claimed
+        ORDER BY c.user_address
+    `, snapshot.AirdropId, snapshot.Id).Scan(&under).Error; err != nil {
+		return nil, err
+	}
+	if len(under) > 0 {
+		examples := make([]string, 0, 5)
+		for i := 0; i < len(under) && i < 5; i++ {
+			examples = append(examples, fmt.Sprintf("%s 分配 %s < 已领取 %s", under[i].UserAddress, under[i].Amount, under[i].Claimed))
+		}
+		violations = append(violations, MerklePolicyViolation{
+			Rule:    MerklePolicyClaimed,
+			Message: fmt.Sprintf("%d 个用户的分配低于已领取金额：%s", len(under), strings.Join(examples, "；")),
+		})
+	}
+
+	// 当前生效的分配即白名单
+	var changes int64
+	if err := ctx.Ctx.DB.Raw(`
+        SELECT COUNT(*)
+        FROM (SELECT wallet_address, amount FROM merkle_snapshot_leaves WHERE snapshot_id = ?) a
+        FULL OUTER JOIN (SELECT wallet_address, total_reward AS amount FROM airdrop_whitelist WHERE airdrop_id = ?) b
+            ON a.wallet_address = b.wallet_address
+        WHERE a.amount IS DISTINCT FROM b.amount
+    `, snapshot.Id, snapshot.AirdropId).Scan(&changes).Error; err != nil {
+		return nil, err
+	}
+	maxChanges := config.Conf.Airdrop.AutoUpdate.MaxChanges
+	if maxChanges <= 0 {
+		maxChanges = defaultMerkleMaxChanges
+	}
+	if changes > int64(maxChanges) {
+		violations = append(violations, MerklePolicyViolation{
+			Rule:    MerklePolicyMaxChanges,
+			Message: fmt.Sprintf("%d 个地址的分配发生变化，超过上限 %d", changes, maxChanges),
+		})
+	}
+	return violations, nil
+}
+
+// rewardPoolBalance 配置的奖励池持有的奖励代币余额
+func rewardPoolBalance(chainId int64) (*big.Int, error) {
+	var pool *config.AirdropRewardPoolConfig
+	for i := range config.Conf.Airdrop.AutoUpdate.RewardPools {
+		if config.Conf.Airdrop.AutoUpdate.RewardPools[i].ChainId == chainId {
+			pool = &config.Conf.Airdrop.AutoUpdate.RewardPools[i]
+			break
+		}
+	}
+	if pool == nil || !common.IsHexAddress(pool.Token) || !common.IsHexAddress(pool.Address) {
+		return nil, fmt.Errorf("链 %d 未配置 airdrop.auto_update.reward_pools", chainId)
+	}
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return nil, fmt.Errorf("不支持的 chainId: %d", chainId)
+	}
+	erc20ABI := abi.GetERC20ABI()
+	data, err := erc20ABI.Pack("balanceOf", common.HexToAddress(pool.Address))
+	if err != nil {
+		return nil, err
+	}
+	token := common.HexToAddress(pool.Token)
+	res, err := ctx.GetEvmClient(int(chainId)).CallContract(context.Background(), ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	out, err := erc20ABI.Unpack("balanceOf", res)
+	if err != nil || len(out) == 0 {
+		return nil, fmt.Errorf("解析 balanceOf 失败: %v", err)
+	}
+	balance, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("balanceOf 返回类型错误")
+	}
+	return balance, nil
+}
+
+// ListPending 所有空投待审批的快照
+func (s *MerkleSnapshotService) ListPending(operator string, pagination dto.Pagination) ([]model.MerkleSnapshot, int64, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, 0, err
+	}
+	query := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("status = ?", model.MerkleSnapshotPendingApproval)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := make([]model.MerkleSnapshot, 0)
+	if err := query.Order("id ASC").Offset(pagination.Offset).Limit(pagination.PageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Approve 审批通过待审批的快照并发布
+func (s *MerkleSnapshotService) Approve(operator string, airdropId int64, version int, in MerkleReviewInput) (*model.MerkleSnapshot, error) {
+	snapshot, err := s.review(operator, airdropId, version, model.MerkleSnapshotBuilt)
+	if err != nil {
+		return nil, err
+	}
+	return s.publish(snapshot, strings.ToLower(operator), in.Mode)
+}
+
+// Reject 拒绝待审批的快照，之后不会被自动发布
+func (s *MerkleSnapshotService) Reject(operator string, airdropId int64, version int, in MerkleReviewInput) (*model.MerkleSnapshot, error) {
+	snapshot, err := s.review(operator, airdropId, version, model.MerkleSnapshotRejected)
+	if err != nil {
+		return nil, err
+	}
+	if in.Reason != "" {
+		if err := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("id = ?", snapshot.Id).Update("error", in.Reason).Error; err != nil {
+			return nil, err
+		}
+	}
+	log.Logger.Info("默克尔快照审批拒绝", zap.Int64("airdrop_id", airdropId), zap.Int("version", version), zap.String("operator", operator))
+	return s.snapshotOf(airdropId, version)
+}
+
+// review 待审批快照转为 status 并记录审批人
+func (s *MerkleSnapshotService) review(operator string, airdropId int64, version int, status string) (*model.MerkleSnapshot, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	snapshot, err := s.snapshotOf(airdropId, version)
+	if err != nil {
+		return nil, err
+	}
+	res := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("id = ? AND status = ?", snapshot.Id, model.MerkleSnapshotPendingApproval).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": strings.ToLower(operator),
+			"reviewed_at": time.Now(),
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: 版本 %d 不是待审批状态", ErrMerkleSnapshotInvalidParam, version)
+	}
+	snapshot.Status = status
+	return snapshot, nil
+}
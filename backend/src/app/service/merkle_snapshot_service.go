@@ -0,0 +1,680 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/app/api/dto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/common/merkle"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MerkleSnapshotCreatedByAuto 定时任务生成的快照的 created_by
+const MerkleSnapshotCreatedByAuto = "auto"
+
+var (
+	// ErrMerkleSnapshotInvalidParam 快照参数错误或当前状态不允许该操作
+	ErrMerkleSnapshotInvalidParam = errors.New("默克尔快照参数无效")
+	// ErrMerkleSnapshotForbidden 调用方不在 airdrop.admins 内
+	ErrMerkleSnapshotForbidden = errors.New("无权管理默克尔快照")
+	// ErrMerkleSnapshotNotFound 快照不存在
+	ErrMerkleSnapshotNotFound = errors.New("默克尔快照不存在")
+)
+
+// merkleAirdropsABI MerkleAirdrop.airdrops(uint256)，只用于读取 treeVersion
+const merkleAirdropsABI = `[{"inputs":[{"internalType":"uint256","name":"","type":"uint256"}],"name":"airdrops","outputs":[{"internalType":"uint256","name":"id","type":"uint256"},{"internalType":"string","name":"name","type":"string"},{"internalType":"bytes32","name":"merkleRoot","type":"bytes32"},{"internalType":"uint256","name":"totalReward","type":"uint256"},{"internalType":"uint256","name":"claimedReward","type":"uint256"},{"internalType":"uint256","name":"startTime","type":"uint256"},{"internalType":"uint256","name":"endTime","type":"uint256"},{"internalType":"bool","name":"isActive","type":"bool"},{"internalType":"uint256","name":"treeVersion","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+// airdropLeafEncoding 空投叶子编码，需与 MerkleAirdrop.calculateLeafHash 一致：keccak256(abi.encodePacked(address,uint256 totalRewardWei))
+var airdropLeafEncoding = merkle.PackedEncoding("address", "uint256")
+
+// MerklePublishInput 发布参数
+type MerklePublishInput struct {
+	Mode string `json:"mode"` // tx（默认）服务端托管交易发送，safe 生成 Safe 多签提案
+}
+
+// MerkleAllocationChange 两个快照间单个地址的分配变化，新增时 from 为空，移除时 to 为空
+type MerkleAllocationChange struct {
+	WalletAddress string `json:"walletAddress"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Delta         string `json:"delta"`
+}
+
+// MerkleSnapshotDiff 两个快照的分配差异
+type MerkleSnapshotDiff struct {
+	AirdropId   int64                    `json:"airdropId"`
+	FromVersion int                      `json:"fromVersion"`
+	ToVersion   int                      `json:"toVersion"`
+	FromRoot    string                   `json:"fromRoot"`
+	ToRoot      string                   `json:"toRoot"`
+	TotalDelta  string                   `json:"totalDelta"` // to.totalAmount - from.totalAmount
+	Added       []MerkleAllocationChange `json:"added"`
+	Removed     []MerkleAllocationChange `json:"removed"`
+	Changed     []MerkleAllocationChange `json:"changed"`
+}
+
+// merkleCampaign 快照所属空投
+type merkleCampaign struct {
+	ChainId               int64
+	MerkleAirdropContract string
+	MerkleRoot            string
+	TotalReward           string
+}
+
+// MerkleSnapshotService 空投默克尔快照：按任务奖励生成不可变快照，显式发布上链，发布确认后切换白名单证明；
+// 回滚为复制历史快照生成新版本并重新发布
+type MerkleSnapshotService struct{}
+
+func NewMerkleSnapshotService() *MerkleSnapshotService {
+	return &MerkleSnapshotService{}
+}
+
+// CheckOperator 调用方需在 airdrop.admins 内
+func (s *MerkleSnapshotService) CheckOperator(operator string) error {
+	if !common.IsHexAddress(operator) {
+		return fmt.Errorf("%w: 无效的登录地址", ErrMerkleSnapshotForbidden)
+	}
+	if !isAirdropAdmin(operator) {
+		return fmt.Errorf("%w: %s 不在 airdrop.admins 内", ErrMerkleSnapshotForbidden, operator)
+	}
+	return nil
+}
+
+// isAirdropAdmin 地址是否在 airdrop.admins 内
+func isAirdropAdmin(operator string) bool {
+	for _, a := range config.Conf.Airdrop.Admins {
+		if strings.EqualFold(strings.TrimSpace(a), operator) {
+			return true
+		}
+	}
+	return false
+}
+
+func merkleCampaignOf(airdropId int64) (*merkleCampaign, error) {
+	var campaigns []merkleCampaign
+	if err := ctx.Ctx.DB.Raw(`SELECT chain_id, COALESCE(merkle_airdrop_contract, '') AS merkle_airdrop_contract,
+            COALESCE(merkle_root, '') AS merkle_root, total_reward::text AS total_reward
+        FROM airdrop_campaigns WHERE airdrop_id = ?`, airdropId).Scan(&campaigns).Error; err != nil {
+		return nil, err
+	}
+	if len(campaigns) == 0 {
+		return nil, fmt.Errorf("%w: 空投 %d 不存在", ErrMerkleSnapshotInvalidParam, airdropId)
+	}
+	if !common.IsHexAddress(campaigns[0].MerkleAirdropContract) {
+		return nil, fmt.Errorf("%w: 空投 %d 缺少合约地址", ErrMerkleSnapshotInvalidParam, airdropId)
+	}
+	return &campaigns[0], nil
+}
+
+// Rebuild 管理员手动按当前任务奖励生成快照
+func (s *MerkleSnapshotService) Rebuild(operator string, airdropId int64) (*model.MerkleSnapshot, bool, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, false, err
+	}
+	return s.BuildSnapshot(airdropId, strings.ToLower(operator))
+}
+
+// BuildSnapshot 聚合已完成任务的用户奖励并建树；根与最新快照相同时返回最新快照且 created 为 false
+func (s *MerkleSnapshotService) BuildSnapshot(airdropId int64, createdBy string) (*model.MerkleSnapshot, bool, error) {
+	campaign, err := merkleCampaignOf(airdropId)
+	if err != nil {
+		return nil, false, err
+	}
+	// 已完成任务的用户总奖励（以任务绑定的奖励为准，代币单位，默认18位精度）
+	type userReward struct {
+		WalletAddress string
+		Total         string
+	}
+	var rewards []userReward
+	if err := ctx.Ctx.DB.Raw(`
+        SELECT LOWER(uts.wallet_address) AS wallet_address, COALESCE(SUM(t.reward_amount), 0)::text AS total
+        FROM user_task_status uts
+        JOIN airdrop_task_bindings b ON b.task_id = uts.task_id AND b.airdrop_id = ?
+        JOIN tasks t ON t.task_id = uts.task_id
+        WHERE uts.user_status = 2
+        GROUP BY LOWER(uts.wallet_address)
+        ORDER BY 1
+    `, airdropId).Scan(&rewards).Error; err != nil {
+		return nil, false, fmt.Errorf("查询用户奖励失败: %v", err)
+	}
+	values := make([][]interface{}, 0, len(rewards))
+	for _, r := range rewards {
+		d, err := decimal.NewFromString(r.Total)
+		if err != nil || !d.IsPositive() || !common.IsHexAddress(r.WalletAddress) {
+			continue
+		}
+		values = append(values, []interface{}{r.WalletAddress, d.Shift(18).BigInt()})
+	}
+	if len(values) == 0 {
+		return nil, false, fmt.Errorf("%w: 空投 %d 没有可分配的用户奖励", ErrMerkleSnapshotInvalidParam, airdropId)
+	}
+	tree, err := merkle.NewTree(values, airdropLeafEncoding)
+	if err != nil {
+		return nil, false, fmt.Errorf("构建默克尔树失败: %v", err)
+	}
+
+	var latest model.MerkleSnapshot
+	err = ctx.Ctx.DB.Where("airdrop_id = ?", airdropId).Order("version DESC").First(&latest).Error
+	if err == nil && strings.EqualFold(latest.Root, tree.Root().Hex()) {
+		return &latest, false, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	snapshot, err := s.saveSnapshot(airdropId, campaign, tree, model.MerkleSnapshotSourceRebuild, nil, createdBy)
+	if err != nil {
+		return nil, false, err
+	}
+	return snapshot, true, nil
+}
+
+// saveSnapshot 分配版本号并写入快照与叶子
+func (s *MerkleSnapshotService) saveSnapshot(airdropId int64, campaign *merkleCampaign, tree *merkle.Tree, source string, rollbackOf *int, createdBy string) (*model.MerkleSnapshot, error) {
+	treeJson, err := tree.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	leaves := make([]model.MerkleSnapshotLeaf, 0, tree.Len())
+	for i := 0; i < tree.Len(); i++ {
+		value, _ := tree.Value(i)
+		leaf, _ := tree.Leaf(i)
+		proof, err := tree.GetProof(i)
+		if err != nil {
+			return nil, err
+		}
+		proofHex := make([]string, len(proof))
+		for k := range proof {
+			proofHex[k] = proof[k].Hex()
+		}
+		proofJson, _ := json.Marshal(proofHex)
+		amount, _ := new(big.Int).SetString(value[1].(string), 10)
+		total.Add(total, amount)
+		leaves = append(leaves, model.MerkleSnapshotLeaf{
+			WalletAddress: strings.ToLower(value[0].(string)),
+			Amount:        amount.String(),
+			LeafHash:      leaf.Hex(),
+			Proof:         string(proofJson),
+		})
+	}
+
+	snapshot := &model.MerkleSnapshot{
+		AirdropId:       airdropId,
+		ChainId:         campaign.ChainId,
+		ContractAddress: strings.ToLower(campaign.MerkleAirdropContract),
+		Root:            tree.Root().Hex(),
+		LeafCount:       tree.Len(),
+		TotalAmount:     total.String(),
+		Tree:            string(treeJson),
+		Source:          source,
+		RollbackOf:      rollbackOf,
+		CreatedBy:       createdBy,
+		Status:          model.MerkleSnapshotBuilt,
+	}
+	err = ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("merkle_snapshot:%d", airdropId)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.MerkleSnapshot{}).Where("airdrop_id = ?", airdropId).
+			Select("COALESCE(MAX(version), 0) + 1").Scan(&snapshot.Version).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(snapshot).Error; err != nil {
+			return err
+		}
+		// 出现更新的快照后，待审批的旧快照不再审批
+		if err := tx.Model(&model.MerkleSnapshot{}).Where("airdrop_id = ? AND status = ?", airdropId, model.MerkleSnapshotPendingApproval).
+			Update("status", model.MerkleSnapshotSuperseded).Error; err != nil {
+			return err
+		}
+		for i := range leaves {
+			leaves[i].SnapshotId = snapshot.Id
+		}
+		return tx.CreateInBatches(leaves, 500).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存默克尔快照失败: %v", err)
+	}
+	log.Logger.Info("默克尔快照已生成", zap.Int64("airdrop_id", airdropId), zap.Int("version", snapshot.Version),
+		zap.String("root", snapshot.Root), zap.Int("leaves", snapshot.LeafCount), zap.String("source", source))
+	return snapshot, nil
+}
+
+// List 快照列表，按版本倒序，可按状态过滤
+func (s *MerkleSnapshotService) List(operator string, airdropId int64, status string, pagination dto.Pagination) ([]model.MerkleSnapshot, int64, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, 0, err
+	}
+	query := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("airdrop_id = ?", airdropId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := make([]model.MerkleSnapshot, 0)
+	if err := query.Order("version DESC").Offset(pagination.Offset).Limit(pagination.PageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Get 快照详情
+func (s *MerkleSnapshotService) Get(operator string, airdropId int64, version int) (*model.MerkleSnapshot, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	return s.snapshotOf(airdropId, version)
+}
+
+func (s *MerkleSnapshotService) snapshotOf(airdropId int64, version int) (*model.MerkleSnapshot, error) {
+	var snapshot model.MerkleSnapshot
+	err := ctx.Ctx.DB.Where("airdrop_id = ? AND version = ?", airdropId, version).First(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMerkleSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Tree 快照的完整树（OpenZeppelin StandardMerkleTree dump 格式）
+func (s *MerkleSnapshotService) Tree(operator string, airdropId int64, version int) (string, error) {
+	snapshot, err := s.Get(operator, airdropId, version)
+	if err != nil {
+		return "", err
+	}
+	return snapshot.Tree, nil
+}
+
+// Leaves 快照叶子分页，可按地址过滤
+func (s *MerkleSnapshotService) Leaves(operator string, airdropId int64, version int, wallet string, pagination dto.Pagination) ([]model.MerkleSnapshotLeaf, int64, error) {
+	snapshot, err := s.Get(operator, airdropId, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := ctx.Ctx.DB.Model(&model.MerkleSnapshotLeaf{}).Where("snapshot_id = ?", snapshot.Id)
+	if wallet != "" {
+		query = query.Where("wallet_address = ?", strings.ToLower(wallet))
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := make([]model.MerkleSnapshotLeaf, 0)
+	if err := query.Order("wallet_address ASC").Offset(pagination.Offset).Limit(pagination.PageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Diff 比较两个快照的分配：新增、移除与金额变化的地址
+func (s *MerkleSnapshotService) Diff(operator string, airdropId int64, fromVersion, toVersion int) (*MerkleSnapshotDiff, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	from, err := s.snapshotOf(airdropId, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.snapshotOf(airdropId, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	return diffSnapshots(from, to)
+}
+
+// diffSnapshots 按地址全外连接两个快照的叶子
+func diffSnapshots(from, to *model.MerkleSnapshot) (*MerkleSnapshotDiff, error) {
+	type row struct {
+		WalletAddress string
+		FromAmount    *string
+		ToAmount      *string
+	}
+	var rows []row
+	if err := ctx.Ctx.DB.Raw(`
+        SELECT COALESCE(a.wallet_address, b.wallet_address) AS wallet_address,
+               a.amount::text AS from_amount, b.amount::text AS to_amount
+        FROM (SELECT wallet_address, amount FROM merkle_snapshot_leaves WHERE snapshot_id = ?) a
+        FULL OUTER JOIN (SELECT wallet_address, amount FROM merkle_snapshot_leaves WHERE snapshot_id = ?) b
+            ON a.wallet_address = b.wallet_address
+        WHERE a.amount IS DISTINCT FROM b.amount
+        ORDER BY 1
+    `, from.Id, to.Id).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	fromTotal, _ := decimal.NewFromString(from.TotalAmount)
+	toTotal, _ := decimal.NewFromString(to.TotalAmount)
+	diff := &MerkleSnapshotDiff{
+		AirdropId:   from.AirdropId,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		FromRoot:    from.Root,
+		ToRoot:      to.Root,
+		TotalDelta:  toTotal.Sub(fromTotal).String(),
+		Added:       make([]MerkleAllocationChange, 0),
+		Removed:     make([]MerkleAllocationChange, 0),
+		Changed:     make([]MerkleAllocationChange, 0),
+	}
+	for _, r := range rows {
+		change := MerkleAllocationChange{WalletAddress: r.WalletAddress}
+		fromAmount, toAmount := decimal.Zero, decimal.Zero
+		if r.FromAmount != nil {
+			change.From = *r.FromAmount
+			fromAmount, _ = decimal.NewFromString(*r.FromAmount)
+		}
+		if r.ToAmount != nil {
+			change.To = *r.ToAmount
+			toAmount, _ = decimal.NewFromString(*r.ToAmount)
+		}
+		change.Delta = toAmount.Sub(fromAmount).String()
+		switch {
+		case r.FromAmount == nil:
+			diff.Added = append(diff.Added, change)
+		case r.ToAmount == nil:
+			diff.Removed = append(diff.Removed, change)
+		default:
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	return diff, nil
+}
+
+// Publish 发布快照：同一空投同时只能有一个发布中的快照，且版本需高于当前生效的版本
+func (s *MerkleSnapshotService) Publish(operator string, airdropId int64, version int, in MerklePublishInput) (*model.MerkleSnapshot, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	snapshot, err := s.snapshotOf(airdropId, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.publish(snapshot, strings.ToLower(operator), in.Mode)
+}
+
+// Rollback 复制已发布过的历史快照为新版本并重新发布，链上版本号仍然递增
+func (s *MerkleSnapshotService) Rollback(operator string, airdropId int64, version int, in MerklePublishInput) (*model.MerkleSnapshot, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	source, err := s.snapshotOf(airdropId, version)
+	if err != nil {
+		return nil, err
+	}
+	if source.PublishedAt == nil {
+		return nil, fmt.Errorf("%w: 版本 %d 从未发布，不能回滚到该版本", ErrMerkleSnapshotInvalidParam, version)
+	}
+	if source.Status == model.MerkleSnapshotPublished {
+		return nil, fmt.Errorf("%w: 版本 %d 已是当前生效的快照", ErrMerkleSnapshotInvalidParam, version)
+	}
+	campaign, err := merkleCampaignOf(airdropId)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := merkle.LoadJSON([]byte(source.Tree))
+	if err != nil {
+		return nil, fmt.Errorf("加载版本 %d 的默克尔树失败: %v", version, err)
+	}
+	rollbackOf := source.Version
+	snapshot, err := s.saveSnapshot(airdropId, campaign, tree, model.MerkleSnapshotSourceRollback, &rollbackOf, strings.ToLower(operator))
+	if err != nil {
+		return nil, err
+	}
+	return s.publish(snapshot, strings.ToLower(operator), in.Mode)
+}
+
+// PublishAuto 定时任务以托管交易发布快照
+func (s *MerkleSnapshotService) PublishAuto(snapshot *model.MerkleSnapshot) (*model.MerkleSnapshot, error) {
+	return s.publish(snapshot, MerkleSnapshotCreatedByAuto, model.MerklePublishTx)
+}
+
+// publish 先将快照标记为发布中（防止并发发布），再发送托管交易或生成 Safe 提案；
+// newVersion 由快照版本确定（见 snapshotChainVersion）
+func (s *MerkleSnapshotService) publish(snapshot *model.MerkleSnapshot, publishedBy, mode string) (*model.MerkleSnapshot, error) {
+	if mode == "" {
+		mode = model.MerklePublishTx
+	}
+	if mode != model.MerklePublishTx && mode != model.MerklePublishSafe {
+		return nil, fmt.Errorf("%w: 未知的发布方式 %s", ErrMerkleSnapshotInvalidParam, mode)
+	}
+	signerName := strings.TrimSpace(config.Conf.Airdrop.Signer)
+	if mode == model.MerklePublishTx && signerName == "" {
+		return nil, fmt.Errorf("%w: 未配置 airdrop.signer，可使用 safe 方式发布", ErrMerkleSnapshotInvalidParam)
+	}
+	var lastPublished int64
+	if err := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("airdrop_id = ? AND published_at IS NOT NULL", snapshot.AirdropId).
+		Select("COALESCE(MAX(chain_version), 0)").Scan(&lastPublished).Error; err != nil {
+		return nil, err
+	}
+	onChain, err := chainTreeVersion(snapshot.ChainId, snapshot.ContractAddress, snapshot.AirdropId)
+	if err != nil {
+		return nil, fmt.Errorf("读取空投 %d 链上 treeVersion 失败: %v", snapshot.AirdropId, err)
+	}
+	chainVersion, err := snapshotChainVersion(snapshot.Version, lastPublished, onChain)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMerkleSnapshotInvalidParam, err)
+	}
+	res := ctx.Ctx.DB.Exec(`
+        UPDATE merkle_snapshots s SET status = ?, publish_mode = ?, published_by = ?, chain_version = ?, error = '', updated_at = NOW()
+        WHERE s.id = ? AND s.status IN (?, ?)
+          AND NOT EXISTS (SELECT 1 FROM merkle_snapshots o WHERE o.airdrop_id = s.airdrop_id AND o.status = ?)
+          AND NOT EXISTS (SELECT 1 FROM merkle_snapshots o WHERE o.airdrop_id = s.airdrop_id AND o.status = ? AND o.version > s.version)
+    `, model.MerkleSnapshotPublishing, mode, publishedBy, chainVersion,
+		snapshot.Id, model.MerkleSnapshotBuilt, model.MerkleSnapshotFailed,
+		model.MerkleSnapshotPublishing, model.MerkleSnapshotPublished)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: 版本 %d 当前状态不可发布，或已有发布中/更新的已发布版本", ErrMerkleSnapshotInvalidParam, snapshot.Version)
+	}
+
+	updates := map[string]interface{}{}
+	var sendErr error
+	root := common.HexToHash(snapshot.Root)
+	switch mode {
+	case model.MerklePublishTx:
+		admin := NewAirdropAdminService()
+		admin.Configure(snapshot.ChainId, snapshot.ContractAddress, signerName)
+		tx, err := admin.UpdateMerkleRoot(big.NewInt(snapshot.AirdropId), root, chainVersion)
+		if err != nil {
+			sendErr = err
+			break
+		}
+		updates["managed_tx_id"] = tx.Id
+		updates["tx_hash"] = tx.TxHash
+	case model.MerklePublishSafe:
+		proposal, err := NewSafeProposalService().Propose(publishedBy, SafeProposalInput{
+			ChainId:     snapshot.ChainId,
+			Title:       fmt.Sprintf("空投 %d 默克尔根 v%d", snapshot.AirdropId, snapshot.Version),
+			Description: fmt.Sprintf("root %s，%d 个地址，合计 %s wei", snapshot.Root, snapshot.LeafCount, snapshot.TotalAmount),
+			Actions: []SafeProposalActionInput{{
+				Action: model.SafeActionUpdateMerkleRoot,
+				Params: json.RawMessage(fmt.Sprintf(`{"airdropId":"%d","newRoot":"%s","newVersion":%d}`, snapshot.AirdropId, snapshot.Root, chainVersion)),
+			}},
+		})
+		if err != nil {
+			sendErr = err
+			break
+		}
+		updates["safe_proposal_id"] = proposal.Id
+	}
+	if sendErr != nil {
+		updates["status"] = model.MerkleSnapshotFailed
+		updates["error"] = sendErr.Error()
+	}
+	if err := ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("id = ?", snapshot.Id).Updates(updates).Error; err != nil {
+		log.Logger.Error("更新默克尔快照发布状态失败", zap.Int64("id", snapshot.Id), zap.Error(err))
+	}
+	if sendErr != nil {
+		if errors.Is(sendErr, ErrTxWouldRevert) {
+			return nil, fmt.Errorf("%w: %v", ErrMerkleSnapshotInvalidParam, sendErr)
+		}
+		return nil, sendErr
+	}
+	log.Logger.Info("默克尔快照发布中", zap.Int64("airdrop_id", snapshot.AirdropId), zap.Int("version", snapshot.Version),
+		zap.String("mode", mode), zap.String("published_by", publishedBy))
+	return s.snapshotOf(snapshot.AirdropId, snapshot.Version)
+}
+
+// snapshotChainVersion 快照发布到链上的 treeVersion：createAirdrop 的初始版本加快照版本，同一空投内随快照版本递增。
+// 链上版本或已发布的版本更高时（此前的任务按 Unix 时间发布过）在其之后继续递增，合约要求 newVersion 严格大于当前版本
+func snapshotChainVersion(version int, lastPublished, onChain int64) (uint32, error) {
+	chainVersion := managedAirdropInitialTreeVersion.Int64() + int64(version)
+	if floor := max(lastPublished, onChain); chainVersion <= floor {
+		chainVersion = floor + 1
+	}
+	if chainVersion > math.MaxUint32 {
+		return 0, fmt.Errorf("链上 treeVersion 已达 uint32 上限，无法继续更新默克尔根")
+	}
+	return uint32(chainVersion), nil
+}
+
+// chainTreeVersion 读取空投在链上的当前 treeVersion（airdrops(id) 公共 getter）
+func chainTreeVersion(chainId int64, contract string, airdropId int64) (int64, error) {
+	client, err := txClient(chainId)
+	if err != nil {
+		return 0, err
+	}
+	parsed, err := gethabi.JSON(strings.NewReader(merkleAirdropsABI))
+	if err != nil {
+		return 0, err
+	}
+	bound := bind.NewBoundContract(common.HexToAddress(contract), parsed, client, nil, nil)
+	var out []interface{}
+	if err := bound.Call(&bind.CallOpts{Context: context.Background()}, &out, "airdrops", big.NewInt(airdropId)); err != nil {
+		return 0, err
+	}
+	version, _ := out[len(out)-1].(*big.Int)
+	if version == nil || !version.IsInt64() {
+		return 0, fmt.Errorf("airdrops(%d) 返回的 treeVersion 无效", airdropId)
+	}
+	return version.Int64(), nil
+}
+
+// ReconcilePublishing 按托管交易或 Safe 提案结果更新发布中的快照，成功后切换白名单证明，返回完成的数量
+func (s *MerkleSnapshotService) ReconcilePublishing() (int, error) {
+	var snapshots []model.MerkleSnapshot
+	if err := ctx.Ctx.DB.Where("status = ?", model.MerkleSnapshotPublishing).Order("id ASC").Limit(100).Find(&snapshots).Error; err != nil {
+		return 0, err
+	}
+	done := 0
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		txHash, failure, ok, err := s.publishResult(snapshot)
+		if err != nil {
+			log.Logger.Warn("查询默克尔快照发布结果失败", zap.Int64("id", snapshot.Id), zap.Error(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if failure != "" {
+			err = ctx.Ctx.DB.Model(&model.MerkleSnapshot{}).Where("id = ?", snapshot.Id).Updates(map[string]interface{}{
+				"status":  model.MerkleSnapshotFailed,
+				"error":   failure,
+				"tx_hash": txHash,
+			}).Error
+		} else {
+			err = s.activate(snapshot, txHash)
+		}
+		if err != nil {
+			log.Logger.Error("更新默克尔快照发布结果失败", zap.Int64("id", snapshot.Id), zap.Error(err))
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+// publishResult ok 为 false 表示仍在等待；failure 非空表示发布失败
+func (s *MerkleSnapshotService) publishResult(snapshot *model.MerkleSnapshot) (txHash, failure string, ok bool, err error) {
+	if snapshot.ManagedTxId != nil {
+		tx, err := NewManagedTxService().Get(*snapshot.ManagedTxId)
+		if err != nil {
+			return "", "", false, err
+		}
+		switch tx.Status {
+		case model.ManagedTxConfirmed:
+			return tx.TxHash, "", true, nil
+		case model.ManagedTxReverted, model.ManagedTxCancelled, model.ManagedTxFailed:
+			return tx.TxHash, fmt.Sprintf("托管交易 %s: %s", tx.Status, tx.Error), true, nil
+		}
+		return "", "", false, nil
+	}
+	if snapshot.SafeProposalId != nil {
+		var proposal model.SafeProposal
+		if err := ctx.Ctx.DB.Where("id = ?", *snapshot.SafeProposalId).First(&proposal).Error; err != nil {
+			return "", "", false, err
+		}
+		switch proposal.Status {
+		case model.SafeProposalIndexed:
+			return proposal.ExecTxHash, "", true, nil
+		case model.SafeProposalFailed, model.SafeProposalReplaced:
+			return proposal.ExecTxHash, "Safe 提案 " + proposal.Status, true, nil
+		}
+		return "", "", false, nil
+	}
+	// 标记发布中后发送前进程退出，视为失败以便重新发布
+	if time.Since(snapshot.UpdatedAt) > 10*time.Minute {
+		return "", "发布中断，未提交交易", true, nil
+	}
+	return "", "", false, nil
+}
+
+// activate 链上根已更新：旧的生效快照标记为已替代，白名单切换为该快照的分配与证明
+func (s *MerkleSnapshotService) activate(snapshot *model.MerkleSnapshot, txHash string) error {
+	err := ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.MerkleSnapshot{}).Where("airdrop_id = ? AND status = ?", snapshot.AirdropId, model.MerkleSnapshotPublished).
+			Update("status", model.MerkleSnapshotSuperseded).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+            DELETE FROM airdrop_whitelist w
+            WHERE w.airdrop_id = ?
+              AND NOT EXISTS (SELECT 1 FROM merkle_snapshot_leaves l WHERE l.snapshot_id = ? AND l.wallet_address = w.wallet_address)
+        `, snapshot.AirdropId, snapshot.Id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+            INSERT INTO airdrop_whitelist (airdrop_id, wallet_address, total_reward, proof)
+            SELECT ?, wallet_address, amount, proof FROM merkle_snapshot_leaves WHERE snapshot_id = ?
+            ON CONFLICT (airdrop_id, wallet_address)
+            DO UPDATE SET total_reward = EXCLUDED.total_reward, proof = EXCLUDED.proof
+        `, snapshot.AirdropId, snapshot.Id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE airdrop_campaigns SET merkle_root = ?, updated_at = NOW() WHERE airdrop_id = ?`,
+			strings.ToLower(snapshot.Root), snapshot.AirdropId).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.MerkleSnapshot{}).Where("id = ?", snapshot.Id).Updates(map[string]interface{}{
+			"status":       model.MerkleSnapshotPublished,
+			"tx_hash":      txHash,
+			"published_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	log.Logger.Info("默克尔快照已生效", zap.Int64("airdrop_id", snapshot.AirdropId), zap.Int("version", snapshot.Version),
+		zap.String("root", snapshot.Root), zap.String("tx_hash", txHash))
+	return nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSnapshotChainVersion(t *testing.T) {
+	cases := []struct {
+		name          string
+		version       int
+		lastPublished int64
+		onChain       int64
+		want          uint32
+	}{
+		// createAirdrop 的 treeVersion 为 1，首个快照须高于它
+		{"首个快照", 1, 0, 1, 2},
+		{"按快照版本递增", 5, 5, 5, 6},
+		{"回滚生成的新版本", 7, 7, 7, 8},
+		// 此前的任务按 Unix 时间更新过默克尔根，链上版本远高于快照版本
+		{"链上版本远高于快照版本", 2, 0, 1700000000, 1700000001},
+		{"已发布版本高于链上", 3, 1700000005, 1700000000, 1700000006},
+		{"链上高于已发布版本", 3, 1700000005, 1700000100, 1700000101},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := snapshotChainVersion(c.version, c.lastPublished, c.onChain)
+			if err != nil || got != c.want {
+				t.Fatalf("snapshotChainVersion(%d, %d, %d) = %d, %v, want %d", c.version, c.lastPublished, c.onChain, got, err, c.want)
+			}
+		})
+	}
+
+	if _, err := snapshotChainVersion(1, 0, math.MaxUint32); err == nil {
+		t.Fatal("链上版本已达 uint32 上限时应报错")
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"math/big"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	PoolTypeV2 = "v2"
+	PoolTypeV3 = "v3"
+)
+
+// PoolModel 屏蔽 V2（恒定乘积）与 V3（集中流动性）池子在定价、交易量、锁仓量与持仓记账上的差异。
+// 两种池子的 Reserve0/Reserve1 均为池子合约持有的代币余额，事件金额均以 in/out 记账。
+type PoolModel interface {
+	// Type 池子类型
+	Type() string
+	// SpotPrice 当前价格（token1/token0，已按精度换算）
+	SpotPrice(pool model.LiquidityPool) decimal.Decimal
+	// HistoricalPrice 历史时点的价格：reserve0/reserve1 为该时点的代币余额，lastSwap 为该时点之前最后一笔Swap（可为 nil）
+	HistoricalPrice(pool model.LiquidityPool, reserve0, reserve1 *big.Int, lastSwap *model.LiquidityPoolEvent) decimal.Decimal
+	// TVLUSD 池子当前USD锁仓量
+	TVLUSD(pool model.LiquidityPool, prices map[string]*TokenPriceQuote) decimal.Decimal
+	// BalanceDelta 单个事件导致的池子代币余额变动
+	BalanceDelta(e model.LiquidityPoolEvent) (*big.Int, *big.Int)
+	// SwapAmounts 单笔Swap两侧的成交数量（原始单位）
+	SwapAmounts(e model.LiquidityPoolEvent) (*big.Int, *big.Int)
+	// FungibleShares 流动性份额是否为可转让的LP代币（决定是否记录LP总供应量与LP转账）
+	FungibleShares() bool
+	// ApplyPositions 在事务内将本批次LP转账与流动性事件累计到持仓
+	ApplyPositions(tx *gorm.DB, pool model.LiquidityPool, transfers []model.LpTransferEvent, events []model.LiquidityPoolEvent) error
+	// RebuildPositions 按全部已索引事件重建池子持仓
+	RebuildPositions(tx *gorm.DB, pool model.LiquidityPool) error
+}
+
+// PoolModelOf 按池子类型返回对应实现，未标记类型的历史池子按 V2 处理
+func PoolModelOf(pool model.LiquidityPool) PoolModel {
+	if pool.PoolType == PoolTypeV3 {
+		return v3PoolModel{}
+	}
+	return v2PoolModel{}
+}
+
+type v2PoolModel struct{}
+
+func (v2PoolModel) Type() string {
+	return PoolTypeV2
+}
+
+func (m v2PoolModel) SpotPrice(pool model.LiquidityPool) decimal.Decimal {
+	return m.HistoricalPrice(pool, parseBigInt(pool.Reserve0), parseBigInt(pool.Reserve1), nil)
+}
+
+// HistoricalPrice V2 价格即储备量之比
+func (v2PoolModel) HistoricalPrice(pool model.LiquidityPool, reserve0, reserve1 *big.Int, _ *model.LiquidityPoolEvent) decimal.Decimal {
+	amount0 := tokenAmount(reserve0, pool.Token0Decimals)
+	if !amount0.IsPositive() {
+		return decimal.Zero
+	}
+	return tokenAmount(reserve1, pool.Token1Decimals).DivRound(amount0, 18)
+}
+
+func (v2PoolModel) TVLUSD(pool model.LiquidityPool, prices map[string]*TokenPriceQuote) decimal.Decimal {
+	return poolTVLFromReserves(pool, parseBigInt(pool.Reserve0), parseBigInt(pool.Reserve1), prices)
+}
+
+// BalanceDelta V2 的兑换与增减流动性都会立即转入/转出代币
+func (v2PoolModel) BalanceDelta(e model.LiquidityPoolEvent) (*big.Int, *big.Int) {
+	return netInOut(e)
+}
+
+func (v2PoolModel) SwapAmounts(e model.LiquidityPoolEvent) (*big.Int, *big.Int) {
+	return grossInOut(e)
+}
+
+func (v2PoolModel) FungibleShares() bool {
+	return true
+}
+
+func (v2PoolModel) ApplyPositions(tx *gorm.DB, pool model.LiquidityPool, transfers []model.LpTransferEvent, events []model.LiquidityPoolEvent) error {
+	return NewLpPositionService().ApplyActivity(tx, pool, transfers, events)
+}
+
+func (v2PoolModel) RebuildPositions(tx *gorm.DB, pool model.LiquidityPool) error {
+	return NewLpPositionService().RebuildPositions(tx, pool)
+}
+
+// netInOut 事件的净流入（in − out）
+func netInOut(e model.LiquidityPoolEvent) (*big.Int, *big.Int) {
+	d0 := new(big.Int).Sub(parseBigInt(e.Amount0In), parseBigInt(e.Amount0Out))
+	d1 := new(big.Int).Sub(parseBigInt(e.Amount1In), parseBigInt(e.Amount1Out))
+	return d0, d1
+}
+
+// grossInOut 事件两侧的成交总量（in + out）
+func grossInOut(e model.LiquidityPoolEvent) (*big.Int, *big.Int) {
+	v0 := new(big.Int).Add(parseBigInt(e.Amount0In), parseBigInt(e.Amount0Out))
+	v1 := new(big.Int).Add(parseBigInt(e.Amount1In), parseBigInt(e.Amount1Out))
+	return v0, v1
+}
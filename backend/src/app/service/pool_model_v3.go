@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/abi"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ChainServiceV3Factory chain 表中 V3 工厂合约的服务类型：索引工厂的 PoolCreated 及其创建的全部池子事件
+const ChainServiceV3Factory = "v3_factory"
+
+// q96 V3 价格的定点数基数 2^96
+var q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+type v3PoolModel struct{}
+
+func (v3PoolModel) Type() string {
+	return PoolTypeV3
+}
+
+// SpotPrice V3 价格取自 slot0 的 sqrtPriceX96，与余额之比无关
+func (v3PoolModel) SpotPrice(pool model.LiquidityPool) decimal.Decimal {
+	return sqrtPriceToPrice(parseBigInt(pool.SqrtPriceX96), pool.Token0Decimals, pool.Token1Decimals)
+}
+
+// HistoricalPrice 取该时点之前最后一笔Swap记录的 sqrtPriceX96，没有Swap时使用当前价格
+func (m v3PoolModel) HistoricalPrice(pool model.LiquidityPool, _, _ *big.Int, lastSwap *model.LiquidityPoolEvent) decimal.Decimal {
+	if lastSwap != nil {
+		if sqrtPrice := parseBigInt(lastSwap.SqrtPriceX96); sqrtPrice.Sign() > 0 {
+			return sqrtPriceToPrice(sqrtPrice, pool.Token0Decimals, pool.Token1Decimals)
+		}
+	}
+	return m.SpotPrice(pool)
+}
+
+func (v3PoolModel) TVLUSD(pool model.LiquidityPool, prices map[string]*TokenPriceQuote) decimal.Decimal {
+	return poolTVLFromReserves(pool, parseBigInt(pool.Reserve0), parseBigInt(pool.Reserve1), prices)
+}
+
+// BalanceDelta V3 的 Burn 只把代币记入仓位的待提取额度，直到 Collect 才转出池子
+func (v3PoolModel) BalanceDelta(e model.LiquidityPoolEvent) (*big.Int, *big.Int) {
+	if e.EventType == "RemoveLiquidity" {
+		return big.NewInt(0), big.NewInt(0)
+	}
+	return netInOut(e)
+}
+
+func (v3PoolModel) SwapAmounts(e model.LiquidityPoolEvent) (*big.Int, *big.Int) {
+	return grossInOut(e)
+}
+
+func (v3PoolModel) FungibleShares() bool {
+	return false
+}
+
+func (v3PoolModel) ApplyPositions(tx *gorm.DB, pool model.LiquidityPool, _ []model.LpTransferEvent, events []model.LiquidityPoolEvent) error {
+	return NewV3PositionService().ApplyActivity(tx, pool, events)
+}
+
+func (v3PoolModel) RebuildPositions(tx *gorm.DB, pool model.LiquidityPool) error {
+	return NewV3PositionService().RebuildPositions(tx, pool)
+}
+
+// V3PoolState V3 池子的链上状态
+type V3PoolState struct {
+	SqrtPriceX96 *big.Int
+	Tick         int
+	Liquidity    *big.Int
+	FeeProtocol  uint8 // 低4位为 token0 的协议费分母，高4位为 token1
+	Balance0     *big.Int
+	Balance1     *big.Int
+}
+
+// FetchV3PoolState 读取 V3 池子的 slot0、活跃流动性与两种代币余额
+func FetchV3PoolState(chainId int64, poolAddress, token0, token1 string) (*V3PoolState, error) {
+	slot0, err := callView(chainId, poolAddress, abi.ABIUniswapV3Pool, "slot0")
+	if err != nil {
+		return nil, fmt.Errorf("读取 slot0 失败: %v", err)
+	}
+	if len(slot0) < 6 {
+		return nil, fmt.Errorf("slot0 返回值格式错误")
+	}
+	sqrtPrice, _ := slot0[0].(*big.Int)
+	tick, _ := slot0[1].(*big.Int)
+	feeProtocol, _ := slot0[5].(uint8)
+	if sqrtPrice == nil || tick == nil {
+		return nil, fmt.Errorf("slot0 返回值格式错误")
+	}
+
+	liquidityOut, err := callView(chainId, poolAddress, abi.ABIUniswapV3Pool, "liquidity")
+	if err != nil {
+		return nil, fmt.Errorf("读取 liquidity 失败: %v", err)
+	}
+	liquidity, _ := liquidityOut[0].(*big.Int)
+
+	balanceOf := func(token string) (*big.Int, error) {
+		out, err := callView(chainId, token, abi.ABIERC20, "balanceOf", common.HexToAddress(poolAddress))
+		if err != nil {
+			return nil, err
+		}
+		balance, _ := out[0].(*big.Int)
+		if balance == nil {
+			return nil, fmt.Errorf("balanceOf 返回值格式错误")
+		}
+		return balance, nil
+	}
+	balance0, err := balanceOf(token0)
+	if err != nil {
+		return nil, fmt.Errorf("读取 token0 余额失败: %v", err)
+	}
+	balance1, err := balanceOf(token1)
+	if err != nil {
+		return nil, fmt.Errorf("读取 token1 余额失败: %v", err)
+	}
+	if liquidity == nil {
+		liquidity = big.NewInt(0)
+	}
+	return &V3PoolState{
+		SqrtPriceX96: sqrtPrice,
+		Tick:         int(tick.Int64()),
+		Liquidity:    liquidity,
+		FeeProtocol:  feeProtocol,
+		Balance0:     balance0,
+		Balance1:     balance1,
+	}, nil
+}
+
+// callView 调用合约只读方法并返回解码后的全部返回值
+func callView(chainId int64, contract, abiName, method string, args ...interface{}) ([]interface{}, error) {
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return nil, fmt.Errorf("不支持的 chainId: %d", chainId)
+	}
+	contractABI, ok := abi.GetABIManager().GetABI(abiName)
+	if !ok {
+		return nil, fmt.Errorf("%s ABI 未找到", abiName)
+	}
+	data, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	to := common.HexToAddress(contract)
+	res, err := ctx.GetEvmClient(int(chainId)).CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	out, err := contractABI.Unpack(method, res)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s 无返回值", method)
+	}
+	return out, nil
+}
+
+// sqrtPriceToPrice 将 sqrtPriceX96 换算为 token1/token0 价格（按精度换算）
+func sqrtPriceToPrice(sqrtPriceX96 *big.Int, decimals0, decimals1 int) decimal.Decimal {
+	if sqrtPriceX96 == nil || sqrtPriceX96.Sign() <= 0 {
+		return decimal.Zero
+	}
+	num := new(big.Int).Mul(sqrtPriceX96, sqrtPriceX96)
+	den := new(big.Int).Lsh(big.NewInt(1), 192)
+	raw := decimal.NewFromBigInt(num, 0).DivRound(decimal.NewFromBigInt(den, 0), 36)
+	return raw.Shift(int32(decimals0 - decimals1)).Round(18)
+}
+
+// priceToSqrtPrice 将 token1/token0 价格（按精度换算）还原为 sqrtPriceX96
+func priceToSqrtPrice(price decimal.Decimal, decimals0, decimals1 int) *big.Int {
+	if !price.IsPositive() {
+		return big.NewInt(0)
+	}
+	raw, ok := new(big.Float).SetPrec(256).SetString(price.Shift(int32(decimals1 - decimals0)).String())
+	if !ok {
+		return big.NewInt(0)
+	}
+	sqrt := new(big.Float).SetPrec(256).Sqrt(raw)
+	sqrt.Mul(sqrt, new(big.Float).SetInt(q96))
+	v, _ := sqrt.Int(nil)
+	return v
+}
+
+// tickSqrtPrice 计算 tick 对应的 √price = 1.0001^(tick/2)
+func tickSqrtPrice(tick int) *big.Float {
+	return new(big.Float).SetPrec(256).SetFloat64(math.Pow(1.0001, float64(tick)/2))
+}
+
+// positionAmounts 计算区间仓位在给定价格下对应的两种代币数量（原始单位）：
+// 价格低于区间时全部为 token0，高于区间时全部为 token1，区间内按
+// amount0 = L·(√Pb − √P)/(√P·√Pb)，amount1 = L·(√P − √Pa) 计算
+func positionAmounts(liquidity, sqrtPriceX96 *big.Int, tickLower, tickUpper int) (*big.Int, *big.Int) {
+	if liquidity == nil || liquidity.Sign() <= 0 || sqrtPriceX96 == nil || sqrtPriceX96.Sign() <= 0 || tickLower >= tickUpper {
+		return big.NewInt(0), big.NewInt(0)
+	}
+	l := new(big.Float).SetPrec(256).SetInt(liquidity)
+	sa, sb := tickSqrtPrice(tickLower), tickSqrtPrice(tickUpper)
+	s := new(big.Float).SetPrec(256).Quo(new(big.Float).SetInt(sqrtPriceX96), new(big.Float).SetInt(q96))
+	if s.Cmp(sa) < 0 {
+		s = sa
+	} else if s.Cmp(sb) > 0 {
+		s = sb
+	}
+
+	amount0 := new(big.Float).SetPrec(256).Sub(sb, s)
+	amount0.Mul(amount0, l)
+	amount0.Quo(amount0, new(big.Float).SetPrec(256).Mul(s, sb))
+	amount1 := new(big.Float).SetPrec(256).Sub(s, sa)
+	amount1.Mul(amount1, l)
+
+	a0, _ := amount0.Int(nil)
+	a1, _ := amount1.Int(nil)
+	return a0, a1
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/abi"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// maxSnapshotBackfillHours 单次回补的最大小时数，避免首次启动时一次性回补过久的历史
+const maxSnapshotBackfillHours = 24 * 90
+
+type PoolSnapshotService struct {
+	priceSvc *PriceService
+}
+
+func NewPoolSnapshotService() *PoolSnapshotService {
+	return &PoolSnapshotService{
+		priceSvc: NewPriceService(),
+	}
+}
+
+// WindowStats 时间窗口内的汇总数据
+type WindowStats struct {
+	VolumeUSD       decimal.Decimal
+	FeesUSD         decimal.Decimal
+	LpFeesUSD       decimal.Decimal
+	ProtocolFeesUSD decimal.Decimal
+	TxCount         int64
+}
+
+// SumWindow 汇总池子在 [start, end) 小时区间内的交易量、手续费与事件数；poolAddress 为空时汇总全链
+func (s *PoolSnapshotService) SumWindow(chainId int64, poolAddress string, start, end time.Time) (WindowStats, error) {
+	var row WindowStats
+	query := ctx.Ctx.DB.Model(&model.PoolHourSnapshot{}).
+		Select("COALESCE(SUM(volume_usd), 0) AS volume_usd, COALESCE(SUM(fees_usd), 0) AS fees_usd, "+
+			"COALESCE(SUM(lp_fees_usd), 0) AS lp_fees_usd, COALESCE(SUM(protocol_fees_usd), 0) AS protocol_fees_usd, "+
+			"COALESCE(SUM(tx_count), 0) AS tx_count").
+		Where("hour_start >= ? AND hour_start < ?", start.UTC(), end.UTC())
+	if chainId > 0 {
+		query = query.Where("chain_id = ?", chainId)
+	}
+	if poolAddress != "" {
+		query = query.Where("pool_address = ?", poolAddress)
+	}
+	if err := query.Scan(&row).Error; err != nil {
+		return WindowStats{}, err
+	}
+	return row, nil
+}
+
+// LatestSnapshot 查询池子最新一条快照
+func (s *PoolSnapshotService) LatestSnapshot(chainId int64, poolAddress string) (*model.PoolHourSnapshot, error) {
+	var snap model.PoolHourSnapshot
+	err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ?", chainId, poolAddress).
+		Order("hour_start DESC").First(&snap).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// SnapshotAt 查询池子在指定时间点的状态（该时间之前最近结束的一小时）
+func (s *PoolSnapshotService) SnapshotAt(chainId int64, poolAddress string, at time.Time) (*model.PoolHourSnapshot, error) {
+	var snap model.PoolHourSnapshot
+	err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND hour_start <= ?", chainId, poolAddress, at.UTC().Add(-time.Hour)).
+		Order("hour_start DESC").First(&snap).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// SumTvlAt 汇总全链（chainId 为 0 时为所有链）池子在指定时间点的 USD 锁仓量
+func (s *PoolSnapshotService) SumTvlAt(chainId int64, at time.Time) (decimal.Decimal, error) {
+	var tvl decimal.Decimal
+	query := `
+		SELECT COALESCE(SUM(tvl_usd), 0) FROM (
+			SELECT DISTINCT ON (chain_id, pool_address) tvl_usd
+			FROM pool_hour_snapshots
+			WHERE hour_start <= ? AND (? = 0 OR chain_id = ?)
+			ORDER BY chain_id, pool_address, hour_start DESC
+		) t`
+	err := ctx.Ctx.DB.Raw(query, at.UTC().Add(-time.Hour), chainId, chainId).Scan(&tvl).Error
+	return tvl, err
+}
+
+// BuildSnapshots 为池子补齐从最后一条快照（无快照时从首个事件）到上一个完整小时的全部快照。
+// 储备量以池子表中的最新链上余额为锚点，按池子类型撤销之后发生的事件变动逆推；
+// 供应量只在之后有增减流动性时才需要按区块查询归档节点，失败时沿用最新值（V3 没有LP代币，不记录供应量）。
+func (s *PoolSnapshotService) BuildSnapshots(pool model.LiquidityPool, now time.Time) (int, error) {
+	lastHour := now.UTC().Truncate(time.Hour).Add(-time.Hour)
+
+	var first time.Time
+	if latest, err := s.LatestSnapshot(pool.ChainId, pool.PoolAddress); err == nil {
+		first = latest.HourStart.UTC().Add(time.Hour)
+	} else {
+		var firstEvent *time.Time
+		if err := ctx.Ctx.DB.Model(&model.LiquidityPoolEvent{}).
+			Where("chain_id = ? AND pool_address = ?", pool.ChainId, pool.PoolAddress).
+			Select("MIN(COALESCE(block_time, created_at))").Scan(&firstEvent).Error; err != nil {
+			return 0, err
+		}
+		if firstEvent == nil {
+			first = lastHour
+		} else {
+			first = firstEvent.UTC().Truncate(time.Hour)
+		}
+	}
+	if earliest := lastHour.Add(-maxSnapshotBackfillHours * time.Hour); first.Before(earliest) {
+		first = earliest
+	}
+	if first.After(lastHour) {
+		return 0, nil
+	}
+
+	// 读取 first 之后的全部事件（含未完成小时，用于逆推储备量）
+	var events []model.LiquidityPoolEvent
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND COALESCE(block_time, created_at) >= ?",
+		pool.ChainId, pool.PoolAddress, first).Find(&events).Error; err != nil {
+		return 0, err
+	}
+	sortEventsByChainOrder(events)
+	poolModel := PoolModelOf(pool)
+
+	// 按池子当前费率计提：总手续费 = 交易量 × 费率，其中协议分成部分归协议，其余归LP
+	feeBps, protocolFeeBps := PoolFeeRates(pool)
+	feeRate := decimal.NewFromInt(int64(feeBps)).Div(decimal.NewFromInt(10000))
+	protocolRate := decimal.NewFromInt(int64(protocolFeeBps)).Div(decimal.NewFromInt(10000))
+
+	series0 := s.priceSvc.LoadPriceSeries(pool.ChainId, pool.Token0Address, first, now)
+	series1 := s.priceSvc.LoadPriceSeries(pool.ChainId, pool.Token1Address, first, now)
+
+	// 之前23小时的LP手续费，用于计算近24小时年化APY
+	feesByHour := make(map[int64]decimal.Decimal)
+	var prevSnaps []model.PoolHourSnapshot
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND hour_start >= ? AND hour_start < ?",
+		pool.ChainId, pool.PoolAddress, first.Add(-23*time.Hour), first).Find(&prevSnaps).Error; err == nil {
+		for _, p := range prevSnaps {
+			feesByHour[p.HourStart.UTC().Unix()] = p.LpFeesUSD
+		}
+	}
+
+	// 按小时分组
+	hourEvents := make(map[int64][]model.LiquidityPoolEvent)
+	for _, e := range events {
+		hour := eventTime(e).Truncate(time.Hour).Unix()
+		hourEvents[hour] = append(hourEvents[hour], e)
+	}
+
+	// 从最新储备量逆推：先扣除 lastHour 之后（未完成小时）的事件
+	reserve0 := parseBigInt(pool.Reserve0)
+	reserve1 := parseBigInt(pool.Reserve1)
+	liveSupply := parseBigInt(pool.TotalSupply)
+	if !poolModel.FungibleShares() {
+		liveSupply = big.NewInt(0)
+	}
+	laterLiquidityChange := false
+	for _, e := range events {
+		if !eventTime(e).Before(lastHour.Add(time.Hour)) {
+			reserve0, reserve1 = revertEvent(poolModel, reserve0, reserve1, e)
+			if e.EventType != "Swap" {
+				laterLiquidityChange = true
+			}
+		}
+	}
+
+	reserves := make(map[int64][2]*big.Int)
+	supplies := make(map[int64]*big.Int)
+	lastBlocks := make(map[int64]int64)
+	var lastKnownBlock int64
+	for hour := lastHour; !hour.Before(first); hour = hour.Add(-time.Hour) {
+		key := hour.Unix()
+		reserves[key] = [2]*big.Int{new(big.Int).Set(reserve0), new(big.Int).Set(reserve1)}
+		if laterLiquidityChange && poolModel.FungibleShares() {
+			supplies[key] = nil // 需要查询归档节点
+		} else {
+			supplies[key] = liveSupply
+		}
+		for _, e := range hourEvents[key] {
+			reserve0, reserve1 = revertEvent(poolModel, reserve0, reserve1, e)
+			if e.EventType != "Swap" {
+				laterLiquidityChange = true
+			}
+		}
+	}
+
+	// 价格取各小时结束前最后一笔Swap（V3），先找出窗口之前的最后一笔
+	var lastSwap *model.LiquidityPoolEvent
+	var prevSwap model.LiquidityPoolEvent
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND event_type = ? AND COALESCE(block_time, created_at) < ?",
+		pool.ChainId, pool.PoolAddress, "Swap", first).
+		Order("block_number DESC, log_index DESC").First(&prevSwap).Error; err == nil {
+		lastSwap = &prevSwap
+	}
+
+	// 正序计算交易量、手续费、TVL 与 APY
+	snapshots := make([]model.PoolHourSnapshot, 0)
+	supplyCache := make(map[int64]*big.Int)
+	for hour := first; !hour.After(lastHour); hour = hour.Add(time.Hour) {
+		key := hour.Unix()
+		hourEnd := hour.Add(time.Hour)
+		volume := decimal.Zero
+		var txCount int64
+		for i, e := range hourEvents[key] {
+			txCount++
+			lastKnownBlock = e.BlockNumber
+			if e.EventType != "Swap" {
+				continue
+			}
+			lastSwap = &hourEvents[key][i]
+			vol0, vol1 := poolModel.SwapAmounts(e)
+			if p, ok := series0.At(eventTime(e)); ok {
+				volume = volume.Add(tokenAmount(vol0, pool.Token0Decimals).Mul(p))
+			} else if p, ok := series1.At(eventTime(e)); ok {
+				volume = volume.Add(tokenAmount(vol1, pool.Token1Decimals).Mul(p))
+			}
+		}
+		lastBlocks[key] = lastKnownBlock
+
+		supply := supplies[key]
+		if supply == nil {
+			supply = s.totalSupplyAtBlock(pool, lastKnownBlock, liveSupply, supplyCache)
+		}
+
+		r := reserves[key]
+		price0, ok0 := series0.At(hourEnd)
+		price1, ok1 := series1.At(hourEnd)
+		prices := map[string]*TokenPriceQuote{}
+		if ok0 {
+			prices[strings.ToLower(pool.Token0Address)] = &TokenPriceQuote{PriceUSD: price0}
+		}
+		if ok1 {
+			prices[strings.ToLower(pool.Token1Address)] = &TokenPriceQuote{PriceUSD: price1}
+		}
+		tvl := poolTVLFromReserves(pool, r[0], r[1], prices)
+		fees := volume.Mul(feeRate)
+		protocolFees := volume.Mul(protocolRate)
+		lpFees := fees.Sub(protocolFees)
+		feesByHour[key] = lpFees
+
+		fees24h := decimal.Zero
+		for h := hour.Add(-23 * time.Hour); !h.After(hour); h = h.Add(time.Hour) {
+			fees24h = fees24h.Add(feesByHour[h.Unix()])
+		}
+		apy := decimal.Zero
+		if tvl.IsPositive() {
+			apy = fees24h.Div(tvl).Mul(decimal.NewFromInt(365 * 100))
+		}
+
+		price := poolModel.HistoricalPrice(pool, r[0], r[1], lastSwap)
+
+		snapshots = append(snapshots, model.PoolHourSnapshot{
+			ChainId:         pool.ChainId,
+			PoolAddress:     pool.PoolAddress,
+			HourStart:       hour,
+			Reserve0:        r[0].String(),
+			Reserve1:        r[1].String(),
+			TotalSupply:     supply.String(),
+			Price:           price,
+			Token0USD:       price0,
+			Token1USD:       price1,
+			TvlUSD:          tvl.Round(2),
+			VolumeUSD:       volume.Round(2),
+			FeesUSD:         fees.Round(2),
+			LpFeesUSD:       lpFees.Round(2),
+			ProtocolFeesUSD: protocolFees.Round(2),
+			Apy:             apy.Round(4),
+			TxCount:         txCount,
+			BlockNumber:     lastBlocks[key],
+		})
+	}
+
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+	err := ctx.Ctx.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain_id"}, {Name: "pool_address"}, {Name: "hour_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reserve0", "reserve1", "total_supply", "price", "token0_usd", "token1_usd", "tvl_usd", "volume_usd", "fees_usd", "lp_fees_usd", "protocol_fees_usd", "apy", "tx_count", "block_number", "updated_at"}),
+	}).CreateInBatches(snapshots, 200).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(snapshots), nil
+}
+
+// revertEvent 撤销单个事件对池子代币余额的影响
+func revertEvent(poolModel PoolModel, reserve0, reserve1 *big.Int, e model.LiquidityPoolEvent) (*big.Int, *big.Int) {
+	d0, d1 := poolModel.BalanceDelta(e)
+	r0 := new(big.Int).Sub(reserve0, d0)
+	r1 := new(big.Int).Sub(reserve1, d1)
+	if r0.Sign() < 0 {
+		r0.SetInt64(0)
+	}
+	if r1.Sign() < 0 {
+		r1.SetInt64(0)
+	}
+	return r0, r1
+}
+
+// totalSupplyAtBlock 通过归档节点查询指定区块的LP总供应量，失败时返回 fallback
+func (s *PoolSnapshotService) totalSupplyAtBlock(pool model.LiquidityPool, blockNumber int64, fallback *big.Int, cache map[int64]*big.Int) *big.Int {
+	if blockNumber <= 0 {
+		return fallback
+	}
+	if v, ok := cache[blockNumber]; ok {
+		return v
+	}
+	supply, err := callTotalSupplyAt(pool.ChainId, pool.PoolAddress, blockNumber)
+	if err != nil {
+		log.Logger.Warn("查询历史LP供应量失败，使用最新值", zap.String("pool", pool.PoolAddress), zap.Int64("block", blockNumber), zap.Error(err))
+		supply = fallback
+	}
+	cache[blockNumber] = supply
+	return supply
+}
+
+// callTotalSupplyAt 调用池子合约 totalSupply（指定区块）
+func callTotalSupplyAt(chainId int64, poolAddress string, blockNumber int64) (*big.Int, error) {
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return nil, fmt.Errorf("不支持的 chainId: %d", chainId)
+	}
+	pairABI, ok := abi.GetABIManager().GetABI(abi.ABIUniswapV2Pair)
+	if !ok {
+		return nil, fmt.Errorf("UniswapV2Pair ABI 未找到")
+	}
+	data, err := pairABI.Pack("totalSupply")
+	if err != nil {
+		return nil, err
+	}
+	to := common.HexToAddress(poolAddress)
+	res, err := ctx.GetEvmClient(int(chainId)).CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, big.NewInt(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+	var supply *big.Int
+	if err := pairABI.UnpackIntoInterface(&supply, "totalSupply", res); err != nil {
+		return nil, err
+	}
+	return supply, nil
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+)
+
+type PortfolioService struct {
+	priceSvc *PriceService
+	lpSvc    *LpPositionService
+	tokenSvc *TokenService
+}
+
+func NewPortfolioService() *PortfolioService {
+	return &PortfolioService{
+		priceSvc: NewPriceService(),
+		lpSvc:    NewLpPositionService(),
+		tokenSvc: NewTokenService(),
+	}
+}
+
+// stakeBalance 按链、质押池与代币累计的质押余额
+type stakeBalance struct {
+	ChainId      int64
+	PoolId       int64
+	TokenAddress string
+	Net          decimal.Decimal
+	LastStakedAt *time.Time
+	UnlockTime   *time.Time
+}
+
+// GetPortfolio 汇总地址在 ctx.Ctx.ChainMap 中所有链上的质押、LP持仓、空投与积分，
+// 净值 = 质押价值 + LP价值，24小时变化按同一口径用历史价格与小时快照重新估值
+func (s *PortfolioService) GetPortfolio(address string) (*model.Portfolio, error) {
+	address = strings.ToLower(address)
+	now := time.Now()
+	dayAgo := now.Add(-24 * time.Hour)
+
+	chainIds := make([]int64, 0, len(ctx.Ctx.ChainMap))
+	for id := range ctx.Ctx.ChainMap {
+		chainIds = append(chainIds, int64(id))
+	}
+	sort.Slice(chainIds, func(i, j int) bool { return chainIds[i] < chainIds[j] })
+
+	portfolio := &model.Portfolio{
+		Address:            address,
+		ChainIds:           chainIds,
+		Stakes:             []model.PortfolioStake{},
+		LiquidityPositions: []model.LpPositionDetail{},
+		Airdrops:           []model.PortfolioAirdrop{},
+		Points:             []model.PortfolioPoints{},
+		UpdatedAt:          now,
+	}
+	if len(chainIds) == 0 {
+		return portfolio, nil
+	}
+
+	stakes, err := s.stakes(address, chainIds, now)
+	if err != nil {
+		return nil, err
+	}
+	portfolio.Stakes = stakes
+	for _, st := range stakes {
+		portfolio.StakedValueUSD = portfolio.StakedValueUSD.Add(st.ValueUSD)
+	}
+	stakedBefore, err := s.stakedValueAt(address, chainIds, dayAgo)
+	if err != nil {
+		return nil, err
+	}
+
+	liquidityBefore := decimal.Zero
+	for _, chainId := range chainIds {
+		positions, err := s.lpSvc.ListPositions(address, chainId)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range positions {
+			portfolio.LiquidityValueUSD = portfolio.LiquidityValueUSD.Add(decimal.NewFromFloat(p.ValueUSD))
+		}
+		portfolio.LiquidityPositions = append(portfolio.LiquidityPositions, positions...)
+
+		before, err := s.lpSvc.ValueUSDAt(address, chainId, dayAgo)
+		if err != nil {
+			return nil, err
+		}
+		liquidityBefore = liquidityBefore.Add(decimal.NewFromFloat(before))
+	}
+	portfolio.StakedValueUSD = portfolio.StakedValueUSD.Round(2)
+	portfolio.LiquidityValueUSD = portfolio.LiquidityValueUSD.Round(2)
+
+	portfolio.NetWorthUSD = portfolio.StakedValueUSD.Add(portfolio.LiquidityValueUSD)
+	portfolio.NetWorthUSD24hAgo = stakedBefore.Add(liquidityBefore).Round(2)
+	portfolio.Change24hUSD = portfolio.NetWorthUSD.Sub(portfolio.NetWorthUSD24hAgo)
+	if portfolio.NetWorthUSD24hAgo.IsPositive() {
+		portfolio.Change24hPercent = portfolio.Change24hUSD.Div(portfolio.NetWorthUSD24hAgo).Mul(decimal.NewFromInt(100)).Round(2)
+	}
+
+	if portfolio.Airdrops, err = s.airdrops(address, chainIds, now); err != nil {
+		return nil, err
+	}
+	if portfolio.Points, err = s.points(address, chainIds); err != nil {
+		return nil, err
+	}
+	for _, p := range portfolio.Points {
+		portfolio.TotalPoints = portfolio.TotalPoints.Add(p.Points)
+	}
+	return portfolio, nil
+}
+
+// stakeBalancesAt 按质押与提取事件累计指定时间点仍有余额的质押
+func (s *PortfolioService) stakeBalancesAt(address string, chainIds []int64, at time.Time) ([]stakeBalance, error) {
+	var rows []stakeBalance
+	err := ctx.Ctx.DB.Raw(`
+		SELECT chain_id, pool_id, LOWER(token_address) AS token_address,
+			SUM(CASE WHEN event_type = 'Staked' THEN amount ELSE -amount END) AS net,
+			MAX(operation_time) FILTER (WHERE event_type = 'Staked') AS last_staked_at,
+			MAX(unlock_time) FILTER (WHERE event_type = 'Staked') AS unlock_time
+		FROM user_operation_record
+		WHERE LOWER(address) = ? AND chain_id IN ? AND operation_time <= ? AND event_type IN ('Staked', 'Withdrawn')
+		GROUP BY chain_id, pool_id, LOWER(token_address)
+		HAVING SUM(CASE WHEN event_type = 'Staked' THEN amount ELSE -amount END) > 0
+		ORDER BY chain_id, pool_id`, address, chainIds, at).Scan(&rows).Error
+	return rows, err
+}
+
+// stakes 当前质押持仓及锁定状态
+func (s *PortfolioService) stakes(address string, chainIds []int64, now time.Time) ([]model.PortfolioStake, error) {
+	balances, err := s.stakeBalancesAt(address, chainIds, now)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.PortfolioStake, 0, len(balances))
+	for _, b := range balances {
+		symbol, decimals := s.tokenMeta(b.ChainId, b.TokenAddress)
+		stake := model.PortfolioStake{
+			ChainId:      b.ChainId,
+			PoolId:       b.PoolId,
+			TokenAddress: b.TokenAddress,
+			TokenSymbol:  symbol,
+			Decimals:     decimals,
+			RawAmount:    b.Net.String(),
+			Amount:       b.Net.Shift(int32(-decimals)),
+		}
+		if price, ok := s.priceSvc.GetTokenPriceUSD(b.ChainId, b.TokenAddress); ok {
+			stake.PriceUSD = price
+			stake.ValueUSD = stake.Amount.Mul(price).Round(2)
+			stake.Priced = true
+		}
+		if b.LastStakedAt != nil {
+			stake.LastStakedAt = *b.LastStakedAt
+		}
+		if b.UnlockTime != nil {
+			stake.UnlockTime = *b.UnlockTime
+			if b.UnlockTime.After(now) {
+				stake.Locked = true
+				stake.UnlockInSecond = int64(b.UnlockTime.Sub(now).Seconds())
+			}
+		}
+		list = append(list, stake)
+	}
+	return list, nil
+}
+
+// stakedValueAt 按历史价格估算指定时间点的质押价值，缺少历史价格时使用当前价格
+func (s *PortfolioService) stakedValueAt(address string, chainIds []int64, at time.Time) (decimal.Decimal, error) {
+	balances, err := s.stakeBalancesAt(address, chainIds, at)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	total := decimal.Zero
+	for _, b := range balances {
+		price, ok := s.priceSvc.GetPriceAt(b.ChainId, b.TokenAddress, at)
+		if !ok {
+			if price, ok = s.priceSvc.GetTokenPriceUSD(b.ChainId, b.TokenAddress); !ok {
+				continue
+			}
+		}
+		_, decimals := s.tokenMeta(b.ChainId, b.TokenAddress)
+		total = total.Add(b.Net.Shift(int32(-decimals)).Mul(price))
+	}
+	return total, nil
+}
+
+// tokenMeta 读取代币符号与精度，读取失败时按18位精度处理
+func (s *PortfolioService) tokenMeta(chainId int64, tokenAddress string) (string, int) {
+	symbol, decimals, err := s.tokenSvc.GetTokenDetails(tokenAddress, chainId)
+	if err != nil {
+		return "", 18
+	}
+	return symbol, decimals
+}
+
+// airdrops 白名单中的空投奖励与已领取数量
+func (s *PortfolioService) airdrops(address string, chainIds []int64, now time.Time) ([]model.PortfolioAirdrop, error) {
+	var rows []struct {
+		AirdropId     string
+		ChainId       int64
+		Name          string
+		TokenSymbol   string
+		IsActive      bool
+		StartTime     sql.NullTime
+		EndTime       sql.NullTime
+		TotalReward   decimal.Decimal
+		ClaimedReward decimal.Decimal
+		LastClaimTime sql.NullTime
+	}
+	err := ctx.Ctx.DB.Raw(`
+		SELECT w.airdrop_id::text AS airdrop_id, c.chain_id, c.name, c.token_symbol, c.is_active, c.start_time, c.end_time,
+			w.total_reward, COALESCE(r.claimed, 0) AS claimed_reward, r.last_claim_time
+		FROM airdrop_whitelist w
+		JOIN airdrop_campaigns c ON c.airdrop_id = w.airdrop_id
+		LEFT JOIN (
+			SELECT airdrop_id, SUM(claim_amount) AS claimed, MAX(event_timestamp) AS last_claim_time
+			FROM reward_claimed_events WHERE user_address = ?
+			GROUP BY airdrop_id
+		) r ON r.airdrop_id = w.airdrop_id
+		WHERE w.wallet_address = ? AND c.chain_id IN ?
+		ORDER BY w.airdrop_id DESC`, address, address, chainIds).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]model.PortfolioAirdrop, 0, len(rows))
+	for _, r := range rows {
+		pending := r.TotalReward.Sub(r.ClaimedReward)
+		if pending.IsNegative() {
+			pending = decimal.Zero
+		}
+		item := model.PortfolioAirdrop{
+			AirdropId:     r.AirdropId,
+			ChainId:       r.ChainId,
+			Name:          r.Name,
+			TokenSymbol:   r.TokenSymbol,
+			TotalReward:   r.TotalReward.String(),
+			ClaimedReward: r.ClaimedReward.String(),
+			PendingReward: pending.String(),
+			TotalAmount:   formatAmount(r.TotalReward.String(), 18),
+			ClaimedAmount: formatAmount(r.ClaimedReward.String(), 18),
+			PendingAmount: formatAmount(pending.String(), 18),
+		}
+		// 状态与可参与空投列表保持一致
+		started := !r.StartTime.Valid || now.After(r.StartTime.Time)
+		notEnded := !r.EndTime.Valid || now.Before(r.EndTime.Time)
+		switch {
+		case r.IsActive && started && notEnded:
+			item.Status = "claimable"
+		case r.EndTime.Valid && now.After(r.EndTime.Time):
+			item.Status = "expired"
+		default:
+			item.Status = "closed"
+		}
+		if r.LastClaimTime.Valid {
+			t := r.LastClaimTime.Time
+			item.LastClaimTime = &t
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// points 各链各质押代币的积分
+func (s *PortfolioService) points(address string, chainIds []int64) ([]model.PortfolioPoints, error) {
+	var users []model.Users
+	if err := ctx.Ctx.DB.Where("LOWER(address) = ? AND chain_id IN ?", address, chainIds).
+		Order("chain_id ASC, token_address ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	list := make([]model.PortfolioPoints, 0, len(users))
+	for _, u := range users {
+		list = append(list, model.PortfolioPoints{
+			ChainId:      u.ChainId,
+			TokenAddress: strings.ToLower(u.TokenAddress),
+			Points:       u.Jf,
+			UpdatedAt:    u.JfTime,
+		})
+	}
+	return list, nil
+}
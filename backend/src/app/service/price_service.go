@@ -0,0 +1,485 @@
+package service
+
+import (
+	"container/heap"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PriceSourceStable = "stable"
+	PriceSourceRoute  = "route"
+	PriceSourceManual = "manual"
+
+	defaultMinLiquidityUSD = 1000
+	defaultMaxHops         = 3
+	priceCacheTTL          = 30 * time.Second
+)
+
+// TokenPriceQuote 代币USD报价
+type TokenPriceQuote struct {
+	TokenAddress string          `json:"tokenAddress"`
+	Symbol       string          `json:"symbol"`
+	PriceUSD     decimal.Decimal `json:"priceUsd"`
+	Source       string          `json:"source"`
+	Route        []string        `json:"route"`
+	LiquidityUSD decimal.Decimal `json:"liquidityUsd"`
+}
+
+type priceCacheEntry struct {
+	prices    map[string]*TokenPriceQuote
+	expiredAt time.Time
+}
+
+var (
+	priceCacheMu sync.Mutex
+	priceCache   = make(map[int64]priceCacheEntry)
+)
+
+type PriceService struct {
+	tokenSvc *TokenService
+}
+
+func NewPriceService() *PriceService {
+	return &PriceService{
+		tokenSvc: NewTokenService(),
+	}
+}
+
+// GetTokenPrices 获取链上所有可定价代币的USD价格（带短时缓存），key 为小写代币地址
+func (s *PriceService) GetTokenPrices(chainId int64) (map[string]*TokenPriceQuote, error) {
+	priceCacheMu.Lock()
+	entry, ok := priceCache[chainId]
+	priceCacheMu.Unlock()
+	if ok && time.Now().Before(entry.expiredAt) {
+		return entry.prices, nil
+	}
+
+	prices, err := s.ComputeTokenPrices(chainId)
+	if err != nil {
+		return nil, err
+	}
+
+	priceCacheMu.Lock()
+	priceCache[chainId] = priceCacheEntry{prices: prices, expiredAt: time.Now().Add(priceCacheTTL)}
+	priceCacheMu.Unlock()
+	return prices, nil
+}
+
+// GetTokenPriceUSD 获取单个代币的USD价格，无法定价时返回 false
+func (s *PriceService) GetTokenPriceUSD(chainId int64, tokenAddress string) (decimal.Decimal, bool) {
+	prices, err := s.GetTokenPrices(chainId)
+	if err != nil {
+		return decimal.Zero, false
+	}
+	quote, ok := prices[strings.ToLower(tokenAddress)]
+	if !ok {
+		return decimal.Zero, false
+	}
+	return quote.PriceUSD, true
+}
+
+// GetPriceAt 获取代币在指定时间点的USD价格（取该时间之前最近的一次快照）
+func (s *PriceService) GetPriceAt(chainId int64, tokenAddress string, at time.Time) (decimal.Decimal, bool) {
+	var price model.TokenPrice
+	err := ctx.Ctx.DB.Where("chain_id = ? AND token_address = ? AND snapshot_time <= ?", chainId, strings.ToLower(tokenAddress), at).
+		Order("snapshot_time DESC").First(&price).Error
+	if err != nil {
+		return decimal.Zero, false
+	}
+	return price.PriceUSD, true
+}
+
+// GetPriceHistory 查询代币历史价格
+func (s *PriceService) GetPriceHistory(chainId int64, tokenAddress string, from, to time.Time) ([]model.TokenPrice, error) {
+	var prices []model.TokenPrice
+	err := ctx.Ctx.DB.Where("chain_id = ? AND token_address = ? AND snapshot_time >= ? AND snapshot_time <= ?",
+		chainId, strings.ToLower(tokenAddress), from, to).
+		Order("snapshot_time ASC").Find(&prices).Error
+	return prices, err
+}
+
+// PriceSeries 一段时间内的代币历史价格序列，用于回补计算
+type PriceSeries struct {
+	times   []time.Time
+	prices  []decimal.Decimal
+	current decimal.Decimal
+	hasNow  bool
+}
+
+// LoadPriceSeries 加载代币在时间段内的历史价格（含开始时间前最近的一条）
+func (s *PriceService) LoadPriceSeries(chainId int64, tokenAddress string, from, to time.Time) *PriceSeries {
+	series := &PriceSeries{}
+	series.current, series.hasNow = s.GetTokenPriceUSD(chainId, tokenAddress)
+
+	var rows []model.TokenPrice
+	token := strings.ToLower(tokenAddress)
+	var first model.TokenPrice
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND token_address = ? AND snapshot_time <= ?", chainId, token, from).
+		Order("snapshot_time DESC").First(&first).Error; err == nil {
+		rows = append(rows, first)
+	}
+	var inRange []model.TokenPrice
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND token_address = ? AND snapshot_time > ? AND snapshot_time <= ?", chainId, token, from, to).
+		Order("snapshot_time ASC").Find(&inRange).Error; err == nil {
+		rows = append(rows, inRange...)
+	}
+	for _, r := range rows {
+		series.times = append(series.times, r.SnapshotTime)
+		series.prices = append(series.prices, r.PriceUSD)
+	}
+	return series
+}
+
+// At 返回指定时间点的价格，早于第一条快照时使用最早价格，无历史时使用当前价格
+func (p *PriceSeries) At(t time.Time) (decimal.Decimal, bool) {
+	if len(p.times) == 0 {
+		return p.current, p.hasNow
+	}
+	idx := sort.Search(len(p.times), func(i int) bool { return p.times[i].After(t) })
+	if idx == 0 {
+		return p.prices[0], true
+	}
+	return p.prices[idx-1], true
+}
+
+// SnapshotPrices 计算当前价格并写入历史价格表
+func (s *PriceService) SnapshotPrices(chainId int64, snapshotTime time.Time) (int, error) {
+	prices, err := s.ComputeTokenPrices(chainId)
+	if err != nil {
+		return 0, err
+	}
+	if len(prices) == 0 {
+		return 0, nil
+	}
+
+	rows := make([]model.TokenPrice, 0, len(prices))
+	for _, q := range prices {
+		rows = append(rows, model.TokenPrice{
+			ChainId:      chainId,
+			TokenAddress: q.TokenAddress,
+			Symbol:       q.Symbol,
+			PriceUSD:     q.PriceUSD,
+			Source:       q.Source,
+			Route:        strings.Join(q.Route, ","),
+			LiquidityUSD: q.LiquidityUSD.Round(2),
+			SnapshotTime: snapshotTime,
+		})
+	}
+	// 同一快照时间重复写入（重启或多实例）时以最新计算结果覆盖
+	if err := ctx.Ctx.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain_id"}, {Name: "token_address"}, {Name: "snapshot_time"}},
+		DoUpdates: clause.AssignmentColumns([]string{"symbol", "price_usd", "source", "route", "liquidity_usd"}),
+	}).CreateInBatches(rows, 100).Error; err != nil {
+		return 0, err
+	}
+
+	priceCacheMu.Lock()
+	priceCache[chainId] = priceCacheEntry{prices: prices, expiredAt: time.Now().Add(priceCacheTTL)}
+	priceCacheMu.Unlock()
+	return len(rows), nil
+}
+
+// PoolTVLUSD 计算池子的USD锁仓量；仅一侧可定价时按两倍该侧估算
+func (s *PriceService) PoolTVLUSD(pool model.LiquidityPool, prices map[string]*TokenPriceQuote) decimal.Decimal {
+	return PoolModelOf(pool).TVLUSD(pool, prices)
+}
+
+// SwapVolumeUSD 计算单笔Swap事件的USD交易量，优先使用 token0 侧
+func (s *PriceService) SwapVolumeUSD(pool model.LiquidityPool, e model.LiquidityPoolEvent, prices map[string]*TokenPriceQuote) decimal.Decimal {
+	vol0, vol1 := PoolModelOf(pool).SwapAmounts(e)
+	if q, ok := prices[strings.ToLower(pool.Token0Address)]; ok {
+		return tokenAmount(vol0, pool.Token0Decimals).Mul(q.PriceUSD)
+	}
+	if q, ok := prices[strings.ToLower(pool.Token1Address)]; ok {
+		return tokenAmount(vol1, pool.Token1Decimals).Mul(q.PriceUSD)
+	}
+	return decimal.Zero
+}
+
+// ComputeTokenPrices 基于已索引池子的储备量计算代币USD价格。
+// 以配置的稳定币锚点为起点，沿瓶颈流动性最大的路径（widest path）向外传播价格；
+// 路由不可达的代币使用配置的手动价格兜底，并可作为起点继续向外定价。
+func (s *PriceService) ComputeTokenPrices(chainId int64) (map[string]*TokenPriceQuote, error) {
+	var pools []model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND is_active = ?", chainId, true).Find(&pools).Error; err != nil {
+		return nil, err
+	}
+	for i := range pools {
+		s.ensurePoolMetadata(&pools[i])
+	}
+	return computeTokenPrices(chainId, pools), nil
+}
+
+// computeTokenPrices 按池子列表计算价格，见 ComputeTokenPrices
+func computeTokenPrices(chainId int64, pools []model.LiquidityPool) map[string]*TokenPriceQuote {
+	minLiquidity := decimal.NewFromFloat(minLiquidityUSD())
+	maxHops := maxPriceHops()
+
+	// 构建代币邻接表，同时累计锚点在各池子中的实际持有量
+	anchors := stableAnchors(chainId)
+	anchorReserves := make(map[string]decimal.Decimal)
+	adjacency := make(map[string][]priceEdge)
+	symbols := make(map[string]string)
+	for i := range pools {
+		pool := &pools[i]
+		t0 := strings.ToLower(pool.Token0Address)
+		t1 := strings.ToLower(pool.Token1Address)
+		if t0 == "" || t1 == "" || t0 == zeroAddress || t1 == zeroAddress {
+			continue
+		}
+		symbols[t0] = pool.Token0Symbol
+		symbols[t1] = pool.Token1Symbol
+
+		// 兑换比例取池子现价（V3 为 sqrtPriceX96），流动性按池子实际持有的代币余额估算
+		amount0 := tokenAmount(parseBigInt(pool.Reserve0), pool.Token0Decimals)
+		amount1 := tokenAmount(parseBigInt(pool.Reserve1), pool.Token1Decimals)
+		spot := PoolModelOf(*pool).SpotPrice(*pool)
+		if !amount0.IsPositive() || !amount1.IsPositive() || !spot.IsPositive() {
+			continue
+		}
+		adjacency[t0] = append(adjacency[t0], priceEdge{pool: pool.PoolAddress, to: t1, fromReserve: amount0, toReserve: amount1, rate: spot})
+		adjacency[t1] = append(adjacency[t1], priceEdge{pool: pool.PoolAddress, to: t0, fromReserve: amount1, toReserve: amount0, rate: decimal.NewFromInt(1).DivRound(spot, 18)})
+		if _, ok := anchors[t0]; ok {
+			anchorReserves[t0] = anchorReserves[t0].Add(amount0)
+		}
+		if _, ok := anchors[t1]; ok {
+			anchorReserves[t1] = anchorReserves[t1].Add(amount1)
+		}
+	}
+
+	pq := &priceQueue{}
+	for token, symbol := range anchors {
+		if _, ok := symbols[token]; !ok {
+			continue
+		}
+		if symbols[token] == "" {
+			symbols[token] = symbol
+		}
+		// 锚点的瓶颈为其在池子中的实际持有量（按1美元计），深度不同的锚点按真实流动性比较
+		bottleneck, _ := anchorReserves[token].Float64()
+		heap.Push(pq, &priceCandidate{
+			token: token, price: decimal.NewFromInt(1), source: PriceSourceStable,
+			bottleneck: bottleneck,
+		})
+	}
+	// 手动价格源优先级最低，只有路由不可达时才会生效
+	for _, feed := range config.Conf.Price.ManualFeeds {
+		if feed.ChainId != chainId || feed.PriceUSD <= 0 {
+			continue
+		}
+		token := strings.ToLower(feed.TokenAddress)
+		if _, ok := symbols[token]; !ok && feed.Symbol != "" {
+			symbols[token] = feed.Symbol
+		}
+		heap.Push(pq, &priceCandidate{
+			token: token, price: decimal.NewFromFloat(feed.PriceUSD), source: PriceSourceManual,
+			bottleneck: -1,
+		})
+	}
+
+	prices := make(map[string]*TokenPriceQuote)
+	for pq.Len() > 0 {
+		cand := heap.Pop(pq).(*priceCandidate)
+		if _, done := prices[cand.token]; done {
+			continue
+		}
+		liquidity := decimal.Zero
+		if cand.bottleneck > 0 {
+			liquidity = decimal.NewFromFloat(cand.bottleneck)
+		}
+		prices[cand.token] = &TokenPriceQuote{
+			TokenAddress: cand.token,
+			Symbol:       symbols[cand.token],
+			PriceUSD:     cand.price,
+			Source:       cand.source,
+			Route:        cand.route,
+			LiquidityUSD: liquidity,
+		}
+
+		if len(cand.route) >= maxHops {
+			continue
+		}
+		for _, edge := range adjacency[cand.token] {
+			if _, done := prices[edge.to]; done {
+				continue
+			}
+			// 池子USD流动性按两侧实际持有量中较少一侧的两倍估算：V2 两侧按现价等值，V3 的余额可能集中在一侧
+			price := cand.price.DivRound(edge.rate, 18)
+			poolLiquidity := decimal.Min(edge.fromReserve.Mul(cand.price), edge.toReserve.Mul(price)).Mul(decimal.NewFromInt(2))
+			if poolLiquidity.LessThan(minLiquidity) {
+				continue
+			}
+			liqFloat, _ := poolLiquidity.Float64()
+			route := make([]string, 0, len(cand.route)+1)
+			route = append(route, cand.route...)
+			route = append(route, edge.pool)
+			source := PriceSourceRoute
+			if cand.source == PriceSourceManual {
+				source = PriceSourceManual
+			}
+			heap.Push(pq, &priceCandidate{
+				token:      edge.to,
+				price:      price,
+				source:     source,
+				route:      route,
+				bottleneck: math.Min(cand.bottleneck, liqFloat),
+			})
+		}
+	}
+	return prices
+}
+
+// ensurePoolMetadata 补全池子代币的符号与精度（早期索引创建的池子未写入）
+func (s *PriceService) ensurePoolMetadata(pool *model.LiquidityPool) {
+	if pool.Token0Symbol != "" && pool.Token1Symbol != "" {
+		return
+	}
+	if ctx.Ctx.ChainMap[int(pool.ChainId)] == nil {
+		return
+	}
+	updates := make(map[string]interface{})
+	if pool.Token0Symbol == "" && pool.Token0Address != "" && pool.Token0Address != zeroAddress {
+		if symbol, decimals, err := s.tokenSvc.GetTokenDetails(pool.Token0Address, pool.ChainId); err == nil && symbol != "" {
+			pool.Token0Symbol, pool.Token0Decimals = symbol, decimals
+			updates["token0_symbol"], updates["token0_decimals"] = symbol, decimals
+		}
+	}
+	if pool.Token1Symbol == "" && pool.Token1Address != "" && pool.Token1Address != zeroAddress {
+		if symbol, decimals, err := s.tokenSvc.GetTokenDetails(pool.Token1Address, pool.ChainId); err == nil && symbol != "" {
+			pool.Token1Symbol, pool.Token1Decimals = symbol, decimals
+			updates["token1_symbol"], updates["token1_decimals"] = symbol, decimals
+		}
+	}
+	if len(updates) == 0 {
+		return
+	}
+	if err := ctx.Ctx.DB.Model(&model.LiquidityPool{}).Where("id = ?", pool.Id).Updates(updates).Error; err != nil {
+		log.Logger.Warn("补全池子代币信息失败", zap.String("pool", pool.PoolAddress), zap.Error(err))
+	}
+}
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// poolTVLFromReserves 按给定储备量计算池子USD锁仓量
+func poolTVLFromReserves(pool model.LiquidityPool, reserve0, reserve1 *big.Int, prices map[string]*TokenPriceQuote) decimal.Decimal {
+	q0, ok0 := prices[strings.ToLower(pool.Token0Address)]
+	q1, ok1 := prices[strings.ToLower(pool.Token1Address)]
+	value0 := decimal.Zero
+	value1 := decimal.Zero
+	if ok0 {
+		value0 = tokenAmount(reserve0, pool.Token0Decimals).Mul(q0.PriceUSD)
+	}
+	if ok1 {
+		value1 = tokenAmount(reserve1, pool.Token1Decimals).Mul(q1.PriceUSD)
+	}
+	switch {
+	case ok0 && ok1:
+		return value0.Add(value1)
+	case ok0:
+		return value0.Mul(decimal.NewFromInt(2))
+	case ok1:
+		return value1.Mul(decimal.NewFromInt(2))
+	default:
+		return decimal.Zero
+	}
+}
+
+// tokenAmount 将链上整数数量按精度转换为代币数量
+func tokenAmount(v *big.Int, decimals int) decimal.Decimal {
+	if v == nil {
+		return decimal.Zero
+	}
+	return decimal.NewFromBigInt(v, -int32(decimals))
+}
+
+// stableAnchors 链上配置的稳定币锚点，key 为小写代币地址，value 为配置的符号
+func stableAnchors(chainId int64) map[string]string {
+	anchors := make(map[string]string)
+	for _, t := range config.Conf.Price.StableTokens {
+		if t.ChainId == chainId && common.IsHexAddress(t.TokenAddress) && !strings.EqualFold(t.TokenAddress, zeroAddress) {
+			anchors[strings.ToLower(t.TokenAddress)] = t.Symbol
+		}
+	}
+	return anchors
+}
+
+// UnpricedChains 既没有稳定币锚点也没有手动价格的链：这些链上不会有任何代币被定价，TVL、APY 与价格快照均为空
+func UnpricedChains(chainIds []int64) []int64 {
+	var unpriced []int64
+	for _, chainId := range chainIds {
+		if len(stableAnchors(chainId)) > 0 {
+			continue
+		}
+		manual := false
+		for _, f := range config.Conf.Price.ManualFeeds {
+			if f.ChainId == chainId && common.IsHexAddress(f.TokenAddress) && !strings.EqualFold(f.TokenAddress, zeroAddress) && f.PriceUSD > 0 {
+				manual = true
+				break
+			}
+		}
+		if !manual {
+			unpriced = append(unpriced, chainId)
+		}
+	}
+	return unpriced
+}
+
+func minLiquidityUSD() float64 {
+	if config.Conf.Price.MinLiquidityUSD > 0 {
+		return config.Conf.Price.MinLiquidityUSD
+	}
+	return defaultMinLiquidityUSD
+}
+
+func maxPriceHops() int {
+	if config.Conf.Price.MaxHops > 0 {
+		return config.Conf.Price.MaxHops
+	}
+	return defaultMaxHops
+}
+
+// priceEdge 定价图中的一条边（一个池子的一个方向）
+type priceEdge struct {
+	pool        string
+	to          string
+	fromReserve decimal.Decimal
+	toReserve   decimal.Decimal
+	rate        decimal.Decimal // 每单位 from 代币可兑换的 to 代币数量
+}
+
+type priceCandidate struct {
+	token      string
+	price      decimal.Decimal
+	source     string
+	route      []string
+	bottleneck float64
+}
+
+// priceQueue 按瓶颈流动性从大到小出队的优先队列
+type priceQueue []*priceCandidate
+
+func (q priceQueue) Len() int            { return len(q) }
+func (q priceQueue) Less(i, j int) bool  { return q[i].bottleneck > q[j].bottleneck }
+func (q priceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *priceQueue) Push(x interface{}) { *q = append(*q, x.(*priceCandidate)) }
+func (q *priceQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testUSDC     = "0x00000000000000000000000000000000000000c1"
+	testUSDT     = "0x00000000000000000000000000000000000000c2"
+	testWETH     = "0x00000000000000000000000000000000000000e1"
+	testFakeUSDC = "0x00000000000000000000000000000000000000f1"
+	testOther    = "0x00000000000000000000000000000000000000f2"
+)
+
+func testV2Pool(address, token0, symbol0 string, decimals0 int, reserve0, token1, symbol1 string, decimals1 int, reserve1 string) model.LiquidityPool {
+	return model.LiquidityPool{
+		ChainId: 1, PoolAddress: address, PoolType: PoolTypeV2, IsActive: true,
+		Token0Address: token0, Token0Symbol: symbol0, Token0Decimals: decimals0, Reserve0: reserve0,
+		Token1Address: token1, Token1Symbol: symbol1, Token1Decimals: decimals1, Reserve1: reserve1,
+	}
+}
+
+func TestComputeTokenPricesAnchors(t *testing.T) {
+	old := config.Conf.Price
+	config.Conf.Price = config.PriceConfig{StableTokens: []config.StableToken{
+		{ChainId: 1, TokenAddress: testUSDC, Symbol: "USDC"},
+		{ChainId: 1, TokenAddress: testUSDT, Symbol: "USDT"},
+		// 其他链的锚点不参与
+		{ChainId: 2, TokenAddress: testFakeUSDC, Symbol: "USDC"},
+	}}
+	defer func() { config.Conf.Price = old }()
+
+	pools := []model.LiquidityPool{
+		// 1,000,000 USDC / 500 WETH，WETH = 2000
+		testV2Pool("deep", testUSDC, "USDC", 6, "1000000000000", testWETH, "WETH", 18, "500000000000000000000"),
+		// 1,000 USDT / 0.4 WETH，WETH = 2500，流动性远小于 USDC 池
+		testV2Pool("thin", testUSDT, "USDT", 6, "1000000000", testWETH, "WETH", 18, "400000000000000000"),
+		// 符号同为 USDC 但地址未配置为锚点
+		testV2Pool("fake", testFakeUSDC, "USDC", 6, "1000000000", testOther, "OTHER", 18, "1000000000000000000"),
+	}
+	prices := computeTokenPrices(1, pools)
+
+	usdc, ok := prices[testUSDC]
+	if !ok || usdc.Source != PriceSourceStable || !usdc.PriceUSD.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("USDC 报价不符: %+v", usdc)
+	}
+	// 锚点流动性为其在池子中的实际持有量
+	if !usdc.LiquidityUSD.Equal(decimal.NewFromInt(1000000)) {
+		t.Fatalf("USDC 流动性 = %s, want 1000000", usdc.LiquidityUSD)
+	}
+
+	weth, ok := prices[testWETH]
+	if !ok {
+		t.Fatal("WETH 未定价")
+	}
+	if !weth.PriceUSD.Equal(decimal.NewFromInt(2000)) || len(weth.Route) != 1 || weth.Route[0] != "deep" {
+		t.Fatalf("WETH 应经深池定价为 2000: %+v", weth)
+	}
+	// 瓶颈不超过锚点自身的持有量
+	if !weth.LiquidityUSD.Equal(decimal.NewFromInt(1000000)) {
+		t.Fatalf("WETH 流动性 = %s, want 1000000", weth.LiquidityUSD)
+	}
+
+	if q, ok := prices[testFakeUSDC]; ok {
+		t.Fatalf("未配置的同名代币不应定价: %+v", q)
+	}
+	if q, ok := prices[testOther]; ok {
+		t.Fatalf("经未配置锚点的代币不应定价: %+v", q)
+	}
+}
+
+func TestComputeTokenPricesManualFallback(t *testing.T) {
+	old := config.Conf.Price
+	config.Conf.Price = config.PriceConfig{ManualFeeds: []config.ManualPriceFeed{
+		{ChainId: 1, TokenAddress: testFakeUSDC, PriceUSD: 0.5},
+	}}
+	defer func() { config.Conf.Price = old }()
+
+	pools := []model.LiquidityPool{
+		testV2Pool("fake", testFakeUSDC, "USDC", 6, "10000000000", testOther, "OTHER", 18, "1000000000000000000"),
+	}
+	prices := computeTokenPrices(1, pools)
+
+	if q := prices[testFakeUSDC]; q == nil || q.Source != PriceSourceManual || !q.PriceUSD.Equal(decimal.NewFromFloat(0.5)) {
+		t.Fatalf("手动价格不符: %+v", q)
+	}
+	// 10,000 * 0.5 / 1
+	if q := prices[testOther]; q == nil || q.Source != PriceSourceManual || !q.PriceUSD.Equal(decimal.NewFromInt(5000)) {
+		t.Fatalf("经手动价格定价不符: %+v", q)
+	}
+}
+
+func TestComputeTokenPricesTwoHops(t *testing.T) {
+	old := config.Conf.Price
+	config.Conf.Price = config.PriceConfig{StableTokens: []config.StableToken{
+		{ChainId: 1, TokenAddress: testUSDC, Symbol: "USDC"},
+	}}
+	defer func() { config.Conf.Price = old }()
+
+	pools := []model.LiquidityPool{
+		// 1,000,000 USDC / 500 WETH，WETH = 2000
+		testV2Pool("usdc-weth", testUSDC, "USDC", 6, "1000000000000", testWETH, "WETH", 18, "500000000000000000000"),
+		// 100 WETH / 400,000 OTHER，OTHER = 2000 / 4000 = 0.5
+		testV2Pool("weth-other", testWETH, "WETH", 18, "100000000000000000000", testOther, "OTHER", 18, "400000000000000000000000"),
+	}
+	prices := computeTokenPrices(1, pools)
+
+	other, ok := prices[testOther]
+	if !ok {
+		t.Fatal("OTHER 未经两跳定价")
+	}
+	if other.Source != PriceSourceRoute || !other.PriceUSD.Equal(decimal.NewFromFloat(0.5)) {
+		t.Fatalf("OTHER 报价不符: %+v", other)
+	}
+	if len(other.Route) != 2 || other.Route[0] != "usdc-weth" || other.Route[1] != "weth-other" {
+		t.Fatalf("OTHER 路由 = %v", other.Route)
+	}
+}
+
+func TestUnpricedChains(t *testing.T) {
+	old := config.Conf.Price
+	config.Conf.Price = config.PriceConfig{
+		StableTokens: []config.StableToken{
+			{ChainId: 1, TokenAddress: testUSDC, Symbol: "USDC"},
+			// 示例配置中的零地址占位不算锚点
+			{ChainId: 2, TokenAddress: zeroAddress, Symbol: "USDC"},
+		},
+		ManualFeeds: []config.ManualPriceFeed{{ChainId: 3, TokenAddress: testWETH, PriceUSD: 3000}},
+	}
+	defer func() { config.Conf.Price = old }()
+
+	got := UnpricedChains([]int64{1, 2, 3, 4})
+	if len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Fatalf("UnpricedChains = %v, want [2 4]", got)
+	}
+}
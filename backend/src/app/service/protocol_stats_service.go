@@ -0,0 +1,357 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxProtocolStatsBackfillDays 首次汇总时最多回补的天数
+const maxProtocolStatsBackfillDays = 90
+
+type ProtocolStatsService struct {
+	snapshotSvc *PoolSnapshotService
+}
+
+func NewProtocolStatsService() *ProtocolStatsService {
+	return &ProtocolStatsService{
+		snapshotSvc: NewPoolSnapshotService(),
+	}
+}
+
+// Rollup 补齐链上从最后一条汇总（含，可能是未完整的当天）到今天的每日汇总，返回写入的天数
+func (s *ProtocolStatsService) Rollup(chainId int64, now time.Time) (int, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	var last *time.Time
+	if err := ctx.Ctx.DB.Model(&model.ProtocolDailyStats{}).Where("chain_id = ?", chainId).
+		Select("MAX(day)").Scan(&last).Error; err != nil {
+		return 0, err
+	}
+	first := today
+	if last != nil {
+		first = last.UTC().Truncate(24 * time.Hour)
+	} else {
+		earliest, err := s.earliestActivity(chainId)
+		if err != nil {
+			return 0, err
+		}
+		if earliest != nil {
+			first = earliest.UTC().Truncate(24 * time.Hour)
+		}
+	}
+	if limit := today.AddDate(0, 0, -maxProtocolStatsBackfillDays); first.Before(limit) {
+		first = limit
+	}
+
+	count := 0
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		stats, err := s.BuildDay(chainId, day)
+		if err != nil {
+			return count, err
+		}
+		if err := s.save(stats); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// earliestActivity 链上最早一条流动性、质押或空投领取事件的时间
+func (s *ProtocolStatsService) earliestActivity(chainId int64) (*time.Time, error) {
+	var earliest *time.Time
+	query := `
+		SELECT MIN(at) FROM (
+			SELECT MIN(COALESCE(block_time, created_at)) AS at FROM liquidity_pool_events WHERE chain_id = ?
+			UNION ALL
+			SELECT MIN(operation_time) FROM user_operation_record WHERE chain_id = ?
+			UNION ALL
+			SELECT MIN(event_timestamp) FROM reward_claimed_events WHERE chain_id = ?
+		) t`
+	err := ctx.Ctx.DB.Raw(query, chainId, chainId, chainId).Scan(&earliest).Error
+	return earliest, err
+}
+
+// BuildDay 计算链在某个 UTC 自然日的协议汇总：
+// 交易量与手续费来自池子小时快照，锁仓量取当日最后一个小时快照；
+// 交易者、LP、质押与空投指标直接统计事件表；DAU/WAU/MAU 为截至当日结束的 1/7/30 天内在任一事件表出现过的地址数。
+func (s *ProtocolStatsService) BuildDay(chainId int64, day time.Time) (*model.ProtocolDailyStats, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+	stats := &model.ProtocolDailyStats{ChainId: chainId, Day: start, AirdropClaimedAmount: "0"}
+
+	window, err := s.snapshotSvc.SumWindow(chainId, "", start, end)
+	if err != nil {
+		return nil, err
+	}
+	stats.VolumeUSD = window.VolumeUSD.Round(2)
+	stats.FeesUSD = window.FeesUSD.Round(2)
+	stats.LpFeesUSD = window.LpFeesUSD.Round(2)
+	stats.ProtocolFeesUSD = window.ProtocolFeesUSD.Round(2)
+	if stats.TvlUSD, err = s.snapshotSvc.SumTvlAt(chainId, end); err != nil {
+		return nil, err
+	}
+	stats.TvlUSD = stats.TvlUSD.Round(2)
+
+	db := ctx.Ctx.DB
+	var trading struct {
+		SwapCount          int64
+		UniqueTraders      int64
+		LiquidityProviders int64
+	}
+	if err := db.Raw(`
+		SELECT COUNT(*) FILTER (WHERE event_type = 'Swap') AS swap_count,
+			COUNT(DISTINCT LOWER(user_address)) FILTER (WHERE event_type = 'Swap') AS unique_traders,
+			COUNT(DISTINCT LOWER(user_address)) FILTER (WHERE event_type IN ('AddLiquidity', 'RemoveLiquidity')) AS liquidity_providers
+		FROM liquidity_pool_events
+		WHERE chain_id = ? AND COALESCE(block_time, created_at) >= ? AND COALESCE(block_time, created_at) < ?`,
+		chainId, start, end).Scan(&trading).Error; err != nil {
+		return nil, err
+	}
+	stats.SwapCount = trading.SwapCount
+	stats.UniqueTraders = trading.UniqueTraders
+	stats.LiquidityProviders = trading.LiquidityProviders
+
+	if err := db.Raw(`
+		SELECT COUNT(*) FROM (
+			SELECT LOWER(user_address) FROM liquidity_pool_events
+			WHERE chain_id = ? AND event_type = 'AddLiquidity'
+			GROUP BY LOWER(user_address)
+			HAVING MIN(COALESCE(block_time, created_at)) >= ? AND MIN(COALESCE(block_time, created_at)) < ?
+		) t`, chainId, start, end).Scan(&stats.NewLps).Error; err != nil {
+		return nil, err
+	}
+
+	var staking struct {
+		StakeCount    int64
+		WithdrawCount int64
+	}
+	if err := db.Raw(`
+		SELECT COUNT(*) FILTER (WHERE event_type = 'Staked') AS stake_count,
+			COUNT(*) FILTER (WHERE event_type = 'Withdrawn') AS withdraw_count
+		FROM user_operation_record
+		WHERE chain_id = ? AND operation_time >= ? AND operation_time < ?`,
+		chainId, start, end).Scan(&staking).Error; err != nil {
+		return nil, err
+	}
+	stats.StakeCount = staking.StakeCount
+	stats.WithdrawCount = staking.WithdrawCount
+
+	if err := db.Raw(`
+		SELECT COUNT(*) FROM (
+			SELECT LOWER(address) FROM user_operation_record
+			WHERE chain_id = ? AND event_type = 'Staked'
+			GROUP BY LOWER(address)
+			HAVING MIN(operation_time) >= ? AND MIN(operation_time) < ?
+		) t`, chainId, start, end).Scan(&stats.NewStakers).Error; err != nil {
+		return nil, err
+	}
+
+	tokenStakes, stakers, err := s.stakesAt(chainId, end)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tokenStakes {
+		tokenStakes[i].Day = start
+	}
+	stats.TokenStakes = tokenStakes
+	stats.Stakers = stakers
+
+	var claims struct {
+		Claims   int64
+		Claimers int64
+		Claimed  decimal.Decimal
+	}
+	if err := db.Raw(`
+		SELECT COUNT(*) AS claims, COUNT(DISTINCT LOWER(user_address)) AS claimers, COALESCE(SUM(claim_amount), 0) AS claimed
+		FROM reward_claimed_events
+		WHERE chain_id = ? AND event_timestamp >= ? AND event_timestamp < ?`,
+		chainId, start, end).Scan(&claims).Error; err != nil {
+		return nil, err
+	}
+	stats.AirdropClaims = claims.Claims
+	stats.AirdropClaimers = claims.Claimers
+	stats.AirdropClaimedAmount = claims.Claimed.String()
+
+	var active struct {
+		Dau int64
+		Wau int64
+		Mau int64
+	}
+	if err := db.Raw(`
+		WITH activity AS (
+			SELECT LOWER(user_address) AS addr, COALESCE(block_time, created_at) AS at FROM liquidity_pool_events
+			WHERE chain_id = ? AND COALESCE(block_time, created_at) >= ? AND COALESCE(block_time, created_at) < ?
+			UNION ALL
+			SELECT LOWER(address), operation_time FROM user_operation_record
+			WHERE chain_id = ? AND operation_time >= ? AND operation_time < ?
+			UNION ALL
+			SELECT LOWER(user_address), event_timestamp FROM reward_claimed_events
+			WHERE chain_id = ? AND event_timestamp >= ? AND event_timestamp < ?
+		)
+		SELECT COUNT(DISTINCT addr) FILTER (WHERE at >= ?) AS dau,
+			COUNT(DISTINCT addr) FILTER (WHERE at >= ?) AS wau,
+			COUNT(DISTINCT addr) AS mau
+		FROM activity`,
+		chainId, end.AddDate(0, 0, -30), end,
+		chainId, end.AddDate(0, 0, -30), end,
+		chainId, end.AddDate(0, 0, -30), end,
+		start, end.AddDate(0, 0, -7)).Scan(&active).Error; err != nil {
+		return nil, err
+	}
+	stats.Dau, stats.Wau, stats.Mau = active.Dau, active.Wau, active.Mau
+	return stats, nil
+}
+
+// stakesAt 按质押与提取事件累计指定时间点各代币的质押总量与持有地址数，并返回有质押余额的地址总数
+func (s *ProtocolStatsService) stakesAt(chainId int64, at time.Time) ([]model.ProtocolDailyTokenStake, int64, error) {
+	var rows []struct {
+		TokenAddress string
+		Address      string
+		Net          decimal.Decimal
+	}
+	if err := ctx.Ctx.DB.Raw(`
+		SELECT LOWER(token_address) AS token_address, LOWER(address) AS address,
+			SUM(CASE WHEN event_type = 'Staked' THEN amount ELSE -amount END) AS net
+		FROM user_operation_record
+		WHERE chain_id = ? AND operation_time < ? AND event_type IN ('Staked', 'Withdrawn')
+		GROUP BY LOWER(token_address), LOWER(address)`, chainId, at).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	byToken := make(map[string]*model.ProtocolDailyTokenStake)
+	stakers := make(map[string]bool)
+	for _, r := range rows {
+		if !r.Net.IsPositive() {
+			continue
+		}
+		stake, ok := byToken[r.TokenAddress]
+		if !ok {
+			stake = &model.ProtocolDailyTokenStake{ChainId: chainId, TokenAddress: r.TokenAddress, TotalStaked: "0"}
+			byToken[r.TokenAddress] = stake
+		}
+		stake.TotalStaked = decimal.RequireFromString(stake.TotalStaked).Add(r.Net).String()
+		stake.Stakers++
+		stakers[r.Address] = true
+	}
+
+	list := make([]model.ProtocolDailyTokenStake, 0, len(byToken))
+	for _, stake := range byToken {
+		list = append(list, *stake)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].TokenAddress < list[j].TokenAddress })
+	return list, int64(len(stakers)), nil
+}
+
+// save 写入（覆盖）某天的汇总与各代币质押量
+func (s *ProtocolStatsService) save(stats *model.ProtocolDailyStats) error {
+	return ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "chain_id"}, {Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"tvl_usd", "volume_usd", "fees_usd", "lp_fees_usd", "protocol_fees_usd", "swap_count", "unique_traders",
+				"liquidity_providers", "new_lps", "stakers", "new_stakers", "stake_count", "withdraw_count",
+				"airdrop_claims", "airdrop_claimers", "airdrop_claimed_amount", "dau", "wau", "mau", "updated_at",
+			}),
+		}).Create(stats).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("chain_id = ? AND day = ?", stats.ChainId, stats.Day).Delete(&model.ProtocolDailyTokenStake{}).Error; err != nil {
+			return err
+		}
+		if len(stats.TokenStakes) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(stats.TokenStakes, 100).Error
+	})
+}
+
+// Query 查询 [from, to] 内的每日汇总；chainId 为 0 时按天合计所有链，
+// 此时地址数类指标按链相加，同一地址在多条链上活跃会重复计数
+func (s *ProtocolStatsService) Query(chainId int64, from, to time.Time) (*model.ProtocolStatsReport, error) {
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+
+	var rows []model.ProtocolDailyStats
+	query := ctx.Ctx.DB.Where("day >= ? AND day <= ?", from, to)
+	if chainId > 0 {
+		query = query.Where("chain_id = ?", chainId)
+	}
+	if err := query.Order("day ASC, chain_id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	var stakes []model.ProtocolDailyTokenStake
+	stakeQuery := ctx.Ctx.DB.Where("day >= ? AND day <= ?", from, to)
+	if chainId > 0 {
+		stakeQuery = stakeQuery.Where("chain_id = ?", chainId)
+	}
+	if err := stakeQuery.Order("token_address ASC").Find(&stakes).Error; err != nil {
+		return nil, err
+	}
+	stakesByDay := make(map[int64][]model.ProtocolDailyTokenStake)
+	for _, st := range stakes {
+		key := st.Day.UTC().Unix()
+		stakesByDay[key] = append(stakesByDay[key], st)
+	}
+
+	report := &model.ProtocolStatsReport{ChainId: chainId, From: from, To: to, List: make([]model.ProtocolDailyStats, 0, len(rows))}
+	byDay := make(map[int64]int)
+	for _, r := range rows {
+		key := r.Day.UTC().Unix()
+		idx, ok := byDay[key]
+		if !ok || chainId > 0 {
+			r.TokenStakes = stakesByDay[key]
+			if chainId == 0 {
+				r.ChainId = 0
+				byDay[key] = len(report.List)
+			}
+			report.List = append(report.List, r)
+		} else {
+			mergeDailyStats(&report.List[idx], r)
+		}
+		report.TotalVolumeUSD = report.TotalVolumeUSD.Add(r.VolumeUSD)
+		report.TotalFeesUSD = report.TotalFeesUSD.Add(r.FeesUSD)
+		report.TotalSwapCount += r.SwapCount
+		report.TotalNewLps += r.NewLps
+		report.TotalNewStakers += r.NewStakers
+		report.TotalClaims += r.AirdropClaims
+	}
+	return report, nil
+}
+
+// mergeDailyStats 将同一天另一条链的汇总累加到 dst
+func mergeDailyStats(dst *model.ProtocolDailyStats, src model.ProtocolDailyStats) {
+	dst.TvlUSD = dst.TvlUSD.Add(src.TvlUSD)
+	dst.VolumeUSD = dst.VolumeUSD.Add(src.VolumeUSD)
+	dst.FeesUSD = dst.FeesUSD.Add(src.FeesUSD)
+	dst.LpFeesUSD = dst.LpFeesUSD.Add(src.LpFeesUSD)
+	dst.ProtocolFeesUSD = dst.ProtocolFeesUSD.Add(src.ProtocolFeesUSD)
+	dst.SwapCount += src.SwapCount
+	dst.UniqueTraders += src.UniqueTraders
+	dst.LiquidityProviders += src.LiquidityProviders
+	dst.NewLps += src.NewLps
+	dst.Stakers += src.Stakers
+	dst.NewStakers += src.NewStakers
+	dst.StakeCount += src.StakeCount
+	dst.WithdrawCount += src.WithdrawCount
+	dst.AirdropClaims += src.AirdropClaims
+	dst.AirdropClaimers += src.AirdropClaimers
+	dst.AirdropClaimedAmount = decimal.RequireFromString(dst.AirdropClaimedAmount).
+		Add(decimal.RequireFromString(src.AirdropClaimedAmount)).String()
+	dst.Dau += src.Dau
+	dst.Wau += src.Wau
+	dst.Mau += src.Mau
+}
+
+// RollupChainIds 需要汇总的链：chain 表中配置的全部链
+func RollupChainIds() ([]int64, error) {
+	var ids []int64
+	err := ctx.Ctx.DB.Model(&model.Chain{}).Distinct("chain_id").Pluck("chain_id", &ids).Error
+	return ids, err
+}
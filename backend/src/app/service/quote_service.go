@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/mumu/cryptoSwap/src/abi"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	TradeTypeExactIn  = "exactIn"
+	TradeTypeExactOut = "exactOut"
+
+	defaultQuoteSlippageBps = 50
+	defaultQuoteMaxHops     = 3
+	maxQuoteHops            = 4
+	defaultQuoteDeadlineSec = 1200
+	// v2RouterFeeBps UniswapV2Library.getAmountOut/getAmountIn 固定按 997/1000 计算，经路由合约成交的数量均按 0.3% 手续费得出
+	v2RouterFeeBps = 30
+
+	// ChainServiceRouter chain 表中路由合约的服务类型
+	ChainServiceRouter = "router"
+)
+
+type QuoteService struct{}
+
+func NewQuoteService() *QuoteService {
+	return &QuoteService{}
+}
+
+// quoteEdge 路由图中的一条边（一个池子的一个方向）
+type quoteEdge struct {
+	pool       model.LiquidityPool
+	tokenOut   string
+	reserveIn  *big.Int
+	reserveOut *big.Int
+	feeBps     int
+}
+
+// quoteRoute 一条候选路由的计算结果
+type quoteRoute struct {
+	tokens  []string
+	edges   []quoteEdge
+	amounts []*big.Int // amounts[i] 为进入第 i 跳的数量，最后一个为最终输出
+}
+
+// Quote 基于已索引池子储备量按恒定乘积公式计算最优路由（最多 maxHops 跳）。
+// exactIn 选择输出最多的路由，exactOut 选择输入最少的路由。
+func (s *QuoteService) Quote(req model.SwapQuoteRequest) (*model.SwapQuoteResponse, error) {
+	tradeType := req.TradeType
+	if tradeType == "" {
+		tradeType = TradeTypeExactIn
+	}
+	if tradeType != TradeTypeExactIn && tradeType != TradeTypeExactOut {
+		return nil, fmt.Errorf("不支持的交易类型: %s", req.TradeType)
+	}
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("数量无效: %s", req.Amount)
+	}
+	tokenIn := strings.ToLower(req.TokenIn)
+	tokenOut := strings.ToLower(req.TokenOut)
+	if tokenIn == tokenOut {
+		return nil, fmt.Errorf("输入与输出代币相同")
+	}
+	maxHops := req.MaxHops
+	if maxHops <= 0 {
+		maxHops = defaultQuoteMaxHops
+	}
+	if maxHops > maxQuoteHops {
+		maxHops = maxQuoteHops
+	}
+	slippageBps := req.SlippageBps
+	if slippageBps <= 0 {
+		slippageBps = defaultQuoteSlippageBps
+	}
+	if slippageBps >= 10000 {
+		return nil, fmt.Errorf("滑点无效: %d", req.SlippageBps)
+	}
+
+	graph, err := s.loadGraph(req.ChainId)
+	if err != nil {
+		return nil, err
+	}
+
+	best := s.bestRoute(graph, tradeType, tokenIn, tokenOut, amount, maxHops)
+	if best == nil {
+		return nil, fmt.Errorf("未找到可用路由或流动性不足")
+	}
+
+	return s.buildResponse(req, tradeType, slippageBps, best)
+}
+
+func (s *QuoteService) buildResponse(req model.SwapQuoteRequest, tradeType string, slippageBps int, route *quoteRoute) (*model.SwapQuoteResponse, error) {
+	amountIn := route.amounts[0]
+	amountOut := route.amounts[len(route.amounts)-1]
+
+	resp := &model.SwapQuoteResponse{
+		TradeType:   tradeType,
+		AmountIn:    amountIn.String(),
+		AmountOut:   amountOut.String(),
+		SlippageBps: slippageBps,
+	}
+
+	path := make([]common.Address, 0, len(route.tokens))
+	for _, hopToken := range route.tokens {
+		path = append(path, common.HexToAddress(hopToken))
+		resp.Path = append(resp.Path, common.HexToAddress(hopToken).Hex())
+	}
+	for i, e := range route.edges {
+		resp.Route = append(resp.Route, model.SwapQuoteHop{
+			PoolAddress: e.pool.PoolAddress,
+			TokenIn:     common.HexToAddress(route.tokens[i]).Hex(),
+			TokenOut:    common.HexToAddress(e.tokenOut).Hex(),
+			AmountIn:    route.amounts[i].String(),
+			AmountOut:   route.amounts[i+1].String(),
+			FeeBps:      e.feeBps,
+		})
+	}
+
+	inDecimals := tokenDecimalsOf(route.edges[0].pool, route.tokens[0])
+	outDecimals := tokenDecimalsOf(route.edges[len(route.edges)-1].pool, route.tokens[len(route.tokens)-1])
+	inAmount := tokenAmount(amountIn, inDecimals)
+	outAmount := tokenAmount(amountOut, outDecimals)
+	if inAmount.IsPositive() {
+		resp.ExecutionPrice = outAmount.Div(inAmount).String()
+	}
+	resp.PriceImpact = priceImpact(route)
+
+	// 滑点保护：exactIn 限制最少输出，exactOut 限制最多输入
+	var amountLimit *big.Int
+	if tradeType == TradeTypeExactIn {
+		amountLimit = new(big.Int).Div(new(big.Int).Mul(amountOut, big.NewInt(int64(10000-slippageBps))), big.NewInt(10000))
+		resp.MinimumReceived = amountLimit.String()
+	} else {
+		amountLimit = new(big.Int).Div(new(big.Int).Mul(amountIn, big.NewInt(int64(10000+slippageBps))), big.NewInt(10000))
+		resp.MaximumSold = amountLimit.String()
+	}
+
+	router, _ := s.RouterAddress(req.ChainId)
+	resp.Router = router
+
+	if req.Recipient != "" {
+		deadlineSec := req.DeadlineSec
+		if deadlineSec <= 0 {
+			deadlineSec = defaultQuoteDeadlineSec
+		}
+		resp.Deadline = time.Now().Unix() + deadlineSec
+		calldata, err := encodeSwapCalldata(tradeType, amountIn, amountOut, amountLimit, path, common.HexToAddress(req.Recipient), resp.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		resp.Calldata = calldata
+	}
+
+	if req.Verify {
+		onchain, err := s.verifyOnchain(req.ChainId, router, tradeType, route, path)
+		if err != nil {
+			return nil, err
+		}
+		matched := len(onchain) == len(route.amounts)
+		for i := 0; matched && i < len(onchain); i++ {
+			matched = onchain[i].Cmp(route.amounts[i]) == 0
+		}
+		resp.Verified = &matched
+		for _, v := range onchain {
+			resp.OnchainAmounts = append(resp.OnchainAmounts, v.String())
+		}
+	}
+	return resp, nil
+}
+
+// RouterAddress 查询链上路由合约地址（chain 表中 service_type = 'router' 的记录）
+func (s *QuoteService) RouterAddress(chainId int64) (string, bool) {
+	var chain model.Chain
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND service_type = ?", chainId, ChainServiceRouter).First(&chain).Error; err != nil || chain.Address == "" {
+		return "", false
+	}
+	return common.HexToAddress(chain.Address).Hex(), true
+}
+
+// loadGraph 加载链上所有有储备量的活跃池子，构建 代币 -> 边 的邻接表
+func (s *QuoteService) loadGraph(chainId int64) (map[string][]quoteEdge, error) {
+	var pools []model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND is_active = ?", chainId, true).Find(&pools).Error; err != nil {
+		return nil, err
+	}
+
+	graph := make(map[string][]quoteEdge)
+	for _, pool := range pools {
+		// 报价按恒定乘积计算且交易经由 V2 Router 执行，V3 池子不参与路由
+		if PoolModelOf(pool).Type() != PoolTypeV2 {
+			continue
+		}
+		reserve0, reserve1 := parseBigInt(pool.Reserve0), parseBigInt(pool.Reserve1)
+		if reserve0.Sign() <= 0 || reserve1.Sign() <= 0 {
+			continue
+		}
+		token0 := strings.ToLower(pool.Token0Address)
+		token1 := strings.ToLower(pool.Token1Address)
+		if token0 == "" || token1 == "" || token0 == zeroAddress || token1 == zeroAddress {
+			continue
+		}
+		feeBps, ok := routerFeeBps(pool)
+		if !ok {
+			continue
+		}
+		graph[token0] = append(graph[token0], quoteEdge{pool: pool, tokenOut: token1, reserveIn: reserve0, reserveOut: reserve1, feeBps: feeBps})
+		graph[token1] = append(graph[token1], quoteEdge{pool: pool, tokenOut: token0, reserveIn: reserve1, reserveOut: reserve0, feeBps: feeBps})
+	}
+	return graph, nil
+}
+
+// routerFeeBps 池子经路由合约成交时的手续费：路由合约按固定 0.3% 计算数量，
+// 池子费率高于 0.3% 时 swap 的 K 值校验会回滚，不参与路由；费率更低的池子仍按 0.3% 成交
+func routerFeeBps(pool model.LiquidityPool) (int, bool) {
+	if feeBps, _ := PoolFeeRates(pool); feeBps > v2RouterFeeBps {
+		return 0, false
+	}
+	return v2RouterFeeBps, true
+}
+
+// bestRoute 枚举候选路由并按交易类型取最优，无可用路由时返回 nil
+func (s *QuoteService) bestRoute(graph map[string][]quoteEdge, tradeType, tokenIn, tokenOut string, amount *big.Int, maxHops int) *quoteRoute {
+	var best *quoteRoute
+	s.walkRoutes(graph, tokenIn, tokenOut, maxHops, func(tokens []string, edges []quoteEdge) {
+		var amounts []*big.Int
+		if tradeType == TradeTypeExactIn {
+			amounts = amountsOut(amount, edges)
+		} else {
+			amounts = amountsIn(amount, edges)
+		}
+		if amounts == nil {
+			return
+		}
+		if best == nil || betterRoute(tradeType, amounts, best.amounts) {
+			best = &quoteRoute{
+				tokens:  append([]string(nil), tokens...),
+				edges:   append([]quoteEdge(nil), edges...),
+				amounts: amounts,
+			}
+		}
+	})
+	return best
+}
+
+// walkRoutes 深度优先枚举从 tokenIn 到 tokenOut 的所有简单路径（代币不重复）
+func (s *QuoteService) walkRoutes(graph map[string][]quoteEdge, tokenIn, tokenOut string, maxHops int, visit func(tokens []string, edges []quoteEdge)) {
+	tokens := []string{tokenIn}
+	var edges []quoteEdge
+	visited := map[string]bool{tokenIn: true}
+
+	var dfs func(current string)
+	dfs = func(current string) {
+		if len(edges) >= maxHops {
+			return
+		}
+		for _, e := range graph[current] {
+			if visited[e.tokenOut] {
+				continue
+			}
+			tokens = append(tokens, e.tokenOut)
+			edges = append(edges, e)
+			if e.tokenOut == tokenOut {
+				visit(tokens, edges)
+			} else {
+				visited[e.tokenOut] = true
+				dfs(e.tokenOut)
+				visited[e.tokenOut] = false
+			}
+			tokens = tokens[:len(tokens)-1]
+			edges = edges[:len(edges)-1]
+		}
+	}
+	dfs(tokenIn)
+}
+
+// betterRoute exactIn 比较输出（越多越好），exactOut 比较输入（越少越好）
+func betterRoute(tradeType string, candidate, best []*big.Int) bool {
+	if tradeType == TradeTypeExactIn {
+		return candidate[len(candidate)-1].Cmp(best[len(best)-1]) > 0
+	}
+	return candidate[0].Cmp(best[0]) < 0
+}
+
+// getAmountOut Uniswap V2 恒定乘积公式：给定输入计算输出
+func getAmountOut(amountIn, reserveIn, reserveOut *big.Int, feeBps int) *big.Int {
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(int64(10000-feeBps)))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(10000)), amountInWithFee)
+	return numerator.Div(numerator, denominator)
+}
+
+// getAmountIn Uniswap V2 恒定乘积公式：给定输出计算所需输入，输出不小于储备量时返回 nil
+func getAmountIn(amountOut, reserveIn, reserveOut *big.Int, feeBps int) *big.Int {
+	if amountOut.Cmp(reserveOut) >= 0 {
+		return nil
+	}
+	numerator := new(big.Int).Mul(new(big.Int).Mul(reserveIn, amountOut), big.NewInt(10000))
+	denominator := new(big.Int).Mul(new(big.Int).Sub(reserveOut, amountOut), big.NewInt(int64(10000-feeBps)))
+	amountIn := numerator.Div(numerator, denominator)
+	return amountIn.Add(amountIn, big.NewInt(1))
+}
+
+// amountsOut 与路由合约 getAmountsOut 相同的逐跳计算，输出为 0 时返回 nil
+func amountsOut(amountIn *big.Int, edges []quoteEdge) []*big.Int {
+	amounts := []*big.Int{amountIn}
+	current := amountIn
+	for _, e := range edges {
+		current = getAmountOut(current, e.reserveIn, e.reserveOut, e.feeBps)
+		if current.Sign() <= 0 {
+			return nil
+		}
+		amounts = append(amounts, current)
+	}
+	return amounts
+}
+
+// amountsIn 与路由合约 getAmountsIn 相同的逆序计算，流动性不足时返回 nil
+func amountsIn(amountOut *big.Int, edges []quoteEdge) []*big.Int {
+	amounts := make([]*big.Int, len(edges)+1)
+	amounts[len(edges)] = amountOut
+	for i := len(edges) - 1; i >= 0; i-- {
+		in := getAmountIn(amounts[i+1], edges[i].reserveIn, edges[i].reserveOut, edges[i].feeBps)
+		if in == nil {
+			return nil
+		}
+		amounts[i] = in
+	}
+	return amounts
+}
+
+// priceImpact 价格影响（百分比）：实际输出相对“按中间价成交并扣除手续费”的输出的偏离
+func priceImpact(route *quoteRoute) float64 {
+	ideal := decimal.NewFromBigInt(route.amounts[0], 0)
+	for _, e := range route.edges {
+		ideal = ideal.Mul(decimal.NewFromBigInt(e.reserveOut, 0)).Div(decimal.NewFromBigInt(e.reserveIn, 0)).
+			Mul(decimal.NewFromInt(int64(10000 - e.feeBps))).Div(decimal.NewFromInt(10000))
+	}
+	if !ideal.IsPositive() {
+		return 0
+	}
+	actual := decimal.NewFromBigInt(route.amounts[len(route.amounts)-1], 0)
+	impact := decimal.NewFromInt(1).Sub(actual.Div(ideal)).Mul(decimal.NewFromInt(100))
+	if impact.IsNegative() {
+		return 0
+	}
+	return impact.InexactFloat64()
+}
+
+// encodeSwapCalldata 编码路由合约 swapExactTokensForTokens / swapTokensForExactTokens 调用数据
+func encodeSwapCalldata(tradeType string, amountIn, amountOut, amountLimit *big.Int, path []common.Address, recipient common.Address, deadline int64) (string, error) {
+	routerABI, ok := abi.GetABIManager().GetABI(abi.ABIUniswapV2Router)
+	if !ok {
+		return "", fmt.Errorf("UniswapV2Router ABI 未找到")
+	}
+	var data []byte
+	var err error
+	if tradeType == TradeTypeExactIn {
+		data, err = routerABI.Pack("swapExactTokensForTokens", amountIn, amountLimit, path, recipient, big.NewInt(deadline))
+	} else {
+		data, err = routerABI.Pack("swapTokensForExactTokens", amountOut, amountLimit, path, recipient, big.NewInt(deadline))
+	}
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(data), nil
+}
+
+// verifyOnchain 调用路由合约 getAmountsOut / getAmountsIn，与链下计算结果对照
+func (s *QuoteService) verifyOnchain(chainId int64, router, tradeType string, route *quoteRoute, path []common.Address) ([]*big.Int, error) {
+	if router == "" {
+		return nil, fmt.Errorf("链 %d 未配置路由合约", chainId)
+	}
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return nil, fmt.Errorf("不支持的 chainId: %d", chainId)
+	}
+	routerABI, ok := abi.GetABIManager().GetABI(abi.ABIUniswapV2Router)
+	if !ok {
+		return nil, fmt.Errorf("UniswapV2Router ABI 未找到")
+	}
+
+	method := "getAmountsOut"
+	amount := route.amounts[0]
+	if tradeType == TradeTypeExactOut {
+		method = "getAmountsIn"
+		amount = route.amounts[len(route.amounts)-1]
+	}
+	data, err := routerABI.Pack(method, amount, path)
+	if err != nil {
+		return nil, err
+	}
+	to := common.HexToAddress(router)
+	res, err := ctx.GetEvmClient(int(chainId)).CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("调用 %s 失败: %v", method, err)
+	}
+	var amounts []*big.Int
+	if err := routerABI.UnpackIntoInterface(&amounts, method, res); err != nil {
+		return nil, err
+	}
+	return amounts, nil
+}
+
+// tokenDecimalsOf 从池子信息中取代币精度
+func tokenDecimalsOf(pool model.LiquidityPool, token string) int {
+	if strings.EqualFold(pool.Token0Address, token) {
+		return pool.Token0Decimals
+	}
+	return pool.Token1Decimals
+}
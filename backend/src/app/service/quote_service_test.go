@@ -0,0 +1,214 @@
+package service
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+)
+
+const (
+	tokenA = "0x000000000000000000000000000000000000000a"
+	tokenB = "0x000000000000000000000000000000000000000b"
+	tokenC = "0x000000000000000000000000000000000000000c"
+)
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+func edge(tokenOut string, reserveIn, reserveOut *big.Int) quoteEdge {
+	return quoteEdge{tokenOut: tokenOut, reserveIn: reserveIn, reserveOut: reserveOut, feeBps: v2RouterFeeBps}
+}
+
+// routerAmountOut / routerAmountIn 按 UniswapV2Library 原文（997/1000）计算，作为对照
+func routerAmountOut(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(997))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(1000)), amountInWithFee)
+	return numerator.Div(numerator, denominator)
+}
+
+func routerAmountIn(amountOut, reserveIn, reserveOut *big.Int) *big.Int {
+	numerator := new(big.Int).Mul(new(big.Int).Mul(reserveIn, amountOut), big.NewInt(1000))
+	denominator := new(big.Int).Mul(new(big.Int).Sub(reserveOut, amountOut), big.NewInt(997))
+	return numerator.Div(numerator, denominator).Add(numerator, big.NewInt(1))
+}
+
+func TestGetAmountOut(t *testing.T) {
+	cases := []struct {
+		name                string
+		amountIn, rIn, rOut *big.Int
+		want                string
+	}{
+		{"1:1 池子 1 ether", ether(1), ether(100), ether(100), "987158034397061298"},
+		// 1 * 997 / 1000 向下取整为 0
+		{"输入扣费后不足 1", big.NewInt(1), big.NewInt(1e6), big.NewInt(1e6), "0"},
+		{"手续费向下取整", big.NewInt(1003), big.NewInt(1e6), big.NewInt(1e6), "998"},
+		{"极小储备", big.NewInt(2), big.NewInt(3), ether(1), "399279134961954345"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := getAmountOut(c.amountIn, c.rIn, c.rOut, v2RouterFeeBps)
+			if got.String() != c.want {
+				t.Fatalf("getAmountOut = %s, want %s", got, c.want)
+			}
+			if ref := routerAmountOut(c.amountIn, c.rIn, c.rOut); got.Cmp(ref) != 0 {
+				t.Fatalf("getAmountOut = %s, router = %s", got, ref)
+			}
+		})
+	}
+}
+
+func TestGetAmountIn(t *testing.T) {
+	cases := []struct {
+		name                 string
+		amountOut, rIn, rOut *big.Int
+		want                 string
+	}{
+		// 路由合约在整除结果上固定加 1
+		{"最小输出", big.NewInt(1), big.NewInt(1e6), big.NewInt(1e6), "2"},
+		{"输入向上取整", big.NewInt(999), big.NewInt(1e6), big.NewInt(1e6), "1004"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := getAmountIn(c.amountOut, c.rIn, c.rOut, v2RouterFeeBps)
+			if got == nil || got.String() != c.want {
+				t.Fatalf("getAmountIn = %v, want %s", got, c.want)
+			}
+			if ref := routerAmountIn(c.amountOut, c.rIn, c.rOut); got.Cmp(ref) != 0 {
+				t.Fatalf("getAmountIn = %s, router = %s", got, ref)
+			}
+			// 按算出的输入成交，输出不少于目标
+			if out := getAmountOut(got, c.rIn, c.rOut, v2RouterFeeBps); out.Cmp(c.amountOut) < 0 {
+				t.Fatalf("按输入 %s 只能得到 %s，少于 %s", got, out, c.amountOut)
+			}
+		})
+	}
+
+	if got := getAmountIn(big.NewInt(1e6), big.NewInt(1e6), big.NewInt(1e6), v2RouterFeeBps); got != nil {
+		t.Fatalf("输出等于储备量时应返回 nil，得到 %s", got)
+	}
+}
+
+func TestAmountsMultiHop(t *testing.T) {
+	// A(18 位) -> B(6 位) -> C(18 位)
+	edges := []quoteEdge{
+		edge(tokenB, ether(1000), big.NewInt(2000e6)),
+		edge(tokenC, big.NewInt(5000e6), ether(2)),
+	}
+	cases := []struct {
+		name      string
+		tradeType string
+		amount    *big.Int
+		want      []string
+	}{
+		{"exactIn", TradeTypeExactIn, ether(3), []string{"3000000000000000000", "5964161", "2375682118043016"}},
+		{"exactOut", TradeTypeExactOut, big.NewInt(1e17), []string{"152497875572896635114", "263949744", "100000000000000000"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []*big.Int
+			if c.tradeType == TradeTypeExactIn {
+				got = amountsOut(c.amount, edges)
+			} else {
+				got = amountsIn(c.amount, edges)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("amounts = %v, want %v", got, c.want)
+			}
+			for i := range got {
+				if got[i].String() != c.want[i] {
+					t.Fatalf("amounts[%d] = %s, want %s", i, got[i], c.want[i])
+				}
+			}
+		})
+	}
+
+	// 中间跳输出为 0 时整条路由不可用
+	dust := []quoteEdge{
+		edge(tokenB, big.NewInt(1e6), big.NewInt(1e6)),
+		edge(tokenC, big.NewInt(1e6), big.NewInt(1e6)),
+	}
+	if got := amountsOut(big.NewInt(1), dust); got != nil {
+		t.Fatalf("输出为 0 时应返回 nil，得到 %v", got)
+	}
+	// 任一跳流动性不足时 exactOut 不可用
+	if got := amountsIn(ether(2), edges); got != nil {
+		t.Fatalf("输出超过储备量时应返回 nil，得到 %v", got)
+	}
+}
+
+func TestBestRoute(t *testing.T) {
+	direct := func(tokenOut string) quoteEdge { return edge(tokenOut, ether(10), ether(10)) }
+	deep := func(tokenOut string) quoteEdge { return edge(tokenOut, ether(1000), ether(1000)) }
+	graph := map[string][]quoteEdge{
+		tokenA: {direct(tokenC), deep(tokenB)},
+		tokenB: {deep(tokenA), deep(tokenC)},
+		tokenC: {direct(tokenA), deep(tokenB)},
+	}
+	s := NewQuoteService()
+
+	cases := []struct {
+		name      string
+		tradeType string
+		amount    *big.Int
+		maxHops   int
+		tokens    []string
+		amountOut string
+	}{
+		// 浅池直连 3326659993326659993，经 B 两跳 4921055669363976492
+		{"两跳优于浅池直连", TradeTypeExactIn, ether(5), 3, []string{tokenA, tokenB, tokenC}, "4921055669363976492"},
+		{"限制一跳", TradeTypeExactIn, ether(5), 1, []string{tokenA, tokenC}, "3326659993326659993"},
+		{"exactOut 取输入最少", TradeTypeExactOut, ether(5), 3, []string{tokenA, tokenB, tokenC}, "5000000000000000000"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			best := s.bestRoute(graph, c.tradeType, tokenA, tokenC, c.amount, c.maxHops)
+			if best == nil {
+				t.Fatal("未找到路由")
+			}
+			if len(best.tokens) != len(c.tokens) {
+				t.Fatalf("路径 = %v, want %v", best.tokens, c.tokens)
+			}
+			for i := range c.tokens {
+				if best.tokens[i] != c.tokens[i] {
+					t.Fatalf("路径 = %v, want %v", best.tokens, c.tokens)
+				}
+			}
+			if got := best.amounts[len(best.amounts)-1].String(); got != c.amountOut {
+				t.Fatalf("输出 = %s, want %s", got, c.amountOut)
+			}
+		})
+	}
+
+	if best := s.bestRoute(graph, TradeTypeExactOut, tokenA, tokenC, ether(10), 1); best != nil {
+		t.Fatalf("直连池子储备不足时不应有路由，得到 %v", best.tokens)
+	}
+}
+
+func TestRouterFeeBps(t *testing.T) {
+	cases := []struct {
+		name   string
+		feeBps int
+		ok     bool
+	}{
+		{"未登记按默认费率", 0, true},
+		{"0.3%", 30, true},
+		// 路由合约按 0.3% 计算，池子实际收取更少，仍按 0.3% 成交
+		{"低于 0.3%", 25, true},
+		// 路由合约按 0.3% 计算的输出会使 K 值校验失败
+		{"高于 0.3%", 100, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			feeBps, ok := routerFeeBps(model.LiquidityPool{FeeBps: c.feeBps})
+			if ok != c.ok {
+				t.Fatalf("ok = %v, want %v", ok, c.ok)
+			}
+			if ok && feeBps != v2RouterFeeBps {
+				t.Fatalf("feeBps = %d, want %d", feeBps, v2RouterFeeBps)
+			}
+		})
+	}
+}
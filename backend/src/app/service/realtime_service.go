@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+const (
+	realtimeChannel    = "realtime:events"
+	realtimeSeqKey     = "realtime:seq"
+	realtimeHistoryKey = "realtime:history"
+	// realtimeHistorySize 保留用于断线重放的最近消息数
+	realtimeHistorySize = 10000
+	// realtimeClientBuffer 单个订阅的缓冲区，写满说明客户端消费过慢，直接断开由其携带 cursor 重连
+	realtimeClientBuffer = 256
+)
+
+type RealtimeService struct{}
+
+func NewRealtimeService() *RealtimeService {
+	return &RealtimeService{}
+}
+
+// Publish 为消息分配递增序号，写入重放历史并发布到 Redis；推送失败只记录日志，不影响索引
+func (s *RealtimeService) Publish(msgs []model.RealtimeMessage) {
+	if len(msgs) == 0 || ctx.Ctx.Redis == nil {
+		return
+	}
+	bg := context.Background()
+	now := time.Now()
+	for _, msg := range msgs {
+		id, err := ctx.Ctx.Redis.Incr(bg, realtimeSeqKey).Result()
+		if err != nil {
+			log.Logger.Error("分配推送消息序号失败", zap.Error(err))
+			return
+		}
+		msg.Id = id
+		msg.PublishedAt = now
+		data, err := json.Marshal(msg)
+		if err != nil {
+			log.Logger.Error("序列化推送消息失败", zap.Error(err))
+			continue
+		}
+		pipe := ctx.Ctx.Redis.TxPipeline()
+		pipe.ZAdd(bg, realtimeHistoryKey, &redis.Z{Score: float64(id), Member: data})
+		pipe.ZRemRangeByRank(bg, realtimeHistoryKey, 0, -realtimeHistorySize-1)
+		pipe.Publish(bg, realtimeChannel, data)
+		if _, err := pipe.Exec(bg); err != nil {
+			log.Logger.Error("发布推送消息失败", zap.Int64("id", id), zap.Error(err))
+		}
+	}
+}
+
+// Replay 读取序号大于 cursor 的历史消息；truncated 表示 cursor 之后的部分消息已被淘汰
+func (s *RealtimeService) Replay(cursor int64, filter RealtimeFilter) ([]model.RealtimeMessage, bool, error) {
+	bg := context.Background()
+	items, err := ctx.Ctx.Redis.ZRangeByScoreWithScores(bg, realtimeHistoryKey, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(cursor, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	truncated := false
+	if len(items) > 0 && int64(items[0].Score) > cursor+1 {
+		// 第一条不是紧接 cursor 的消息时，确认中间的消息是否已被淘汰
+		oldest, err := ctx.Ctx.Redis.ZRangeWithScores(bg, realtimeHistoryKey, 0, 0).Result()
+		truncated = err == nil && len(oldest) > 0 && int64(oldest[0].Score) > cursor+1
+	}
+
+	list := make([]model.RealtimeMessage, 0, len(items))
+	for _, item := range items {
+		raw, ok := item.Member.(string)
+		if !ok {
+			continue
+		}
+		var msg model.RealtimeMessage
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			continue
+		}
+		if filter.Match(&msg) {
+			list = append(list, msg)
+		}
+	}
+	return list, truncated, nil
+}
+
+// RealtimeFilter 订阅条件：池子、用户、消息类型三个维度之间为且，同一维度内多个值为或，空维度不过滤
+type RealtimeFilter struct {
+	Pools map[string]bool
+	Users map[string]bool
+	Types map[string]bool
+}
+
+func NewRealtimeFilter(pools, users, types []string) RealtimeFilter {
+	toSet := func(values []string) map[string]bool {
+		set := make(map[string]bool)
+		for _, v := range values {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+				set[v] = true
+			}
+		}
+		return set
+	}
+	return RealtimeFilter{Pools: toSet(pools), Users: toSet(users), Types: toSet(types)}
+}
+
+func (f RealtimeFilter) Match(msg *model.RealtimeMessage) bool {
+	if len(f.Pools) > 0 && !f.Pools[msg.PoolAddress] {
+		return false
+	}
+	if len(f.Users) > 0 && !f.Users[msg.UserAddress] {
+		return false
+	}
+	if len(f.Types) > 0 && !f.Types[msg.Type] {
+		return false
+	}
+	return true
+}
+
+// RealtimeSubscription 单个客户端的订阅；C 被关闭表示订阅已结束（客户端消费过慢或已取消）
+type RealtimeSubscription struct {
+	C      <-chan model.RealtimeMessage
+	ch     chan model.RealtimeMessage
+	filter RealtimeFilter
+	hub    *RealtimeHub
+}
+
+// Close 取消订阅
+func (s *RealtimeSubscription) Close() {
+	s.hub.remove(s)
+}
+
+// RealtimeHub API 进程内共享一个 Redis 订阅，按订阅条件分发给各客户端
+type RealtimeHub struct {
+	mu   sync.Mutex
+	subs map[*RealtimeSubscription]struct{}
+	once sync.Once
+}
+
+var realtimeHub = &RealtimeHub{subs: make(map[*RealtimeSubscription]struct{})}
+
+func GetRealtimeHub() *RealtimeHub {
+	return realtimeHub
+}
+
+// Subscribe 注册订阅；cursor 大于 0 时返回其后的历史消息用于重放。
+// 订阅先于重放注册，重放与实时消息可能重叠，调用方需按消息序号去重
+func (h *RealtimeHub) Subscribe(filter RealtimeFilter, cursor int64) (*RealtimeSubscription, []model.RealtimeMessage, bool, error) {
+	h.once.Do(func() { go h.run() })
+
+	ch := make(chan model.RealtimeMessage, realtimeClientBuffer)
+	sub := &RealtimeSubscription{C: ch, ch: ch, filter: filter, hub: h}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	if cursor <= 0 {
+		return sub, nil, false, nil
+	}
+	replay, truncated, err := NewRealtimeService().Replay(cursor, filter)
+	if err != nil {
+		sub.Close()
+		return nil, nil, false, err
+	}
+	return sub, replay, truncated, nil
+}
+
+func (h *RealtimeHub) remove(sub *RealtimeSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// run 订阅 Redis 频道并分发消息，go-redis 会在连接断开后自动重新订阅
+func (h *RealtimeHub) run() {
+	pubsub := ctx.Ctx.Redis.Subscribe(context.Background(), realtimeChannel)
+	log.Logger.Info("实时推送已订阅 Redis 频道", zap.String("channel", realtimeChannel))
+	for m := range pubsub.Channel() {
+		var msg model.RealtimeMessage
+		if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+			log.Logger.Warn("解析推送消息失败", zap.Error(err))
+			continue
+		}
+		h.dispatch(msg)
+	}
+}
+
+func (h *RealtimeHub) dispatch(msg model.RealtimeMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.filter.Match(&msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			log.Logger.Warn("实时推送客户端消费过慢，断开订阅", zap.Int64("id", msg.Id))
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// RealtimeFromLiquidityEvents 将流动性池事件转换为推送消息
+func RealtimeFromLiquidityEvents(events []*model.LiquidityPoolEvent) []model.RealtimeMessage {
+	msgs := make([]model.RealtimeMessage, 0, len(events))
+	for _, e := range events {
+		event, ok := ExplorerEventFromLiquidity(e)
+		if !ok {
+			continue
+		}
+		msgs = append(msgs, model.RealtimeMessage{
+			Type:        event.EventType,
+			ChainId:     e.ChainId,
+			PoolAddress: strings.ToLower(e.PoolAddress),
+			UserAddress: strings.ToLower(e.UserAddress),
+			Event:       &event,
+		})
+	}
+	return msgs
+}
+
+// RealtimeFromStakeRecords 将质押/提取记录转换为推送消息
+func RealtimeFromStakeRecords(records []*model.UserOperationRecord) []model.RealtimeMessage {
+	msgs := make([]model.RealtimeMessage, 0, len(records))
+	for _, r := range records {
+		event, ok := ExplorerEventFromStake(r)
+		if !ok {
+			continue
+		}
+		msgs = append(msgs, model.RealtimeMessage{
+			Type:        event.EventType,
+			ChainId:     r.ChainId,
+			UserAddress: strings.ToLower(r.Address),
+			Event:       &event,
+		})
+	}
+	return msgs
+}
+
+// RealtimeFromClaims 将空投领取事件转换为推送消息
+func RealtimeFromClaims(claims []*model.RewardClaimedEvent) []model.RealtimeMessage {
+	msgs := make([]model.RealtimeMessage, 0, len(claims))
+	for _, e := range claims {
+		if e == nil {
+			continue
+		}
+		event := ExplorerEventFromClaim(e)
+		msgs = append(msgs, model.RealtimeMessage{
+			Type:        event.EventType,
+			ChainId:     e.ChainId,
+			UserAddress: event.UserAddress,
+			Event:       &event,
+		})
+	}
+	return msgs
+}
+
+// RealtimeFromPools 将池子最新状态转换为推送消息
+func RealtimeFromPools(pools []model.LiquidityPool) []model.RealtimeMessage {
+	msgs := make([]model.RealtimeMessage, 0, len(pools))
+	for _, p := range pools {
+		msgs = append(msgs, model.RealtimeMessage{
+			Type:        model.RealtimeTypePool,
+			ChainId:     p.ChainId,
+			PoolAddress: strings.ToLower(p.PoolAddress),
+			Pool: &model.RealtimePoolUpdate{
+				ChainId:       p.ChainId,
+				PoolAddress:   p.PoolAddress,
+				PoolType:      PoolModelOf(p).Type(),
+				Token0Address: p.Token0Address,
+				Token1Address: p.Token1Address,
+				Token0Symbol:  p.Token0Symbol,
+				Token1Symbol:  p.Token1Symbol,
+				Reserve0:      p.Reserve0,
+				Reserve1:      p.Reserve1,
+				Price:         p.Price,
+				SqrtPriceX96:  p.SqrtPriceX96,
+				Tick:          p.Tick,
+				Liquidity:     p.Liquidity,
+				LastBlockNum:  p.LastBlockNum,
+			},
+		})
+	}
+	return msgs
+}
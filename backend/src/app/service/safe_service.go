@@ -0,0 +1,968 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mumu/cryptoSwap/src/app/api/dto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// safeABI Safe（v1.3.0 及以上）用到的只读方法
+const safeABI = `[{"inputs":[],"name":"nonce","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"owner","type":"address"}],"name":"isOwner","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
+
+// safeExecTransactionABI Safe.execTransaction，用于解析执行交易中实际调用的内容
+const safeExecTransactionABI = `[{"inputs":[{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"},{"internalType":"bytes","name":"data","type":"bytes"},{"internalType":"uint8","name":"operation","type":"uint8"},{"internalType":"uint256","name":"safeTxGas","type":"uint256"},{"internalType":"uint256","name":"baseGas","type":"uint256"},{"internalType":"uint256","name":"gasPrice","type":"uint256"},{"internalType":"address","name":"gasToken","type":"address"},{"internalType":"address payable","name":"refundReceiver","type":"address"},{"internalType":"bytes","name":"signatures","type":"bytes"}],"name":"execTransaction","outputs":[{"internalType":"bool","name":"success","type":"bool"}],"stateMutability":"payable","type":"function"}]`
+
+// multiSendABI MultiSendCallOnly.multiSend(bytes)
+const multiSendABI = `[{"inputs":[{"internalType":"bytes","name":"transactions","type":"bytes"}],"name":"multiSend","outputs":[],"stateMutability":"payable","type":"function"}]`
+
+const (
+	// defaultMultiSendCallOnly v1.3.0 MultiSendCallOnly 规范部署地址
+	defaultMultiSendCallOnly = "0x40A2aCCbd92BCA938b02010E17A5b8929b49130D"
+	// safeTxBuilderVersion 生成的 Transaction Builder 文件版本
+	safeTxBuilderVersion   = "1.16.5"
+	safeProposalMaxActions = 20
+	safeProposalTitleMax   = 128
+	// safeExecScanRange 单次 eth_getLogs 查找执行事件的区块跨度
+	safeExecScanRange = 2000
+	// safeExecScanRounds 每轮对账单个提案最多查询次数，剩余部分下一轮继续
+	safeExecScanRounds = 10
+)
+
+var (
+	// ErrSafeInvalidParam Safe 提案参数错误或调用模拟失败
+	ErrSafeInvalidParam = errors.New("Safe 提案参数无效")
+	// ErrSafeForbidden 调用方不是 Safe owner 或功能未启用
+	ErrSafeForbidden = errors.New("无权操作 Safe 提案")
+	// ErrSafeNotFound 提案不存在
+	ErrSafeNotFound = errors.New("Safe 提案不存在")
+)
+
+var (
+	safeDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	safeTxTypeHash     = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+	// Safe 执行事件的 txHash 未建索引，按 data 前 32 字节匹配
+	safeExecutionSuccessTopic = crypto.Keccak256Hash([]byte("ExecutionSuccess(bytes32,uint256)"))
+	safeExecutionFailureTopic = crypto.Keccak256Hash([]byte("ExecutionFailure(bytes32,uint256)"))
+)
+
+// SafeProposalActionInput 提案中的单个操作，params 与对应管理接口的请求体一致（无需 chainId、dryRun）
+type SafeProposalActionInput struct {
+	// create_airdrop、update_merkle_root、activate_airdrop、add_pool、set_pool_active、pause、unpause、grant_role、revoke_role
+	Action string          `json:"action" binding:"required"`
+	Params json.RawMessage `json:"params" swaggertype:"object"`
+}
+
+// SafeProposalInput 创建 Safe 提案参数，多个操作按顺序经 MultiSendCallOnly 批量执行
+type SafeProposalInput struct {
+	ChainId     int64                     `json:"chainId" binding:"required"`
+	Title       string                    `json:"title" binding:"required"`
+	Description string                    `json:"description"`
+	Actions     []SafeProposalActionInput `json:"actions" binding:"required"`
+}
+
+// safeUpdateMerkleRootParams update_merkle_root 参数
+type safeUpdateMerkleRootParams struct {
+	AirdropId  string `json:"airdropId"`
+	NewRoot    string `json:"newRoot"`
+	NewVersion uint32 `json:"newVersion"`
+}
+
+// safeCreateAirdropParams create_airdrop 参数，空投ID由合约分配，执行后按 AirdropCreated 事件确认
+type safeCreateAirdropParams struct {
+	ContractAddress string `json:"contractAddress"` // 留空时使用该链已索引空投的合约
+	Name            string `json:"name"`
+	MerkleRoot      string `json:"merkleRoot"`
+	TotalReward     string `json:"totalReward"` // 最小单位（wei）
+	StartTime       int64  `json:"startTime"`   // Unix 秒
+	EndTime         int64  `json:"endTime"`     // Unix 秒
+	TreeVersion     string `json:"treeVersion"` // 留空时为 1
+}
+
+// safeAirdropParams activate_airdrop 参数
+type safeAirdropParams struct {
+	AirdropId string `json:"airdropId"`
+}
+
+// safeSetPoolActiveParams set_pool_active 参数
+type safeSetPoolActiveParams struct {
+	PoolId   int64 `json:"poolId"`
+	IsActive *bool `json:"isActive"`
+}
+
+// safeCampaign 提案涉及的空投活动
+type safeCampaign struct {
+	ChainId               int64
+	MerkleAirdropContract string
+	MerkleRoot            string
+	IsActive              bool
+}
+
+// safeBuilderBatch Safe Transaction Builder 导入文件
+type safeBuilderBatch struct {
+	Version      string          `json:"version"`
+	ChainId      string          `json:"chainId"`
+	CreatedAt    int64           `json:"createdAt"`
+	Meta         safeBuilderMeta `json:"meta"`
+	Transactions []safeBuilderTx `json:"transactions"`
+}
+
+type safeBuilderMeta struct {
+	Name                    string `json:"name"`
+	Description             string `json:"description"`
+	TxBuilderVersion        string `json:"txBuilderVersion"`
+	CreatedFromSafeAddress  string `json:"createdFromSafeAddress"`
+	CreatedFromOwnerAddress string `json:"createdFromOwnerAddress"`
+}
+
+type safeBuilderTx struct {
+	To                   string      `json:"to"`
+	Value                string      `json:"value"`
+	Data                 string      `json:"data"`
+	ContractMethod       interface{} `json:"contractMethod"`
+	ContractInputsValues interface{} `json:"contractInputsValues"`
+}
+
+// SafeProposalService 为 owner 为 Safe 的合约生成待签名交易：Transaction Builder JSON 与 EIP-712 SafeTx 哈希，
+// 跟踪 Safe 执行事件直到操作结果被索引
+type SafeProposalService struct {
+	stakingAdmin *StakingAdminService
+	airdropAdmin *AirdropAdminService
+}
+
+func NewSafeProposalService() *SafeProposalService {
+	return &SafeProposalService{
+		stakingAdmin: NewStakingAdminService(),
+		airdropAdmin: NewAirdropAdminService(),
+	}
+}
+
+// safeOf 配置中该链的 Safe 地址
+func safeOf(chainId int64) (common.Address, error) {
+	for _, a := range config.Conf.Safe.Accounts {
+		if a.ChainId == chainId && common.IsHexAddress(a.Address) {
+			if ctx.Ctx.ChainMap[int(chainId)] == nil {
+				return common.Address{}, fmt.Errorf("%w: 无法获取链ID为 %d 的以太坊客户端", ErrSafeInvalidParam, chainId)
+			}
+			return common.HexToAddress(a.Address), nil
+		}
+	}
+	return common.Address{}, fmt.Errorf("%w: 链 %d 未配置 Safe 地址", ErrSafeInvalidParam, chainId)
+}
+
+func safeContract(client *ethclient.Client, safe common.Address) (*bind.BoundContract, error) {
+	parsed, err := gethabi.JSON(strings.NewReader(safeABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(safe, parsed, client, nil, nil), nil
+}
+
+// safeNonceAt 读取 Safe 在指定区块的 nonce，blockNumber 为空时取最新
+func safeNonceAt(bound *bind.BoundContract, blockNumber *big.Int) (int64, error) {
+	var out []interface{}
+	if err := bound.Call(&bind.CallOpts{Context: context.Background(), BlockNumber: blockNumber}, &out, "nonce"); err != nil {
+		return 0, err
+	}
+	nonce, _ := out[0].(*big.Int)
+	if nonce == nil {
+		return 0, fmt.Errorf("Safe nonce 返回值无效")
+	}
+	return nonce.Int64(), nil
+}
+
+// checkOwner 调用方需为该 Safe 的 owner
+func (s *SafeProposalService) checkOwner(bound *bind.BoundContract, safe common.Address, operator string) error {
+	if !config.Conf.Safe.Enabled {
+		return fmt.Errorf("%w: Safe 提案未启用", ErrSafeForbidden)
+	}
+	if !common.IsHexAddress(operator) {
+		return fmt.Errorf("%w: 无效的登录地址", ErrSafeForbidden)
+	}
+	var out []interface{}
+	if err := bound.Call(&bind.CallOpts{Context: context.Background()}, &out, "isOwner", common.HexToAddress(operator)); err != nil {
+		return fmt.Errorf("查询 Safe owner 失败: %v", err)
+	}
+	if owner, _ := out[0].(bool); !owner {
+		return fmt.Errorf("%w: %s 不是 Safe %s 的 owner", ErrSafeForbidden, operator, safe.Hex())
+	}
+	return nil
+}
+
+// CheckOperator 查询提案前校验调用方为该链 Safe 的 owner
+func (s *SafeProposalService) CheckOperator(operator string, chainId int64) error {
+	if !config.Conf.Safe.Enabled {
+		return fmt.Errorf("%w: Safe 提案未启用", ErrSafeForbidden)
+	}
+	safe, err := safeOf(chainId)
+	if err != nil {
+		return err
+	}
+	bound, err := safeContract(ctx.GetEvmClient(int(chainId)), safe)
+	if err != nil {
+		return err
+	}
+	return s.checkOwner(bound, safe, operator)
+}
+
+// Propose 编码并模拟每个调用（以 Safe 为调用方 eth_call），生成 SafeTx 与 Transaction Builder 文件。
+// Safe nonce 取链上 nonce 与未执行提案之后的较大值，多个待执行提案按 nonce 顺序排队
+func (s *SafeProposalService) Propose(operator string, in SafeProposalInput) (*model.SafeProposal, error) {
+	if !config.Conf.Safe.Enabled {
+		return nil, fmt.Errorf("%w: Safe 提案未启用", ErrSafeForbidden)
+	}
+	in.Title = strings.TrimSpace(in.Title)
+	if in.Title == "" || len([]rune(in.Title)) > safeProposalTitleMax {
+		return nil, fmt.Errorf("%w: 标题不能为空且不超过 %d 个字符", ErrSafeInvalidParam, safeProposalTitleMax)
+	}
+	if len(in.Actions) == 0 || len(in.Actions) > safeProposalMaxActions {
+		return nil, fmt.Errorf("%w: 操作数量需在 1 到 %d 之间", ErrSafeInvalidParam, safeProposalMaxActions)
+	}
+	safe, err := safeOf(in.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	client := ctx.GetEvmClient(int(in.ChainId))
+	bound, err := safeContract(client, safe)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOwner(bound, safe, operator); err != nil {
+		return nil, err
+	}
+
+	bg := context.Background()
+	actions := make([]model.SafeProposalAction, 0, len(in.Actions))
+	for i, item := range in.Actions {
+		action, err := s.buildAction(in.ChainId, item)
+		if err != nil {
+			return nil, err
+		}
+		to := common.HexToAddress(action.To)
+		if _, err := client.CallContract(bg, ethereum.CallMsg{From: safe, To: &to, Data: hexutil.MustDecode(action.Data)}, nil); err != nil {
+			return nil, fmt.Errorf("%w: 第 %d 个操作 %s 以 Safe 身份模拟失败: %v", ErrSafeInvalidParam, i+1, action.Action, err)
+		}
+		actions = append(actions, action)
+	}
+	to, data, operation, err := encodeSafeTxCall(actions)
+	if err != nil {
+		return nil, err
+	}
+	head, err := client.BlockNumber(bg)
+	if err != nil {
+		return nil, fmt.Errorf("获取区块高度失败: %v", err)
+	}
+	chainNonce, err := safeNonceAt(bound, nil)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Safe nonce 失败: %v", err)
+	}
+
+	actionsJson, err := json.Marshal(actions)
+	if err != nil {
+		return nil, err
+	}
+	builderJson, err := json.Marshal(buildSafeBuilderBatch(in, safe, operator, actions))
+	if err != nil {
+		return nil, err
+	}
+	proposal := &model.SafeProposal{
+		ChainId:      in.ChainId,
+		SafeAddress:  strings.ToLower(safe.Hex()),
+		Title:        in.Title,
+		Description:  strings.TrimSpace(in.Description),
+		Operator:     strings.ToLower(operator),
+		Actions:      string(actionsJson),
+		ToAddress:    strings.ToLower(to.Hex()),
+		Value:        "0",
+		Data:         hexutil.Encode(data),
+		Operation:    int(operation),
+		BuilderJson:  string(builderJson),
+		CreatedBlock: int64(head),
+		ScannedBlock: int64(head) - 1,
+		Status:       model.SafeProposalProposed,
+	}
+	// 同一 Safe 的提案串行分配 nonce
+	err = ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("safe:%d:%s", in.ChainId, proposal.SafeAddress)).Error; err != nil {
+			return err
+		}
+		var queued *int64
+		if err := tx.Model(&model.SafeProposal{}).
+			Where("chain_id = ? AND safe_address = ? AND status = ? AND safe_nonce >= ?", in.ChainId, proposal.SafeAddress, model.SafeProposalProposed, chainNonce).
+			Select("MAX(safe_nonce)").Scan(&queued).Error; err != nil {
+			return err
+		}
+		proposal.SafeNonce = chainNonce
+		if queued != nil {
+			proposal.SafeNonce = *queued + 1
+		}
+		proposal.SafeTxHash = safeTxHash(in.ChainId, safe, to, big.NewInt(0), data, operation, big.NewInt(proposal.SafeNonce)).Hex()
+		return tx.Create(proposal).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存 Safe 提案失败: %v", err)
+	}
+	log.Logger.Info("Safe 提案已生成", zap.Int64("id", proposal.Id), zap.String("safe", proposal.SafeAddress),
+		zap.Int64("safe_nonce", proposal.SafeNonce), zap.String("safe_tx_hash", proposal.SafeTxHash), zap.Int("actions", len(actions)))
+	return proposal, nil
+}
+
+// buildAction 校验参数并编码单个调用，质押操作复用质押管理接口的校验
+func (s *SafeProposalService) buildAction(chainId int64, in SafeProposalActionInput) (model.SafeProposalAction, error) {
+	action := strings.ToLower(strings.TrimSpace(in.Action))
+	params := in.Params
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	decode := func(v interface{}) error {
+		if err := json.Unmarshal(params, v); err != nil {
+			return fmt.Errorf("%w: %s 参数解析失败: %v", ErrSafeInvalidParam, action, err)
+		}
+		return nil
+	}
+
+	switch action {
+	case model.SafeActionCreateAirdrop:
+		var p safeCreateAirdropParams
+		if err := decode(&p); err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		contract, data, err := encodeSafeCreateAirdrop(chainId, &p)
+		if err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		return newSafeAction(action, contract, data, p), nil
+	case model.SafeActionUpdateMerkleRoot:
+		var p safeUpdateMerkleRootParams
+		if err := decode(&p); err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		if len(p.NewRoot) != 66 || !strings.HasPrefix(strings.ToLower(p.NewRoot), "0x") {
+			return model.SafeProposalAction{}, fmt.Errorf("%w: newRoot 需为 0x 开头的 bytes32", ErrSafeInvalidParam)
+		}
+		aid, campaign, err := s.campaignOf(chainId, p.AirdropId)
+		if err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		root := common.HexToHash(p.NewRoot)
+		data, err := s.airdropAdmin.EncodeUpdateMerkleRootData(aid, root, p.NewVersion)
+		if err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		p.AirdropId = aid.String()
+		p.NewRoot = strings.ToLower(root.Hex())
+		return newSafeAction(action, campaign.MerkleAirdropContract, data, p), nil
+	case model.SafeActionActivateAirdrop:
+		var p safeAirdropParams
+		if err := decode(&p); err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		aid, campaign, err := s.campaignOf(chainId, p.AirdropId)
+		if err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		if campaign.IsActive {
+			return model.SafeProposalAction{}, fmt.Errorf("%w: 空投 %s 已激活", ErrSafeInvalidParam, aid.String())
+		}
+		data, err := s.airdropAdmin.EncodeActivateAirdropData(aid)
+		if err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		p.AirdropId = aid.String()
+		return newSafeAction(action, campaign.MerkleAirdropContract, data, p), nil
+	}
+
+	var call stakingAdminCall
+	var err error
+	switch action {
+	case model.StakingAdminAddPool:
+		var p StakingAdminAddPoolInput
+		if err := decode(&p); err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		p.ChainId, p.DryRun = chainId, false
+		call, err = s.stakingAdmin.addPoolCall(p)
+	case model.StakingAdminSetPoolActive:
+		var p safeSetPoolActiveParams
+		if err := decode(&p); err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		call, err = s.stakingAdmin.setPoolActiveCall(p.PoolId, StakingAdminPoolActiveInput{ChainId: chainId, IsActive: p.IsActive})
+	case model.StakingAdminPause, model.StakingAdminUnpause:
+		method := map[string]string{model.StakingAdminPause: "pause", model.StakingAdminUnpause: "unpause"}[action]
+		call = s.stakingAdmin.pauseCall(StakingAdminPauseInput{ChainId: chainId}, action, method)
+	case model.StakingAdminGrantRole, model.StakingAdminRevokeRole:
+		var p StakingAdminRoleInput
+		if err := decode(&p); err != nil {
+			return model.SafeProposalAction{}, err
+		}
+		p.ChainId, p.DryRun = chainId, false
+		method := map[string]string{model.StakingAdminGrantRole: "grantRole", model.StakingAdminRevokeRole: "revokeRole"}[action]
+		call, err = s.stakingAdmin.roleCall(p, action, method)
+	default:
+		return model.SafeProposalAction{}, fmt.Errorf("%w: 不支持的操作 %s", ErrSafeInvalidParam, in.Action)
+	}
+	if err != nil {
+		return model.SafeProposalAction{}, err
+	}
+	stakeContract, err := stakingContractOf(chainId)
+	if err != nil {
+		return model.SafeProposalAction{}, err
+	}
+	data, err := call.encode()
+	if err != nil {
+		return model.SafeProposalAction{}, err
+	}
+	return newSafeAction(action, stakeContract.Hex(), data, call.params), nil
+}
+
+func newSafeAction(action, to string, data []byte, params interface{}) model.SafeProposalAction {
+	return model.SafeProposalAction{
+		Action: action,
+		To:     strings.ToLower(to),
+		Value:  "0",
+		Data:   hexutil.Encode(data),
+		Params: params,
+	}
+}
+
+// encodeSafeCreateAirdrop 校验并规范化 create_airdrop 参数，返回目标合约与调用数据
+func encodeSafeCreateAirdrop(chainId int64, p *safeCreateAirdropParams) (string, []byte, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return "", nil, fmt.Errorf("%w: name 不能为空", ErrSafeInvalidParam)
+	}
+	if len(p.MerkleRoot) != 66 || !strings.HasPrefix(strings.ToLower(p.MerkleRoot), "0x") || common.HexToHash(p.MerkleRoot) == (common.Hash{}) {
+		return "", nil, fmt.Errorf("%w: merkleRoot 需为 0x 开头的非零 bytes32", ErrSafeInvalidParam)
+	}
+	totalReward, ok := new(big.Int).SetString(strings.TrimSpace(p.TotalReward), 10)
+	if !ok || totalReward.Sign() <= 0 {
+		return "", nil, fmt.Errorf("%w: totalReward 需为正整数（最小单位）", ErrSafeInvalidParam)
+	}
+	if p.StartTime <= 0 || p.EndTime <= p.StartTime || p.EndTime <= time.Now().Unix() {
+		return "", nil, fmt.Errorf("%w: 需满足 0 < startTime < endTime 且 endTime 晚于当前时间", ErrSafeInvalidParam)
+	}
+	treeVersion := big.NewInt(1)
+	if strings.TrimSpace(p.TreeVersion) != "" {
+		v, ok := new(big.Int).SetString(strings.TrimSpace(p.TreeVersion), 10)
+		if !ok || v.Sign() <= 0 {
+			return "", nil, fmt.Errorf("%w: 无效的 treeVersion: %s", ErrSafeInvalidParam, p.TreeVersion)
+		}
+		treeVersion = v
+	}
+	contract := strings.TrimSpace(p.ContractAddress)
+	if contract == "" {
+		if err := ctx.Ctx.DB.Raw(`SELECT COALESCE(merkle_airdrop_contract, '') FROM airdrop_campaigns
+            WHERE chain_id = ? AND merkle_airdrop_contract IS NOT NULL ORDER BY updated_at DESC LIMIT 1`, chainId).Scan(&contract).Error; err != nil {
+			return "", nil, err
+		}
+		if contract == "" {
+			return "", nil, fmt.Errorf("%w: 链 %d 没有已索引的空投合约，请指定 contractAddress", ErrSafeInvalidParam, chainId)
+		}
+	}
+	if !common.IsHexAddress(contract) {
+		return "", nil, fmt.Errorf("%w: 无效的 contractAddress: %s", ErrSafeInvalidParam, contract)
+	}
+	root := common.HexToHash(p.MerkleRoot)
+	data, err := encodeMerkleAirdropCall("createAirdrop", p.Name, root, totalReward, big.NewInt(p.StartTime), big.NewInt(p.EndTime), treeVersion)
+	if err != nil {
+		return "", nil, err
+	}
+	p.ContractAddress = strings.ToLower(common.HexToAddress(contract).Hex())
+	p.MerkleRoot = strings.ToLower(root.Hex())
+	p.TotalReward = totalReward.String()
+	p.TreeVersion = treeVersion.String()
+	return p.ContractAddress, data, nil
+}
+
+// campaignOf 已索引的空投活动，合约地址与所在链以 AirdropCreated 事件为准
+func (s *SafeProposalService) campaignOf(chainId int64, airdropId string) (*big.Int, *safeCampaign, error) {
+	aid, ok := new(big.Int).SetString(strings.TrimSpace(airdropId), 10)
+	if !ok || aid.Sign() < 0 {
+		return nil, nil, fmt.Errorf("%w: 无效的 airdropId: %s", ErrSafeInvalidParam, airdropId)
+	}
+	var campaigns []safeCampaign
+	if err := ctx.Ctx.DB.Raw("SELECT chain_id, merkle_airdrop_contract, merkle_root, is_active FROM airdrop_campaigns WHERE airdrop_id = ?", aid.String()).
+		Scan(&campaigns).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(campaigns) == 0 {
+		return nil, nil, fmt.Errorf("%w: 空投 %s 不存在", ErrSafeInvalidParam, aid.String())
+	}
+	campaign := campaigns[0]
+	if campaign.ChainId != chainId || !common.IsHexAddress(campaign.MerkleAirdropContract) {
+		return nil, nil, fmt.Errorf("%w: 空投 %s 不在链 %d 上", ErrSafeInvalidParam, aid.String(), chainId)
+	}
+	return aid, &campaign, nil
+}
+
+// encodeSafeTxCall 单个调用直接 CALL 目标合约；多个调用打包为 MultiSendCallOnly.multiSend 并以 DELEGATECALL 执行
+func encodeSafeTxCall(actions []model.SafeProposalAction) (common.Address, []byte, uint8, error) {
+	if len(actions) == 1 {
+		return common.HexToAddress(actions[0].To), hexutil.MustDecode(actions[0].Data), 0, nil
+	}
+	// 每笔：operation(1) ‖ to(20) ‖ value(32) ‖ dataLength(32) ‖ data
+	var packed []byte
+	for _, a := range actions {
+		data := hexutil.MustDecode(a.Data)
+		value, _ := new(big.Int).SetString(a.Value, 10)
+		if value == nil {
+			value = big.NewInt(0)
+		}
+		packed = append(packed, 0)
+		packed = append(packed, common.HexToAddress(a.To).Bytes()...)
+		packed = append(packed, common.LeftPadBytes(value.Bytes(), 32)...)
+		packed = append(packed, common.LeftPadBytes(big.NewInt(int64(len(data))).Bytes(), 32)...)
+		packed = append(packed, data...)
+	}
+	parsed, err := gethabi.JSON(strings.NewReader(multiSendABI))
+	if err != nil {
+		return common.Address{}, nil, 0, err
+	}
+	data, err := parsed.Pack("multiSend", packed)
+	if err != nil {
+		return common.Address{}, nil, 0, err
+	}
+	multiSend := strings.TrimSpace(config.Conf.Safe.MultiSendCallOnly)
+	if !common.IsHexAddress(multiSend) {
+		multiSend = defaultMultiSendCallOnly
+	}
+	return common.HexToAddress(multiSend), data, 1, nil
+}
+
+// safeTxHash EIP-712 SafeTx 哈希（Safe v1.3.0 及以上的 domain 含 chainId），不使用 Safe 内退款，gas 相关字段均为 0
+func safeTxHash(chainId int64, safe, to common.Address, value *big.Int, data []byte, operation uint8, nonce *big.Int) common.Hash {
+	word := func(b []byte) []byte { return common.LeftPadBytes(b, 32) }
+	zero := make([]byte, 32)
+	domainSeparator := crypto.Keccak256(safeDomainTypeHash.Bytes(), word(big.NewInt(chainId).Bytes()), word(safe.Bytes()))
+	structHash := crypto.Keccak256(
+		safeTxTypeHash.Bytes(),
+		word(to.Bytes()),
+		word(value.Bytes()),
+		crypto.Keccak256(data),
+		word([]byte{operation}),
+		zero, // safeTxGas
+		zero, // baseGas
+		zero, // gasPrice
+		zero, // gasToken
+		zero, // refundReceiver
+		word(nonce.Bytes()),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+// buildSafeBuilderBatch Transaction Builder 导入文件，每个调用单独列出，由 Safe 界面自行打包。
+// 界面使用的 MultiSend 地址与 nonce 可能与提案不同，此时 SafeTx 哈希不同，执行后按实际调用匹配（见 checkExecution）
+func buildSafeBuilderBatch(in SafeProposalInput, safe common.Address, operator string, actions []model.SafeProposalAction) safeBuilderBatch {
+	txs := make([]safeBuilderTx, 0, len(actions))
+	for _, a := range actions {
+		txs = append(txs, safeBuilderTx{To: common.HexToAddress(a.To).Hex(), Value: a.Value, Data: a.Data})
+	}
+	return safeBuilderBatch{
+		Version:   "1.0",
+		ChainId:   strconv.FormatInt(in.ChainId, 10),
+		CreatedAt: time.Now().UnixMilli(),
+		Meta: safeBuilderMeta{
+			Name:                    in.Title,
+			Description:             strings.TrimSpace(in.Description),
+			TxBuilderVersion:        safeTxBuilderVersion,
+			CreatedFromSafeAddress:  safe.Hex(),
+			CreatedFromOwnerAddress: common.HexToAddress(operator).Hex(),
+		},
+		Transactions: txs,
+	}
+}
+
+// List 提案列表，按 ID 倒序
+func (s *SafeProposalService) List(operator string, chainId int64, status string, pagination dto.Pagination) ([]model.SafeProposal, int64, error) {
+	if err := s.CheckOperator(operator, chainId); err != nil {
+		return nil, 0, err
+	}
+	query := ctx.Ctx.DB.Model(&model.SafeProposal{}).Where("chain_id = ?", chainId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := make([]model.SafeProposal, 0)
+	if err := query.Order("id DESC").Offset(pagination.Offset).Limit(pagination.PageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Get 提案详情
+func (s *SafeProposalService) Get(operator string, id int64) (*model.SafeProposal, error) {
+	var proposal model.SafeProposal
+	err := ctx.Ctx.DB.Where("id = ?", id).First(&proposal).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSafeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckOperator(operator, proposal.ChainId); err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// ReconcileProposals 跟踪待执行与已执行的提案：查找 Safe 执行事件，执行后按索引数据核对结果，返回状态变化的数量
+func (s *SafeProposalService) ReconcileProposals() (int, error) {
+	var proposals []model.SafeProposal
+	if err := ctx.Ctx.DB.Where("status IN ?", []string{model.SafeProposalProposed, model.SafeProposalExecuted}).
+		Order("id ASC").Limit(100).Find(&proposals).Error; err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range proposals {
+		p := &proposals[i]
+		if ctx.Ctx.ChainMap[int(p.ChainId)] == nil {
+			continue
+		}
+		var updates map[string]interface{}
+		var err error
+		if p.Status == model.SafeProposalProposed {
+			updates, err = s.checkExecution(p)
+		} else {
+			updates, err = s.checkIndexed(p)
+		}
+		if err != nil {
+			log.Logger.Warn("Safe 提案对账失败", zap.Int64("id", p.Id), zap.Error(err))
+			continue
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := ctx.Ctx.DB.Model(&model.SafeProposal{}).Where("id = ? AND status = ?", p.Id, p.Status).Updates(updates).Error; err != nil {
+			log.Logger.Error("更新 Safe 提案失败", zap.Int64("id", p.Id), zap.Error(err))
+			continue
+		}
+		if status, ok := updates["status"].(string); ok && status != p.Status {
+			log.Logger.Info("Safe 提案状态变化", zap.Int64("id", p.Id), zap.String("from", p.Status), zap.String("to", status))
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// checkExecution Safe nonce 越过提案 nonce 后，从创建区块起查找该提案的执行事件：SafeTx 哈希相同，
+// 或执行交易中的实际调用与提案逐一相同（经 Transaction Builder 导入时由 Safe 界面重新打包并分配 nonce，哈希可能不同）；
+// 查到链头仍未找到说明该 nonce 被其他交易占用
+func (s *SafeProposalService) checkExecution(p *model.SafeProposal) (map[string]interface{}, error) {
+	var actions []model.SafeProposalAction
+	if err := json.Unmarshal([]byte(p.Actions), &actions); err != nil {
+		return nil, err
+	}
+	client := ctx.GetEvmClient(int(p.ChainId))
+	safe := common.HexToAddress(p.SafeAddress)
+	bound, err := safeContract(client, safe)
+	if err != nil {
+		return nil, err
+	}
+	bg := context.Background()
+	head, err := client.BlockNumber(bg)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := safeNonceAt(bound, new(big.Int).SetUint64(head))
+	if err != nil {
+		return nil, err
+	}
+	if nonce <= p.SafeNonce {
+		return nil, nil
+	}
+
+	hash := common.HexToHash(p.SafeTxHash)
+	from := p.ScannedBlock + 1
+	if from < p.CreatedBlock {
+		from = p.CreatedBlock
+	}
+	for round := 0; round < safeExecScanRounds && from <= int64(head); round++ {
+		to := from + safeExecScanRange - 1
+		if to > int64(head) {
+			to = int64(head)
+		}
+		logs, err := client.FilterLogs(bg, ethereum.FilterQuery{
+			FromBlock: big.NewInt(from),
+			ToBlock:   big.NewInt(to),
+			Addresses: []common.Address{safe},
+			Topics:    [][]common.Hash{{safeExecutionSuccessTopic, safeExecutionFailureTopic}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vLog := range logs {
+			if len(vLog.Data) < 32 {
+				continue
+			}
+			executed := common.BytesToHash(vLog.Data[:32])
+			if executed != hash {
+				matched, err := executedSameCalls(bg, client, safe, vLog.TxHash, actions)
+				if err != nil {
+					return nil, err
+				}
+				if !matched {
+					continue
+				}
+			}
+			now := time.Now()
+			updates := map[string]interface{}{
+				"exec_tx_hash":      strings.ToLower(vLog.TxHash.Hex()),
+				"exec_safe_tx_hash": strings.ToLower(executed.Hex()),
+				"exec_block":        int64(vLog.BlockNumber),
+				"executed_at":       now,
+				"scanned_block":     int64(vLog.BlockNumber),
+				"status":            model.SafeProposalExecuted,
+			}
+			if vLog.Topics[0] == safeExecutionFailureTopic {
+				updates["status"] = model.SafeProposalFailed
+			}
+			return updates, nil
+		}
+		from = to + 1
+	}
+	if from > int64(head) {
+		return map[string]interface{}{"scanned_block": int64(head), "status": model.SafeProposalReplaced}, nil
+	}
+	return map[string]interface{}{"scanned_block": from - 1}, nil
+}
+
+// executedSameCalls 执行交易直接调用该 Safe 的 execTransaction，且实际执行的调用与提案相同；
+// 经其他合约转发的执行无法从交易调用数据解析，只按 SafeTx 哈希匹配
+func executedSameCalls(c context.Context, client *ethclient.Client, safe common.Address, txHash common.Hash, actions []model.SafeProposalAction) (bool, error) {
+	tx, _, err := client.TransactionByHash(c, txHash)
+	if err != nil {
+		return false, fmt.Errorf("查询执行交易 %s 失败: %v", txHash.Hex(), err)
+	}
+	if tx.To() == nil || *tx.To() != safe {
+		return false, nil
+	}
+	calls, err := decodeSafeExecCalls(tx.Data())
+	if err != nil {
+		return false, nil
+	}
+	return safeCallsMatch(actions, calls), nil
+}
+
+// safeCall SafeTx 实际执行的单个调用
+type safeCall struct {
+	to    common.Address
+	value *big.Int
+	data  []byte
+}
+
+// decodeSafeExecCalls 解析 execTransaction 调用数据：CALL 为单个调用，DELEGATECALL 仅识别 multiSend 批量并展开其中每笔 CALL
+func decodeSafeExecCalls(input []byte) ([]safeCall, error) {
+	parsed, err := gethabi.JSON(strings.NewReader(safeExecTransactionABI))
+	if err != nil {
+		return nil, err
+	}
+	method := parsed.Methods["execTransaction"]
+	if len(input) < 4 || !bytes.Equal(input[:4], method.ID) {
+		return nil, fmt.Errorf("不是 execTransaction 调用")
+	}
+	values, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	to, _ := values[0].(common.Address)
+	value, _ := values[1].(*big.Int)
+	data, _ := values[2].([]byte)
+	operation, _ := values[3].(uint8)
+	if operation == 0 {
+		return []safeCall{{to: to, value: value, data: data}}, nil
+	}
+
+	multi, err := gethabi.JSON(strings.NewReader(multiSendABI))
+	if err != nil {
+		return nil, err
+	}
+	multiSend := multi.Methods["multiSend"]
+	if len(data) < 4 || !bytes.Equal(data[:4], multiSend.ID) {
+		return nil, fmt.Errorf("DELEGATECALL 目标不是 multiSend")
+	}
+	args, err := multiSend.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	packed, _ := args[0].([]byte)
+	// 每笔：operation(1) ‖ to(20) ‖ value(32) ‖ dataLength(32) ‖ data
+	var calls []safeCall
+	for i := 0; i < len(packed); {
+		if len(packed)-i < 85 {
+			return nil, fmt.Errorf("multiSend 数据长度无效")
+		}
+		if packed[i] != 0 {
+			return nil, fmt.Errorf("multiSend 中包含 DELEGATECALL")
+		}
+		size := new(big.Int).SetBytes(packed[i+53 : i+85])
+		if !size.IsInt64() || size.Int64() > int64(len(packed)-i-85) {
+			return nil, fmt.Errorf("multiSend 数据长度无效")
+		}
+		end := i + 85 + int(size.Int64())
+		calls = append(calls, safeCall{
+			to:    common.BytesToAddress(packed[i+1 : i+21]),
+			value: new(big.Int).SetBytes(packed[i+21 : i+53]),
+			data:  packed[i+85 : end],
+		})
+		i = end
+	}
+	return calls, nil
+}
+
+// safeCallsMatch 实际调用与提案的调用数量、顺序、目标、金额与调用数据均相同
+func safeCallsMatch(actions []model.SafeProposalAction, calls []safeCall) bool {
+	if len(actions) != len(calls) {
+		return false
+	}
+	for i, a := range actions {
+		value, _ := new(big.Int).SetString(a.Value, 10)
+		if value == nil {
+			value = big.NewInt(0)
+		}
+		data, err := hexutil.Decode(a.Data)
+		if err != nil || calls[i].to != common.HexToAddress(a.To) || calls[i].value.Cmp(value) != 0 || !bytes.Equal(calls[i].data, data) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkIndexed 目标合约的索引进度越过执行区块，且每个操作的结果都能在索引数据或链上状态中确认
+func (s *SafeProposalService) checkIndexed(p *model.SafeProposal) (map[string]interface{}, error) {
+	var actions []model.SafeProposalAction
+	if err := json.Unmarshal([]byte(p.Actions), &actions); err != nil {
+		return nil, err
+	}
+	results := make([]json.RawMessage, 0, len(actions))
+	stakePoolChanged := false
+	for _, a := range actions {
+		synced, err := indexedThrough(p.ChainId, a.To, p.ExecBlock)
+		if err != nil || !synced {
+			return nil, err
+		}
+		params, err := json.Marshal(a.Params)
+		if err != nil {
+			return nil, err
+		}
+		var res string
+		var ok bool
+		switch a.Action {
+		case model.SafeActionCreateAirdrop:
+			res, ok, err = reconcileSafeCreateAirdrop(p.ChainId, p.ExecTxHash, params)
+		case model.SafeActionUpdateMerkleRoot, model.SafeActionActivateAirdrop:
+			res, ok, err = reconcileSafeAirdropAction(a.Action, params)
+		default:
+			// 质押操作复用质押管理对账：按执行交易哈希匹配已索引的事件
+			res, ok, err = s.stakingAdmin.reconcile(&model.StakingAdminAction{
+				ChainId:         p.ChainId,
+				ContractAddress: a.To,
+				Action:          a.Action,
+				Params:          string(params),
+				TxHash:          p.ExecTxHash,
+			})
+			if a.Action == model.StakingAdminAddPool || a.Action == model.StakingAdminSetPoolActive {
+				stakePoolChanged = true
+			}
+		}
+		if err != nil || !ok {
+			return nil, err
+		}
+		results = append(results, json.RawMessage(res))
+	}
+	result, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+	if stakePoolChanged {
+		stakePoolCacheMu.Lock()
+		delete(stakePoolCache, p.ChainId)
+		stakePoolCacheMu.Unlock()
+	}
+	return map[string]interface{}{
+		"status":     model.SafeProposalIndexed,
+		"result":     string(result),
+		"indexed_at": time.Now(),
+	}, nil
+}
+
+// indexedThrough 事件监听对该合约的处理进度是否已越过指定区块；未在 chain 表登记的合约只按结果核对
+func indexedThrough(chainId int64, address string, blockNumber int64) (bool, error) {
+	var chains []model.Chain
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND LOWER(address) = ?", chainId, strings.ToLower(address)).Find(&chains).Error; err != nil {
+		return false, err
+	}
+	for _, chain := range chains {
+		if int64(chain.LastBlockNum) < blockNumber {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// reconcileSafeCreateAirdrop 执行交易中的 AirdropCreated 入库后，以名称与默克尔根确认并返回合约分配的空投ID
+func reconcileSafeCreateAirdrop(chainId int64, execTxHash string, params []byte) (string, bool, error) {
+	var p safeCreateAirdropParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", false, err
+	}
+	var airdropIds []string
+	if err := ctx.Ctx.DB.Raw(`SELECT airdrop_id::text FROM airdrop_campaigns
+        WHERE chain_id = ? AND created_tx_hash = ? AND name = ? AND LOWER(merkle_root) = ? ORDER BY airdrop_id`,
+		chainId, strings.ToLower(execTxHash), p.Name, strings.ToLower(p.MerkleRoot)).Scan(&airdropIds).Error; err != nil {
+		return "", false, err
+	}
+	if len(airdropIds) == 0 {
+		return "", false, nil
+	}
+	return fmt.Sprintf(`{"airdropId":"%s","merkleRoot":"%s"}`, airdropIds[0], strings.ToLower(p.MerkleRoot)), true, nil
+}
+
+// reconcileSafeAirdropAction 空投操作的事件入库后体现在 airdrop_campaigns 的 merkle_root 与 is_active 上
+func reconcileSafeAirdropAction(action string, params []byte) (string, bool, error) {
+	var p safeUpdateMerkleRootParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", false, err
+	}
+	var campaigns []safeCampaign
+	if err := ctx.Ctx.DB.Raw("SELECT chain_id, merkle_airdrop_contract, merkle_root, is_active FROM airdrop_campaigns WHERE airdrop_id = ?", p.AirdropId).
+		Scan(&campaigns).Error; err != nil {
+		return "", false, err
+	}
+	if len(campaigns) == 0 {
+		return "", false, nil
+	}
+	if action == model.SafeActionActivateAirdrop {
+		if !campaigns[0].IsActive {
+			return "", false, nil
+		}
+		return fmt.Sprintf(`{"airdropId":"%s","isActive":true}`, p.AirdropId), true, nil
+	}
+	if !strings.EqualFold(campaigns[0].MerkleRoot, p.NewRoot) {
+		return "", false, nil
+	}
+	return fmt.Sprintf(`{"airdropId":"%s","merkleRoot":"%s"}`, p.AirdropId, strings.ToLower(p.NewRoot)), true, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/app/model"
+)
+
+// execTransactionInput 按 owner 在 Safe 界面执行时的调用数据构造 execTransaction 输入
+func execTransactionInput(t *testing.T, to common.Address, value *big.Int, data []byte, operation uint8) []byte {
+	t.Helper()
+	parsed, err := gethabi.JSON(strings.NewReader(safeExecTransactionABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero := big.NewInt(0)
+	input, err := parsed.Pack("execTransaction", to, value, data, operation, zero, zero, zero, common.Address{}, common.Address{}, []byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func testSafeActions() []model.SafeProposalAction {
+	return []model.SafeProposalAction{
+		{Action: model.SafeActionUpdateMerkleRoot, To: "0x00000000000000000000000000000000000000a1", Value: "0", Data: "0x12345678aa"},
+		{Action: model.SafeActionActivateAirdrop, To: "0x00000000000000000000000000000000000000A2", Value: "", Data: "0x87654321"},
+	}
+}
+
+func TestDecodeSafeExecCallsMultiSend(t *testing.T) {
+	actions := testSafeActions()
+	_, data, operation, err := encodeSafeTxCall(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Transaction Builder 使用的 MultiSend 地址可能不同，只比较展开后的调用
+	calls, err := decodeSafeExecCalls(execTransactionInput(t, common.HexToAddress("0x00000000000000000000000000000000000000b1"), big.NewInt(0), data, operation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if !safeCallsMatch(actions, calls) {
+		t.Fatalf("展开的调用与提案不符: %+v", calls)
+	}
+	// 顺序不同视为不同的提案
+	if safeCallsMatch([]model.SafeProposalAction{actions[1], actions[0]}, calls) {
+		t.Fatal("调用顺序不同仍匹配")
+	}
+	if safeCallsMatch(actions[:1], calls) {
+		t.Fatal("调用数量不同仍匹配")
+	}
+}
+
+func TestDecodeSafeExecCallsSingle(t *testing.T) {
+	actions := testSafeActions()[:1]
+	to, data, operation, err := encodeSafeTxCall(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls, err := decodeSafeExecCalls(execTransactionInput(t, to, big.NewInt(0), data, operation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !safeCallsMatch(actions, calls) {
+		t.Fatalf("单个调用不匹配: %+v", calls)
+	}
+
+	other := []model.SafeProposalAction{actions[0]}
+	other[0].Data = "0x12345678ab"
+	if safeCallsMatch(other, calls) {
+		t.Fatal("调用数据不同仍匹配")
+	}
+	other[0] = actions[0]
+	other[0].Value = "1"
+	if safeCallsMatch(other, calls) {
+		t.Fatal("金额不同仍匹配")
+	}
+}
+
+func TestDecodeSafeExecCallsRejects(t *testing.T) {
+	if _, err := decodeSafeExecCalls([]byte{0x01, 0x02}); err == nil {
+		t.Fatal("非 execTransaction 调用应报错")
+	}
+	// DELEGATECALL 到 multiSend 以外的合约无法确定实际调用
+	input := execTransactionInput(t, common.HexToAddress("0x00000000000000000000000000000000000000b1"), big.NewInt(0), []byte{0xde, 0xad, 0xbe, 0xef}, 1)
+	if _, err := decodeSafeExecCalls(input); err == nil {
+		t.Fatal("DELEGATECALL 到其他合约应报错")
+	}
+
+	// multiSend 批量中嵌套 DELEGATECALL
+	_, data, _, err := encodeSafeTxCall(testSafeActions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 调用数据为 selector(4) ‖ offset(32) ‖ length(32) ‖ packed，首笔 operation 位于 68
+	data = append([]byte(nil), data...)
+	data[68] = 1
+	input = execTransactionInput(t, common.HexToAddress("0x00000000000000000000000000000000000000b1"), big.NewInt(0), data, 1)
+	if _, err := decodeSafeExecCalls(input); err == nil {
+		t.Fatal("批量中的 DELEGATECALL 应报错")
+	}
+}
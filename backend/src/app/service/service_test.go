@@ -0,0 +1,17 @@
+package service
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+// TestMain 以空配置与静默日志运行不依赖数据库的单元测试
+func TestMain(m *testing.M) {
+	config.Conf = &config.Config{}
+	log.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/contract"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSharePriceDeviationPct = 10
+	defaultSharePriceReceiptWait  = 180 * time.Second
+)
+
+// SharePriceKeeperService 维护 StakeV2 池子的日份额价格：按价格来源计算后调用 setDailySharePrice 上链
+type SharePriceKeeperService struct {
+	priceSvc *PriceService
+	txm      *TxManager
+}
+
+// NewSharePriceKeeperService 使用配置的签名账户创建维护服务
+func NewSharePriceKeeperService() (*SharePriceKeeperService, error) {
+	name := config.Conf.SharePriceKeeper.Signer
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("未配置 share_price_keeper.signer")
+	}
+	txm, err := NewTxManagerByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return &SharePriceKeeperService{
+		priceSvc: NewPriceService(),
+		txm:      txm,
+	}, nil
+}
+
+// sharePricePoolConfig 池子的价格来源配置，未单独配置时使用默认来源
+func sharePricePoolConfig(chainId, poolId int64) config.SharePricePoolConfig {
+	for _, p := range config.Conf.SharePriceKeeper.Pools {
+		if p.ChainId == chainId && p.PoolId == poolId {
+			if p.Source == "" {
+				p.Source = defaultSharePriceSource()
+			}
+			return p
+		}
+	}
+	return config.SharePricePoolConfig{ChainId: chainId, PoolId: poolId, Source: defaultSharePriceSource()}
+}
+
+func defaultSharePriceSource() string {
+	if s := config.Conf.SharePriceKeeper.Source; s != "" {
+		return s
+	}
+	return model.SharePriceSourceOracle
+}
+
+func sharePriceDeviationThreshold() decimal.Decimal {
+	if pct := config.Conf.SharePriceKeeper.AlertDeviationPct; pct > 0 {
+		return decimal.NewFromFloat(pct)
+	}
+	return decimal.NewFromInt(defaultSharePriceDeviationPct)
+}
+
+func sharePriceReceiptTimeout() time.Duration {
+	if sec := config.Conf.SharePriceKeeper.ReceiptTimeout; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultSharePriceReceiptWait
+}
+
+// Run 处理全部配置了质押合约的链，返回各状态的池子数量
+func (s *SharePriceKeeperService) Run(now time.Time) (map[string]int, error) {
+	var chains []model.Chain
+	if err := ctx.Ctx.DB.Where("service_type = ? AND address <> ''", ChainServiceStaking).Find(&chains).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, chain := range chains {
+		if err := s.runContract(chain.ChainId, common.HexToAddress(chain.Address), now, counts); err != nil {
+			log.Logger.Error("维护份额价格失败", zap.Int64("chain_id", chain.ChainId), zap.String("contract", chain.Address), zap.Error(err))
+		}
+	}
+	return counts, nil
+}
+
+// runContract 处理单个质押合约的全部启用池子
+func (s *SharePriceKeeperService) runContract(chainId int64, stakeContract common.Address, now time.Time, counts map[string]int) error {
+	pools, client, err := readStakePools(chainId, strings.ToLower(stakeContract.Hex()))
+	if err != nil {
+		return err
+	}
+	caller, err := contract.NewAbiCaller(stakeContract, client)
+	if err != nil {
+		return err
+	}
+	day := SharePriceDay(now)
+	type submittedPrice struct {
+		update *model.SharePriceUpdate
+		txId   int64
+	}
+	var submitted []submittedPrice
+	for _, pool := range pools {
+		if pool.Id == nil || !pool.IsActive {
+			continue
+		}
+		cfg := sharePricePoolConfig(chainId, pool.Id.Int64())
+		if cfg.Disabled {
+			continue
+		}
+		update, tx := s.updatePool(caller, chainId, stakeContract, pool, cfg, day)
+		if tx != nil {
+			submitted = append(submitted, submittedPrice{update: update, txId: tx.Id})
+			continue
+		}
+		saveSharePriceUpdate(update, counts)
+	}
+
+	// 全部池子提交后再等待回执，共用同一超时，单个池子的交易卡住不会阻塞其他池子提交
+	waitCtx, cancel := context.WithTimeout(context.Background(), sharePriceReceiptTimeout())
+	defer cancel()
+	for _, p := range submitted {
+		s.awaitReceipt(waitCtx, p.update, p.txId)
+		saveSharePriceUpdate(p.update, counts)
+	}
+	return nil
+}
+
+func saveSharePriceUpdate(update *model.SharePriceUpdate, counts map[string]int) {
+	if err := ctx.Ctx.DB.Create(update).Error; err != nil {
+		log.Logger.Error("保存份额价格维护记录失败", zap.Error(err))
+	}
+	counts[update.Status]++
+}
+
+// failSharePrice 记录失败原因
+func failSharePrice(update *model.SharePriceUpdate, err error) *model.SharePriceUpdate {
+	log.Logger.Error("份额价格维护失败", zap.Int64("chain_id", update.ChainId), zap.Int64("pool_id", update.PoolId), zap.Error(err))
+	update.Status = model.SharePriceStatusFailed
+	update.Error = err.Error()
+	return update
+}
+
+// updatePool 计算单个池子当天的份额价格，需要上链时提交交易并返回托管交易，由调用方等待回执
+func (s *SharePriceKeeperService) updatePool(caller *contract.AbiCaller, chainId int64, stakeContract common.Address, pool contract.StakeV2Pool, cfg config.SharePricePoolConfig, day int64) (*model.SharePriceUpdate, *model.ManagedTx) {
+	update := &model.SharePriceUpdate{
+		ChainId:         chainId,
+		ContractAddress: strings.ToLower(stakeContract.Hex()),
+		PoolId:          pool.Id.Int64(),
+		Day:             day,
+		Source:          cfg.Source,
+		Price:           "0",
+		PreviousPrice:   "0",
+		DeviationPct:    decimal.Zero,
+	}
+	fail := func(err error) (*model.SharePriceUpdate, *model.ManagedTx) {
+		return failSharePrice(update, err), nil
+	}
+
+	price, err := s.computePrice(chainId, pool, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	update.Price = price.String()
+
+	opts := &bind.CallOpts{Context: context.Background()}
+	current, err := caller.DailySharePrices(opts, pool.Id, big.NewInt(day))
+	if err != nil {
+		return fail(fmt.Errorf("读取当天份额价格失败: %v", err))
+	}
+	if current != nil && current.Cmp(price) == 0 {
+		update.Status = model.SharePriceStatusSkipped
+		return update, nil
+	}
+
+	// 与上一次链上价格比较偏离
+	previousDay, previous := findSharePrice(caller, pool.Id, day-1, day-sharePriceLookbackDays)
+	if previous != nil {
+		update.PreviousPrice = previous.String()
+		if unchangedSharePrice(day, previousDay, previous, price) {
+			update.Status = model.SharePriceStatusSkipped
+			return update, nil
+		}
+		prev := decimal.NewFromBigInt(previous, 0)
+		update.DeviationPct = decimal.NewFromBigInt(price, 0).Sub(prev).Abs().Div(prev).Mul(decimal.NewFromInt(100)).Round(6)
+	}
+	threshold := sharePriceDeviationThreshold()
+	if update.DeviationPct.GreaterThan(threshold) {
+		update.Alerted = true
+		held := config.Conf.SharePriceKeeper.HaltOnDeviation
+		s.alert(update, threshold, held)
+		if held {
+			update.Status = model.SharePriceStatusHeld
+			return update, nil
+		}
+	}
+
+	stakeAbi, err := contract.AbiMetaData.GetAbi()
+	if err != nil {
+		return fail(err)
+	}
+	data, err := stakeAbi.Pack("setDailySharePrice", pool.Id, big.NewInt(day), price)
+	if err != nil {
+		return fail(err)
+	}
+	tx, err := s.txm.Submit(TxRequest{ChainId: chainId, To: stakeContract, Data: data, Purpose: "stake.share_price"})
+	if err != nil {
+		return fail(fmt.Errorf("提交 setDailySharePrice 失败: %w", err))
+	}
+	update.TxHash = tx.TxHash
+	return update, tx
+}
+
+// unchangedSharePrice 价格与最近一次发布的价格相同，且不发布时该价格在下一天仍处于回看窗口内，可跳过本次发布
+func unchangedSharePrice(day, previousDay int64, previous, price *big.Int) bool {
+	return previous.Cmp(price) == 0 && day-previousDay < sharePriceLookbackDays
+}
+
+// awaitReceipt 等待已提交的 setDailySharePrice 进入终态
+func (s *SharePriceKeeperService) awaitReceipt(waitCtx context.Context, update *model.SharePriceUpdate, txId int64) {
+	tx, err := s.txm.Wait(waitCtx, txId)
+	if tx != nil && tx.TxHash != "" {
+		update.TxHash = tx.TxHash
+	}
+	if err != nil {
+		failSharePrice(update, fmt.Errorf("setDailySharePrice 未成功: %w", err))
+		return
+	}
+	update.Status = model.SharePriceStatusConfirmed
+	log.Logger.Info("份额价格已上链", zap.Int64("chain_id", update.ChainId), zap.Int64("pool_id", update.PoolId),
+		zap.String("price", update.Price), zap.String("tx_hash", update.TxHash))
+}
+
+// computePrice 按来源计算份额价格，返回 1e18 精度的整数
+func (s *SharePriceKeeperService) computePrice(chainId int64, pool contract.StakeV2Pool, cfg config.SharePricePoolConfig) (*big.Int, error) {
+	var price decimal.Decimal
+	switch cfg.Source {
+	case model.SharePriceSourceOracle:
+		if (pool.OracleDataFeedAddress == common.Address{}) {
+			return nil, errors.New("池子未配置预言机地址")
+		}
+		p, err := readChainlinkPrice(ctx.GetEvmClient(int(chainId)), pool.OracleDataFeedAddress)
+		if err != nil {
+			return nil, fmt.Errorf("读取预言机价格失败: %v", err)
+		}
+		price = p
+	case model.SharePriceSourceDex:
+		if (pool.TokenAddress == common.Address{}) {
+			return nil, errors.New("原生币池子无法使用 DEX 定价")
+		}
+		p, ok := s.priceSvc.GetTokenPriceUSD(chainId, pool.TokenAddress.Hex())
+		if !ok {
+			return nil, errors.New("DEX 路由无法定价该代币")
+		}
+		price = p
+	case model.SharePriceSourceStatic:
+		price = decimal.NewFromFloat(cfg.StaticPrice)
+	default:
+		return nil, fmt.Errorf("未知的价格来源: %s", cfg.Source)
+	}
+	if !price.IsPositive() {
+		return nil, errors.New("份额价格必须大于0")
+	}
+	return price.Shift(sharePriceDecimals).Truncate(0).BigInt(), nil
+}
+
+// alert 记录告警日志并生成 share_price_alert Webhook 事件
+func (s *SharePriceKeeperService) alert(update *model.SharePriceUpdate, threshold decimal.Decimal, held bool) {
+	date := time.Unix(update.Day*86400, 0).UTC().Format("2006-01-02")
+	log.Logger.Warn("份额价格偏离超过阈值",
+		zap.Int64("chain_id", update.ChainId), zap.Int64("pool_id", update.PoolId), zap.String("date", date),
+		zap.String("price", update.Price), zap.String("previous_price", update.PreviousPrice),
+		zap.String("deviation_pct", update.DeviationPct.String()), zap.Bool("held", held))
+
+	event := model.WebhookEvent{
+		Id:         fmt.Sprintf("%s:%d:%s:%d:%d", model.WebhookEventSharePriceAlert, update.ChainId, update.ContractAddress, update.PoolId, update.Day),
+		Type:       model.WebhookEventSharePriceAlert,
+		ChainId:    update.ChainId,
+		OccurredAt: time.Now(),
+		Data: model.SharePriceAlert{
+			ChainId:         update.ChainId,
+			ContractAddress: update.ContractAddress,
+			PoolId:          update.PoolId,
+			Date:            date,
+			Source:          update.Source,
+			Price:           decimal.NewFromBigInt(parseBigInt(update.Price), -sharePriceDecimals),
+			PreviousPrice:   decimal.NewFromBigInt(parseBigInt(update.PreviousPrice), -sharePriceDecimals),
+			DeviationPct:    update.DeviationPct,
+			ThresholdPct:    threshold,
+			Held:            held,
+		},
+		PoolAddress: update.ContractAddress,
+	}
+	if err := NewWebhookService().Enqueue([]model.WebhookEvent{event}); err != nil {
+		log.Logger.Error("生成份额价格告警投递任务失败", zap.Error(err))
+	}
+}
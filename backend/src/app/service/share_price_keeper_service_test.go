@@ -0,0 +1,30 @@
+package service
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUnchangedSharePrice(t *testing.T) {
+	price := big.NewInt(1e18)
+	cases := []struct {
+		name        string
+		previousDay int64
+		previous    *big.Int
+		want        bool
+	}{
+		{"昨天发布过相同价格", 99, big.NewInt(1e18), true},
+		{"价格变化", 99, big.NewInt(2e18), false},
+		// 明天的回看窗口仍能找到第 94 天的价格
+		{"回看窗口内最早一天", 100 - sharePriceLookbackDays + 1, big.NewInt(1e18), true},
+		// 再跳过一天，回看窗口内将没有价格
+		{"即将超出回看窗口", 100 - sharePriceLookbackDays, big.NewInt(1e18), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := unchangedSharePrice(100, c.previousDay, c.previous, price); got != c.want {
+				t.Fatalf("unchangedSharePrice = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
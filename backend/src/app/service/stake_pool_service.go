@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/contract"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	stakePoolCacheTTL = 60 * time.Second
+	// sharePriceDecimals 合约日份额价格精度
+	sharePriceDecimals = 18
+	// sharePriceLookbackDays 向前查找最近一次日份额价格的天数
+	sharePriceLookbackDays = 7
+	// stakeAprWindowDays APR 计算窗口，窗口起点无价格时继续向前查找至两倍窗口
+	stakeAprWindowDays = 7
+)
+
+// chainlinkFeedABI Chainlink AggregatorV3 的 decimals 与 latestRoundData
+const chainlinkFeedABI = `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"latestRoundData","outputs":[{"internalType":"uint80","name":"roundId","type":"uint80"},{"internalType":"int256","name":"answer","type":"int256"},{"internalType":"uint256","name":"startedAt","type":"uint256"},{"internalType":"uint256","name":"updatedAt","type":"uint256"},{"internalType":"uint80","name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}]`
+
+type stakePoolCacheEntry struct {
+	pools     []model.StakePoolInfo
+	expiredAt time.Time
+}
+
+var (
+	stakePoolCacheMu sync.Mutex
+	stakePoolCache   = make(map[int64]stakePoolCacheEntry)
+)
+
+type StakePoolService struct {
+	priceSvc *PriceService
+}
+
+func NewStakePoolService() *StakePoolService {
+	return &StakePoolService{
+		priceSvc: NewPriceService(),
+	}
+}
+
+// SharePriceDay 合约 dailySharePrices 的日期键：UTC 自然日序号（unix 时间戳 / 86400）
+func SharePriceDay(t time.Time) int64 {
+	return t.UTC().Unix() / 86400
+}
+
+// ListPools 获取链上全部质押池（带短时缓存）。质押合约地址来自 chain 表（service_type = staking），
+// 池子数据以合约 getPools 为准并合并已索引的 PoolCreated 事件；链上读取失败时仅返回索引数据
+func (s *StakePoolService) ListPools(chainId int64) ([]model.StakePoolInfo, error) {
+	stakePoolCacheMu.Lock()
+	entry, ok := stakePoolCache[chainId]
+	stakePoolCacheMu.Unlock()
+	if ok && time.Now().Before(entry.expiredAt) {
+		return entry.pools, nil
+	}
+
+	var chains []model.Chain
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND service_type = ? AND address <> ''", chainId, ChainServiceStaking).Find(&chains).Error; err != nil {
+		return nil, err
+	}
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("%w: 链 %d 未配置质押合约", ErrStakeInvalidParam, chainId)
+	}
+
+	pools := make([]model.StakePoolInfo, 0)
+	for _, chain := range chains {
+		list, err := s.listContractPools(chainId, strings.ToLower(chain.Address))
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, list...)
+	}
+
+	stakePoolCacheMu.Lock()
+	stakePoolCache[chainId] = stakePoolCacheEntry{pools: pools, expiredAt: time.Now().Add(stakePoolCacheTTL)}
+	stakePoolCacheMu.Unlock()
+	return pools, nil
+}
+
+// listContractPools 读取单个质押合约的池子
+func (s *StakePoolService) listContractPools(chainId int64, stakeContract string) ([]model.StakePoolInfo, error) {
+	var indexed []model.StakePool
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND contract_address = ?", chainId, stakeContract).
+		Order("pool_id ASC").Find(&indexed).Error; err != nil {
+		return nil, err
+	}
+	indexedById := make(map[int64]model.StakePool, len(indexed))
+	for _, p := range indexed {
+		indexedById[p.PoolId] = p
+	}
+
+	onChain, client, err := readStakePools(chainId, stakeContract)
+	if err != nil {
+		log.Logger.Warn("读取链上质押池失败，仅返回索引数据", zap.Int64("chain_id", chainId), zap.String("contract", stakeContract), zap.Error(err))
+		pools := make([]model.StakePoolInfo, 0, len(indexed))
+		for _, p := range indexed {
+			info := model.StakePoolInfo{
+				ChainId:       chainId,
+				StakeContract: stakeContract,
+				PoolId:        p.PoolId,
+				Name:          p.Name,
+				Token:         model.StakePoolToken{Address: p.TokenAddress, Native: p.TokenAddress == strings.ToLower(common.Address{}.Hex())},
+				LockDuration:  p.LockDuration,
+				TotalStaked:   decimal.Zero,
+			}
+			applyIndexedPool(&info, p)
+			pools = append(pools, info)
+		}
+		return pools, nil
+	}
+
+	tokenSvc := NewTokenService()
+	stakeCaller, err := contract.NewAbiCaller(common.HexToAddress(stakeContract), client)
+	if err != nil {
+		return nil, fmt.Errorf("创建质押合约实例失败: %v", err)
+	}
+	today := SharePriceDay(time.Now())
+
+	pools := make([]model.StakePoolInfo, 0, len(onChain))
+	for _, p := range onChain {
+		if p.Id == nil {
+			continue
+		}
+		info := model.StakePoolInfo{
+			ChainId:       chainId,
+			StakeContract: stakeContract,
+			PoolId:        p.Id.Int64(),
+			Name:          p.PoolName,
+			Token:         s.poolToken(tokenSvc, chainId, p.TokenAddress),
+			IsActive:      p.IsActive,
+			FeeRatio:      bigString(p.FeeRatio),
+			Live:          true,
+		}
+		if p.LockDuration != nil {
+			info.LockDuration = p.LockDuration.Int64()
+		}
+		if (p.OracleDataFeedAddress != common.Address{}) {
+			info.OracleDataFeed = strings.ToLower(p.OracleDataFeedAddress.Hex())
+		}
+		if ip, ok := indexedById[info.PoolId]; ok {
+			applyIndexedPool(&info, ip)
+		}
+
+		info.TotalStaked = tokenAmount(p.AmountTotal, info.Token.Decimals)
+		if price, ok := s.tokenPriceUSD(client, chainId, p.TokenAddress, p.OracleDataFeedAddress); ok {
+			tvl := info.TotalStaked.Mul(price).Round(2)
+			info.TvlUSD = &tvl
+		}
+
+		s.fillSharePrice(stakeCaller, &info, p.Id, today)
+		pools = append(pools, info)
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].PoolId < pools[j].PoolId })
+	return pools, nil
+}
+
+// readStakePools 调用质押合约 getPools
+func readStakePools(chainId int64, stakeContract string) ([]contract.StakeV2Pool, *ethclient.Client, error) {
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return nil, nil, fmt.Errorf("无法获取链ID为 %d 的以太坊客户端", chainId)
+	}
+	client := ctx.GetEvmClient(int(chainId))
+	caller, err := contract.NewAbiCaller(common.HexToAddress(stakeContract), client)
+	if err != nil {
+		return nil, nil, err
+	}
+	pools, err := caller.GetPools(&bind.CallOpts{Context: context.Background()})
+	if err != nil {
+		return nil, nil, err
+	}
+	return pools, client, nil
+}
+
+// applyIndexedPool 补充索引到的创建信息
+func applyIndexedPool(info *model.StakePoolInfo, p model.StakePool) {
+	if info.Name == "" {
+		info.Name = p.Name
+	}
+	info.CreatedBlock = p.BlockNumber
+	info.CreatedTxHash = p.TxHash
+	if !p.BlockTime.IsZero() {
+		createdAt := p.BlockTime
+		info.CreatedAt = &createdAt
+	}
+}
+
+// poolToken 代币元数据，原生币池子按 18 位精度处理
+func (s *StakePoolService) poolToken(tokenSvc *TokenService, chainId int64, token common.Address) model.StakePoolToken {
+	t := model.StakePoolToken{Address: strings.ToLower(token.Hex()), Decimals: 18}
+	if (token == common.Address{}) {
+		t.Native = true
+		return t
+	}
+	symbol, decimals, err := tokenSvc.GetTokenDetails(token.Hex(), chainId)
+	if err != nil {
+		log.Logger.Warn("获取质押代币元数据失败", zap.String("token", token.Hex()), zap.Error(err))
+		return t
+	}
+	t.Symbol = symbol
+	t.Decimals = decimals
+	return t
+}
+
+// tokenPriceUSD 优先使用价格服务，无法定价时读取池子配置的 Chainlink 喂价
+func (s *StakePoolService) tokenPriceUSD(client *ethclient.Client, chainId int64, token, feed common.Address) (decimal.Decimal, bool) {
+	if (token != common.Address{}) {
+		if price, ok := s.priceSvc.GetTokenPriceUSD(chainId, token.Hex()); ok {
+			return price, true
+		}
+	}
+	if (feed == common.Address{}) {
+		return decimal.Zero, false
+	}
+	price, err := readChainlinkPrice(client, feed)
+	if err != nil {
+		log.Logger.Warn("读取预言机价格失败", zap.String("feed", feed.Hex()), zap.Error(err))
+		return decimal.Zero, false
+	}
+	return price, true
+}
+
+// readChainlinkPrice 读取 Chainlink AggregatorV3 最新价格
+func readChainlinkPrice(client *ethclient.Client, feed common.Address) (decimal.Decimal, error) {
+	parsed, err := gethabi.JSON(strings.NewReader(chainlinkFeedABI))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	bound := bind.NewBoundContract(feed, parsed, client, nil, nil)
+	opts := &bind.CallOpts{Context: context.Background()}
+
+	var out []interface{}
+	if err := bound.Call(opts, &out, "decimals"); err != nil {
+		return decimal.Zero, err
+	}
+	decimals, _ := out[0].(uint8)
+	out = nil
+	if err := bound.Call(opts, &out, "latestRoundData"); err != nil {
+		return decimal.Zero, err
+	}
+	answer, _ := out[1].(*big.Int)
+	if answer == nil || answer.Sign() <= 0 {
+		return decimal.Zero, fmt.Errorf("预言机价格无效")
+	}
+	return decimal.NewFromBigInt(answer, -int32(decimals)), nil
+}
+
+// fillSharePrice 查找最近一次日份额价格，并按窗口内的份额价格增长年化得到 APR
+func (s *StakePoolService) fillSharePrice(caller *contract.AbiCaller, info *model.StakePoolInfo, poolId *big.Int, today int64) {
+	latestDay, latest := findSharePrice(caller, poolId, today, today-sharePriceLookbackDays)
+	if latest == nil {
+		return
+	}
+	price := decimal.NewFromBigInt(latest, -sharePriceDecimals)
+	info.SharePrice = &price
+	info.SharePriceDate = time.Unix(latestDay*86400, 0).UTC().Format("2006-01-02")
+
+	baseDay, base := findSharePrice(caller, poolId, latestDay-stakeAprWindowDays, latestDay-2*stakeAprWindowDays)
+	if base == nil {
+		return
+	}
+	days := latestDay - baseDay
+	growth := decimal.NewFromBigInt(latest, 0).Div(decimal.NewFromBigInt(base, 0)).Sub(decimal.NewFromInt(1))
+	apr := growth.Mul(decimal.NewFromInt(365)).Div(decimal.NewFromInt(days)).Round(6)
+	info.Apr = &apr
+	info.AprWindowDays = int(days)
+}
+
+// findSharePrice 从 from 日向前查找到 to 日，返回第一个已设置的日份额价格
+func findSharePrice(caller *contract.AbiCaller, poolId *big.Int, from, to int64) (int64, *big.Int) {
+	opts := &bind.CallOpts{Context: context.Background()}
+	for day := from; day >= to && day > 0; day-- {
+		price, err := caller.DailySharePrices(opts, poolId, big.NewInt(day))
+		if err != nil {
+			log.Logger.Warn("读取日份额价格失败", zap.String("pool_id", poolId.String()), zap.Int64("day", day), zap.Error(err))
+			return 0, nil
+		}
+		if price != nil && price.Sign() > 0 {
+			return day, price
+		}
+	}
+	return 0, nil
+}
+
+func bigString(v *big.Int) string {
+	if v == nil {
+		return "0"
+	}
+	return v.String()
+}
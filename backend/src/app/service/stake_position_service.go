@@ -0,0 +1,373 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/api/dto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StakePositionService struct {
+	tokenSvc *TokenService
+}
+
+func NewStakePositionService() *StakePositionService {
+	return &StakePositionService{
+		tokenSvc: NewTokenService(),
+	}
+}
+
+// recordAmount 记录的原始单位数量，历史记录没有 amount_raw 时退回 amount
+func recordAmount(r *model.UserOperationRecord) *big.Int {
+	if r.AmountRaw != "" {
+		return parseBigInt(r.AmountRaw)
+	}
+	return big.NewInt(r.Amount)
+}
+
+func stakePositionKey(chainId int64, contract, user string, poolId int64) string {
+	return strconv.FormatInt(chainId, 10) + ":" + contract + ":" + user + ":" + strconv.FormatInt(poolId, 10)
+}
+
+// ApplyRecords 在索引事务内按链上顺序把质押、提取与领取收益记录累计到持仓。
+// 每笔 Staked 记为一个质押批次；提取按解锁时间先后扣减批次剩余本金（合约只允许提取已解锁部分）
+func (s *StakePositionService) ApplyRecords(tx *gorm.DB, records []*model.UserOperationRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	sorted := make([]*model.UserOperationRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].BlockNumber != sorted[j].BlockNumber {
+			return sorted[i].BlockNumber < sorted[j].BlockNumber
+		}
+		return sorted[i].LogIndex < sorted[j].LogIndex
+	})
+
+	positions := make(map[string]*model.StakePosition)
+	for _, r := range sorted {
+		if r.EventType != "Staked" && r.EventType != "Withdrawn" && r.EventType != "ClaimRewards" {
+			continue
+		}
+		contract := strings.ToLower(r.ContractAddress)
+		user := strings.ToLower(r.Address)
+		key := stakePositionKey(r.ChainId, contract, user, r.PoolId)
+		pos, ok := positions[key]
+		if !ok {
+			var err error
+			if pos, err = s.loadPosition(tx, r.ChainId, contract, user, r.PoolId); err != nil {
+				return err
+			}
+			positions[key] = pos
+		}
+		if pos.TokenAddress == "" && r.TokenAddress != "" {
+			pos.TokenAddress = strings.ToLower(r.TokenAddress)
+		}
+		if pos.Id == 0 {
+			pos.FirstStakedAt = r.OperationTime
+			if err := tx.Create(pos).Error; err != nil {
+				return err
+			}
+		}
+
+		amount := recordAmount(r)
+		reward := parseBigInt(r.Reward)
+		switch r.EventType {
+		case "Staked":
+			lot := model.StakePositionLot{
+				PositionId:      pos.Id,
+				RecordId:        r.Id,
+				ChainId:         r.ChainId,
+				ContractAddress: contract,
+				UserAddress:     user,
+				PoolId:          r.PoolId,
+				TokenAddress:    pos.TokenAddress,
+				Amount:          amount.String(),
+				Remaining:       amount.String(),
+				StakedAt:        r.OperationTime,
+				UnlockTime:      r.UnlockTime,
+				TxHash:          r.TxHash,
+				BlockNumber:     r.BlockNumber,
+				LogIndex:        r.LogIndex,
+			}
+			if err := tx.Create(&lot).Error; err != nil {
+				return err
+			}
+			pos.Principal = new(big.Int).Add(parseBigInt(pos.Principal), amount).String()
+			pos.TotalStaked = new(big.Int).Add(parseBigInt(pos.TotalStaked), amount).String()
+			pos.StakeCount++
+		case "Withdrawn":
+			if err := s.consumeLots(tx, pos, amount, r.OperationTime); err != nil {
+				return err
+			}
+			principal := new(big.Int).Sub(parseBigInt(pos.Principal), amount)
+			if principal.Sign() < 0 {
+				principal.SetInt64(0)
+			}
+			pos.Principal = principal.String()
+			pos.TotalWithdrawn = new(big.Int).Add(parseBigInt(pos.TotalWithdrawn), amount).String()
+			pos.ClaimedRewards = new(big.Int).Add(parseBigInt(pos.ClaimedRewards), reward).String()
+		case "ClaimRewards":
+			pos.ClaimedRewards = new(big.Int).Add(parseBigInt(pos.ClaimedRewards), reward).String()
+		}
+		pos.LastActivityAt = r.OperationTime
+		pos.LastBlockNumber = r.BlockNumber
+	}
+
+	for _, pos := range positions {
+		if err := tx.Save(pos).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPosition 加锁读取持仓，不存在时返回未入库的新持仓
+func (s *StakePositionService) loadPosition(tx *gorm.DB, chainId int64, contract, user string, poolId int64) (*model.StakePosition, error) {
+	var pos model.StakePosition
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("chain_id = ? AND contract_address = ? AND user_address = ? AND pool_id = ?", chainId, contract, user, poolId).
+		First(&pos).Error
+	if err == gorm.ErrRecordNotFound {
+		return &model.StakePosition{
+			ChainId:         chainId,
+			ContractAddress: contract,
+			UserAddress:     user,
+			PoolId:          poolId,
+			Principal:       "0",
+			TotalStaked:     "0",
+			TotalWithdrawn:  "0",
+			ClaimedRewards:  "0",
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pos, nil
+}
+
+// consumeLots 按解锁时间先后扣减质押批次的剩余本金
+func (s *StakePositionService) consumeLots(tx *gorm.DB, pos *model.StakePosition, amount *big.Int, at time.Time) error {
+	var lots []model.StakePositionLot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("position_id = ? AND remaining > 0", pos.Id).
+		Order("unlock_time ASC, id ASC").Find(&lots).Error; err != nil {
+		return err
+	}
+	left := new(big.Int).Set(amount)
+	for i := range lots {
+		if left.Sign() <= 0 {
+			break
+		}
+		lot := &lots[i]
+		remaining := parseBigInt(lot.Remaining)
+		take := remaining
+		if left.Cmp(remaining) < 0 {
+			take = left
+		}
+		remaining = new(big.Int).Sub(remaining, take)
+		left = new(big.Int).Sub(left, take)
+		updates := map[string]interface{}{"remaining": remaining.String()}
+		if remaining.Sign() == 0 {
+			updates["withdrawn_at"] = at
+		}
+		if err := tx.Model(&model.StakePositionLot{}).Where("id = ?", lot.Id).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if left.Sign() > 0 {
+		log.Logger.Warn("提取数量超过已索引的质押本金",
+			zap.Int64("chain_id", pos.ChainId), zap.String("user", pos.UserAddress),
+			zap.Int64("pool_id", pos.PoolId), zap.String("excess", left.String()))
+	}
+	return nil
+}
+
+// RebuildPositions 按链上全部已索引的质押记录重建持仓；锁表期间实时索引的持仓更新会等待重建完成
+func (s *StakePositionService) RebuildPositions(tx *gorm.DB, chainId int64) error {
+	if err := tx.Exec("LOCK TABLE stake_positions, stake_position_lots IN EXCLUSIVE MODE").Error; err != nil {
+		return err
+	}
+	if err := tx.Where("chain_id = ?", chainId).Delete(&model.StakePositionLot{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("chain_id = ?", chainId).Delete(&model.StakePosition{}).Error; err != nil {
+		return err
+	}
+
+	for offset := 0; ; offset += 1000 {
+		var batch []*model.UserOperationRecord
+		if err := tx.Where("chain_id = ? AND event_type IN ?", chainId, []string{"Staked", "Withdrawn", "ClaimRewards"}).
+			Order("block_number ASC, log_index ASC, id ASC").
+			Offset(offset).Limit(1000).Find(&batch).Error; err != nil {
+			return err
+		}
+		if err := s.ApplyRecords(tx, batch); err != nil {
+			return err
+		}
+		if len(batch) < 1000 {
+			return nil
+		}
+	}
+}
+
+// lotStatus 质押批次状态
+func lotStatus(lot model.StakePositionLot, now time.Time) string {
+	switch {
+	case parseBigInt(lot.Remaining).Sign() == 0:
+		return model.StakeStatusWithdrawn
+	case !lot.UnlockTime.After(now):
+		return model.StakeStatusUnlockable
+	default:
+		return model.StakeStatusLocked
+	}
+}
+
+func (s *StakePositionService) tokenMeta(chainId int64, tokenAddress string) (string, int) {
+	if tokenAddress == "" || tokenAddress == "0x0000000000000000000000000000000000000000" {
+		return "", 18
+	}
+	symbol, decimals, err := s.tokenSvc.GetTokenDetails(tokenAddress, chainId)
+	if err != nil {
+		return "", 18
+	}
+	return symbol, decimals
+}
+
+// ListRecords 分页查询用户的质押批次及状态
+func (s *StakePositionService) ListRecords(userAddress string, chainId int64, pagination dto.Pagination) ([]model.StakeRecord, int64, error) {
+	query := ctx.Ctx.DB.Model(&model.StakePositionLot{}).Where("user_address = ?", strings.ToLower(userAddress))
+	if chainId > 0 {
+		query = query.Where("chain_id = ?", chainId)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询记录总数失败: %v", err)
+	}
+	var lots []model.StakePositionLot
+	if err := query.Order("staked_at DESC, id DESC").Offset(pagination.Offset).Limit(pagination.PageSize).Find(&lots).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询质押记录失败: %v", err)
+	}
+
+	now := time.Now()
+	records := make([]model.StakeRecord, 0, len(lots))
+	for _, lot := range lots {
+		symbol, decimals := s.tokenMeta(lot.ChainId, lot.TokenAddress)
+		amount := tokenAmount(parseBigInt(lot.Amount), decimals)
+		remaining := tokenAmount(parseBigInt(lot.Remaining), decimals)
+		updatedAt := lot.StakedAt
+		if lot.WithdrawnAt != nil {
+			updatedAt = *lot.WithdrawnAt
+		}
+		records = append(records, model.StakeRecord{
+			ID:              lot.RecordId,
+			UserAddress:     lot.UserAddress,
+			ChainId:         lot.ChainId,
+			PoolId:          lot.PoolId,
+			Amount:          amount.InexactFloat64(),
+			Remaining:       remaining.InexactFloat64(),
+			Withdrawn:       amount.Sub(remaining).InexactFloat64(),
+			Token:           lot.TokenAddress,
+			Symbol:          symbol,
+			Status:          lotStatus(lot, now),
+			UnlockTime:      lot.UnlockTime,
+			TxHash:          lot.TxHash,
+			ContractAddress: lot.ContractAddress,
+			CreatedAt:       lot.StakedAt,
+			UpdatedAt:       updatedAt,
+		})
+	}
+	return records, total, nil
+}
+
+// ListPositions 查询用户的质押持仓，锁定与可提取数量按当前时间与各批次解锁时间计算
+func (s *StakePositionService) ListPositions(userAddress string, chainId int64) ([]model.StakePositionDetail, error) {
+	user := strings.ToLower(userAddress)
+	query := ctx.Ctx.DB.Where("user_address = ?", user)
+	if chainId > 0 {
+		query = query.Where("chain_id = ?", chainId)
+	}
+	var positions []model.StakePosition
+	if err := query.Order("chain_id ASC, pool_id ASC").Find(&positions).Error; err != nil {
+		return nil, fmt.Errorf("查询质押持仓失败: %v", err)
+	}
+	lotQuery := ctx.Ctx.DB.Where("user_address = ? AND remaining > 0", user)
+	if chainId > 0 {
+		lotQuery = lotQuery.Where("chain_id = ?", chainId)
+	}
+	var lots []model.StakePositionLot
+	if err := lotQuery.Find(&lots).Error; err != nil {
+		return nil, fmt.Errorf("查询质押批次失败: %v", err)
+	}
+	lotsOf := make(map[int64][]model.StakePositionLot)
+	for _, lot := range lots {
+		lotsOf[lot.PositionId] = append(lotsOf[lot.PositionId], lot)
+	}
+
+	now := time.Now()
+	details := make([]model.StakePositionDetail, 0, len(positions))
+	for _, pos := range positions {
+		symbol, decimals := s.tokenMeta(pos.ChainId, pos.TokenAddress)
+		locked, unlockable := new(big.Int), new(big.Int)
+		var nextUnlock *time.Time
+		for _, lot := range lotsOf[pos.Id] {
+			remaining := parseBigInt(lot.Remaining)
+			if lot.UnlockTime.After(now) {
+				locked.Add(locked, remaining)
+				if nextUnlock == nil || lot.UnlockTime.Before(*nextUnlock) {
+					t := lot.UnlockTime
+					nextUnlock = &t
+				}
+			} else {
+				unlockable.Add(unlockable, remaining)
+			}
+		}
+		status := model.StakeStatusWithdrawn
+		if unlockable.Sign() > 0 {
+			status = model.StakeStatusUnlockable
+		} else if locked.Sign() > 0 {
+			status = model.StakeStatusLocked
+		}
+		details = append(details, model.StakePositionDetail{
+			ChainId:          pos.ChainId,
+			ContractAddress:  pos.ContractAddress,
+			PoolId:           pos.PoolId,
+			TokenAddress:     pos.TokenAddress,
+			Symbol:           symbol,
+			Decimals:         decimals,
+			Status:           status,
+			Principal:        tokenAmount(parseBigInt(pos.Principal), decimals),
+			LockedAmount:     tokenAmount(locked, decimals),
+			UnlockableAmount: tokenAmount(unlockable, decimals),
+			TotalStaked:      tokenAmount(parseBigInt(pos.TotalStaked), decimals),
+			TotalWithdrawn:   tokenAmount(parseBigInt(pos.TotalWithdrawn), decimals),
+			ClaimedRewards:   tokenAmount(parseBigInt(pos.ClaimedRewards), decimals),
+			NextUnlockTime:   nextUnlock,
+			ActiveStakes:     len(lotsOf[pos.Id]),
+			StakeCount:       pos.StakeCount,
+			FirstStakedAt:    pos.FirstStakedAt,
+			LastActivityAt:   pos.LastActivityAt,
+		})
+	}
+	return details, nil
+}
+
+// sumDetails 汇总各持仓的数量（不同代币直接相加，仅用于概览展示）
+func sumDetails(details []model.StakePositionDetail, field func(model.StakePositionDetail) decimal.Decimal) float64 {
+	total := decimal.Zero
+	for _, d := range details {
+		total = total.Add(field(d))
+	}
+	return total.InexactFloat64()
+}
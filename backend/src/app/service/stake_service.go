@@ -2,396 +2,368 @@ package service
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/mumu/cryptoSwap/src/abi"
 	"github.com/mumu/cryptoSwap/src/app/api/dto"
 	"github.com/mumu/cryptoSwap/src/app/model"
 	"github.com/mumu/cryptoSwap/src/contract"
 	"github.com/mumu/cryptoSwap/src/core/ctx"
-	"github.com/mumu/cryptoSwap/src/core/log"
 	"github.com/shopspring/decimal"
-	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
-type StakeService struct {
-	// 私钥用于交易签名
-	privateKey string
-	// 质押合约地址
-	stakeContractAddress string
-	// ERC20代币合约地址
-	erc20ContractAddress string
-}
+const (
+	// ChainServiceStaking chain 表中质押合约的服务类型
+	ChainServiceStaking = "staking"
+
+	// 无法预估 gas 时（依赖前一笔授权交易）使用的默认值
+	defaultApproveGas  = uint64(60000)
+	defaultDepositGas  = uint64(300000)
+	defaultWithdrawGas = uint64(300000)
+	// stakeGasBufferPct 预估 gas 上浮比例（百分比）
+	stakeGasBufferPct = 20
+)
+
+// ErrStakeInvalidParam 质押/提取参数错误（池子、代币、数量、余额等），与链或数据库异常区分
+var ErrStakeInvalidParam = errors.New("质押参数无效")
+
+type StakeService struct{}
 
 func NewStakeService() *StakeService {
-	return &StakeService{
-		privateKey:           "e22afa1331382e752eb5afce597290f5d14c307b613f649ae0b5c47cd84ad974", // 应该从配置文件或环境变量中获取
-		stakeContractAddress: "0xBE023722Da91C220D2051Fa37072b0378754CC72",                       // 应该从配置文件或环境变量中获取
-		erc20ContractAddress: "0x241bBa478bAD3945B9b122b80B756b5D19b423a5",                       // 应该从配置文件或环境变量中获取
-	}
+	return &StakeService{}
 }
 
-// ProcessStake 处理质押逻辑
-func (s *StakeService) ProcessStake(userAddress string, chainId int64, amount float64, token string, poolId int64) (*model.StakeRecord, error) {
-	// 1. 验证用户地址和代币有效性
-	if !common.IsHexAddress(userAddress) {
-		return nil, fmt.Errorf("无效的用户地址: %s", userAddress)
+// BuildStakeTx 构建质押交易：授权额度不足时先 approve，再 deposit（原生币池子为 depositEth）。
+// 交易由用户钱包签名发送，服务端不签名也不写入记录，质押记录以索引到的 Staked 事件为准
+func (s *StakeService) BuildStakeTx(userAddress string, chainId int64, amount decimal.Decimal, token string, poolId int64) (*model.StakeTxPlan, error) {
+	b, err := newStakeTxBuilder(userAddress, chainId, poolId)
+	if err != nil {
+		return nil, err
 	}
-
-	// 2. 获取以太坊客户端
-	client := ctx.GetEvmClient(int(chainId))
-	if client == nil {
-		return nil, fmt.Errorf("无法获取链ID为 %d 的以太坊客户端", chainId)
+	if !b.pool.IsActive {
+		return nil, fmt.Errorf("%w: 质押池 %d 未启用", ErrStakeInvalidParam, poolId)
 	}
-
-	// 3. 检查余额是否足够
-	balance, err := s.checkTokenBalance(client, userAddress, token)
-	if err != nil {
-		return nil, fmt.Errorf("检查余额失败: %v", err)
+	if token != "" && !strings.EqualFold(token, b.pool.TokenAddress.Hex()) {
+		return nil, fmt.Errorf("%w: 代币 %s 与质押池代币 %s 不一致", ErrStakeInvalidParam, token, b.pool.TokenAddress.Hex())
 	}
-
-	// 将amount转换为最小单位（假设18位小数）
-	amountInWei := decimal.NewFromFloat(amount).Mul(decimal.New(1, 18)).BigInt()
-	if balance.Cmp(amountInWei) < 0 {
-		return nil, fmt.Errorf("余额不足，当前余额: %s, 需要: %s", balance.String(), amountInWei.String())
+	rawAmount, err := b.toRawAmount(amount)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. 获取私钥
-	privateKey, err := crypto.HexToECDSA(s.privateKey)
+	plan := b.plan("stake", rawAmount)
+	stakeAbi, err := contract.AbiMetaData.GetAbi()
 	if err != nil {
-		return nil, fmt.Errorf("无效的私钥: %v", err)
+		return nil, err
 	}
 
-	// 5. 获取发送者地址
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("获取公钥失败")
+	// 原生币池子：depositEth 随交易转入
+	if b.native() {
+		balance, err := b.client.BalanceAt(b.bg, b.user, nil)
+		if err != nil {
+			return nil, fmt.Errorf("查询余额失败: %v", err)
+		}
+		if balance.Cmp(rawAmount) < 0 {
+			return nil, fmt.Errorf("%w: 余额不足，当前余额: %s, 需要: %s", ErrStakeInvalidParam, balance, rawAmount)
+		}
+		data, err := stakeAbi.Pack("depositEth", big.NewInt(poolId))
+		if err != nil {
+			return nil, err
+		}
+		tx, err := b.tx("deposit", b.stakeContract, data, rawAmount, 0, defaultDepositGas, true)
+		if err != nil {
+			return nil, err
+		}
+		plan.Transactions = append(plan.Transactions, *tx)
+		return plan, nil
 	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	// 6. 获取nonce
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	erc20, err := abi.NewAbi(b.pool.TokenAddress, b.client)
 	if err != nil {
-		return nil, fmt.Errorf("获取nonce失败: %v", err)
+		return nil, fmt.Errorf("创建ERC20合约实例失败: %v", err)
 	}
-
-	// 7. 设置交易参数
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("获取建议gas价格失败: %v", err)
-	}
-
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // in wei
-	auth.GasLimit = uint64(300000) // in units
-	auth.GasPrice = gasPrice
-
-	// 8. 创建ERC20合约实例
-	erc20Address := common.HexToAddress(s.erc20ContractAddress)
-	abiManager := abi.GetABIManager()
-	erc20ABI, exists := abiManager.GetABI("ERC20")
-	if !exists {
-		return nil, fmt.Errorf("ERC20 ABI未找到，请确保已调用abi.InitABIManager()")
-	}
-
-	// 创建绑定合约
-	// 正确创建合约实例：明确三个角色（可共用client，但语义更清晰）
-	var erc20Contract = bind.NewBoundContract(
-		erc20Address, // 合约地址
-		erc20ABI,     // 合约ABI
-		client,       // 用于调用只读方法（caller）
-		client,       // 用于发送交易（transactor）
-		client,       // 用于过滤日志（filterer）
-	) // 调用token0()函数获取代币0地址
-	// 调用token0()函数获取代币0地址
-	//erc20Contract, err := bind.NewBoundContract(erc20Address, erc20ABI, client, client, client)
-	//if err != nil {
-	//	return nil, fmt.Errorf("创建 ERC20 合约实例失败: %v", err)
-	//}
-
-	// 9. 创建质押合约实例
-	stakeAddress := common.HexToAddress(s.stakeContractAddress)
-	stakeContract, err := contract.NewAbi(stakeAddress, client)
+	callOpts := &bind.CallOpts{Context: b.bg}
+	balance, err := erc20.BalanceOf(callOpts, b.user)
 	if err != nil {
-		return nil, fmt.Errorf("创建质押合约实例失败: %v", err)
+		return nil, fmt.Errorf("查询余额失败: %v", err)
 	}
-
-	// 10. 授权质押合约使用代币
-	tx, err := erc20Contract.Transact(auth, "approve", stakeAddress, amountInWei)
-	if err != nil {
-		return nil, fmt.Errorf("授权失败: %v", err)
+	if balance.Cmp(rawAmount) < 0 {
+		return nil, fmt.Errorf("%w: 余额不足，当前余额: %s, 需要: %s", ErrStakeInvalidParam, balance, rawAmount)
 	}
+	allowance, err := erc20.Allowance(callOpts, b.user, b.stakeContract)
 	if err != nil {
-		return nil, fmt.Errorf("授权失败: %v", err)
-	}
-	log.Logger.Info("ERC20授权交易已发送", zap.String("txHash", tx.Hash().Hex()))
-
-	// 11. 等待授权交易确认
-	// 这里简化处理，实际应用中应该等待交易确认
-	// 11. 等待授权交易确认（至少等待1个区块确认）
-	//receipt, err := bind.WaitMined(context.Background(), client, tx)
-	//if err != nil {
-	//	return nil, fmt.Errorf("等待授权交易确认失败: %v", err)
-	//}
-	//if receipt.Status != 1 {
-	//	return nil, fmt.Errorf("授权交易失败，交易状态: %d", receipt.Status)
-	//}
-	//log.Logger.Info("ERC20授权交易已确认", zap.String("txHash", tx.Hash().Hex()))
-	//
-	//// 12. 为质押交易获取新的nonce
-	//nonce, err = client.PendingNonceAt(context.Background(), fromAddress)
-	//if err != nil {
-	//	return nil, fmt.Errorf("获取质押交易nonce失败: %v", err)
-	//}
-	//auth.Nonce = big.NewInt(int64(nonce))
-	// 11. 为质押交易使用下一个nonce（362）
-	auth.Nonce = big.NewInt(int64(nonce + 1))
-	// 12. 调用质押合约进行质押
-	poolIdBig := big.NewInt(poolId)
-	tx, err = stakeContract.Deposit(auth, poolIdBig, amountInWei)
-	if err != nil {
-		return nil, fmt.Errorf("质押失败: %v", err)
+		return nil, fmt.Errorf("查询授权额度失败: %v", err)
 	}
-	log.Logger.Info("质押交易已发送", zap.String("txHash", tx.Hash().Hex()))
+	plan.Allowance = allowance.String()
 
-	// 13. 记录质押信息到UserOperationRecord表
-	operationRecord := &model.UserOperationRecord{
-		ChainId:       chainId,
-		Address:       userAddress,
-		PoolId:        poolId,
-		Amount:        amountInWei.Int64(), // 存储为最小单位
-		OperationTime: time.Now(),
-		UnlockTime:    time.Now().Add(7 * 24 * time.Hour), // 示例：7天锁定期
-		TxHash:        tx.Hash().Hex(),
-		EventType:     "Staked",
-		TokenAddress:  token,
+	nonceOffset := uint64(0)
+	needApprove := allowance.Cmp(rawAmount) < 0
+	if needApprove {
+		erc20Abi, err := abi.AbiMetaData.GetAbi()
+		if err != nil {
+			return nil, err
+		}
+		data, err := erc20Abi.Pack("approve", b.stakeContract, rawAmount)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := b.tx("approve", b.pool.TokenAddress, data, nil, 0, defaultApproveGas, true)
+		if err != nil {
+			return nil, err
+		}
+		plan.Transactions = append(plan.Transactions, *tx)
+		nonceOffset = 1
 	}
 
-	if err := ctx.Ctx.DB.Create(operationRecord).Error; err != nil {
-		return nil, fmt.Errorf("创建质押记录失败: %v", err)
+	data, err := stakeAbi.Pack("deposit", big.NewInt(poolId), rawAmount)
+	if err != nil {
+		return nil, err
 	}
-
-	// 14. 更新用户积分（基于ScoreRules计算）
-	go s.updateUserScore(userAddress, chainId, token, amount)
-
-	// 15. 返回StakeRecord格式的数据
-	stakeRecord := &model.StakeRecord{
-		ID:          operationRecord.Id,
-		UserAddress: userAddress,
-		ChainId:     chainId,
-		Amount:      amount,
-		Token:       token,
-		Status:      "active",
-		CreatedAt:   operationRecord.OperationTime,
-		UpdatedAt:   operationRecord.OperationTime,
+	// 需要先授权时 deposit 在授权上链前必然回滚，无法预估
+	tx, err := b.tx("deposit", b.stakeContract, data, nil, nonceOffset, defaultDepositGas, !needApprove)
+	if err != nil {
+		return nil, err
 	}
-
-	return stakeRecord, nil
+	plan.Transactions = append(plan.Transactions, *tx)
+	return plan, nil
 }
 
-// ProcessWithdraw 处理提取逻辑
-func (s *StakeService) ProcessWithdraw(userAddress string, chainId int64, stakeId int64, poolId int64) (*model.StakeRecord, error) {
-	// 1. 验证质押记录存在且属于该用户
-	var operationRecord model.UserOperationRecord
-	if err := ctx.Ctx.DB.Where("id = ? AND address = ? AND chain_id = ? AND event_type = ?",
-		stakeId, userAddress, chainId, "Staked").First(&operationRecord).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("质押记录不存在或不属于该用户")
-		}
-		return nil, fmt.Errorf("查询质押记录失败: %v", err)
+// BuildWithdrawTx 构建提取交易。stakeId 大于 0 时按该笔质押的剩余本金提取，否则按 amount 提取；
+// 锁定期等条件由合约校验，预估 gas 失败即说明交易会回滚
+func (s *StakeService) BuildWithdrawTx(userAddress string, chainId int64, amount decimal.Decimal, stakeId int64, poolId int64) (*model.StakeTxPlan, error) {
+	b, err := newStakeTxBuilder(userAddress, chainId, poolId)
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 检查质押是否可提取（检查锁定期）
-	if time.Now().Before(operationRecord.UnlockTime) {
-		return nil, fmt.Errorf("质押未过锁定期，解锁时间: %s", operationRecord.UnlockTime.Format("2006-01-02 15:04:05"))
+	var rawAmount *big.Int
+	if stakeId > 0 {
+		var lot model.StakePositionLot
+		err := ctx.Ctx.DB.Where("record_id = ? AND user_address = ? AND chain_id = ? AND pool_id = ?",
+			stakeId, strings.ToLower(userAddress), chainId, poolId).First(&lot).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: 质押记录不存在或不属于该用户", ErrStakeInvalidParam)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询质押记录失败: %v", err)
+		}
+		rawAmount = parseBigInt(lot.Remaining)
+		if rawAmount.Sign() == 0 {
+			return nil, fmt.Errorf("%w: 该笔质押已全部提取", ErrStakeInvalidParam)
+		}
+		if lot.UnlockTime.After(time.Now()) {
+			return nil, fmt.Errorf("%w: 该笔质押将于 %s 解锁", ErrStakeInvalidParam, lot.UnlockTime.Format(time.RFC3339))
+		}
+	} else if rawAmount, err = b.toRawAmount(amount); err != nil {
+		return nil, err
 	}
 
-	// 3. 获取以太坊客户端
-	client := ctx.GetEvmClient(int(chainId))
-	if client == nil {
-		return nil, fmt.Errorf("无法获取链ID为 %d 的以太坊客户端", chainId)
+	stakeContract, err := contract.NewAbi(b.stakeContract, b.client)
+	if err != nil {
+		return nil, fmt.Errorf("创建质押合约实例失败: %v", err)
 	}
-
-	// 4. 获取私钥
-	privateKey, err := crypto.HexToECDSA(s.privateKey)
+	info, err := stakeContract.Users(&bind.CallOpts{Context: b.bg}, big.NewInt(poolId), b.user)
 	if err != nil {
-		return nil, fmt.Errorf("无效的私钥: %v", err)
+		return nil, fmt.Errorf("查询链上质押余额失败: %v", err)
 	}
-
-	// 5. 获取发送者地址
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("获取公钥失败")
+	if info.AmountTotal.Cmp(rawAmount) < 0 {
+		return nil, fmt.Errorf("%w: 质押余额不足，当前质押: %s, 需要: %s", ErrStakeInvalidParam, info.AmountTotal, rawAmount)
 	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	// 6. 获取nonce
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	stakeAbi, err := contract.AbiMetaData.GetAbi()
 	if err != nil {
-		return nil, fmt.Errorf("获取nonce失败: %v", err)
+		return nil, err
 	}
-
-	// 7. 设置交易参数
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	data, err := stakeAbi.Pack("withdraw", big.NewInt(poolId), rawAmount)
 	if err != nil {
-		return nil, fmt.Errorf("获取建议gas价格失败: %v", err)
+		return nil, err
 	}
+	plan := b.plan("withdraw", rawAmount)
+	tx, err := b.tx("withdraw", b.stakeContract, data, nil, 0, defaultWithdrawGas, true)
+	if err != nil {
+		return nil, err
+	}
+	plan.Transactions = append(plan.Transactions, *tx)
+	return plan, nil
+}
 
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // in wei
-	auth.GasLimit = uint64(300000) // in units
-	auth.GasPrice = gasPrice
+// stakeTxBuilder 构建单个用户在单个质押池上的交易
+type stakeTxBuilder struct {
+	bg            context.Context
+	client        *ethclient.Client
+	chainId       int64
+	user          common.Address
+	stakeContract common.Address
+	poolId        int64
+	pool          contract.StakeV2Pool
+	decimals      int
+	nonce         uint64
+	maxFee        *big.Int
+	tipCap        *big.Int
+}
+
+func newStakeTxBuilder(userAddress string, chainId int64, poolId int64) (*stakeTxBuilder, error) {
+	if !common.IsHexAddress(userAddress) {
+		return nil, fmt.Errorf("%w: 无效的用户地址: %s", ErrStakeInvalidParam, userAddress)
+	}
+	var chain model.Chain
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND service_type = ?", chainId, ChainServiceStaking).First(&chain).Error; err != nil || chain.Address == "" {
+		return nil, fmt.Errorf("%w: 链 %d 未配置质押合约", ErrStakeInvalidParam, chainId)
+	}
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return nil, fmt.Errorf("%w: 无法获取链ID为 %d 的以太坊客户端", ErrStakeInvalidParam, chainId)
+	}
+	b := &stakeTxBuilder{
+		bg:            context.Background(),
+		client:        ctx.GetEvmClient(int(chainId)),
+		chainId:       chainId,
+		user:          common.HexToAddress(userAddress),
+		stakeContract: common.HexToAddress(chain.Address),
+		poolId:        poolId,
+	}
 
-	// 8. 创建质押合约实例
-	stakeAddress := common.HexToAddress(s.stakeContractAddress)
-	stakeContract, err := contract.NewAbi(stakeAddress, client)
+	stakeContract, err := contract.NewAbi(b.stakeContract, b.client)
 	if err != nil {
 		return nil, fmt.Errorf("创建质押合约实例失败: %v", err)
 	}
-
-	// 9. 调用质押合约进行提取
-	poolIdBig := big.NewInt(poolId)
-	amountBig := big.NewInt(operationRecord.Amount)
-	tx, err := stakeContract.Withdraw(auth, poolIdBig, amountBig)
+	pool, err := stakeContract.Pools(&bind.CallOpts{Context: b.bg}, big.NewInt(poolId))
 	if err != nil {
-		return nil, fmt.Errorf("提取失败: %v", err)
+		return nil, fmt.Errorf("查询质押池失败: %v", err)
 	}
-	log.Logger.Info("提取交易已发送", zap.String("txHash", tx.Hash().Hex()))
-
-	// 10. 创建提取记录
-	withdrawRecord := &model.UserOperationRecord{
-		ChainId:       chainId,
-		Address:       userAddress,
-		PoolId:        poolId,
-		Amount:        operationRecord.Amount, // 提取相同金额
-		TokenAddress:  operationRecord.TokenAddress,
-		OperationTime: time.Now(),
-		TxHash:        tx.Hash().Hex(),
-		EventType:     "withdraw",
+	if pool.Id == nil || pool.Id.Int64() != poolId || (pool.TokenAddress == common.Address{} && pool.PoolName == "") {
+		return nil, fmt.Errorf("%w: 质押池 %d 不存在", ErrStakeInvalidParam, poolId)
 	}
+	b.pool = contract.StakeV2Pool(pool)
 
-	if err := ctx.Ctx.DB.Create(withdrawRecord).Error; err != nil {
-		return nil, fmt.Errorf("创建提取记录失败: %v", err)
+	b.decimals = 18
+	if !b.native() {
+		erc20, err := abi.NewAbi(pool.TokenAddress, b.client)
+		if err != nil {
+			return nil, fmt.Errorf("创建ERC20合约实例失败: %v", err)
+		}
+		decimals, err := erc20.Decimals(&bind.CallOpts{Context: b.bg})
+		if err != nil {
+			return nil, fmt.Errorf("查询代币精度失败: %v", err)
+		}
+		b.decimals = int(decimals)
 	}
 
-	// 11. 返回StakeRecord格式的数据
-	stakeRecord := &model.StakeRecord{
-		ID:          stakeId,
-		UserAddress: userAddress,
-		ChainId:     chainId,
-		Amount:      float64(operationRecord.Amount) / 1e18, // 转换回浮点数（假设18位小数）
-		Token:       operationRecord.TokenAddress,
-		Status:      "withdrawn",
-		CreatedAt:   operationRecord.OperationTime,
-		UpdatedAt:   time.Now(),
+	if b.nonce, err = b.client.PendingNonceAt(b.bg, b.user); err != nil {
+		return nil, fmt.Errorf("获取nonce失败: %v", err)
 	}
+	if err := b.loadFees(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
 
-	return stakeRecord, nil
+// loadFees 按最新区块 baseFee 计算 EIP-1559 费用：maxFee = 2 * baseFee + tip
+func (b *stakeTxBuilder) loadFees() error {
+	tip, err := b.client.SuggestGasTipCap(b.bg)
+	if err != nil {
+		return fmt.Errorf("获取建议小费失败: %v", err)
+	}
+	head, err := b.client.HeaderByNumber(b.bg, nil)
+	if err != nil {
+		return fmt.Errorf("获取最新区块失败: %v", err)
+	}
+	if head.BaseFee == nil {
+		return fmt.Errorf("链 %d 不支持 EIP-1559", b.chainId)
+	}
+	b.tipCap = tip
+	b.maxFee = new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	return nil
 }
 
-// GetStakeRecords 获取质押记录
-func (s *StakeService) GetStakeRecords(userAddress string, chainId int64, pagination dto.Pagination) ([]model.StakeRecord, int64, error) {
-	var operationRecords []model.UserOperationRecord
-	var total int64
+func (b *stakeTxBuilder) native() bool {
+	return b.pool.TokenAddress == common.Address{}
+}
 
-	// 构建查询条件（只查询质押事件）
-	query := ctx.Ctx.DB.Where("address = ? AND event_type = ?", userAddress, "Staked")
-	if chainId > 0 {
-		query = query.Where("chain_id = ?", chainId)
+// toRawAmount 按代币精度换算为原始单位，不允许超出精度的小数
+func (b *stakeTxBuilder) toRawAmount(amount decimal.Decimal) (*big.Int, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: 数量必须大于0", ErrStakeInvalidParam)
 	}
-
-	// 获取总数
-	if err := query.Model(&model.UserOperationRecord{}).Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("查询记录总数失败: %v", err)
+	raw := amount.Shift(int32(b.decimals))
+	if !raw.Equal(raw.Truncate(0)) {
+		return nil, fmt.Errorf("%w: 数量精度超过代币精度 %d", ErrStakeInvalidParam, b.decimals)
 	}
+	return raw.BigInt(), nil
+}
 
-	// 应用分页查询记录
-	if err := query.Order("operation_time DESC").
-		Offset(pagination.Offset).
-		Limit(pagination.PageSize).
-		Find(&operationRecords).Error; err != nil {
-		return nil, 0, fmt.Errorf("查询质押记录失败: %v", err)
+func (b *stakeTxBuilder) plan(action string, rawAmount *big.Int) *model.StakeTxPlan {
+	return &model.StakeTxPlan{
+		Action:        action,
+		ChainId:       b.chainId,
+		UserAddress:   b.user.Hex(),
+		StakeContract: b.stakeContract.Hex(),
+		PoolId:        b.poolId,
+		TokenAddress:  b.pool.TokenAddress.Hex(),
+		Decimals:      b.decimals,
+		Amount:        rawAmount.String(),
 	}
+}
 
-	// 转换为StakeRecord格式
-	var stakeRecords []model.StakeRecord
-	for _, record := range operationRecords {
-		// 检查是否已提取
-		status := "active"
-		var withdrawRecord model.UserOperationRecord
-		if err := ctx.Ctx.DB.Where("address = ? AND chain_id = ? AND event_type = ? AND amount = ?",
-			userAddress, chainId, "withdraw", record.Amount).First(&withdrawRecord).Error; err == nil {
-			status = "withdrawn"
-		}
-
-		stakeRecord := model.StakeRecord{
-			ID:          record.Id,
-			UserAddress: record.Address,
-			ChainId:     record.ChainId,
-			Amount:      float64(record.Amount) / 1e18, // 假设18位小数
-			Token:       record.TokenAddress,
-			Status:      status,
-			CreatedAt:   record.OperationTime,
-			UpdatedAt:   record.OperationTime,
+// tx 构建一笔待签名交易；estimate 为 true 时预估 gas，预估失败说明交易会回滚，直接返回错误
+func (b *stakeTxBuilder) tx(step string, to common.Address, data []byte, value *big.Int, nonceOffset uint64, defaultGas uint64, estimate bool) (*model.UnsignedTx, error) {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	gas := defaultGas
+	if estimate {
+		estimated, err := b.client.EstimateGas(b.bg, ethereum.CallMsg{
+			From:      b.user,
+			To:        &to,
+			GasFeeCap: b.maxFee,
+			GasTipCap: b.tipCap,
+			Value:     value,
+			Data:      data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s 交易预计失败: %v", ErrStakeInvalidParam, step, err)
 		}
-		stakeRecords = append(stakeRecords, stakeRecord)
-	}
+		gas = estimated * (100 + stakeGasBufferPct) / 100
+	}
+	return &model.UnsignedTx{
+		Step:                 step,
+		Type:                 "0x2",
+		ChainId:              b.chainId,
+		From:                 b.user.Hex(),
+		To:                   to.Hex(),
+		Data:                 hexutil.Encode(data),
+		Value:                value.String(),
+		Nonce:                b.nonce + nonceOffset,
+		Gas:                  gas,
+		GasEstimated:         estimate,
+		MaxFeePerGas:         b.maxFee.String(),
+		MaxPriorityFeePerGas: b.tipCap.String(),
+	}, nil
+}
 
-	return stakeRecords, total, nil
+// GetStakeRecords 获取质押记录，状态由质押持仓投影按解锁时间与剩余本金得出
+func (s *StakeService) GetStakeRecords(userAddress string, chainId int64, pagination dto.Pagination) ([]model.StakeRecord, int64, error) {
+	return NewStakePositionService().ListRecords(userAddress, chainId, pagination)
 }
 
 // GetStakeOverview 获取质押概览
 func (s *StakeService) GetStakeOverview(userAddress string, chainId int64) (*model.StakeOverview, error) {
-	var totalStaked int64
-	var activeStakes int64
-
-	// 构建查询条件
-	query := ctx.Ctx.DB.Model(&model.UserOperationRecord{}).Where("address = ? AND event_type = ?", userAddress, "Staked")
-	if chainId > 0 {
-		query = query.Where("chain_id = ?", chainId)
-	}
-
-	// 计算总质押量（活跃状态的质押）
-	//var totalStakedStr string
-	if err := query.Select("COALESCE(SUM(amount), 0)").Scan(&totalStaked).Error; err != nil {
-		return nil, fmt.Errorf("计算总质押量失败: %v", err)
-	}
-	// 将字符串结果转换为int64
-	//totalStaked, err := strconv.ParseInt(totalStakedStr, 10, 64)
-	//if err != nil {
-	//	return nil, fmt.Errorf("转换总质押量失败: %v", err)
-	//}
-	// 计算活跃质押数量（未提取的质押记录）
-	activeQuery := ctx.Ctx.DB.Model(&model.UserOperationRecord{}).Where("address = ? AND event_type = ?", userAddress, "Staked")
-	if chainId > 0 {
-		activeQuery = activeQuery.Where("chain_id = ?", chainId)
-	}
-
-	// 统计未提取的质押记录数量
-	var stakeRecords []model.UserOperationRecord
-	if err := activeQuery.Find(&stakeRecords).Error; err != nil {
-		return nil, fmt.Errorf("查询质押记录失败: %v", err)
-	}
-
-	for _, record := range stakeRecords {
-		var withdrawRecord model.UserOperationRecord
-		if err := ctx.Ctx.DB.Where("address = ? AND chain_id = ? AND event_type = ? AND amount = ?",
-			userAddress, record.ChainId, "withdraw", record.Amount).First(&withdrawRecord).Error; err != nil {
-			// 如果没有找到对应的提取记录，说明是活跃质押
-			activeStakes++
-		}
+	positions, err := NewStakePositionService().ListPositions(userAddress, chainId)
+	if err != nil {
+		return nil, err
+	}
+	activeStakes := 0
+	for _, p := range positions {
+		activeStakes += p.ActiveStakes
 	}
 
 	// 计算总收益（从Users表获取积分信息）
@@ -401,73 +373,20 @@ func (s *StakeService) GetStakeOverview(userAddress string, chainId int64) (*mod
 	}
 
 	overview := &model.StakeOverview{
-		TotalStaked:  float64(totalStaked) / 1e18, // 假设18位小数
-		TotalRewards: totalRewards,
-		ActiveStakes: int(activeStakes),
-		UserAddress:  userAddress,
-		ChainId:      chainId,
+		TotalStaked:      sumDetails(positions, func(d model.StakePositionDetail) decimal.Decimal { return d.Principal }),
+		LockedAmount:     sumDetails(positions, func(d model.StakePositionDetail) decimal.Decimal { return d.LockedAmount }),
+		UnlockableAmount: sumDetails(positions, func(d model.StakePositionDetail) decimal.Decimal { return d.UnlockableAmount }),
+		ClaimedRewards:   sumDetails(positions, func(d model.StakePositionDetail) decimal.Decimal { return d.ClaimedRewards }),
+		TotalRewards:     totalRewards,
+		ActiveStakes:     activeStakes,
+		UserAddress:      userAddress,
+		ChainId:          chainId,
+		Positions:        positions,
 	}
 
 	return overview, nil
 }
 
-// updateUserScore 更新用户积分
-func (s *StakeService) updateUserScore(userAddress string, chainId int64, token string, amount float64) {
-	// 1. 获取积分规则
-	var scoreRule model.ScoreRules
-	if err := ctx.Ctx.DB.Where("chain_id = ? AND token_address = ?", chainId, token).First(&scoreRule).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// 如果没有找到积分规则，使用默认规则
-			scoreRule.Score = decimal.NewFromFloat(1.0) // 默认1:1积分
-			scoreRule.Decimals = 18
-		} else {
-			fmt.Printf("查询积分规则失败: %v\n", err)
-			return
-		}
-	}
-
-	// 2. 计算应得积分
-	amountDecimal := decimal.NewFromFloat(amount)
-	score := amountDecimal.Mul(scoreRule.Score)
-
-	// 3. 更新用户积分信息
-	var user model.Users
-	if err := ctx.Ctx.DB.Where("chain_id = ? AND address = ?", chainId, userAddress).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// 创建新用户记录
-			user = model.Users{
-				ChainId:      chainId,
-				Address:      userAddress,
-				TokenAddress: token,
-				TotalAmount:  int64(amount * 1e18), // 假设18位小数
-				JfAmount:     score.IntPart(),
-				Jf:           score,
-				JfTime:       time.Now(),
-			}
-			if err := ctx.Ctx.DB.Create(&user).Error; err != nil {
-				fmt.Printf("创建用户记录失败: %v\n", err)
-				return
-			}
-		} else {
-			fmt.Printf("查询用户记录失败: %v\n", err)
-			return
-		}
-	} else {
-		// 更新现有用户记录
-		user.TotalAmount += int64(amount * 1e18) // 假设18位小数
-		user.JfAmount += score.IntPart()
-		user.Jf = user.Jf.Add(score)
-		user.JfTime = time.Now()
-
-		if err := ctx.Ctx.DB.Save(&user).Error; err != nil {
-			fmt.Printf("更新用户积分失败: %v\n", err)
-			return
-		}
-	}
-
-	fmt.Printf("用户 %s 积分更新成功，当前积分: %s\n", userAddress, user.Jf.String())
-}
-
 // getUserRewards 获取用户收益（从积分信息计算）
 func (s *StakeService) getUserRewards(userAddress string, chainId int64) (float64, error) {
 	var user model.Users
@@ -486,21 +405,3 @@ func (s *StakeService) getUserRewards(userAddress string, chainId int64) (float6
 	reward, _ := totalRewards.Float64()
 	return reward, nil
 }
-
-// checkTokenBalance 检查代币余额
-func (s *StakeService) checkTokenBalance(client *ethclient.Client, userAddress, token string) (*big.Int, error) {
-	// 创建ERC20合约实例
-	erc20Address := common.HexToAddress(s.erc20ContractAddress)
-	erc20Contract, err := abi.NewAbi(erc20Address, client)
-	if err != nil {
-		return nil, fmt.Errorf("创建ERC20合约实例失败: %v", err)
-	}
-
-	// 查询用户余额
-	balance, err := erc20Contract.BalanceOf(&bind.CallOpts{}, common.HexToAddress(userAddress))
-	if err != nil {
-		return nil, fmt.Errorf("查询余额失败: %v", err)
-	}
-
-	return balance, nil
-}
@@ -0,0 +1,663 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mumu/cryptoSwap/src/app/api/dto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/contract"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const stakingAdminPoolNameMaxLen = 64
+
+var (
+	// ErrStakingAdminInvalidParam 管理操作参数错误
+	ErrStakingAdminInvalidParam = errors.New("质押管理参数无效")
+	// ErrStakingAdminForbidden 调用方无权执行管理操作
+	ErrStakingAdminForbidden = errors.New("无权执行质押管理操作")
+	// ErrStakingAdminNotFound 管理操作记录不存在
+	ErrStakingAdminNotFound = errors.New("质押管理操作不存在")
+)
+
+// stakingRoles 可授权的角色，DEFAULT_ADMIN_ROLE 为 0x00
+var stakingRoles = map[string][32]byte{
+	"DEFAULT_ADMIN_ROLE": {},
+	"ADMIN_ROLE":         crypto.Keccak256Hash([]byte("ADMIN_ROLE")),
+	"UPGRADER_ROLE":      crypto.Keccak256Hash([]byte("UPGRADER_ROLE")),
+}
+
+// StakingAdminAddPoolInput 新建质押池参数
+type StakingAdminAddPoolInput struct {
+	ChainId               int64  `json:"chainId" binding:"required"`
+	Name                  string `json:"name" binding:"required"`
+	TokenAddress          string `json:"tokenAddress"`          // 零地址表示原生币池子
+	LockDuration          int64  `json:"lockDuration"`          // 锁定时长（秒）
+	OracleDataFeedAddress string `json:"oracleDataFeedAddress"` // Chainlink 价格源，可为零地址
+	SharePrice            string `json:"sharePrice"`            // 初始份额价格（十进制，按 1e18 精度上链）
+	FeeRatio              int64  `json:"feeRatio"`
+	DryRun                bool   `json:"dryRun"` // 仅通过 eth_call 模拟，不发送交易
+}
+
+// StakingAdminPoolActiveInput 启用/停用质押池参数
+type StakingAdminPoolActiveInput struct {
+	ChainId  int64 `json:"chainId" binding:"required"`
+	IsActive *bool `json:"isActive" binding:"required"`
+	DryRun   bool  `json:"dryRun"`
+}
+
+// StakingAdminPauseInput 暂停/恢复合约参数
+type StakingAdminPauseInput struct {
+	ChainId int64 `json:"chainId" binding:"required"`
+	DryRun  bool  `json:"dryRun"`
+}
+
+// StakingAdminRoleInput 授予/撤销角色参数
+type StakingAdminRoleInput struct {
+	ChainId int64  `json:"chainId" binding:"required"`
+	Role    string `json:"role" binding:"required"` // ADMIN_ROLE、UPGRADER_ROLE、DEFAULT_ADMIN_ROLE
+	Account string `json:"account" binding:"required"`
+	DryRun  bool   `json:"dryRun"`
+}
+
+// StakingAdminService 封装 StakeV2 管理方法：校验参数与权限、eth_call 模拟、发送交易并记录审计，
+// 交易确认后按已索引的事件对账
+type StakingAdminService struct{}
+
+func NewStakingAdminService() *StakingAdminService {
+	return &StakingAdminService{}
+}
+
+// stakingAdminCall 一次待执行的合约调用
+type stakingAdminCall struct {
+	operator string
+	chainId  int64
+	action   string
+	params   interface{}
+	dryRun   bool
+	method   string
+	args     []interface{}
+	// authRole 调用方需持有的链上角色，默认 ADMIN_ROLE
+	authRole *[32]byte
+}
+
+// encode 打包 StakeV2 调用数据
+func (call stakingAdminCall) encode() ([]byte, error) {
+	stakeAbi, err := contract.AbiMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := stakeAbi.Pack(call.method, call.args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 打包调用数据失败: %v", ErrStakingAdminInvalidParam, err)
+	}
+	return data, nil
+}
+
+// AddPool 新建质押池
+func (s *StakingAdminService) AddPool(operator string, in StakingAdminAddPoolInput) (*model.StakingAdminAction, error) {
+	call, err := s.addPoolCall(in)
+	if err != nil {
+		return nil, err
+	}
+	call.operator = operator
+	return s.execute(call)
+}
+
+// addPoolCall 校验 addPool 参数
+func (s *StakingAdminService) addPoolCall(in StakingAdminAddPoolInput) (stakingAdminCall, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" || len([]rune(in.Name)) > stakingAdminPoolNameMaxLen {
+		return stakingAdminCall{}, fmt.Errorf("%w: 池子名称不能为空且不超过 %d 个字符", ErrStakingAdminInvalidParam, stakingAdminPoolNameMaxLen)
+	}
+	token, err := optionalAddress(in.TokenAddress, "代币地址")
+	if err != nil {
+		return stakingAdminCall{}, err
+	}
+	oracle, err := optionalAddress(in.OracleDataFeedAddress, "预言机地址")
+	if err != nil {
+		return stakingAdminCall{}, err
+	}
+	if in.LockDuration < 0 {
+		return stakingAdminCall{}, fmt.Errorf("%w: 锁定时长不能为负数", ErrStakingAdminInvalidParam)
+	}
+	if in.FeeRatio < 0 {
+		return stakingAdminCall{}, fmt.Errorf("%w: 手续费比例不能为负数", ErrStakingAdminInvalidParam)
+	}
+	price, err := decimal.NewFromString(strings.TrimSpace(in.SharePrice))
+	if err != nil || !price.IsPositive() {
+		return stakingAdminCall{}, fmt.Errorf("%w: 份额价格必须大于0", ErrStakingAdminInvalidParam)
+	}
+	if price.Exponent() < -sharePriceDecimals {
+		return stakingAdminCall{}, fmt.Errorf("%w: 份额价格精度超过 %d 位", ErrStakingAdminInvalidParam, sharePriceDecimals)
+	}
+	in.TokenAddress = strings.ToLower(token.Hex())
+	in.OracleDataFeedAddress = strings.ToLower(oracle.Hex())
+
+	return stakingAdminCall{
+		chainId: in.ChainId,
+		action:  model.StakingAdminAddPool,
+		params:  in,
+		dryRun:  in.DryRun,
+		method:  "addPool",
+		args: []interface{}{in.Name, token, big.NewInt(in.LockDuration), oracle,
+			price.Shift(sharePriceDecimals).BigInt(), big.NewInt(in.FeeRatio)},
+	}, nil
+}
+
+// SetPoolActive 启用或停用质押池，池子需已存在
+func (s *StakingAdminService) SetPoolActive(operator string, poolId int64, in StakingAdminPoolActiveInput) (*model.StakingAdminAction, error) {
+	call, err := s.setPoolActiveCall(poolId, in)
+	if err != nil {
+		return nil, err
+	}
+	call.operator = operator
+	return s.execute(call)
+}
+
+// setPoolActiveCall 校验池子存在并构造 setPoolActive 调用
+func (s *StakingAdminService) setPoolActiveCall(poolId int64, in StakingAdminPoolActiveInput) (stakingAdminCall, error) {
+	if in.IsActive == nil {
+		return stakingAdminCall{}, fmt.Errorf("%w: 缺少启用状态", ErrStakingAdminInvalidParam)
+	}
+	if poolId < 0 {
+		return stakingAdminCall{}, fmt.Errorf("%w: 无效的池子ID", ErrStakingAdminInvalidParam)
+	}
+	stakeContract, err := stakingContractOf(in.ChainId)
+	if err != nil {
+		return stakingAdminCall{}, err
+	}
+	caller, err := contract.NewAbiCaller(stakeContract, ctx.GetEvmClient(int(in.ChainId)))
+	if err != nil {
+		return stakingAdminCall{}, err
+	}
+	pool, err := caller.Pools(&bind.CallOpts{Context: context.Background()}, big.NewInt(poolId))
+	if err != nil {
+		return stakingAdminCall{}, fmt.Errorf("查询质押池失败: %v", err)
+	}
+	if pool.Id == nil || pool.Id.Int64() != poolId || (pool.TokenAddress == common.Address{} && pool.PoolName == "") {
+		return stakingAdminCall{}, fmt.Errorf("%w: 质押池 %d 不存在", ErrStakingAdminInvalidParam, poolId)
+	}
+
+	return stakingAdminCall{
+		chainId: in.ChainId,
+		action:  model.StakingAdminSetPoolActive,
+		params: map[string]interface{}{
+			"chainId":  in.ChainId,
+			"poolId":   poolId,
+			"isActive": *in.IsActive,
+			"dryRun":   in.DryRun,
+		},
+		dryRun: in.DryRun,
+		method: "setPoolActive",
+		args:   []interface{}{big.NewInt(poolId), *in.IsActive},
+	}, nil
+}
+
+// Pause 暂停合约
+func (s *StakingAdminService) Pause(operator string, in StakingAdminPauseInput) (*model.StakingAdminAction, error) {
+	call := s.pauseCall(in, model.StakingAdminPause, "pause")
+	call.operator = operator
+	return s.execute(call)
+}
+
+// Unpause 恢复合约
+func (s *StakingAdminService) Unpause(operator string, in StakingAdminPauseInput) (*model.StakingAdminAction, error) {
+	call := s.pauseCall(in, model.StakingAdminUnpause, "unpause")
+	call.operator = operator
+	return s.execute(call)
+}
+
+func (s *StakingAdminService) pauseCall(in StakingAdminPauseInput, action, method string) stakingAdminCall {
+	return stakingAdminCall{
+		chainId: in.ChainId,
+		action:  action,
+		params:  in,
+		dryRun:  in.DryRun,
+		method:  method,
+	}
+}
+
+// GrantRole 授予角色，调用方需持有该角色的管理角色
+func (s *StakingAdminService) GrantRole(operator string, in StakingAdminRoleInput) (*model.StakingAdminAction, error) {
+	call, err := s.roleCall(in, model.StakingAdminGrantRole, "grantRole")
+	if err != nil {
+		return nil, err
+	}
+	call.operator = operator
+	return s.execute(call)
+}
+
+// RevokeRole 撤销角色，调用方需持有该角色的管理角色
+func (s *StakingAdminService) RevokeRole(operator string, in StakingAdminRoleInput) (*model.StakingAdminAction, error) {
+	call, err := s.roleCall(in, model.StakingAdminRevokeRole, "revokeRole")
+	if err != nil {
+		return nil, err
+	}
+	call.operator = operator
+	return s.execute(call)
+}
+
+// roleCall 校验角色与账户，并查询该角色的管理角色作为调用方所需权限
+func (s *StakingAdminService) roleCall(in StakingAdminRoleInput, action, method string) (stakingAdminCall, error) {
+	in.Role = strings.ToUpper(strings.TrimSpace(in.Role))
+	role, ok := stakingRoles[in.Role]
+	if !ok {
+		return stakingAdminCall{}, fmt.Errorf("%w: 未知角色 %s", ErrStakingAdminInvalidParam, in.Role)
+	}
+	if !common.IsHexAddress(in.Account) {
+		return stakingAdminCall{}, fmt.Errorf("%w: 无效的账户地址: %s", ErrStakingAdminInvalidParam, in.Account)
+	}
+	account := common.HexToAddress(in.Account)
+	if (account == common.Address{}) {
+		return stakingAdminCall{}, fmt.Errorf("%w: 账户地址不能为零地址", ErrStakingAdminInvalidParam)
+	}
+	in.Account = strings.ToLower(account.Hex())
+
+	stakeContract, err := stakingContractOf(in.ChainId)
+	if err != nil {
+		return stakingAdminCall{}, err
+	}
+	caller, err := contract.NewAbiCaller(stakeContract, ctx.GetEvmClient(int(in.ChainId)))
+	if err != nil {
+		return stakingAdminCall{}, err
+	}
+	adminRole, err := caller.GetRoleAdmin(&bind.CallOpts{Context: context.Background()}, role)
+	if err != nil {
+		return stakingAdminCall{}, fmt.Errorf("查询角色管理员失败: %v", err)
+	}
+
+	return stakingAdminCall{
+		chainId:  in.ChainId,
+		action:   action,
+		params:   in,
+		dryRun:   in.DryRun,
+		method:   method,
+		args:     []interface{}{role, account},
+		authRole: &adminRole,
+	}, nil
+}
+
+// optionalAddress 校验可为空的地址参数，空值视为零地址
+func optionalAddress(s, name string) (common.Address, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return common.Address{}, nil
+	}
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("%w: 无效的%s: %s", ErrStakingAdminInvalidParam, name, s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+// stakingContractOf chain 表中配置的质押合约地址
+func stakingContractOf(chainId int64) (common.Address, error) {
+	var chain model.Chain
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND service_type = ? AND address <> ''", chainId, ChainServiceStaking).First(&chain).Error; err != nil {
+		return common.Address{}, fmt.Errorf("%w: 链 %d 未配置质押合约", ErrStakingAdminInvalidParam, chainId)
+	}
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return common.Address{}, fmt.Errorf("%w: 无法获取链ID为 %d 的以太坊客户端", ErrStakingAdminInvalidParam, chainId)
+	}
+	return common.HexToAddress(chain.Address), nil
+}
+
+// authorize 校验调用方：配置了白名单时需在白名单内，且需在链上持有所需角色
+func (s *StakingAdminService) authorize(caller *contract.AbiCaller, operator common.Address, role [32]byte) error {
+	if admins := config.Conf.StakingAdmin.Admins; len(admins) > 0 {
+		allowed := false
+		for _, a := range admins {
+			if strings.EqualFold(strings.TrimSpace(a), operator.Hex()) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s 不在管理员白名单内", ErrStakingAdminForbidden, operator.Hex())
+		}
+	}
+	has, err := caller.HasRole(&bind.CallOpts{Context: context.Background()}, role, operator)
+	if err != nil {
+		return fmt.Errorf("查询链上角色失败: %v", err)
+	}
+	if !has {
+		return fmt.Errorf("%w: %s 未持有所需的链上角色", ErrStakingAdminForbidden, operator.Hex())
+	}
+	return nil
+}
+
+// execute 校验权限、打包调用数据并通过 eth_call 模拟；非模拟模式下记录审计后异步发送交易
+func (s *StakingAdminService) execute(call stakingAdminCall) (*model.StakingAdminAction, error) {
+	if !config.Conf.StakingAdmin.Enabled {
+		return nil, fmt.Errorf("%w: 质押管理接口未启用", ErrStakingAdminForbidden)
+	}
+	if !common.IsHexAddress(call.operator) {
+		return nil, fmt.Errorf("%w: 无效的登录地址", ErrStakingAdminForbidden)
+	}
+	operator := common.HexToAddress(call.operator)
+
+	stakeContract, err := stakingContractOf(call.chainId)
+	if err != nil {
+		return nil, err
+	}
+	client := ctx.GetEvmClient(int(call.chainId))
+	caller, err := contract.NewAbiCaller(stakeContract, client)
+	if err != nil {
+		return nil, err
+	}
+	role := stakingRoles["ADMIN_ROLE"]
+	if call.authRole != nil {
+		role = *call.authRole
+	}
+	if err := s.authorize(caller, operator, role); err != nil {
+		return nil, err
+	}
+
+	// 服务端发送账户；未配置时只能模拟，模拟以调用方身份执行
+	var txm *TxManager
+	if name := strings.TrimSpace(config.Conf.StakingAdmin.Signer); name != "" {
+		if txm, err = NewTxManagerByName(name); err != nil {
+			return nil, err
+		}
+	} else if !call.dryRun {
+		return nil, fmt.Errorf("%w: 未配置 staking_admin.signer，仅支持模拟", ErrStakingAdminInvalidParam)
+	}
+	from := operator
+	if txm != nil {
+		from = txm.From()
+	}
+
+	data, err := call.encode()
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(call.params)
+	if err != nil {
+		return nil, err
+	}
+
+	action := &model.StakingAdminAction{
+		ChainId:         call.chainId,
+		ContractAddress: strings.ToLower(stakeContract.Hex()),
+		Action:          call.action,
+		Params:          string(params),
+		Operator:        strings.ToLower(operator.Hex()),
+		Sender:          strings.ToLower(from.Hex()),
+		DryRun:          call.dryRun,
+		CallData:        hexutil.Encode(data),
+	}
+	if _, err := client.CallContract(context.Background(), ethereum.CallMsg{From: from, To: &stakeContract, Data: data}, nil); err != nil {
+		action.Status = model.StakingAdminStatusSimulationFailed
+		action.Error = err.Error()
+	} else if call.dryRun {
+		action.Status = model.StakingAdminStatusSimulated
+	} else {
+		action.Status = model.StakingAdminStatusSubmitting
+	}
+	if err := ctx.Ctx.DB.Create(action).Error; err != nil {
+		return nil, fmt.Errorf("保存质押管理操作失败: %v", err)
+	}
+	log.Logger.Info("质押管理操作", zap.Int64("id", action.Id), zap.String("action", action.Action),
+		zap.String("operator", action.Operator), zap.Bool("dry_run", action.DryRun), zap.String("status", action.Status))
+
+	if action.Status == model.StakingAdminStatusSubmitting {
+		s.submit(txm, action, stakeContract, data)
+	}
+	return action, nil
+}
+
+// submit 通过托管交易发送，回执由托管交易监控跟踪，对账任务据此更新状态
+func (s *StakingAdminService) submit(txm *TxManager, action *model.StakingAdminAction, to common.Address, data []byte) {
+	updates := map[string]interface{}{}
+	tx, err := txm.Submit(TxRequest{ChainId: action.ChainId, To: to, Data: data, Purpose: "stake.admin." + action.Action})
+	if err != nil {
+		log.Logger.Error("质押管理交易发送失败", zap.Int64("id", action.Id), zap.Error(err))
+		action.Status = model.StakingAdminStatusFailed
+		action.Error = err.Error()
+		updates["status"] = action.Status
+		updates["error"] = action.Error
+	} else {
+		action.ManagedTxId = &tx.Id
+		action.TxHash = tx.TxHash
+		updates["managed_tx_id"] = tx.Id
+		updates["tx_hash"] = tx.TxHash
+	}
+	if err := ctx.Ctx.DB.Model(&model.StakingAdminAction{}).Where("id = ?", action.Id).Updates(updates).Error; err != nil {
+		log.Logger.Error("更新质押管理操作失败", zap.Int64("id", action.Id), zap.Error(err))
+	}
+}
+
+// syncSubmitting 按托管交易的终态更新发送中的操作
+func (s *StakingAdminService) syncSubmitting() error {
+	var actions []model.StakingAdminAction
+	if err := ctx.Ctx.DB.Where("status = ? AND managed_tx_id IS NOT NULL", model.StakingAdminStatusSubmitting).
+		Order("id ASC").Limit(100).Find(&actions).Error; err != nil {
+		return err
+	}
+	txSvc := NewManagedTxService()
+	for _, action := range actions {
+		tx, err := txSvc.Get(*action.ManagedTxId)
+		if err != nil {
+			log.Logger.Warn("查询托管交易失败", zap.Int64("id", action.Id), zap.Error(err))
+			continue
+		}
+		updates := map[string]interface{}{"tx_hash": tx.TxHash}
+		switch tx.Status {
+		case model.ManagedTxConfirmed:
+			updates["status"] = model.StakingAdminStatusConfirmed
+		case model.ManagedTxReverted:
+			updates["status"] = model.StakingAdminStatusReverted
+			updates["error"] = tx.Error
+		case model.ManagedTxCancelled, model.ManagedTxFailed:
+			updates["status"] = model.StakingAdminStatusFailed
+			updates["error"] = fmt.Sprintf("托管交易 %s: %s", tx.Status, tx.Error)
+		default:
+			if tx.TxHash == action.TxHash {
+				continue
+			}
+		}
+		if err := ctx.Ctx.DB.Model(&model.StakingAdminAction{}).Where("id = ?", action.Id).Updates(updates).Error; err != nil {
+			log.Logger.Error("更新质押管理操作失败", zap.Int64("id", action.Id), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// CheckOperator 查询审计记录前校验调用方：与管理操作相同，需在白名单内且在该链持有 ADMIN_ROLE
+func (s *StakingAdminService) CheckOperator(operator string, chainId int64) error {
+	if !config.Conf.StakingAdmin.Enabled {
+		return fmt.Errorf("%w: 质押管理接口未启用", ErrStakingAdminForbidden)
+	}
+	if !common.IsHexAddress(operator) {
+		return fmt.Errorf("%w: 无效的登录地址", ErrStakingAdminForbidden)
+	}
+	stakeContract, err := stakingContractOf(chainId)
+	if err != nil {
+		return err
+	}
+	caller, err := contract.NewAbiCaller(stakeContract, ctx.GetEvmClient(int(chainId)))
+	if err != nil {
+		return err
+	}
+	return s.authorize(caller, common.HexToAddress(operator), stakingRoles["ADMIN_ROLE"])
+}
+
+// ListActions 管理操作审计列表，按时间倒序
+func (s *StakingAdminService) ListActions(operator string, chainId int64, action, status string, pagination dto.Pagination) ([]model.StakingAdminAction, int64, error) {
+	if err := s.CheckOperator(operator, chainId); err != nil {
+		return nil, 0, err
+	}
+	query := ctx.Ctx.DB.Model(&model.StakingAdminAction{}).Where("chain_id = ?", chainId)
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := make([]model.StakingAdminAction, 0)
+	if err := query.Order("id DESC").Offset(pagination.Offset).Limit(pagination.PageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// GetAction 管理操作详情
+func (s *StakingAdminService) GetAction(operator string, id int64) (*model.StakingAdminAction, error) {
+	var action model.StakingAdminAction
+	err := ctx.Ctx.DB.Where("id = ?", id).First(&action).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrStakingAdminNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckOperator(operator, action.ChainId); err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// ReconcileActions 对已确认的管理操作按索引数据核对状态变更，返回完成对账的数量
+func (s *StakingAdminService) ReconcileActions() (int, error) {
+	if err := s.syncSubmitting(); err != nil {
+		return 0, err
+	}
+	var actions []model.StakingAdminAction
+	if err := ctx.Ctx.DB.Where("status = ? AND tx_hash <> ''", model.StakingAdminStatusConfirmed).
+		Order("id ASC").Limit(100).Find(&actions).Error; err != nil {
+		return 0, err
+	}
+	reconciled := 0
+	for i := range actions {
+		action := &actions[i]
+		res, ok, err := s.reconcile(action)
+		if err != nil {
+			log.Logger.Warn("质押管理操作对账失败", zap.Int64("id", action.Id), zap.Error(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		now := time.Now()
+		if err := ctx.Ctx.DB.Model(&model.StakingAdminAction{}).Where("id = ?", action.Id).Updates(map[string]interface{}{
+			"status":        model.StakingAdminStatusReconciled,
+			"result":        res,
+			"reconciled_at": now,
+		}).Error; err != nil {
+			log.Logger.Error("更新质押管理操作对账状态失败", zap.Int64("id", action.Id), zap.Error(err))
+			continue
+		}
+		if action.Action == model.StakingAdminAddPool || action.Action == model.StakingAdminSetPoolActive {
+			stakePoolCacheMu.Lock()
+			delete(stakePoolCache, action.ChainId)
+			stakePoolCacheMu.Unlock()
+		}
+		reconciled++
+	}
+	return reconciled, nil
+}
+
+// reconcile 单条操作对账：新建池子匹配 PoolCreated，暂停与角色变更匹配管理事件，启用状态读取合约
+func (s *StakingAdminService) reconcile(action *model.StakingAdminAction) (string, bool, error) {
+	switch action.Action {
+	case model.StakingAdminAddPool:
+		var pool model.StakePool
+		err := ctx.Ctx.DB.Where("chain_id = ? AND contract_address = ? AND tx_hash = ?",
+			action.ChainId, action.ContractAddress, action.TxHash).First(&pool).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return fmt.Sprintf(`{"poolId":%d}`, pool.PoolId), true, nil
+	case model.StakingAdminPause, model.StakingAdminUnpause, model.StakingAdminGrantRole, model.StakingAdminRevokeRole:
+		eventType := map[string]string{
+			model.StakingAdminPause:      "Paused",
+			model.StakingAdminUnpause:    "Unpaused",
+			model.StakingAdminGrantRole:  "RoleGranted",
+			model.StakingAdminRevokeRole: "RoleRevoked",
+		}[action.Action]
+		var count int64
+		if err := ctx.Ctx.DB.Model(&model.StakingAdminEvent{}).Where("chain_id = ? AND contract_address = ? AND tx_hash = ? AND event_type = ?",
+			action.ChainId, action.ContractAddress, action.TxHash, eventType).Count(&count).Error; err != nil {
+			return "", false, err
+		}
+		if count == 0 {
+			// 重复授权/撤销不会产生事件，以链上当前状态为准
+			if action.Action == model.StakingAdminGrantRole || action.Action == model.StakingAdminRevokeRole {
+				return s.reconcileRole(action)
+			}
+			return "", false, nil
+		}
+		return fmt.Sprintf(`{"event":"%s"}`, eventType), true, nil
+	case model.StakingAdminSetPoolActive:
+		var params struct {
+			PoolId   int64 `json:"poolId"`
+			IsActive bool  `json:"isActive"`
+		}
+		if err := json.Unmarshal([]byte(action.Params), &params); err != nil {
+			return "", false, err
+		}
+		caller, err := s.caller(action)
+		if err != nil {
+			return "", false, err
+		}
+		pool, err := caller.Pools(&bind.CallOpts{Context: context.Background()}, big.NewInt(params.PoolId))
+		if err != nil {
+			return "", false, err
+		}
+		if pool.IsActive != params.IsActive {
+			return "", false, nil
+		}
+		return fmt.Sprintf(`{"poolId":%d,"isActive":%t}`, params.PoolId, pool.IsActive), true, nil
+	}
+	return "", false, nil
+}
+
+// reconcileRole 读取链上角色状态核对授权/撤销结果
+func (s *StakingAdminService) reconcileRole(action *model.StakingAdminAction) (string, bool, error) {
+	var params StakingAdminRoleInput
+	if err := json.Unmarshal([]byte(action.Params), &params); err != nil {
+		return "", false, err
+	}
+	caller, err := s.caller(action)
+	if err != nil {
+		return "", false, err
+	}
+	has, err := caller.HasRole(&bind.CallOpts{Context: context.Background()}, stakingRoles[params.Role], common.HexToAddress(params.Account))
+	if err != nil {
+		return "", false, err
+	}
+	if has != (action.Action == model.StakingAdminGrantRole) {
+		return "", false, nil
+	}
+	return fmt.Sprintf(`{"role":"%s","account":"%s","hasRole":%t}`, params.Role, params.Account, has), true, nil
+}
+
+func (s *StakingAdminService) caller(action *model.StakingAdminAction) (*contract.AbiCaller, error) {
+	if ctx.Ctx.ChainMap[int(action.ChainId)] == nil {
+		return nil, fmt.Errorf("无法获取链ID为 %d 的以太坊客户端", action.ChainId)
+	}
+	return contract.NewAbiCaller(common.HexToAddress(action.ContractAddress), ctx.GetEvmClient(int(action.ChainId)))
+}
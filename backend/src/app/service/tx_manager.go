@@ -0,0 +1,731 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/signer"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultTxPollInterval    = 5 * time.Second
+	defaultTxStuckAfter      = 120 * time.Second
+	defaultTxFeeBumpPct      = 15
+	minTxFeeBumpPct          = 10 // 节点接受同 nonce 替换交易的最低费用上浮
+	defaultTxMaxReplacements = 5
+	// defaultTxMaxBroadcastFailures 未能广播的交易重试上限，之后改发取消交易填补 nonce
+	defaultTxMaxBroadcastFailures = 10
+	defaultTxConfirmations        = 3
+	// txClaimLease 监控领取交易后的租期，实例中途退出时租期过后会被重新领取
+	txClaimLease = 60 * time.Second
+	txCancelGas  = 21000
+)
+
+var (
+	// ErrTxReverted 交易已上链但执行失败
+	ErrTxReverted = errors.New("交易执行失败")
+	// ErrTxWouldRevert 预估 gas 失败，交易预计回滚，未分配 nonce
+	ErrTxWouldRevert = errors.New("交易预计回滚")
+	// ErrManagedTxNotFound 托管交易不存在
+	ErrManagedTxNotFound = errors.New("托管交易不存在")
+	// ErrManagedTxInvalidState 托管交易已是终态或发送账户不可用，无法提速或取消
+	ErrManagedTxInvalidState = errors.New("托管交易当前状态不允许该操作")
+	// ErrTxFeeCapReached 最高费用上限内无法满足替换交易的最低上浮
+	ErrTxFeeCapReached = errors.New("已达最高费用上限，无法替换交易")
+	// ErrManagedTxForbidden 调用方无权操作托管交易
+	ErrManagedTxForbidden = errors.New("无权操作托管交易")
+)
+
+// txSigners 已注册的签名账户（小写地址 -> signer.Signer），监控任务据此重签提速与取消交易
+var txSigners sync.Map
+
+// TxRequest 待托管发送的合约调用
+type TxRequest struct {
+	ChainId int64
+	To      common.Address
+	Data    []byte
+	Value   *big.Int
+	Purpose string // 业务用途，写入发件箱便于排查
+}
+
+// TxManager 托管交易发送：持久化发件箱、按发送账户分配 nonce、预估 gas 与 EIP-1559 费用，
+// 回执跟踪与卡住交易替换由 ProcessDue 完成
+type TxManager struct {
+	signer signer.Signer
+	from   common.Address
+}
+
+// NewTxManager 使用签名账户创建发送者，并注册到监控任务
+func NewTxManager(s signer.Signer) *TxManager {
+	RegisterTxSigner(s)
+	return &TxManager{signer: s, from: s.Address()}
+}
+
+// NewTxManagerByName 按 [signers.<name>] 配置加载签名账户并创建发送者
+func NewTxManagerByName(name string) (*TxManager, error) {
+	s, err := signer.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewTxManager(s), nil
+}
+
+// RegisterTxSigner 注册签名账户，重启后未完成的交易可由监控任务继续提速或取消
+func RegisterTxSigner(s signer.Signer) {
+	txSigners.Store(strings.ToLower(s.Address().Hex()), s)
+}
+
+// From 发送账户地址
+func (m *TxManager) From() common.Address {
+	return m.from
+}
+
+func txPollInterval() time.Duration {
+	if sec := config.Conf.TxManager.PollInterval; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultTxPollInterval
+}
+
+func txStuckAfter() time.Duration {
+	if sec := config.Conf.TxManager.StuckAfter; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultTxStuckAfter
+}
+
+func txFeeBumpPct() int64 {
+	pct := config.Conf.TxManager.FeeBumpPct
+	if pct <= 0 {
+		pct = defaultTxFeeBumpPct
+	}
+	if pct < minTxFeeBumpPct {
+		pct = minTxFeeBumpPct
+	}
+	return int64(pct)
+}
+
+func txMaxReplacements() int {
+	if n := config.Conf.TxManager.MaxReplacements; n > 0 {
+		return n
+	}
+	return defaultTxMaxReplacements
+}
+
+func txMaxBroadcastFailures() int {
+	if n := config.Conf.TxManager.MaxBroadcastFailures; n > 0 {
+		return n
+	}
+	return defaultTxMaxBroadcastFailures
+}
+
+func txConfirmations() int64 {
+	if n := config.Conf.TxManager.Confirmations; n > 0 {
+		return int64(n)
+	}
+	return defaultTxConfirmations
+}
+
+// txMaxFeeCap 最高费用上限（wei），未配置时返回 nil
+func txMaxFeeCap() *big.Int {
+	if gwei := config.Conf.TxManager.MaxFeeGwei; gwei > 0 {
+		return new(big.Int).Mul(big.NewInt(gwei), big.NewInt(params.GWei))
+	}
+	return nil
+}
+
+// txFeeClient 估算 EIP-1559 费用所需的节点接口
+type txFeeClient interface {
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// txSendClient 广播托管交易所需的节点接口，*ethclient.Client 实现该接口
+type txSendClient interface {
+	txFeeClient
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// managedTxStore 广播过程中对发件箱的写入
+type managedTxStore interface {
+	CreateAttempt(attempt *model.ManagedTxAttempt) error
+	UpdateTx(id int64, updates map[string]interface{}) error
+}
+
+type dbManagedTxStore struct{}
+
+func (dbManagedTxStore) CreateAttempt(attempt *model.ManagedTxAttempt) error {
+	return ctx.Ctx.DB.Create(attempt).Error
+}
+
+func (dbManagedTxStore) UpdateTx(id int64, updates map[string]interface{}) error {
+	return ctx.Ctx.DB.Model(&model.ManagedTx{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func txClient(chainId int64) (*ethclient.Client, error) {
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return nil, fmt.Errorf("无法获取链ID为 %d 的以太坊客户端", chainId)
+	}
+	return ctx.GetEvmClient(int(chainId)), nil
+}
+
+// Submit 预估 gas、分配 nonce 并写入发件箱后立即广播。预计回滚时返回 ErrTxWouldRevert；
+// 广播失败时交易保持 pending 由监控任务重试，超过 max_broadcast_failures 后以取消交易填补 nonce，
+// 调用方按返回的 ID 查询结果
+func (m *TxManager) Submit(req TxRequest) (*model.ManagedTx, error) {
+	client, err := txClient(req.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	bg := context.Background()
+	value := req.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	gas, err := client.EstimateGas(bg, ethereum.CallMsg{From: m.from, To: &req.To, Data: req.Data, Value: value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTxWouldRevert, err)
+	}
+	gas = gas * (100 + stakeGasBufferPct) / 100
+	tip, maxFee, err := suggestTxFees(bg, client, req.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	chainNonce, err := client.PendingNonceAt(bg, m.from)
+	if err != nil {
+		return nil, fmt.Errorf("获取nonce失败: %v", err)
+	}
+
+	from := strings.ToLower(m.from.Hex())
+	record := &model.ManagedTx{
+		ChainId:              req.ChainId,
+		FromAddress:          from,
+		ToAddress:            strings.ToLower(req.To.Hex()),
+		Data:                 hexutil.Encode(req.Data),
+		Value:                value.String(),
+		GasLimit:             int64(gas),
+		MaxFeePerGas:         maxFee.String(),
+		MaxPriorityFeePerGas: tip.String(),
+		Purpose:              req.Purpose,
+		Status:               model.ManagedTxPending,
+		NextCheckAt:          time.Now().Add(txPollInterval()),
+	}
+	// 在同一事务内锁定发送账户的 nonce 行并写入发件箱；链上 pending nonce 更大时说明有外部交易，跳到链上值
+	err = ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.ManagedTxNonce{
+			ChainId: req.ChainId, Address: from, NextNonce: int64(chainNonce),
+		}).Error; err != nil {
+			return err
+		}
+		var row model.ManagedTxNonce
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("chain_id = ? AND address = ?", req.ChainId, from).First(&row).Error; err != nil {
+			return err
+		}
+		record.Nonce = row.NextNonce
+		if int64(chainNonce) > record.Nonce {
+			record.Nonce = int64(chainNonce)
+		}
+		if err := tx.Model(&model.ManagedTxNonce{}).Where("chain_id = ? AND address = ?", req.ChainId, from).
+			Update("next_nonce", record.Nonce+1).Error; err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("写入托管交易失败: %v", err)
+	}
+
+	if err := broadcastManagedTx(dbManagedTxStore{}, client, m.signer, record, model.ManagedTxAttemptInitial, tip, maxFee); err != nil {
+		log.Logger.Warn("托管交易广播失败，等待重试", zap.Int64("id", record.Id), zap.Error(err))
+	}
+	return record, nil
+}
+
+// Wait 轮询发件箱直到交易进入终态或 bg 结束；交易执行失败时返回 ErrTxReverted
+func (m *TxManager) Wait(bg context.Context, id int64) (*model.ManagedTx, error) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		record, err := NewManagedTxService().Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if record.Final() {
+			switch record.Status {
+			case model.ManagedTxConfirmed:
+				return record, nil
+			case model.ManagedTxReverted:
+				return record, ErrTxReverted
+			default:
+				return record, fmt.Errorf("托管交易 %d 未执行: %s %s", id, record.Status, record.Error)
+			}
+		}
+		select {
+		case <-bg.Done():
+			return record, fmt.Errorf("等待托管交易 %d 回执超时，交易仍在跟踪中", id)
+		case <-ticker.C:
+		}
+	}
+}
+
+// suggestTxFees EIP-1559 费用：小费取节点建议值，最高费用为 2 倍基础费用加小费，不超过 max_fee_gwei
+func suggestTxFees(bg context.Context, client txFeeClient, chainId int64) (*big.Int, *big.Int, error) {
+	tip, err := client.SuggestGasTipCap(bg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取建议小费失败: %v", err)
+	}
+	head, err := client.HeaderByNumber(bg, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取最新区块失败: %v", err)
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("链 %d 不支持 EIP-1559", chainId)
+	}
+	maxFee := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	if feeCap := txMaxFeeCap(); feeCap != nil && maxFee.Cmp(feeCap) > 0 {
+		maxFee = new(big.Int).Set(feeCap)
+	}
+	if tip.Cmp(maxFee) > 0 {
+		tip = new(big.Int).Set(maxFee)
+	}
+	return tip, maxFee, nil
+}
+
+// bumpTxFees 替换交易的费用：在原费用上浮配置比例，且不低于当前建议费用；
+// 受 max_fee_gwei 限制后小费或最高费用不足原值的 110% 时节点会拒绝替换，返回 ErrTxFeeCapReached
+func bumpTxFees(bg context.Context, client txFeeClient, record *model.ManagedTx) (*big.Int, *big.Int, error) {
+	tip, maxFee, err := suggestTxFees(bg, client, record.ChainId)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldTip, oldMaxFee := parseBigInt(record.MaxPriorityFeePerGas), parseBigInt(record.MaxFeePerGas)
+	if b := bumpFee(oldTip, txFeeBumpPct()); b.Cmp(tip) > 0 {
+		tip = b
+	}
+	if b := bumpFee(oldMaxFee, txFeeBumpPct()); b.Cmp(maxFee) > 0 {
+		maxFee = b
+	}
+	if maxFee.Cmp(tip) < 0 {
+		maxFee = new(big.Int).Set(tip)
+	}
+	feeCap := txMaxFeeCap()
+	if feeCap != nil && maxFee.Cmp(feeCap) > 0 {
+		maxFee = new(big.Int).Set(feeCap)
+		if tip.Cmp(maxFee) > 0 {
+			tip = new(big.Int).Set(maxFee)
+		}
+	}
+	if tip.Cmp(bumpFee(oldTip, minTxFeeBumpPct)) < 0 || maxFee.Cmp(bumpFee(oldMaxFee, minTxFeeBumpPct)) < 0 {
+		return nil, nil, fmt.Errorf("%w: 上限 %s wei，原最高费用 %s wei", ErrTxFeeCapReached, feeCap, oldMaxFee)
+	}
+	return tip, maxFee, nil
+}
+
+// bumpFee 按比例上浮并向上取整，节点以 new*100 >= old*(100+pct) 判断替换费用
+func bumpFee(old *big.Int, pct int64) *big.Int {
+	v := new(big.Int).Mul(old, big.NewInt(100+pct))
+	v.Add(v, big.NewInt(99))
+	return v.Div(v, big.NewInt(100))
+}
+
+// signManagedTx 按发件箱记录签名；取消交易向自身转账 0 以占用同一 nonce
+func signManagedTx(txSigner signer.Signer, record *model.ManagedTx, kind string, tip, maxFee *big.Int) (*types.Transaction, error) {
+	to := common.HexToAddress(record.ToAddress)
+	data := common.FromHex(record.Data)
+	value := parseBigInt(record.Value)
+	gas := uint64(record.GasLimit)
+	if kind == model.ManagedTxAttemptCancel {
+		to = common.HexToAddress(record.FromAddress)
+		data = nil
+		value = big.NewInt(0)
+		gas = txCancelGas
+	}
+	chainId := big.NewInt(record.ChainId)
+	signed, err := txSigner.SignTx(context.Background(), types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     uint64(record.Nonce),
+		GasTipCap: tip,
+		GasFeeCap: maxFee,
+		Gas:       gas,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	}), chainId)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %v", err)
+	}
+	return signed, nil
+}
+
+// broadcastManagedTx 签名并广播一次。广播记录在发送前写入，发送后即使更新发件箱失败，
+// 回执检查仍能按该哈希找到结果
+func broadcastManagedTx(store managedTxStore, client txSendClient, txSigner signer.Signer, record *model.ManagedTx, kind string, tip, maxFee *big.Int) error {
+	signed, err := signManagedTx(txSigner, record, kind, tip, maxFee)
+	if err != nil {
+		return err
+	}
+
+	attempt := &model.ManagedTxAttempt{
+		TxId:                 record.Id,
+		Kind:                 kind,
+		TxHash:               signed.Hash().Hex(),
+		GasLimit:             int64(signed.Gas()),
+		MaxFeePerGas:         maxFee.String(),
+		MaxPriorityFeePerGas: tip.String(),
+	}
+	if err := store.CreateAttempt(attempt); err != nil {
+		return fmt.Errorf("保存广播记录失败: %v", err)
+	}
+	// 发送报错时交易仍可能已进入节点交易池，保留广播记录以便按哈希查询回执
+	if err := client.SendTransaction(context.Background(), signed); err != nil && !strings.Contains(err.Error(), "already known") {
+		record.BroadcastFailures++
+		record.Error = "发送交易失败: " + err.Error()
+		store.UpdateTx(record.Id, map[string]interface{}{
+			"broadcast_failures": record.BroadcastFailures,
+			"error":              record.Error,
+		})
+		return fmt.Errorf("发送交易失败: %v", err)
+	}
+
+	now := time.Now()
+	record.Status = model.ManagedTxSubmitted
+	record.TxHash = attempt.TxHash
+	record.Attempts++
+	record.MaxFeePerGas = attempt.MaxFeePerGas
+	record.MaxPriorityFeePerGas = attempt.MaxPriorityFeePerGas
+	record.LastBroadcastAt = &now
+	record.NextCheckAt = now.Add(txPollInterval())
+	record.Error = ""
+	err = store.UpdateTx(record.Id, map[string]interface{}{
+		"status":                   record.Status,
+		"tx_hash":                  record.TxHash,
+		"attempts":                 record.Attempts,
+		"max_fee_per_gas":          record.MaxFeePerGas,
+		"max_priority_fee_per_gas": record.MaxPriorityFeePerGas,
+		"last_broadcast_at":        now,
+		"next_check_at":            record.NextCheckAt,
+		"error":                    "",
+	})
+	if err != nil {
+		log.Logger.Error("保存托管交易广播状态失败", zap.Int64("id", record.Id), zap.String("tx_hash", attempt.TxHash), zap.Error(err))
+	}
+	log.Logger.Info("托管交易已广播", zap.Int64("id", record.Id), zap.Int64("chain_id", record.ChainId), zap.String("kind", kind),
+		zap.String("from", record.FromAddress), zap.Int64("nonce", record.Nonce), zap.String("tx_hash", attempt.TxHash))
+	return nil
+}
+
+// ManagedTxService 托管交易查询、手动提速/取消与回执跟踪
+type ManagedTxService struct {
+	store managedTxStore
+}
+
+func NewManagedTxService() *ManagedTxService {
+	return &ManagedTxService{store: dbManagedTxStore{}}
+}
+
+// Get 托管交易详情
+func (s *ManagedTxService) Get(id int64) (*model.ManagedTx, error) {
+	var record model.ManagedTx
+	err := ctx.Ctx.DB.Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrManagedTxNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListAttempts 托管交易的广播记录
+func (s *ManagedTxService) ListAttempts(id int64) ([]model.ManagedTxAttempt, error) {
+	list := make([]model.ManagedTxAttempt, 0)
+	err := ctx.Ctx.DB.Where("tx_id = ?", id).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// CheckOperator 手动提速、取消需在 tx_manager.admins 白名单内
+func (s *ManagedTxService) CheckOperator(operator string) error {
+	for _, a := range config.Conf.TxManager.Admins {
+		if strings.EqualFold(strings.TrimSpace(a), operator) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s 不在托管交易管理员白名单内", ErrManagedTxForbidden, operator)
+}
+
+// SpeedUp 以更高费用重发同一 nonce 的交易
+func (s *ManagedTxService) SpeedUp(operator string, id int64) (*model.ManagedTx, error) {
+	return s.replace(operator, id, model.ManagedTxAttemptSpeedUp)
+}
+
+// Cancel 以更高费用向自身转账 0 占用同一 nonce，原交易不再上链
+func (s *ManagedTxService) Cancel(operator string, id int64) (*model.ManagedTx, error) {
+	return s.replace(operator, id, model.ManagedTxAttemptCancel)
+}
+
+func (s *ManagedTxService) replace(operator string, id int64, kind string) (*model.ManagedTx, error) {
+	if err := s.CheckOperator(operator); err != nil {
+		return nil, err
+	}
+	record, err := s.claim(id)
+	if err != nil {
+		return nil, err
+	}
+	defer s.release(id)
+	txSigner, ok := txSigners.Load(record.FromAddress)
+	if !ok {
+		return nil, fmt.Errorf("%w: 发送账户 %s 未注册", ErrManagedTxInvalidState, record.FromAddress)
+	}
+	client, err := txClient(record.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	tip, maxFee, err := bumpTxFees(context.Background(), client, record)
+	if err != nil {
+		return nil, err
+	}
+	if kind == model.ManagedTxAttemptCancel {
+		if err := s.store.UpdateTx(id, map[string]interface{}{"cancel_requested": true}); err != nil {
+			return nil, err
+		}
+		record.CancelRequested = true
+	}
+	if err := broadcastManagedTx(s.store, client, txSigner.(signer.Signer), record, kind, tip, maxFee); err != nil {
+		return nil, err
+	}
+	log.Logger.Info("托管交易已手动替换", zap.Int64("id", id), zap.String("kind", kind), zap.String("operator", operator))
+	return record, nil
+}
+
+// claim 锁定交易行并取得与 ProcessDue 相同的租约，监控任务处理中的交易返回 ErrManagedTxInvalidState，
+// 避免手动替换与自动提速同时广播同一 nonce
+func (s *ManagedTxService) claim(id int64) (*model.ManagedTx, error) {
+	var record model.ManagedTx
+	err := ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&record).Error; err != nil {
+			return err
+		}
+		if record.Final() {
+			return fmt.Errorf("%w: 交易已是 %s", ErrManagedTxInvalidState, record.Status)
+		}
+		if record.ClaimedUntil != nil && record.ClaimedUntil.After(time.Now()) {
+			return fmt.Errorf("%w: 交易正在被处理，请稍后重试", ErrManagedTxInvalidState)
+		}
+		until := time.Now().Add(txClaimLease)
+		record.ClaimedUntil = &until
+		return tx.Model(&model.ManagedTx{}).Where("id = ?", id).Update("claimed_until", until).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrManagedTxNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// release 释放租约，未进入终态的交易按轮询间隔再次检查
+func (s *ManagedTxService) release(id int64) {
+	err := ctx.Ctx.DB.Exec(`UPDATE managed_txs SET claimed_until = NULL,
+		next_check_at = CASE WHEN status IN (?, ?) THEN ? ELSE next_check_at END, updated_at = NOW()
+		WHERE id = ?`, model.ManagedTxPending, model.ManagedTxSubmitted, time.Now().Add(txPollInterval()), id).Error
+	if err != nil {
+		log.Logger.Error("释放托管交易租约失败", zap.Int64("id", id), zap.Error(err))
+	}
+}
+
+// ProcessDue 领取到期的未完成交易：检查回执、广播未发出的交易、按配置提速卡住的交易，返回处理数量
+func (s *ManagedTxService) ProcessDue(batchSize int) (int, error) {
+	var records []model.ManagedTx
+	lease := time.Now().Add(txClaimLease)
+	err := ctx.Ctx.DB.Raw(`
+		UPDATE managed_txs SET next_check_at = ?, claimed_until = ?, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM managed_txs
+			WHERE status IN (?, ?) AND next_check_at <= NOW()
+				AND (claimed_until IS NULL OR claimed_until <= NOW())
+			ORDER BY next_check_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, lease, lease, model.ManagedTxPending, model.ManagedTxSubmitted, batchSize).Scan(&records).Error
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	for i := range records {
+		if err := s.check(&records[i]); err != nil {
+			log.Logger.Warn("检查托管交易失败", zap.Int64("id", records[i].Id), zap.Error(err))
+		}
+		s.release(records[i].Id)
+	}
+	return len(records), nil
+}
+
+// check 检查单笔交易：任一广播哈希有回执即为结果；nonce 已被其他交易占用则失败；否则按需重发
+func (s *ManagedTxService) check(record *model.ManagedTx) error {
+	client, err := txClient(record.ChainId)
+	if err != nil {
+		return err
+	}
+	bg := context.Background()
+
+	attempts, err := s.ListAttempts(record.Id)
+	if err != nil {
+		return err
+	}
+	if done, err := s.finalizeByReceipt(bg, client, record, attempts); done || err != nil {
+		return err
+	}
+
+	mined, err := client.NonceAt(bg, common.HexToAddress(record.FromAddress), nil)
+	if err != nil {
+		return fmt.Errorf("获取nonce失败: %v", err)
+	}
+	if int64(mined) > record.Nonce {
+		// 交易可能在查询回执与查询 nonce 之间上链，重新读取全部广播记录并查询回执，确认不是本交易占用了该 nonce
+		if attempts, err = s.ListAttempts(record.Id); err != nil {
+			return err
+		}
+		if done, err := s.finalizeByReceipt(bg, client, record, attempts); done || err != nil {
+			return err
+		}
+		return s.markFailed(record, "nonce 已被其他交易占用")
+	}
+
+	value, ok := txSigners.Load(record.FromAddress)
+	if !ok {
+		return fmt.Errorf("发送账户 %s 未注册，无法重发", record.FromAddress)
+	}
+	txSigner := value.(signer.Signer)
+
+	switch {
+	case record.Status == model.ManagedTxPending:
+		return s.broadcastPending(bg, client, txSigner, record)
+	case record.CancelRequested && len(attempts) > 0 && attempts[len(attempts)-1].Kind != model.ManagedTxAttemptCancel:
+		tip, maxFee, err := bumpTxFees(bg, client, record)
+		if err != nil {
+			return err
+		}
+		return broadcastManagedTx(s.store, client, txSigner, record, model.ManagedTxAttemptCancel, tip, maxFee)
+	case record.LastBroadcastAt != nil && time.Since(*record.LastBroadcastAt) > txStuckAfter() && record.Attempts <= txMaxReplacements():
+		kind := model.ManagedTxAttemptSpeedUp
+		if record.CancelRequested {
+			kind = model.ManagedTxAttemptCancel
+		}
+		tip, maxFee, err := bumpTxFees(bg, client, record)
+		if err != nil {
+			return err
+		}
+		log.Logger.Warn("托管交易长时间未上链，提高费用重发", zap.Int64("id", record.Id), zap.Int("attempts", record.Attempts))
+		return broadcastManagedTx(s.store, client, txSigner, record, kind, tip, maxFee)
+	}
+	return nil
+}
+
+// broadcastPending 广播尚未发出的交易；广播失败达到 max_broadcast_failures 后改发取消交易，
+// 一直无法广播的交易会在该 nonce 留下缺口，阻塞该账户之后的全部交易
+func (s *ManagedTxService) broadcastPending(bg context.Context, client txSendClient, txSigner signer.Signer, record *model.ManagedTx) error {
+	if !record.CancelRequested && record.BroadcastFailures >= txMaxBroadcastFailures() {
+		if err := s.store.UpdateTx(record.Id, map[string]interface{}{"cancel_requested": true}); err != nil {
+			return err
+		}
+		record.CancelRequested = true
+		log.Logger.Error("托管交易多次广播失败，改发取消交易填补 nonce", zap.Int64("id", record.Id),
+			zap.Int64("nonce", record.Nonce), zap.Int("failures", record.BroadcastFailures), zap.String("error", record.Error))
+	}
+	kind := model.ManagedTxAttemptInitial
+	if record.CancelRequested {
+		kind = model.ManagedTxAttemptCancel
+	}
+	tip, maxFee, err := suggestTxFees(bg, client, record.ChainId)
+	if err != nil {
+		return err
+	}
+	return broadcastManagedTx(s.store, client, txSigner, record, kind, tip, maxFee)
+}
+
+// finalizeByReceipt 任一广播哈希有回执时返回 true，确认数达到 tx_manager.confirmations 才写入终态；
+// 确认前发生重组时回执消失，交易回到待检查状态
+func (s *ManagedTxService) finalizeByReceipt(bg context.Context, client *ethclient.Client, record *model.ManagedTx, attempts []model.ManagedTxAttempt) (bool, error) {
+	for _, attempt := range attempts {
+		receipt, err := client.TransactionReceipt(bg, common.HexToHash(attempt.TxHash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("查询交易回执失败: %v", err)
+		}
+		head, err := client.BlockNumber(bg)
+		if err != nil {
+			return false, fmt.Errorf("获取最新区块失败: %v", err)
+		}
+		if !receiptConfirmed(head, receipt.BlockNumber.Uint64(), txConfirmations()) {
+			return true, nil
+		}
+		return true, s.finalize(record, attempt, receipt)
+	}
+	return false, nil
+}
+
+// receiptConfirmed 回执所在区块（含本区块）的确认数是否达到要求
+func receiptConfirmed(head, block uint64, confirmations int64) bool {
+	return head >= block && int64(head-block)+1 >= confirmations
+}
+
+// finalize 按回执写入终态
+func (s *ManagedTxService) finalize(record *model.ManagedTx, attempt model.ManagedTxAttempt, receipt *types.Receipt) error {
+	status := model.ManagedTxConfirmed
+	errMsg := ""
+	switch {
+	case attempt.Kind == model.ManagedTxAttemptCancel:
+		status = model.ManagedTxCancelled
+		if record.BroadcastFailures >= txMaxBroadcastFailures() {
+			errMsg = fmt.Sprintf("广播失败 %d 次，已以取消交易填补 nonce: %s", record.BroadcastFailures, record.Error)
+		}
+	case receipt.Status != types.ReceiptStatusSuccessful:
+		status = model.ManagedTxReverted
+		errMsg = ErrTxReverted.Error()
+	}
+	now := time.Now()
+	if err := ctx.Ctx.DB.Model(&model.ManagedTx{}).Where("id = ?", record.Id).Updates(map[string]interface{}{
+		"status":       status,
+		"tx_hash":      attempt.TxHash,
+		"block_number": receipt.BlockNumber.Int64(),
+		"gas_used":     int64(receipt.GasUsed),
+		"error":        errMsg,
+		"confirmed_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	log.Logger.Info("托管交易已上链", zap.Int64("id", record.Id), zap.String("status", status),
+		zap.String("tx_hash", attempt.TxHash), zap.Uint64("block_number", receipt.BlockNumber.Uint64()))
+	return nil
+}
+
+func (s *ManagedTxService) markFailed(record *model.ManagedTx, reason string) error {
+	log.Logger.Error("托管交易失败", zap.Int64("id", record.Id), zap.String("reason", reason))
+	return ctx.Ctx.DB.Model(&model.ManagedTx{}).Where("id = ?", record.Id).Updates(map[string]interface{}{
+		"status": model.ManagedTxFailed,
+		"error":  reason,
+	}).Error
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+)
+
+func gwei(n float64) *big.Int {
+	v, _ := new(big.Float).Mul(big.NewFloat(n), big.NewFloat(1e9)).Int(nil)
+	return v
+}
+
+// fakeTxClient 固定建议费用，按顺序返回 SendTransaction 的错误并记录发出的交易
+type fakeTxClient struct {
+	tip     *big.Int
+	baseFee *big.Int
+	sendErr []error
+	sent    []*types.Transaction
+}
+
+func (c *fakeTxClient) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return new(big.Int).Set(c.tip), nil
+}
+
+func (c *fakeTxClient) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), BaseFee: new(big.Int).Set(c.baseFee)}, nil
+}
+
+func (c *fakeTxClient) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	c.sent = append(c.sent, tx)
+	if len(c.sendErr) > 0 {
+		err := c.sendErr[0]
+		c.sendErr = c.sendErr[1:]
+		return err
+	}
+	return nil
+}
+
+// fakeTxSigner 使用内存私钥签名
+type fakeTxSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func newFakeTxSigner(t *testing.T) *fakeTxSigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeTxSigner{key: key}
+}
+
+func (s *fakeTxSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *fakeTxSigner) SignTx(_ context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), s.key)
+}
+
+// fakeTxStore 内存中的发件箱写入
+type fakeTxStore struct {
+	attempts []model.ManagedTxAttempt
+	updates  map[string]interface{}
+}
+
+func (s *fakeTxStore) CreateAttempt(attempt *model.ManagedTxAttempt) error {
+	s.attempts = append(s.attempts, *attempt)
+	return nil
+}
+
+func (s *fakeTxStore) UpdateTx(_ int64, updates map[string]interface{}) error {
+	if s.updates == nil {
+		s.updates = map[string]interface{}{}
+	}
+	for k, v := range updates {
+		s.updates[k] = v
+	}
+	return nil
+}
+
+func setTxManagerConfig(t *testing.T, conf config.TxManagerConfig) {
+	t.Helper()
+	old := config.Conf.TxManager
+	config.Conf.TxManager = conf
+	t.Cleanup(func() { config.Conf.TxManager = old })
+}
+
+// atLeastBumped 节点的替换规则：new*100 >= old*110
+func atLeastBumped(newFee, oldFee *big.Int) bool {
+	return new(big.Int).Mul(newFee, big.NewInt(100)).Cmp(new(big.Int).Mul(oldFee, big.NewInt(100+minTxFeeBumpPct))) >= 0
+}
+
+func TestBumpTxFees(t *testing.T) {
+	cases := []struct {
+		name                string
+		conf                config.TxManagerConfig
+		oldTip, oldMaxFee   *big.Int
+		tip, baseFee        *big.Int // 节点建议值
+		wantTip, wantMaxFee *big.Int
+		wantErr             error
+	}{
+		{
+			name:   "按配置比例上浮",
+			conf:   config.TxManagerConfig{FeeBumpPct: 15},
+			oldTip: gwei(2), oldMaxFee: gwei(30),
+			tip: gwei(1), baseFee: gwei(10),
+			wantTip: gwei(2.3), wantMaxFee: gwei(34.5),
+		},
+		{
+			name:   "配置低于 10% 时按 10% 上浮",
+			conf:   config.TxManagerConfig{FeeBumpPct: 5},
+			oldTip: gwei(2), oldMaxFee: gwei(30),
+			tip: gwei(1), baseFee: gwei(10),
+			wantTip: gwei(2.2), wantMaxFee: gwei(33),
+		},
+		{
+			name:   "建议费用更高时取建议值",
+			conf:   config.TxManagerConfig{FeeBumpPct: 15},
+			oldTip: gwei(2), oldMaxFee: gwei(30),
+			tip: gwei(5), baseFee: gwei(50),
+			wantTip: gwei(5), wantMaxFee: gwei(105),
+		},
+		{
+			name:   "上浮结果向上取整",
+			conf:   config.TxManagerConfig{FeeBumpPct: 10},
+			oldTip: big.NewInt(15), oldMaxFee: big.NewInt(25),
+			tip: big.NewInt(1), baseFee: big.NewInt(1),
+			wantTip: big.NewInt(17), wantMaxFee: big.NewInt(28),
+		},
+		{
+			name:   "上限截断建议费用",
+			conf:   config.TxManagerConfig{FeeBumpPct: 15, MaxFeeGwei: 40},
+			oldTip: gwei(2), oldMaxFee: gwei(30),
+			tip: gwei(1), baseFee: gwei(50),
+			wantTip: gwei(2.3), wantMaxFee: gwei(40),
+		},
+		{
+			name:   "上限内仍满足最低上浮",
+			conf:   config.TxManagerConfig{FeeBumpPct: 15, MaxFeeGwei: 33},
+			oldTip: gwei(2), oldMaxFee: gwei(30),
+			tip: gwei(1), baseFee: gwei(10),
+			wantTip: gwei(2.3), wantMaxFee: gwei(33),
+		},
+		{
+			name:   "上限不足最低上浮",
+			conf:   config.TxManagerConfig{FeeBumpPct: 15, MaxFeeGwei: 32},
+			oldTip: gwei(2), oldMaxFee: gwei(30),
+			tip: gwei(1), baseFee: gwei(10),
+			wantErr: ErrTxFeeCapReached,
+		},
+		{
+			name:   "上限截断小费后不足最低上浮",
+			conf:   config.TxManagerConfig{FeeBumpPct: 15, MaxFeeGwei: 3},
+			oldTip: gwei(2.8), oldMaxFee: gwei(2.9),
+			tip: gwei(1), baseFee: gwei(1),
+			wantErr: ErrTxFeeCapReached,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			setTxManagerConfig(t, c.conf)
+			record := &model.ManagedTx{ChainId: 1, MaxPriorityFeePerGas: c.oldTip.String(), MaxFeePerGas: c.oldMaxFee.String()}
+			tip, maxFee, err := bumpTxFees(context.Background(), &fakeTxClient{tip: c.tip, baseFee: c.baseFee}, record)
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Fatalf("err = %v, want %v", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tip.Cmp(c.wantTip) != 0 || maxFee.Cmp(c.wantMaxFee) != 0 {
+				t.Fatalf("fees = %s/%s, want %s/%s", tip, maxFee, c.wantTip, c.wantMaxFee)
+			}
+			if !atLeastBumped(tip, c.oldTip) || !atLeastBumped(maxFee, c.oldMaxFee) {
+				t.Fatalf("替换费用 %s/%s 未达原费用 %s/%s 的 110%%", tip, maxFee, c.oldTip, c.oldMaxFee)
+			}
+			if feeCap := txMaxFeeCap(); feeCap != nil && maxFee.Cmp(feeCap) > 0 {
+				t.Fatalf("maxFee %s 超过上限 %s", maxFee, feeCap)
+			}
+			if tip.Cmp(maxFee) > 0 {
+				t.Fatalf("tip %s 高于 maxFee %s", tip, maxFee)
+			}
+		})
+	}
+}
+
+func TestBroadcastPendingCancelsAfterFailures(t *testing.T) {
+	setTxManagerConfig(t, config.TxManagerConfig{MaxBroadcastFailures: 3})
+	txSigner := newFakeTxSigner(t)
+	from := txSigner.Address()
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	record := &model.ManagedTx{
+		Id:          7,
+		ChainId:     11155111,
+		FromAddress: from.Hex(),
+		ToAddress:   contract.Hex(),
+		Data:        "0xabcdef01",
+		Value:       "1",
+		Nonce:       5,
+		GasLimit:    100000,
+		Status:      model.ManagedTxPending,
+	}
+	rejected := errors.New("insufficient funds for gas * price + value")
+	client := &fakeTxClient{tip: gwei(1), baseFee: gwei(10), sendErr: []error{rejected, rejected, rejected}}
+	store := &fakeTxStore{}
+	s := &ManagedTxService{store: store}
+	bg := context.Background()
+
+	// 达到上限前按原调用重试广播
+	for i := 1; i <= 3; i++ {
+		if err := s.broadcastPending(bg, client, txSigner, record); err == nil {
+			t.Fatalf("第 %d 次广播应失败", i)
+		}
+		if record.BroadcastFailures != i || store.updates["broadcast_failures"] != i {
+			t.Fatalf("第 %d 次失败后 broadcast_failures = %d/%v", i, record.BroadcastFailures, store.updates["broadcast_failures"])
+		}
+		if sent := client.sent[len(client.sent)-1]; *sent.To() != contract || store.attempts[len(store.attempts)-1].Kind != model.ManagedTxAttemptInitial {
+			t.Fatalf("第 %d 次广播应为原交易: to=%s", i, sent.To())
+		}
+		if record.Status != model.ManagedTxPending || record.CancelRequested {
+			t.Fatalf("第 %d 次失败后状态 = %s cancel=%v", i, record.Status, record.CancelRequested)
+		}
+	}
+
+	// 达到上限后改发取消交易占用同一 nonce
+	if err := s.broadcastPending(bg, client, txSigner, record); err != nil {
+		t.Fatal(err)
+	}
+	if !record.CancelRequested || store.updates["cancel_requested"] != true {
+		t.Fatal("应标记 cancel_requested")
+	}
+	cancel := client.sent[len(client.sent)-1]
+	if *cancel.To() != from || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 || cancel.Gas() != txCancelGas {
+		t.Fatalf("取消交易应为向自身转账 0: to=%s value=%s data=%x gas=%d", cancel.To(), cancel.Value(), cancel.Data(), cancel.Gas())
+	}
+	if cancel.Nonce() != uint64(record.Nonce) {
+		t.Fatalf("nonce = %d, want %d", cancel.Nonce(), record.Nonce)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(cancel.ChainId()), cancel)
+	if err != nil || sender != from {
+		t.Fatalf("sender = %s, %v", sender, err)
+	}
+	last := store.attempts[len(store.attempts)-1]
+	if last.Kind != model.ManagedTxAttemptCancel || last.TxHash != cancel.Hash().Hex() || last.GasLimit != txCancelGas {
+		t.Fatalf("attempt = %+v", last)
+	}
+	if record.Status != model.ManagedTxSubmitted || record.TxHash != cancel.Hash().Hex() || store.updates["status"] != model.ManagedTxSubmitted {
+		t.Fatalf("status = %s tx_hash = %s", record.Status, record.TxHash)
+	}
+}
+
+func TestReceiptConfirmed(t *testing.T) {
+	cases := []struct {
+		head, block   uint64
+		confirmations int64
+		want          bool
+	}{
+		{head: 100, block: 100, confirmations: 1, want: true},
+		{head: 100, block: 100, confirmations: 3, want: false},
+		{head: 102, block: 100, confirmations: 3, want: true},
+		{head: 101, block: 100, confirmations: 3, want: false},
+		// 节点落后于回执所在区块
+		{head: 99, block: 100, confirmations: 1, want: false},
+	}
+	for _, c := range cases {
+		if got := receiptConfirmed(c.head, c.block, c.confirmations); got != c.want {
+			t.Fatalf("receiptConfirmed(%d, %d, %d) = %v, want %v", c.head, c.block, c.confirmations, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type V3PositionService struct {
+	priceSvc    *PriceService
+	snapshotSvc *PoolSnapshotService
+}
+
+func NewV3PositionService() *V3PositionService {
+	return &V3PositionService{
+		priceSvc:    NewPriceService(),
+		snapshotSvc: NewPoolSnapshotService(),
+	}
+}
+
+// v3PositionKey 仓位归集维度：交易发起地址 + tick 区间。
+// 经由 NonfungiblePositionManager 操作时池子事件里的 owner 是管理合约，因此按交易发起人归属到用户。
+type v3PositionKey struct {
+	owner     string
+	tickLower int
+	tickUpper int
+}
+
+func v3KeyOf(e model.LiquidityPoolEvent) v3PositionKey {
+	return v3PositionKey{owner: strings.ToLower(e.UserAddress), tickLower: e.TickLower, tickUpper: e.TickUpper}
+}
+
+// ApplyActivity 在事务内按链上顺序将 V3 的 Mint/Burn/Collect 累计到区间仓位：
+// Mint 增加流动性与成本；Burn 按移除流动性的比例结转成本并记入已移除本金；Collect 只累计提取数量。
+func (s *V3PositionService) ApplyActivity(tx *gorm.DB, pool model.LiquidityPool, events []model.LiquidityPoolEvent) error {
+	var relevant []model.LiquidityPoolEvent
+	for _, e := range events {
+		if e.EventType == "AddLiquidity" || e.EventType == "RemoveLiquidity" || e.EventType == "Collect" {
+			relevant = append(relevant, e)
+		}
+	}
+	if len(relevant) == 0 {
+		return nil
+	}
+	sortEventsByChainOrder(relevant)
+
+	keys := make(map[v3PositionKey]bool)
+	for _, e := range relevant {
+		keys[v3KeyOf(e)] = true
+	}
+	positions, err := s.loadPositions(tx, pool, keys)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range relevant {
+		pos := positions[v3KeyOf(e)]
+		switch e.EventType {
+		case "AddLiquidity":
+			in0, in1 := parseBigInt(e.Amount0In), parseBigInt(e.Amount1In)
+			pos.Liquidity = new(big.Int).Add(parseBigInt(pos.Liquidity), parseBigInt(e.Liquidity)).String()
+			pos.CostToken0 = new(big.Int).Add(parseBigInt(pos.CostToken0), in0).String()
+			pos.CostToken1 = new(big.Int).Add(parseBigInt(pos.CostToken1), in1).String()
+			pos.Deposited0 = new(big.Int).Add(parseBigInt(pos.Deposited0), in0).String()
+			pos.Deposited1 = new(big.Int).Add(parseBigInt(pos.Deposited1), in1).String()
+			pos.CostBasisUSD = pos.CostBasisUSD.Add(historicalValueUSD(s.priceSvc, pool, in0, in1, e.BlockTime))
+		case "RemoveLiquidity":
+			liquidity := parseBigInt(pos.Liquidity)
+			removed := parseBigInt(e.Liquidity)
+			cost0, cost1 := parseBigInt(pos.CostToken0), parseBigInt(pos.CostToken1)
+			if liquidity.Sign() > 0 {
+				if removed.Cmp(liquidity) >= 0 {
+					pos.CostToken0, pos.CostToken1, pos.CostBasisUSD = "0", "0", decimal.Zero
+				} else {
+					moved0 := new(big.Int).Div(new(big.Int).Mul(cost0, removed), liquidity)
+					moved1 := new(big.Int).Div(new(big.Int).Mul(cost1, removed), liquidity)
+					movedUSD := pos.CostBasisUSD.Mul(decimal.NewFromBigInt(removed, 0)).Div(decimal.NewFromBigInt(liquidity, 0))
+					pos.CostToken0 = new(big.Int).Sub(cost0, moved0).String()
+					pos.CostToken1 = new(big.Int).Sub(cost1, moved1).String()
+					pos.CostBasisUSD = pos.CostBasisUSD.Sub(movedUSD)
+				}
+			}
+			remaining := new(big.Int).Sub(liquidity, removed)
+			if remaining.Sign() < 0 {
+				remaining = big.NewInt(0)
+			}
+			pos.Liquidity = remaining.String()
+			pos.Withdrawn0 = new(big.Int).Add(parseBigInt(pos.Withdrawn0), parseBigInt(e.Amount0Out)).String()
+			pos.Withdrawn1 = new(big.Int).Add(parseBigInt(pos.Withdrawn1), parseBigInt(e.Amount1Out)).String()
+		case "Collect":
+			pos.Collected0 = new(big.Int).Add(parseBigInt(pos.Collected0), parseBigInt(e.Amount0Out)).String()
+			pos.Collected1 = new(big.Int).Add(parseBigInt(pos.Collected1), parseBigInt(e.Amount1Out)).String()
+		}
+		pos.LastBlockNum = e.BlockNumber
+	}
+
+	rows := make([]*model.V3Position, 0, len(positions))
+	for _, pos := range positions {
+		rows = append(rows, pos)
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain_id"}, {Name: "pool_address"}, {Name: "owner_address"}, {Name: "tick_lower"}, {Name: "tick_upper"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"liquidity", "cost_token0", "cost_token1", "cost_basis_usd", "deposited0", "deposited1",
+			"withdrawn0", "withdrawn1", "collected0", "collected1", "last_block_num", "updated_at",
+		}),
+	}).CreateInBatches(rows, 100).Error
+}
+
+// RebuildPositions 按全部已索引的 Mint/Burn/Collect 事件重建池子的区间仓位（锁定池子行，避免与实时索引并发写入）
+func (s *V3PositionService) RebuildPositions(tx *gorm.DB, pool model.LiquidityPool) error {
+	var locked model.LiquidityPool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pool.Id).First(&locked).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("chain_id = ? AND pool_address = ?", pool.ChainId, pool.PoolAddress).Delete(&model.V3Position{}).Error; err != nil {
+		return err
+	}
+	var events []model.LiquidityPoolEvent
+	if err := tx.Where("chain_id = ? AND pool_address = ? AND event_type IN ?", pool.ChainId, pool.PoolAddress,
+		[]string{"AddLiquidity", "RemoveLiquidity", "Collect"}).Find(&events).Error; err != nil {
+		return err
+	}
+	return s.ApplyActivity(tx, locked, events)
+}
+
+// GetPosition 查询地址在 V3 池子指定区间的仓位详情
+func (s *V3PositionService) GetPosition(pool model.LiquidityPool, owner string, tickLower, tickUpper int) (*model.LpPositionDetail, error) {
+	var pos model.V3Position
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ? AND owner_address = ? AND tick_lower = ? AND tick_upper = ?",
+		pool.ChainId, pool.PoolAddress, strings.ToLower(owner), tickLower, tickUpper).First(&pos).Error; err != nil {
+		return nil, err
+	}
+	prices, err := s.priceSvc.GetTokenPrices(pool.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	detail := s.buildDetail(pool, pos, prices)
+	return &detail, nil
+}
+
+// ListPositions 查询地址持有的全部 V3 区间仓位（流动性大于0）
+func (s *V3PositionService) ListPositions(owner string, chainIdOpt int64, priceCache map[int64]map[string]*TokenPriceQuote) ([]model.LpPositionDetail, error) {
+	var positions []model.V3Position
+	query := ctx.Ctx.DB.Where("owner_address = ? AND liquidity > 0", strings.ToLower(owner))
+	if chainIdOpt > 0 {
+		query = query.Where("chain_id = ?", chainIdOpt)
+	}
+	if err := query.Order("chain_id ASC, pool_address ASC, tick_lower ASC").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]model.LpPositionDetail, 0, len(positions))
+	for _, pos := range positions {
+		var pool model.LiquidityPool
+		if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ?", pos.ChainId, pos.PoolAddress).First(&pool).Error; err != nil {
+			continue
+		}
+		prices, ok := priceCache[pos.ChainId]
+		if !ok {
+			p, err := s.priceSvc.GetTokenPrices(pos.ChainId)
+			if err != nil {
+				return nil, err
+			}
+			prices = p
+			priceCache[pos.ChainId] = prices
+		}
+		list = append(list, s.buildDetail(pool, pos, prices))
+	}
+	return list, nil
+}
+
+// ListOwnerPoolAddresses 查询地址当前有流动性的 V3 池子地址
+func (s *V3PositionService) ListOwnerPoolAddresses(owner string, chainIdOpt int64) ([]string, error) {
+	var poolAddresses []string
+	query := ctx.Ctx.DB.Model(&model.V3Position{}).
+		Where("owner_address = ? AND liquidity > 0", strings.ToLower(owner))
+	if chainIdOpt > 0 {
+		query = query.Where("chain_id = ?", chainIdOpt)
+	}
+	if err := query.Distinct("pool_address").Pluck("pool_address", &poolAddresses).Error; err != nil {
+		return nil, err
+	}
+	return poolAddresses, nil
+}
+
+// ValueUSDAt 计算地址在指定时间点的 V3 仓位总价值：按该时间点之前的 Mint/Burn 累计各区间流动性，
+// 以所在小时快照的价格换算为代币数量，再按快照中的代币USD价格估值
+func (s *V3PositionService) ValueUSDAt(owner string, chainIdOpt int64, at time.Time) (decimal.Decimal, error) {
+	var rows []struct {
+		ChainId     int64
+		PoolAddress string
+		TickLower   int
+		TickUpper   int
+		Liquidity   decimal.Decimal
+	}
+	query := `
+		SELECT e.chain_id, e.pool_address, e.tick_lower, e.tick_upper,
+			SUM(CASE WHEN e.event_type = 'AddLiquidity' THEN e.liquidity ELSE -e.liquidity END) AS liquidity
+		FROM liquidity_pool_events e
+		JOIN liquidity_pools p ON p.chain_id = e.chain_id AND p.pool_address = e.pool_address AND p.pool_type = ?
+		WHERE LOWER(e.user_address) = ? AND e.event_type IN ('AddLiquidity', 'RemoveLiquidity')
+			AND COALESCE(e.block_time, e.created_at) <= ? AND (? = 0 OR e.chain_id = ?)
+		GROUP BY e.chain_id, e.pool_address, e.tick_lower, e.tick_upper`
+	if err := ctx.Ctx.DB.Raw(query, PoolTypeV3, strings.ToLower(owner), at.UTC(), chainIdOpt, chainIdOpt).Scan(&rows).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	pools := make(map[string]*model.LiquidityPool)
+	for _, r := range rows {
+		if !r.Liquidity.IsPositive() {
+			continue
+		}
+		pool, ok := pools[r.PoolAddress]
+		if !ok {
+			var p model.LiquidityPool
+			if err := ctx.Ctx.DB.Where("chain_id = ? AND pool_address = ?", r.ChainId, r.PoolAddress).First(&p).Error; err != nil {
+				continue
+			}
+			pool = &p
+			pools[r.PoolAddress] = pool
+		}
+		snap, err := s.snapshotSvc.SnapshotAt(r.ChainId, r.PoolAddress, at)
+		if err != nil {
+			continue
+		}
+		sqrtPrice := priceToSqrtPrice(snap.Price, pool.Token0Decimals, pool.Token1Decimals)
+		amount0, amount1 := positionAmounts(r.Liquidity.BigInt(), sqrtPrice, r.TickLower, r.TickUpper)
+		prices := make(map[string]*TokenPriceQuote)
+		if snap.Token0USD.IsPositive() {
+			prices[strings.ToLower(pool.Token0Address)] = &TokenPriceQuote{PriceUSD: snap.Token0USD}
+		}
+		if snap.Token1USD.IsPositive() {
+			prices[strings.ToLower(pool.Token1Address)] = &TokenPriceQuote{PriceUSD: snap.Token1USD}
+		}
+		total = total.Add(poolTVLFromReserves(*pool, amount0, amount1, prices))
+	}
+	return total, nil
+}
+
+// buildDetail 按池子当前价格计算区间仓位的底层资产、价值与收益：
+// 无常损失 = 仓位价值 / 成本代币按现价的持币价值 − 1（仓位价值不含未提取手续费）；
+// 手续费收益为累计提取减去累计移除本金的部分，未提取的手续费需读取链上 feeGrowth，此处不计入。
+func (s *V3PositionService) buildDetail(pool model.LiquidityPool, pos model.V3Position, prices map[string]*TokenPriceQuote) model.LpPositionDetail {
+	detail := model.LpPositionDetail{
+		ChainId:         pos.ChainId,
+		PoolAddress:     pos.PoolAddress,
+		PoolType:        PoolTypeV3,
+		OwnerAddress:    pos.OwnerAddress,
+		TickLower:       pos.TickLower,
+		TickUpper:       pos.TickUpper,
+		Liquidity:       pos.Liquidity,
+		InRange:         pool.Tick >= pos.TickLower && pool.Tick < pos.TickUpper,
+		Token0Symbol:    pool.Token0Symbol,
+		Token1Symbol:    pool.Token1Symbol,
+		CostBasisUSD:    pos.CostBasisUSD.InexactFloat64(),
+		Deposited0:      tokenAmount(parseBigInt(pos.Deposited0), pool.Token0Decimals).String(),
+		Deposited1:      tokenAmount(parseBigInt(pos.Deposited1), pool.Token1Decimals).String(),
+		Withdrawn0:      tokenAmount(parseBigInt(pos.Withdrawn0), pool.Token0Decimals).String(),
+		Withdrawn1:      tokenAmount(parseBigInt(pos.Withdrawn1), pool.Token1Decimals).String(),
+		LastUpdateBlock: pos.LastBlockNum,
+		Underlying0:     "0",
+		Underlying1:     "0",
+	}
+
+	fees0 := new(big.Int).Sub(parseBigInt(pos.Collected0), parseBigInt(pos.Withdrawn0))
+	fees1 := new(big.Int).Sub(parseBigInt(pos.Collected1), parseBigInt(pos.Withdrawn1))
+	if fees0.Sign() < 0 {
+		fees0 = big.NewInt(0)
+	}
+	if fees1.Sign() < 0 {
+		fees1 = big.NewInt(0)
+	}
+	fees := poolTVLFromReserves(pool, fees0, fees1, prices)
+	detail.FeesEarnedUSD = fees.InexactFloat64()
+
+	liquidity := parseBigInt(pos.Liquidity)
+	if liquidity.Sign() <= 0 {
+		return detail
+	}
+	if active := parseBigInt(pool.Liquidity); detail.InRange && active.Sign() > 0 {
+		detail.ShareOfPool = decimal.NewFromBigInt(liquidity, 0).Div(decimal.NewFromBigInt(active, 0)).Mul(decimal.NewFromInt(100)).InexactFloat64()
+	}
+	under0, under1 := positionAmounts(liquidity, parseBigInt(pool.SqrtPriceX96), pos.TickLower, pos.TickUpper)
+	detail.Underlying0 = tokenAmount(under0, pool.Token0Decimals).String()
+	detail.Underlying1 = tokenAmount(under1, pool.Token1Decimals).String()
+
+	value := poolTVLFromReserves(pool, under0, under1, prices)
+	detail.ValueUSD = value.InexactFloat64()
+	detail.PnlUSD = value.Add(fees).Sub(pos.CostBasisUSD).InexactFloat64()
+
+	hodl := poolTVLFromReserves(pool, parseBigInt(pos.CostToken0), parseBigInt(pos.CostToken1), prices)
+	detail.HodlValueUSD = hodl.InexactFloat64()
+	if hodl.IsPositive() {
+		detail.ImpermanentLoss = value.Div(hodl).Sub(decimal.NewFromInt(1)).Mul(decimal.NewFromInt(100)).InexactFloat64()
+	}
+	return detail
+}
+
+// loadPositions 加载相关区间的仓位，不存在的初始化为空仓位
+func (s *V3PositionService) loadPositions(tx *gorm.DB, pool model.LiquidityPool, keys map[v3PositionKey]bool) (map[v3PositionKey]*model.V3Position, error) {
+	owners := make([]string, 0, len(keys))
+	seen := make(map[string]bool)
+	for k := range keys {
+		if !seen[k.owner] {
+			seen[k.owner] = true
+			owners = append(owners, k.owner)
+		}
+	}
+	var existing []model.V3Position
+	if err := tx.Where("chain_id = ? AND pool_address = ? AND owner_address IN ?", pool.ChainId, pool.PoolAddress, owners).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	positions := make(map[v3PositionKey]*model.V3Position, len(keys))
+	for i := range existing {
+		k := v3PositionKey{owner: existing[i].OwnerAddress, tickLower: existing[i].TickLower, tickUpper: existing[i].TickUpper}
+		if keys[k] {
+			positions[k] = &existing[i]
+		}
+	}
+	for k := range keys {
+		if _, ok := positions[k]; ok {
+			continue
+		}
+		positions[k] = &model.V3Position{
+			ChainId:      pool.ChainId,
+			PoolAddress:  pool.PoolAddress,
+			OwnerAddress: k.owner,
+			TickLower:    k.tickLower,
+			TickUpper:    k.tickUpper,
+			Liquidity:    "0",
+			CostToken0:   "0",
+			CostToken1:   "0",
+			CostBasisUSD: decimal.Zero,
+			Deposited0:   "0",
+			Deposited1:   "0",
+			Withdrawn0:   "0",
+			Withdrawn1:   "0",
+			Collected0:   "0",
+			Collected1:   "0",
+		}
+	}
+	return positions, nil
+}
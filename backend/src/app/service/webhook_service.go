@@ -0,0 +1,627 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookBackoffBase = 30 * time.Second
+	defaultWebhookBackoffMax  = 6 * time.Hour
+	defaultWebhookWorkers     = 4
+	// webhookClaimLease 领取的投递任务在该时间内不会被其他实例重复领取，需大于单次请求超时
+	webhookClaimLease = 5 * time.Minute
+	// webhookResponseLimit 投递记录保存的响应内容长度
+	webhookResponseLimit = 1024
+)
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook 订阅不存在")
+	ErrWebhookInvalidParam = errors.New("webhook 参数无效")
+)
+
+type WebhookService struct {
+	client *http.Client
+}
+
+func NewWebhookService() *WebhookService {
+	timeout := defaultWebhookTimeout
+	if config.Conf.Webhook.Timeout > 0 {
+		timeout = time.Duration(config.Conf.Webhook.Timeout) * time.Second
+	}
+	return NewWebhookServiceWithClient(&http.Client{Timeout: timeout})
+}
+
+// NewWebhookServiceWithClient 使用指定的 HTTP 客户端投递，便于对接本地接收端
+func NewWebhookServiceWithClient(client *http.Client) *WebhookService {
+	return &WebhookService{client: client}
+}
+
+// WebhookEnabled 是否启用 Webhook 投递
+func WebhookEnabled() bool {
+	return config.Conf.Webhook.Enabled
+}
+
+// WebhookEventTypes 可订阅的事件类型
+func WebhookEventTypes() []string {
+	return append(ExplorerEventTypes(), model.WebhookEventMerkleRoot, model.WebhookEventSharePriceAlert)
+}
+
+// WebhookSubscriptionInput 创建或更新订阅的参数
+type WebhookSubscriptionInput struct {
+	Url         string   `json:"url"`
+	Description string   `json:"description"`
+	ChainId     int64    `json:"chainId"`
+	EventTypes  []string `json:"eventTypes"`
+	Pools       []string `json:"pools"`
+	Wallets     []string `json:"wallets"`
+	IsActive    *bool    `json:"isActive"`
+}
+
+// validate 校验并规范化订阅参数
+func (in *WebhookSubscriptionInput) validate() error {
+	u, err := url.Parse(strings.TrimSpace(in.Url))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: 地址无效", ErrWebhookInvalidParam)
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && config.Conf.Webhook.AllowHttp) {
+		return fmt.Errorf("%w: 仅支持 https 地址", ErrWebhookInvalidParam)
+	}
+	in.Url = u.String()
+
+	allowed := make(map[string]bool)
+	for _, t := range WebhookEventTypes() {
+		allowed[t] = true
+	}
+	in.EventTypes = normalizeList(in.EventTypes)
+	for _, t := range in.EventTypes {
+		if !allowed[t] {
+			return fmt.Errorf("%w: 未知事件类型 %s", ErrWebhookInvalidParam, t)
+		}
+	}
+	in.Pools = normalizeList(in.Pools)
+	in.Wallets = normalizeList(in.Wallets)
+	for _, addr := range append(append([]string{}, in.Pools...), in.Wallets...) {
+		if !strings.HasPrefix(addr, "0x") || !common.IsHexAddress(addr) {
+			return fmt.Errorf("%w: 地址无效 %s", ErrWebhookInvalidParam, addr)
+		}
+	}
+	if in.ChainId < 0 {
+		return fmt.Errorf("%w: 链ID无效", ErrWebhookInvalidParam)
+	}
+	return nil
+}
+
+// CreateSubscription 创建订阅并生成签名密钥；返回的密钥只在创建时可见
+func (s *WebhookService) CreateSubscription(owner string, in WebhookSubscriptionInput) (*model.WebhookSubscription, string, error) {
+	if err := in.validate(); err != nil {
+		return nil, "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	sub := &model.WebhookSubscription{
+		OwnerAddress: strings.ToLower(owner),
+		Url:          in.Url,
+		Secret:       secret,
+		Description:  in.Description,
+		ChainId:      in.ChainId,
+		EventTypes:   strings.Join(in.EventTypes, ","),
+		Pools:        strings.Join(in.Pools, ","),
+		Wallets:      strings.Join(in.Wallets, ","),
+		IsActive:     in.IsActive == nil || *in.IsActive,
+	}
+	if err := ctx.Ctx.DB.Create(sub).Error; err != nil {
+		return nil, "", err
+	}
+	fillWebhookLists(sub)
+	return sub, secret, nil
+}
+
+// ListSubscriptions 查询地址名下的订阅
+func (s *WebhookService) ListSubscriptions(owner string) ([]model.WebhookSubscription, error) {
+	var subs []model.WebhookSubscription
+	if err := ctx.Ctx.DB.Where("owner_address = ?", strings.ToLower(owner)).Order("id").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		fillWebhookLists(&subs[i])
+	}
+	return subs, nil
+}
+
+// GetSubscription 查询地址名下的单个订阅
+func (s *WebhookService) GetSubscription(owner string, id int64) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	err := ctx.Ctx.DB.Where("id = ? AND owner_address = ?", id, strings.ToLower(owner)).First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	fillWebhookLists(&sub)
+	return &sub, nil
+}
+
+// UpdateSubscription 整体更新订阅条件；IsActive 为空时保持原状态
+func (s *WebhookService) UpdateSubscription(owner string, id int64, in WebhookSubscriptionInput) (*model.WebhookSubscription, error) {
+	sub, err := s.GetSubscription(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.validate(); err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{
+		"url":         in.Url,
+		"description": in.Description,
+		"chain_id":    in.ChainId,
+		"event_types": strings.Join(in.EventTypes, ","),
+		"pools":       strings.Join(in.Pools, ","),
+		"wallets":     strings.Join(in.Wallets, ","),
+	}
+	if in.IsActive != nil {
+		updates["is_active"] = *in.IsActive
+	}
+	if err := ctx.Ctx.DB.Model(sub).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return s.GetSubscription(owner, id)
+}
+
+// RotateSecret 重新生成签名密钥
+func (s *WebhookService) RotateSecret(owner string, id int64) (string, error) {
+	sub, err := s.GetSubscription(owner, id)
+	if err != nil {
+		return "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.Ctx.DB.Model(sub).Update("secret", secret).Error; err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// DeleteSubscription 删除订阅及其投递任务与投递记录
+func (s *WebhookService) DeleteSubscription(owner string, id int64) error {
+	if _, err := s.GetSubscription(owner, id); err != nil {
+		return err
+	}
+	return ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM webhook_delivery_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE subscription_id = ?)`, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.WebhookSubscription{}, id).Error
+	})
+}
+
+// ListDeliveries 分页查询订阅的投递任务，status 为空时不过滤；status=dead 即死信队列
+func (s *WebhookService) ListDeliveries(owner string, subscriptionId int64, status string, offset, limit int) ([]model.WebhookDelivery, int64, error) {
+	if _, err := s.GetSubscription(owner, subscriptionId); err != nil {
+		return nil, 0, err
+	}
+	query := ctx.Ctx.DB.Model(&model.WebhookDelivery{}).Where("subscription_id = ?", subscriptionId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []model.WebhookDelivery
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// getDelivery 查询地址名下订阅的投递任务
+func (s *WebhookService) getDelivery(owner string, deliveryId int64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := ctx.Ctx.DB.Table("webhook_deliveries d").
+		Select("d.*").
+		Joins("JOIN webhook_subscriptions s ON s.id = d.subscription_id").
+		Where("d.id = ? AND s.owner_address = ?", deliveryId, strings.ToLower(owner)).
+		Take(&delivery).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListAttempts 查询投递任务的每次请求记录
+func (s *WebhookService) ListAttempts(owner string, deliveryId int64) ([]model.WebhookDeliveryAttempt, error) {
+	if _, err := s.getDelivery(owner, deliveryId); err != nil {
+		return nil, err
+	}
+	var list []model.WebhookDeliveryAttempt
+	if err := ctx.Ctx.DB.Where("delivery_id = ?", deliveryId).Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Redeliver 将投递任务（通常来自死信队列）重置为待投递并立即重试
+func (s *WebhookService) Redeliver(owner string, deliveryId int64) error {
+	if _, err := s.getDelivery(owner, deliveryId); err != nil {
+		return err
+	}
+	return ctx.Ctx.DB.Model(&model.WebhookDelivery{}).Where("id = ?", deliveryId).Updates(map[string]interface{}{
+		"status":          model.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"last_error":      "",
+	}).Error
+}
+
+// Enqueue 按订阅条件为已提交的事件生成投递任务；同一订阅同一事件只投递一次
+func (s *WebhookService) Enqueue(events []model.WebhookEvent) error {
+	if !WebhookEnabled() || len(events) == 0 {
+		return nil
+	}
+	var subs []model.WebhookSubscription
+	if err := ctx.Ctx.DB.Where("is_active = ?", true).Find(&subs).Error; err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+	for i := range subs {
+		fillWebhookLists(&subs[i])
+	}
+
+	now := time.Now()
+	var deliveries []model.WebhookDelivery
+	for _, e := range events {
+		var payload []byte
+		for i := range subs {
+			if !webhookMatch(&subs[i], &e) {
+				continue
+			}
+			if payload == nil {
+				var err error
+				if payload, err = json.Marshal(e); err != nil {
+					return err
+				}
+			}
+			deliveries = append(deliveries, model.WebhookDelivery{
+				SubscriptionId: subs[i].Id,
+				EventId:        e.Id,
+				EventType:      e.Type,
+				Payload:        string(payload),
+				Status:         model.WebhookDeliveryPending,
+				NextAttemptAt:  now,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return ctx.Ctx.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).CreateInBatches(&deliveries, 100).Error
+}
+
+// webhookMatch 订阅条件匹配：链、事件类型、池子、钱包之间为且；事件没有池子或钱包时不匹配设置了对应条件的订阅
+func webhookMatch(sub *model.WebhookSubscription, e *model.WebhookEvent) bool {
+	if sub.ChainId != 0 && sub.ChainId != e.ChainId {
+		return false
+	}
+	return matchList(sub.EventTypeList, e.Type) && matchList(sub.PoolList, e.PoolAddress) && matchList(sub.WalletList, e.UserAddress)
+}
+
+func matchList(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliverDue 领取一批到期的投递任务并发投递，返回处理的任务数
+func (s *WebhookService) DeliverDue(batchSize int) (int, error) {
+	var deliveries []model.WebhookDelivery
+	// 先把任务的下次投递时间推后一个租期再投递，实例在投递中途退出时租期过后会被重新领取
+	err := ctx.Ctx.DB.Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, time.Now().Add(webhookClaimLease), model.WebhookDeliveryPending, batchSize).Scan(&deliveries).Error
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	subIds := make([]int64, 0, len(deliveries))
+	for _, d := range deliveries {
+		subIds = append(subIds, d.SubscriptionId)
+	}
+	var subs []model.WebhookSubscription
+	if err := ctx.Ctx.DB.Where("id IN ?", subIds).Find(&subs).Error; err != nil {
+		return 0, err
+	}
+	subMap := make(map[int64]*model.WebhookSubscription, len(subs))
+	for i := range subs {
+		subMap[subs[i].Id] = &subs[i]
+	}
+
+	workers := config.Conf.Webhook.Workers
+	if workers <= 0 {
+		workers = defaultWebhookWorkers
+	}
+	sem := make(chan struct{}, workers)
+	var wg gosync.WaitGroup
+	for i := range deliveries {
+		d := &deliveries[i]
+		sub := subMap[d.SubscriptionId]
+		if sub == nil || !sub.IsActive {
+			s.finish(d, model.WebhookDeliveryDead, 0, "订阅已删除或停用")
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.deliver(sub, d)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// deliver 发送一次投递请求并记录结果
+func (s *WebhookService) deliver(sub *model.WebhookSubscription, d *model.WebhookDelivery) {
+	record := s.send(sub, d)
+	if e := ctx.Ctx.DB.Create(&record).Error; e != nil {
+		log.Logger.Warn("写入 webhook 投递记录失败", zap.Int64("delivery_id", d.Id), zap.Error(e))
+	}
+
+	d.Attempts = record.Attempt
+	status, retryAfter := webhookOutcome(record)
+	switch status {
+	case model.WebhookDeliverySucceeded:
+		s.finish(d, status, record.StatusCode, "")
+		return
+	case model.WebhookDeliveryDead:
+		log.Logger.Warn("webhook 投递重试耗尽，进入死信队列", zap.Int64("delivery_id", d.Id), zap.String("event_id", d.EventId), zap.String("error", record.Error))
+		s.finish(d, status, record.StatusCode, record.Error)
+		return
+	}
+	if e := ctx.Ctx.DB.Model(&model.WebhookDelivery{}).Where("id = ?", d.Id).Updates(map[string]interface{}{
+		"attempts":         record.Attempt,
+		"last_status_code": record.StatusCode,
+		"last_error":       record.Error,
+		"next_attempt_at":  time.Now().Add(retryAfter),
+	}).Error; e != nil {
+		log.Logger.Error("更新 webhook 投递任务失败", zap.Int64("delivery_id", d.Id), zap.Error(e))
+	}
+}
+
+// send 发送第 d.Attempts+1 次请求，返回本次的投递记录；非 2xx 响应与请求错误均记为失败
+func (s *WebhookService) send(sub *model.WebhookSubscription, d *model.WebhookDelivery) model.WebhookDeliveryAttempt {
+	attempt := d.Attempts + 1
+	start := time.Now()
+	statusCode, body, err := s.post(sub, d, attempt)
+	record := model.WebhookDeliveryAttempt{
+		DeliveryId:   d.Id,
+		Attempt:      attempt,
+		StatusCode:   statusCode,
+		ResponseBody: body,
+		DurationMs:   time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else if statusCode < 200 || statusCode >= 300 {
+		record.Error = "HTTP " + strconv.Itoa(statusCode)
+	}
+	return record
+}
+
+// webhookOutcome 按本次投递记录决定任务状态：成功、进入死信队列，或保持待投递并在 retryAfter 后重试
+func webhookOutcome(record model.WebhookDeliveryAttempt) (status string, retryAfter time.Duration) {
+	if record.Error == "" {
+		return model.WebhookDeliverySucceeded, 0
+	}
+	maxAttempts := config.Conf.Webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	if record.Attempt >= maxAttempts {
+		return model.WebhookDeliveryDead, 0
+	}
+	return model.WebhookDeliveryPending, webhookBackoff(record.Attempt)
+}
+
+// finish 将投递任务置为终态
+func (s *WebhookService) finish(d *model.WebhookDelivery, status string, statusCode int, lastError string) {
+	updates := map[string]interface{}{
+		"status":           status,
+		"attempts":         d.Attempts,
+		"last_status_code": statusCode,
+		"last_error":       lastError,
+	}
+	if status == model.WebhookDeliverySucceeded {
+		updates["delivered_at"] = time.Now()
+	}
+	if err := ctx.Ctx.DB.Model(&model.WebhookDelivery{}).Where("id = ?", d.Id).Updates(updates).Error; err != nil {
+		log.Logger.Error("更新 webhook 投递任务失败", zap.Int64("delivery_id", d.Id), zap.Error(err))
+	}
+}
+
+// post 发送签名后的请求，返回状态码与截断后的响应内容
+func (s *WebhookService) post(sub *model.WebhookSubscription, d *model.WebhookDelivery, attempt int) (int, string, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, sub.Url, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AlanSwap-Webhook/1.0")
+	req.Header.Set("X-AlanSwap-Event", d.EventType)
+	req.Header.Set("X-AlanSwap-Event-Id", d.EventId)
+	req.Header.Set("X-AlanSwap-Delivery", strconv.FormatInt(d.Id, 10))
+	req.Header.Set("X-AlanSwap-Attempt", strconv.Itoa(attempt))
+	req.Header.Set("X-AlanSwap-Timestamp", timestamp)
+	req.Header.Set("X-AlanSwap-Signature", "sha256="+SignWebhookPayload(sub.Secret, timestamp, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhookPayload 签名为 hex(HMAC-SHA256(secret, timestamp + "." + body))，接收方按同样方式计算并比对，
+// 同时校验时间戳以防重放
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff 第 attempt 次失败后的重试间隔：base * 2^(attempt-1)，不超过上限
+func webhookBackoff(attempt int) time.Duration {
+	base := defaultWebhookBackoffBase
+	if config.Conf.Webhook.BackoffBase > 0 {
+		base = time.Duration(config.Conf.Webhook.BackoffBase) * time.Second
+	}
+	max := defaultWebhookBackoffMax
+	if config.Conf.Webhook.BackoffMax > 0 {
+		max = time.Duration(config.Conf.Webhook.BackoffMax) * time.Second
+	}
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// WebhookEventsFromExplorer 将统一事件转换为 Webhook 事件
+func WebhookEventsFromExplorer(events []model.ExplorerEvent) []model.WebhookEvent {
+	list := make([]model.WebhookEvent, 0, len(events))
+	for _, e := range events {
+		pool := ""
+		if e.Source == "liquidity" {
+			pool = strings.ToLower(e.ContractAddress)
+		}
+		list = append(list, model.WebhookEvent{
+			Id:          BusEventId(e),
+			Type:        e.EventType,
+			ChainId:     e.ChainId,
+			OccurredAt:  e.EventTime,
+			Data:        e,
+			PoolAddress: pool,
+			UserAddress: strings.ToLower(e.UserAddress),
+		})
+	}
+	return list
+}
+
+// WebhookEventsFromMerkleRoots 将默克尔根更新事件转换为 Webhook 事件
+func WebhookEventsFromMerkleRoots(events []*model.MerkleRootUpdatedEvent) []model.WebhookEvent {
+	list := make([]model.WebhookEvent, 0, len(events))
+	for _, e := range events {
+		if e == nil {
+			continue
+		}
+		list = append(list, model.WebhookEvent{
+			Id:         fmt.Sprintf("%s:%d:%s:%d", model.WebhookEventMerkleRoot, e.ChainId, e.TxHash, e.LogIndex),
+			Type:       model.WebhookEventMerkleRoot,
+			ChainId:    e.ChainId,
+			OccurredAt: e.EventTime,
+			Data:       e,
+		})
+	}
+	return list
+}
+
+func fillWebhookLists(sub *model.WebhookSubscription) {
+	sub.EventTypeList = splitList(sub.EventTypes)
+	sub.PoolList = splitList(sub.Pools)
+	sub.WalletList = splitList(sub.Wallets)
+}
+
+func splitList(v string) []string {
+	list := []string{}
+	for _, item := range strings.Split(v, ",") {
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// normalizeList 去空白、转小写并去重
+func normalizeList(values []string) []string {
+	seen := make(map[string]bool)
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" && !seen[v] {
+			seen[v] = true
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
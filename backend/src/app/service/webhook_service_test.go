@@ -0,0 +1,196 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/core/config"
+)
+
+func withWebhookConfig(t *testing.T, cfg config.WebhookConfig) {
+	t.Helper()
+	old := config.Conf.Webhook
+	config.Conf.Webhook = cfg
+	t.Cleanup(func() { config.Conf.Webhook = old })
+}
+
+func testDelivery() *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		Id:        42,
+		EventId:   "swap:1:0xabc:1",
+		EventType: model.ExplorerEventSwap,
+		Payload:   `{"id":"swap:1:0xabc:1"}`,
+		Status:    model.WebhookDeliveryPending,
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	got := SignWebhookPayload("whsec_test", "1700000000", []byte(`{"id":"swap:1:0xabc:1"}`))
+	want := "b839059ffc9d9ed4931260e2ee72d9a0834be734ff4316d823acfc7d943b2721"
+	if got != want {
+		t.Fatalf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookSignatureHeaders(t *testing.T) {
+	const secret = "whsec_test"
+	d := testDelivery()
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookServiceWithClient(srv.Client())
+	record := s.send(&model.WebhookSubscription{Url: srv.URL, Secret: secret}, d)
+	if record.Error != "" || record.StatusCode != http.StatusNoContent || record.Attempt != 1 || record.DeliveryId != d.Id {
+		t.Fatalf("投递记录不符: %+v", record)
+	}
+
+	if string(body) != d.Payload {
+		t.Fatalf("body = %s", body)
+	}
+	// 接收方按文档校验：hex(HMAC-SHA256(secret, timestamp + "." + body))
+	timestamp := header.Get("X-AlanSwap-Timestamp")
+	if ts, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Fatalf("时间戳无效: %s", timestamp)
+	}
+	if got, want := header.Get("X-AlanSwap-Signature"), "sha256="+SignWebhookPayload(secret, timestamp, body); got != want {
+		t.Fatalf("签名 = %s, want %s", got, want)
+	}
+	if got := header.Get("X-AlanSwap-Signature"); got == "sha256="+SignWebhookPayload("whsec_other", timestamp, body) {
+		t.Fatal("不同密钥得到相同签名")
+	}
+	expected := map[string]string{
+		"Content-Type":        "application/json",
+		"X-AlanSwap-Event":    d.EventType,
+		"X-AlanSwap-Event-Id": d.EventId,
+		"X-AlanSwap-Delivery": "42",
+		"X-AlanSwap-Attempt":  "1",
+	}
+	for k, v := range expected {
+		if got := header.Get(k); got != v {
+			t.Fatalf("%s = %s, want %s", k, got, v)
+		}
+	}
+}
+
+func TestWebhookRetryOn5xx(t *testing.T) {
+	withWebhookConfig(t, config.WebhookConfig{MaxAttempts: 5, BackoffBase: 30, BackoffMax: 3600})
+
+	var calls int32
+	var attempts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts = append(attempts, r.Header.Get("X-AlanSwap-Attempt"))
+		if atomic.AddInt32(&calls, 1) <= 3 {
+			http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookServiceWithClient(srv.Client())
+	sub := &model.WebhookSubscription{Url: srv.URL, Secret: "whsec_test"}
+	d := testDelivery()
+	backoffs := []time.Duration{30 * time.Second, 60 * time.Second, 120 * time.Second}
+	for i, want := range backoffs {
+		record := s.send(sub, d)
+		if record.StatusCode != http.StatusServiceUnavailable || record.Error != "HTTP 503" || !strings.Contains(record.ResponseBody, "upstream unavailable") {
+			t.Fatalf("第 %d 次投递记录不符: %+v", i+1, record)
+		}
+		status, retryAfter := webhookOutcome(record)
+		if status != model.WebhookDeliveryPending || retryAfter != want {
+			t.Fatalf("第 %d 次失败后 status=%s retryAfter=%s, want pending %s", i+1, status, retryAfter, want)
+		}
+		d.Attempts = record.Attempt
+	}
+
+	record := s.send(sub, d)
+	if status, _ := webhookOutcome(record); status != model.WebhookDeliverySucceeded {
+		t.Fatalf("第 4 次投递应成功: %+v", record)
+	}
+	if strings.Join(attempts, ",") != "1,2,3,4" {
+		t.Fatalf("X-AlanSwap-Attempt = %v", attempts)
+	}
+}
+
+func TestWebhookDeadAfterMaxAttempts(t *testing.T) {
+	withWebhookConfig(t, config.WebhookConfig{MaxAttempts: 3})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookServiceWithClient(srv.Client())
+	sub := &model.WebhookSubscription{Url: srv.URL, Secret: "whsec_test"}
+	d := testDelivery()
+	var statuses []string
+	for i := 0; i < 3; i++ {
+		record := s.send(sub, d)
+		status, _ := webhookOutcome(record)
+		statuses = append(statuses, status)
+		d.Attempts = record.Attempt
+	}
+	if got := strings.Join(statuses, ","); got != "pending,pending,dead" {
+		t.Fatalf("状态 = %s, want pending,pending,dead", got)
+	}
+
+	// 接收端不可达同样计入失败
+	srv.Close()
+	d.Attempts = 2
+	record := s.send(sub, d)
+	if record.StatusCode != 0 || record.Error == "" {
+		t.Fatalf("请求失败的投递记录不符: %+v", record)
+	}
+	if status, _ := webhookOutcome(record); status != model.WebhookDeliveryDead {
+		t.Fatalf("status = %s, want dead", status)
+	}
+}
+
+func TestWebhookResponseTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		io.WriteString(w, strings.Repeat("x", webhookResponseLimit*4))
+	}))
+	defer srv.Close()
+
+	s := NewWebhookServiceWithClient(srv.Client())
+	record := s.send(&model.WebhookSubscription{Url: srv.URL}, testDelivery())
+	if len(record.ResponseBody) != webhookResponseLimit {
+		t.Fatalf("响应内容长度 = %d, want %d", len(record.ResponseBody), webhookResponseLimit)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     config.WebhookConfig
+		attempt int
+		want    time.Duration
+	}{
+		{"默认首次", config.WebhookConfig{}, 1, defaultWebhookBackoffBase},
+		{"默认第 4 次", config.WebhookConfig{}, 4, 240 * time.Second},
+		{"默认上限", config.WebhookConfig{}, 20, defaultWebhookBackoffMax},
+		{"配置上限", config.WebhookConfig{BackoffBase: 10, BackoffMax: 50}, 4, 50 * time.Second},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			withWebhookConfig(t, c.cfg)
+			if got := webhookBackoff(c.attempt); got != c.want {
+				t.Fatalf("webhookBackoff(%d) = %s, want %s", c.attempt, got, c.want)
+			}
+		})
+	}
+}
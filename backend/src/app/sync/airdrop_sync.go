@@ -22,6 +22,7 @@ type AirdropEvents struct {
 	TotalRewardUpdatedEvents []*model.TotalRewardUpdatedEvent
 	AirdropCreatedEvents     []*AirdropCreatedInfo
 	AirdropActivatedIds      []string
+	MerkleRootUpdatedEvents  []*model.MerkleRootUpdatedEvent
 }
 
 // ParseAirdropEvents 统一解析空投相关事件
@@ -33,6 +34,7 @@ func ParseAirdropEvents(vLog types.Log, chainId int, address string) *AirdropEve
 	updateTotalRewardTopic := crypto.Keccak256Hash([]byte("UpdateTotalRewardUpdated(uint256,address,uint256,uint256,uint256,uint256)")).Hex()
 	airdropCreatedTopic := crypto.Keccak256Hash([]byte("AirdropCreated(uint256,string,bytes32,uint256,uint256)")).Hex()
 	airdropActivatedTopic := crypto.Keccak256Hash([]byte("AirdropActivated(uint256)")).Hex()
+	merkleRootUpdatedTopic := crypto.Keccak256Hash([]byte("MerkleRootUpdated(uint256,bytes32,uint32)")).Hex()
 	//rewardPoolUpdatedTopic := crypto.Keccak256Hash([]byte("RewardPoolUpdated(address,address)")).Hex()
 	switch topic0 {
 	case rewardClaimedTopic:
@@ -51,6 +53,10 @@ func ParseAirdropEvents(vLog types.Log, chainId int, address string) *AirdropEve
 		if id := parseAirdropActivatedEvent(vLog, chainId); id != "" {
 			events.AirdropActivatedIds = append(events.AirdropActivatedIds, id)
 		}
+	case merkleRootUpdatedTopic:
+		if e := parseMerkleRootUpdatedEvent(vLog, chainId); e != nil {
+			events.MerkleRootUpdatedEvents = append(events.MerkleRootUpdatedEvents, e)
+		}
 	default:
 		return nil
 	}
@@ -80,12 +86,13 @@ func SaveAirdropEvents(events *AirdropEvents, chainId int, targetBlockNum uint64
 		}
 	}
 
-	// 保存空投活动创建与激活事件
-	if len(events.AirdropCreatedEvents) > 0 || len(events.AirdropActivatedIds) > 0 {
+	// 保存空投活动创建、激活与默克尔根更新事件
+	if len(events.AirdropCreatedEvents) > 0 || len(events.AirdropActivatedIds) > 0 || len(events.MerkleRootUpdatedEvents) > 0 {
 		log.Logger.Info("解析空投活动管理事件成功",
 			zap.Int("created_count", len(events.AirdropCreatedEvents)),
-			zap.Int("activated_count", len(events.AirdropActivatedIds)))
-		if err := saveAirdropAdminEvents(events.AirdropCreatedEvents, events.AirdropActivatedIds, chainId, targetBlockNum, addresses); err != nil {
+			zap.Int("activated_count", len(events.AirdropActivatedIds)),
+			zap.Int("merkle_root_updated_count", len(events.MerkleRootUpdatedEvents)))
+		if err := saveAirdropAdminEvents(events.AirdropCreatedEvents, events.AirdropActivatedIds, events.MerkleRootUpdatedEvents, chainId, targetBlockNum, addresses); err != nil {
 			log.Logger.Error("保存空投活动管理事件失败", zap.Error(err))
 			return err
 		}
@@ -185,6 +192,12 @@ func saveAirdropEvents(rewardClaimed []*model.RewardClaimedEvent, chainId int, t
 			}
 		}
 
+		// 写入事件总线 outbox，随本事务提交
+		if err := enqueueClaims(tx, rewardClaimed); err != nil {
+			log.Logger.Error("写入事件总线 outbox 失败", zap.Error(err))
+			return err
+		}
+
 		// 更新链区块高度
 		return updateBlockNumber(chainId, targetBlockNum, addresses)
 	})
@@ -226,6 +239,7 @@ type AirdropCreatedInfo struct {
 	Name            string
 	MerkleRoot      string
 	TotalReward     string
+	TxHash          string
 }
 
 // parseAirdropCreatedEvent 解析 AirdropCreated(uint256 indexed airdropId, string name, bytes32 merkleRoot, uint256 totalReward, uint256 treeVersion)
@@ -278,6 +292,7 @@ func parseAirdropCreatedEvent(vLog types.Log, chainId int) *AirdropCreatedInfo {
 				return "0"
 			}
 		}(),
+		TxHash: strings.ToLower(vLog.TxHash.Hex()),
 	}
 }
 
@@ -290,8 +305,27 @@ func parseAirdropActivatedEvent(vLog types.Log, chainId int) string {
 	return airdropId.String()
 }
 
-// saveAirdropAdminEvents 保存活动创建与激活信息到 airdrop_campaigns
-func saveAirdropAdminEvents(created []*AirdropCreatedInfo, activated []string, chainId int, targetBlockNum uint64, addresses string) error {
+// parseMerkleRootUpdatedEvent 解析 MerkleRootUpdated(uint256 indexed airdropId, bytes32 newRoot, uint32 newVersion)
+func parseMerkleRootUpdatedEvent(vLog types.Log, chainId int) *model.MerkleRootUpdatedEvent {
+	if len(vLog.Topics) < 2 || len(vLog.Data) < 64 {
+		return nil
+	}
+	airdropId := new(big.Int).SetBytes(common.TrimLeftZeroes(vLog.Topics[1].Bytes()))
+	version := new(big.Int).SetBytes(vLog.Data[32:64])
+	return &model.MerkleRootUpdatedEvent{
+		ChainId:         int64(chainId),
+		ContractAddress: strings.ToLower(vLog.Address.Hex()),
+		AirdropId:       airdropId.String(),
+		MerkleRoot:      "0x" + hex.EncodeToString(vLog.Data[0:32]),
+		TreeVersion:     uint32(version.Uint64()),
+		BlockNumber:     int64(vLog.BlockNumber),
+		LogIndex:        int(vLog.Index),
+		TxHash:          strings.ToLower(vLog.TxHash.Hex()),
+	}
+}
+
+// saveAirdropAdminEvents 保存活动创建、激活与默克尔根更新信息到 airdrop_campaigns
+func saveAirdropAdminEvents(created []*AirdropCreatedInfo, activated []string, rootUpdates []*model.MerkleRootUpdatedEvent, chainId int, targetBlockNum uint64, addresses string) error {
 	return ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
 		// 处理创建事件：存在则更新，不存在则插入（token_symbol 用占位符）
 		for _, e := range created {
@@ -299,16 +333,17 @@ func saveAirdropAdminEvents(created []*AirdropCreatedInfo, activated []string, c
 				continue
 			}
 			if err := tx.Exec(`
-                INSERT INTO airdrop_campaigns (airdrop_id, chain_id, merkle_airdrop_contract, name, merkle_root, total_reward, token_symbol, is_active, created_at, updated_at)
-                VALUES (?, ?, LOWER(?), ?, ?, ?, 'CSWAP', FALSE, NOW(), NOW())
+                INSERT INTO airdrop_campaigns (airdrop_id, chain_id, merkle_airdrop_contract, name, merkle_root, total_reward, token_symbol, is_active, created_tx_hash, created_at, updated_at)
+                VALUES (?, ?, LOWER(?), ?, ?, ?, 'CSWAP', FALSE, ?, NOW(), NOW())
                 ON CONFLICT (airdrop_id) DO UPDATE
                 SET chain_id = EXCLUDED.chain_id,
                     merkle_airdrop_contract = EXCLUDED.merkle_airdrop_contract,
                     name = EXCLUDED.name,
                     merkle_root = EXCLUDED.merkle_root,
                     total_reward = EXCLUDED.total_reward,
+                    created_tx_hash = EXCLUDED.created_tx_hash,
                     updated_at = NOW()
-            `, e.AirdropId, e.ChainId, e.ContractAddress, e.Name, e.MerkleRoot, e.TotalReward).Error; err != nil {
+            `, e.AirdropId, e.ChainId, e.ContractAddress, e.Name, e.MerkleRoot, e.TotalReward, e.TxHash).Error; err != nil {
 				log.Logger.Error("保存 AirdropCreated 事件影响活动元数据失败", zap.Error(err))
 				return err
 			}
@@ -327,6 +362,19 @@ func saveAirdropAdminEvents(created []*AirdropCreatedInfo, activated []string, c
 			}
 		}
 
+		// 处理默克尔根更新事件：以链上最新的根为准
+		for _, e := range rootUpdates {
+			if e == nil {
+				continue
+			}
+			if err := tx.Exec(`
+                UPDATE airdrop_campaigns SET merkle_root = ?, updated_at = NOW() WHERE airdrop_id = ?
+            `, e.MerkleRoot, e.AirdropId).Error; err != nil {
+				log.Logger.Error("更新 MerkleRootUpdated 事件失败", zap.Error(err))
+				return err
+			}
+		}
+
 		// 更新链区块高度
 		return updateBlockNumber(chainId, targetBlockNum, addresses)
 	})
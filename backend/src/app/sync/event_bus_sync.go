@@ -0,0 +1,116 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// eventBusDebugGroup 进程内调试消费者使用的消费组
+const eventBusDebugGroup = "alanswap-debug"
+
+// StartEventBusRelay 启动 outbox 中继：轮询未发布的 outbox 记录并发布到 Redis Streams
+func StartEventBusRelay(c context.Context) {
+	if !service.EventBusEnabled() {
+		return
+	}
+	interval := time.Second
+	if config.Conf.EventBus.PollInterval > 0 {
+		interval = time.Duration(config.Conf.EventBus.PollInterval) * time.Millisecond
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Done():
+				log.Logger.Info("事件总线中继停止")
+				return
+			case <-ticker.C:
+				relayOutbox()
+			}
+		}
+	}()
+
+	if config.Conf.EventBus.DebugConsumer {
+		StartEventBusDebugConsumer(c)
+	}
+}
+
+// relayOutbox 连续发布直到 outbox 中没有整批待发布记录
+func relayOutbox() {
+	svc := service.NewEventBusService()
+	batchSize := config.Conf.EventBus.BatchSize
+	for {
+		count, err := svc.PublishPending(batchSize)
+		if err != nil {
+			log.Logger.Error("发布 outbox 事件失败", zap.Error(err))
+			return
+		}
+		if count > 0 {
+			log.Logger.Debug("发布 outbox 事件成功", zap.Int("count", count))
+		}
+		if batchSize <= 0 || count < batchSize {
+			return
+		}
+	}
+}
+
+// StartEventBusDebugConsumer 启动进程内消费者，以独立消费组读取全部事件并打印，用于联调与测试
+func StartEventBusDebugConsumer(c context.Context) {
+	hostname, _ := os.Hostname()
+	consumer := service.NewEventBusConsumer(eventBusDebugGroup, hostname, nil, func(event model.BusEvent) error {
+		log.Logger.Info("事件总线收到事件",
+			zap.String("event_id", event.Id),
+			zap.String("type", event.Type),
+			zap.Int64("chain_id", event.ChainId),
+			zap.Int("version", event.Version),
+			zap.Int64("block_number", event.Data.BlockNumber))
+		return nil
+	})
+	go func() {
+		if err := consumer.Run(c); err != nil {
+			log.Logger.Error("事件总线调试消费者退出", zap.Error(err))
+		}
+	}()
+}
+
+// enqueueLiquidityEvents 在索引事务内把流动性池事件写入 outbox
+func enqueueLiquidityEvents(tx *gorm.DB, events []*model.LiquidityPoolEvent) error {
+	list := make([]model.ExplorerEvent, 0, len(events))
+	for _, e := range events {
+		if event, ok := service.ExplorerEventFromLiquidity(e); ok {
+			list = append(list, event)
+		}
+	}
+	return service.EnqueueOutbox(tx, list)
+}
+
+// enqueueStakeRecords 在索引事务内把质押/提取记录写入 outbox
+func enqueueStakeRecords(tx *gorm.DB, records []*model.UserOperationRecord) error {
+	list := make([]model.ExplorerEvent, 0, len(records))
+	for _, r := range records {
+		if event, ok := service.ExplorerEventFromStake(r); ok {
+			list = append(list, event)
+		}
+	}
+	return service.EnqueueOutbox(tx, list)
+}
+
+// enqueueClaims 在索引事务内把空投领取事件写入 outbox
+func enqueueClaims(tx *gorm.DB, claims []*model.RewardClaimedEvent) error {
+	list := make([]model.ExplorerEvent, 0, len(claims))
+	for _, e := range claims {
+		if e != nil {
+			list = append(list, service.ExplorerEventFromClaim(e))
+		}
+	}
+	return service.EnqueueOutbox(tx, list)
+}
@@ -0,0 +1,53 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// StartPoolFeeDetection 启动池子手续费检测：启动时执行一次，之后每小时整点刷新（登记表变更与工厂 feeTo 开关）
+func StartPoolFeeDetection(c context.Context) {
+	go detectAllPoolFees()
+
+	job := cron.New()
+	if _, err := job.AddFunc("0 * * * *", detectAllPoolFees); err != nil {
+		log.Logger.Error("添加手续费检测定时任务失败", zap.Error(err))
+		return
+	}
+	job.Start()
+	go func() {
+		<-c.Done()
+		job.Stop()
+		log.Logger.Info("手续费检测任务停止")
+	}()
+}
+
+func detectAllPoolFees() {
+	var pools []model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("is_active = ?", true).Find(&pools).Error; err != nil {
+		log.Logger.Error("查询流动性池失败", zap.Error(err))
+		return
+	}
+
+	feeSvc := service.NewFeeService()
+	for _, pool := range pools {
+		updated, err := feeSvc.DetectPoolFees(pool)
+		if err != nil {
+			log.Logger.Warn("检测池子手续费失败", zap.String("pool", pool.PoolAddress), zap.Int64("chain_id", pool.ChainId), zap.Error(err))
+			continue
+		}
+		if updated.FeeBps != pool.FeeBps || updated.ProtocolFeeBps != pool.ProtocolFeeBps {
+			log.Logger.Info("池子手续费配置变更",
+				zap.String("pool", pool.PoolAddress),
+				zap.Int("fee_bps", updated.FeeBps),
+				zap.Int("protocol_fee_bps", updated.ProtocolFeeBps),
+				zap.String("source", updated.FeeSource))
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/mumu/cryptoSwap/src/abi"
 	"github.com/mumu/cryptoSwap/src/app/api"
 	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
 	"github.com/mumu/cryptoSwap/src/core/ctx"
 	"github.com/mumu/cryptoSwap/src/core/log"
 	"go.uber.org/zap"
@@ -43,6 +44,18 @@ func parseLiquidityPoolEvent(vLog types.Log, chainId int, address string) *model
 		return parseBurnEvent(vLog, chainId, address)
 	}
 
+	// V3 池子事件解析
+	switch vLog.Topics[0] {
+	case v3SwapTopic:
+		return parseV3SwapEvent(vLog, chainId, address)
+	case v3MintTopic:
+		return parseV3MintEvent(vLog, chainId, address)
+	case v3BurnTopic:
+		return parseV3BurnEvent(vLog, chainId, address)
+	case v3CollectTopic:
+		return parseV3CollectEvent(vLog, chainId, address)
+	}
+
 	return nil
 }
 func getPoolTokenAddressesFromContract(poolAddress string, chainId int) (string, string, error) {
@@ -52,7 +65,7 @@ func getPoolTokenAddressesFromContract(poolAddress string, chainId int) (string,
 	uniswapV2PairABI, flag := abiManager.GetABI("UniswapV2Pair")
 	if !flag {
 		log.Logger.Error("获取UniswapV2Pair ABI失败")
-		return "", "", fmt.Errorf("获取ABI失败: %w", flag)
+		return "", "", fmt.Errorf("获取UniswapV2Pair ABI失败")
 	}
 
 	contractAddress := common.HexToAddress(poolAddress)
@@ -195,6 +208,7 @@ func parseSwapEvent(vLog types.Log, chainId int, address string) *model.Liquidit
 		ChainId:       int64(chainId),
 		TxHash:        vLog.TxHash.Hex(),
 		BlockNumber:   int64(vLog.BlockNumber),
+		LogIndex:      int(vLog.Index),
 		EventType:     "Swap",
 		PoolAddress:   vLog.Address.Hex(),
 		Token0Address: token0Address,
@@ -230,6 +244,7 @@ func parseMintEvent(vLog types.Log, chainId int, address string) *model.Liquidit
 		ChainId:       int64(chainId),
 		TxHash:        vLog.TxHash.Hex(),
 		BlockNumber:   int64(vLog.BlockNumber),
+		LogIndex:      int(vLog.Index),
 		EventType:     "AddLiquidity",
 		PoolAddress:   vLog.Address.Hex(),
 		Token0Address: token0Address,
@@ -266,6 +281,7 @@ func parseBurnEvent(vLog types.Log, chainId int, address string) *model.Liquidit
 		ChainId:       int64(chainId),
 		TxHash:        vLog.TxHash.Hex(),
 		BlockNumber:   int64(vLog.BlockNumber),
+		LogIndex:      int(vLog.Index),
 		EventType:     "RemoveLiquidity",
 		PoolAddress:   vLog.Address.Hex(),
 		Token0Address: token0Address,
@@ -284,24 +300,51 @@ func parseBurnEvent(vLog types.Log, chainId int, address string) *model.Liquidit
 }
 
 // saveLiquidityPoolEvents 保存流动性池事件到数据库
-func saveLiquidityPoolEvents(events []*model.LiquidityPoolEvent, chainId int, targetBlockNum uint64, addresses string) error {
-	if len(events) == 0 {
-		return updateLiquidityPoolBlockNumber(chainId, targetBlockNum, addresses)
+func saveLiquidityPoolEvents(events []*model.LiquidityPoolEvent, transfers []*model.LpTransferEvent, chainId int, targetBlockNum uint64, addresses string) error {
+	if len(events) == 0 && len(transfers) == 0 {
+		if err := updateLiquidityPoolBlockNumber(chainId, targetBlockNum, addresses); err != nil {
+			return err
+		}
+		rebuildPendingCandles(chainId)
+		return nil
 	}
 
-	return ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+	// 新池子的代币信息需要多次 RPC，在开启事务前读取
+	newPools := fetchNewPools(events)
+
+	err := ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
 		// 批量插入流动性池事件
-		if err := tx.CreateInBatches(events, 100).Error; err != nil {
-			log.Logger.Error("批量插入流动性池事件失败", zap.Error(err))
-			return err
+		if len(events) > 0 {
+			if err := tx.CreateInBatches(events, 100).Error; err != nil {
+				log.Logger.Error("批量插入流动性池事件失败", zap.Error(err))
+				return err
+			}
 		}
 
 		// 更新流动性池信息
-		if err := updateLiquidityPoolInfo(tx, events); err != nil {
+		if err := updateLiquidityPoolInfo(tx, events, newPools); err != nil {
 			log.Logger.Error("更新流动性池信息失败", zap.Error(err))
 			return err
 		}
 
+		// 增量更新K线
+		if err := updatePoolCandles(tx, events); err != nil {
+			log.Logger.Error("更新池子K线失败", zap.Error(err))
+			return err
+		}
+
+		// 写入LP转账事件并更新LP持仓
+		if err := updateLpPositions(tx, events, transfers); err != nil {
+			log.Logger.Error("更新LP持仓失败", zap.Error(err))
+			return err
+		}
+
+		// 写入事件总线 outbox，随本事务提交
+		if err := enqueueLiquidityEvents(tx, events); err != nil {
+			log.Logger.Error("写入事件总线 outbox 失败", zap.Error(err))
+			return err
+		}
+
 		// 根据事件标记对应的任务为已完成（自动验证类）
 		for _, e := range events {
 			switch e.EventType {
@@ -319,6 +362,18 @@ func saveLiquidityPoolEvents(events []*model.LiquidityPoolEvent, chainId int, ta
 		// 更新区块号
 		return updateLiquidityPoolBlockNumber(chainId, targetBlockNum, addresses)
 	})
+	if err != nil {
+		return err
+	}
+	rebuildPendingCandles(chainId)
+	return nil
+}
+
+// rebuildPendingCandles 在索引事务之外重建回补历史区块时标记的池子K线，失败时保留标记，下一批次重试
+func rebuildPendingCandles(chainId int) {
+	if err := service.NewCandleService().RebuildPendingCandles(int64(chainId)); err != nil {
+		log.Logger.Warn("重建池子K线失败", zap.Int("chain_id", chainId), zap.Error(err))
+	}
 }
 
 // 根据任务名将用户任务状态设为完成（2）。仅作用于 verify_type = 'auto' 的任务。
@@ -349,8 +404,61 @@ func calculatePrice(reserve0, reserve1 *big.Int) string {
 	return price.Text('f', 18) // 保留18位小数
 }
 
-// updateLiquidityPoolInfo 更新流动性池信息
-func updateLiquidityPoolInfo(tx *gorm.DB, events []*model.LiquidityPoolEvent) error {
+// fetchNewPools 为尚未登记的池子从链上读取代币地址、符号与精度，key 为池子地址
+func fetchNewPools(events []*model.LiquidityPoolEvent) map[string]model.LiquidityPool {
+	pools := make(map[string]model.LiquidityPool)
+	for _, event := range events {
+		if _, ok := pools[event.PoolAddress]; ok {
+			continue
+		}
+		var count int64
+		if err := ctx.Ctx.DB.Model(&model.LiquidityPool{}).Where("pool_address = ? AND chain_id = ?", event.PoolAddress, event.ChainId).Count(&count).Error; err != nil || count > 0 {
+			continue
+		}
+		pools[event.PoolAddress] = newLiquidityPool(event)
+	}
+	return pools
+}
+
+// newLiquidityPool 按池子的首个事件构造池子记录，代币地址与代币信息从合约读取
+func newLiquidityPool(event *model.LiquidityPoolEvent) model.LiquidityPool {
+	// 创建新的流动性池记录前，先获取真实的代币地址
+	token0Address, token1Address, err := getPoolTokenAddressesFromContract(event.PoolAddress, int(event.ChainId))
+	if err != nil {
+		log.Logger.Warn("创建流动性池时获取代币地址失败，使用默认值",
+			zap.String("pool_address", event.PoolAddress),
+			zap.Int64("chain_id", event.ChainId),
+			zap.Error(err))
+		token0Address = "0x0000000000000000000000000000000000000000"
+		token1Address = "0x0000000000000000000000000000000000000000"
+	}
+
+	// 获取代币符号与精度，定价服务依赖这些信息
+	token0Symbol, token0Decimals := getTokenMetadata(token0Address, event.ChainId)
+	token1Symbol, token1Decimals := getTokenMetadata(token1Address, event.ChainId)
+
+	return model.LiquidityPool{
+		ChainId:        event.ChainId,
+		PoolAddress:    event.PoolAddress,
+		PoolType:       event.PoolType,
+		Token0Address:  token0Address, // 使用从合约获取的真实地址
+		Token1Address:  token1Address, // 使用从合约获取的真实地址
+		Token0Symbol:   token0Symbol,
+		Token1Symbol:   token1Symbol,
+		Token0Decimals: token0Decimals,
+		Token1Decimals: token1Decimals,
+		Reserve0:       "0", // 默认值
+		Reserve1:       "0", // 默认值
+		TotalSupply:    "0", // 默认值
+		Price:          "0", // 默认值
+		Volume24h:      "0", // 默认值
+		TxCount:        0,   // 默认值
+		IsActive:       true,
+	}
+}
+
+// updateLiquidityPoolInfo 更新流动性池信息，newPools 为事务前读取的新池子信息
+func updateLiquidityPoolInfo(tx *gorm.DB, events []*model.LiquidityPoolEvent, newPools map[string]model.LiquidityPool) error {
 	// 按池子地址分组
 	poolEvents := make(map[string][]*model.LiquidityPoolEvent)
 	for _, event := range events {
@@ -362,36 +470,13 @@ func updateLiquidityPoolInfo(tx *gorm.DB, events []*model.LiquidityPoolEvent) er
 		var pool model.LiquidityPool
 		err := tx.Where("pool_address = ? AND chain_id = ?", poolAddress, poolEventList[0].ChainId).First(&pool).Error
 		if err == gorm.ErrRecordNotFound {
-			// 创建新的流动性池记录前，先获取真实的代币地址
-			token0Address, token1Address, err := getPoolTokenAddressesFromContract(poolAddress, int(poolEventList[0].ChainId))
-			if err != nil {
-				log.Logger.Warn("创建流动性池时获取代币地址失败，使用默认值",
-					zap.String("pool_address", poolAddress),
-					zap.Int64("chain_id", poolEventList[0].ChainId),
-					zap.Error(err))
-				token0Address = "0x0000000000000000000000000000000000000000"
-				token1Address = "0x0000000000000000000000000000000000000000"
-			}
-
-			// 创建新的流动性池记录
-			pool = model.LiquidityPool{
-				ChainId:        poolEventList[0].ChainId,
-				PoolAddress:    poolAddress,
-				Token0Address:  token0Address, // 使用从合约获取的真实地址
-				Token1Address:  token1Address, // 使用从合约获取的真实地址
-				Token0Symbol:   "",            // 暂时留空
-				Token1Symbol:   "",            // 暂时留空
-				Token0Decimals: 0,             // 默认值
-				Token1Decimals: 0,             // 默认值
-				Reserve0:       "0",           // 默认值
-				Reserve1:       "0",           // 默认值
-				TotalSupply:    "0",           // 默认值
-				Price:          "0",           // 默认值
-				Volume24h:      "0",           // 默认值
-				TxCount:        0,             // 默认值
-				LastBlockNum:   poolEventList[len(poolEventList)-1].BlockNumber,
-				IsActive:       true,
+			created, ok := newPools[poolAddress]
+			if !ok {
+				// 事务前查询池子失败时未预取，回退为在事务内读取
+				created = newLiquidityPool(poolEventList[0])
 			}
+			pool = created
+			pool.LastBlockNum = poolEventList[len(poolEventList)-1].BlockNumber
 
 			if err := tx.Create(&pool).Error; err != nil {
 				log.Logger.Error("创建流动性池记录失败", zap.Error(err))
@@ -413,6 +498,15 @@ func updateLiquidityPoolInfo(tx *gorm.DB, events []*model.LiquidityPoolEvent) er
 			log.Logger.Error("更新流动性池交易计数失败", zap.Error(err))
 			return err
 		}
+		// V3 池子读取 slot0 与代币余额
+		if service.PoolModelOf(pool).Type() == service.PoolTypeV3 {
+			if err := refreshV3PoolState(tx, &pool); err != nil {
+				log.Logger.Error("更新V3池子状态失败", zap.Error(err))
+				return err
+			}
+			continue
+		}
+
 		// 直接从Uniswap V2池子合约获取最新储备量
 		reserve0, reserve1, totalSupply, err := api.GetPoolReserves(poolAddress, int(poolEventList[0].ChainId))
 		if err != nil {
@@ -442,6 +536,42 @@ func updateLiquidityPoolInfo(tx *gorm.DB, events []*model.LiquidityPoolEvent) er
 	return nil
 }
 
+// updatePoolCandles 按池子分组，将本批次Swap事件聚合进K线（需回补的池子只标记重建）
+func updatePoolCandles(tx *gorm.DB, events []*model.LiquidityPoolEvent) error {
+	poolSwaps := make(map[string][]model.LiquidityPoolEvent)
+	for _, e := range events {
+		if e.EventType == "Swap" {
+			poolSwaps[e.PoolAddress] = append(poolSwaps[e.PoolAddress], *e)
+		}
+	}
+
+	candleSvc := service.NewCandleService()
+	for poolAddress, swaps := range poolSwaps {
+		var pool model.LiquidityPool
+		if err := tx.Where("pool_address = ? AND chain_id = ?", poolAddress, swaps[0].ChainId).First(&pool).Error; err != nil {
+			return err
+		}
+		if err := candleSvc.ApplySwaps(tx, pool, swaps); err != nil {
+			log.Logger.Error("聚合K线失败", zap.String("pool", poolAddress), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// getTokenMetadata 获取代币符号与精度，失败时返回空符号与18位精度
+func getTokenMetadata(tokenAddress string, chainId int64) (string, int) {
+	if tokenAddress == "" || tokenAddress == "0x0000000000000000000000000000000000000000" || ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return "", 18
+	}
+	symbol, decimals, err := service.NewTokenService().GetTokenDetails(tokenAddress, chainId)
+	if err != nil || symbol == "" {
+		log.Logger.Warn("获取代币信息失败", zap.String("token", tokenAddress), zap.Int64("chain_id", chainId), zap.Error(err))
+		return "", 18
+	}
+	return symbol, decimals
+}
+
 // updateLiquidityPoolBlockNumber 更新流动性池监听的区块号
 func updateLiquidityPoolBlockNumber(chainId int, blockNumber uint64, address string) error {
 	// 更新流动性池服务配置的区块号
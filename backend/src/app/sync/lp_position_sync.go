@@ -0,0 +1,225 @@
+package sync
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/chainclient/evm"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var lpTransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// parseLpTransferEvent 解析LP代币Transfer事件（ERC721 的 Transfer 有4个topic，直接忽略）
+func parseLpTransferEvent(vLog types.Log, chainId int) *model.LpTransferEvent {
+	if len(vLog.Topics) != 3 || len(vLog.Data) < 32 {
+		return nil
+	}
+
+	// Transfer(address indexed from, address indexed to, uint value)
+	from := common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
+	to := common.BytesToAddress(vLog.Topics[2].Bytes()).Hex()
+	amount := new(big.Int).SetBytes(common.TrimLeftZeroes(vLog.Data[0:32]))
+	return &model.LpTransferEvent{
+		ChainId:     int64(chainId),
+		PoolAddress: vLog.Address.Hex(),
+		TxHash:      vLog.TxHash.Hex(),
+		BlockNumber: int64(vLog.BlockNumber),
+		LogIndex:    int(vLog.Index),
+		FromAddress: strings.ToLower(from),
+		ToAddress:   strings.ToLower(to),
+		Amount:      amount.String(),
+	}
+}
+
+// updateLpPositions 按池子分组写入LP转账事件并按池子类型累计持仓；发出合约不是已知池子的转账（普通ERC20）直接丢弃
+func updateLpPositions(tx *gorm.DB, events []*model.LiquidityPoolEvent, transfers []*model.LpTransferEvent) error {
+	poolTransfers := make(map[string][]model.LpTransferEvent)
+	poolEvents := make(map[string][]model.LiquidityPoolEvent)
+	chainOf := make(map[string]int64)
+	for _, t := range transfers {
+		poolTransfers[t.PoolAddress] = append(poolTransfers[t.PoolAddress], *t)
+		chainOf[t.PoolAddress] = t.ChainId
+	}
+	for _, e := range events {
+		if e.EventType == "AddLiquidity" || e.EventType == "RemoveLiquidity" || e.EventType == "Collect" {
+			poolEvents[e.PoolAddress] = append(poolEvents[e.PoolAddress], *e)
+			chainOf[e.PoolAddress] = e.ChainId
+		}
+	}
+
+	for poolAddress, chainId := range chainOf {
+		// 锁定池子行，与持仓重建互斥
+		var pool model.LiquidityPool
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("pool_address = ? AND chain_id = ?", poolAddress, chainId).First(&pool).Error
+		if err == gorm.ErrRecordNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		newTransfers, err := insertLpTransfers(tx, poolTransfers[poolAddress])
+		if err != nil {
+			log.Logger.Error("保存LP转账事件失败", zap.String("pool", poolAddress), zap.Error(err))
+			return err
+		}
+		if err := service.PoolModelOf(pool).ApplyPositions(tx, pool, newTransfers, poolEvents[poolAddress]); err != nil {
+			log.Logger.Error("更新LP持仓失败", zap.String("pool", poolAddress), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// insertLpTransfers 写入LP转账事件，返回实际新增的记录（已存在的跳过，避免重复累计）
+func insertLpTransfers(tx *gorm.DB, transfers []model.LpTransferEvent) ([]model.LpTransferEvent, error) {
+	if len(transfers) == 0 {
+		return nil, nil
+	}
+	hashes := make([]string, 0, len(transfers))
+	for _, t := range transfers {
+		hashes = append(hashes, t.TxHash)
+	}
+	var existing []model.LpTransferEvent
+	if err := tx.Select("tx_hash, log_index").
+		Where("chain_id = ? AND tx_hash IN ?", transfers[0].ChainId, hashes).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		seen[lpTransferKey(e)] = true
+	}
+
+	var fresh []model.LpTransferEvent
+	for _, t := range transfers {
+		if !seen[lpTransferKey(t)] {
+			seen[lpTransferKey(t)] = true
+			fresh = append(fresh, t)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+	if err := tx.CreateInBatches(fresh, 100).Error; err != nil {
+		return nil, err
+	}
+	return fresh, nil
+}
+
+func lpTransferKey(t model.LpTransferEvent) string {
+	return t.TxHash + ":" + strconv.Itoa(t.LogIndex)
+}
+
+// StartLpPositionBackfill 启动LP持仓回补：对首个LP转账晚于首个流动性事件（或尚无转账）的池子，
+// 从链上补拉历史Transfer日志后重建持仓
+func StartLpPositionBackfill(c context.Context) {
+	go func() {
+		var pools []model.LiquidityPool
+		if err := ctx.Ctx.DB.Find(&pools).Error; err != nil {
+			log.Logger.Error("查询流动性池失败", zap.Error(err))
+			return
+		}
+		for _, pool := range pools {
+			select {
+			case <-c.Done():
+				return
+			default:
+			}
+			if err := backfillPoolLpTransfers(c, pool); err != nil {
+				log.Logger.Error("回补LP持仓失败", zap.String("pool", pool.PoolAddress), zap.Int64("chain_id", pool.ChainId), zap.Error(err))
+			}
+		}
+	}()
+}
+
+func backfillPoolLpTransfers(c context.Context, pool model.LiquidityPool) error {
+	// V3 池子没有LP代币，仓位完全由已索引的池子事件得出
+	if !service.PoolModelOf(pool).FungibleShares() {
+		return nil
+	}
+	var firstEventBlock, firstTransferBlock *int64
+	if err := ctx.Ctx.DB.Model(&model.LiquidityPoolEvent{}).
+		Where("chain_id = ? AND pool_address = ?", pool.ChainId, pool.PoolAddress).
+		Select("MIN(block_number)").Scan(&firstEventBlock).Error; err != nil {
+		return err
+	}
+	if firstEventBlock == nil {
+		return nil
+	}
+	if err := ctx.Ctx.DB.Model(&model.LpTransferEvent{}).
+		Where("chain_id = ? AND pool_address = ?", pool.ChainId, pool.PoolAddress).
+		Select("MIN(block_number)").Scan(&firstTransferBlock).Error; err != nil {
+		return err
+	}
+	if firstTransferBlock != nil && *firstTransferBlock <= *firstEventBlock {
+		return nil
+	}
+	if ctx.Ctx.ChainMap[int(pool.ChainId)] == nil {
+		return nil
+	}
+	evmClient := ctx.GetClient(int(pool.ChainId)).(*evm.Evm)
+
+	// 只回补实时索引已经处理过的区块，之后的区块由实时索引写入
+	var endBlock int64
+	if err := ctx.Ctx.DB.Model(&model.Chain{}).
+		Where("chain_id = ? AND LOWER(address) = LOWER(?)", pool.ChainId, pool.PoolAddress).
+		Select("COALESCE(MAX(last_block_num), 0)").Scan(&endBlock).Error; err != nil {
+		return err
+	}
+	if endBlock == 0 {
+		endBlock = pool.LastBlockNum
+	}
+
+	log.Logger.Info("开始回补LP转账事件", zap.String("pool", pool.PoolAddress), zap.Int64("from_block", *firstEventBlock), zap.Int64("to_block", endBlock))
+	blockTimes := make(map[uint64]time.Time)
+	total := 0
+	for from := *firstEventBlock; from <= endBlock; from += 1000 {
+		select {
+		case <-c.Done():
+			return nil
+		default:
+		}
+		to := from + 999
+		if to > endBlock {
+			to = endBlock
+		}
+		logs, err := evmClient.GetFilterLogsWithTopics(big.NewInt(from), big.NewInt(to), []string{pool.PoolAddress}, [][]common.Hash{{lpTransferTopic}})
+		if err != nil {
+			return err
+		}
+		var transfers []model.LpTransferEvent
+		for _, vLog := range logs {
+			if t := parseLpTransferEvent(vLog, int(pool.ChainId)); t != nil {
+				t.BlockTime = blockTimeOf(evmClient, vLog.BlockNumber, blockTimes)
+				transfers = append(transfers, *t)
+			}
+		}
+		if len(transfers) > 0 {
+			if err := ctx.Ctx.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(transfers, 100).Error; err != nil {
+				return err
+			}
+			total += len(transfers)
+		}
+	}
+
+	if err := ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		return service.PoolModelOf(pool).RebuildPositions(tx, pool)
+	}); err != nil {
+		return err
+	}
+	log.Logger.Info("回补LP持仓完成", zap.String("pool", pool.PoolAddress), zap.Int("transfer_count", total))
+	return nil
+}
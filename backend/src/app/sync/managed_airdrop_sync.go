@@ -0,0 +1,37 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+const managedAirdropReconcileInterval = 30 * time.Second
+
+// StartManagedAirdropReconcile 启动空投活动生命周期跟踪：按托管交易结果与索引的 AirdropCreated、AirdropActivated 事件推进状态
+func StartManagedAirdropReconcile(c context.Context) {
+	svc := service.NewManagedAirdropService()
+	go func() {
+		ticker := time.NewTicker(managedAirdropReconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Done():
+				log.Logger.Info("空投活动生命周期跟踪任务停止")
+				return
+			case <-ticker.C:
+				count, err := svc.Reconcile()
+				if err != nil {
+					log.Logger.Error("空投活动生命周期跟踪失败", zap.Error(err))
+					continue
+				}
+				if count > 0 {
+					log.Logger.Info("空投活动状态已更新", zap.Int("count", count))
+				}
+			}
+		}
+	}()
+}
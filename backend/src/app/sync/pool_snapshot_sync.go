@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"context"
+	gosync "sync"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+var poolSnapshotMu gosync.Mutex
+
+// StartPoolSnapshot 启动池子小时快照任务：启动时先回补历史，之后每小时第1分钟生成上一小时的快照
+func StartPoolSnapshot(c context.Context) {
+	go buildAllPoolSnapshots()
+
+	job := cron.New()
+	if _, err := job.AddFunc("1 * * * *", buildAllPoolSnapshots); err != nil {
+		log.Logger.Error("添加池子快照定时任务失败", zap.Error(err))
+		return
+	}
+	job.Start()
+	go func() {
+		<-c.Done()
+		job.Stop()
+		log.Logger.Info("池子快照任务停止")
+	}()
+}
+
+func buildAllPoolSnapshots() {
+	// 回补耗时较长时跳过本轮，避免与上一轮重叠
+	if !poolSnapshotMu.TryLock() {
+		log.Logger.Warn("上一轮池子快照尚未完成，跳过本轮")
+		return
+	}
+	defer poolSnapshotMu.Unlock()
+
+	var pools []model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("is_active = ?", true).Find(&pools).Error; err != nil {
+		log.Logger.Error("查询流动性池失败", zap.Error(err))
+		return
+	}
+
+	snapshotSvc := service.NewPoolSnapshotService()
+	now := time.Now()
+	for _, pool := range pools {
+		count, err := snapshotSvc.BuildSnapshots(pool, now)
+		if err != nil {
+			log.Logger.Error("生成池子快照失败", zap.String("pool", pool.PoolAddress), zap.Int64("chain_id", pool.ChainId), zap.Error(err))
+			continue
+		}
+		if count > 0 {
+			log.Logger.Info("生成池子快照成功", zap.String("pool", pool.PoolAddress), zap.Int("count", count))
+		}
+	}
+}
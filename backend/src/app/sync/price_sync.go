@@ -0,0 +1,64 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+// StartPriceSnapshot 定时计算代币USD价格并写入历史价格表
+func StartPriceSnapshot(c context.Context, interval time.Duration) {
+	warnUnpricedChains()
+	go func() {
+		snapshotAllChainPrices()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Done():
+				log.Logger.Info("价格快照任务停止")
+				return
+			case <-ticker.C:
+				snapshotAllChainPrices()
+			}
+		}
+	}()
+}
+
+// priceSnapshotInterval 价格快照间隔，未配置时默认5分钟
+func priceSnapshotInterval() time.Duration {
+	if config.Conf.Price.SnapshotInterval > 0 {
+		return time.Duration(config.Conf.Price.SnapshotInterval) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+func snapshotAllChainPrices() {
+	priceSvc := service.NewPriceService()
+	now := time.Now().Truncate(time.Minute)
+	for chainId := range ctx.Ctx.ChainMap {
+		count, err := priceSvc.SnapshotPrices(int64(chainId), now)
+		if err != nil {
+			log.Logger.Error("写入代币价格快照失败", zap.Int("chain_id", chainId), zap.Error(err))
+			continue
+		}
+		log.Logger.Info("写入代币价格快照成功", zap.Int("chain_id", chainId), zap.Int("token_count", count))
+	}
+}
+
+// warnUnpricedChains 启动时检查每条已配置的链是否有定价来源，未配置 price.stable_tokens 或 price.manual_feeds 时所有代币都无法定价
+func warnUnpricedChains() {
+	chainIds := make([]int64, 0, len(config.Conf.Chains))
+	for _, chain := range config.Conf.Chains {
+		chainIds = append(chainIds, int64(chain.ChainId))
+	}
+	for _, chainId := range service.UnpricedChains(chainIds) {
+		log.Logger.Error("链未配置稳定币锚点或手动价格，所有代币都不会被定价，TVL、APY 与价格快照将为空；请在 [[price.stable_tokens]] 中按地址配置稳定币",
+			zap.Int64("chain_id", chainId))
+	}
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"context"
+	gosync "sync"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+var protocolStatsMu gosync.Mutex
+
+// StartProtocolDailyStats 启动协议每日汇总任务：启动时回补历史，之后每小时第5分钟（小时快照生成之后）刷新当天汇总
+func StartProtocolDailyStats(c context.Context) {
+	go rollupProtocolStats()
+
+	job := cron.New()
+	if _, err := job.AddFunc("5 * * * *", rollupProtocolStats); err != nil {
+		log.Logger.Error("添加协议汇总定时任务失败", zap.Error(err))
+		return
+	}
+	job.Start()
+	go func() {
+		<-c.Done()
+		job.Stop()
+		log.Logger.Info("协议汇总任务停止")
+	}()
+}
+
+func rollupProtocolStats() {
+	if !protocolStatsMu.TryLock() {
+		log.Logger.Warn("上一轮协议汇总尚未完成，跳过本轮")
+		return
+	}
+	defer protocolStatsMu.Unlock()
+
+	chainIds, err := service.RollupChainIds()
+	if err != nil {
+		log.Logger.Error("查询链信息失败", zap.Error(err))
+		return
+	}
+
+	statsSvc := service.NewProtocolStatsService()
+	now := time.Now()
+	for _, chainId := range chainIds {
+		count, err := statsSvc.Rollup(chainId, now)
+		if err != nil {
+			log.Logger.Error("生成协议每日汇总失败", zap.Int64("chain_id", chainId), zap.Error(err))
+			continue
+		}
+		log.Logger.Info("生成协议每日汇总成功", zap.Int64("chain_id", chainId), zap.Int("days", count))
+	}
+}
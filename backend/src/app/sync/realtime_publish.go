@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"strings"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+// publishLiquidityBatch 流动性池事件提交后推送事件消息，并推送涉及池子的最新储备与价格
+func publishLiquidityBatch(chainId int, events []*model.LiquidityPoolEvent) {
+	if len(events) == 0 {
+		return
+	}
+	msgs := service.RealtimeFromLiquidityEvents(events)
+
+	seen := make(map[string]bool)
+	var poolAddresses []string
+	for _, e := range events {
+		addr := strings.ToLower(e.PoolAddress)
+		if !seen[addr] {
+			seen[addr] = true
+			poolAddresses = append(poolAddresses, addr)
+		}
+	}
+	var pools []model.LiquidityPool
+	if err := ctx.Ctx.DB.Where("chain_id = ? AND LOWER(pool_address) IN ?", chainId, poolAddresses).Find(&pools).Error; err != nil {
+		log.Logger.Warn("查询推送的池子状态失败", zap.Int("chain_id", chainId), zap.Error(err))
+	} else {
+		msgs = append(msgs, service.RealtimeFromPools(pools)...)
+	}
+	service.NewRealtimeService().Publish(msgs)
+}
+
+// publishStakeBatch 质押/提取记录提交后推送
+func publishStakeBatch(records []*model.UserOperationRecord) {
+	service.NewRealtimeService().Publish(service.RealtimeFromStakeRecords(records))
+}
+
+// publishClaimBatch 空投领取事件提交后推送
+func publishClaimBatch(events *AirdropEvents) {
+	if events == nil {
+		return
+	}
+	service.NewRealtimeService().Publish(service.RealtimeFromClaims(events.RewardClaimedEvents))
+}
@@ -0,0 +1,41 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+const safeProposalReconcileInterval = 30 * time.Second
+
+// StartSafeProposalReconcile 启动 Safe 提案跟踪：查找 Safe 执行事件，执行后按已索引的数据确认操作结果
+func StartSafeProposalReconcile(c context.Context) {
+	if !config.Conf.Safe.Enabled {
+		return
+	}
+	svc := service.NewSafeProposalService()
+	go func() {
+		ticker := time.NewTicker(safeProposalReconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Done():
+				log.Logger.Info("Safe 提案跟踪任务停止")
+				return
+			case <-ticker.C:
+				count, err := svc.ReconcileProposals()
+				if err != nil {
+					log.Logger.Error("Safe 提案跟踪失败", zap.Error(err))
+					continue
+				}
+				if count > 0 {
+					log.Logger.Info("Safe 提案状态已更新", zap.Int("count", count))
+				}
+			}
+		}
+	}()
+}
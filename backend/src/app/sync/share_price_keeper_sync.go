@@ -0,0 +1,60 @@
+package sync
+
+import (
+	"context"
+	gosync "sync"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const defaultSharePriceCron = "CRON_TZ=UTC 5 0 * * *"
+
+var sharePriceKeeperMu gosync.Mutex
+
+// StartSharePriceKeeper 启动质押池日份额价格维护任务，默认每天 UTC 00:05 执行
+func StartSharePriceKeeper(c context.Context) {
+	if !config.Conf.SharePriceKeeper.Enabled {
+		return
+	}
+	keeper, err := service.NewSharePriceKeeperService()
+	if err != nil {
+		log.Logger.Error("份额价格维护任务未启动", zap.Error(err))
+		return
+	}
+	spec := config.Conf.SharePriceKeeper.Cron
+	if spec == "" {
+		spec = defaultSharePriceCron
+	}
+
+	job := cron.New()
+	if _, err := job.AddFunc(spec, func() { runSharePriceKeeper(keeper) }); err != nil {
+		log.Logger.Error("添加份额价格维护定时任务失败", zap.String("cron", spec), zap.Error(err))
+		return
+	}
+	job.Start()
+	go func() {
+		<-c.Done()
+		job.Stop()
+		log.Logger.Info("份额价格维护任务停止")
+	}()
+}
+
+func runSharePriceKeeper(keeper *service.SharePriceKeeperService) {
+	if !sharePriceKeeperMu.TryLock() {
+		log.Logger.Warn("上一轮份额价格维护尚未完成，跳过本轮")
+		return
+	}
+	defer sharePriceKeeperMu.Unlock()
+
+	counts, err := keeper.Run(time.Now())
+	if err != nil {
+		log.Logger.Error("份额价格维护失败", zap.Error(err))
+		return
+	}
+	log.Logger.Info("份额价格维护完成", zap.Any("status_counts", counts))
+}
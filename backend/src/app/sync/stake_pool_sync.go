@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/contract"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stakePoolCreatedTopic 质押合约 PoolCreated(uint256 indexed poolId, address indexed token, uint256 lockDuration, string name)
+var stakePoolCreatedTopic = func() common.Hash {
+	stakeAbi, err := contract.AbiMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	return stakeAbi.Events["PoolCreated"].ID
+}()
+
+// parseStakePoolCreated 解析质押池创建事件
+func parseStakePoolCreated(vLog types.Log, chainId int) *model.StakePool {
+	if len(vLog.Topics) < 3 {
+		log.Logger.Warn("PoolCreated 事件 topics 数量不足", zap.String("tx_hash", vLog.TxHash.Hex()))
+		return nil
+	}
+	stakeAbi, err := contract.AbiMetaData.GetAbi()
+	if err != nil {
+		log.Logger.Error("加载质押合约ABI失败", zap.Error(err))
+		return nil
+	}
+	values, err := stakeAbi.Unpack("PoolCreated", vLog.Data)
+	if err != nil || len(values) < 2 {
+		log.Logger.Error("解析 PoolCreated 事件失败", zap.String("tx_hash", vLog.TxHash.Hex()), zap.Error(err))
+		return nil
+	}
+	lockDuration, _ := values[0].(*big.Int)
+	name, _ := values[1].(string)
+
+	pool := &model.StakePool{
+		ChainId:         int64(chainId),
+		ContractAddress: strings.ToLower(vLog.Address.Hex()),
+		PoolId:          vLog.Topics[1].Big().Int64(),
+		TokenAddress:    strings.ToLower(common.BytesToAddress(vLog.Topics[2].Bytes()).Hex()),
+		Name:            name,
+		BlockNumber:     int64(vLog.BlockNumber),
+		TxHash:          vLog.TxHash.Hex(),
+	}
+	if lockDuration != nil {
+		pool.LockDuration = lockDuration.Int64()
+	}
+	return pool
+}
+
+// 质押合约管理事件
+var (
+	stakeV2PausedTopic      = stakeV2EventId("Paused")
+	stakeV2UnpausedTopic    = stakeV2EventId("Unpaused")
+	stakeV2RoleGrantedTopic = stakeV2EventId("RoleGranted")
+	stakeV2RoleRevokedTopic = stakeV2EventId("RoleRevoked")
+)
+
+// parseStakingAdminEvent 解析质押合约的暂停、恢复与角色变更事件
+func parseStakingAdminEvent(vLog types.Log, chainId int) *model.StakingAdminEvent {
+	event := &model.StakingAdminEvent{
+		ChainId:         int64(chainId),
+		ContractAddress: strings.ToLower(vLog.Address.Hex()),
+		BlockNumber:     int64(vLog.BlockNumber),
+		LogIndex:        int(vLog.Index),
+		TxHash:          vLog.TxHash.Hex(),
+	}
+	switch vLog.Topics[0] {
+	case stakeV2PausedTopic, stakeV2UnpausedTopic:
+		// Paused(address account) / Unpaused(address account)
+		if len(vLog.Data) < 32 {
+			return nil
+		}
+		event.EventType = "Paused"
+		if vLog.Topics[0] == stakeV2UnpausedTopic {
+			event.EventType = "Unpaused"
+		}
+		event.Account = strings.ToLower(common.BytesToAddress(vLog.Data[0:32]).Hex())
+	case stakeV2RoleGrantedTopic, stakeV2RoleRevokedTopic:
+		// RoleGranted(bytes32 indexed role, address indexed account, address indexed sender)
+		if len(vLog.Topics) < 4 {
+			return nil
+		}
+		event.EventType = "RoleGranted"
+		if vLog.Topics[0] == stakeV2RoleRevokedTopic {
+			event.EventType = "RoleRevoked"
+		}
+		event.Role = vLog.Topics[1].Hex()
+		event.Account = strings.ToLower(common.BytesToAddress(vLog.Topics[2].Bytes()).Hex())
+		event.Sender = strings.ToLower(common.BytesToAddress(vLog.Topics[3].Bytes()).Hex())
+	default:
+		return nil
+	}
+	return event
+}
+
+// saveStakeContractEvents 保存新建的质押池与管理事件并更新区块高度，重复事件忽略
+func saveStakeContractEvents(pools []*model.StakePool, adminEvents []*model.StakingAdminEvent, chainId int, targetBlockNum uint64, address string) error {
+	return ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if len(pools) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(pools, 100).Error; err != nil {
+				log.Logger.Error("保存质押池失败", zap.Error(err))
+				return err
+			}
+		}
+		if len(adminEvents) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(adminEvents, 100).Error; err != nil {
+				log.Logger.Error("保存质押合约管理事件失败", zap.Error(err))
+				return err
+			}
+		}
+		if err := tx.Model(&model.Chain{}).Where("chain_id = ? AND address = ?", int64(chainId), address).Update("last_block_num", targetBlockNum).Error; err != nil {
+			log.Logger.Error("更新质押池最后区块号失败", zap.Int("chain_id", chainId), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+}
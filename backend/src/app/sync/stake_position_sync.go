@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/contract"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// StakeV2 合约事件：
+// Staked(address indexed user, uint256 indexed poolId, uint256 amount, uint256 stakedAt, uint256 unlockTime)
+// Withdrawn(address indexed user, uint256 indexed poolId, uint256 amount, uint256 reward, uint256 withdrawnAt)
+// ClaimRewards(address indexed user, uint256 indexed poolId, uint256 reward, uint256 claimedAt)
+var (
+	stakeV2StakedTopic       = stakeV2EventId("Staked")
+	stakeV2WithdrawnTopic    = stakeV2EventId("Withdrawn")
+	stakeV2ClaimRewardsTopic = stakeV2EventId("ClaimRewards")
+)
+
+func stakeV2EventId(name string) common.Hash {
+	stakeAbi, err := contract.AbiMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	return stakeAbi.Events[name].ID
+}
+
+// parseStakeV2Event 解析 StakeV2 的质押、提取与领取收益事件，时间字段为秒级时间戳
+func parseStakeV2Event(vLog types.Log, chainId int) *model.UserOperationRecord {
+	if len(vLog.Topics) < 3 {
+		return nil
+	}
+	word := func(i int) *big.Int {
+		return new(big.Int).SetBytes(vLog.Data[i*32 : (i+1)*32])
+	}
+	record := &model.UserOperationRecord{
+		ChainId:         int64(chainId),
+		Address:         common.BytesToAddress(vLog.Topics[1].Bytes()).Hex(),
+		PoolId:          vLog.Topics[2].Big().Int64(),
+		TxHash:          vLog.TxHash.Hex(),
+		BlockNumber:     int64(vLog.BlockNumber),
+		ContractAddress: vLog.Address.Hex(),
+		LogIndex:        int(vLog.Index),
+	}
+
+	switch vLog.Topics[0] {
+	case stakeV2StakedTopic:
+		if len(vLog.Data) < 96 {
+			return nil
+		}
+		amount := word(0)
+		record.EventType = "Staked"
+		record.Amount = amount.Int64()
+		record.AmountRaw = amount.String()
+		record.OperationTime = time.Unix(word(1).Int64(), 0)
+		record.UnlockTime = time.Unix(word(2).Int64(), 0)
+	case stakeV2WithdrawnTopic:
+		if len(vLog.Data) < 96 {
+			return nil
+		}
+		amount := word(0)
+		record.EventType = "Withdrawn"
+		record.Amount = amount.Int64()
+		record.AmountRaw = amount.String()
+		record.Reward = word(1).String()
+		record.OperationTime = time.Unix(word(2).Int64(), 0)
+	case stakeV2ClaimRewardsTopic:
+		if len(vLog.Data) < 64 {
+			return nil
+		}
+		reward := word(0)
+		record.EventType = "ClaimRewards"
+		record.Amount = 0
+		record.AmountRaw = "0"
+		record.Reward = reward.String()
+		record.OperationTime = time.Unix(word(1).Int64(), 0)
+	default:
+		return nil
+	}
+
+	token, err := stakePoolTokenOf(int64(chainId), vLog.Address, record.PoolId)
+	if err != nil {
+		log.Logger.Warn("查询质押池代币失败", zap.Int64("pool_id", record.PoolId), zap.String("tx_hash", record.TxHash), zap.Error(err))
+	}
+	record.TokenAddress = token
+	return record
+}
+
+// stakePoolTokenOf 质押池代币地址：优先取已索引的 PoolCreated，未索引时读取合约
+func stakePoolTokenOf(chainId int64, stakeContract common.Address, poolId int64) (string, error) {
+	var pool model.StakePool
+	err := ctx.Ctx.DB.Where("chain_id = ? AND contract_address = ? AND pool_id = ?",
+		chainId, strings.ToLower(stakeContract.Hex()), poolId).First(&pool).Error
+	if err == nil {
+		return common.HexToAddress(pool.TokenAddress).Hex(), nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return "", err
+	}
+	if ctx.Ctx.ChainMap[int(chainId)] == nil {
+		return "", nil
+	}
+	caller, err := contract.NewAbiCaller(stakeContract, ctx.GetEvmClient(int(chainId)))
+	if err != nil {
+		return "", err
+	}
+	onChain, err := caller.Pools(&bind.CallOpts{Context: context.Background()}, big.NewInt(poolId))
+	if err != nil {
+		return "", err
+	}
+	return onChain.TokenAddress.Hex(), nil
+}
+
+// StartStakePositionBackfill 启动质押持仓回补：链上已有质押记录但投影为空时，按历史记录重建
+func StartStakePositionBackfill(c context.Context) {
+	go func() {
+		var chainIds []int64
+		if err := ctx.Ctx.DB.Model(&model.UserOperationRecord{}).Distinct("chain_id").Pluck("chain_id", &chainIds).Error; err != nil {
+			log.Logger.Error("查询质押记录链失败", zap.Error(err))
+			return
+		}
+		svc := service.NewStakePositionService()
+		for _, chainId := range chainIds {
+			select {
+			case <-c.Done():
+				return
+			default:
+			}
+			var positions int64
+			if err := ctx.Ctx.DB.Model(&model.StakePosition{}).Where("chain_id = ?", chainId).Count(&positions).Error; err != nil {
+				log.Logger.Error("查询质押持仓失败", zap.Int64("chain_id", chainId), zap.Error(err))
+				continue
+			}
+			if positions > 0 {
+				continue
+			}
+			log.Logger.Info("开始重建质押持仓", zap.Int64("chain_id", chainId))
+			if err := ctx.Ctx.DB.Transaction(func(tx *gorm.DB) error {
+				return svc.RebuildPositions(tx, chainId)
+			}); err != nil {
+				log.Logger.Error("重建质押持仓失败", zap.Int64("chain_id", chainId), zap.Error(err))
+			}
+		}
+	}()
+}
@@ -0,0 +1,41 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+const stakingAdminReconcileInterval = 30 * time.Second
+
+// StartStakingAdminReconcile 启动质押管理操作对账：交易确认后按已索引的事件核对状态变更
+func StartStakingAdminReconcile(c context.Context) {
+	if !config.Conf.StakingAdmin.Enabled {
+		return
+	}
+	svc := service.NewStakingAdminService()
+	go func() {
+		ticker := time.NewTicker(stakingAdminReconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Done():
+				log.Logger.Info("质押管理操作对账任务停止")
+				return
+			case <-ticker.C:
+				count, err := svc.ReconcileActions()
+				if err != nil {
+					log.Logger.Error("质押管理操作对账失败", zap.Error(err))
+					continue
+				}
+				if count > 0 {
+					log.Logger.Info("质押管理操作对账完成", zap.Int("count", count))
+				}
+			}
+		}
+	}()
+}
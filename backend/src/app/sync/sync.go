@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
 	"github.com/mumu/cryptoSwap/src/core/chainclient/evm"
 	"github.com/mumu/cryptoSwap/src/core/ctx"
 	"github.com/mumu/cryptoSwap/src/core/log"
@@ -19,14 +20,43 @@ import (
 )
 
 func StartSync(c context.Context) {
-	// 启动：定时重建默克尔树与上链更新（每60秒）
-	//go StartMerkleAutoUpdate(c, 60*time.Second)
+	// 启动：默克尔根自动更新（生成快照，策略检查通过的自动发布，其余进入待审批）
+	StartMerkleAutoUpdate(c)
+	// 启动：定时计算代币USD价格并记录历史
+	StartPriceSnapshot(c, priceSnapshotInterval())
+	// 启动：池子手续费检测（登记表 / 工厂 feeTo）
+	StartPoolFeeDetection(c)
+	// 启动：池子小时快照（启动时回补历史）
+	StartPoolSnapshot(c)
+	// 启动：协议每日汇总（TVL、交易量、用户、质押与空投）
+	StartProtocolDailyStats(c)
+	// 启动：回补历史LP转账并重建LP持仓
+	StartLpPositionBackfill(c)
+	// 启动：质押持仓投影为空时按历史质押记录重建
+	StartStakePositionBackfill(c)
+	// 启动：托管交易监控（回执跟踪、卡住交易提速、取消）
+	StartTxMonitor(c)
+	// 启动：质押池日份额价格维护（按配置的价格来源调用 setDailySharePrice）
+	StartSharePriceKeeper(c)
+	// 启动：质押管理操作对账（按索引的 PoolCreated、Paused、RoleGranted 等事件核对）
+	StartStakingAdminReconcile(c)
+	// 启动：Safe 多签提案跟踪（执行事件与索引结果）
+	StartSafeProposalReconcile(c)
+	// 启动：默克尔快照发布跟踪（确认后切换白名单证明）
+	StartMerkleSnapshotReconcile(c)
+	// 启动：空投活动生命周期跟踪（创建、激活与结束）
+	StartManagedAirdropReconcile(c)
+	// 启动：事件总线中继（outbox -> Redis Streams）
+	StartEventBusRelay(c)
+	// 启动：Webhook 投递（签名、指数退避重试、死信队列）
+	StartWebhookDelivery(c)
 	var wg sync.WaitGroup
 	// 查询所有链信息
 	// 查询所有链信息
 	var chains []model.Chain
 	// 修复：直接查询所有链信息，而不是循环查询
-	err := ctx.Ctx.DB.Model(&model.Chain{}).Find(&chains).Error
+	// 路由合约不产生需要索引的事件，不参与监听
+	err := ctx.Ctx.DB.Model(&model.Chain{}).Where("service_type IS DISTINCT FROM ?", service.ChainServiceRouter).Find(&chains).Error
 	if err != nil {
 		log.Logger.Error("查询所有链信息失败", zap.Error(err))
 		return
@@ -59,17 +89,35 @@ func StartSync(c context.Context) {
 			// 质押池事件
 			stakedTopic := crypto.Keccak256Hash([]byte("Staked(address,uint256,address,uint256,uint256,uint256)")).Hex()
 			withdrawnTopic := crypto.Keccak256Hash([]byte("Withdrawn(address,uint256,address,uint256,uint256)")).Hex()
+			poolCreatedTopic := stakePoolCreatedTopic.Hex()
+			// StakeV2 合约事件（代币地址不在事件中，按质押池补全）
+			stakedV2Topic := stakeV2StakedTopic.Hex()
+			withdrawnV2Topic := stakeV2WithdrawnTopic.Hex()
+			claimRewardsTopic := stakeV2ClaimRewardsTopic.Hex()
+			pausedTopic := stakeV2PausedTopic.Hex()
+			unpausedTopic := stakeV2UnpausedTopic.Hex()
+			roleGrantedTopic := stakeV2RoleGrantedTopic.Hex()
+			roleRevokedTopic := stakeV2RoleRevokedTopic.Hex()
 
 			// 流动性池事件
 			swapTopic := crypto.Keccak256Hash([]byte("Swap(address,uint256,uint256,uint256,uint256,address)")).Hex()
 			mintTopic := crypto.Keccak256Hash([]byte("Mint(address,uint256,uint256)")).Hex()
 			burnTopic := crypto.Keccak256Hash([]byte("Burn(address,uint256,uint256,address)")).Hex()
+			// LP代币转账事件（其他ERC20的转账在入库时按池子过滤）
+			transferTopic := lpTransferTopic.Hex()
+			// V3 池子与工厂事件
+			v3SwapTopicHex := v3SwapTopic.Hex()
+			v3MintTopicHex := v3MintTopic.Hex()
+			v3BurnTopicHex := v3BurnTopic.Hex()
+			v3CollectTopicHex := v3CollectTopic.Hex()
+			v3PoolCreatedTopicHex := v3PoolCreatedTopic.Hex()
 
 			// 空投事件
 			rewardClaimedTopic := crypto.Keccak256Hash([]byte("RewardClaimed(uint256,address,uint256,uint256,uint256,uint256,uint256)")).Hex()
 			updateTotalRewardTopic := crypto.Keccak256Hash([]byte("UpdateTotalRewardUpdated(uint256,address,uint256,uint256,uint256,uint256)")).Hex()
 			airdropCreatedTopic := crypto.Keccak256Hash([]byte("AirdropCreated(uint256,string,bytes32,uint256,uint256)")).Hex()
 			airdropActivatedTopic := crypto.Keccak256Hash([]byte("AirdropActivated(uint256)")).Hex()
+			merkleRootUpdatedTopic := crypto.Keccak256Hash([]byte("MerkleRootUpdated(uint256,bytes32,uint32)")).Hex()
 
 			// 直接使用链信息中的合约地址
 			contractAddresses := []string{chain.Address}
@@ -116,13 +164,23 @@ func StartSync(c context.Context) {
 					// 监听链配置中的合约地址的事件
 					// 修改：合并循环获取日志和错误处理
 					var allLogs []types.Log
-					for _, address := range contractAddresses {
-						logs, err := evmClient.GetFilterLogs(big.NewInt(int64(lastBlockNum)), big.NewInt(int64(targetBlockNum)), address)
+					if chain.ServiceType == service.ChainServiceV3Factory {
+						// V3 工厂：同时监听工厂创建的全部池子，拉取失败时不推进区块高度
+						logs, err := fetchV3FactoryLogs(evmClient, chainId, chain.Address, lastBlockNum, targetBlockNum)
 						if err != nil {
-							log.Logger.Error("GetFilterLogs failed!", zap.String("address", address), zap.Error(err))
+							log.Logger.Error("拉取V3工厂日志失败", zap.String("factory", chain.Address), zap.Error(err))
 							continue
 						}
-						allLogs = append(allLogs, logs...)
+						allLogs = logs
+					} else {
+						for _, address := range contractAddresses {
+							logs, err := evmClient.GetFilterLogs(big.NewInt(int64(lastBlockNum)), big.NewInt(int64(targetBlockNum)), address)
+							if err != nil {
+								log.Logger.Error("GetFilterLogs failed!", zap.String("address", address), zap.Error(err))
+								continue
+							}
+							allLogs = append(allLogs, logs...)
+						}
 					}
 
 					if len(allLogs) == 0 {
@@ -137,8 +195,12 @@ func StartSync(c context.Context) {
 					}
 
 					var userOperationRecords []*model.UserOperationRecord
+					var stakePools []*model.StakePool
+					var stakingAdminEvents []*model.StakingAdminEvent
 					var liquidityPoolEvents []*model.LiquidityPoolEvent
+					var lpTransfers []*model.LpTransferEvent
 					var airdropEvents *AirdropEvents
+					blockTimes := make(map[uint64]time.Time)
 					//var rewardClaimedEvents []*model.RewardClaimedEvent
 					//var totalRewardUpdatedEvents []*model.TotalRewardUpdatedEvent
 					//var airdropCreatedEvents []*AirdropCreatedInfo
@@ -164,12 +226,38 @@ func StartSync(c context.Context) {
 							if withdrawnStruct != nil {
 								userOperationRecords = append(userOperationRecords, withdrawnStruct)
 							}
-						case swapTopic, mintTopic, burnTopic:
+						case stakedV2Topic, withdrawnV2Topic, claimRewardsTopic:
+							record := parseStakeV2Event(vLog, chainId)
+							if record != nil {
+								userOperationRecords = append(userOperationRecords, record)
+							}
+						case pausedTopic, unpausedTopic, roleGrantedTopic, roleRevokedTopic:
+							event := parseStakingAdminEvent(vLog, chainId)
+							if event != nil {
+								event.BlockTime = blockTimeOf(evmClient, vLog.BlockNumber, blockTimes)
+								stakingAdminEvents = append(stakingAdminEvents, event)
+							}
+						case poolCreatedTopic:
+							pool := parseStakePoolCreated(vLog, chainId)
+							if pool != nil {
+								pool.BlockTime = blockTimeOf(evmClient, vLog.BlockNumber, blockTimes)
+								stakePools = append(stakePools, pool)
+							}
+						case swapTopic, mintTopic, burnTopic, v3SwapTopicHex, v3MintTopicHex, v3BurnTopicHex, v3CollectTopicHex:
 							event := parseLiquidityPoolEvent(vLog, chainId, address)
 							if event != nil {
+								event.BlockTime = blockTimeOf(evmClient, vLog.BlockNumber, blockTimes)
 								liquidityPoolEvents = append(liquidityPoolEvents, event)
 							}
-						case rewardClaimedTopic, updateTotalRewardTopic, airdropCreatedTopic, airdropActivatedTopic:
+						case v3PoolCreatedTopicHex:
+							// 新池子已在拉取日志时登记
+						case transferTopic:
+							transfer := parseLpTransferEvent(vLog, chainId)
+							if transfer != nil {
+								transfer.BlockTime = blockTimeOf(evmClient, vLog.BlockNumber, blockTimes)
+								lpTransfers = append(lpTransfers, transfer)
+							}
+						case rewardClaimedTopic, updateTotalRewardTopic, airdropCreatedTopic, airdropActivatedTopic, merkleRootUpdatedTopic:
 							// 处理空投相关事件
 							if airdropEvents == nil {
 								airdropEvents = &AirdropEvents{}
@@ -181,6 +269,10 @@ func StartSync(c context.Context) {
 								airdropEvents.TotalRewardUpdatedEvents = append(airdropEvents.TotalRewardUpdatedEvents, parsedEvents.TotalRewardUpdatedEvents...)
 								airdropEvents.AirdropCreatedEvents = append(airdropEvents.AirdropCreatedEvents, parsedEvents.AirdropCreatedEvents...)
 								airdropEvents.AirdropActivatedIds = append(airdropEvents.AirdropActivatedIds, parsedEvents.AirdropActivatedIds...)
+								for _, e := range parsedEvents.MerkleRootUpdatedEvents {
+									e.EventTime = blockTimeOf(evmClient, vLog.BlockNumber, blockTimes)
+								}
+								airdropEvents.MerkleRootUpdatedEvents = append(airdropEvents.MerkleRootUpdatedEvents, parsedEvents.MerkleRootUpdatedEvents...)
 							}
 						default:
 							log.Logger.Debug("未知的事件类型",
@@ -197,14 +289,28 @@ func StartSync(c context.Context) {
 						if err := updateDbUserAmount(userOperationRecords, chainId, targetBlockNum, chain.Address); err != nil {
 							log.Logger.Error("保存质押池事件失败", zap.Error(err))
 							success = false
+						} else {
+							publishStakeBatch(userOperationRecords)
+							webhookStakeBatch(userOperationRecords)
+						}
+					}
+
+					if len(stakePools) > 0 || len(stakingAdminEvents) > 0 {
+						log.Logger.Info("解析质押合约管理事件成功", zap.Int("pool_count", len(stakePools)), zap.Int("admin_event_count", len(stakingAdminEvents)))
+						if err := saveStakeContractEvents(stakePools, stakingAdminEvents, chainId, targetBlockNum, chain.Address); err != nil {
+							log.Logger.Error("保存质押合约管理事件失败", zap.Error(err))
+							success = false
 						}
 					}
 
-					if len(liquidityPoolEvents) > 0 {
-						log.Logger.Info("解析流动性池事件成功", zap.Int("event_count", len(liquidityPoolEvents)))
-						if err := saveLiquidityPoolEvents(liquidityPoolEvents, chainId, targetBlockNum, chain.Address); err != nil {
+					if len(liquidityPoolEvents) > 0 || len(lpTransfers) > 0 {
+						log.Logger.Info("解析流动性池事件成功", zap.Int("event_count", len(liquidityPoolEvents)), zap.Int("lp_transfer_count", len(lpTransfers)))
+						if err := saveLiquidityPoolEvents(liquidityPoolEvents, lpTransfers, chainId, targetBlockNum, chain.Address); err != nil {
 							log.Logger.Error("保存流动性池事件失败", zap.Error(err))
 							success = false
+						} else {
+							publishLiquidityBatch(chainId, liquidityPoolEvents)
+							webhookLiquidityBatch(liquidityPoolEvents)
 						}
 					}
 
@@ -213,16 +319,20 @@ func StartSync(c context.Context) {
 						if err := SaveAirdropEvents(airdropEvents, chainId, targetBlockNum, chain.Address); err != nil {
 							log.Logger.Error("保存空投事件失败", zap.Error(err))
 							success = false
+						} else {
+							publishClaimBatch(airdropEvents)
+							webhookAirdropBatch(airdropEvents)
 						}
 					}
 
 					// 如果所有事件处理成功，更新区块高度
 					if success {
-						if len(userOperationRecords) == 0 && len(liquidityPoolEvents) == 0 && (airdropEvents == nil ||
+						if len(userOperationRecords) == 0 && len(stakePools) == 0 && len(stakingAdminEvents) == 0 && len(liquidityPoolEvents) == 0 && len(lpTransfers) == 0 && (airdropEvents == nil ||
 							(len(airdropEvents.RewardClaimedEvents) == 0 &&
 								len(airdropEvents.TotalRewardUpdatedEvents) == 0 &&
 								len(airdropEvents.AirdropCreatedEvents) == 0 &&
-								len(airdropEvents.AirdropActivatedIds) == 0)) {
+								len(airdropEvents.AirdropActivatedIds) == 0 &&
+								len(airdropEvents.MerkleRootUpdatedEvents) == 0)) {
 							// 没有事件时也要更新区块高度
 							if err := updateBlockNumber(chainId, targetBlockNum, chain.Address); err != nil {
 								log.Logger.Error("更新区块高度失败", zap.Error(err))
@@ -242,6 +352,20 @@ func StartSync(c context.Context) {
 	wg.Wait()
 }
 
+// blockTimeOf 获取日志所在区块的时间，同一批次内按区块号缓存
+func blockTimeOf(evmClient *evm.Evm, blockNumber uint64, cache map[uint64]time.Time) time.Time {
+	if t, ok := cache[blockNumber]; ok {
+		return t
+	}
+	t, err := evmClient.GetBlockTime(blockNumber)
+	if err != nil {
+		log.Logger.Warn("获取区块时间失败，使用当前时间", zap.Uint64("block_number", blockNumber), zap.Error(err))
+		return time.Now()
+	}
+	cache[blockNumber] = t
+	return t
+}
+
 // updateBlockNumber 更新区块高度
 func updateBlockNumber(chainId int, blockNum uint64, address string) error {
 	return ctx.Ctx.DB.Model(&model.Chain{}).
@@ -270,16 +394,19 @@ func analysisStakedTopic(vLog types.Log, chainId int) *model.UserOperationRecord
 
 		// 创建质押记录并保存到数据库
 		userOperationRecord := model.UserOperationRecord{
-			ChainId:       int64(chainId),
-			Address:       user,
-			PoolId:        poolId.Int64(), // 修改:将big.Int转换为int64
-			TokenAddress:  tokenAddress,
-			Amount:        amount.Int64(),
-			OperationTime: time.UnixMilli(stakedAt.Int64()),
-			UnlockTime:    time.UnixMilli(unlockTime.Int64()),
-			TxHash:        vLog.TxHash.Hex(),
-			BlockNumber:   int64(vLog.BlockNumber),
-			EventType:     "Staked",
+			ChainId:         int64(chainId),
+			Address:         user,
+			PoolId:          poolId.Int64(), // 修改:将big.Int转换为int64
+			TokenAddress:    tokenAddress,
+			Amount:          amount.Int64(),
+			AmountRaw:       amount.String(),
+			OperationTime:   time.UnixMilli(stakedAt.Int64()),
+			UnlockTime:      time.UnixMilli(unlockTime.Int64()),
+			TxHash:          vLog.TxHash.Hex(),
+			BlockNumber:     int64(vLog.BlockNumber),
+			EventType:       "Staked",
+			ContractAddress: vLog.Address.Hex(),
+			LogIndex:        int(vLog.Index),
 		}
 		return &userOperationRecord
 	}
@@ -310,11 +437,14 @@ func analysisWithdrawnTopic(vLog types.Log, chainId int) *model.UserOperationRec
 			PoolId:        poolId.Int64(), // 修改:将big.Int转换为int64
 			TokenAddress:  tokenAddress,
 			Amount:        amount.Int64(),
+			AmountRaw:     amount.String(),
 			OperationTime: time.UnixMilli(withdrawnAt.Int64()), // 解除质押时间
 			//UnlockTime:    ni,                                 // 不再使用此字段
-			TxHash:      vLog.TxHash.Hex(),
-			BlockNumber: int64(vLog.BlockNumber),
-			EventType:   "Withdrawn",
+			TxHash:          vLog.TxHash.Hex(),
+			BlockNumber:     int64(vLog.BlockNumber),
+			EventType:       "Withdrawn",
+			ContractAddress: vLog.Address.Hex(),
+			LogIndex:        int(vLog.Index),
 		}
 		return &userOperationRecord
 	}
@@ -339,6 +469,18 @@ func updateDbUserAmount(userOperationRecords []*model.UserOperationRecord, chain
 			return err
 		}
 
+		// 写入事件总线 outbox，随本事务提交
+		if err := enqueueStakeRecords(tx, userOperationRecords); err != nil {
+			log.Logger.Error("写入事件总线 outbox 失败", zap.Error(err))
+			return err
+		}
+
+		// 更新质押持仓投影
+		if err := service.NewStakePositionService().ApplyRecords(tx, userOperationRecords); err != nil {
+			log.Logger.Error("更新质押持仓失败", zap.Error(err))
+			return err
+		}
+
 		// 根据质押事件标记对应的任务为已完成（自动验证类）
 		for _, record := range userOperationRecords {
 			if record.EventType == "Staked" {
@@ -354,6 +496,9 @@ func updateDbUserAmount(userOperationRecords []*model.UserOperationRecord, chain
 		}
 		userAmounts := make(map[userTokenKey]*big.Int)
 		for _, record := range userOperationRecords {
+			if record.EventType != "Staked" && record.EventType != "Withdrawn" {
+				continue
+			}
 			key := userTokenKey{
 				Address:      record.Address,
 				TokenAddress: record.TokenAddress,
@@ -0,0 +1,54 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"github.com/mumu/cryptoSwap/src/core/signer"
+	"go.uber.org/zap"
+)
+
+const txMonitorBatchSize = 50
+
+// StartTxMonitor 启动托管交易监控：跟踪回执、重发未广播的交易、提速卡住的交易
+func StartTxMonitor(c context.Context) {
+	interval := 5 * time.Second
+	if config.Conf.TxManager.PollInterval > 0 {
+		interval = time.Duration(config.Conf.TxManager.PollInterval) * time.Second
+	}
+	// 预先加载全部签名账户，重启前未完成的交易可继续提速或取消
+	signers, errs := signer.LoadAll()
+	for _, s := range signers {
+		service.RegisterTxSigner(s)
+	}
+	for name, err := range errs {
+		log.Logger.Error("加载签名账户失败", zap.String("signer", name), zap.Error(err))
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		svc := service.NewManagedTxService()
+		for {
+			select {
+			case <-c.Done():
+				log.Logger.Info("托管交易监控任务停止")
+				return
+			case <-ticker.C:
+				// 连续处理直到没有整批到期的交易
+				for {
+					count, err := svc.ProcessDue(txMonitorBatchSize)
+					if err != nil {
+						log.Logger.Error("领取托管交易失败", zap.Error(err))
+						break
+					}
+					if count < txMonitorBatchSize {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,268 @@
+package sync
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/chainclient/evm"
+	"github.com/mumu/cryptoSwap/src/core/ctx"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// V3 池子与工厂事件
+var (
+	v3SwapTopic        = crypto.Keccak256Hash([]byte("Swap(address,address,int256,int256,uint160,uint128,int24)"))
+	v3MintTopic        = crypto.Keccak256Hash([]byte("Mint(address,address,int24,int24,uint128,uint256,uint256)"))
+	v3BurnTopic        = crypto.Keccak256Hash([]byte("Burn(address,int24,int24,uint128,uint256,uint256)"))
+	v3CollectTopic     = crypto.Keccak256Hash([]byte("Collect(address,address,int24,int24,uint128,uint128)"))
+	v3PoolCreatedTopic = crypto.Keccak256Hash([]byte("PoolCreated(address,address,uint24,int24,address)"))
+)
+
+// two256 2^256，用于还原补码表示的有符号整数
+var two256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// decodeInt256 将32字节补码解析为有符号整数（int24 等较短类型在日志中同样符号扩展为32字节）
+func decodeInt256(b []byte) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if len(b) == 32 && b[0]&0x80 != 0 {
+		v.Sub(v, two256)
+	}
+	return v
+}
+
+// splitSigned 将有符号的池子余额变动拆分为 in/out：正数为转入池子，负数为转出池子
+func splitSigned(v *big.Int) (in, out string) {
+	if v.Sign() >= 0 {
+		return v.String(), "0"
+	}
+	return "0", new(big.Int).Neg(v).String()
+}
+
+// newV3Event 构造 V3 池子事件的公共字段
+func newV3Event(vLog types.Log, chainId int, address, eventType string) *model.LiquidityPoolEvent {
+	token0Address, token1Address := getPoolTokenAddresses(vLog.Address.Hex(), chainId)
+	return &model.LiquidityPoolEvent{
+		ChainId:       int64(chainId),
+		TxHash:        vLog.TxHash.Hex(),
+		BlockNumber:   int64(vLog.BlockNumber),
+		LogIndex:      int(vLog.Index),
+		EventType:     eventType,
+		PoolAddress:   vLog.Address.Hex(),
+		Token0Address: token0Address,
+		Token1Address: token1Address,
+		UserAddress:   address,
+		Amount0In:     "0",
+		Amount1In:     "0",
+		Amount0Out:    "0",
+		Amount1Out:    "0",
+		Reserve0:      "0",
+		Reserve1:      "0",
+		Price:         "0",
+		Liquidity:     "0",
+		SqrtPriceX96:  "0",
+		PoolType:      service.PoolTypeV3,
+	}
+}
+
+// parseV3SwapEvent 解析 V3 Swap 事件
+func parseV3SwapEvent(vLog types.Log, chainId int, address string) *model.LiquidityPoolEvent {
+	if len(vLog.Topics) < 3 || len(vLog.Data) < 160 {
+		return nil
+	}
+
+	// Swap(address indexed sender, address indexed recipient, int256 amount0, int256 amount1, uint160 sqrtPriceX96, uint128 liquidity, int24 tick)
+	data := vLog.Data
+	event := newV3Event(vLog, chainId, address, "Swap")
+	event.CallerAddress = common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
+	event.Amount0In, event.Amount0Out = splitSigned(decodeInt256(data[0:32]))
+	event.Amount1In, event.Amount1Out = splitSigned(decodeInt256(data[32:64]))
+	event.SqrtPriceX96 = new(big.Int).SetBytes(data[64:96]).String()
+	event.Liquidity = new(big.Int).SetBytes(data[96:128]).String()
+	event.Tick = int(decodeInt256(data[128:160]).Int64())
+	return event
+}
+
+// parseV3MintEvent 解析 V3 Mint 事件（增加区间流动性）
+func parseV3MintEvent(vLog types.Log, chainId int, address string) *model.LiquidityPoolEvent {
+	if len(vLog.Topics) < 4 || len(vLog.Data) < 128 {
+		return nil
+	}
+
+	// Mint(address sender, address indexed owner, int24 indexed tickLower, int24 indexed tickUpper, uint128 amount, uint256 amount0, uint256 amount1)
+	data := vLog.Data
+	event := newV3Event(vLog, chainId, address, "AddLiquidity")
+	event.CallerAddress = common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
+	event.TickLower = int(decodeInt256(vLog.Topics[2].Bytes()).Int64())
+	event.TickUpper = int(decodeInt256(vLog.Topics[3].Bytes()).Int64())
+	event.Liquidity = new(big.Int).SetBytes(data[32:64]).String()
+	event.Amount0In = new(big.Int).SetBytes(data[64:96]).String()
+	event.Amount1In = new(big.Int).SetBytes(data[96:128]).String()
+	return event
+}
+
+// parseV3BurnEvent 解析 V3 Burn 事件（移除区间流动性，代币记入待提取额度，由 Collect 转出）
+func parseV3BurnEvent(vLog types.Log, chainId int, address string) *model.LiquidityPoolEvent {
+	if len(vLog.Topics) < 4 || len(vLog.Data) < 96 {
+		return nil
+	}
+
+	// Burn(address indexed owner, int24 indexed tickLower, int24 indexed tickUpper, uint128 amount, uint256 amount0, uint256 amount1)
+	data := vLog.Data
+	event := newV3Event(vLog, chainId, address, "RemoveLiquidity")
+	event.CallerAddress = common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
+	event.TickLower = int(decodeInt256(vLog.Topics[2].Bytes()).Int64())
+	event.TickUpper = int(decodeInt256(vLog.Topics[3].Bytes()).Int64())
+	event.Liquidity = new(big.Int).SetBytes(data[0:32]).String()
+	event.Amount0Out = new(big.Int).SetBytes(data[32:64]).String()
+	event.Amount1Out = new(big.Int).SetBytes(data[64:96]).String()
+	return event
+}
+
+// parseV3CollectEvent 解析 V3 Collect 事件（提取已移除的本金与手续费）
+func parseV3CollectEvent(vLog types.Log, chainId int, address string) *model.LiquidityPoolEvent {
+	if len(vLog.Topics) < 4 || len(vLog.Data) < 96 {
+		return nil
+	}
+
+	// Collect(address indexed owner, address recipient, int24 indexed tickLower, int24 indexed tickUpper, uint128 amount0, uint128 amount1)
+	data := vLog.Data
+	event := newV3Event(vLog, chainId, address, "Collect")
+	event.CallerAddress = common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
+	event.TickLower = int(decodeInt256(vLog.Topics[2].Bytes()).Int64())
+	event.TickUpper = int(decodeInt256(vLog.Topics[3].Bytes()).Int64())
+	event.Amount0Out = new(big.Int).SetBytes(data[32:64]).String()
+	event.Amount1Out = new(big.Int).SetBytes(data[64:96]).String()
+	return event
+}
+
+// fetchV3FactoryLogs 拉取 V3 工厂及其已登记池子在区块范围内的日志；
+// 本批次新创建的池子先登记，再补拉其在同一范围内的日志，保证创建后紧接着的 Mint 不会遗漏
+func fetchV3FactoryLogs(evmClient *evm.Evm, chainId int, factory string, fromBlock, toBlock uint64) ([]types.Log, error) {
+	var pools []string
+	if err := ctx.Ctx.DB.Model(&model.LiquidityPool{}).
+		Where("chain_id = ? AND pool_type = ? AND LOWER(factory_address) = LOWER(?)", chainId, service.PoolTypeV3, factory).
+		Pluck("pool_address", &pools).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(pools))
+	for _, p := range pools {
+		known[strings.ToLower(p)] = true
+	}
+
+	from, to := new(big.Int).SetUint64(fromBlock), new(big.Int).SetUint64(toBlock)
+	logs, err := evmClient.GetFilterLogsWithTopics(from, to, append([]string{factory}, pools...), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := registerV3Pools(chainId, factory, logs)
+	if err != nil {
+		return nil, err
+	}
+	var fresh []string
+	for _, p := range created {
+		if !known[strings.ToLower(p)] {
+			fresh = append(fresh, p)
+		}
+	}
+	if len(fresh) > 0 {
+		more, err := evmClient.GetFilterLogsWithTopics(from, to, fresh, nil)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, more...)
+		sort.SliceStable(logs, func(i, j int) bool {
+			if logs[i].BlockNumber != logs[j].BlockNumber {
+				return logs[i].BlockNumber < logs[j].BlockNumber
+			}
+			return logs[i].Index < logs[j].Index
+		})
+	}
+	return logs, nil
+}
+
+// registerV3Pools 根据工厂的 PoolCreated 事件登记新池子，返回本批次创建的池子地址
+func registerV3Pools(chainId int, factory string, logs []types.Log) ([]string, error) {
+	var created []string
+	for _, vLog := range logs {
+		if len(vLog.Topics) < 4 || vLog.Topics[0] != v3PoolCreatedTopic || len(vLog.Data) < 64 {
+			continue
+		}
+		if !strings.EqualFold(vLog.Address.Hex(), factory) {
+			continue
+		}
+
+		// PoolCreated(address indexed token0, address indexed token1, uint24 indexed fee, int24 tickSpacing, address pool)
+		token0 := common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
+		token1 := common.BytesToAddress(vLog.Topics[2].Bytes()).Hex()
+		fee := new(big.Int).SetBytes(vLog.Topics[3].Bytes()).Int64()
+		tickSpacing := int(decodeInt256(vLog.Data[0:32]).Int64())
+		poolAddress := common.BytesToAddress(vLog.Data[32:64]).Hex()
+
+		token0Symbol, token0Decimals := getTokenMetadata(token0, int64(chainId))
+		token1Symbol, token1Decimals := getTokenMetadata(token1, int64(chainId))
+		pool := model.LiquidityPool{
+			ChainId:        int64(chainId),
+			PoolAddress:    poolAddress,
+			PoolType:       service.PoolTypeV3,
+			Token0Address:  token0,
+			Token1Address:  token1,
+			Token0Symbol:   token0Symbol,
+			Token1Symbol:   token1Symbol,
+			Token0Decimals: token0Decimals,
+			Token1Decimals: token1Decimals,
+			Reserve0:       "0",
+			Reserve1:       "0",
+			TotalSupply:    "0",
+			Price:          "0",
+			Volume24h:      "0",
+			SqrtPriceX96:   "0",
+			Liquidity:      "0",
+			TickSpacing:    tickSpacing,
+			FeeBps:         int(fee / 100),
+			FeeSource:      service.FeeSourceFactory,
+			FactoryAddress: factory,
+			LastBlockNum:   int64(vLog.BlockNumber),
+			IsActive:       true,
+		}
+		if err := ctx.Ctx.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&pool).Error; err != nil {
+			log.Logger.Error("登记V3池子失败", zap.String("pool", poolAddress), zap.Error(err))
+			return nil, err
+		}
+		log.Logger.Info("登记V3池子", zap.Int("chain_id", chainId), zap.String("pool", poolAddress), zap.Int64("fee", fee))
+		created = append(created, poolAddress)
+	}
+	return created, nil
+}
+
+// refreshV3PoolState 读取 V3 池子链上状态并写回池子表：余额作为储备量，价格取自 sqrtPriceX96
+func refreshV3PoolState(tx *gorm.DB, pool *model.LiquidityPool) error {
+	state, err := service.FetchV3PoolState(pool.ChainId, pool.PoolAddress, pool.Token0Address, pool.Token1Address)
+	if err != nil {
+		log.Logger.Warn("获取V3池子链上状态失败", zap.String("pool", pool.PoolAddress), zap.Error(err))
+		return nil
+	}
+	pool.Reserve0 = state.Balance0.String()
+	pool.Reserve1 = state.Balance1.String()
+	pool.SqrtPriceX96 = state.SqrtPriceX96.String()
+	pool.Tick = state.Tick
+	pool.Liquidity = state.Liquidity.String()
+	price := service.PoolModelOf(*pool).SpotPrice(*pool)
+	return tx.Model(pool).Updates(map[string]interface{}{
+		"reserve0":       pool.Reserve0,
+		"reserve1":       pool.Reserve1,
+		"sqrt_price_x96": pool.SqrtPriceX96,
+		"tick":           pool.Tick,
+		"liquidity":      pool.Liquidity,
+		"price":          price.String(),
+	}).Error
+}
@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumu/cryptoSwap/src/app/model"
+	"github.com/mumu/cryptoSwap/src/app/service"
+	"github.com/mumu/cryptoSwap/src/core/config"
+	"github.com/mumu/cryptoSwap/src/core/log"
+	"go.uber.org/zap"
+)
+
+// webhookBatchSize 每轮领取的投递任务数
+const webhookBatchSize = 200
+
+// StartWebhookDelivery 启动 Webhook 投递任务：轮询到期的投递任务并发送
+func StartWebhookDelivery(c context.Context) {
+	if !service.WebhookEnabled() {
+		return
+	}
+	interval := 5 * time.Second
+	if config.Conf.Webhook.PollInterval > 0 {
+		interval = time.Duration(config.Conf.Webhook.PollInterval) * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Done():
+				log.Logger.Info("Webhook 投递任务停止")
+				return
+			case <-ticker.C:
+				deliverWebhooks()
+			}
+		}
+	}()
+}
+
+// deliverWebhooks 连续投递直到没有整批到期的任务
+func deliverWebhooks() {
+	svc := service.NewWebhookService()
+	for {
+		count, err := svc.DeliverDue(webhookBatchSize)
+		if err != nil {
+			log.Logger.Error("领取 webhook 投递任务失败", zap.Error(err))
+			return
+		}
+		if count < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// enqueueWebhooks 事件提交后按订阅生成投递任务，失败只记录日志，不影响索引
+func enqueueWebhooks(events []model.WebhookEvent) {
+	if len(events) == 0 {
+		return
+	}
+	if err := service.NewWebhookService().Enqueue(events); err != nil {
+		log.Logger.Error("生成 webhook 投递任务失败", zap.Int("event_count", len(events)), zap.Error(err))
+	}
+}
+
+// webhookLiquidityBatch 流动性池事件提交后生成投递任务
+func webhookLiquidityBatch(events []*model.LiquidityPoolEvent) {
+	list := make([]model.ExplorerEvent, 0, len(events))
+	for _, e := range events {
+		if event, ok := service.ExplorerEventFromLiquidity(e); ok {
+			list = append(list, event)
+		}
+	}
+	enqueueWebhooks(service.WebhookEventsFromExplorer(list))
+}
+
+// webhookStakeBatch 质押/提取记录提交后生成投递任务
+func webhookStakeBatch(records []*model.UserOperationRecord) {
+	list := make([]model.ExplorerEvent, 0, len(records))
+	for _, r := range records {
+		if event, ok := service.ExplorerEventFromStake(r); ok {
+			list = append(list, event)
+		}
+	}
+	enqueueWebhooks(service.WebhookEventsFromExplorer(list))
+}
+
+// webhookAirdropBatch 空投领取与默克尔根更新事件提交后生成投递任务
+func webhookAirdropBatch(events *AirdropEvents) {
+	if events == nil {
+		return
+	}
+	list := make([]model.ExplorerEvent, 0, len(events.RewardClaimedEvents))
+	for _, e := range events.RewardClaimedEvents {
+		if e != nil {
+			list = append(list, service.ExplorerEventFromClaim(e))
+		}
+	}
+	webhookEvents := service.WebhookEventsFromExplorer(list)
+	webhookEvents = append(webhookEvents, service.WebhookEventsFromMerkleRoots(events.MerkleRootUpdatedEvents)...)
+	enqueueWebhooks(webhookEvents)
+}
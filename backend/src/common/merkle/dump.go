@@ -0,0 +1,98 @@
+package merkle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// FormatStandard OpenZeppelin StandardMerkleTree.dump() 的格式
+	FormatStandard = "standard-v1"
+	// FormatPacked 相同结构，叶子为 abi.encodePacked 单次哈希（OpenZeppelin 工具无法加载）
+	FormatPacked = "packed-v1"
+)
+
+// Dump 与 StandardMerkleTree.dump() 相同的 JSON 结构
+type Dump struct {
+	Format       string        `json:"format"`
+	LeafEncoding []string      `json:"leafEncoding"`
+	Tree         []common.Hash `json:"tree"`
+	Values       []DumpValue   `json:"values"`
+}
+
+// DumpValue 值及其叶子在 tree 数组中的位置
+type DumpValue struct {
+	Value     []interface{} `json:"value"`
+	TreeIndex int           `json:"treeIndex"`
+}
+
+// Dump 导出树
+func (t *Tree) Dump() Dump {
+	format := FormatStandard
+	if t.encoding.Mode == LeafPacked {
+		format = FormatPacked
+	}
+	d := Dump{
+		Format:       format,
+		LeafEncoding: append([]string(nil), t.encoding.Types...),
+		Tree:         append([]common.Hash(nil), t.tree...),
+		Values:       make([]DumpValue, len(t.values)),
+	}
+	for i, v := range t.values {
+		d.Values[i] = DumpValue{Value: v.value, TreeIndex: v.treeIndex}
+	}
+	return d
+}
+
+// MarshalJSON 按 Dump 格式序列化
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Dump())
+}
+
+// Load 加载 Dump 并校验树结构与每个值的叶子哈希
+func Load(d Dump) (*Tree, error) {
+	var mode LeafMode
+	switch d.Format {
+	case FormatStandard:
+		mode = LeafStandard
+	case FormatPacked:
+		mode = LeafPacked
+	default:
+		return nil, fmt.Errorf("merkle: 未知的格式 %s", d.Format)
+	}
+	t := &Tree{
+		encoding: Encoding{Types: d.LeafEncoding, Mode: mode},
+		tree:     d.Tree,
+		values:   make([]treeValue, len(d.Values)),
+	}
+	args, err := t.encoding.parseTypes()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range d.Values {
+		converted, err := convertValues(args, v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个值: %w", i, err)
+		}
+		normalized := make([]interface{}, len(converted))
+		for k, c := range converted {
+			normalized[k] = normalizeValue(c)
+		}
+		t.values[i] = treeValue{value: normalized, treeIndex: v.TreeIndex}
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadJSON 从 JSON 加载，兼容 StandardMerkleTree.dump() 的输出
+func LoadJSON(data []byte) (*Tree, error) {
+	var d Dump
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("merkle: 解析 JSON 失败: %v", err)
+	}
+	return Load(d)
+}
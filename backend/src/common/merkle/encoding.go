@@ -0,0 +1,300 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LeafMode 叶子哈希方式
+type LeafMode string
+
+const (
+	// LeafStandard OpenZeppelin StandardMerkleTree：keccak256(bytes.concat(keccak256(abi.encode(values...))))，
+	// 双重哈希避免叶子与内部节点混淆（第二原像攻击）
+	LeafStandard LeafMode = "standard"
+	// LeafPacked keccak256(abi.encodePacked(values...))，单次哈希，兼容按 encodePacked 计算叶子的合约
+	LeafPacked LeafMode = "packed"
+)
+
+// Encoding 叶子编码：Solidity 类型列表与哈希方式
+type Encoding struct {
+	Types []string
+	Mode  LeafMode
+}
+
+// StandardEncoding OpenZeppelin StandardMerkleTree 的叶子编码
+func StandardEncoding(types ...string) Encoding {
+	return Encoding{Types: types, Mode: LeafStandard}
+}
+
+// PackedEncoding abi.encodePacked 单次哈希的叶子编码
+func PackedEncoding(types ...string) Encoding {
+	return Encoding{Types: types, Mode: LeafPacked}
+}
+
+// parseTypes 解析类型列表，仅支持基础类型（不支持数组与元组）
+func (e Encoding) parseTypes() (abi.Arguments, error) {
+	if len(e.Types) == 0 {
+		return nil, fmt.Errorf("merkle: 叶子编码类型为空")
+	}
+	args := make(abi.Arguments, 0, len(e.Types))
+	for _, name := range e.Types {
+		t, err := abi.NewType(strings.TrimSpace(name), "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("merkle: 无效的类型 %s: %v", name, err)
+		}
+		switch t.T {
+		case abi.AddressTy, abi.BoolTy, abi.UintTy, abi.IntTy, abi.FixedBytesTy, abi.BytesTy, abi.StringTy:
+		default:
+			return nil, fmt.Errorf("merkle: 不支持的类型 %s", name)
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+	return args, nil
+}
+
+// LeafHash 按编码计算叶子哈希
+func (e Encoding) LeafHash(value []interface{}) (common.Hash, error) {
+	args, err := e.parseTypes()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	converted, err := convertValues(args, value)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return e.leafHash(args, converted)
+}
+
+func (e Encoding) leafHash(args abi.Arguments, converted []interface{}) (common.Hash, error) {
+	switch e.Mode {
+	case LeafStandard:
+		encoded, err := args.Pack(converted...)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("merkle: abi.encode 失败: %v", err)
+		}
+		return crypto.Keccak256Hash(crypto.Keccak256(encoded)), nil
+	case LeafPacked:
+		return crypto.Keccak256Hash(encodePacked(args, converted)), nil
+	}
+	return common.Hash{}, fmt.Errorf("merkle: 未知的叶子哈希方式 %s", e.Mode)
+}
+
+// convertValues 将调用方的值转换为 go-ethereum abi 打包所需的类型：
+// address 接受 common.Address 或十六进制字符串，整数接受 *big.Int、Go 整数或十进制/0x 字符串，
+// bytes/bytesN 接受 []byte 或十六进制字符串
+func convertValues(args abi.Arguments, value []interface{}) ([]interface{}, error) {
+	if len(value) != len(args) {
+		return nil, fmt.Errorf("merkle: 值数量 %d 与类型数量 %d 不一致", len(value), len(args))
+	}
+	out := make([]interface{}, len(value))
+	for i, v := range value {
+		c, err := convertValue(args[i].Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("merkle: 第 %d 个值: %v", i, err)
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+func convertValue(t abi.Type, v interface{}) (interface{}, error) {
+	switch t.T {
+	case abi.AddressTy:
+		switch a := v.(type) {
+		case common.Address:
+			return a, nil
+		case string:
+			if !common.IsHexAddress(a) {
+				return nil, fmt.Errorf("无效的地址 %s", a)
+			}
+			return common.HexToAddress(a), nil
+		}
+	case abi.BoolTy:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case abi.StringTy:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case abi.BytesTy:
+		return toBytes(v)
+	case abi.FixedBytesTy:
+		b, err := toBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("bytes%d 长度不符: %d", t.Size, len(b))
+		}
+		arr := reflect.New(t.GetType()).Elem()
+		reflect.Copy(arr, reflect.ValueOf(b))
+		return arr.Interface(), nil
+	case abi.UintTy, abi.IntTy:
+		n, err := toBigInt(v)
+		if err != nil {
+			return nil, err
+		}
+		if t.T == abi.UintTy && (n.Sign() < 0 || n.BitLen() > t.Size) {
+			return nil, fmt.Errorf("%s 超出范围: %s", t.String(), n.String())
+		}
+		if t.T == abi.IntTy {
+			limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+			if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return nil, fmt.Errorf("%s 超出范围: %s", t.String(), n.String())
+			}
+		}
+		// go-ethereum 仅对 8/16/32/64 位使用原生整数，其余位宽（uint24、int48 等）均为 *big.Int
+		switch t.Size {
+		case 8, 16, 32, 64:
+		default:
+			return n, nil
+		}
+		rv := reflect.New(t.GetType()).Elem()
+		if t.T == abi.UintTy {
+			rv.SetUint(n.Uint64())
+		} else {
+			rv.SetInt(n.Int64())
+		}
+		return rv.Interface(), nil
+	}
+	return nil, fmt.Errorf("%s 不接受 %T 类型的值", t.String(), v)
+}
+
+func toBigInt(v interface{}) (*big.Int, error) {
+	switch n := v.(type) {
+	case *big.Int:
+		if n == nil {
+			return nil, fmt.Errorf("整数为空")
+		}
+		return new(big.Int).Set(n), nil
+	case int:
+		return big.NewInt(int64(n)), nil
+	case int64:
+		return big.NewInt(n), nil
+	case uint64:
+		return new(big.Int).SetUint64(n), nil
+	case uint32:
+		return new(big.Int).SetUint64(uint64(n)), nil
+	case float64:
+		// JSON 数字，仅接受整数且不超过 2^53
+		if n != float64(int64(n)) || n > 1<<53 || n < -(1<<53) {
+			return nil, fmt.Errorf("无效的整数 %v，大整数请使用字符串", n)
+		}
+		return big.NewInt(int64(n)), nil
+	case string:
+		s := strings.TrimSpace(n)
+		base := 10
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			s, base = s[2:], 16
+		}
+		bi, ok := new(big.Int).SetString(s, base)
+		if !ok {
+			return nil, fmt.Errorf("无效的整数 %s", n)
+		}
+		return bi, nil
+	}
+	return nil, fmt.Errorf("无法转换为整数: %T", v)
+}
+
+func toBytes(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case common.Hash:
+		return b.Bytes(), nil
+	case string:
+		s := strings.TrimPrefix(strings.TrimPrefix(b, "0x"), "0X")
+		out, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的十六进制 %s", b)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("无法转换为 bytes: %T", v)
+}
+
+// encodePacked Solidity abi.encodePacked：静态类型按自身宽度紧凑拼接，bytes/string 直接拼接原始内容
+func encodePacked(args abi.Arguments, values []interface{}) []byte {
+	var out []byte
+	for i, arg := range args {
+		switch arg.Type.T {
+		case abi.AddressTy:
+			a := values[i].(common.Address)
+			out = append(out, a.Bytes()...)
+		case abi.BoolTy:
+			if values[i].(bool) {
+				out = append(out, 1)
+			} else {
+				out = append(out, 0)
+			}
+		case abi.StringTy:
+			out = append(out, []byte(values[i].(string))...)
+		case abi.BytesTy:
+			out = append(out, values[i].([]byte)...)
+		case abi.FixedBytesTy:
+			rv := reflect.ValueOf(values[i])
+			for k := 0; k < rv.Len(); k++ {
+				out = append(out, byte(rv.Index(k).Uint()))
+			}
+		case abi.UintTy, abi.IntTy:
+			n, _ := toBigInt(reflectInt(values[i]))
+			width := arg.Type.Size / 8
+			if n.Sign() < 0 {
+				// 补码
+				n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), uint(arg.Type.Size)))
+			}
+			out = append(out, common.LeftPadBytes(n.Bytes(), width)...)
+		}
+	}
+	return out
+}
+
+// reflectInt 将 convertValue 生成的定宽整数转回 toBigInt 可接受的类型
+func reflectInt(v interface{}) interface{} {
+	if n, ok := v.(*big.Int); ok {
+		return n
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint()
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	}
+	return v
+}
+
+// normalizeValue 转换后的值还原为可 JSON 序列化的形式：整数为十进制字符串，字节为 0x 十六进制
+func normalizeValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case common.Address:
+		return x.Hex()
+	case bool, string:
+		return x
+	case []byte:
+		return "0x" + hex.EncodeToString(x)
+	case *big.Int:
+		return x.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array:
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return "0x" + hex.EncodeToString(b)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()).String()
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()).String()
+	}
+	return v
+}
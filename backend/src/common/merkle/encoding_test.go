@@ -0,0 +1,124 @@
+package merkle
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+// hardhat 默认账户 #1～#3，即 contract/AirdropContract/test/airdropTest.js 中的 user1～user3
+var airdropTestAccounts = []struct {
+	address string
+	amount  *big.Int
+	leaf    string
+}{
+	{"0x70997970C51812dc3A010C7d01b50e0d17dc79C8", ether(100), "0xb744c83281c8c4e19fd76aa9822f709c68558891ccff788560f3873aab6b19b3"},
+	{"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC", ether(200), "0x7602bd89cdc861ef544e52daf876d2eba38424ec3d4a443febdc56312fed9d5a"},
+	{"0x90F79bf6EB2c4f870365E785982E1f101E93b906", ether(300), "0x89b67bb595b295fa17063ff9ce340a697d6d6811070f9305d9d57fa907aa6037"},
+}
+
+// 与 ethers.solidityPackedKeccak256(["address","uint256"], ...) 及 MerkleAirdrop.calculateLeafHash 一致
+func TestPackedLeafMatchesAirdropTest(t *testing.T) {
+	enc := PackedEncoding("address", "uint256")
+	for _, acc := range airdropTestAccounts {
+		// 测试脚本中地址先转为小写，打包结果与大小写无关
+		for _, addr := range []string{acc.address, common.HexToAddress(acc.address).Hex()} {
+			leaf, err := enc.LeafHash([]interface{}{addr, acc.amount})
+			if err != nil {
+				t.Fatalf("LeafHash(%s): %v", addr, err)
+			}
+			if leaf != common.HexToHash(acc.leaf) {
+				t.Errorf("LeafHash(%s) = %s, want %s", addr, leaf.Hex(), acc.leaf)
+			}
+		}
+	}
+}
+
+func TestStandardLeafMatchesOpenZeppelin(t *testing.T) {
+	enc := StandardEncoding("address", "uint256")
+	leaf, err := enc.LeafHash([]interface{}{"0x1111111111111111111111111111111111111111", "5000000000000000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToHash("0xeb02c421cfa48976e66dfb29120745909ea3a0f843456c263cf8f1253483e283")
+	if leaf != want {
+		t.Errorf("LeafHash = %s, want %s", leaf.Hex(), want.Hex())
+	}
+}
+
+// 非 8/16/32/64 位的整数（go-ethereum 中为 *big.Int）按 Solidity 位宽编码
+func TestOddWidthIntegers(t *testing.T) {
+	cases := []struct {
+		typ    string
+		value  interface{}
+		packed []byte
+	}{
+		{"uint24", 0x123456, []byte{0x12, 0x34, 0x56}},
+		{"uint40", "1099511627775", []byte{0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"int48", -2, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}},
+		{"uint8", 7, []byte{0x07}},
+		{"int64", "-1", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, c := range cases {
+		packed, err := PackedEncoding(c.typ).LeafHash([]interface{}{c.value})
+		if err != nil {
+			t.Fatalf("%s packed: %v", c.typ, err)
+		}
+		if want := crypto.Keccak256Hash(c.packed); packed != want {
+			t.Errorf("%s packed = %s, want %s", c.typ, packed.Hex(), want.Hex())
+		}
+
+		standard, err := StandardEncoding(c.typ).LeafHash([]interface{}{c.value})
+		if err != nil {
+			t.Fatalf("%s standard: %v", c.typ, err)
+		}
+		// abi.encode 左侧补齐 32 字节，负数以 0xff 补齐
+		fill := byte(0)
+		if c.packed[0]&0x80 != 0 && c.typ[0] == 'i' {
+			fill = 0xff
+		}
+		word := make([]byte, 32)
+		for i := range word {
+			word[i] = fill
+		}
+		copy(word[32-len(c.packed):], c.packed)
+		if want := crypto.Keccak256Hash(crypto.Keccak256(word)); standard != want {
+			t.Errorf("%s standard = %s, want %s", c.typ, standard.Hex(), want.Hex())
+		}
+	}
+}
+
+func TestIntegerRange(t *testing.T) {
+	cases := []struct {
+		typ   string
+		value interface{}
+	}{
+		{"uint24", 1 << 24},
+		{"uint8", -1},
+		{"int48", new(big.Int).Lsh(big.NewInt(1), 47)},
+		{"int24", -(1 << 23) - 1},
+	}
+	for _, c := range cases {
+		if _, err := PackedEncoding(c.typ).LeafHash([]interface{}{c.value}); err == nil {
+			t.Errorf("%s(%v) 应超出范围", c.typ, c.value)
+		}
+	}
+}
+
+func TestPackedDynamicAndFixedBytes(t *testing.T) {
+	enc := PackedEncoding("bytes4", "bool", "string", "bytes")
+	leaf, err := enc.LeafHash([]interface{}{"0xdeadbeef", true, "abc", "0x0102"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := crypto.Keccak256Hash([]byte{0xde, 0xad, 0xbe, 0xef, 0x01, 'a', 'b', 'c', 0x01, 0x02})
+	if leaf != want {
+		t.Errorf("LeafHash = %s, want %s", leaf.Hex(), want.Hex())
+	}
+}
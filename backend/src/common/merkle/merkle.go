@@ -0,0 +1,324 @@
+// Package merkle 与 OpenZeppelin StandardMerkleTree（@openzeppelin/merkle-tree）兼容的默克尔树：
+// 叶子按哈希升序排序后以完全二叉树的数组形式存放（根在下标 0，叶子倒序位于数组末尾），
+// 节点为排序后拼接再哈希，可由 OpenZeppelin MerkleProof.verify / multiProofVerify 校验。
+// 叶子编码可选 OZ 双重哈希的 abi.encode 或单次哈希的 abi.encodePacked。
+//
+// 参考向量（@openzeppelin/merkle-tree README）：
+// StandardEncoding("address","uint256")，值
+// [0x1111111111111111111111111111111111111111, 5000000000000000000]、
+// [0x2222222222222222222222222222222222222222, 2500000000000000000]，
+// 根为 0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrEmptyTree 没有任何叶子
+	ErrEmptyTree = errors.New("merkle: 叶子为空")
+	// ErrIndexOutOfRange 值下标越界
+	ErrIndexOutOfRange = errors.New("merkle: 下标越界")
+	// ErrInvalidTree 树结构或叶子哈希校验失败
+	ErrInvalidTree = errors.New("merkle: 无效的默克尔树")
+	// ErrInvalidMultiProof 多重证明的叶子、证明与标记数量不匹配
+	ErrInvalidMultiProof = errors.New("merkle: 无效的多重证明")
+)
+
+// Tree 默克尔树，values 保持构建时的输入顺序，treeIndex 指向其叶子在数组中的位置
+type Tree struct {
+	encoding Encoding
+	tree     []common.Hash
+	values   []treeValue
+}
+
+type treeValue struct {
+	value     []interface{} // 规范化后的值，可直接 JSON 序列化
+	treeIndex int
+}
+
+// MultiProof 多重证明，Leaves/Values 按 OpenZeppelin processMultiProof 的消费顺序排列
+type MultiProof struct {
+	Values     [][]interface{} `json:"values"`
+	Leaves     []common.Hash   `json:"leaves"`
+	Proof      []common.Hash   `json:"proof"`
+	ProofFlags []bool          `json:"proofFlags"`
+}
+
+// NewTree 按编码计算每个值的叶子哈希并建树
+func NewTree(values [][]interface{}, encoding Encoding) (*Tree, error) {
+	if len(values) == 0 {
+		return nil, ErrEmptyTree
+	}
+	args, err := encoding.parseTypes()
+	if err != nil {
+		return nil, err
+	}
+	type hashed struct {
+		index int
+		hash  common.Hash
+	}
+	leaves := make([]hashed, len(values))
+	normalized := make([][]interface{}, len(values))
+	for i, v := range values {
+		converted, err := convertValues(args, v)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个值: %w", i, err)
+		}
+		h, err := encoding.leafHash(args, converted)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = hashed{index: i, hash: h}
+		normalized[i] = make([]interface{}, len(converted))
+		for k, c := range converted {
+			normalized[i][k] = normalizeValue(c)
+		}
+	}
+	sort.SliceStable(leaves, func(i, j int) bool {
+		return bytes.Compare(leaves[i].hash[:], leaves[j].hash[:]) < 0
+	})
+
+	hashes := make([]common.Hash, len(leaves))
+	for i, l := range leaves {
+		hashes[i] = l.hash
+	}
+	t := &Tree{encoding: encoding, tree: makeTree(hashes), values: make([]treeValue, len(values))}
+	for i, l := range leaves {
+		t.values[l.index] = treeValue{value: normalized[l.index], treeIndex: len(t.tree) - 1 - i}
+	}
+	return t, nil
+}
+
+// makeTree 叶子倒序放在数组末尾，内部节点 i 的子节点为 2i+1、2i+2
+func makeTree(leaves []common.Hash) []common.Hash {
+	tree := make([]common.Hash, 2*len(leaves)-1)
+	for i, leaf := range leaves {
+		tree[len(tree)-1-i] = leaf
+	}
+	for i := len(tree) - 1 - len(leaves); i >= 0; i-- {
+		tree[i] = HashPair(tree[2*i+1], tree[2*i+2])
+	}
+	return tree
+}
+
+// HashPair 排序后拼接再哈希，与 OpenZeppelin Hashes.commutativeKeccak256 一致
+func HashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// Root 默克尔根
+func (t *Tree) Root() common.Hash {
+	return t.tree[0]
+}
+
+// Encoding 叶子编码
+func (t *Tree) Encoding() Encoding {
+	return t.encoding
+}
+
+// Len 值的数量
+func (t *Tree) Len() int {
+	return len(t.values)
+}
+
+// Value 第 i 个值（构建时的输入顺序）
+func (t *Tree) Value(i int) ([]interface{}, error) {
+	if i < 0 || i >= len(t.values) {
+		return nil, ErrIndexOutOfRange
+	}
+	return t.values[i].value, nil
+}
+
+// Leaf 第 i 个值的叶子哈希
+func (t *Tree) Leaf(i int) (common.Hash, error) {
+	if i < 0 || i >= len(t.values) {
+		return common.Hash{}, ErrIndexOutOfRange
+	}
+	return t.tree[t.values[i].treeIndex], nil
+}
+
+// GetProof 第 i 个值的证明，自叶子向根
+func (t *Tree) GetProof(i int) ([]common.Hash, error) {
+	if i < 0 || i >= len(t.values) {
+		return nil, ErrIndexOutOfRange
+	}
+	proof := make([]common.Hash, 0)
+	for index := t.values[i].treeIndex; index > 0; index = (index - 1) / 2 {
+		proof = append(proof, t.tree[siblingIndex(index)])
+	}
+	return proof, nil
+}
+
+// GetMultiProof 一组值的多重证明
+func (t *Tree) GetMultiProof(indices []int) (*MultiProof, error) {
+	seen := make(map[int]bool, len(indices))
+	treeIndices := make([]int, 0, len(indices))
+	for _, i := range indices {
+		if i < 0 || i >= len(t.values) {
+			return nil, ErrIndexOutOfRange
+		}
+		if seen[i] {
+			return nil, fmt.Errorf("merkle: 重复的下标 %d", i)
+		}
+		seen[i] = true
+		treeIndices = append(treeIndices, t.values[i].treeIndex)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(treeIndices)))
+
+	mp := &MultiProof{Proof: make([]common.Hash, 0), ProofFlags: make([]bool, 0)}
+	stack := append([]int(nil), treeIndices...)
+	for len(stack) > 0 && stack[0] > 0 {
+		j := stack[0]
+		stack = stack[1:]
+		s := siblingIndex(j)
+		if len(stack) > 0 && stack[0] == s {
+			mp.ProofFlags = append(mp.ProofFlags, true)
+			stack = stack[1:]
+		} else {
+			mp.ProofFlags = append(mp.ProofFlags, false)
+			mp.Proof = append(mp.Proof, t.tree[s])
+		}
+		stack = append(stack, (j-1)/2)
+	}
+	if len(treeIndices) == 0 {
+		mp.Proof = append(mp.Proof, t.tree[0])
+	}
+
+	byTreeIndex := make(map[int]int, len(t.values))
+	for i, v := range t.values {
+		byTreeIndex[v.treeIndex] = i
+	}
+	mp.Values = make([][]interface{}, len(treeIndices))
+	mp.Leaves = make([]common.Hash, len(treeIndices))
+	for k, ti := range treeIndices {
+		mp.Values[k] = t.values[byTreeIndex[ti]].value
+		mp.Leaves[k] = t.tree[ti]
+	}
+	return mp, nil
+}
+
+// Verify 校验第 i 个值的证明
+func (t *Tree) Verify(i int, proof []common.Hash) bool {
+	leaf, err := t.Leaf(i)
+	if err != nil {
+		return false
+	}
+	return VerifyProof(t.Root(), leaf, proof)
+}
+
+// VerifyValue 按编码计算值的叶子并校验证明，不要求值在树中
+func (t *Tree) VerifyValue(value []interface{}, proof []common.Hash) bool {
+	leaf, err := t.encoding.LeafHash(value)
+	if err != nil {
+		return false
+	}
+	return VerifyProof(t.Root(), leaf, proof)
+}
+
+// VerifyMultiProof 校验多重证明
+func (t *Tree) VerifyMultiProof(mp *MultiProof) bool {
+	return VerifyMultiProof(t.Root(), mp.Leaves, mp.Proof, mp.ProofFlags)
+}
+
+// ProcessProof 由叶子与证明计算根，与 MerkleProof.processProof 一致
+func ProcessProof(leaf common.Hash, proof []common.Hash) common.Hash {
+	computed := leaf
+	for _, p := range proof {
+		computed = HashPair(computed, p)
+	}
+	return computed
+}
+
+// VerifyProof 校验单个证明
+func VerifyProof(root, leaf common.Hash, proof []common.Hash) bool {
+	return ProcessProof(leaf, proof) == root
+}
+
+// ProcessMultiProof 由叶子、证明与标记计算根，与 MerkleProof.processMultiProof 一致
+func ProcessMultiProof(leaves, proof []common.Hash, proofFlags []bool) (common.Hash, error) {
+	falseFlags := 0
+	for _, f := range proofFlags {
+		if !f {
+			falseFlags++
+		}
+	}
+	if len(proof) < falseFlags || len(leaves)+len(proof) != len(proofFlags)+1 {
+		return common.Hash{}, ErrInvalidMultiProof
+	}
+	stack := append([]common.Hash(nil), leaves...)
+	rest := append([]common.Hash(nil), proof...)
+	for _, flag := range proofFlags {
+		a := stack[0]
+		stack = stack[1:]
+		var b common.Hash
+		if flag {
+			if len(stack) == 0 {
+				return common.Hash{}, ErrInvalidMultiProof
+			}
+			b, stack = stack[0], stack[1:]
+		} else {
+			b, rest = rest[0], rest[1:]
+		}
+		stack = append(stack, HashPair(a, b))
+	}
+	if len(stack) > 0 {
+		return stack[len(stack)-1], nil
+	}
+	return rest[0], nil
+}
+
+// VerifyMultiProof 校验多重证明
+func VerifyMultiProof(root common.Hash, leaves, proof []common.Hash, proofFlags []bool) bool {
+	computed, err := ProcessMultiProof(leaves, proof, proofFlags)
+	return err == nil && computed == root
+}
+
+func siblingIndex(i int) int {
+	if i%2 == 1 {
+		return i + 1
+	}
+	return i - 1
+}
+
+// validate 树为完全二叉树，每个内部节点为子节点的哈希，且每个值的叶子哈希与其位置一致
+func (t *Tree) validate() error {
+	if len(t.tree) == 0 {
+		return ErrInvalidTree
+	}
+	for i := range t.tree {
+		l, r := 2*i+1, 2*i+2
+		if r >= len(t.tree) {
+			if l < len(t.tree) {
+				return fmt.Errorf("%w: 节点 %d 缺少右子节点", ErrInvalidTree, i)
+			}
+			continue
+		}
+		if t.tree[i] != HashPair(t.tree[l], t.tree[r]) {
+			return fmt.Errorf("%w: 节点 %d 哈希不一致", ErrInvalidTree, i)
+		}
+	}
+	leafStart := len(t.tree) / 2
+	for i, v := range t.values {
+		if v.treeIndex < leafStart || v.treeIndex >= len(t.tree) {
+			return fmt.Errorf("%w: 第 %d 个值的位置 %d 不是叶子", ErrInvalidTree, i, v.treeIndex)
+		}
+		h, err := t.encoding.LeafHash(v.value)
+		if err != nil {
+			return err
+		}
+		if h != t.tree[v.treeIndex] {
+			return fmt.Errorf("%w: 第 %d 个值的叶子哈希不一致", ErrInvalidTree, i)
+		}
+	}
+	return nil
+}
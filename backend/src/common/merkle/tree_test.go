@@ -0,0 +1,191 @@
+package merkle
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// @openzeppelin/merkle-tree README 中 StandardMerkleTree.of(values, ["address","uint256"]).dump() 的输出
+const ozReadmeDump = `{
+  "format": "standard-v1",
+  "tree": [
+    "0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77",
+    "0xeb02c421cfa48976e66dfb29120745909ea3a0f843456c263cf8f1253483e283",
+    "0xb92c48e9d7abe27fd8dfd6b5dfdbfb1c9a463f80c712b66f3a5180a090cccafc"
+  ],
+  "values": [
+    {
+      "value": ["0x1111111111111111111111111111111111111111", "5000000000000000000"],
+      "treeIndex": 1
+    },
+    {
+      "value": ["0x2222222222222222222222222222222222222222", "2500000000000000000"],
+      "treeIndex": 2
+    }
+  ],
+  "leafEncoding": ["address", "uint256"]
+}`
+
+func ozReadmeTree(t *testing.T) *Tree {
+	t.Helper()
+	tree, err := NewTree([][]interface{}{
+		{"0x1111111111111111111111111111111111111111", "5000000000000000000"},
+		{"0x2222222222222222222222222222222222222222", "2500000000000000000"},
+	}, StandardEncoding("address", "uint256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestStandardTreeMatchesOpenZeppelinDump(t *testing.T) {
+	tree := ozReadmeTree(t)
+	var want Dump
+	if err := json.Unmarshal([]byte(ozReadmeDump), &want); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != want.Tree[0] {
+		t.Fatalf("Root = %s, want %s", tree.Root().Hex(), want.Tree[0].Hex())
+	}
+	got := tree.Dump()
+	if got.Format != want.Format || len(got.Tree) != len(want.Tree) || len(got.Values) != len(want.Values) {
+		t.Fatalf("Dump = %+v, want %+v", got, want)
+	}
+	for i := range want.Tree {
+		if got.Tree[i] != want.Tree[i] {
+			t.Errorf("tree[%d] = %s, want %s", i, got.Tree[i].Hex(), want.Tree[i].Hex())
+		}
+	}
+	for i := range want.Values {
+		if got.Values[i].TreeIndex != want.Values[i].TreeIndex {
+			t.Errorf("values[%d].treeIndex = %d, want %d", i, got.Values[i].TreeIndex, want.Values[i].TreeIndex)
+		}
+		if got.Values[i].Value[1] != want.Values[i].Value[1] {
+			t.Errorf("values[%d].value = %v, want %v", i, got.Values[i].Value, want.Values[i].Value)
+		}
+	}
+
+	// tree.getProof(0) 为另一片叶子
+	proof, err := tree.GetProof(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof) != 1 || proof[0] != want.Tree[2] {
+		t.Errorf("GetProof(0) = %v, want [%s]", proof, want.Tree[2].Hex())
+	}
+	if !tree.Verify(0, proof) || !VerifyProof(tree.Root(), want.Tree[1], proof) {
+		t.Error("证明校验失败")
+	}
+}
+
+func TestLoadOpenZeppelinDump(t *testing.T) {
+	tree, err := LoadJSON([]byte(ozReadmeDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != common.HexToHash("0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77") {
+		t.Errorf("Root = %s", tree.Root().Hex())
+	}
+	if !tree.VerifyValue([]interface{}{"0x2222222222222222222222222222222222222222", "2500000000000000000"},
+		[]common.Hash{common.HexToHash("0xeb02c421cfa48976e66dfb29120745909ea3a0f843456c263cf8f1253483e283")}) {
+		t.Error("VerifyValue 失败")
+	}
+
+	// 篡改数量后叶子哈希不一致，加载应失败
+	var d Dump
+	_ = json.Unmarshal([]byte(ozReadmeDump), &d)
+	d.Values[0].Value[1] = "5000000000000000001"
+	if _, err := Load(d); err == nil {
+		t.Error("篡改后的 dump 不应加载成功")
+	}
+}
+
+// 与 airdropTest.js 相同的构建方式：solidityPackedKeccak256 叶子，merkletreejs sortLeaves + sortPairs。
+// 三个叶子时 merkletreejs 将奇数节点直接上移，与 OpenZeppelin 布局得到相同的根
+func TestPackedTreeMatchesAirdropTest(t *testing.T) {
+	values := make([][]interface{}, len(airdropTestAccounts))
+	for i, acc := range airdropTestAccounts {
+		values[i] = []interface{}{acc.address, acc.amount}
+	}
+	tree, err := NewTree(values, PackedEncoding("address", "uint256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 排序后的叶子：0x7602…（user2）< 0x89b6…（user3）< 0xb744…（user1）
+	user1, user2, user3 := common.HexToHash(airdropTestAccounts[0].leaf), common.HexToHash(airdropTestAccounts[1].leaf), common.HexToHash(airdropTestAccounts[2].leaf)
+	inner := common.HexToHash("0x6ace6d1eaa1aa5348efa25e6839d5ff2630a315bb48b34e9d08af1f896c23a9c")
+	if HashPair(user2, user3) != inner {
+		t.Fatalf("HashPair(user2, user3) = %s", HashPair(user2, user3).Hex())
+	}
+	wantRoot := common.HexToHash("0x0911fc239f92b51f1c3b21caad7302b458fa537cbca112c70f5c658da8df9960")
+	if tree.Root() != wantRoot {
+		t.Fatalf("Root = %s, want %s", tree.Root().Hex(), wantRoot.Hex())
+	}
+
+	wantProofs := [][]common.Hash{
+		{inner},
+		{user3, user1},
+		{user2, user1},
+	}
+	for i, want := range wantProofs {
+		proof, err := tree.GetProof(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(proof) != len(want) {
+			t.Fatalf("GetProof(%d) = %v, want %v", i, proof, want)
+		}
+		for k := range want {
+			if proof[k] != want[k] {
+				t.Errorf("GetProof(%d)[%d] = %s, want %s", i, k, proof[k].Hex(), want[k].Hex())
+			}
+		}
+		if !VerifyProof(wantRoot, common.HexToHash(airdropTestAccounts[i].leaf), proof) {
+			t.Errorf("GetProof(%d) 校验失败", i)
+		}
+	}
+
+	d := tree.Dump()
+	if d.Format != FormatPacked {
+		t.Errorf("Format = %s", d.Format)
+	}
+	data, _ := json.Marshal(d)
+	loaded, err := LoadJSON(data)
+	if err != nil || loaded.Root() != wantRoot {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+}
+
+func TestMultiProof(t *testing.T) {
+	values := make([][]interface{}, 0, 7)
+	for i := 1; i <= 7; i++ {
+		values = append(values, []interface{}{common.BigToAddress(ether(int64(i))).Hex(), ether(int64(i))})
+	}
+	tree, err := NewTree(values, StandardEncoding("address", "uint256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, indices := range [][]int{{0}, {1, 5}, {0, 2, 4, 6}, {0, 1, 2, 3, 4, 5, 6}, {}} {
+		mp, err := tree.GetMultiProof(indices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tree.VerifyMultiProof(mp) {
+			t.Errorf("VerifyMultiProof(%v) 失败", indices)
+		}
+		if len(mp.Leaves)+len(mp.Proof) != len(mp.ProofFlags)+1 {
+			t.Errorf("%v: 叶子、证明与标记数量不匹配", indices)
+		}
+	}
+	for i := range values {
+		proof, _ := tree.GetProof(i)
+		if !tree.VerifyValue(values[i], proof) {
+			t.Errorf("VerifyValue(%d) 失败", i)
+		}
+	}
+	if _, err := tree.GetMultiProof([]int{1, 1}); err == nil {
+		t.Error("重复下标应返回错误")
+	}
+}
@@ -3,6 +3,7 @@ package evm
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -107,3 +108,13 @@ func (c *Evm) GetBlockByNumber(blockNumber *big.Int) (*types.Block, error) {
 	}
 	return block, nil
 }
+
+// GetBlockTime 获取区块时间戳
+func (c *Evm) GetBlockTime(blockNumber uint64) (time.Time, error) {
+	header, err := c.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		log.Logger.Error("GetBlockTime failed!", zap.Uint64("block_number", blockNumber), zap.Error(err))
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
@@ -10,12 +10,26 @@ import (
 var Conf *Config
 
 type Config struct {
-	App     AppConfig
-	Monitor MonitorConfig
-	Pgsql   PgsqlConfig
-	Redis   RedisConfig
-	Chains  []ChainConfig
-	Airdrop AirdropConfig
+	App      AppConfig
+	Monitor  MonitorConfig
+	Pgsql    PgsqlConfig
+	Redis    RedisConfig
+	Chains   []ChainConfig
+	Airdrop  AirdropConfig
+	Price    PriceConfig
+	Fee      FeeConfig
+	EventBus EventBusConfig `toml:"event_bus"`
+	Webhook  WebhookConfig
+	// SharePriceKeeper StakeV2 日份额价格维护任务
+	SharePriceKeeper SharePriceKeeperConfig `toml:"share_price_keeper"`
+	// StakingAdmin StakeV2 合约管理接口
+	StakingAdmin StakingAdminConfig `toml:"staking_admin"`
+	// TxManager 服务端托管交易发送
+	TxManager TxManagerConfig `toml:"tx_manager"`
+	// Signers 服务端签名账户，按名称被各模块引用
+	Signers map[string]SignerConfig `toml:"signers"`
+	// Safe 多签提案：生成 Safe 交易批次，替代服务端直接发送
+	Safe SafeConfig `toml:"safe"`
 }
 type AppConfig struct {
 	Name      string `toml:"name" json:"name"`
@@ -58,7 +72,49 @@ type ChainConfig struct {
 
 // 新增：空投配置
 type AirdropConfig struct {
-	AdminPrivateKey string `toml:"admin_private_key" json:"adminPrivateKey"`
+	Signer string   `toml:"signer" json:"signer"` // 更新默克尔根使用的签名账户，对应 [signers.<name>]
+	Admins []string `toml:"admins" json:"admins"` // 允许管理默克尔快照的登录钱包，留空时管理接口不可用
+	// AutoUpdate 定时生成默克尔快照，通过策略检查的自动发布，其余进入待审批
+	AutoUpdate AirdropAutoUpdateConfig `toml:"auto_update" json:"autoUpdate"`
+}
+
+// AirdropAutoUpdateConfig 默克尔根自动更新策略
+type AirdropAutoUpdateConfig struct {
+	Enabled     bool                      `toml:"enabled" json:"enabled"`
+	Interval    int                       `toml:"interval" json:"interval"`        // 生成快照的间隔（秒），默认 60
+	MaxChanges  int                       `toml:"max_changes" json:"maxChanges"`   // 相对当前生效快照新增、移除与金额变化的地址数上限，默认 50
+	RewardPools []AirdropRewardPoolConfig `toml:"reward_pools" json:"rewardPools"` // 未配置奖励池的链无法核对余额，快照一律进入待审批
+}
+
+// AirdropRewardPoolConfig 空投合约的奖励池：领取时从该地址转出 token
+type AirdropRewardPoolConfig struct {
+	ChainId int64  `toml:"chain_id" json:"chainId"`
+	Token   string `toml:"token" json:"token"`     // 奖励代币
+	Address string `toml:"address" json:"address"` // 奖励池地址，即最近一次 RewardPoolUpdated 的 newPool
+}
+
+// PriceConfig 代币USD定价配置
+type PriceConfig struct {
+	StableTokens     []StableToken     `toml:"stable_tokens" json:"stableTokens"`         // 视为1美元的定价锚点，按链与代币地址配置
+	MinLiquidityUSD  float64           `toml:"min_liquidity_usd" json:"minLiquidityUsd"`  // 参与定价路由的池子最小流动性（USD）
+	MaxHops          int               `toml:"max_hops" json:"maxHops"`                   // 路由最大跳数
+	SnapshotInterval int               `toml:"snapshot_interval" json:"snapshotInterval"` // 历史价格快照间隔（秒）
+	ManualFeeds      []ManualPriceFeed `toml:"manual_feeds" json:"manualFeeds"`
+}
+
+// StableToken 定价锚点：按地址识别稳定币，同符号的其他代币不会被当作1美元
+type StableToken struct {
+	ChainId      int64  `toml:"chain_id" json:"chainId"`
+	TokenAddress string `toml:"token_address" json:"tokenAddress"`
+	Symbol       string `toml:"symbol" json:"symbol"`
+}
+
+// ManualPriceFeed 手动价格源，路由无法定价时兜底
+type ManualPriceFeed struct {
+	ChainId      int64   `toml:"chain_id" json:"chainId"`
+	TokenAddress string  `toml:"token_address" json:"tokenAddress"`
+	Symbol       string  `toml:"symbol" json:"symbol"`
+	PriceUSD     float64 `toml:"price_usd" json:"priceUsd"`
 }
 
 // InitConfig 初始化配置
@@ -97,3 +153,103 @@ func getCurrentAbPath() string {
 func getConfigAbPath() string {
 	return getCurrentAbPath() + "/config"
 }
+
+// FeeConfig 池子交易手续费配置
+type FeeConfig struct {
+	DefaultFeeBps int               `toml:"default_fee_bps" json:"defaultFeeBps"` // 未登记且无法检测时的默认手续费（基点），默认 30
+	Pools         []PoolFeeRegistry `toml:"pools" json:"pools"`                   // 手续费登记表，优先于链上检测
+}
+
+// PoolFeeRegistry 单个池子的手续费登记
+type PoolFeeRegistry struct {
+	ChainId        int64  `toml:"chain_id" json:"chainId"`
+	PoolAddress    string `toml:"pool_address" json:"poolAddress"`
+	FeeBps         int    `toml:"fee_bps" json:"feeBps"`                  // 交易手续费（基点）
+	ProtocolFeeBps *int   `toml:"protocol_fee_bps" json:"protocolFeeBps"` // 协议分成（基点），不填时按工厂 feeTo 开关检测
+}
+
+// EventBusConfig 事件总线配置：已提交的索引事件经 outbox 表发布到 Redis Streams，每种事件类型一个 stream
+type EventBusConfig struct {
+	Enabled       bool   `toml:"enabled" json:"enabled"`
+	StreamPrefix  string `toml:"stream_prefix" json:"streamPrefix"`   // stream 名前缀，默认 alanswap:events，完整名为 <prefix>:<eventType>
+	MaxLen        int64  `toml:"max_len" json:"maxLen"`               // 每个 stream 保留的近似条数，默认 100000
+	PollInterval  int    `toml:"poll_interval" json:"pollInterval"`   // outbox 轮询间隔（毫秒），默认 1000
+	BatchSize     int    `toml:"batch_size" json:"batchSize"`         // 每批发布条数，默认 500
+	DebugConsumer bool   `toml:"debug_consumer" json:"debugConsumer"` // 启动进程内消费者打印事件，用于联调
+}
+
+// WebhookConfig Webhook 投递配置
+type WebhookConfig struct {
+	Enabled      bool `toml:"enabled" json:"enabled"`
+	AllowHttp    bool `toml:"allow_http" json:"allowHttp"`       // 允许注册 http 地址，仅用于本地联调
+	MaxAttempts  int  `toml:"max_attempts" json:"maxAttempts"`   // 最大投递次数，超过后进入死信队列，默认 8
+	Timeout      int  `toml:"timeout" json:"timeout"`            // 单次请求超时（秒），默认 10
+	BackoffBase  int  `toml:"backoff_base" json:"backoffBase"`   // 首次重试间隔（秒），之后按 2 的幂递增，默认 30
+	BackoffMax   int  `toml:"backoff_max" json:"backoffMax"`     // 重试间隔上限（秒），默认 21600
+	PollInterval int  `toml:"poll_interval" json:"pollInterval"` // 投递轮询间隔（秒），默认 5
+	Workers      int  `toml:"workers" json:"workers"`            // 并发投递数，默认 4
+}
+
+// SharePriceKeeperConfig 质押池日份额价格维护配置
+type SharePriceKeeperConfig struct {
+	Enabled           bool                   `toml:"enabled" json:"enabled"`
+	Cron              string                 `toml:"cron" json:"cron"`                             // 执行时间，默认每天 UTC 00:05
+	Signer            string                 `toml:"signer" json:"signer"`                         // 具有 ADMIN_ROLE 的签名账户，对应 [signers.<name>]
+	Source            string                 `toml:"source" json:"source"`                         // 默认价格来源：oracle（池子配置的预言机）、dex（DEX 路由定价）、static（固定价格）
+	AlertDeviationPct float64                `toml:"alert_deviation_pct" json:"alertDeviationPct"` // 与上一次价格的偏离超过该百分比时告警，默认 10
+	HaltOnDeviation   bool                   `toml:"halt_on_deviation" json:"haltOnDeviation"`     // 偏离超限时不提交，等待人工确认
+	ReceiptTimeout    int                    `toml:"receipt_timeout" json:"receiptTimeout"`        // 同一合约全部池子提交后等待回执的超时（秒），默认 180
+	Pools             []SharePricePoolConfig `toml:"pools" json:"pools"`
+}
+
+// SharePricePoolConfig 单个质押池的价格来源，未配置的池子使用默认来源
+type SharePricePoolConfig struct {
+	ChainId     int64   `toml:"chain_id" json:"chainId"`
+	PoolId      int64   `toml:"pool_id" json:"poolId"`
+	Source      string  `toml:"source" json:"source"`
+	StaticPrice float64 `toml:"static_price" json:"staticPrice"` // source = static 时使用
+	Disabled    bool    `toml:"disabled" json:"disabled"`        // 不维护该池子
+}
+
+// StakingAdminConfig 质押合约管理接口配置
+type StakingAdminConfig struct {
+	Enabled bool     `toml:"enabled" json:"enabled"`
+	Signer  string   `toml:"signer" json:"signer"` // 发送管理交易的签名账户，需具有 ADMIN_ROLE，对应 [signers.<name>]
+	Admins  []string `toml:"admins" json:"admins"` // 允许调用管理接口的登录钱包，留空时仅校验链上 ADMIN_ROLE
+}
+
+// TxManagerConfig 托管交易配置：nonce 分配、回执跟踪、卡住交易提速
+type TxManagerConfig struct {
+	PollInterval         int      `toml:"poll_interval" json:"pollInterval"`                  // 检查回执的间隔（秒），默认 5
+	StuckAfter           int      `toml:"stuck_after" json:"stuckAfter"`                      // 广播后超过该时长（秒）未上链视为卡住并提速，默认 120
+	FeeBumpPct           int      `toml:"fee_bump_pct" json:"feeBumpPct"`                     // 替换交易的费用上浮比例，默认 15，最低 10
+	MaxReplacements      int      `toml:"max_replacements" json:"maxReplacements"`            // 自动提速的最大次数，默认 5
+	MaxBroadcastFailures int      `toml:"max_broadcast_failures" json:"maxBroadcastFailures"` // 广播被拒绝的最大次数，默认 10，达到后改发取消交易填补 nonce
+	Confirmations        int      `toml:"confirmations" json:"confirmations"`                 // 回执所在区块达到该确认数（含本区块）才写入终态，默认 3
+	MaxFeeGwei           int64    `toml:"max_fee_gwei" json:"maxFeeGwei"`                     // 最高费用上限（gwei），0 不限制；提速在上限内无法满足最低上浮时不再替换
+	Admins               []string `toml:"admins" json:"admins"`                               // 允许手动提速、取消托管交易的登录钱包
+}
+
+// SafeConfig Safe 多签提案配置，合约 owner 为 Safe 时由服务端生成待签名的交易批次
+type SafeConfig struct {
+	Enabled           bool          `toml:"enabled" json:"enabled"`
+	MultiSendCallOnly string        `toml:"multi_send_call_only" json:"multiSendCallOnly"` // 批量调用使用的 MultiSendCallOnly 合约，默认 v1.3.0 规范部署地址
+	Accounts          []SafeAccount `toml:"accounts" json:"accounts"`
+}
+
+// SafeAccount 各链作为合约 owner 的 Safe 地址
+type SafeAccount struct {
+	ChainId int64  `toml:"chain_id" json:"chainId"`
+	Address string `toml:"address" json:"address"`
+}
+
+// SignerConfig 签名账户：keystore 加密文件、Web3Signer 远程签名，或仅用于测试的进程内临时账户
+type SignerConfig struct {
+	Type         string `toml:"type" json:"type"`                  // keystore, web3signer, memory
+	KeystorePath string `toml:"keystore_path" json:"keystorePath"` // keystore 文件路径
+	PasswordFile string `toml:"password_file" json:"-"`            // keystore 密码文件，优先于 password_env
+	PasswordEnv  string `toml:"password_env" json:"-"`             // 保存 keystore 密码的环境变量名
+	Url          string `toml:"url" json:"url"`                    // Web3Signer JSON-RPC 地址
+	Address      string `toml:"address" json:"address"`            // Web3Signer 中的签名账户地址
+	Timeout      int    `toml:"timeout" json:"timeout"`            // 远程签名超时（秒），默认 10
+}
@@ -83,6 +83,111 @@ func ApiBind(r *gin.Engine, ctx *ctx.Context) {
 	//5.获取流动性池事件列表
 	v.GET("/liquidity-pool-events", liquidityPoolApi.GetLiquidityPoolEvents)
 
+	lpPositionApi := api.NewLpPositionApi()
+	// LP持仓（由LP代币转账事件累计）
+	v.GET("/liquidity/position", lpPositionApi.GetPosition)
+	v.GET("/liquidity/positions", lpPositionApi.GetPositions)
+
+	priceApi := api.NewPriceApi()
+	// 代币USD价格（池子路由定价 + 手动价格源兜底）
+	v.GET("/price/tokens", priceApi.GetTokenPrices)
+	// 代币历史价格
+	v.GET("/price/history", priceApi.GetPriceHistory)
+
+	udfApi := api.NewUdfApi()
+	// TradingView UDF 数据源（池子K线）
+	v.GET("/udf/config", udfApi.Config)
+	v.GET("/udf/time", udfApi.Time)
+	v.GET("/udf/symbols", udfApi.Symbols)
+	v.GET("/udf/history", udfApi.History)
+
+	swapApi := api.NewSwapApi()
+	// 兑换询价与多跳路由
+	v.POST("/swap/quote", swapApi.Quote)
+
+	feeApi := api.NewFeeApi()
+	// 协议收入报表（手续费拆分与 feeTo 实收）
+	v.GET("/analytics/protocol-revenue", feeApi.GetProtocolRevenue)
+
+	analyticsApi := api.NewAnalyticsApi()
+	// 协议每日汇总指标
+	v.GET("/analytics/protocol", analyticsApi.GetProtocolStats)
+
+	portfolioApi := api.NewPortfolioApi()
+	// 钱包资产总览（质押、LP、空投、积分）
+	v.GET("/portfolio/:address", portfolioApi.GetPortfolio)
+
+	eventExplorerApi := api.NewEventExplorerApi()
+	// 事件浏览（游标分页）与流式导出
+	v.GET("/events", eventExplorerApi.ListEvents)
+	v.GET("/events/export", eventExplorerApi.ExportEvents)
+
+	realtimeApi := api.NewRealtimeApi()
+	// 实时推送（WebSocket / SSE），按池子、用户、事件类型订阅
+	v.GET("/realtime/ws", realtimeApi.WebSocket)
+	v.GET("/realtime/sse", realtimeApi.ServerSentEvents)
+
+	webhookApi := api.NewWebhookApi()
+	// Webhook 订阅与投递日志（需要登录，只能管理自己创建的订阅）
+	author.POST("/webhooks", webhookApi.CreateWebhook)
+	author.GET("/webhooks", webhookApi.ListWebhooks)
+	author.GET("/webhooks/:id", webhookApi.GetWebhook)
+	author.PUT("/webhooks/:id", webhookApi.UpdateWebhook)
+	author.DELETE("/webhooks/:id", webhookApi.DeleteWebhook)
+	author.POST("/webhooks/:id/rotate-secret", webhookApi.RotateWebhookSecret)
+	author.GET("/webhooks/:id/deliveries", webhookApi.ListWebhookDeliveries)
+	author.GET("/webhook-deliveries/:id/attempts", webhookApi.ListWebhookAttempts)
+	author.POST("/webhook-deliveries/:id/redeliver", webhookApi.RedeliverWebhook)
+
+	stakingAdminApi := api.NewStakingAdminApi()
+	// 质押合约管理（需要登录，调用方需在链上持有 ADMIN_ROLE）
+	author.POST("/admin/stake/pools", stakingAdminApi.AddPool)
+	author.POST("/admin/stake/pools/:poolId/active", stakingAdminApi.SetPoolActive)
+	author.POST("/admin/stake/pause", stakingAdminApi.Pause)
+	author.POST("/admin/stake/unpause", stakingAdminApi.Unpause)
+	author.POST("/admin/stake/roles/grant", stakingAdminApi.GrantRole)
+	author.POST("/admin/stake/roles/revoke", stakingAdminApi.RevokeRole)
+	author.GET("/admin/stake/actions", stakingAdminApi.ListActions)
+	author.GET("/admin/stake/actions/:id", stakingAdminApi.GetAction)
+
+	safeProposalApi := api.NewSafeProposalApi()
+	// Safe 多签提案（需要登录，调用方需为该链 Safe 的 owner）
+	author.POST("/safe/proposals", safeProposalApi.CreateProposal)
+	author.GET("/safe/proposals", safeProposalApi.ListProposals)
+	author.GET("/safe/proposals/:id", safeProposalApi.GetProposal)
+	author.GET("/safe/proposals/:id/builder", safeProposalApi.DownloadBuilder)
+
+	merkleSnapshotApi := api.NewMerkleSnapshotApi()
+	// 空投默克尔快照：生成、比较、显式发布与回滚（需在 airdrop.admins 白名单内）
+	author.POST("/admin/airdrops/:airdropId/snapshots", merkleSnapshotApi.BuildSnapshot)
+	author.GET("/admin/airdrops/:airdropId/snapshots", merkleSnapshotApi.ListSnapshots)
+	author.GET("/admin/airdrops/:airdropId/snapshots/diff", merkleSnapshotApi.DiffSnapshots)
+	author.GET("/admin/airdrops/:airdropId/snapshots/:version", merkleSnapshotApi.GetSnapshot)
+	author.GET("/admin/airdrops/:airdropId/snapshots/:version/leaves", merkleSnapshotApi.ListSnapshotLeaves)
+	author.GET("/admin/airdrops/:airdropId/snapshots/:version/tree", merkleSnapshotApi.DownloadSnapshotTree)
+	author.POST("/admin/airdrops/:airdropId/snapshots/:version/publish", merkleSnapshotApi.PublishSnapshot)
+	author.POST("/admin/airdrops/:airdropId/snapshots/:version/rollback", merkleSnapshotApi.RollbackSnapshot)
+	// 自动更新未通过策略检查的快照审批
+	author.GET("/admin/airdrops/pending-snapshots", merkleSnapshotApi.ListPendingSnapshots)
+	author.POST("/admin/airdrops/:airdropId/snapshots/:version/approve", merkleSnapshotApi.ApproveSnapshot)
+	author.POST("/admin/airdrops/:airdropId/snapshots/:version/reject", merkleSnapshotApi.RejectSnapshot)
+
+	managedAirdropApi := api.NewManagedAirdropApi()
+	// 空投活动生命周期：草稿、链上创建、激活（需在 airdrop.admins 白名单内）
+	author.POST("/admin/airdrops/campaigns", managedAirdropApi.CreateCampaign)
+	author.GET("/admin/airdrops/campaigns", managedAirdropApi.ListCampaigns)
+	author.GET("/admin/airdrops/campaigns/:id", managedAirdropApi.GetCampaign)
+	author.PUT("/admin/airdrops/campaigns/:id", managedAirdropApi.UpdateCampaign)
+	author.DELETE("/admin/airdrops/campaigns/:id", managedAirdropApi.DeleteCampaign)
+	author.POST("/admin/airdrops/campaigns/:id/create", managedAirdropApi.CreateCampaignOnChain)
+	author.POST("/admin/airdrops/campaigns/:id/activate", managedAirdropApi.ActivateCampaign)
+
+	managedTxApi := api.NewManagedTxApi()
+	// 托管交易状态查询，手动提速与取消（需在 tx_manager.admins 白名单内）
+	author.GET("/txs/:id", managedTxApi.GetTx)
+	author.POST("/txs/:id/speed-up", managedTxApi.SpeedUpTx)
+	author.POST("/txs/:id/cancel", managedTxApi.CancelTx)
+
 	airDropApi := api.NewAirDropApi()
 	// 空投相关接口（开放访问，地址可从token或参数解析）
 	//我的空投奖励预览
@@ -95,8 +200,6 @@ func ApiBind(r *gin.Engine, ctx *ctx.Context) {
 	v.POST("/userTask/list", airDropApi.UserTaskList)
 	//用户领取空投（获取prof）
 	v.POST("/airdrop/claimReward", airDropApi.ClaimReward)
-	// 管理员更新默克尔根（需要运维调用，后续可加鉴权）
-	v.POST("/airdrop/admin/updateMerkleRoot", airDropApi.AdminUpdateMerkleRoot)
 
 	// 质押相关接口（需要验证）
 	stakeApi := api.NewStakeApi()
@@ -108,4 +211,6 @@ func ApiBind(r *gin.Engine, ctx *ctx.Context) {
 	v.POST("/stake/records", stakeApi.GetStakeRecords)
 	// 获取用户的质押概览
 	v.POST("/stake/overview", stakeApi.GetStakeOverview)
+	// 质押池列表
+	v.GET("/stake/pools", stakeApi.GetStakePools)
 }
@@ -21,6 +21,8 @@ const (
 	ErrorCode = 100000
 	// InvalidParameter 参数错误状态码 1001xx
 	InvalidParameter = 100100
+	// PermissionDenied 无权限状态码 1002xx
+	PermissionDenied = 100200
 
 	// SystemError 系统级别错误状态码 2开头
 	SystemError = 200000
@@ -51,6 +53,10 @@ var ErrMsgMap = map[int]map[int]string{
 		LANG_ZH: "参数错误，请检查",
 		LANG_EN: "Invalid parameters",
 	},
+	PermissionDenied: {
+		LANG_ZH: "无权限执行该操作",
+		LANG_EN: "Permission denied",
+	},
 	SystemError: {
 		LANG_ZH: "服务器内部错误，请稍后重试",
 		LANG_EN: "Internal server error, please try again later",
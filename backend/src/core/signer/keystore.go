@@ -0,0 +1,66 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// KeystoreSigner 从 go-ethereum 加密 keystore 文件解密出的签名账户，解密后的私钥只保存在内存中
+type KeystoreSigner struct {
+	inner *MemorySigner
+}
+
+// NewKeystoreSigner 读取 keystore 文件并解密；密码优先从 passwordFile 读取，未配置时取环境变量 passwordEnv
+func NewKeystoreSigner(path, passwordFile, passwordEnv string) (*KeystoreSigner, error) {
+	if path == "" {
+		return nil, errors.New("未配置 keystore_path")
+	}
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 keystore 文件失败: %v", err)
+	}
+	password, err := readPassword(passwordFile, passwordEnv)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("解密 keystore 失败: %v", err)
+	}
+	return &KeystoreSigner{inner: NewMemorySigner(key.PrivateKey)}, nil
+}
+
+// readPassword 密码文件只取首行并去掉首尾空白
+func readPassword(passwordFile, passwordEnv string) (string, error) {
+	if passwordFile != "" {
+		b, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("读取密码文件失败: %v", err)
+		}
+		return strings.TrimSpace(strings.SplitN(string(b), "\n", 2)[0]), nil
+	}
+	if passwordEnv != "" {
+		password, ok := os.LookupEnv(passwordEnv)
+		if !ok {
+			return "", fmt.Errorf("环境变量 %s 未设置", passwordEnv)
+		}
+		return password, nil
+	}
+	return "", errors.New("未配置 password_file 或 password_env")
+}
+
+func (s *KeystoreSigner) Address() common.Address {
+	return s.inner.Address()
+}
+
+func (s *KeystoreSigner) SignTx(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	return s.inner.SignTx(ctx, tx, chainId)
+}
@@ -0,0 +1,39 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MemorySigner 持有内存私钥的签名账户，用于测试与本地开发链
+type MemorySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewMemorySigner 使用给定私钥创建
+func NewMemorySigner(key *ecdsa.PrivateKey) *MemorySigner {
+	return &MemorySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// GenerateMemorySigner 随机生成临时账户，进程退出后私钥即丢失
+func GenerateMemorySigner() (*MemorySigner, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewMemorySigner(key), nil
+}
+
+func (s *MemorySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *MemorySigner) SignTx(_ context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), s.key)
+}
@@ -0,0 +1,95 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mumu/cryptoSwap/src/core/config"
+)
+
+// 签名账户类型
+const (
+	TypeKeystore   = "keystore"   // go-ethereum 加密 keystore 文件
+	TypeWeb3Signer = "web3signer" // 兼容 Web3Signer eth1 接口的远程签名服务
+	TypeMemory     = "memory"     // 进程内随机生成的临时账户，仅用于本地测试
+)
+
+// ErrSignerNotConfigured 未配置该名称的签名账户
+var ErrSignerNotConfigured = errors.New("签名账户未配置")
+
+// Signer 交易签名：服务端所有链上写操作经由该接口签名，私钥不出现在代码与明文配置中
+type Signer interface {
+	// Address 签名账户地址
+	Address() common.Address
+	// SignTx 对交易签名，返回已签名交易
+	SignTx(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+}
+
+var (
+	loadedMu sync.Mutex
+	loaded   = make(map[string]Signer)
+)
+
+// Load 按名称加载 [signers.<name>] 配置的签名账户，同名账户只加载一次
+func Load(name string) (Signer, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrSignerNotConfigured
+	}
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	if s, ok := loaded[name]; ok {
+		return s, nil
+	}
+	cfg, ok := config.Conf.Signers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSignerNotConfigured, name)
+	}
+	s, err := New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("加载签名账户 %s 失败: %v", name, err)
+	}
+	loaded[name] = s
+	return s, nil
+}
+
+// LoadAll 加载全部已配置的签名账户，加载失败的账户跳过并返回错误
+func LoadAll() ([]Signer, map[string]error) {
+	names := make([]string, 0, len(config.Conf.Signers))
+	for name := range config.Conf.Signers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	signers := make([]Signer, 0, len(names))
+	errs := make(map[string]error)
+	for _, name := range names {
+		s, err := Load(name)
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+		signers = append(signers, s)
+	}
+	return signers, errs
+}
+
+// New 按配置创建签名账户
+func New(cfg config.SignerConfig) (Signer, error) {
+	switch cfg.Type {
+	case TypeKeystore:
+		return NewKeystoreSigner(cfg.KeystorePath, cfg.PasswordFile, cfg.PasswordEnv)
+	case TypeWeb3Signer:
+		return NewWeb3Signer(cfg.Url, cfg.Address, cfg.Timeout)
+	case TypeMemory:
+		return GenerateMemorySigner()
+	default:
+		return nil, fmt.Errorf("未知的签名账户类型: %q", cfg.Type)
+	}
+}
@@ -0,0 +1,145 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const defaultWeb3SignerTimeout = 10 * time.Second
+
+// Web3Signer 远程签名：调用 Web3Signer eth1 模式的 JSON-RPC eth_signTransaction，私钥保存在签名服务中
+type Web3Signer struct {
+	url     string
+	address common.Address
+	client  *http.Client
+}
+
+// NewWeb3Signer url 为签名服务的 JSON-RPC 地址，address 为签名服务中已加载的账户，timeout 单位为秒
+func NewWeb3Signer(url, address string, timeout int) (*Web3Signer, error) {
+	if url == "" {
+		return nil, errors.New("未配置 url")
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("无效的签名账户地址: %q", address)
+	}
+	wait := defaultWeb3SignerTimeout
+	if timeout > 0 {
+		wait = time.Duration(timeout) * time.Second
+	}
+	return &Web3Signer{
+		url:     url,
+		address: common.HexToAddress(address),
+		client:  &http.Client{Timeout: wait},
+	}, nil
+}
+
+func (s *Web3Signer) Address() common.Address {
+	return s.address
+}
+
+type web3SignerTx struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Data                 hexutil.Bytes   `json:"data"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+}
+
+type web3SignerResponse struct {
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SignTx 仅支持 EIP-1559 交易；签名服务的链ID需与 chainId 一致，返回的交易会校验链ID与签名账户
+func (s *Web3Signer) SignTx(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, errors.New("远程签名仅支持 EIP-1559 交易")
+	}
+	value := tx.Value()
+	if value == nil {
+		value = new(big.Int)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_signTransaction",
+		"params": []web3SignerTx{{
+			From:                 s.address,
+			To:                   tx.To(),
+			Gas:                  hexutil.Uint64(tx.Gas()),
+			MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap()),
+			MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap()),
+			Value:                (*hexutil.Big)(value),
+			Data:                 tx.Data(),
+			Nonce:                hexutil.Uint64(tx.Nonce()),
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求远程签名失败: %v", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取远程签名响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("远程签名返回 HTTP %d: %s", resp.StatusCode, string(raw))
+	}
+	var out web3SignerResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("解析远程签名响应失败: %v", err)
+	}
+	if out.Error != nil {
+		return nil, fmt.Errorf("远程签名失败: %d %s", out.Error.Code, out.Error.Message)
+	}
+	encoded, err := hexutil.Decode(out.Result)
+	if err != nil {
+		return nil, fmt.Errorf("远程签名返回的交易无效: %v", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(encoded); err != nil {
+		return nil, fmt.Errorf("远程签名返回的交易无效: %v", err)
+	}
+
+	// 签名服务按自身配置的链ID签名，与目标链不一致或账户不符时拒绝广播
+	if signed.ChainId().Cmp(chainId) != 0 {
+		return nil, fmt.Errorf("远程签名的链ID %s 与目标链 %s 不一致", signed.ChainId(), chainId)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainId), signed)
+	if err != nil {
+		return nil, fmt.Errorf("校验远程签名失败: %v", err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("远程签名账户 %s 与配置的账户 %s 不一致", from.Hex(), s.address.Hex())
+	}
+	if signed.Nonce() != tx.Nonce() || signed.Gas() != tx.Gas() || !bytes.Equal(signed.Data(), tx.Data()) {
+		return nil, errors.New("远程签名返回的交易与请求不一致")
+	}
+	return signed, nil
+}